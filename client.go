@@ -29,9 +29,11 @@ type client struct {
 	srcConnID  protocol.ConnectionID
 	destConnID protocol.ConnectionID
 
-	initialPacketNumber  protocol.PacketNumber
-	hasNegotiatedVersion bool
-	version              protocol.VersionNumber
+	initialPacketNumber     protocol.PacketNumber
+	hasNegotiatedVersion    bool
+	serverSupportedVersions []protocol.VersionNumber // the versions offered in the server's Version Negotiation packet
+	version                 protocol.VersionNumber
+	numRetries              int // number of times a new session was created
 
 	handshakeChan chan struct{}
 
@@ -64,6 +66,7 @@ func DialAddr(
 // It uses a new UDP connection and closes this connection when the QUIC session is closed.
 // The hostname for SNI is taken from the given address.
 // The tls.Config.CipherSuites allows setting of TLS 1.3 cipher suites.
+// See DialEarly for details about session resumption and 0-RTT.
 func DialAddrEarly(
 	addr string,
 	tlsConf *tls.Config,
@@ -89,6 +92,7 @@ func DialAddrEarlyContext(
 }
 
 // DialAddrContext establishes a new QUIC connection to a server using the provided context.
+// The context also applies to the resolution of the address.
 // See DialAddr for details.
 func DialAddrContext(
 	ctx context.Context,
@@ -106,7 +110,7 @@ func dialAddrContext(
 	config *Config,
 	use0RTT bool,
 ) (quicSession, error) {
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	udpAddr, err := resolveUDPAddr(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +118,47 @@ func dialAddrContext(
 	if err != nil {
 		return nil, err
 	}
-	return dialContext(ctx, udpConn, udpAddr, addr, tlsConf, config, use0RTT, true)
+	sess, err := dialContext(ctx, udpConn, udpAddr, addr, tlsConf, config, use0RTT, true)
+	if err != nil {
+		udpConn.Close()
+		return nil, err
+	}
+	return sess, nil
+}
+
+// resolveUDPAddr resolves a UDP address, like net.ResolveUDPAddr does.
+// The context is used for the DNS lookup, so a slow resolver can't block the dial.
+// Like net.ResolveUDPAddr, it prefers IPv4 addresses.
+func resolveUDPAddr(ctx context.Context, addr string) (*net.UDPAddr, error) {
+	host, service, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", service)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if host == "" {
+		return &net.UDPAddr{Port: port}, nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	ip := ips[0]
+	for _, a := range ips {
+		if a.IP.To4() != nil {
+			ip = a
+			break
+		}
+	}
+	return &net.UDPAddr{IP: ip.IP, Port: port, Zone: ip.Zone}, nil
 }
 
 // Dial establishes a new QUIC connection to a server using a net.PacketConn. If
@@ -140,6 +184,14 @@ func Dial(
 // QUIC connection IDs are used for demultiplexing the different connections.
 // The host parameter is used for SNI.
 // The tls.Config must define an application protocol (using NextProtos).
+//
+// Session resumption and 0-RTT require a session ticket from a previous connection to the same server.
+// Tickets are stored in and retrieved from the tls.Config.ClientSessionCache.
+// If the server rejects the ticket, a full handshake is performed.
+// If the server only rejects 0-RTT, all data sent in 0-RTT is discarded,
+// and the Err0RTTRejected error is returned from the session's streams (see EarlySession.NextSession).
+// Note that 0-RTT data is not protected against replay attacks: an attacker can resend it to the server.
+// Only data that is safe to process multiple times (e.g. idempotent requests) should be sent before the handshake completes.
 func DialEarly(
 	pconn net.PacketConn,
 	remoteAddr net.Addr,
@@ -193,7 +245,7 @@ func dialContext(
 		return nil, err
 	}
 	config = populateClientConfig(config, createdPacketConn)
-	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey, config.Tracer)
+	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey, config.ReceiveBufferSize, config.Tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +306,7 @@ func newClient(
 		}
 	}
 
-	srcConnID, err := generateConnectionID(config.ConnectionIDLength)
+	srcConnID, err := config.generateConnectionID(config.ConnectionIDLength)
 	if err != nil {
 		return nil, err
 	}
@@ -262,17 +314,21 @@ func newClient(
 	if err != nil {
 		return nil, err
 	}
+	var bw batchWriter
+	if !config.DisableBatchedWrites {
+		bw = newBatchWriter(pconn)
+	}
 	c := &client{
 		srcConnID:         srcConnID,
 		destConnID:        destConnID,
-		conn:              newSendPconn(pconn, remoteAddr),
+		conn:              newSendPconn(pconn, remoteAddr, bw),
 		createdPacketConn: createdPacketConn,
 		use0RTT:           use0RTT,
 		tlsConf:           tlsConf,
 		config:            config,
 		version:           config.Versions[0],
 		handshakeChan:     make(chan struct{}),
-		logger:            utils.DefaultLogger.WithPrefix("client"),
+		logger:            newLogger(config.Logger).WithPrefix("client"),
 	}
 	return c, nil
 }
@@ -290,6 +346,7 @@ func (c *client) dial(ctx context.Context) error {
 		c.initialPacketNumber,
 		c.use0RTT,
 		c.hasNegotiatedVersion,
+		c.serverSupportedVersions,
 		c.tracer,
 		c.tracingID,
 		c.logger,
@@ -299,7 +356,10 @@ func (c *client) dial(ctx context.Context) error {
 
 	errorChan := make(chan error, 1)
 	go func() {
-		err := c.session.run() // returns as soon as the session is closed
+		var err error
+		c.config.runLoop(func() {
+			err = c.session.run() // returns as soon as the session is closed
+		})
 
 		if e := (&errCloseForRecreating{}); !errors.As(err, &e) && c.createdPacketConn {
 			c.packetHandlers.Destroy()
@@ -321,9 +381,19 @@ func (c *client) dial(ctx context.Context) error {
 	case err := <-errorChan:
 		var recreateErr *errCloseForRecreating
 		if errors.As(err, &recreateErr) {
+			if c.numRetries >= c.config.MaxRetries {
+				if c.createdPacketConn {
+					c.packetHandlers.Destroy()
+				}
+				return ErrTooManyRetries
+			}
+			c.numRetries++
 			c.initialPacketNumber = recreateErr.nextPacketNumber
 			c.version = recreateErr.nextVersion
 			c.hasNegotiatedVersion = true
+			if recreateErr.serverSupportedVersions != nil {
+				c.serverSupportedVersions = recreateErr.serverSupportedVersions
+			}
 			return c.dial(ctx)
 		}
 		return err
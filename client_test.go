@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 	"os"
+	"sync"
 	"time"
 
 	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
@@ -41,6 +43,7 @@ var _ = Describe("Client", func() {
 			initialPacketNumber protocol.PacketNumber,
 			enable0RTT bool,
 			hasNegotiatedVersion bool,
+			serverSupportedVersions []protocol.VersionNumber,
 			tracer logging.ConnectionTracer,
 			tracingID uint64,
 			logger utils.Logger,
@@ -65,7 +68,7 @@ var _ = Describe("Client", func() {
 			srcConnID:  connID,
 			destConnID: connID,
 			version:    protocol.VersionTLS,
-			conn:       newSendPconn(packetConn, addr),
+			conn:       newSendPconn(packetConn, addr, nil),
 			tracer:     tracer,
 			logger:     utils.DefaultLogger,
 		}
@@ -116,7 +119,7 @@ var _ = Describe("Client", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			remoteAddrChan := make(chan string, 1)
 			newClientSession = func(
@@ -129,6 +132,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -149,7 +153,7 @@ var _ = Describe("Client", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			hostnameChan := make(chan string, 1)
 			newClientSession = func(
@@ -162,6 +166,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -182,7 +187,7 @@ var _ = Describe("Client", func() {
 		It("allows passing host without port as server name", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			hostnameChan := make(chan string, 1)
 			newClientSession = func(
@@ -195,6 +200,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -218,10 +224,80 @@ var _ = Describe("Client", func() {
 			Eventually(hostnameChan).Should(Receive(Equal("test.com")))
 		})
 
+		It("uses the configured receive buffer size", func() {
+			config.ReceiveBufferSize = 1 << 22
+			manager := NewMockPacketHandlerManager(mockCtrl)
+			manager.EXPECT().Add(gomock.Any(), gomock.Any())
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), 1<<22, gomock.Any()).Return(manager, nil)
+
+			newClientSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ *Config,
+				_ *tls.Config,
+				_ protocol.PacketNumber,
+				_ bool,
+				_ bool,
+				_ []protocol.VersionNumber,
+				_ logging.ConnectionTracer,
+				_ uint64,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sess := NewMockQuicSession(mockCtrl)
+				sess.EXPECT().HandshakeComplete().Return(context.Background())
+				sess.EXPECT().run()
+				return sess
+			}
+			tracer.EXPECT().StartedConnection(packetConn.LocalAddr(), addr, gomock.Any(), gomock.Any())
+			_, err := Dial(packetConn, addr, "localhost:1337", tlsConf, config)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("uses the configured logger", func() {
+			logger := newRecordingLogger()
+			config.Logger = logger
+			manager := NewMockPacketHandlerManager(mockCtrl)
+			manager.EXPECT().Add(gomock.Any(), gomock.Any())
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+
+			var sessLogger utils.Logger
+			newClientSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ *Config,
+				_ *tls.Config,
+				_ protocol.PacketNumber,
+				_ bool,
+				_ bool,
+				_ []protocol.VersionNumber,
+				_ logging.ConnectionTracer,
+				_ uint64,
+				l utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sessLogger = l
+				sess := NewMockQuicSession(mockCtrl)
+				sess.EXPECT().HandshakeComplete().Return(context.Background())
+				sess.EXPECT().run()
+				return sess
+			}
+			tracer.EXPECT().StartedConnection(packetConn.LocalAddr(), addr, gomock.Any(), gomock.Any())
+			_, err := Dial(packetConn, addr, "localhost:1337", tlsConf, config)
+			Expect(err).ToNot(HaveOccurred())
+			sessLogger.Debugf("foobar")
+			Expect(logger.Lines()).To(ContainElement(HavePrefix("client Starting new connection to localhost")))
+			Expect(logger.Lines()).To(ContainElement("client foobar"))
+		})
+
 		It("returns after the handshake is complete", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			run := make(chan struct{})
 			newClientSession = func(
@@ -234,6 +310,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				enable0RTT bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -260,10 +337,52 @@ var _ = Describe("Client", func() {
 			Eventually(run).Should(BeClosed())
 		})
 
+		It("runs the session using the RunLoopHook", func() {
+			manager := NewMockPacketHandlerManager(mockCtrl)
+			manager.EXPECT().Add(gomock.Any(), gomock.Any())
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+
+			hookDone := make(chan struct{})
+			var runInHook bool
+			config.RunLoopHook = func(run func()) {
+				defer close(hookDone)
+				run()
+				runInHook = true
+			}
+			newClientSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ *Config,
+				_ *tls.Config,
+				_ protocol.PacketNumber,
+				_ bool,
+				_ bool,
+				_ []protocol.VersionNumber,
+				_ logging.ConnectionTracer,
+				_ uint64,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sess := NewMockQuicSession(mockCtrl)
+				sess.EXPECT().run()
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				sess.EXPECT().HandshakeComplete().Return(ctx).MaxTimes(1)
+				return sess
+			}
+			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			_, err := Dial(packetConn, addr, "localhost:1337", tlsConf, config)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(hookDone).Should(BeClosed())
+			Expect(runInHook).To(BeTrue())
+		})
+
 		It("returns early sessions", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			readyChan := make(chan struct{})
 			done := make(chan struct{})
@@ -277,6 +396,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				enable0RTT bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -312,7 +432,7 @@ var _ = Describe("Client", func() {
 		It("returns an error that occurs while waiting for the handshake to complete", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			testErr := errors.New("early handshake error")
 			newClientSession = func(
@@ -325,6 +445,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -349,7 +470,7 @@ var _ = Describe("Client", func() {
 		It("closes the session when the context is canceled", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			sessionRunning := make(chan struct{})
 			defer close(sessionRunning)
@@ -368,6 +489,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -403,7 +525,7 @@ var _ = Describe("Client", func() {
 			}
 
 			manager := NewMockPacketHandlerManager(mockCtrl)
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 
 			var conn sendConn
@@ -420,6 +542,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -445,7 +568,7 @@ var _ = Describe("Client", func() {
 			Eventually(sessionCreated).Should(BeClosed())
 
 			// check that the connection is not closed
-			Expect(conn.Write([]byte("foobar"))).To(Succeed())
+			Expect(conn.Write([]byte("foobar"), protocol.ECNNon)).To(Succeed())
 
 			manager.EXPECT().Destroy()
 			close(run)
@@ -454,6 +577,35 @@ var _ = Describe("Client", func() {
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("returns the context error when it is canceled during address resolution", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := DialAddrContext(ctx, "localhost:1337", tlsConf, nil)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("returns address resolution errors", func() {
+			_, err := DialAddrContext(context.Background(), "localhost", tlsConf, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing port"))
+		})
+
+		It("closes the connection created by DialAddr when dialing fails", func() {
+			testErr := errors.New("test error")
+			var pconn net.PacketConn
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(c net.PacketConn, _ int, _ []byte, _ int, _ logging.Tracer) (packetHandlerManager, error) {
+					pconn = c
+					return nil, testErr
+				},
+			)
+			_, err := DialAddrContext(context.Background(), "localhost:1337", tlsConf, nil)
+			Expect(err).To(MatchError(testErr))
+			Expect(pconn).ToNot(BeNil())
+			_, err = pconn.WriteTo([]byte("foobar"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337})
+			Expect(err).To(MatchError(net.ErrClosed))
+		})
+
 		Context("quic.Config", func() {
 			It("setups with the right values", func() {
 				tokenStore := NewLRUTokenStore(10, 4)
@@ -480,7 +632,7 @@ var _ = Describe("Client", func() {
 
 			It("errors when the Config contains an invalid version", func() {
 				manager := NewMockPacketHandlerManager(mockCtrl)
-				mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+				mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 				version := protocol.VersionNumber(0x1234)
 				_, err := Dial(packetConn, nil, "localhost:1234", tlsConf, &Config{Versions: []protocol.VersionNumber{version}})
@@ -523,7 +675,7 @@ var _ = Describe("Client", func() {
 		It("creates new sessions with the right parameters", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(connID, gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			config := &Config{Versions: []protocol.VersionNumber{protocol.VersionTLS}}
 			c := make(chan struct{})
@@ -540,6 +692,7 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -567,7 +720,7 @@ var _ = Describe("Client", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(connID, gomock.Any()).Times(2)
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			var counter int
 			newClientSession = func(
@@ -580,6 +733,7 @@ var _ = Describe("Client", func() {
 				pn protocol.PacketNumber,
 				_ bool,
 				hasNegotiatedVersion bool,
+				serverSupportedVersions []protocol.VersionNumber,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -590,13 +744,16 @@ var _ = Describe("Client", func() {
 				if counter == 0 {
 					Expect(pn).To(BeZero())
 					Expect(hasNegotiatedVersion).To(BeFalse())
+					Expect(serverSupportedVersions).To(BeNil())
 					sess.EXPECT().run().Return(&errCloseForRecreating{
-						nextPacketNumber: 109,
-						nextVersion:      789,
+						nextPacketNumber:        109,
+						nextVersion:             789,
+						serverSupportedVersions: []protocol.VersionNumber{456, 789},
 					})
 				} else {
 					Expect(pn).To(Equal(protocol.PacketNumber(109)))
 					Expect(hasNegotiatedVersion).To(BeTrue())
+					Expect(serverSupportedVersions).To(Equal([]protocol.VersionNumber{456, 789}))
 					sess.EXPECT().run()
 				}
 				counter++
@@ -608,5 +765,74 @@ var _ = Describe("Client", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(counter).To(Equal(2))
 		})
+
+		It("stops creating new sessions after MaxRetries", func() {
+			manager := NewMockPacketHandlerManager(mockCtrl)
+			manager.EXPECT().Add(connID, gomock.Any()).Times(3)
+			manager.EXPECT().Destroy()
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+
+			var counter int
+			newClientSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ *Config,
+				_ *tls.Config,
+				_ protocol.PacketNumber,
+				_ bool,
+				_ bool,
+				_ []protocol.VersionNumber,
+				_ logging.ConnectionTracer,
+				_ uint64,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sess := NewMockQuicSession(mockCtrl)
+				sess.EXPECT().HandshakeComplete().Return(context.Background())
+				sess.EXPECT().run().Return(&errCloseForRecreating{
+					nextPacketNumber: 109,
+					nextVersion:      789,
+				})
+				counter++
+				return sess
+			}
+
+			config.MaxRetries = 2
+			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			_, err := DialAddr("localhost:7890", tlsConf, config)
+			Expect(err).To(MatchError(ErrTooManyRetries))
+			Expect(counter).To(Equal(3))
+		})
 	})
 })
+
+// A recordingLogger is a Logger that records all log lines, regardless of the log level.
+type recordingLogger struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+var _ Logger = &recordingLogger{}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{}
+}
+
+func (l *recordingLogger) Debug() bool                       { return true }
+func (l *recordingLogger) Errorf(f string, a ...interface{}) { l.log(f, a...) }
+func (l *recordingLogger) Infof(f string, a ...interface{})  { l.log(f, a...) }
+func (l *recordingLogger) Debugf(f string, a ...interface{}) { l.log(f, a...) }
+
+func (l *recordingLogger) log(format string, args ...interface{}) {
+	l.mutex.Lock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+	l.mutex.Unlock()
+}
+
+func (l *recordingLogger) Lines() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.lines...)
+}
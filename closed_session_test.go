@@ -35,7 +35,7 @@ var _ = Describe("Closed local session", func() {
 
 	It("repeats the packet containing the CONNECTION_CLOSE frame", func() {
 		written := make(chan []byte)
-		mconn.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p }).AnyTimes()
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any()).Do(func(p []byte, _ protocol.ECN) { written <- p }).AnyTimes()
 		for i := 1; i <= 20; i++ {
 			sess.handlePacket(&receivedPacket{})
 			if i == 1 || i == 2 || i == 4 || i == 8 || i == 16 {
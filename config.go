@@ -2,12 +2,15 @@ package quic
 
 import (
 	"errors"
+	"fmt"
 	"github.com/BGrewell/quic-go/internal/congestion"
+	"sync"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/utils"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/quicvarint"
 )
 
 // Clone clones a Config
@@ -16,6 +19,65 @@ func (c *Config) Clone() *Config {
 	return &copy
 }
 
+// LowLatencyConfig returns a Config tuned for latency-sensitive applications.
+// It uses small initial flow control windows, short timeouts, acknowledges every ack-eliciting packet right away,
+// and uses NewReno with a small initial congestion window, to avoid building up queues on the path.
+// Pacing stays enabled for the same reason: sending a whole congestion window in a burst would build up a queue
+// at the bottleneck, and delay all packets of that burst.
+// It is meant as a starting point, and can be modified before it is passed to Dial or Listen.
+func LowLatencyConfig() *Config {
+	return &Config{
+		HandshakeIdleTimeout:           2 * time.Second,
+		MaxIdleTimeout:                 10 * time.Second,
+		InitialStreamReceiveWindow:     64 << 10, // 64 KB
+		InitialConnectionReceiveWindow: 96 << 10, // 96 KB
+		KeepAlive:                      true,
+		CongestionControlAlgo:          congestion.ALGO_RENO,
+		InitialCongestionWindowPackets: 10, // the initial window recommended by RFC 9002
+		AckElicitingThreshold:          1,
+	}
+}
+
+// SupportedVersions returns the QUIC versions supported by this implementation, in order of preference.
+// These versions are used if Config.Versions is not set.
+// The returned slice is a copy, and may be modified by the caller.
+func SupportedVersions() []VersionNumber {
+	versions := make([]VersionNumber, len(protocol.SupportedVersions))
+	copy(versions, protocol.SupportedVersions)
+	return versions
+}
+
+// runLoop runs the event loop of a session, using the RunLoopHook, if one is set.
+// The event loop is run exactly once, even if the hook calls run multiple times.
+func (c *Config) runLoop(run func()) {
+	if c.RunLoopHook == nil {
+		run()
+		return
+	}
+	var once sync.Once
+	runOnce := func() { once.Do(run) }
+	c.RunLoopHook(runOnce)
+	// If the hook didn't call run, run the event loop now.
+	// If the hook started it on a different goroutine, this blocks until the event loop returns.
+	runOnce()
+}
+
+// generateConnectionID generates a connection ID of the given length,
+// using the ConnectionIDGenerator, if one is set.
+func (c *Config) generateConnectionID(l int) (protocol.ConnectionID, error) {
+	if c.ConnectionIDGenerator == nil || l == 0 {
+		return generateConnectionID(l)
+	}
+	connID, err := c.ConnectionIDGenerator(l)
+	if err != nil {
+		return nil, err
+	}
+	if connID.Len() != l {
+		return nil, fmt.Errorf("Config.ConnectionIDGenerator returned a connection ID of length %d, expected %d", connID.Len(), l)
+	}
+	return connID, nil
+}
+
 func (c *Config) handshakeTimeout() time.Duration {
 	return utils.MaxDuration(protocol.DefaultHandshakeTimeout, 2*c.HandshakeIdleTimeout)
 }
@@ -30,6 +92,60 @@ func validateConfig(config *Config) error {
 	if config.MaxIncomingUniStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingUniStreams")
 	}
+	if (config.GetRetryToken == nil) != (config.ValidateRetryToken == nil) {
+		return errors.New("Config.GetRetryToken and Config.ValidateRetryToken must be set together")
+	}
+	if config.ReceiveBufferSize < 0 {
+		return errors.New("invalid value for Config.ReceiveBufferSize")
+	}
+	if config.KeepAlivePeriod < 0 {
+		return errors.New("invalid value for Config.KeepAlivePeriod")
+	}
+	if config.DrainingTimeout < 0 {
+		return errors.New("invalid value for Config.DrainingTimeout")
+	}
+	if l := config.ActiveConnectionIDLimit; l != 0 && (l < protocol.MinActiveConnectionIDLimit || l > quicvarint.Max) {
+		return errors.New("invalid value for Config.ActiveConnectionIDLimit")
+	}
+	if config.MaxPathChallenges < 0 {
+		return errors.New("invalid value for Config.MaxPathChallenges")
+	}
+	if config.MaxCoalescedPackets < 0 {
+		return errors.New("invalid value for Config.MaxCoalescedPackets")
+	}
+	if config.MaxSendBufferBytes < 0 {
+		return errors.New("invalid value for Config.MaxSendBufferBytes")
+	}
+	if config.CongestionEventBufferSize < 0 {
+		return errors.New("invalid value for Config.CongestionEventBufferSize")
+	}
+	if config.AckElicitingThreshold < 0 {
+		return errors.New("invalid value for Config.AckElicitingThreshold")
+	}
+	if config.InitialCongestionWindowPackets < 0 {
+		return errors.New("invalid value for Config.InitialCongestionWindowPackets")
+	}
+	if config.LocoMaxBytesInFlight < 0 {
+		return errors.New("invalid value for Config.LocoMaxBytesInFlight")
+	}
+	if config.InitialSlowStartThreshold < 0 {
+		return errors.New("invalid value for Config.InitialSlowStartThreshold")
+	}
+	if config.PTOProbeStrategy > PTOProbeSendPing {
+		return errors.New("invalid value for Config.PTOProbeStrategy")
+	}
+	if config.RetransmissionOrder > RetransmissionOrderHighestPriorityFirst {
+		return errors.New("invalid value for Config.RetransmissionOrder")
+	}
+	if hs := config.HyStartConfig; hs.MaxRTTIncreaseThreshold != 0 && hs.MinRTTIncreaseThreshold > hs.MaxRTTIncreaseThreshold {
+		return errors.New("invalid value for Config.HyStartConfig: MinRTTIncreaseThreshold is larger than MaxRTTIncreaseThreshold")
+	}
+	// The flow control windows are compared after applying the defaults for unset values.
+	if c := populateConfig(config); c.InitialStreamReceiveWindow > c.MaxStreamReceiveWindow {
+		return errors.New("invalid value for Config.InitialStreamReceiveWindow: larger than MaxStreamReceiveWindow")
+	} else if c.InitialConnectionReceiveWindow > c.MaxConnectionReceiveWindow {
+		return errors.New("invalid value for Config.InitialConnectionReceiveWindow: larger than MaxConnectionReceiveWindow")
+	}
 	return nil
 }
 
@@ -88,6 +204,14 @@ func populateConfig(config *Config) *Config {
 	if maxConnectionReceiveWindow == 0 {
 		maxConnectionReceiveWindow = protocol.DefaultMaxReceiveConnectionFlowControlWindow
 	}
+	activeConnectionIDLimit := config.ActiveConnectionIDLimit
+	if activeConnectionIDLimit == 0 {
+		activeConnectionIDLimit = protocol.MinActiveConnectionIDLimit
+	}
+	maxPathChallenges := config.MaxPathChallenges
+	if maxPathChallenges == 0 {
+		maxPathChallenges = protocol.DefaultMaxPathChallenges
+	}
 	maxIncomingStreams := config.MaxIncomingStreams
 	if maxIncomingStreams == 0 {
 		maxIncomingStreams = protocol.DefaultMaxIncomingStreams
@@ -100,31 +224,93 @@ func populateConfig(config *Config) *Config {
 	} else if maxIncomingUniStreams < 0 {
 		maxIncomingUniStreams = 0
 	}
+	maxRetries := config.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = protocol.DefaultMaxRetries
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
 	congestionControlAlgo := config.CongestionControlAlgo
 	if congestionControlAlgo == congestion.ALGO_UNKNOWN {
-		congestionControlAlgo = congestion.ALGO_CUBIC
+		// CUBIC is opt-in, the default congestion controller is the cubic sender in Reno mode.
+		congestionControlAlgo = congestion.ALGO_RENO
+	}
+	clock := config.Clock
+	if clock == nil {
+		clock = congestion.DefaultClock{}
+	}
+	ackElicitingThreshold := config.AckElicitingThreshold
+	if ackElicitingThreshold == 0 {
+		ackElicitingThreshold = protocol.DefaultAckElicitingThreshold
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = utils.DefaultLogger
 	}
 
 	return &Config{
 		Versions:                         versions,
 		HandshakeIdleTimeout:             handshakeIdleTimeout,
 		MaxIdleTimeout:                   idleTimeout,
+		DrainingTimeout:                  config.DrainingTimeout,
 		AcceptToken:                      config.AcceptToken,
+		GetRetryToken:                    config.GetRetryToken,
+		ValidateRetryToken:               config.ValidateRetryToken,
+		RequireAddressValidation:         config.RequireAddressValidation,
 		KeepAlive:                        config.KeepAlive,
+		KeepAlivePeriod:                  config.KeepAlivePeriod,
 		InitialStreamReceiveWindow:       initialStreamReceiveWindow,
 		MaxStreamReceiveWindow:           maxStreamReceiveWindow,
 		InitialConnectionReceiveWindow:   initialConnectionReceiveWindow,
 		MaxConnectionReceiveWindow:       maxConnectionReceiveWindow,
+		DisableReceiveWindowAutoTuning:   config.DisableReceiveWindowAutoTuning,
 		AllowConnectionWindowIncrease:    config.AllowConnectionWindowIncrease,
+		MaxSendBufferBytes:               config.MaxSendBufferBytes,
+		RunLoopHook:                      config.RunLoopHook,
 		MaxIncomingStreams:               maxIncomingStreams,
 		MaxIncomingUniStreams:            maxIncomingUniStreams,
+		MaxTotalStreams:                  config.MaxTotalStreams,
+		OnNewStream:                      config.OnNewStream,
+		MaxEncryptionRate:                config.MaxEncryptionRate,
+		MaxStreamResetRate:               config.MaxStreamResetRate,
+		AEADFactory:                      config.AEADFactory,
 		ConnectionIDLength:               config.ConnectionIDLength,
+		ConnectionIDGenerator:            config.ConnectionIDGenerator,
+		OnConnectionIDChanged:            config.OnConnectionIDChanged,
+		OnConnectionIDIssued:             config.OnConnectionIDIssued,
+		OnConnectionIDRetired:            config.OnConnectionIDRetired,
+		ActiveConnectionIDLimit:          activeConnectionIDLimit,
+		MaxPathChallenges:                maxPathChallenges,
 		StatelessResetKey:                config.StatelessResetKey,
+		OnUnknownConnectionID:            config.OnUnknownConnectionID,
 		TokenStore:                       config.TokenStore,
 		EnableDatagrams:                  config.EnableDatagrams,
+		DeliverEmptyDatagrams:            config.DeliverEmptyDatagrams,
+		ReceiveBufferSize:                config.ReceiveBufferSize,
 		DisablePathMTUDiscovery:          config.DisablePathMTUDiscovery,
+		DisableBatchedWrites:             config.DisableBatchedWrites,
+		ReusePort:                        config.ReusePort,
+		MaxCoalescedPackets:              config.MaxCoalescedPackets,
 		DisableVersionNegotiationPackets: config.DisableVersionNegotiationPackets,
+		DisableVersionNegotiation:        config.DisableVersionNegotiation,
+		MaxRetries:                       maxRetries,
 		CongestionControlAlgo:            congestionControlAlgo,
+		HyStartConfig:                    config.HyStartConfig,
+		InitialCongestionWindowPackets:   config.InitialCongestionWindowPackets,
+		InitialSlowStartThreshold:        config.InitialSlowStartThreshold,
+		LocoMaxBytesInFlight:             config.LocoMaxBytesInFlight,
+		MaxSendRate:                      config.MaxSendRate,
+		Clock:                            clock,
+		AckElicitingThreshold:            ackElicitingThreshold,
+		CongestionLog:                    config.CongestionLog,
+		OnCongestionCollapse:             config.OnCongestionCollapse,
+		CongestionEventBufferSize:        config.CongestionEventBufferSize,
+		PTOProbeStrategy:                 config.PTOProbeStrategy,
+		RetransmissionOrder:              config.RetransmissionOrder,
+		PacketCapture:                    config.PacketCapture,
+		PacketLossSimulator:              config.PacketLossSimulator,
+		OnDroppedPacket:                  config.OnDroppedPacket,
 		Tracer:                           config.Tracer,
+		Logger:                           logger,
 	}
 }
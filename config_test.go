@@ -1,13 +1,19 @@
 package quic
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"reflect"
+	"sync/atomic"
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
 	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
+	"github.com/BGrewell/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -30,6 +36,101 @@ var _ = Describe("Config", func() {
 		It("errors on too large values for MaxIncomingUniStreams", func() {
 			Expect(validateConfig(&Config{MaxIncomingUniStreams: 1<<60 + 1})).To(MatchError("invalid value for Config.MaxIncomingUniStreams"))
 		})
+
+		It("errors on too small values for ActiveConnectionIDLimit", func() {
+			Expect(validateConfig(&Config{ActiveConnectionIDLimit: 1})).To(MatchError("invalid value for Config.ActiveConnectionIDLimit"))
+			Expect(validateConfig(&Config{ActiveConnectionIDLimit: 2})).To(Succeed())
+		})
+
+		It("errors on too large values for ActiveConnectionIDLimit", func() {
+			Expect(validateConfig(&Config{ActiveConnectionIDLimit: 1 << 62})).To(MatchError("invalid value for Config.ActiveConnectionIDLimit"))
+		})
+
+		It("errors on negative values for MaxPathChallenges", func() {
+			Expect(validateConfig(&Config{MaxPathChallenges: -1})).To(MatchError("invalid value for Config.MaxPathChallenges"))
+		})
+
+		It("errors on negative values for ReceiveBufferSize", func() {
+			Expect(validateConfig(&Config{ReceiveBufferSize: -1})).To(MatchError("invalid value for Config.ReceiveBufferSize"))
+		})
+
+		It("errors on negative values for KeepAlivePeriod", func() {
+			Expect(validateConfig(&Config{KeepAlivePeriod: -time.Second})).To(MatchError("invalid value for Config.KeepAlivePeriod"))
+		})
+
+		It("errors on negative values for DrainingTimeout", func() {
+			Expect(validateConfig(&Config{DrainingTimeout: -time.Second})).To(MatchError("invalid value for Config.DrainingTimeout"))
+		})
+
+		It("errors on negative values for MaxCoalescedPackets", func() {
+			Expect(validateConfig(&Config{MaxCoalescedPackets: -1})).To(MatchError("invalid value for Config.MaxCoalescedPackets"))
+		})
+
+		It("errors on negative values for AckElicitingThreshold", func() {
+			Expect(validateConfig(&Config{AckElicitingThreshold: -1})).To(MatchError("invalid value for Config.AckElicitingThreshold"))
+		})
+
+		It("errors on negative values for MaxSendBufferBytes", func() {
+			Expect(validateConfig(&Config{MaxSendBufferBytes: -1})).To(MatchError("invalid value for Config.MaxSendBufferBytes"))
+		})
+
+		It("errors on negative values for CongestionEventBufferSize", func() {
+			Expect(validateConfig(&Config{CongestionEventBufferSize: -1})).To(MatchError("invalid value for Config.CongestionEventBufferSize"))
+		})
+
+		It("errors on negative values for InitialCongestionWindowPackets", func() {
+			Expect(validateConfig(&Config{InitialCongestionWindowPackets: -1})).To(MatchError("invalid value for Config.InitialCongestionWindowPackets"))
+		})
+
+		It("errors on negative values for LocoMaxBytesInFlight", func() {
+			Expect(validateConfig(&Config{LocoMaxBytesInFlight: -1})).To(MatchError("invalid value for Config.LocoMaxBytesInFlight"))
+		})
+
+		It("errors on negative values for InitialSlowStartThreshold", func() {
+			Expect(validateConfig(&Config{InitialSlowStartThreshold: -1})).To(MatchError("invalid value for Config.InitialSlowStartThreshold"))
+		})
+
+		It("errors when only one of the Retry token callbacks is set", func() {
+			getRetryToken := func(net.Addr) ([]byte, error) { return nil, nil }
+			validateRetryToken := func(net.Addr, []byte) bool { return true }
+			Expect(validateConfig(&Config{GetRetryToken: getRetryToken})).To(MatchError("Config.GetRetryToken and Config.ValidateRetryToken must be set together"))
+			Expect(validateConfig(&Config{ValidateRetryToken: validateRetryToken})).To(MatchError("Config.GetRetryToken and Config.ValidateRetryToken must be set together"))
+			Expect(validateConfig(&Config{GetRetryToken: getRetryToken, ValidateRetryToken: validateRetryToken})).To(Succeed())
+		})
+
+		It("errors when the initial stream receive window is larger than the maximum", func() {
+			Expect(validateConfig(&Config{InitialStreamReceiveWindow: 1 << 20, MaxStreamReceiveWindow: 1 << 19})).To(MatchError("invalid value for Config.InitialStreamReceiveWindow: larger than MaxStreamReceiveWindow"))
+			Expect(validateConfig(&Config{InitialStreamReceiveWindow: protocol.DefaultMaxReceiveStreamFlowControlWindow + 1})).To(MatchError("invalid value for Config.InitialStreamReceiveWindow: larger than MaxStreamReceiveWindow"))
+			Expect(validateConfig(&Config{InitialStreamReceiveWindow: 1 << 20, MaxStreamReceiveWindow: 1 << 20})).To(Succeed())
+			// the default initial window is larger than this maximum
+			Expect(validateConfig(&Config{MaxStreamReceiveWindow: 1})).To(MatchError("invalid value for Config.InitialStreamReceiveWindow: larger than MaxStreamReceiveWindow"))
+		})
+
+		It("errors when the initial connection receive window is larger than the maximum", func() {
+			Expect(validateConfig(&Config{InitialConnectionReceiveWindow: 1 << 20, MaxConnectionReceiveWindow: 1 << 19})).To(MatchError("invalid value for Config.InitialConnectionReceiveWindow: larger than MaxConnectionReceiveWindow"))
+			Expect(validateConfig(&Config{InitialConnectionReceiveWindow: protocol.DefaultMaxReceiveConnectionFlowControlWindow + 1})).To(MatchError("invalid value for Config.InitialConnectionReceiveWindow: larger than MaxConnectionReceiveWindow"))
+			Expect(validateConfig(&Config{InitialConnectionReceiveWindow: 1 << 20, MaxConnectionReceiveWindow: 1 << 20})).To(Succeed())
+			// the default initial window is larger than this maximum
+			Expect(validateConfig(&Config{MaxConnectionReceiveWindow: 1})).To(MatchError("invalid value for Config.InitialConnectionReceiveWindow: larger than MaxConnectionReceiveWindow"))
+		})
+
+		It("errors on invalid values for RetransmissionOrder", func() {
+			Expect(validateConfig(&Config{RetransmissionOrder: 42})).To(MatchError("invalid value for Config.RetransmissionOrder"))
+			Expect(validateConfig(&Config{RetransmissionOrder: RetransmissionOrderHighestPriorityFirst})).To(Succeed())
+		})
+
+		It("errors on invalid values for PTOProbeStrategy", func() {
+			Expect(validateConfig(&Config{PTOProbeStrategy: 42})).To(MatchError("invalid value for Config.PTOProbeStrategy"))
+			Expect(validateConfig(&Config{PTOProbeStrategy: PTOProbeSendPing})).To(Succeed())
+		})
+
+		It("errors on inconsistent HyStart RTT increase thresholds", func() {
+			Expect(validateConfig(&Config{HyStartConfig: HyStartConfig{
+				MinRTTIncreaseThreshold: 20 * time.Millisecond,
+				MaxRTTIncreaseThreshold: 10 * time.Millisecond,
+			}})).To(MatchError("invalid value for Config.HyStartConfig: MinRTTIncreaseThreshold is larger than MaxRTTIncreaseThreshold"))
+			Expect(validateConfig(&Config{HyStartConfig: HyStartConfig{MinRTTIncreaseThreshold: 20 * time.Millisecond}})).To(Succeed())
+		})
 	})
 
 	configWithNonZeroNonFunctionFields := func() *Config {
@@ -45,7 +146,7 @@ var _ = Describe("Config", func() {
 			}
 
 			switch fn := typ.Field(i).Name; fn {
-			case "AcceptToken", "GetLogWriter", "AllowConnectionWindowIncrease":
+			case "AcceptToken", "GetLogWriter", "AllowConnectionWindowIncrease", "RunLoopHook", "GetRetryToken", "ValidateRetryToken", "ConnectionIDGenerator", "OnConnectionIDChanged", "OnConnectionIDIssued", "OnConnectionIDRetired", "OnNewStream", "OnUnknownConnectionID", "AEADFactory", "PacketCapture", "OnDroppedPacket", "RequireAddressValidation", "OnCongestionCollapse", "PacketLossSimulator":
 				// Can't compare functions.
 			case "Versions":
 				f.Set(reflect.ValueOf([]VersionNumber{1, 2, 3}))
@@ -55,6 +156,8 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf(time.Second))
 			case "MaxIdleTimeout":
 				f.Set(reflect.ValueOf(time.Hour))
+			case "DrainingTimeout":
+				f.Set(reflect.ValueOf(time.Minute))
 			case "TokenStore":
 				f.Set(reflect.ValueOf(NewLRUTokenStore(2, 3)))
 			case "InitialStreamReceiveWindow":
@@ -65,22 +168,78 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf(uint64(4321)))
 			case "MaxConnectionReceiveWindow":
 				f.Set(reflect.ValueOf(uint64(10)))
+			case "DisableReceiveWindowAutoTuning":
+				f.Set(reflect.ValueOf(true))
+			case "MaxSendBufferBytes":
+				f.Set(reflect.ValueOf(1 << 20))
+			case "ActiveConnectionIDLimit":
+				f.Set(reflect.ValueOf(uint64(8)))
+			case "MaxPathChallenges":
+				f.Set(reflect.ValueOf(5))
 			case "MaxIncomingStreams":
 				f.Set(reflect.ValueOf(int64(11)))
 			case "MaxIncomingUniStreams":
 				f.Set(reflect.ValueOf(int64(12)))
+			case "MaxTotalStreams":
+				f.Set(reflect.ValueOf(int64(1000)))
+			case "MaxEncryptionRate":
+				f.Set(reflect.ValueOf(uint64(13)))
+			case "MaxStreamResetRate":
+				f.Set(reflect.ValueOf(uint64(16)))
 			case "StatelessResetKey":
 				f.Set(reflect.ValueOf([]byte{1, 2, 3, 4}))
 			case "KeepAlive":
 				f.Set(reflect.ValueOf(true))
+			case "KeepAlivePeriod":
+				f.Set(reflect.ValueOf(15 * time.Second))
 			case "EnableDatagrams":
 				f.Set(reflect.ValueOf(true))
+			case "DeliverEmptyDatagrams":
+				f.Set(reflect.ValueOf(true))
 			case "DisableVersionNegotiationPackets":
 				f.Set(reflect.ValueOf(true))
+			case "DisableVersionNegotiation":
+				f.Set(reflect.ValueOf(true))
+			case "MaxRetries":
+				f.Set(reflect.ValueOf(5))
+			case "ReceiveBufferSize":
+				f.Set(reflect.ValueOf(1 << 22))
 			case "DisablePathMTUDiscovery":
 				f.Set(reflect.ValueOf(true))
+			case "DisableBatchedWrites":
+				f.Set(reflect.ValueOf(true))
+			case "ReusePort":
+				f.Set(reflect.ValueOf(true))
+			case "MaxCoalescedPackets":
+				f.Set(reflect.ValueOf(2))
+			case "CongestionControlAlgo":
+				f.Set(reflect.ValueOf(congestion.ALGO_LOCO))
+			case "MaxSendRate":
+				f.Set(reflect.ValueOf(congestion.Bandwidth(14)))
+			case "Clock":
+				f.Set(reflect.ValueOf(congestion.DefaultClock{}))
+			case "CongestionLog":
+				f.Set(reflect.ValueOf(&bytes.Buffer{}))
+			case "CongestionEventBufferSize":
+				f.Set(reflect.ValueOf(64))
+			case "AckElicitingThreshold":
+				f.Set(reflect.ValueOf(10))
+			case "PTOProbeStrategy":
+				f.Set(reflect.ValueOf(PTOProbeSendPing))
+			case "RetransmissionOrder":
+				f.Set(reflect.ValueOf(RetransmissionOrderLowestStreamIDFirst))
+			case "HyStartConfig":
+				f.Set(reflect.ValueOf(HyStartConfig{Disable: true, MinRTTSamples: 4}))
+			case "InitialCongestionWindowPackets":
+				f.Set(reflect.ValueOf(64))
+			case "LocoMaxBytesInFlight":
+				f.Set(reflect.ValueOf(protocol.ByteCount(1 << 20)))
+			case "InitialSlowStartThreshold":
+				f.Set(reflect.ValueOf(protocol.ByteCount(100000)))
 			case "Tracer":
 				f.Set(reflect.ValueOf(mocklogging.NewMockTracer(mockCtrl)))
+			case "Logger":
+				f.Set(reflect.ValueOf(utils.DefaultLogger.WithPrefix("foobar")))
 			default:
 				Fail(fmt.Sprintf("all fields must be accounted for, but saw unknown field %q", fn))
 			}
@@ -88,6 +247,56 @@ var _ = Describe("Config", func() {
 		return c
 	}
 
+	Context("low latency preset", func() {
+		It("sets latency-oriented values", func() {
+			c := LowLatencyConfig()
+			Expect(c.HandshakeIdleTimeout).To(Equal(2 * time.Second))
+			Expect(c.MaxIdleTimeout).To(Equal(10 * time.Second))
+			Expect(c.InitialStreamReceiveWindow).To(BeNumerically("<", protocol.DefaultInitialMaxStreamData))
+			Expect(c.InitialConnectionReceiveWindow).To(BeNumerically("<", protocol.DefaultInitialMaxData))
+			Expect(c.KeepAlive).To(BeTrue())
+			Expect(c.CongestionControlAlgo).To(Equal(congestion.ALGO_RENO))
+			Expect(c.InitialCongestionWindowPackets).To(BeNumerically("<", 32)) // smaller than the default
+			Expect(c.AckElicitingThreshold).To(Equal(1))
+		})
+
+		It("produces a valid config", func() {
+			Expect(validateConfig(LowLatencyConfig())).To(Succeed())
+			c := populateClientConfig(LowLatencyConfig(), false)
+			Expect(validateConfig(c)).To(Succeed())
+			Expect(c.HandshakeIdleTimeout).To(Equal(2 * time.Second))
+			Expect(c.MaxIdleTimeout).To(Equal(10 * time.Second))
+			Expect(c.InitialStreamReceiveWindow).To(BeEquivalentTo(64 << 10))
+			Expect(c.InitialConnectionReceiveWindow).To(BeEquivalentTo(96 << 10))
+			Expect(c.MaxStreamReceiveWindow).To(BeEquivalentTo(protocol.DefaultMaxReceiveStreamFlowControlWindow))
+			Expect(c.CongestionControlAlgo).To(Equal(congestion.ALGO_RENO))
+			Expect(c.InitialCongestionWindowPackets).To(Equal(10))
+			Expect(c.AckElicitingThreshold).To(Equal(1))
+			Expect(c.ConnectionIDLength).To(Equal(protocol.DefaultConnectionIDLength))
+		})
+
+		It("returns a new config every time", func() {
+			c := LowLatencyConfig()
+			c.MaxIdleTimeout = time.Minute
+			Expect(LowLatencyConfig().MaxIdleTimeout).To(Equal(10 * time.Second))
+		})
+	})
+
+	Context("supported versions", func() {
+		It("returns the supported versions", func() {
+			Expect(SupportedVersions()).To(Equal(protocol.SupportedVersions))
+		})
+
+		It("returns a copy", func() {
+			orig := make([]protocol.VersionNumber, len(protocol.SupportedVersions))
+			copy(orig, protocol.SupportedVersions)
+			versions := SupportedVersions()
+			versions[0] = 0x1337
+			Expect(protocol.SupportedVersions).To(Equal(orig))
+			Expect(SupportedVersions()).To(Equal(orig))
+		})
+	})
+
 	It("uses 10s handshake timeout for short handshake idle timeouts", func() {
 		c := &Config{HandshakeIdleTimeout: time.Second}
 		Expect(c.handshakeTimeout()).To(Equal(protocol.DefaultHandshakeTimeout))
@@ -98,6 +307,108 @@ var _ = Describe("Config", func() {
 		Expect(c.handshakeTimeout()).To(Equal(11 * time.Second))
 	})
 
+	Context("running the event loop", func() {
+		It("runs the event loop if no hook is set", func() {
+			var ran bool
+			(&Config{}).runLoop(func() { ran = true })
+			Expect(ran).To(BeTrue())
+		})
+
+		It("wraps the event loop in the hook", func() {
+			var events []string
+			c := &Config{
+				RunLoopHook: func(run func()) {
+					events = append(events, "before")
+					run()
+					events = append(events, "after")
+				},
+			}
+			c.runLoop(func() { events = append(events, "run") })
+			Expect(events).To(Equal([]string{"before", "run", "after"}))
+		})
+
+		It("runs the event loop if the hook doesn't", func() {
+			var hookCalled, ran bool
+			c := &Config{RunLoopHook: func(func()) { hookCalled = true }}
+			c.runLoop(func() { ran = true })
+			Expect(hookCalled).To(BeTrue())
+			Expect(ran).To(BeTrue())
+		})
+
+		It("runs the event loop only once if the hook calls run multiple times", func() {
+			var counter int
+			c := &Config{RunLoopHook: func(run func()) {
+				run()
+				run()
+			}}
+			c.runLoop(func() { counter++ })
+			Expect(counter).To(Equal(1))
+		})
+
+		It("runs the event loop only once if the hook runs it on a different goroutine", func() {
+			var counter int32
+			running := make(chan struct{})
+			stop := make(chan struct{})
+			c := &Config{RunLoopHook: func(run func()) { go run() }}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.runLoop(func() {
+					atomic.AddInt32(&counter, 1)
+					close(running)
+					<-stop
+				})
+			}()
+			Eventually(running).Should(BeClosed())
+			// runLoop waits for the event loop to return
+			Expect(done).ToNot(BeClosed())
+			close(stop)
+			Eventually(done).Should(BeClosed())
+			Expect(atomic.LoadInt32(&counter)).To(BeEquivalentTo(1))
+		})
+	})
+
+	Context("generating connection IDs", func() {
+		It("generates random connection IDs if no generator is set", func() {
+			connID, err := (&Config{}).generateConnectionID(8)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connID.Len()).To(Equal(8))
+		})
+
+		It("uses the ConnectionIDGenerator", func() {
+			c := &Config{ConnectionIDGenerator: func(l int) (ConnectionID, error) {
+				Expect(l).To(Equal(5))
+				return ConnectionID{1, 2, 3, 4, 5}, nil
+			}}
+			connID, err := c.generateConnectionID(5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connID).To(Equal(protocol.ConnectionID{1, 2, 3, 4, 5}))
+		})
+
+		It("doesn't use the ConnectionIDGenerator for zero-length connection IDs", func() {
+			c := &Config{ConnectionIDGenerator: func(int) (ConnectionID, error) {
+				Fail("generator should not be called")
+				return nil, nil
+			}}
+			connID, err := c.generateConnectionID(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connID.Len()).To(BeZero())
+		})
+
+		It("returns errors from the ConnectionIDGenerator", func() {
+			testErr := errors.New("test error")
+			c := &Config{ConnectionIDGenerator: func(int) (ConnectionID, error) { return nil, testErr }}
+			_, err := c.generateConnectionID(5)
+			Expect(err).To(MatchError(testErr))
+		})
+
+		It("errors when the ConnectionIDGenerator returns a connection ID of the wrong length", func() {
+			c := &Config{ConnectionIDGenerator: func(int) (ConnectionID, error) { return ConnectionID{1, 2, 3, 4}, nil }}
+			_, err := c.generateConnectionID(5)
+			Expect(err).To(MatchError("Config.ConnectionIDGenerator returned a connection ID of length 4, expected 5"))
+		})
+	})
+
 	Context("cloning", func() {
 		It("clones function fields", func() {
 			var calledAcceptToken, calledAllowConnectionWindowIncrease bool
@@ -133,13 +444,34 @@ var _ = Describe("Config", func() {
 
 	Context("populating", func() {
 		It("populates function fields", func() {
-			var calledAcceptToken bool
+			var calledAcceptToken, calledRunLoopHook, calledPacketCapture, calledOnDroppedPacket, calledRequireAddressValidation, calledOnCongestionCollapse, calledOnConnectionIDIssued, calledOnConnectionIDRetired bool
 			c1 := &Config{
-				AcceptToken: func(_ net.Addr, _ *Token) bool { calledAcceptToken = true; return true },
+				AcceptToken:              func(_ net.Addr, _ *Token) bool { calledAcceptToken = true; return true },
+				RunLoopHook:              func(func()) { calledRunLoopHook = true },
+				PacketCapture:            func(PacketDirection, []byte, net.Addr) { calledPacketCapture = true },
+				OnDroppedPacket:          func(DropReason, *logging.Header) { calledOnDroppedPacket = true },
+				RequireAddressValidation: func(net.Addr) bool { calledRequireAddressValidation = true; return true },
+				OnCongestionCollapse:     func() { calledOnCongestionCollapse = true },
+				OnConnectionIDIssued:     func(ConnectionID) { calledOnConnectionIDIssued = true },
+				OnConnectionIDRetired:    func(ConnectionID) { calledOnConnectionIDRetired = true },
 			}
 			c2 := populateConfig(c1)
 			c2.AcceptToken(&net.UDPAddr{}, &Token{})
 			Expect(calledAcceptToken).To(BeTrue())
+			c2.RunLoopHook(func() {})
+			Expect(calledRunLoopHook).To(BeTrue())
+			c2.PacketCapture(PacketDirectionSent, nil, &net.UDPAddr{})
+			Expect(calledPacketCapture).To(BeTrue())
+			c2.OnDroppedPacket(logging.PacketDropDuplicate, nil)
+			Expect(calledOnDroppedPacket).To(BeTrue())
+			c2.RequireAddressValidation(&net.UDPAddr{})
+			Expect(calledRequireAddressValidation).To(BeTrue())
+			c2.OnCongestionCollapse()
+			Expect(calledOnCongestionCollapse).To(BeTrue())
+			c2.OnConnectionIDIssued(nil)
+			Expect(calledOnConnectionIDIssued).To(BeTrue())
+			c2.OnConnectionIDRetired(nil)
+			Expect(calledOnConnectionIDRetired).To(BeTrue())
 		})
 
 		It("copies non-function fields", func() {
@@ -157,8 +489,18 @@ var _ = Describe("Config", func() {
 			Expect(c.MaxConnectionReceiveWindow).To(BeEquivalentTo(protocol.DefaultMaxReceiveConnectionFlowControlWindow))
 			Expect(c.MaxIncomingStreams).To(BeEquivalentTo(protocol.DefaultMaxIncomingStreams))
 			Expect(c.MaxIncomingUniStreams).To(BeEquivalentTo(protocol.DefaultMaxIncomingUniStreams))
+			Expect(c.ActiveConnectionIDLimit).To(BeEquivalentTo(protocol.MinActiveConnectionIDLimit))
+			Expect(c.MaxPathChallenges).To(Equal(protocol.DefaultMaxPathChallenges))
 			Expect(c.DisableVersionNegotiationPackets).To(BeFalse())
 			Expect(c.DisablePathMTUDiscovery).To(BeFalse())
+			Expect(c.DisableBatchedWrites).To(BeFalse())
+			Expect(c.CongestionControlAlgo).To(Equal(congestion.ALGO_RENO))
+			Expect(c.Clock).To(Equal(congestion.DefaultClock{}))
+			Expect(c.AckElicitingThreshold).To(Equal(protocol.DefaultAckElicitingThreshold))
+			Expect(c.DrainingTimeout).To(BeZero())
+			Expect(c.ReceiveBufferSize).To(BeZero())
+			Expect(c.MaxRetries).To(Equal(protocol.DefaultMaxRetries))
+			Expect(c.Logger).To(Equal(utils.DefaultLogger))
 		})
 
 		It("populates empty fields with default values, for the server", func() {
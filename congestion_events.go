@@ -0,0 +1,127 @@
+package quic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/logging"
+)
+
+// A CongestionEventType is the type of a CongestionEvent.
+type CongestionEventType uint8
+
+const (
+	// CongestionEventStateUpdated is emitted when the congestion controller changes its state,
+	// e.g. when it leaves slow start or enters recovery.
+	CongestionEventStateUpdated CongestionEventType = iota
+	// CongestionEventWindowUpdated is emitted when the congestion window or the slow start threshold changes.
+	CongestionEventWindowUpdated
+	// CongestionEventPacketLost is emitted when a packet is declared lost.
+	CongestionEventPacketLost
+)
+
+// A CongestionEvent is an event of the congestion controller, as delivered by Session.CongestionEvents.
+// Depending on the Type, only some of the fields are set.
+type CongestionEvent struct {
+	Type CongestionEventType
+	Time time.Time
+
+	// set for CongestionEventStateUpdated
+	State logging.CongestionState
+
+	// set for CongestionEventWindowUpdated
+	CongestionWindow   protocol.ByteCount
+	SlowStartThreshold protocol.ByteCount
+	BytesInFlight      protocol.ByteCount
+
+	// set for CongestionEventPacketLost
+	EncryptionLevel protocol.EncryptionLevel
+	PacketNumber    protocol.PacketNumber
+	LossReason      logging.PacketLossReason
+}
+
+// The congestionEventTracer turns the congestion related tracer events into CongestionEvents.
+// It never blocks: events are dropped if the channel is full.
+type congestionEventTracer struct {
+	logging.ConnectionTracer
+
+	mutex  sync.Mutex
+	events chan CongestionEvent
+	closed bool
+
+	// only accessed from the run loop, through the tracer
+	lastCongestionWindow   protocol.ByteCount
+	lastSlowStartThreshold protocol.ByteCount
+
+	dropped uint64 // accessed atomically
+}
+
+var _ logging.ConnectionTracer = &congestionEventTracer{}
+
+func newCongestionEventTracer(bufferSize int) *congestionEventTracer {
+	return &congestionEventTracer{
+		ConnectionTracer: logging.NullConnectionTracer,
+		events:           make(chan CongestionEvent, bufferSize),
+	}
+}
+
+func (t *congestionEventTracer) UpdatedCongestionState(state logging.CongestionState) {
+	t.queueEvent(CongestionEvent{Type: CongestionEventStateUpdated, State: state})
+}
+
+func (t *congestionEventTracer) UpdatedMetrics(_ *logging.RTTStats, cwnd, bytesInFlight, ssthresh logging.ByteCount, _ int) {
+	// UpdatedMetrics is called for every ACK, but we only report changes of the window
+	if cwnd == t.lastCongestionWindow && ssthresh == t.lastSlowStartThreshold {
+		return
+	}
+	t.lastCongestionWindow = cwnd
+	t.lastSlowStartThreshold = ssthresh
+	t.queueEvent(CongestionEvent{
+		Type:               CongestionEventWindowUpdated,
+		CongestionWindow:   cwnd,
+		SlowStartThreshold: ssthresh,
+		BytesInFlight:      bytesInFlight,
+	})
+}
+
+func (t *congestionEventTracer) LostPacket(encLevel logging.EncryptionLevel, pn logging.PacketNumber, reason logging.PacketLossReason) {
+	t.queueEvent(CongestionEvent{
+		Type:            CongestionEventPacketLost,
+		EncryptionLevel: encLevel,
+		PacketNumber:    pn,
+		LossReason:      reason,
+	})
+}
+
+func (t *congestionEventTracer) Close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.events)
+	}
+}
+
+func (t *congestionEventTracer) queueEvent(e CongestionEvent) {
+	e.Time = time.Now()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.events <- e:
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+	}
+}
+
+func (t *congestionEventTracer) Events() <-chan CongestionEvent {
+	return t.events
+}
+
+func (t *congestionEventTracer) Dropped() uint64 {
+	return atomic.LoadUint64(&t.dropped)
+}
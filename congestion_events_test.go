@@ -0,0 +1,76 @@
+package quic
+
+import (
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Congestion Events", func() {
+	var tracer *congestionEventTracer
+
+	BeforeEach(func() {
+		tracer = newCongestionEventTracer(3)
+	})
+
+	It("exports congestion state updates", func() {
+		tracer.UpdatedCongestionState(logging.CongestionStateRecovery)
+		var event CongestionEvent
+		Expect(tracer.Events()).To(Receive(&event))
+		Expect(event.Type).To(Equal(CongestionEventStateUpdated))
+		Expect(event.State).To(Equal(logging.CongestionStateRecovery))
+		Expect(event.Time).ToNot(BeZero())
+	})
+
+	It("exports lost packets", func() {
+		tracer.LostPacket(protocol.Encryption1RTT, 42, logging.PacketLossTimeThreshold)
+		var event CongestionEvent
+		Expect(tracer.Events()).To(Receive(&event))
+		Expect(event.Type).To(Equal(CongestionEventPacketLost))
+		Expect(event.EncryptionLevel).To(Equal(protocol.Encryption1RTT))
+		Expect(event.PacketNumber).To(Equal(protocol.PacketNumber(42)))
+		Expect(event.LossReason).To(Equal(logging.PacketLossTimeThreshold))
+	})
+
+	It("only exports metrics updates when the congestion window or the slow start threshold changes", func() {
+		tracer.UpdatedMetrics(nil, 1000, 500, 2000, 1)
+		tracer.UpdatedMetrics(nil, 1000, 700, 2000, 2)
+		tracer.UpdatedMetrics(nil, 1200, 700, 2000, 2)
+		var event CongestionEvent
+		Expect(tracer.Events()).To(Receive(&event))
+		Expect(event.Type).To(Equal(CongestionEventWindowUpdated))
+		Expect(event.CongestionWindow).To(Equal(protocol.ByteCount(1000)))
+		Expect(event.SlowStartThreshold).To(Equal(protocol.ByteCount(2000)))
+		Expect(event.BytesInFlight).To(Equal(protocol.ByteCount(500)))
+		Expect(tracer.Events()).To(Receive(&event))
+		Expect(event.CongestionWindow).To(Equal(protocol.ByteCount(1200)))
+		Expect(event.BytesInFlight).To(Equal(protocol.ByteCount(700)))
+		Expect(tracer.Events()).ToNot(Receive())
+	})
+
+	It("drops events when the buffer is full", func() {
+		for i := 0; i < 5; i++ {
+			tracer.LostPacket(protocol.Encryption1RTT, protocol.PacketNumber(i), logging.PacketLossReorderingThreshold)
+		}
+		Expect(tracer.Dropped()).To(BeEquivalentTo(2))
+		for i := 0; i < 3; i++ {
+			var event CongestionEvent
+			Expect(tracer.Events()).To(Receive(&event))
+			Expect(event.PacketNumber).To(Equal(protocol.PacketNumber(i)))
+		}
+		Expect(tracer.Events()).ToNot(Receive())
+	})
+
+	It("closes the channel when the tracer is closed", func() {
+		tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
+		tracer.Close()
+		tracer.Close() // closing twice doesn't panic
+		Expect(tracer.Events()).To(Receive())
+		Expect(tracer.Events()).To(BeClosed())
+		// events are ignored after closing
+		tracer.LostPacket(protocol.Encryption1RTT, 1, logging.PacketLossReorderingThreshold)
+		Expect(tracer.Dropped()).To(BeZero())
+	})
+})
@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"errors"
 	"io"
 	"net"
 	"syscall"
@@ -17,6 +18,16 @@ type connection interface {
 	io.Closer
 }
 
+// A batchWriter sends multiple packets to the same address, using as few syscalls as possible.
+type batchWriter interface {
+	// WritePackets returns the number of syscalls that were needed to send the packets.
+	// Packets that are too large to be sent are skipped.
+	// If the packets can't be sent in a batch, it returns errBatchWriteUnsupported without sending any packet.
+	WritePackets(packets [][]byte, addr net.Addr, oob []byte) (syscalls int, err error)
+}
+
+var errBatchWriteUnsupported = errors.New("batch writes not supported")
+
 // If the PacketConn passed to Dial or Listen satisfies this interface, quic-go will read the ECN bits from the IP header.
 // In this case, ReadMsgUDP() will be used instead of ReadFrom() to read packets.
 type OOBCapablePacketConn interface {
@@ -3,7 +3,11 @@
 
 package quic
 
-import "net"
+import (
+	"net"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
 
 func newConn(c net.PacketConn) (connection, error) {
 	return &basicConn{PacketConn: c}, nil
@@ -14,3 +18,7 @@ func inspectReadBuffer(interface{}) (int, error) {
 }
 
 func (i *packetInfo) OOB() []byte { return nil }
+
+func newBatchWriter(net.PacketConn) batchWriter { return nil }
+
+func ecnControlMessage(protocol.ECN, net.Addr) []byte { return nil }
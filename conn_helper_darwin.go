@@ -3,7 +3,13 @@
 
 package quic
 
-import "golang.org/x/sys/unix"
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
 
 const msgTypeIPTOS = unix.IP_RECVTOS
 
@@ -20,3 +26,10 @@ const (
 // ReadBatch only returns a single packet on OSX,
 // see https://godoc.org/golang.org/x/net/ipv4#PacketConn.ReadBatch.
 const batchSize = 1
+
+// WriteBatch only sends a single packet per syscall on OSX,
+// see https://godoc.org/golang.org/x/net/ipv4#PacketConn.WriteBatch.
+func newBatchWriter(net.PacketConn) batchWriter { return nil }
+
+// Sending of ECN marks is only implemented on Linux.
+func ecnControlMessage(protocol.ECN, net.Addr) []byte { return nil }
@@ -3,7 +3,14 @@
 
 package quic
 
-import "golang.org/x/sys/unix"
+import (
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
 
 const msgTypeIPTOS = unix.IP_TOS
 
@@ -18,3 +25,24 @@ const (
 )
 
 const batchSize = 8 // needs to smaller than MaxUint8 (otherwise the type of oobConn.readPos has to be changed)
+
+// ecnControlMessage returns the control message that sets the ECN marking of a packet sent to addr.
+// IPv4 packets are marked using IP_TOS, even when they're sent on an IPv6 socket using an IPv4-mapped address.
+func ecnControlMessage(ecn protocol.ECN, addr net.Addr) []byte {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok || ecn == protocol.ECNNon {
+		return nil
+	}
+	b := make([]byte, unix.CmsgSpace(4))
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&b[0]))
+	if udpAddr.IP.To4() != nil {
+		h.Level = unix.IPPROTO_IP
+		h.Type = unix.IP_TOS
+	} else {
+		h.Level = unix.IPPROTO_IPV6
+		h.Type = unix.IPV6_TCLASS
+	}
+	h.SetLen(unix.CmsgLen(4))
+	*(*int32)(unsafe.Pointer(&b[unix.CmsgLen(0)])) = int32(ecn)
+	return b
+}
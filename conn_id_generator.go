@@ -2,6 +2,7 @@ package quic
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
@@ -16,11 +17,12 @@ type connIDGenerator struct {
 	activeSrcConnIDs        map[uint64]protocol.ConnectionID
 	initialClientDestConnID protocol.ConnectionID
 
+	generateConnectionID   func(int) (protocol.ConnectionID, error)
 	addConnectionID        func(protocol.ConnectionID)
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken
 	removeConnectionID     func(protocol.ConnectionID)
 	retireConnectionID     func(protocol.ConnectionID)
-	replaceWithClosed      func(protocol.ConnectionID, packetHandler)
+	replaceWithClosed      func(protocol.ConnectionID, packetHandler, time.Duration)
 	queueControlFrame      func(wire.Frame)
 
 	version protocol.VersionNumber
@@ -29,17 +31,19 @@ type connIDGenerator struct {
 func newConnIDGenerator(
 	initialConnectionID protocol.ConnectionID,
 	initialClientDestConnID protocol.ConnectionID, // nil for the client
+	generateConnectionID func(int) (protocol.ConnectionID, error),
 	addConnectionID func(protocol.ConnectionID),
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken,
 	removeConnectionID func(protocol.ConnectionID),
 	retireConnectionID func(protocol.ConnectionID),
-	replaceWithClosed func(protocol.ConnectionID, packetHandler),
+	replaceWithClosed func(protocol.ConnectionID, packetHandler, time.Duration),
 	queueControlFrame func(wire.Frame),
 	version protocol.VersionNumber,
 ) *connIDGenerator {
 	m := &connIDGenerator{
 		connIDLen:              initialConnectionID.Len(),
 		activeSrcConnIDs:       make(map[uint64]protocol.ConnectionID),
+		generateConnectionID:   generateConnectionID,
 		addConnectionID:        addConnectionID,
 		getStatelessResetToken: getStatelessResetToken,
 		removeConnectionID:     removeConnectionID,
@@ -99,7 +103,7 @@ func (m *connIDGenerator) Retire(seq uint64, sentWithDestConnID protocol.Connect
 }
 
 func (m *connIDGenerator) issueNewConnID() error {
-	connID, err := protocol.GenerateConnectionID(m.connIDLen)
+	connID, err := m.generateConnectionID(m.connIDLen)
 	if err != nil {
 		return err
 	}
@@ -121,6 +125,18 @@ func (m *connIDGenerator) SetHandshakeComplete() {
 	}
 }
 
+// ActiveConnIDs returns all connection IDs that the peer might currently use.
+func (m *connIDGenerator) ActiveConnIDs() []protocol.ConnectionID {
+	connIDs := make([]protocol.ConnectionID, 0, len(m.activeSrcConnIDs)+1)
+	if m.initialClientDestConnID != nil {
+		connIDs = append(connIDs, m.initialClientDestConnID)
+	}
+	for _, connID := range m.activeSrcConnIDs {
+		connIDs = append(connIDs, connID)
+	}
+	return connIDs
+}
+
 func (m *connIDGenerator) RemoveAll() {
 	if m.initialClientDestConnID != nil {
 		m.removeConnectionID(m.initialClientDestConnID)
@@ -130,11 +146,11 @@ func (m *connIDGenerator) RemoveAll() {
 	}
 }
 
-func (m *connIDGenerator) ReplaceWithClosed(handler packetHandler) {
+func (m *connIDGenerator) ReplaceWithClosed(handler packetHandler, timeout time.Duration) {
 	if m.initialClientDestConnID != nil {
-		m.replaceWithClosed(m.initialClientDestConnID, handler)
+		m.replaceWithClosed(m.initialClientDestConnID, handler, timeout)
 	}
 	for _, connID := range m.activeSrcConnIDs {
-		m.replaceWithClosed(connID, handler)
+		m.replaceWithClosed(connID, handler, timeout)
 	}
 }
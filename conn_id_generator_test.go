@@ -1,7 +1,9 @@
 package quic
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
@@ -17,6 +19,7 @@ var _ = Describe("Connection ID Generator", func() {
 		retiredConnIDs     []protocol.ConnectionID
 		removedConnIDs     []protocol.ConnectionID
 		replacedWithClosed map[string]packetHandler
+		closedTimeouts     []time.Duration
 		queuedFrames       []wire.Frame
 		g                  *connIDGenerator
 	)
@@ -33,14 +36,19 @@ var _ = Describe("Connection ID Generator", func() {
 		removedConnIDs = nil
 		queuedFrames = nil
 		replacedWithClosed = make(map[string]packetHandler)
+		closedTimeouts = nil
 		g = newConnIDGenerator(
 			initialConnID,
 			initialClientDestConnID,
+			protocol.GenerateConnectionID,
 			func(c protocol.ConnectionID) { addedConnIDs = append(addedConnIDs, c) },
 			connIDToToken,
 			func(c protocol.ConnectionID) { removedConnIDs = append(removedConnIDs, c) },
 			func(c protocol.ConnectionID) { retiredConnIDs = append(retiredConnIDs, c) },
-			func(c protocol.ConnectionID, h packetHandler) { replacedWithClosed[string(c)] = h },
+			func(c protocol.ConnectionID, h packetHandler, timeout time.Duration) {
+				replacedWithClosed[string(c)] = h
+				closedTimeouts = append(closedTimeouts, timeout)
+			},
 			func(f wire.Frame) { queuedFrames = append(queuedFrames, f) },
 			protocol.VersionDraft29,
 		)
@@ -64,6 +72,27 @@ var _ = Describe("Connection ID Generator", func() {
 		}
 	})
 
+	It("uses the connection ID generation function", func() {
+		var lengths []int
+		g.generateConnectionID = func(l int) (protocol.ConnectionID, error) {
+			lengths = append(lengths, l)
+			return protocol.ConnectionID{byte(len(lengths)), 0, 0, 0, 0, 0, 0}, nil
+		}
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		Expect(lengths).To(Equal([]int{7, 7}))
+		Expect(addedConnIDs).To(Equal([]protocol.ConnectionID{
+			{1, 0, 0, 0, 0, 0, 0},
+			{2, 0, 0, 0, 0, 0, 0},
+		}))
+	})
+
+	It("returns errors from the connection ID generation function", func() {
+		testErr := errors.New("test error")
+		g.generateConnectionID = func(int) (protocol.ConnectionID, error) { return nil, testErr }
+		Expect(g.SetMaxActiveConnIDs(3)).To(MatchError(testErr))
+		Expect(addedConnIDs).To(BeEmpty())
+	})
+
 	It("limits the number of connection IDs that it issues", func() {
 		Expect(g.SetMaxActiveConnIDs(9999999)).To(Succeed())
 		Expect(retiredConnIDs).To(BeEmpty())
@@ -158,6 +187,21 @@ var _ = Describe("Connection ID Generator", func() {
 		Expect(retiredConnIDs[0]).To(Equal(initialClientDestConnID))
 	})
 
+	It("returns all active connection IDs", func() {
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(2))
+		connIDs := g.ActiveConnIDs()
+		Expect(connIDs).To(HaveLen(4)) // initial conn ID, initial client dest conn id, and newly issued ones
+		Expect(connIDs).To(ContainElement(initialConnID))
+		Expect(connIDs).To(ContainElement(initialClientDestConnID))
+		for _, f := range queuedFrames {
+			Expect(connIDs).To(ContainElement(f.(*wire.NewConnectionIDFrame).ConnectionID))
+		}
+		g.SetHandshakeComplete()
+		Expect(g.ActiveConnIDs()).To(HaveLen(3))
+		Expect(g.ActiveConnIDs()).ToNot(ContainElement(initialClientDestConnID))
+	})
+
 	It("removes all connection IDs", func() {
 		Expect(g.SetMaxActiveConnIDs(5)).To(Succeed())
 		Expect(queuedFrames).To(HaveLen(4))
@@ -175,8 +219,12 @@ var _ = Describe("Connection ID Generator", func() {
 		Expect(g.SetMaxActiveConnIDs(5)).To(Succeed())
 		Expect(queuedFrames).To(HaveLen(4))
 		sess := NewMockPacketHandler(mockCtrl)
-		g.ReplaceWithClosed(sess)
+		g.ReplaceWithClosed(sess, time.Minute)
 		Expect(replacedWithClosed).To(HaveLen(6)) // initial conn ID, initial client dest conn id, and newly issued ones
+		Expect(closedTimeouts).To(HaveLen(6))
+		for _, t := range closedTimeouts {
+			Expect(t).To(Equal(time.Minute))
+		}
 		Expect(replacedWithClosed).To(HaveKeyWithValue(string(initialClientDestConnID), sess))
 		Expect(replacedWithClosed).To(HaveKeyWithValue(string(initialConnID), sess))
 		for _, f := range queuedFrames {
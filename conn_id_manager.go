@@ -17,6 +17,9 @@ type connIDManager struct {
 	highestRetired            uint64
 	activeConnectionID        protocol.ConnectionID
 	activeStatelessResetToken *protocol.StatelessResetToken
+	activeConnectionIDLimit   uint64 // the number of connection IDs we store, including the active one
+	// the connection ID used on a new path while it is validated, see Session.MigrateTo
+	pathConnID *utils.NewConnectionID
 
 	// We change the connection ID after sending on average
 	// protocol.PacketsPerConnectionID packets. The actual value is randomized
@@ -32,12 +35,14 @@ type connIDManager struct {
 
 func newConnIDManager(
 	initialDestConnID protocol.ConnectionID,
+	activeConnectionIDLimit uint64,
 	addStatelessResetToken func(protocol.StatelessResetToken),
 	removeStatelessResetToken func(protocol.StatelessResetToken),
 	queueControlFrame func(wire.Frame),
 ) *connIDManager {
 	return &connIDManager{
 		activeConnectionID:        initialDestConnID,
+		activeConnectionIDLimit:   activeConnectionIDLimit,
 		addStatelessResetToken:    addStatelessResetToken,
 		removeStatelessResetToken: removeStatelessResetToken,
 		queueControlFrame:         queueControlFrame,
@@ -52,7 +57,7 @@ func (h *connIDManager) Add(f *wire.NewConnectionIDFrame) error {
 	if err := h.add(f); err != nil {
 		return err
 	}
-	if h.queue.Len() >= protocol.MaxActiveConnectionIDs {
+	if uint64(h.numStored()) >= h.activeConnectionIDLimit {
 		return &qerr.TransportError{ErrorCode: qerr.ConnectionIDLimitError}
 	}
 	return nil
@@ -152,10 +157,71 @@ func (h *connIDManager) updateConnectionID() {
 	h.addStatelessResetToken(*h.activeStatelessResetToken)
 }
 
+// numStored returns the number of connection IDs stored, not counting the active one.
+func (h *connIDManager) numStored() int {
+	n := h.queue.Len()
+	if h.pathConnID != nil {
+		n++
+	}
+	return n
+}
+
+// GetForNewPath takes an unused connection ID from the queue, to be used on a new path.
+// A connection ID must not be used on more than one path, see section 9.5 of RFC 9000.
+// The stateless reset token is only expected on the new path, so the caller registers it there.
+// It returns false if the peer didn't provide an unused connection ID.
+func (h *connIDManager) GetForNewPath() (protocol.ConnectionID, protocol.StatelessResetToken, bool) {
+	if h.pathConnID == nil {
+		if h.queue.Len() == 0 {
+			return nil, protocol.StatelessResetToken{}, false
+		}
+		front := h.queue.Remove(h.queue.Front())
+		h.pathConnID = &front
+	}
+	return h.pathConnID.ConnectionID, h.pathConnID.StatelessResetToken, true
+}
+
+// SwitchToNewPath makes the connection ID returned by GetForNewPath the active connection ID.
+// The connection ID that was used on the old path is retired.
+func (h *connIDManager) SwitchToNewPath() {
+	if h.pathConnID == nil {
+		return
+	}
+	h.queueControlFrame(&wire.RetireConnectionIDFrame{
+		SequenceNumber: h.activeSequenceNumber,
+	})
+	h.highestRetired = utils.MaxUint64(h.highestRetired, h.activeSequenceNumber)
+	if h.activeStatelessResetToken != nil {
+		h.removeStatelessResetToken(*h.activeStatelessResetToken)
+	}
+	h.activeSequenceNumber = h.pathConnID.SequenceNumber
+	h.activeConnectionID = h.pathConnID.ConnectionID
+	h.activeStatelessResetToken = &h.pathConnID.StatelessResetToken
+	h.pathConnID = nil
+	h.packetsSinceLastChange = 0
+	h.addStatelessResetToken(*h.activeStatelessResetToken)
+}
+
+// AbandonNewPath retires the connection ID returned by GetForNewPath.
+// It was already used on the new path, and therefore can't be used on the active path.
+func (h *connIDManager) AbandonNewPath() {
+	if h.pathConnID == nil {
+		return
+	}
+	h.queueControlFrame(&wire.RetireConnectionIDFrame{
+		SequenceNumber: h.pathConnID.SequenceNumber,
+	})
+	h.removeStatelessResetToken(h.pathConnID.StatelessResetToken)
+	h.pathConnID = nil
+}
+
 func (h *connIDManager) Close() {
 	if h.activeStatelessResetToken != nil {
 		h.removeStatelessResetToken(*h.activeStatelessResetToken)
 	}
+	if h.pathConnID != nil {
+		h.removeStatelessResetToken(h.pathConnID.StatelessResetToken)
+	}
 }
 
 // is called when the server performs a Retry
@@ -176,6 +242,12 @@ func (h *connIDManager) SetStatelessResetToken(token protocol.StatelessResetToke
 	h.addStatelessResetToken(token)
 }
 
+// StatelessResetToken returns the stateless reset token of the active connection ID.
+// It returns nil if the peer didn't provide a stateless reset token for this connection ID.
+func (h *connIDManager) StatelessResetToken() *protocol.StatelessResetToken {
+	return h.activeStatelessResetToken
+}
+
 func (h *connIDManager) SentPacket() {
 	h.packetsSinceLastChange++
 }
@@ -191,7 +263,7 @@ func (h *connIDManager) shouldUpdateConnID() bool {
 	// For later changes, only change if
 	// 1. The queue of connection IDs is filled more than 50%.
 	// 2. We sent at least PacketsPerConnectionID packets
-	return 2*h.queue.Len() >= protocol.MaxActiveConnectionIDs &&
+	return 2*uint64(h.queue.Len()) >= h.activeConnectionIDLimit &&
 		h.packetsSinceLastChange >= h.packetsPerConnectionID
 }
 
@@ -10,6 +10,9 @@ import (
 )
 
 var _ = Describe("Connection ID Manager", func() {
+	// the active_connection_id_limit used by the tests
+	const activeConnIDLimit = 4
+
 	var (
 		m             *connIDManager
 		frameQueue    []wire.Frame
@@ -24,6 +27,7 @@ var _ = Describe("Connection ID Manager", func() {
 		removedTokens = nil
 		m = newConnIDManager(
 			initialConnID,
+			activeConnIDLimit,
 			func(token protocol.StatelessResetToken) { tokenAdded = &token },
 			func(token protocol.StatelessResetToken) { removedTokens = append(removedTokens, token) },
 			func(f wire.Frame,
@@ -42,6 +46,7 @@ var _ = Describe("Connection ID Manager", func() {
 
 	It("returns the initial connection ID", func() {
 		Expect(m.Get()).To(Equal(initialConnID))
+		Expect(m.StatelessResetToken()).To(BeNil())
 	})
 
 	It("changes the initial connection ID", func() {
@@ -54,6 +59,7 @@ var _ = Describe("Connection ID Manager", func() {
 		m.SetStatelessResetToken(token)
 		Expect(*m.activeStatelessResetToken).To(Equal(token))
 		Expect(*tokenAdded).To(Equal(token))
+		Expect(m.StatelessResetToken()).To(Equal(&token))
 	})
 
 	It("adds and gets connection IDs", func() {
@@ -222,7 +228,24 @@ var _ = Describe("Connection ID Manager", func() {
 	})
 
 	It("errors when the peer sends too connection IDs", func() {
-		for i := uint8(1); i < protocol.MaxActiveConnectionIDs; i++ {
+		for i := uint8(1); i < activeConnIDLimit; i++ {
+			Expect(m.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      uint64(i),
+				ConnectionID:        protocol.ConnectionID{i, i, i, i},
+				StatelessResetToken: protocol.StatelessResetToken{i, i, i, i, i, i, i, i, i, i, i, i, i, i, i, i},
+			})).To(Succeed())
+		}
+		Expect(m.Add(&wire.NewConnectionIDFrame{
+			SequenceNumber:      uint64(9999),
+			ConnectionID:        protocol.ConnectionID{1, 2, 3, 4},
+			StatelessResetToken: protocol.StatelessResetToken{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		})).To(MatchError(&qerr.TransportError{ErrorCode: qerr.ConnectionIDLimitError}))
+	})
+
+	It("uses the configured limit for the number of connection IDs", func() {
+		const limit = 2 * activeConnIDLimit
+		m.activeConnectionIDLimit = limit
+		for i := uint8(1); i < limit; i++ {
 			Expect(m.Add(&wire.NewConnectionIDFrame{
 				SequenceNumber:      uint64(i),
 				ConnectionID:        protocol.ConnectionID{i, i, i, i},
@@ -261,7 +284,7 @@ var _ = Describe("Connection ID Manager", func() {
 
 	It("initiates subsequent updates when enough packets are sent", func() {
 		var s uint8
-		for s = uint8(1); s < protocol.MaxActiveConnectionIDs; s++ {
+		for s = uint8(1); s < activeConnIDLimit; s++ {
 			Expect(m.Add(&wire.NewConnectionIDFrame{
 				SequenceNumber:      uint64(s),
 				ConnectionID:        protocol.ConnectionID{s, s, s, s},
@@ -295,7 +318,7 @@ var _ = Describe("Connection ID Manager", func() {
 	})
 
 	It("retires delayed connection IDs that arrive after a higher connection ID was already retired", func() {
-		for s := uint8(10); s <= 10+protocol.MaxActiveConnectionIDs/2; s++ {
+		for s := uint8(10); s <= 10+activeConnIDLimit/2; s++ {
 			Expect(m.Add(&wire.NewConnectionIDFrame{
 				SequenceNumber:      uint64(s),
 				ConnectionID:        protocol.ConnectionID{s, s, s, s},
@@ -325,7 +348,7 @@ var _ = Describe("Connection ID Manager", func() {
 	})
 
 	It("only initiates subsequent updates when enough if enough connection IDs are queued", func() {
-		for i := uint8(1); i <= protocol.MaxActiveConnectionIDs/2; i++ {
+		for i := uint8(1); i <= activeConnIDLimit/2; i++ {
 			Expect(m.Add(&wire.NewConnectionIDFrame{
 				SequenceNumber:      uint64(i),
 				ConnectionID:        protocol.ConnectionID{i, i, i, i},
@@ -361,4 +384,84 @@ var _ = Describe("Connection ID Manager", func() {
 		Expect(removedTokens).To(HaveLen(1))
 		Expect(removedTokens[0]).To(Equal(protocol.StatelessResetToken{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}))
 	})
+
+	Context("migrating to a new path", func() {
+		BeforeEach(func() {
+			Expect(m.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      1,
+				ConnectionID:        protocol.ConnectionID{1, 2, 3, 4},
+				StatelessResetToken: protocol.StatelessResetToken{1},
+			})).To(Succeed())
+			Expect(m.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      2,
+				ConnectionID:        protocol.ConnectionID{2, 3, 4, 5},
+				StatelessResetToken: protocol.StatelessResetToken{2},
+			})).To(Succeed())
+		})
+
+		It("doesn't return a connection ID for a new path if the peer didn't provide an unused one", func() {
+			m.queue.Init()
+			_, _, ok := m.GetForNewPath()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("uses an unused connection ID for the new path", func() {
+			connID, token, ok := m.GetForNewPath()
+			Expect(ok).To(BeTrue())
+			Expect(connID).To(Equal(protocol.ConnectionID{1, 2, 3, 4}))
+			Expect(token).To(Equal(protocol.StatelessResetToken{1}))
+			Expect(tokenAdded).To(BeNil())
+			// the connection ID is reserved for the new path
+			connID, _, ok = m.GetForNewPath()
+			Expect(ok).To(BeTrue())
+			Expect(connID).To(Equal(protocol.ConnectionID{1, 2, 3, 4}))
+			// the active path doesn't switch to the reserved connection ID
+			m.SetHandshakeComplete()
+			Expect(m.Get()).To(Equal(protocol.ConnectionID{2, 3, 4, 5}))
+		})
+
+		It("retires the connection ID of the old path when switching to the new path", func() {
+			m.GetForNewPath()
+			m.SwitchToNewPath()
+			Expect(m.Get()).To(Equal(protocol.ConnectionID{1, 2, 3, 4}))
+			Expect(m.StatelessResetToken()).To(Equal(&protocol.StatelessResetToken{1}))
+			Expect(*tokenAdded).To(Equal(protocol.StatelessResetToken{1}))
+			Expect(frameQueue).To(Equal([]wire.Frame{&wire.RetireConnectionIDFrame{SequenceNumber: 0}}))
+			_, _, ok := m.GetForNewPath()
+			Expect(ok).To(BeTrue())
+		})
+
+		It("retires the connection ID of the new path when the migration is abandoned", func() {
+			m.GetForNewPath()
+			m.AbandonNewPath()
+			Expect(m.Get()).To(Equal(initialConnID))
+			Expect(removedTokens).To(Equal([]protocol.StatelessResetToken{{1}}))
+			Expect(frameQueue).To(Equal([]wire.Frame{&wire.RetireConnectionIDFrame{SequenceNumber: 1}}))
+			connID, _, ok := m.GetForNewPath()
+			Expect(ok).To(BeTrue())
+			Expect(connID).To(Equal(protocol.ConnectionID{2, 3, 4, 5}))
+		})
+
+		It("counts the connection ID of the new path towards the limit", func() {
+			m.GetForNewPath()
+			for i := uint64(3); i < activeConnIDLimit; i++ {
+				Expect(m.Add(&wire.NewConnectionIDFrame{
+					SequenceNumber:      i,
+					ConnectionID:        protocol.ConnectionID{byte(i), byte(i), byte(i), byte(i)},
+					StatelessResetToken: protocol.StatelessResetToken{byte(i)},
+				})).To(Succeed())
+			}
+			Expect(m.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      activeConnIDLimit,
+				ConnectionID:        protocol.ConnectionID{0xff, 0xff, 0xff, 0xff},
+				StatelessResetToken: protocol.StatelessResetToken{0xff},
+			})).To(MatchError(&qerr.TransportError{ErrorCode: qerr.ConnectionIDLimitError}))
+		})
+
+		It("removes the stateless reset token of the new path when it is closed", func() {
+			m.GetForNewPath()
+			m.Close()
+			Expect(removedTokens).To(Equal([]protocol.StatelessResetToken{{1}}))
+		})
+	})
 })
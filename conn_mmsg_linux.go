@@ -0,0 +1,174 @@
+//go:build linux
+// +build linux
+
+package quic
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+// newBatchWriter returns a batchWriter that uses sendmmsg to send multiple packets using a single syscall.
+// It returns nil if the connection doesn't support this.
+func newBatchWriter(c net.PacketConn) batchWriter {
+	// Allows callers to pass in a connection that already satisfies the batchConn interface, see newConn.
+	if bc, ok := c.(batchConn); ok {
+		return &oobBatchWriter{conn: bc}
+	}
+	conn, ok := c.(OOBCapablePacketConn)
+	if !ok {
+		return nil
+	}
+	udpAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	return &oobBatchWriter{conn: &mmsgConn{rawConn: rawConn, ipv6: udpAddr.IP.To4() == nil}}
+}
+
+// mmsgConn sends messages using sendmmsg.
+// It is used instead of ipv4.PacketConn.WriteBatch, since x/net reuses the message headers between
+// ReadBatch and WriteBatch calls, and doesn't reset the control messages for messages without OOB data.
+type mmsgConn struct {
+	rawConn syscall.RawConn
+	ipv6    bool // on IPv6 sockets, IPv4 addresses are sent to as IPv4-mapped IPv6 addresses
+}
+
+var _ messageWriter = &mmsgConn{}
+
+// mmsghdr is the struct mmsghdr, see https://man7.org/linux/man-pages/man2/sendmmsg.2.html.
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+}
+
+// mmsgBuffers holds the arguments of a sendmmsg syscall.
+// They are reused between calls, see mmsgBufferPool.
+type mmsgBuffers struct {
+	hdrs []mmsghdr
+	iovs []unix.Iovec
+	// large enough for both IPv4 and IPv6 addresses
+	sockaddrs []unix.RawSockaddrInet6
+}
+
+var mmsgBufferPool = sync.Pool{New: func() interface{} { return &mmsgBuffers{} }}
+
+func (c *mmsgConn) WriteBatch(ms []ipv4.Message, flags int) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	var numBuffers int
+	for _, m := range ms {
+		numBuffers += len(m.Buffers)
+	}
+	bufs := mmsgBufferPool.Get().(*mmsgBuffers)
+	if cap(bufs.hdrs) < len(ms) {
+		bufs.hdrs = make([]mmsghdr, len(ms))
+		bufs.sockaddrs = make([]unix.RawSockaddrInet6, len(ms))
+	}
+	if cap(bufs.iovs) < numBuffers {
+		bufs.iovs = make([]unix.Iovec, 0, numBuffers)
+	}
+	hdrs := bufs.hdrs[:len(ms)]
+	sockaddrs := bufs.sockaddrs[:len(ms)]
+	iovs := bufs.iovs[:0] // large enough for all buffers, so appending never reallocates
+	defer func() {
+		// don't keep the packets alive while the buffers are in the pool
+		for i := range hdrs {
+			hdrs[i] = mmsghdr{}
+		}
+		for i := range iovs {
+			iovs[i] = unix.Iovec{}
+		}
+		mmsgBufferPool.Put(bufs)
+	}()
+	for i, m := range ms {
+		sockaddrs[i] = unix.RawSockaddrInet6{}
+		addr, ok := m.Addr.(*net.UDPAddr)
+		if !ok {
+			return 0, errors.New("sendmmsg: invalid address")
+		}
+		h := &hdrs[i].hdr
+		h.Name = (*byte)(unsafe.Pointer(&sockaddrs[i]))
+		h.Namelen = c.putSockaddr(&sockaddrs[i], addr)
+		start := len(iovs)
+		for _, b := range m.Buffers {
+			var iov unix.Iovec
+			if len(b) > 0 {
+				iov.Base = &b[0]
+				iov.SetLen(len(b))
+			}
+			iovs = append(iovs, iov)
+		}
+		if len(iovs) > start {
+			h.Iov = &iovs[start]
+			h.SetIovlen(len(iovs) - start)
+		}
+		if len(m.OOB) > 0 {
+			h.Control = &m.OOB[0]
+			h.SetControllen(len(m.OOB))
+		}
+	}
+
+	var n int
+	var operr error
+	if err := c.rawConn.Write(func(fd uintptr) bool {
+		r, _, errno := unix.Syscall6(unix.SYS_SENDMMSG, fd, uintptr(unsafe.Pointer(&hdrs[0])), uintptr(len(hdrs)), uintptr(flags), 0, 0)
+		if errno == unix.EAGAIN {
+			return false
+		}
+		if errno != 0 {
+			operr = errno
+		} else {
+			n = int(r)
+		}
+		return true
+	}); err != nil {
+		return 0, err
+	}
+	if operr != nil {
+		return 0, os.NewSyscallError("sendmmsg", operr)
+	}
+	for i := 0; i < n; i++ {
+		ms[i].N = int(hdrs[i].len)
+	}
+	return n, nil
+}
+
+// putSockaddr encodes the address and returns the length of the sockaddr.
+func (c *mmsgConn) putSockaddr(sa *unix.RawSockaddrInet6, addr *net.UDPAddr) uint32 {
+	if ip4 := addr.IP.To4(); ip4 != nil && !c.ipv6 {
+		sa4 := (*unix.RawSockaddrInet4)(unsafe.Pointer(sa))
+		sa4.Family = unix.AF_INET
+		putPort(&sa4.Port, addr.Port)
+		copy(sa4.Addr[:], ip4)
+		return unix.SizeofSockaddrInet4
+	}
+	sa.Family = unix.AF_INET6
+	putPort(&sa.Port, addr.Port)
+	copy(sa.Addr[:], addr.IP.To16())
+	if addr.Zone != "" {
+		if ifi, err := net.InterfaceByName(addr.Zone); err == nil {
+			sa.Scope_id = uint32(ifi.Index)
+		}
+	}
+	return unix.SizeofSockaddrInet6
+}
+
+// putPort writes the port in network byte order.
+func putPort(p *uint16, port int) {
+	b := (*[2]byte)(unsafe.Pointer(p))
+	b[0] = byte(port >> 8)
+	b[1] = byte(port)
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"syscall"
 	"time"
 
@@ -31,6 +32,7 @@ var _ ipv4.Message = ipv6.Message{}
 
 type batchConn interface {
 	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
 }
 
 func inspectReadBuffer(c interface{}) (int, error) {
@@ -227,6 +229,71 @@ func (c *oobConn) WritePacket(b []byte, addr net.Addr, oob []byte) (n int, err e
 	return n, err
 }
 
+// A messageWriter sends multiple messages using a single syscall, see ipv4.PacketConn.WriteBatch.
+type messageWriter interface {
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+type oobBatchWriter struct {
+	conn messageWriter
+}
+
+var _ batchWriter = &oobBatchWriter{}
+
+// A messageBatch holds the messages of a single WritePackets call.
+// It is reused between calls, see messageBatchPool.
+type messageBatch struct {
+	msgs    []ipv4.Message
+	buffers [][]byte // msgs[i].Buffers is buffers[i:i+1]
+}
+
+// The batch writer of a server is shared between sessions, so the batches are taken from a pool.
+var messageBatchPool = sync.Pool{New: func() interface{} { return &messageBatch{} }}
+
+func (w *oobBatchWriter) WritePackets(packets [][]byte, addr net.Addr, oob []byte) (int, error) {
+	if _, ok := addr.(*net.UDPAddr); !ok {
+		return 0, errBatchWriteUnsupported
+	}
+	batch := messageBatchPool.Get().(*messageBatch)
+	defer func() {
+		// don't keep the packets alive while the batch is in the pool
+		for i := range batch.msgs {
+			batch.msgs[i] = ipv4.Message{}
+			batch.buffers[i] = nil
+		}
+		messageBatchPool.Put(batch)
+	}()
+	if cap(batch.msgs) < len(packets) {
+		batch.msgs = make([]ipv4.Message, len(packets))
+		batch.buffers = make([][]byte, len(packets))
+	}
+	batch.msgs = batch.msgs[:len(packets)]
+	batch.buffers = batch.buffers[:len(packets)]
+	for i, p := range packets {
+		batch.buffers[i] = p
+		batch.msgs[i] = ipv4.Message{Buffers: batch.buffers[i : i+1], OOB: oob, Addr: addr}
+	}
+	msgs := batch.msgs
+	var syscalls int
+	for len(msgs) > 0 {
+		n, err := w.conn.WriteBatch(msgs, 0)
+		syscalls++
+		if err != nil {
+			// sendmmsg only returns an error if the first message couldn't be sent
+			switch {
+			case isMsgSizeErr(err):
+				n = 1
+			case syscalls == 1 && errors.Is(err, unix.ENOSYS):
+				return 0, errBatchWriteUnsupported
+			default:
+				return syscalls, err
+			}
+		}
+		msgs = msgs[n:]
+	}
+	return syscalls, nil
+}
+
 func (info *packetInfo) OOB() []byte {
 	if info == nil {
 		return nil
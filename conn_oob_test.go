@@ -6,6 +6,7 @@ package quic
 import (
 	"fmt"
 	"net"
+	"testing"
 	"time"
 
 	"golang.org/x/net/ipv4"
@@ -19,6 +20,10 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type nopMessageWriter struct{}
+
+func (nopMessageWriter) WriteBatch(ms []ipv4.Message, _ int) (int, error) { return len(ms), nil }
+
 var _ = Describe("OOB Conn Test", func() {
 	runServer := func(network, address string) (*net.UDPConn, <-chan *receivedPacket) {
 		addr, err := net.ResolveUDPAddr(network, address)
@@ -131,6 +136,63 @@ var _ = Describe("OOB Conn Test", func() {
 		})
 	})
 
+	Context("sending ECN marks", func() {
+		// sendAndReceive sends packets from a socket listening on address, using the sendConn returned by newSendConnFn
+		sendAndReceive := func(network, address string, newSendConnFn func(conn *net.UDPConn, remote net.Addr) sendConn) {
+			server, packetChan := runServer("udp4", "127.0.0.1:0")
+			defer server.Close()
+			if ecnControlMessage(protocol.ECT0, server.LocalAddr()) == nil {
+				Skip("sending ECN marks not supported on this platform")
+			}
+			addr, err := net.ResolveUDPAddr(network, address)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			udpConn, err := net.ListenUDP(network, addr)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			c := newSendConnFn(udpConn, server.LocalAddr())
+			defer c.Close()
+
+			ExpectWithOffset(1, c.Write([]byte("foo"), protocol.ECT0)).To(Succeed())
+			ExpectWithOffset(1, c.Write([]byte("bar"), protocol.ECNNon)).To(Succeed())
+			_, err = c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECT0)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			for _, expected := range []struct {
+				data string
+				ecn  protocol.ECN
+			}{{"foo", protocol.ECT0}, {"bar", protocol.ECNNon}, {"foo", protocol.ECT0}, {"bar", protocol.ECT0}} {
+				var p *receivedPacket
+				EventuallyWithOffset(1, packetChan).Should(Receive(&p))
+				ExpectWithOffset(1, string(p.data)).To(Equal(expected.data))
+				ExpectWithOffset(1, p.ecn).To(Equal(expected.ecn))
+			}
+		}
+
+		newOOBSendConn := func(conn *net.UDPConn, remote net.Addr) sendConn {
+			oobConn, err := newConn(conn)
+			Expect(err).ToNot(HaveOccurred())
+			return newSendConn(oobConn, remote, nil, newBatchWriter(conn))
+		}
+
+		newPacketSendConn := func(conn *net.UDPConn, remote net.Addr) sendConn {
+			return newSendPconn(conn, remote, newBatchWriter(conn))
+		}
+
+		It("sends ECN marks on IPv4", func() {
+			sendAndReceive("udp4", "127.0.0.1:0", newOOBSendConn)
+		})
+
+		It("sends ECN marks to an IPv4 address on a dual-stack socket", func() {
+			sendAndReceive("udp", ":0", newOOBSendConn)
+		})
+
+		It("sends ECN marks on a net.PacketConn", func() {
+			sendAndReceive("udp4", "127.0.0.1:0", newPacketSendConn)
+		})
+
+		It("sends ECN marks to an IPv4 address on a dual-stack net.PacketConn", func() {
+			sendAndReceive("udp", ":0", newPacketSendConn)
+		})
+	})
+
 	Context("Packet Info conn", func() {
 		sendPacket := func(network string, addr *net.UDPAddr) net.Addr {
 			conn, err := net.DialUDP(network, nil, addr)
@@ -240,4 +302,137 @@ var _ = Describe("OOB Conn Test", func() {
 			}
 		})
 	})
+
+	Context("Batch Writing", func() {
+		var (
+			batchConn *MockBatchConn
+			w         *oobBatchWriter
+			addr      *net.UDPAddr
+			packets   [][]byte
+		)
+
+		BeforeEach(func() {
+			batchConn = NewMockBatchConn(mockCtrl)
+			w = &oobBatchWriter{conn: batchConn}
+			addr = &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
+			packets = [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}
+		})
+
+		It("writes multiple messages in one batch", func() {
+			batchConn.EXPECT().WriteBatch(gomock.Any(), 0).DoAndReturn(func(ms []ipv4.Message, _ int) (int, error) {
+				Expect(ms).To(HaveLen(3))
+				for i, m := range ms {
+					Expect(m.Buffers).To(Equal([][]byte{packets[i]}))
+					Expect(m.OOB).To(Equal([]byte("oob")))
+					Expect(m.Addr).To(Equal(addr))
+				}
+				return 3, nil
+			})
+			syscalls, err := w.WritePackets(packets, addr, []byte("oob"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syscalls).To(Equal(1))
+		})
+
+		It("continues writing if the kernel only accepts some of the messages", func() {
+			gomock.InOrder(
+				batchConn.EXPECT().WriteBatch(gomock.Any(), 0).DoAndReturn(func(ms []ipv4.Message, _ int) (int, error) {
+					Expect(ms).To(HaveLen(3))
+					return 2, nil
+				}),
+				batchConn.EXPECT().WriteBatch(gomock.Any(), 0).DoAndReturn(func(ms []ipv4.Message, _ int) (int, error) {
+					Expect(ms).To(HaveLen(1))
+					Expect(ms[0].Buffers[0]).To(Equal([]byte("baz")))
+					return 1, nil
+				}),
+			)
+			syscalls, err := w.WritePackets(packets, addr, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syscalls).To(Equal(2))
+		})
+
+		It("skips messages that are too large", func() {
+			gomock.InOrder(
+				batchConn.EXPECT().WriteBatch(gomock.Any(), 0).Return(1, nil),
+				batchConn.EXPECT().WriteBatch(gomock.Any(), 0).Return(-1, unix.EMSGSIZE),
+				batchConn.EXPECT().WriteBatch(gomock.Any(), 0).DoAndReturn(func(ms []ipv4.Message, _ int) (int, error) {
+					Expect(ms).To(HaveLen(1))
+					Expect(ms[0].Buffers[0]).To(Equal([]byte("baz")))
+					return 1, nil
+				}),
+			)
+			syscalls, err := w.WritePackets(packets, addr, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syscalls).To(Equal(3))
+		})
+
+		It("returns write errors", func() {
+			batchConn.EXPECT().WriteBatch(gomock.Any(), 0).Return(-1, unix.ECONNREFUSED)
+			_, err := w.WritePackets(packets, addr, nil)
+			Expect(err).To(MatchError(unix.ECONNREFUSED))
+		})
+
+		It("doesn't reuse the OOB data of a previous batch", func() {
+			batchConn.EXPECT().WriteBatch(gomock.Any(), 0).Return(3, nil)
+			_, err := w.WritePackets(packets, addr, []byte("oob"))
+			Expect(err).ToNot(HaveOccurred())
+			batchConn.EXPECT().WriteBatch(gomock.Any(), 0).DoAndReturn(func(ms []ipv4.Message, _ int) (int, error) {
+				Expect(ms).To(HaveLen(2))
+				for _, m := range ms {
+					Expect(m.OOB).To(BeEmpty())
+				}
+				return 2, nil
+			})
+			_, err = w.WritePackets(packets[:2], addr, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("doesn't allocate when writing a batch", func() {
+			w := &oobBatchWriter{conn: nopMessageWriter{}}
+			Expect(testing.AllocsPerRun(100, func() {
+				w.WritePackets(packets, addr, nil)
+			})).To(BeNumerically("<", 1))
+		})
+
+		It("reports if sendmmsg is not available", func() {
+			batchConn.EXPECT().WriteBatch(gomock.Any(), 0).Return(-1, unix.ENOSYS)
+			_, err := w.WritePackets(packets, addr, nil)
+			Expect(err).To(MatchError(errBatchWriteUnsupported))
+		})
+
+		sendAndReceive := func(network, address string) {
+			udpConn, packetChan := runServer("udp4", "localhost:0")
+			defer udpConn.Close()
+			addr, err := net.ResolveUDPAddr(network, address)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			conn, err := net.ListenUDP(network, addr)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			defer conn.Close()
+			bw := newBatchWriter(conn)
+			if bw == nil {
+				Skip("batch writes not supported on this platform")
+			}
+			// Receive a packet first. x/net reuses message headers (including the control messages)
+			// between ReadBatch and WriteBatch calls, which must not affect sending.
+			_, err = conn.WriteTo([]byte("first"), udpConn.LocalAddr())
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			EventuallyWithOffset(1, packetChan).Should(Receive())
+			syscalls, err := bw.WritePackets(packets, udpConn.LocalAddr(), nil)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, syscalls).To(Equal(1))
+			for _, data := range packets {
+				var p *receivedPacket
+				EventuallyWithOffset(1, packetChan).Should(Receive(&p))
+				ExpectWithOffset(1, p.data).To(Equal(data))
+				ExpectWithOffset(1, p.remoteAddr.(*net.UDPAddr).Port).To(Equal(conn.LocalAddr().(*net.UDPAddr).Port))
+			}
+		}
+
+		It("sends packets on an IPv4 socket", func() {
+			sendAndReceive("udp4", "127.0.0.1:0")
+		})
+
+		It("sends packets to an IPv4 address on a dual-stack socket", func() {
+			sendAndReceive("udp", ":0")
+		})
+	})
 })
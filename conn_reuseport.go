@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package quic
+
+import (
+	"errors"
+	"syscall"
+)
+
+func setReusePort(string, string, syscall.RawConn) error {
+	return errors.New("Config.ReusePort is not supported on this platform")
+}
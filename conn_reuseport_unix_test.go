@@ -0,0 +1,31 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package quic
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SO_REUSEPORT", func() {
+	It("doesn't allow binding the same port twice, by default", func() {
+		conn, err := listenUDP(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, false)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		_, err = listenUDP(conn.LocalAddr().(*net.UDPAddr), false)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("allows binding the same port twice, if SO_REUSEPORT is set", func() {
+		conn1, err := listenUDP(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, true)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn1.Close()
+		conn2, err := listenUDP(conn1.LocalAddr().(*net.UDPAddr), true)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn2.Close()
+		Expect(conn2.LocalAddr()).To(Equal(conn1.LocalAddr()))
+	})
+})
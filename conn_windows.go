@@ -10,6 +10,8 @@ import (
 	"syscall"
 
 	"golang.org/x/sys/windows"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
 )
 
 func newConn(c OOBCapablePacketConn) (connection, error) {
@@ -38,3 +40,7 @@ func inspectReadBuffer(c net.PacketConn) (int, error) {
 }
 
 func (i *packetInfo) OOB() []byte { return nil }
+
+func newBatchWriter(net.PacketConn) batchWriter { return nil }
+
+func ecnControlMessage(protocol.ECN, net.Addr) []byte { return nil }
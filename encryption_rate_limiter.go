@@ -0,0 +1,33 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
+// The encryptionRateLimiter limits the number of packets sealed per second.
+// It allows bursts of packets that are sent within a single pacing interval.
+type encryptionRateLimiter struct {
+	*tokenBucket
+}
+
+func newEncryptionRateLimiter(rate uint64) *encryptionRateLimiter {
+	return &encryptionRateLimiter{tokenBucket: newTokenBucket(rate, protocol.MinPacingDelay+protocol.TimerGranularity)}
+}
+
+// SealedPacket must be called for every packet that was sealed.
+func (l *encryptionRateLimiter) SealedPacket(sealTime time.Time) {
+	l.Take(sealTime)
+}
+
+// HasBudget says if another packet can be sealed at this moment.
+func (l *encryptionRateLimiter) HasBudget(now time.Time) bool {
+	return l.HasToken(now)
+}
+
+// TimeUntilSend returns when the next packet can be sealed.
+// It returns the zero value of time.Time if a packet can be sealed immediately.
+func (l *encryptionRateLimiter) TimeUntilSend() time.Time {
+	return l.NextTokenTime()
+}
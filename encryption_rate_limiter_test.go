@@ -0,0 +1,75 @@
+package quic
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryption Rate Limiter", func() {
+	It("allows sealing a burst of packets", func() {
+		l := newEncryptionRateLimiter(10000) // allows a burst of 20 packets
+		now := time.Now()
+		for i := 0; i < 20; i++ {
+			Expect(l.HasBudget(now)).To(BeTrue())
+			Expect(l.TimeUntilSend()).To(BeZero())
+			l.SealedPacket(now)
+		}
+		Expect(l.HasBudget(now)).To(BeFalse())
+		Expect(l.TimeUntilSend()).To(Equal(now.Add(100 * time.Microsecond)))
+	})
+
+	It("allows sealing a single packet at low rates", func() {
+		l := newEncryptionRateLimiter(10)
+		now := time.Now()
+		Expect(l.HasBudget(now)).To(BeTrue())
+		l.SealedPacket(now)
+		Expect(l.HasBudget(now)).To(BeFalse())
+		Expect(l.TimeUntilSend()).To(Equal(now.Add(100 * time.Millisecond)))
+		Expect(l.HasBudget(now.Add(99 * time.Millisecond))).To(BeFalse())
+		Expect(l.HasBudget(now.Add(100 * time.Millisecond))).To(BeTrue())
+	})
+
+	It("replenishes the budget over time", func() {
+		l := newEncryptionRateLimiter(1000)
+		now := time.Now()
+		l.SealedPacket(now)
+		l.SealedPacket(now)
+		Expect(l.HasBudget(now)).To(BeFalse())
+		Expect(l.TimeUntilSend()).To(Equal(now.Add(time.Millisecond)))
+		now = now.Add(time.Millisecond)
+		Expect(l.HasBudget(now)).To(BeTrue())
+		l.SealedPacket(now)
+		Expect(l.HasBudget(now)).To(BeFalse())
+	})
+
+	It("doesn't accumulate more budget than the maximum burst size", func() {
+		l := newEncryptionRateLimiter(1000) // allows a burst of 2 packets
+		now := time.Now()
+		l.SealedPacket(now)
+		now = now.Add(24 * time.Hour)
+		Expect(l.HasBudget(now)).To(BeTrue())
+		l.SealedPacket(now)
+		l.SealedPacket(now)
+		Expect(l.HasBudget(now)).To(BeFalse())
+	})
+
+	It("keeps the sealing rate under the limit", func() {
+		const rate = 5000
+		l := newEncryptionRateLimiter(rate)
+		start := time.Now()
+		now := start
+		var sealed int
+		// Try to seal a packet every 10us, which is twice the allowed rate.
+		for now.Sub(start) < time.Second {
+			if l.HasBudget(now) {
+				l.SealedPacket(now)
+				sealed++
+			}
+			now = now.Add(10 * time.Microsecond)
+		}
+		Expect(sealed).To(BeNumerically("<=", rate+int(l.maxBurst/tokenCost)))
+		Expect(sealed).To(BeNumerically(">=", rate))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/BGrewell/quic-go/internal/qerr"
+	"github.com/BGrewell/quic-go/internal/wire"
 )
 
 type (
@@ -15,6 +16,9 @@ type (
 	HandshakeTimeoutError   = qerr.HandshakeTimeoutError
 )
 
+// A TruncatedPacketError is used when a received packet is shorter than the length declared in its header.
+type TruncatedPacketError = wire.TruncatedPacketError
+
 type (
 	TransportErrorCode   = qerr.TransportErrorCode
 	ApplicationErrorCode = qerr.ApplicationErrorCode
@@ -0,0 +1,131 @@
+package quic
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
+// fileTokenStoreTempPrefix is the prefix of the temporary files used for atomically replacing token files
+const fileTokenStoreTempPrefix = ".tmp-"
+
+// storedToken is the struct that is used for ASN1 serialization and deserialization
+type storedToken struct {
+	Data      []byte
+	Timestamp int64 // time when the token was stored, in nanoseconds since the Unix epoch
+}
+
+type fileTokenStore struct {
+	mutex sync.Mutex
+
+	dir           string
+	tokensPerKey  int
+	tokenValidity time.Duration
+}
+
+var _ TokenStore = &fileTokenStore{}
+
+// NewFileTokenStore creates a TokenStore that persists tokens to files in dir,
+// such that they can be used across restarts of the application.
+// Tokens for each key are stored in a separate file. The directory is created if it doesn't exist.
+// Tokens older than 24 hours are discarded, and at most 4 tokens are kept for every key.
+// Files that can't be parsed are treated as if they didn't contain any tokens.
+func NewFileTokenStore(dir string) (TokenStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileTokenStore{
+		dir:           dir,
+		tokensPerKey:  protocol.MaxFileTokenStoreTokensPerOrigin,
+		tokenValidity: protocol.TokenValidity,
+	}, nil
+}
+
+func (s *fileTokenStore) Put(key string, token *ClientToken) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tokens := append(s.load(key), storedToken{Data: token.data, Timestamp: time.Now().UnixNano()})
+	if len(tokens) > s.tokensPerKey {
+		tokens = tokens[len(tokens)-s.tokensPerKey:]
+	}
+	s.store(key, tokens)
+}
+
+func (s *fileTokenStore) Pop(key string) *ClientToken {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tokens := s.load(key)
+	if len(tokens) == 0 {
+		// remove files that only contained expired tokens, or that couldn't be parsed
+		s.store(key, nil)
+		return nil
+	}
+	// the most recently stored token is used first
+	token := tokens[len(tokens)-1]
+	s.store(key, tokens[:len(tokens)-1])
+	return &ClientToken{data: token.Data}
+}
+
+func (s *fileTokenStore) filename(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(h[:]))
+}
+
+// load reads the tokens stored for a key, skipping all expired tokens.
+func (s *fileTokenStore) load(key string) []storedToken {
+	data, err := os.ReadFile(s.filename(key))
+	if err != nil {
+		return nil
+	}
+	var tokens []storedToken
+	if rest, err := asn1.Unmarshal(data, &tokens); err != nil || len(rest) != 0 {
+		return nil
+	}
+	minTimestamp := time.Now().Add(-s.tokenValidity).UnixNano()
+	valid := tokens[:0]
+	for _, t := range tokens {
+		if t.Timestamp >= minTimestamp {
+			valid = append(valid, t)
+		}
+	}
+	return valid
+}
+
+// store atomically replaces the file for a key.
+// If there are no tokens, the file is removed.
+// Errors are ignored, since the TokenStore can't return them.
+func (s *fileTokenStore) store(key string, tokens []storedToken) {
+	filename := s.filename(key)
+	if len(tokens) == 0 {
+		os.Remove(filename)
+		return
+	}
+	data, err := asn1.Marshal(tokens)
+	if err != nil {
+		return
+	}
+	f, err := os.CreateTemp(s.dir, fileTokenStoreTempPrefix+"*")
+	if err != nil {
+		return
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), filename); err != nil {
+		os.Remove(f.Name())
+	}
+}
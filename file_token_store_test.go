@@ -0,0 +1,132 @@
+package quic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File Token Store", func() {
+	const origin = "localhost"
+
+	var (
+		dir string
+		s   TokenStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "quic-go-tokens")
+		Expect(err).ToNot(HaveOccurred())
+		s, err = NewFileTokenStore(dir)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	mockToken := func(num int) *ClientToken {
+		return &ClientToken{data: []byte(fmt.Sprintf("%d", num))}
+	}
+
+	readDir := func() []string {
+		entries, err := os.ReadDir(dir)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	It("creates the directory", func() {
+		d := filepath.Join(dir, "foo", "bar")
+		_, err := NewFileTokenStore(d)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(d).To(BeADirectory())
+	})
+
+	It("errors when the directory can't be created", func() {
+		f := filepath.Join(dir, "file")
+		Expect(os.WriteFile(f, []byte("foobar"), 0o600)).To(Succeed())
+		_, err := NewFileTokenStore(f)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("adds and gets tokens", func() {
+		s.Put(origin, mockToken(1))
+		s.Put(origin, mockToken(2))
+		Expect(s.Pop(origin)).To(Equal(mockToken(2)))
+		Expect(s.Pop(origin)).To(Equal(mockToken(1)))
+		Expect(s.Pop(origin)).To(BeNil())
+		Expect(readDir()).To(BeEmpty())
+	})
+
+	It("stores tokens for different origins in different files", func() {
+		s.Put("foo", mockToken(1))
+		s.Put("bar", mockToken(2))
+		Expect(readDir()).To(HaveLen(2))
+		Expect(s.Pop("foo")).To(Equal(mockToken(1)))
+		Expect(s.Pop("bar")).To(Equal(mockToken(2)))
+		Expect(s.Pop("baz")).To(BeNil())
+	})
+
+	It("uses the tokens after a restart", func() {
+		s.Put(origin, mockToken(1))
+		s.Put("[::1]:443", mockToken(2))
+		s2, err := NewFileTokenStore(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s2.Pop(origin)).To(Equal(mockToken(1)))
+		Expect(s2.Pop("[::1]:443")).To(Equal(mockToken(2)))
+		Expect(s.Pop(origin)).To(BeNil())
+	})
+
+	It("limits the number of tokens per origin", func() {
+		for i := 1; i <= 6; i++ {
+			s.Put(origin, mockToken(i))
+		}
+		Expect(s.Pop(origin)).To(Equal(mockToken(6)))
+		Expect(s.Pop(origin)).To(Equal(mockToken(5)))
+		Expect(s.Pop(origin)).To(Equal(mockToken(4)))
+		Expect(s.Pop(origin)).To(Equal(mockToken(3)))
+		Expect(s.Pop(origin)).To(BeNil())
+	})
+
+	It("doesn't return expired tokens", func() {
+		s.(*fileTokenStore).tokenValidity = 25 * time.Millisecond
+		s.Put(origin, mockToken(1))
+		time.Sleep(50 * time.Millisecond)
+		s.Put(origin, mockToken(2))
+		Expect(s.Pop(origin)).To(Equal(mockToken(2)))
+		Expect(s.Pop(origin)).To(BeNil())
+	})
+
+	It("removes files that only contain expired tokens", func() {
+		s.(*fileTokenStore).tokenValidity = 25 * time.Millisecond
+		s.Put(origin, mockToken(1))
+		Expect(readDir()).To(HaveLen(1))
+		time.Sleep(50 * time.Millisecond)
+		Expect(s.Pop(origin)).To(BeNil())
+		Expect(readDir()).To(BeEmpty())
+	})
+
+	It("ignores corrupt files", func() {
+		filename := s.(*fileTokenStore).filename(origin)
+		Expect(os.WriteFile(filename, []byte("foobar"), 0o600)).To(Succeed())
+		Expect(s.Pop(origin)).To(BeNil())
+		Expect(os.WriteFile(filename, []byte("foobar"), 0o600)).To(Succeed())
+		s.Put(origin, mockToken(1))
+		Expect(s.Pop(origin)).To(Equal(mockToken(1)))
+	})
+
+	It("doesn't leave temporary files behind", func() {
+		s.Put(origin, mockToken(1))
+		s.Put(origin, mockToken(2))
+		Expect(readDir()).To(Equal([]string{filepath.Base(s.(*fileTokenStore).filename(origin))}))
+	})
+})
@@ -1,11 +1,14 @@
 package quic
 
 import (
+	"container/heap"
 	"errors"
+	"math"
 	"sync"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
 	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
 	"github.com/BGrewell/quic-go/quicvarint"
 )
@@ -17,6 +20,7 @@ type framer interface {
 	AppendControlFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	AddActiveStream(protocol.StreamID)
+	AddRetransmittingStream(protocol.StreamID, uint8)
 	AppendStreamFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	Handle0RTTRejection() error
@@ -25,11 +29,21 @@ type framer interface {
 type framerI struct {
 	mutex sync.Mutex
 
-	streamGetter streamGetter
-	version      protocol.VersionNumber
+	streamGetter        streamGetter
+	version             protocol.VersionNumber
+	retransmissionOrder RetransmissionOrder
 
-	activeStreams map[protocol.StreamID]struct{}
-	streamQueue   []protocol.StreamID
+	// Sending data advances a stream's virtual time inversely proportional to its priority.
+	// The stream with the lowest virtual time is scheduled next.
+	activeStreams map[protocol.StreamID]*activeStream
+	streamQueue   activeStreamQueue
+	numQueued     uint64 // used to order streams with the same virtual time
+	virtualTime   uint64 // the virtual time of the stream that was scheduled last
+
+	// Streams with lost data, unless the round robin retransmission order is used.
+	retransmittingStreams map[protocol.StreamID]*retransmittingStream
+	retransmissions       streamRetransmissionQueue
+	numRetransmissions    uint64 // used to order streams that were queued at the same virtual time
 
 	controlFrameMutex sync.Mutex
 	controlFrames     []wire.Frame
@@ -39,18 +53,22 @@ var _ framer = &framerI{}
 
 func newFramer(
 	streamGetter streamGetter,
+	retransmissionOrder RetransmissionOrder,
 	v protocol.VersionNumber,
 ) framer {
 	return &framerI{
-		streamGetter:  streamGetter,
-		activeStreams: make(map[protocol.StreamID]struct{}),
-		version:       v,
+		streamGetter:          streamGetter,
+		activeStreams:         make(map[protocol.StreamID]*activeStream),
+		retransmissionOrder:   retransmissionOrder,
+		retransmittingStreams: make(map[protocol.StreamID]*retransmittingStream),
+		retransmissions:       streamRetransmissionQueue{order: retransmissionOrder},
+		version:               v,
 	}
 }
 
 func (f *framerI) HasData() bool {
 	f.mutex.Lock()
-	hasData := len(f.streamQueue) > 0
+	hasData := f.streamQueue.Len() > 0
 	f.mutex.Unlock()
 	if hasData {
 		return true
@@ -86,30 +104,112 @@ func (f *framerI) AppendControlFrames(frames []ackhandler.Frame, maxLen protocol
 
 func (f *framerI) AddActiveStream(id protocol.StreamID) {
 	f.mutex.Lock()
-	if _, ok := f.activeStreams[id]; !ok {
-		f.streamQueue = append(f.streamQueue, id)
-		f.activeStreams[id] = struct{}{}
-	}
+	f.addActiveStreamImpl(id)
 	f.mutex.Unlock()
 }
 
+func (f *framerI) addActiveStreamImpl(id protocol.StreamID) *activeStream {
+	s, ok := f.activeStreams[id]
+	if !ok {
+		s = &activeStream{id: id, virtualTime: f.virtualTime}
+		f.activeStreams[id] = s
+		f.queueStream(s)
+	}
+	return s
+}
+
+// queueStream queues a stream behind all streams with the same virtual time.
+func (f *framerI) queueStream(s *activeStream) {
+	s.seq = f.numQueued
+	f.numQueued++
+	heap.Push(&f.streamQueue, s)
+}
+
+// AddRetransmittingStream adds a stream that has lost data to retransmit.
+// Unless the round robin retransmission order is used, it is scheduled before streams that only have new data to send.
+func (f *framerI) AddRetransmittingStream(id protocol.StreamID, priority uint8) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	as := f.addActiveStreamImpl(id)
+	if f.retransmissionOrder == RetransmissionOrderRoundRobin {
+		return
+	}
+	if s, ok := f.retransmittingStreams[id]; ok {
+		s.priority = priority
+		if s.index >= 0 {
+			heap.Fix(&f.retransmissions, s.index)
+		}
+		return
+	}
+	s := &retransmittingStream{stream: as, priority: priority, seq: f.numRetransmissions}
+	f.numRetransmissions++
+	f.retransmittingStreams[id] = s
+	heap.Push(&f.retransmissions, s)
+}
+
+// nextStream dequeues the stream that should be scheduled next.
+// This is the stream with the lowest virtual time, or the one queued first, if multiple streams have the same virtual time.
+// Unless the round robin retransmission order is used, streams with lost data are scheduled first.
+func (f *framerI) nextStream(skipped []*retransmittingStream) (*activeStream, []*retransmittingStream) {
+	var s *retransmittingStream
+	if s, skipped = f.nextRetransmittingStream(skipped); s != nil {
+		heap.Remove(&f.streamQueue, s.stream.index)
+		return s.stream, skipped
+	}
+	return heap.Pop(&f.streamQueue).(*activeStream), skipped
+}
+
+// nextRetransmittingStream returns the queued stream that has lost data and should be retransmitted next,
+// according to the retransmission order, or nil if there's none.
+// Streams that were already dequeued for this packet are removed from the retransmission queue,
+// and appended to skipped. They must be put back once the packet is packed.
+func (f *framerI) nextRetransmittingStream(skipped []*retransmittingStream) (*retransmittingStream, []*retransmittingStream) {
+	for f.retransmissions.Len() > 0 {
+		if s := f.retransmissions.streams[0]; s.stream.index >= 0 {
+			return s, skipped
+		}
+		skipped = append(skipped, heap.Pop(&f.retransmissions).(*retransmittingStream))
+	}
+	return nil, skipped
+}
+
+// removeRetransmittingStream removes a stream that doesn't have any lost data to retransmit any more.
+func (f *framerI) removeRetransmittingStream(id protocol.StreamID) {
+	s, ok := f.retransmittingStreams[id]
+	if !ok {
+		return
+	}
+	delete(f.retransmittingStreams, id)
+	if s.index >= 0 {
+		heap.Remove(&f.retransmissions, s.index)
+	}
+}
+
 func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount) {
 	var length protocol.ByteCount
 	var lastFrame *ackhandler.Frame
+	var stalledStreams []*activeStream
+	var skipped []*retransmittingStream
+	// Streams that still have data are queued again once the packet is packed,
+	// so that every stream is dequeued at most once per packet.
+	var requeue []*activeStream
 	f.mutex.Lock()
 	// pop STREAM frames, until less than MinStreamFrameSize bytes are left in the packet
-	numActiveStreams := len(f.streamQueue)
-	for i := 0; i < numActiveStreams; i++ {
+	for f.streamQueue.Len() > 0 {
 		if protocol.MinStreamFrameSize+length > maxLen {
 			break
 		}
-		id := f.streamQueue[0]
-		f.streamQueue = f.streamQueue[1:]
+		var s *activeStream
+		s, skipped = f.nextStream(skipped)
+		id := s.id
+		f.virtualTime = s.virtualTime
 		// This should never return an error. Better check it anyway.
 		// The stream will only be in the streamQueue, if it enqueued itself there.
 		str, err := f.streamGetter.GetOrOpenSendStream(id)
 		// The stream can be nil if it completed after it said it had data.
 		if str == nil || err != nil {
+			f.removeRetransmittingStream(id)
 			delete(f.activeStreams, id)
 			continue
 		}
@@ -119,21 +219,60 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 		// the STREAM frame (which will always have the DataLen set).
 		remainingLen += quicvarint.Len(uint64(remainingLen))
 		frame, hasMoreData := str.popStreamFrame(remainingLen)
-		if hasMoreData { // put the stream back in the queue (at the end)
-			f.streamQueue = append(f.streamQueue, id)
+		if hasMoreData { // put the stream back in the queue
+			requeue = append(requeue, s)
 		} else { // no more data to send. Stream is not active any more
 			delete(f.activeStreams, id)
 		}
 		// The frame can be nil
 		// * if the receiveStream was canceled after it said it had data
 		// * the remaining size doesn't allow us to add another STREAM frame
+		// * if the stream is blocked by flow control
 		if frame == nil {
+			if hasMoreData {
+				stalledStreams = append(stalledStreams, s)
+			} else {
+				f.removeRetransmittingStream(id)
+			}
 			continue
 		}
+		frameLen := frame.Length(f.version)
+		if hasMoreData {
+			priority := str.getPriority()
+			s.virtualTime += uint64(frameLen) * math.MaxUint8 / utils.MaxUint64(uint64(priority), 1)
+			if rs, ok := f.retransmittingStreams[id]; ok {
+				if str.hasRetransmission() {
+					rs.priority = priority
+					heap.Fix(&f.retransmissions, rs.index)
+				} else {
+					f.removeRetransmittingStream(id)
+				}
+			}
+		} else {
+			f.removeRetransmittingStream(id)
+		}
 		frames = append(frames, *frame)
-		length += frame.Length(f.version)
+		length += frameLen
 		lastFrame = frame
 	}
+	// Streams that didn't send anything don't accumulate credit.
+	// Otherwise, a high-priority stream would monopolize the connection once it has data to send again.
+	for _, s := range stalledStreams {
+		if s.virtualTime < f.virtualTime {
+			s.virtualTime = f.virtualTime
+			if rs, ok := f.retransmittingStreams[s.id]; ok && rs.index >= 0 {
+				heap.Fix(&f.retransmissions, rs.index)
+			}
+		}
+	}
+	for _, s := range requeue {
+		f.queueStream(s)
+	}
+	for _, s := range skipped {
+		if _, ok := f.retransmittingStreams[s.stream.id]; ok {
+			heap.Push(&f.retransmissions, s)
+		}
+	}
 	f.mutex.Unlock()
 	if lastFrame != nil {
 		lastFrameLen := lastFrame.Length(f.version)
@@ -149,10 +288,14 @@ func (f *framerI) Handle0RTTRejection() error {
 	defer f.mutex.Unlock()
 
 	f.controlFrameMutex.Lock()
-	f.streamQueue = f.streamQueue[:0]
+	f.streamQueue.streams = f.streamQueue.streams[:0]
 	for id := range f.activeStreams {
 		delete(f.activeStreams, id)
 	}
+	for id := range f.retransmittingStreams {
+		delete(f.retransmittingStreams, id)
+	}
+	f.retransmissions.streams = f.retransmissions.streams[:0]
 	var j int
 	for i, frame := range f.controlFrames {
 		switch frame.(type) {
@@ -169,3 +312,105 @@ func (f *framerI) Handle0RTTRejection() error {
 	f.controlFrameMutex.Unlock()
 	return nil
 }
+
+// An activeStream is a stream that has data to send.
+type activeStream struct {
+	id          protocol.StreamID
+	virtualTime uint64 // the virtual time at which the stream is next scheduled
+	seq         uint64 // the order in which the streams were queued
+	index       int    // the index in the activeStreamQueue, -1 if it is not queued
+}
+
+// The activeStreamQueue orders the active streams by their virtual time, and then by the order in which they were queued.
+// It implements heap.Interface.
+type activeStreamQueue struct {
+	streams []*activeStream
+}
+
+var _ heap.Interface = &activeStreamQueue{}
+
+func (q *activeStreamQueue) Len() int { return len(q.streams) }
+
+func (q *activeStreamQueue) Less(i, j int) bool {
+	a, b := q.streams[i], q.streams[j]
+	if a.virtualTime != b.virtualTime {
+		return a.virtualTime < b.virtualTime
+	}
+	return a.seq < b.seq
+}
+
+func (q *activeStreamQueue) Swap(i, j int) {
+	q.streams[i], q.streams[j] = q.streams[j], q.streams[i]
+	q.streams[i].index = i
+	q.streams[j].index = j
+}
+
+func (q *activeStreamQueue) Push(x interface{}) {
+	s := x.(*activeStream)
+	s.index = len(q.streams)
+	q.streams = append(q.streams, s)
+}
+
+func (q *activeStreamQueue) Pop() interface{} {
+	n := len(q.streams)
+	s := q.streams[n-1]
+	q.streams[n-1] = nil
+	q.streams = q.streams[:n-1]
+	s.index = -1
+	return s
+}
+
+// A retransmittingStream is a stream that has lost data to retransmit.
+type retransmittingStream struct {
+	stream   *activeStream
+	priority uint8
+	seq      uint64 // the order in which the streams were queued
+	index    int    // the index in the streamRetransmissionQueue, -1 if it was removed
+}
+
+// The streamRetransmissionQueue orders the streams with lost data according to the retransmission order.
+// Streams with the same priority are ordered by their virtual time, and then by the order in which they were queued.
+// It implements heap.Interface.
+type streamRetransmissionQueue struct {
+	order   RetransmissionOrder
+	streams []*retransmittingStream
+}
+
+var _ heap.Interface = &streamRetransmissionQueue{}
+
+func (q *streamRetransmissionQueue) Len() int { return len(q.streams) }
+
+func (q *streamRetransmissionQueue) Less(i, j int) bool {
+	a, b := q.streams[i], q.streams[j]
+	if q.order == RetransmissionOrderLowestStreamIDFirst {
+		return a.stream.id < b.stream.id
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if vtA, vtB := a.stream.virtualTime, b.stream.virtualTime; vtA != vtB {
+		return vtA < vtB
+	}
+	return a.seq < b.seq
+}
+
+func (q *streamRetransmissionQueue) Swap(i, j int) {
+	q.streams[i], q.streams[j] = q.streams[j], q.streams[i]
+	q.streams[i].index = i
+	q.streams[j].index = j
+}
+
+func (q *streamRetransmissionQueue) Push(x interface{}) {
+	s := x.(*retransmittingStream)
+	s.index = len(q.streams)
+	q.streams = append(q.streams, s)
+}
+
+func (q *streamRetransmissionQueue) Pop() interface{} {
+	n := len(q.streams)
+	s := q.streams[n-1]
+	q.streams[n-1] = nil
+	q.streams = q.streams[:n-1]
+	s.index = -1
+	return s
+}
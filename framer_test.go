@@ -23,17 +23,22 @@ var _ = Describe("Framer", func() {
 	var (
 		framer           framer
 		stream1, stream2 *MockSendStreamI
+		prio1, prio2     uint8
 		streamGetter     *MockStreamGetter
 		version          protocol.VersionNumber
 	)
 
 	BeforeEach(func() {
 		streamGetter = NewMockStreamGetter(mockCtrl)
+		prio1 = protocol.DefaultStreamPriority
+		prio2 = protocol.DefaultStreamPriority
 		stream1 = NewMockSendStreamI(mockCtrl)
 		stream1.EXPECT().StreamID().Return(protocol.StreamID(5)).AnyTimes()
+		stream1.EXPECT().getPriority().DoAndReturn(func() uint8 { return prio1 }).AnyTimes()
 		stream2 = NewMockSendStreamI(mockCtrl)
 		stream2.EXPECT().StreamID().Return(protocol.StreamID(6)).AnyTimes()
-		framer = newFramer(streamGetter, version)
+		stream2.EXPECT().getPriority().DoAndReturn(func() uint8 { return prio2 }).AnyTimes()
+		framer = newFramer(streamGetter, RetransmissionOrderRoundRobin, version)
 	})
 
 	Context("handling control frames", func() {
@@ -374,6 +379,277 @@ var _ = Describe("Framer", func() {
 			Expect(length).To(Equal(f.Length(version)))
 		})
 
+		Context("prioritizing streams", func() {
+			// popFullFrames makes the stream return STREAM frames that fill the whole packet
+			popFullFrames := func(str *MockSendStreamI, id protocol.StreamID) {
+				streamGetter.EXPECT().GetOrOpenSendStream(id).Return(str, nil).AnyTimes()
+				str.EXPECT().popStreamFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) (*ackhandler.Frame, bool) {
+					f := &wire.StreamFrame{StreamID: id, DataLenPresent: true}
+					f.Data = make([]byte, f.MaxDataLen(size, version))
+					return &ackhandler.Frame{Frame: f}, true
+				}).AnyTimes()
+			}
+
+			// sendPackets packs n packets, and returns the number of bytes sent on each stream
+			sendPackets := func(n int) map[protocol.StreamID]protocol.ByteCount {
+				sent := make(map[protocol.StreamID]protocol.ByteCount)
+				for i := 0; i < n; i++ {
+					frames, _ := framer.AppendStreamFrames(nil, 1000)
+					for _, f := range frames {
+						sf := f.Frame.(*wire.StreamFrame)
+						sent[sf.StreamID] += sf.DataLen()
+					}
+				}
+				return sent
+			}
+
+			// scheduleStreams packs n packets, and returns the stream that the STREAM frame in each packet belonged to
+			scheduleStreams := func(n int) []protocol.StreamID {
+				var ids []protocol.StreamID
+				for i := 0; i < n; i++ {
+					frames, _ := framer.AppendStreamFrames(nil, 1000)
+					ExpectWithOffset(1, frames).To(HaveLen(1))
+					ids = append(ids, frames[0].Frame.(*wire.StreamFrame).StreamID)
+				}
+				return ids
+			}
+
+			It("schedules the stream with the lowest virtual time", func() {
+				// sending a frame advances the virtual time of stream 2 three times as much as that of stream 1
+				prio1 = 255
+				prio2 = 85
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				Expect(scheduleStreams(9)).To(Equal([]protocol.StreamID{id1, id2, id1, id1, id2, id1, id1, id1, id2}))
+			})
+
+			It("schedules streams with the same virtual time in the order they were queued", func() {
+				const id3 = protocol.StreamID(12)
+				stream3 := NewMockSendStreamI(mockCtrl)
+				stream3.EXPECT().getPriority().Return(protocol.DefaultStreamPriority).AnyTimes()
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				popFullFrames(stream3, id3)
+				framer.AddActiveStream(id2)
+				framer.AddActiveStream(id3)
+				framer.AddActiveStream(id1)
+				Expect(scheduleStreams(6)).To(Equal([]protocol.StreamID{id2, id3, id1, id2, id3, id1}))
+			})
+
+			It("re-queues a stream that still has data for the next packet", func() {
+				streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).Times(3)
+				f1 := &wire.StreamFrame{StreamID: id1, Data: []byte("foo")}
+				f2 := &wire.StreamFrame{StreamID: id1, Data: []byte("bar")}
+				f3 := &wire.StreamFrame{StreamID: id1, Data: []byte("baz")}
+				gomock.InOrder(
+					stream1.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: f1}, true),
+					stream1.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: f2}, true),
+					stream1.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: f3}, false),
+				)
+				framer.AddActiveStream(id1)
+				for _, f := range []*wire.StreamFrame{f1, f2, f3} {
+					Expect(framer.HasData()).To(BeTrue())
+					// the packet has enough space for all frames, but the stream is only dequeued once per packet
+					frames, _ := framer.AppendStreamFrames(nil, 1000)
+					Expect(frames).To(HaveLen(1))
+					Expect(frames[0].Frame).To(Equal(f))
+				}
+				Expect(framer.HasData()).To(BeFalse())
+			})
+
+			It("removes a queued stream that doesn't exist any more", func() {
+				popFullFrames(stream2, id2)
+				streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(nil, nil)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				Expect(scheduleStreams(2)).To(Equal([]protocol.StreamID{id2, id2}))
+				// the stream can be queued again
+				popFullFrames(stream1, id1)
+				framer.AddActiveStream(id1)
+				Expect(scheduleStreams(2)).To(ContainElement(id1))
+			})
+
+			It("shares the bandwidth equally between streams with the same priority", func() {
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				sent := sendPackets(100)
+				Expect(sent[id1]).To(Equal(sent[id2]))
+			})
+
+			It("shares the bandwidth proportionally to the stream priorities", func() {
+				prio1 = 48
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				sent := sendPackets(100)
+				Expect(sent[id1] + sent[id2]).To(BeNumerically(">", 100*900))
+				Expect(float64(sent[id1]) / float64(sent[id2])).To(BeNumerically("~", 3, 0.1))
+			})
+
+			It("treats a priority of 0 like a priority of 1", func() {
+				prio1 = 0
+				prio2 = 1
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				sent := sendPackets(100)
+				Expect(sent[id1]).To(Equal(sent[id2]))
+			})
+
+			It("doesn't waste the window on a high-priority stream that has no data to send", func() {
+				prio1 = 48
+				blocked := true // blocked by flow control
+				streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).AnyTimes()
+				stream1.EXPECT().popStreamFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) (*ackhandler.Frame, bool) {
+					if blocked {
+						return nil, true
+					}
+					f := &wire.StreamFrame{StreamID: id1, DataLenPresent: true}
+					f.Data = make([]byte, f.MaxDataLen(size, version))
+					return &ackhandler.Frame{Frame: f}, true
+				}).AnyTimes()
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				sent := sendPackets(50)
+				Expect(sent[id1]).To(BeZero())
+				Expect(sent[id2]).To(BeNumerically(">", 50*900))
+				// once it has data again, it doesn't get more than its share of the window
+				blocked = false
+				sent = sendPackets(100)
+				Expect(float64(sent[id1]) / float64(sent[id2])).To(BeNumerically("~", 3, 0.2))
+			})
+
+			It("doesn't let a stream that becomes active catch up on the bandwidth it didn't use", func() {
+				popFullFrames(stream1, id1)
+				popFullFrames(stream2, id2)
+				framer.AddActiveStream(id1)
+				sent := sendPackets(50)
+				Expect(sent[id1]).ToNot(BeZero())
+				framer.AddActiveStream(id2)
+				sent = sendPackets(100)
+				Expect(sent[id1]).To(BeNumerically("~", sent[id2], 1000))
+			})
+		})
+
+		Context("retransmission order", func() {
+			// popRetransmissions makes the stream return STREAM frames that fill the whole packet.
+			// The first numRetransmissions frames are retransmissions.
+			popRetransmissions := func(str *MockSendStreamI, id protocol.StreamID, numRetransmissions int) {
+				streamGetter.EXPECT().GetOrOpenSendStream(id).Return(str, nil).AnyTimes()
+				str.EXPECT().hasRetransmission().DoAndReturn(func() bool { return numRetransmissions > 0 }).AnyTimes()
+				str.EXPECT().popStreamFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) (*ackhandler.Frame, bool) {
+					if numRetransmissions > 0 {
+						numRetransmissions--
+					}
+					f := &wire.StreamFrame{StreamID: id, DataLenPresent: true}
+					f.Data = make([]byte, f.MaxDataLen(size, version))
+					return &ackhandler.Frame{Frame: f}, true
+				}).AnyTimes()
+			}
+
+			// sendPackets packs n packets, and returns the stream that the STREAM frame in each packet belonged to
+			sendPackets := func(n int) []protocol.StreamID {
+				var ids []protocol.StreamID
+				for i := 0; i < n; i++ {
+					frames, _ := framer.AppendStreamFrames(nil, 1000)
+					ExpectWithOffset(1, frames).To(HaveLen(1))
+					ids = append(ids, frames[0].Frame.(*wire.StreamFrame).StreamID)
+				}
+				return ids
+			}
+
+			It("doesn't consider retransmissions when using the round robin order", func() {
+				streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).AnyTimes()
+				streamGetter.EXPECT().GetOrOpenSendStream(id2).Return(stream2, nil).AnyTimes()
+				// don't EXPECT any calls to hasRetransmission
+				for id, str := range map[protocol.StreamID]*MockSendStreamI{id1: stream1, id2: stream2} {
+					id := id
+					str.EXPECT().popStreamFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) (*ackhandler.Frame, bool) {
+						f := &wire.StreamFrame{StreamID: id, DataLenPresent: true}
+						f.Data = make([]byte, f.MaxDataLen(size, version))
+						return &ackhandler.Frame{Frame: f}, true
+					}).AnyTimes()
+				}
+				framer.AddRetransmittingStream(id2, prio2)
+				framer.AddRetransmittingStream(id1, prio1)
+				Expect(sendPackets(4)).To(Equal([]protocol.StreamID{id2, id1, id2, id1}))
+			})
+
+			It("retransmits the stream with the lowest stream ID first", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderLowestStreamIDFirst, version)
+				popRetransmissions(stream1, id1, 2)
+				popRetransmissions(stream2, id2, 2)
+				framer.AddRetransmittingStream(id2, prio2)
+				framer.AddRetransmittingStream(id1, prio1)
+				Expect(sendPackets(6)).To(Equal([]protocol.StreamID{id1, id1, id2, id2, id1, id2}))
+			})
+
+			It("retransmits the stream with the highest priority first", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderHighestPriorityFirst, version)
+				prio2 = 48
+				popRetransmissions(stream1, id1, 2)
+				popRetransmissions(stream2, id2, 2)
+				framer.AddRetransmittingStream(id1, prio1)
+				framer.AddRetransmittingStream(id2, prio2)
+				Expect(sendPackets(4)).To(Equal([]protocol.StreamID{id2, id2, id1, id1}))
+			})
+
+			It("retransmits streams with the same priority round robin", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderHighestPriorityFirst, version)
+				popRetransmissions(stream1, id1, 2)
+				popRetransmissions(stream2, id2, 2)
+				framer.AddRetransmittingStream(id2, prio2)
+				framer.AddRetransmittingStream(id1, prio1)
+				Expect(sendPackets(4)).To(Equal([]protocol.StreamID{id2, id1, id2, id1}))
+			})
+
+			It("retransmits before sending new data", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderLowestStreamIDFirst, version)
+				popRetransmissions(stream1, id1, 0)
+				popRetransmissions(stream2, id2, 2)
+				framer.AddActiveStream(id1)
+				framer.AddRetransmittingStream(id2, prio2)
+				// retransmissions count towards the stream's share of the bandwidth
+				Expect(sendPackets(4)).To(Equal([]protocol.StreamID{id2, id2, id1, id1}))
+			})
+
+			It("retransmits before sending new data on streams with a higher priority", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderHighestPriorityFirst, version)
+				prio1 = 255
+				popRetransmissions(stream1, id1, 0)
+				popRetransmissions(stream2, id2, 2)
+				framer.AddActiveStream(id1)
+				framer.AddRetransmittingStream(id2, prio2)
+				Expect(sendPackets(3)).To(Equal([]protocol.StreamID{id2, id2, id1}))
+			})
+
+			It("doesn't look at streams without lost data when scheduling retransmissions", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderLowestStreamIDFirst, version)
+				// don't EXPECT any calls for stream 1
+				popRetransmissions(stream2, id2, 1)
+				framer.AddActiveStream(id1)
+				framer.AddRetransmittingStream(id2, prio2)
+				Expect(sendPackets(1)).To(Equal([]protocol.StreamID{id2}))
+			})
+
+			It("drops the retransmitting streams when 0-RTT is rejected", func() {
+				framer = newFramer(streamGetter, RetransmissionOrderLowestStreamIDFirst, version)
+				framer.AddRetransmittingStream(id1, prio1)
+				Expect(framer.Handle0RTTRejection()).To(Succeed())
+				Expect(framer.HasData()).To(BeFalse())
+				popRetransmissions(stream2, id2, 1)
+				framer.AddRetransmittingStream(id2, prio2)
+				Expect(sendPackets(1)).To(Equal([]protocol.StreamID{id2}))
+			})
+		})
+
 		It("drops all STREAM frames when 0-RTT is rejected", func() {
 			framer.AddActiveStream(id1)
 			Expect(framer.Handle0RTTRejection()).To(Succeed())
@@ -89,6 +89,7 @@ func main() {
 		false,
 		utils.NewRTTStats(),
 		nil,
+		nil,
 		utils.DefaultLogger.WithPrefix("client"),
 		protocol.VersionTLS,
 	)
@@ -108,6 +109,7 @@ func main() {
 		false,
 		utils.NewRTTStats(),
 		nil,
+		nil,
 		utils.DefaultLogger.WithPrefix("server"),
 		protocol.VersionTLS,
 	)
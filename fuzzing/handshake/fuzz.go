@@ -386,6 +386,7 @@ func runHandshake(runConfig [confLen]byte, messageConfig uint8, clientConf *tls.
 		enable0RTTClient,
 		utils.NewRTTStats(),
 		nil,
+		nil,
 		utils.DefaultLogger.WithPrefix("client"),
 		protocol.VersionTLS,
 	)
@@ -403,6 +404,7 @@ func runHandshake(runConfig [confLen]byte, messageConfig uint8, clientConf *tls.
 		enable0RTTServer,
 		utils.NewRTTStats(),
 		nil,
+		nil,
 		utils.DefaultLogger.WithPrefix("server"),
 		protocol.VersionTLS,
 	)
@@ -0,0 +1,58 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ACK Stats", func() {
+	It("counts the ACK frames sent and received", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		serverSess := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(PRData))
+			serverSess <- sess
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write(PRData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		var s quic.Session
+		Eventually(serverSess, scaleDuration(5*time.Second)).Should(Receive(&s))
+
+		// The server acknowledges at most every packet it received.
+		// Since packets are received in batches, it usually sends a lot fewer ACKs than that.
+		_, clientReceived := sess.AckStats()
+		serverSent, _ := s.AckStats()
+		Expect(clientReceived).ToNot(BeZero())
+		Expect(clientReceived).To(BeNumerically("<=", serverSent))
+		Expect(serverSent).To(BeNumerically("<=", len(PRData)/1000))
+	})
+})
@@ -0,0 +1,50 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/BGrewell/quic-go"
+	"github.com/BGrewell/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bytes Acked", func() {
+	It("counts the bytes acknowledged by the peer during an upload", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = io.Copy(io.Discard, str)
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write(PRData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+
+		// Only the stream data is counted, packet overhead and retransmissions are not.
+		Eventually(sess.BytesAcked).Should(Equal(protocol.ByteCount(len(PRData))))
+		Expect(sess.BytesSent()).To(Equal(protocol.ByteCount(len(PRData))))
+		Consistently(sess.BytesAcked).Should(Equal(protocol.ByteCount(len(PRData))))
+	})
+})
@@ -0,0 +1,71 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// offsetClock is a congestion.Clock that runs ahead of the system clock
+type offsetClock time.Duration
+
+func (c offsetClock) Now() time.Time { return time.Now().Add(time.Duration(c)) }
+
+var _ = Describe("Clock", func() {
+	It("uses the clock of the config for all timing", func() {
+		const offset = time.Hour
+		server, err := quic.ListenAddr(
+			"localhost:0",
+			getTLSConfig(),
+			getQuicConfig(&quic.Config{Clock: offsetClock(offset)}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		serverSessChan := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverSessChan <- sess
+			str, err := sess.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{Clock: offsetClock(-offset)}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+
+		var serverSess quic.Session
+		Eventually(serverSessChan).Should(Receive(&serverSess))
+		created, _, _ := sess.Timestamps()
+		Expect(created).To(BeTemporally("~", time.Now().Add(-offset), time.Minute))
+		created, _, _ = serverSess.Timestamps()
+		Expect(created).To(BeTemporally("~", time.Now().Add(offset), time.Minute))
+		// The RTT is measured using the clock, both when sending and when receiving packets.
+		for _, s := range []quic.Session{sess, serverSess} {
+			stats := s.ConnectionStats()
+			Expect(stats.MinRTT).To(And(BeNumerically(">", 0), BeNumerically("<", time.Second)))
+			Expect(stats.SmoothedRTT).To(And(BeNumerically(">", 0), BeNumerically("<", time.Second)))
+		}
+	})
+})
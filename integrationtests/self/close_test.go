@@ -0,0 +1,42 @@
+package self_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Application Close", func() {
+	It("transmits the error code and the reason to the peer", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sess.CloseWithError(0x1337, "shutting down: ümlaut")).To(Succeed())
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = sess.AcceptStream(context.Background())
+		Expect(err).To(HaveOccurred())
+		var appErr *quic.ApplicationError
+		Expect(errors.As(err, &appErr)).To(BeTrue())
+		Expect(appErr.Remote).To(BeTrue())
+		Expect(appErr.ErrorCode).To(Equal(quic.ApplicationErrorCode(0x1337)))
+		Expect(appErr.ErrorMessage).To(Equal("shutting down: ümlaut"))
+	})
+})
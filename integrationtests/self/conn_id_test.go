@@ -6,6 +6,7 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"sync"
 
 	quic "github.com/BGrewell/quic-go"
 	"github.com/BGrewell/quic-go/internal/protocol"
@@ -87,4 +88,51 @@ var _ = Describe("Connection ID lengths tests", func() {
 		defer ln.Close()
 		runClient(ln.Addr(), clientConf)
 	})
+
+	It("reports changes of the connection IDs", func() {
+		ln := runServer(getQuicConfig(&quic.Config{ConnectionIDLength: randomConnIDLen()}))
+		defer ln.Close()
+
+		type change struct{ old, new quic.ConnectionID }
+		var mutex sync.Mutex
+		var peerChanges []change
+		var issued []quic.ConnectionID
+		cl, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{
+				ConnectionIDLength: randomConnIDLen(),
+				OnConnectionIDChanged: func(old, new quic.ConnectionID) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					peerChanges = append(peerChanges, change{old: old, new: new})
+				},
+				OnConnectionIDIssued: func(connID quic.ConnectionID) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					issued = append(issued, connID)
+				},
+			}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer cl.CloseWithError(0, "")
+		str, err := cl.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		// The client switches to the connection ID chosen by the server during the handshake,
+		// and to a connection ID issued in a NEW_CONNECTION_ID frame after the handshake.
+		Expect(len(peerChanges)).To(BeNumerically(">=", 2))
+		for i := 1; i < len(peerChanges); i++ {
+			Expect(peerChanges[i].old).To(Equal(peerChanges[i-1].new))
+		}
+		_, dest := cl.ConnectionIDs()
+		Expect(dest).To(Equal(peerChanges[len(peerChanges)-1].new))
+		// The client issues connection IDs to the server after the handshake.
+		Expect(issued).ToNot(BeEmpty())
+	})
 })
@@ -50,6 +50,7 @@ var _ = Describe("Datagram test", func() {
 					sess, err := ln.Accept(context.Background())
 					Expect(err).ToNot(HaveOccurred())
 					Expect(sess.ConnectionState().SupportsDatagrams).To(BeTrue())
+					Expect(sess.PeerSupportsDatagrams()).To(BeTrue())
 
 					var wg sync.WaitGroup
 					wg.Add(num)
@@ -114,6 +115,7 @@ var _ = Describe("Datagram test", func() {
 				)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(sess.ConnectionState().SupportsDatagrams).To(BeTrue())
+				Expect(sess.PeerSupportsDatagrams()).To(BeTrue())
 				var counter int
 				for {
 					// Close the session if no message is received for 100 ms.
@@ -0,0 +1,102 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+
+	"github.com/BGrewell/quic-go"
+	quicproxy "github.com/BGrewell/quic-go/integrationtests/tools/proxy"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ecnStateTracer struct {
+	connTracer
+
+	mutex  sync.Mutex
+	states []logging.ECNState
+}
+
+func (t *ecnStateTracer) UpdatedECNState(state logging.ECNState) {
+	t.mutex.Lock()
+	t.states = append(t.states, state)
+	t.mutex.Unlock()
+}
+
+func (t *ecnStateTracer) getStates() []logging.ECNState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]logging.ECNState{}, t.states...)
+}
+
+var _ = Describe("ECN validation", func() {
+	// runServer starts a server that sends PRData on a new stream, and traces the ECN state of the connection
+	runServer := func(tracer *ecnStateTracer) quic.Listener {
+		ln, err := quic.ListenAddr(
+			"localhost:0",
+			getTLSConfig(),
+			getQuicConfig(&quic.Config{Tracer: newTracer(func() logging.ConnectionTracer { return tracer })}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+		return ln
+	}
+
+	downloadFile := func(port int) {
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		str, err := sess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+		sess.CloseWithError(0, "")
+	}
+
+	It("enables ECN if the path preserves the ECN marks", func() {
+		if runtime.GOOS != "linux" {
+			Skip("sending ECN marks is only supported on Linux")
+		}
+		tracer := &ecnStateTracer{}
+		ln := runServer(tracer)
+		defer ln.Close()
+		downloadFile(ln.Addr().(*net.UDPAddr).Port)
+		Eventually(tracer.getStates).Should(ContainElement(logging.ECNStateCapable))
+		Expect(tracer.getStates()).ToNot(ContainElement(logging.ECNStateFailed))
+		Expect(tracer.getStates()[0]).To(Equal(logging.ECNStateTesting))
+	})
+
+	It("disables ECN if the path bleaches the ECN marks", func() {
+		tracer := &ecnStateTracer{}
+		ln := runServer(tracer)
+		defer ln.Close()
+		// the proxy forwards packets without ECN marks
+		proxy, err := quicproxy.NewQuicProxy("localhost:0", &quicproxy.Opts{
+			RemoteAddr: fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer proxy.Close()
+		downloadFile(proxy.LocalPort())
+		Eventually(tracer.getStates).Should(ContainElement(logging.ECNStateFailed))
+		Expect(tracer.getStates()).ToNot(ContainElement(logging.ECNStateCapable))
+	})
+})
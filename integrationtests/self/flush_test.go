@@ -0,0 +1,52 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Flush", func() {
+	It("sends out buffered data", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		received := make(chan []byte, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			b := make([]byte, 6)
+			n, err := str.Read(b)
+			Expect(err).ToNot(HaveOccurred())
+			received <- b[:n]
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Flush()).To(Succeed())
+		Eventually(received).Should(Receive(Equal([]byte("foobar"))))
+
+		Expect(sess.CloseWithError(0, "")).To(Succeed())
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -11,6 +11,7 @@ import (
 
 	"github.com/BGrewell/quic-go"
 	"github.com/BGrewell/quic-go/integrationtests/tools/israce"
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/logging"
 
@@ -430,6 +431,75 @@ var _ = Describe("Handshake tests", func() {
 		})
 	})
 
+	It("records when the handshake completes and is confirmed", func() {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), serverConfig)
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+
+		serverSessChan := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverSessChan <- sess
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		var serverSess quic.Session
+		Eventually(serverSessChan).Should(Receive(&serverSess))
+
+		// the client's handshake is confirmed when it receives the HANDSHAKE_DONE frame
+		Eventually(func() time.Time {
+			_, _, confirmed := sess.Timestamps()
+			return confirmed
+		}).ShouldNot(BeZero())
+		for _, s := range []quic.Session{sess, serverSess} {
+			created, complete, confirmed := s.Timestamps()
+			Expect(created).ToNot(BeZero())
+			Expect(complete).To(BeTemporally(">=", created))
+			Expect(confirmed).To(BeTemporally(">=", complete))
+		}
+	})
+
+	It("uses NewReno by default, and the configured congestion controller otherwise", func() {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), serverConfig)
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		go func() {
+			defer GinkgoRecover()
+			for {
+				if _, err := ln.Accept(context.Background()); err != nil {
+					return
+				}
+			}
+		}()
+
+		dial := func(conf *quic.Config) quic.Session {
+			sess, err := quic.DialAddr(
+				fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+				getTLSClientConfig(),
+				getQuicConfig(conf),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			return sess
+		}
+		sess := dial(nil)
+		defer sess.CloseWithError(0, "")
+		Expect(sess.CongestionControl()).To(Equal(congestion.ALGO_RENO))
+		sess = dial(&quic.Config{CongestionControlAlgo: congestion.ALGO_CUBIC})
+		defer sess.CloseWithError(0, "")
+		Expect(sess.CongestionControl()).To(Equal(congestion.ALGO_CUBIC))
+		sess = dial(&quic.Config{CongestionControlAlgo: congestion.ALGO_CUBIC_RFC8312})
+		defer sess.CloseWithError(0, "")
+		Expect(sess.CongestionControl()).To(Equal(congestion.ALGO_CUBIC_RFC8312))
+	})
+
 	Context("ALPN", func() {
 		It("negotiates an application protocol", func() {
 			ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), serverConfig)
@@ -454,6 +524,9 @@ var _ = Describe("Handshake tests", func() {
 			defer sess.CloseWithError(0, "")
 			cs := sess.ConnectionState()
 			Expect(cs.TLS.NegotiatedProtocol).To(Equal(alpn))
+			Expect(cs.TLS.Version).To(BeEquivalentTo(tls.VersionTLS13))
+			Expect(cs.TLS.HandshakeComplete).To(BeTrue())
+			Expect(cs.TLS.Used0RTT).To(BeFalse())
 			Eventually(done).Should(BeClosed())
 			Expect(ln.Close()).To(Succeed())
 		})
@@ -533,6 +606,49 @@ var _ = Describe("Handshake tests", func() {
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("completes the handshake using custom Retry tokens", func() {
+			tokens := make(chan []byte, 10)
+			serverConfig.GetRetryToken = func(net.Addr) ([]byte, error) { return []byte("foobar"), nil }
+			serverConfig.ValidateRetryToken = func(_ net.Addr, token []byte) bool {
+				tokens <- token
+				return string(token) == "foobar"
+			}
+
+			server, err := quic.ListenAddr("localhost:0", getTLSConfig(), serverConfig)
+			Expect(err).ToNot(HaveOccurred())
+			defer server.Close()
+
+			// The client checks the retry_source_connection_id transport parameter,
+			// so the handshake only succeeds if the server restored the connection IDs of the Retry from the token.
+			sess, err := quic.DialAddr(
+				fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+				getTLSClientConfig(),
+				getQuicConfig(nil),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			defer sess.CloseWithError(0, "")
+			Expect(tokens).To(Receive(Equal([]byte("foobar"))))
+		})
+
+		It("rejects custom Retry tokens with the INVALID_TOKEN error", func() {
+			serverConfig.GetRetryToken = func(net.Addr) ([]byte, error) { return []byte("foobar"), nil }
+			serverConfig.ValidateRetryToken = func(net.Addr, []byte) bool { return false }
+
+			server, err := quic.ListenAddr("localhost:0", getTLSConfig(), serverConfig)
+			Expect(err).ToNot(HaveOccurred())
+			defer server.Close()
+
+			_, err = quic.DialAddr(
+				fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+				getTLSClientConfig(),
+				nil,
+			)
+			Expect(err).To(HaveOccurred())
+			var transportErr *quic.TransportError
+			Expect(errors.As(err, &transportErr)).To(BeTrue())
+			Expect(transportErr.ErrorCode).To(Equal(quic.InvalidToken))
+		})
+
 		It("rejects invalid Retry token with the INVALID_TOKEN error", func() {
 			tokenChan := make(chan *quic.Token, 10)
 			serverConfig.AcceptToken = func(addr net.Addr, token *quic.Token) bool {
@@ -0,0 +1,153 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// A blackholeConn drops all packets written to it.
+type blackholeConn struct {
+	net.PacketConn
+	numWritten int32
+}
+
+func (c *blackholeConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	atomic.AddInt32(&c.numWritten, 1)
+	return len(b), nil
+}
+
+var _ = Describe("Connection Migration", func() {
+	It("migrates to a new connection", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		serverSess := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverSess <- sess
+			str, err := sess.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			// echo all data
+			_, err = io.Copy(str, str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+
+		addr, err := net.ResolveUDPAddr("udp", "localhost:0")
+		Expect(err).ToNot(HaveOccurred())
+		conn1, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn1.Close()
+		conn2, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn2.Close()
+
+		sess, err := quic.Dial(
+			conn1,
+			server.Addr(),
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			// the client needs an unused connection ID for the new path
+			getQuicConfig(&quic.Config{ActiveConnectionIDLimit: 4}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foo"))
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 3)
+		_, err = io.ReadFull(str, b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foo")))
+		Expect(sess.LocalAddr()).To(Equal(conn1.LocalAddr()))
+
+		// The handshake might not be confirmed yet.
+		Eventually(func() error { return sess.MigrateTo(conn2) }).Should(Succeed())
+		Expect(sess.LocalAddr()).To(Equal(conn2.LocalAddr()))
+		_, err = str.Write(PRData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+
+		var s quic.Session
+		Eventually(serverSess).Should(Receive(&s))
+		Expect(s.RemoteAddr().(*net.UDPAddr).Port).To(Equal(conn2.LocalAddr().(*net.UDPAddr).Port))
+	})
+
+	It("fails to migrate to an unresponsive path", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = io.Copy(str, str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+
+		addr, err := net.ResolveUDPAddr("udp", "localhost:0")
+		Expect(err).ToNot(HaveOccurred())
+		conn1, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn1.Close()
+		udpConn, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		conn2 := &blackholeConn{PacketConn: udpConn}
+
+		sess, err := quic.Dial(
+			conn1,
+			server.Addr(),
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{MaxPathChallenges: 2, ActiveConnectionIDLimit: 4}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foo"))
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 3)
+		_, err = io.ReadFull(str, b)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The handshake might not be confirmed yet.
+		Eventually(func() error {
+			err := sess.MigrateTo(conn2)
+			if err != nil && err.Error() == "can't migrate before the handshake is confirmed" {
+				return err
+			}
+			Expect(err).To(MatchError("path validation failed: no response to 2 PATH_CHALLENGE frames"))
+			return nil
+		}).Should(Succeed())
+		Expect(atomic.LoadInt32(&conn2.numWritten)).To(BeEquivalentTo(2))
+		Expect(sess.LocalAddr()).To(Equal(conn1.LocalAddr()))
+		// the session still works on the old path
+		_, err = str.Write(PRData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+	})
+})
@@ -0,0 +1,120 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mtuTracer struct {
+	connTracer
+
+	mutex         sync.Mutex
+	maxPacketSize logging.ByteCount
+	mtuUpdates    []logging.ByteCount
+}
+
+func (t *mtuTracer) SentPacket(_ *logging.ExtendedHeader, size logging.ByteCount, _ *logging.AckFrame, _ []logging.Frame) {
+	t.mutex.Lock()
+	if size > t.maxPacketSize {
+		t.maxPacketSize = size
+	}
+	t.mutex.Unlock()
+}
+
+func (t *mtuTracer) UpdatedMTU(mtu logging.ByteCount, _ bool) {
+	t.mutex.Lock()
+	t.mtuUpdates = append(t.mtuUpdates, mtu)
+	t.mutex.Unlock()
+}
+
+func (t *mtuTracer) getMaxPacketSize() logging.ByteCount {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.maxPacketSize
+}
+
+func (t *mtuTracer) getMTUUpdates() []logging.ByteCount {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]logging.ByteCount{}, t.mtuUpdates...)
+}
+
+var _ = Describe("Path MTU Discovery", func() {
+	// the tests run on localhost, which resolves to an IPv4 address
+	const initialMTU = protocol.InitialPacketSizeIPv4
+
+	// runServer starts a server that sends PRData on a new stream, and keeps the session open
+	runServer := func(conf *quic.Config, tracer *mtuTracer) (quic.Listener, <-chan quic.Session) {
+		conf.Tracer = newTracer(func() logging.ConnectionTracer { return tracer })
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(conf))
+		Expect(err).ToNot(HaveOccurred())
+		sessChan := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			sessChan <- sess
+			str, err := sess.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+		return ln, sessChan
+	}
+
+	downloadFile := func(port int) quic.Session {
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		str, err := sess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+		return sess
+	}
+
+	It("increases the packet size", func() {
+		tracer := &mtuTracer{}
+		ln, sessChan := runServer(&quic.Config{}, tracer)
+		defer ln.Close()
+		sess := downloadFile(ln.Addr().(*net.UDPAddr).Port)
+		defer sess.CloseWithError(0, "")
+		var serverSess quic.Session
+		Eventually(sessChan).Should(Receive(&serverSess))
+		Eventually(serverSess.CurrentMTU).Should(BeNumerically(">", initialMTU))
+		Eventually(tracer.getMTUUpdates).ShouldNot(BeEmpty())
+		Expect(tracer.getMaxPacketSize()).To(BeNumerically(">", initialMTU))
+	})
+
+	It("doesn't send packets larger than the initial packet size when Path MTU Discovery is disabled", func() {
+		tracer := &mtuTracer{}
+		ln, sessChan := runServer(&quic.Config{DisablePathMTUDiscovery: true}, tracer)
+		defer ln.Close()
+		sess := downloadFile(ln.Addr().(*net.UDPAddr).Port)
+		defer sess.CloseWithError(0, "")
+		var serverSess quic.Session
+		Eventually(sessChan).Should(Receive(&serverSess))
+		Expect(serverSess.CurrentMTU()).To(BeEquivalentTo(initialMTU))
+		// give the server enough time to send probe packets, if it was doing Path MTU Discovery
+		time.Sleep(scaleDuration(100 * time.Millisecond))
+		Expect(serverSess.CurrentMTU()).To(BeEquivalentTo(initialMTU))
+		Expect(tracer.getMTUUpdates()).To(BeEmpty())
+		Expect(tracer.getMaxPacketSize()).To(BeNumerically("<=", initialMTU))
+	})
+})
@@ -0,0 +1,64 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/BGrewell/quic-go"
+	"github.com/BGrewell/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Packet Loss Simulator", func() {
+	It("transfers data when packets are dropped deterministically", func() {
+		var serverDropped, clientDropped int32
+		dropEveryFifth := func(counter *int32) func(quic.PacketDirection, protocol.PacketNumber) bool {
+			return func(_ quic.PacketDirection, pn protocol.PacketNumber) bool {
+				if pn%5 == 4 {
+					atomic.AddInt32(counter, 1)
+					return true
+				}
+				return false
+			}
+		}
+
+		server, err := quic.ListenAddr(
+			"localhost:0",
+			getTLSConfig(),
+			getQuicConfig(&quic.Config{PacketLossSimulator: dropEveryFifth(&serverDropped)}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.OpenUniStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{PacketLossSimulator: dropEveryFifth(&clientDropped)}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.AcceptUniStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+		Expect(atomic.LoadInt32(&serverDropped)).ToNot(BeZero())
+		Expect(atomic.LoadInt32(&clientDropped)).ToNot(BeZero())
+	})
+})
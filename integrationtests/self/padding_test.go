@@ -0,0 +1,44 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Padding Stats", func() {
+	It("counts the padding of the client's Initial packets", func() {
+		server, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		serverSess := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := server.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverSess <- sess
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		var s quic.Session
+		Eventually(serverSess).Should(Receive(&s))
+
+		// The ClientHello is much smaller than the minimum size of the client's Initial packet.
+		sent, _ := sess.PaddingStats()
+		Expect(sent).To(BeNumerically(">", 500))
+		_, received := s.PaddingStats()
+		Expect(received).To(BeNumerically(">", 500))
+	})
+})
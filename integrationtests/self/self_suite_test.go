@@ -356,14 +356,23 @@ func (t *connTracer) ReceivedPacket(hdr *logging.ExtendedHeader, size logging.By
 }
 func (t *connTracer) BufferedPacket(logging.PacketType)                                             {}
 func (t *connTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {}
-func (t *connTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, packetsInFlight int) {
+func (t *connTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight, ssthresh logging.ByteCount, packetsInFlight int) {
 }
 
 func (t *connTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
 func (t *connTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
 }
 func (t *connTracer) UpdatedCongestionState(logging.CongestionState)                     {}
+func (t *connTracer) UpdatedECNState(logging.ECNState)                                   {}
+func (t *connTracer) UpdatedMTU(logging.ByteCount, bool)                                 {}
 func (t *connTracer) UpdatedPTOCount(value uint32)                                       {}
+func (t *connTracer) OpenedStream(logging.StreamID, logging.Perspective)                 {}
+func (t *connTracer) ClosedStream(logging.StreamID, logging.StreamCloseReason)           {}
+func (t *connTracer) StartedHandshake()                                                  {}
+func (t *connTracer) ReceivedKeys(logging.EncryptionLevel)                               {}
+func (t *connTracer) CompletedHandshake()                                                {}
+func (t *connTracer) SentHandshakeMessage(logging.HandshakeMessageType)                  {}
+func (t *connTracer) ReceivedHandshakeMessage(logging.HandshakeMessageType)              {}
 func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
 func (t *connTracer) UpdatedKey(generation logging.KeyPhase, remote bool)                {}
 func (t *connTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
@@ -0,0 +1,112 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Send buffer limit", func() {
+	runServer := func(maxSendBufferBytes int) (quic.Listener, <-chan quic.Session) {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(&quic.Config{MaxSendBufferBytes: maxSendBufferBytes}))
+		Expect(err).ToNot(HaveOccurred())
+		sessChan := make(chan quic.Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			sessChan <- sess
+		}()
+		return ln, sessChan
+	}
+
+	It("completes transfers on multiple streams", func() {
+		const numStreams = 5
+		ln, sessChan := runServer(5000)
+		defer ln.Close()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		var serverSess quic.Session
+		Eventually(sessChan).Should(Receive(&serverSess))
+
+		for i := 0; i < numStreams; i++ {
+			go func() {
+				defer GinkgoRecover()
+				str, err := serverSess.OpenUniStream()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = str.Write(PRData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(str.Close()).To(Succeed())
+			}()
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(numStreams)
+		for i := 0; i < numStreams; i++ {
+			str, err := sess.AcceptUniStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				data, err := io.ReadAll(str)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(PRData))
+			}()
+		}
+		wg.Wait()
+	})
+
+	It("blocks writes when the limit is reached", func() {
+		const (
+			streamWindow = 1000
+			dataLen      = streamWindow + 500
+		)
+		ln, sessChan := runServer(2000)
+		defer ln.Close()
+
+		// The client never reads any data, so the server's streams are blocked by flow control,
+		// and the last 500 bytes of every stream stay buffered.
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{
+				InitialStreamReceiveWindow:     streamWindow,
+				DisableReceiveWindowAutoTuning: true,
+			}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		var serverSess quic.Session
+		Eventually(sessChan).Should(Receive(&serverSess))
+
+		for i := 0; i < 4; i++ {
+			str, err := serverSess.OpenUniStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData[:dataLen])
+			Expect(err).ToNot(HaveOccurred())
+		}
+		str, err := serverSess.OpenUniStream()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.SetWriteDeadline(time.Now().Add(scaleDuration(100 * time.Millisecond)))).To(Succeed())
+		n, err := str.Write(PRData[:dataLen])
+		Expect(err).To(HaveOccurred())
+		nerr, ok := err.(net.Error)
+		Expect(ok).To(BeTrue())
+		Expect(nerr.Timeout()).To(BeTrue())
+		Expect(n).To(BeZero())
+	})
+})
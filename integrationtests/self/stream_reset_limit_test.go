@@ -0,0 +1,64 @@
+package self_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Reset Rate Limit", func() {
+	It("closes the connection when the peer resets too many streams", func() {
+		server, err := quic.ListenAddr(
+			"localhost:0",
+			getTLSConfig(),
+			getQuicConfig(&quic.Config{MaxStreamResetRate: 10, MaxIncomingStreams: 1000}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		go func() {
+			defer GinkgoRecover()
+			// The session might already be closed when it would be returned by Accept.
+			sess, err := server.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			for {
+				if _, err := sess.AcceptStream(context.Background()); err != nil {
+					return
+				}
+			}
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		for i := 0; i < 100; i++ {
+			str, err := sess.OpenStream()
+			if err != nil {
+				break
+			}
+			_, err = str.Write([]byte("foobar"))
+			if err != nil {
+				break
+			}
+			str.CancelWrite(42)
+		}
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err = sess.AcceptStream(context.Background())
+		var transportErr *quic.TransportError
+		Expect(errors.As(err, &transportErr)).To(BeTrue())
+		Expect(transportErr.Remote).To(BeTrue())
+		Expect(transportErr.ErrorCode).To(Equal(quic.ProtocolViolation))
+	})
+})
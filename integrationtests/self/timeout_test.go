@@ -59,7 +59,7 @@ var _ = Describe("Timeout tests", func() {
 		ExpectWithOffset(1, nerr.Timeout()).To(BeTrue())
 	}
 
-	It("returns net.Error timeout errors when dialing", func() {
+	It("returns net.Error handshake timeout errors when dialing", func() {
 		errChan := make(chan error)
 		go func() {
 			_, err := quic.DialAddr(
@@ -71,7 +71,10 @@ var _ = Describe("Timeout tests", func() {
 		}()
 		var err error
 		Eventually(errChan).Should(Receive(&err))
-		checkTimeoutError(err)
+		Expect(err).To(MatchError(&quic.HandshakeTimeoutError{}))
+		nerr, ok := err.(net.Error)
+		Expect(ok).To(BeTrue())
+		Expect(nerr.Timeout()).To(BeTrue())
 	})
 
 	It("returns the context error when the context expires", func() {
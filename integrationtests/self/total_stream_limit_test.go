@@ -0,0 +1,110 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Total stream limit", func() {
+	It("limits the number of streams across all sessions", func() {
+		server, err := quic.ListenAddr(
+			"localhost:0",
+			getTLSConfig(),
+			getQuicConfig(&quic.Config{
+				MaxTotalStreams:       4,
+				MaxIncomingStreams:    2,
+				MaxIncomingUniStreams: -1,
+			}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		acceptedStreams := make(chan quic.Stream, 10)
+		go func() {
+			defer GinkgoRecover()
+			for {
+				sess, err := server.Accept(context.Background())
+				if err != nil {
+					return
+				}
+				go func() {
+					defer GinkgoRecover()
+					for {
+						// Accept the streams, but don't read from them, such that they stay open.
+						str, err := sess.AcceptStream(context.Background())
+						if err != nil {
+							return
+						}
+						acceptedStreams <- str
+					}
+				}()
+			}
+		}()
+
+		dial := func() quic.Session {
+			sess, err := quic.DialAddr(
+				fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
+				getTLSClientConfig(),
+				getQuicConfig(nil),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			return sess
+		}
+		openStream := func(sess quic.Session) quic.Stream {
+			str, err := sess.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			return str
+		}
+
+		sess1 := dial()
+		defer sess1.CloseWithError(0, "")
+		sess2 := dial()
+		defer sess2.CloseWithError(0, "")
+		var str quic.Stream
+		var serverStr quic.Stream
+		for i := 0; i < 2; i++ {
+			str = openStream(sess1)
+			Eventually(acceptedStreams).Should(Receive(&serverStr))
+			openStream(sess2)
+			Eventually(acceptedStreams).Should(Receive())
+		}
+		// The third session isn't granted any credit to open streams.
+		sess3 := dial()
+		defer sess3.CloseWithError(0, "")
+		_, err = sess3.OpenStream()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(net.Error).Temporary()).To(BeTrue())
+
+		// Once a stream of the first session is completed, the third session can open a stream.
+		Expect(str.Close()).To(Succeed())
+		_, err = io.ReadAll(serverStr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(serverStr.Close()).To(Succeed())
+		_, err = io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		str3, err := sess3.OpenStreamSync(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str3.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(acceptedStreams).Should(Receive())
+		// The credit went to the third session, not to the first one.
+		_, err = sess1.OpenStream()
+		Expect(err).To(HaveOccurred())
+		// None of the sessions was closed.
+		for _, sess := range []quic.Session{sess1, sess2, sess3} {
+			Expect(sess.Context().Err()).ToNot(HaveOccurred())
+		}
+	})
+})
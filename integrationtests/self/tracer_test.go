@@ -57,14 +57,23 @@ func (t *customConnTracer) BufferedPacket(logging.PacketType) {}
 func (t *customConnTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
 }
 
-func (t *customConnTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, packetsInFlight int) {
+func (t *customConnTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight, ssthresh logging.ByteCount, packetsInFlight int) {
 }
 
 func (t *customConnTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
 func (t *customConnTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
 }
 func (t *customConnTracer) UpdatedCongestionState(logging.CongestionState)                     {}
+func (t *customConnTracer) UpdatedECNState(logging.ECNState)                                   {}
+func (t *customConnTracer) UpdatedMTU(logging.ByteCount, bool)                                 {}
 func (t *customConnTracer) UpdatedPTOCount(value uint32)                                       {}
+func (t *customConnTracer) OpenedStream(logging.StreamID, logging.Perspective)                 {}
+func (t *customConnTracer) ClosedStream(logging.StreamID, logging.StreamCloseReason)           {}
+func (t *customConnTracer) StartedHandshake()                                                  {}
+func (t *customConnTracer) ReceivedKeys(logging.EncryptionLevel)                               {}
+func (t *customConnTracer) CompletedHandshake()                                                {}
+func (t *customConnTracer) SentHandshakeMessage(logging.HandshakeMessageType)                  {}
+func (t *customConnTracer) ReceivedHandshakeMessage(logging.HandshakeMessageType)              {}
 func (t *customConnTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
 func (t *customConnTracer) UpdatedKey(generation logging.KeyPhase, remote bool)                {}
 func (t *customConnTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
@@ -0,0 +1,139 @@
+package self_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/BGrewell/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Non-blocking writes", func() {
+	It("transfers data using TryWrite", func() {
+		// TryWrite accepts data up to the send window, so make sure that the window is smaller than the data
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(&quic.Config{
+			InitialStreamReceiveWindow:     50 * 1024,
+			DisableReceiveWindowAutoTuning: true,
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			str, err := sess.AcceptUniStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(PRData))
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		str, err := sess.OpenUniStream()
+		Expect(err).ToNot(HaveOccurred())
+		var bytesWritten, wouldBlockCounter int
+		for bytesWritten < len(PRData) {
+			n, err := str.TryWrite(PRData[bytesWritten:])
+			if errors.Is(err, quic.ErrWouldBlock) {
+				wouldBlockCounter++
+				time.Sleep(time.Millisecond)
+				continue
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(BeNumerically(">", 0))
+			bytesWritten += n
+		}
+		Expect(str.Close()).To(Succeed())
+		Eventually(done).Should(BeClosed())
+		// make sure that TryWrite actually ran into a full buffer
+		Expect(wouldBlockCounter).ToNot(BeZero())
+	})
+
+	It("writes to multiple streams from a single go-routine, using the writability notifications", func() {
+		const numStreams = 3
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(numStreams)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < numStreams; i++ {
+				str, err := sess.AcceptUniStream(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					data, err := io.ReadAll(str)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal(PRData))
+				}()
+			}
+		}()
+
+		sess, err := quic.DialAddr(
+			fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{MaxSendBufferBytes: 20000}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+
+		streams := make([]quic.SendStream, numStreams)
+		bytesWritten := make([]int, numStreams)
+		for i := range streams {
+			str, err := sess.OpenUniStream()
+			Expect(err).ToNot(HaveOccurred())
+			streams[i] = str
+		}
+		for open := numStreams; open > 0; {
+			cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(5 * time.Second))}}
+			for i, str := range streams {
+				if str == nil {
+					continue
+				}
+				for bytesWritten[i] < len(PRData) {
+					n, err := str.TryWrite(PRData[bytesWritten[i]:])
+					if errors.Is(err, quic.ErrWouldBlock) {
+						break
+					}
+					Expect(err).ToNot(HaveOccurred())
+					bytesWritten[i] += n
+				}
+				if bytesWritten[i] == len(PRData) {
+					Expect(str.Close()).To(Succeed())
+					streams[i] = nil
+					open--
+					continue
+				}
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(str.Writable())})
+			}
+			if open == 0 {
+				break
+			}
+			chosen, _, _ := reflect.Select(cases)
+			Expect(chosen).ToNot(BeZero(), "timed out waiting for a stream to become writable")
+		}
+		wg.Wait()
+	})
+})
@@ -2,12 +2,13 @@ package quic
 
 import (
 	"context"
+	"crypto/cipher"
 	"errors"
-	"github.com/BGrewell/quic-go/internal/congestion"
 	"io"
 	"net"
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/handshake"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/logging"
@@ -19,6 +20,12 @@ type StreamID = protocol.StreamID
 // A VersionNumber is a QUIC version number.
 type VersionNumber = protocol.VersionNumber
 
+// A ConnectionID is a QUIC connection ID.
+type ConnectionID = protocol.ConnectionID
+
+// HyStartConfig is used to tune the hybrid slow start algorithm.
+type HyStartConfig = congestion.HyStartConfig
+
 const (
 	// VersionDraft29 is IETF QUIC draft-29
 	VersionDraft29 = protocol.VersionDraft29
@@ -53,6 +60,58 @@ type TokenStore interface {
 	Put(key string, token *ClientToken)
 }
 
+// An UnknownConnectionIDAction determines how a packet with an unknown connection ID is handled.
+type UnknownConnectionIDAction uint8
+
+const (
+	// UnknownConnectionIDSendStatelessReset sends a stateless reset, if a StatelessResetKey is configured.
+	UnknownConnectionIDSendStatelessReset UnknownConnectionIDAction = iota
+	// UnknownConnectionIDDrop drops the packet without sending a response.
+	UnknownConnectionIDDrop
+)
+
+// A PacketDirection is the direction of a UDP datagram passed to Config.PacketCapture,
+// or of a packet passed to Config.PacketLossSimulator.
+type PacketDirection uint8
+
+const (
+	// PacketDirectionSent is used for datagrams sent to the peer.
+	PacketDirectionSent PacketDirection = iota
+	// PacketDirectionReceived is used for datagrams received from the peer.
+	PacketDirectionReceived
+)
+
+// A PTOProbeStrategy determines what is sent in the probe packets sent when the probe timeout (PTO) expires.
+type PTOProbeStrategy uint8
+
+const (
+	// PTOProbeRetransmitData retransmits the data of the oldest outstanding packet.
+	// If there is no outstanding data, a PING frame is sent.
+	PTOProbeRetransmitData PTOProbeStrategy = iota
+	// PTOProbeSendPing only sends a PING frame in 1-RTT probe packets.
+	// Data is retransmitted once the loss detection declares the packet lost.
+	// Initial and Handshake probe packets always retransmit the crypto data.
+	PTOProbeSendPing
+)
+
+// A RetransmissionOrder determines which stream is scheduled first when multiple streams have lost data to retransmit.
+type RetransmissionOrder uint8
+
+const (
+	// RetransmissionOrderRoundRobin schedules streams with lost data like any other stream with data to send,
+	// i.e. weighted by their priority.
+	RetransmissionOrderRoundRobin RetransmissionOrder = iota
+	// RetransmissionOrderLowestStreamIDFirst retransmits the lost data of the stream with the lowest stream ID first.
+	RetransmissionOrderLowestStreamIDFirst
+	// RetransmissionOrderHighestPriorityFirst retransmits the lost data of the stream with the highest priority first.
+	// Streams with the same priority are scheduled round robin.
+	RetransmissionOrderHighestPriorityFirst
+)
+
+// A DropReason is the reason why a received packet was dropped, as passed to Config.OnDroppedPacket.
+// It takes the values of the logging.PacketDrop* constants.
+type DropReason = logging.PacketDropReason
+
 // Err0RTTRejected is the returned from:
 // * Open{Uni}Stream{Sync}
 // * Accept{Uni}Stream
@@ -60,6 +119,30 @@ type TokenStore interface {
 // when the server rejects a 0-RTT connection attempt.
 var Err0RTTRejected = errors.New("0-RTT rejected")
 
+// ErrSendBlocked is returned from SendStream.Flush when the data couldn't be sent out right away,
+// because flow control, congestion control or pacing don't allow sending at this moment.
+// The data is sent as soon as they allow it.
+var ErrSendBlocked = errors.New("sending is blocked")
+
+// ErrWouldBlock is returned from SendStream.TryWrite when no data could be written without blocking.
+// The application should try again later, e.g. after the peer had a chance to acknowledge data.
+var ErrWouldBlock = errors.New("write would block")
+
+// ErrVersionNegotiationDisabled is returned by Dial (and used to close the session)
+// when the client receives a Version Negotiation packet, and Config.DisableVersionNegotiation is set.
+var ErrVersionNegotiationDisabled = errors.New("received a Version Negotiation packet, but version negotiation is disabled")
+
+// ErrTooManyRetries is returned by Dial when the server caused the client to create
+// more than Config.MaxRetries new sessions.
+var ErrTooManyRetries = errors.New("too many retries")
+
+// ErrSessionClosing is returned from Accept{Uni}Stream after Session.CloseGracefully was called.
+var ErrSessionClosing = errors.New("session is closing")
+
+// ErrGracefulCloseTimeout is returned from Session.CloseGracefully
+// when streams were still open after the timeout expired.
+var ErrGracefulCloseTimeout = errors.New("timeout while waiting for streams to complete")
+
 // SessionTracingKey can be used to associate a ConnectionTracer with a Session.
 // It is set on the Session.Context() context,
 // as well as on the context passed to logging.Tracer.NewConnectionTracer.
@@ -115,6 +198,20 @@ type SendStream interface {
 	// If the session was closed due to a timeout, the error satisfies
 	// the net.Error interface, and Timeout() will be true.
 	io.Writer
+	// TryWrite writes as much of p as can be buffered right away, and returns the number of bytes written.
+	// Unlike Write, it never blocks: if no data can be written, it returns ErrWouldBlock.
+	// It buffers data up to the stream's current send window (but at least one packet worth of data),
+	// and Config.MaxSendBufferBytes applies.
+	// Just like Write, it returns an error if the write deadline has expired, or if the stream was canceled or closed.
+	// It must not be called concurrently with Write.
+	TryWrite(p []byte) (n int, err error)
+	// Writable returns a channel that is signaled once more data can be written using TryWrite,
+	// after a call to TryWrite returned ErrWouldBlock, or didn't write all of the data.
+	// The notification is edge-triggered: the channel is signaled once per blocked TryWrite call,
+	// and it is not signaled if the last call to TryWrite wrote all of the data.
+	// It is also signaled when the stream is canceled, such that TryWrite returns the error.
+	// The channel is never closed.
+	Writable() <-chan struct{}
 	// Close closes the write-direction of the stream.
 	// Future calls to Write are not permitted after calling Close.
 	// It must not be called concurrently with Write.
@@ -136,14 +233,29 @@ type SendStream interface {
 	// some data was successfully written.
 	// A zero value for t means Write will not time out.
 	SetWriteDeadline(t time.Time) error
+	// BufferedBytes returns the number of bytes that were written to the stream, but not acknowledged by the peer yet.
+	// This includes both data that wasn't sent yet (e.g. due to flow control), and data that was sent but is still in flight.
+	BufferedBytes() uint64
+	// Flush sends out the data written to the stream right away,
+	// instead of waiting for the session to schedule sending.
+	// It returns once the data has been packed and handed to the connection.
+	// If flow control, congestion control or pacing prevent sending all of the data,
+	// or if the session couldn't send any more data of this stream, it returns ErrSendBlocked.
+	// It must not be called concurrently with Write.
+	Flush() error
+	// SetPriority sets the weight of the stream.
+	// When multiple streams have data to send, the available bandwidth is shared
+	// between them proportionally to their weights.
+	// A weight of 0 is treated like a weight of 1. Streams start with a weight of 16.
+	SetPriority(weight uint8)
 }
 
 // A Session is a QUIC connection between two peers.
 // Calls to the session (and to streams) can return the following types of errors:
 // * ApplicationError: for errors triggered by the application running on top of QUIC
 // * TransportError: for errors triggered by the QUIC transport (in many cases a misbehaving peer)
-// * IdleTimeoutError: when the peer goes away unexpectedly (this is a net.Error timeout error)
-// * HandshakeTimeoutError: when the cryptographic handshake takes too long (this is a net.Error timeout error)
+// * IdleTimeoutError: when the peer goes away unexpectedly after the handshake completed (this is a net.Error timeout error)
+// * HandshakeTimeoutError: when the cryptographic handshake takes too long, or the peer goes away before it completed (this is a net.Error timeout error)
 // * StatelessResetError: when we receive a stateless reset (this is a net.Error temporary error)
 // * VersionNegotiationError: returned by the client, when there's no version overlap between the peers
 type Session interface {
@@ -181,9 +293,25 @@ type Session interface {
 	LocalAddr() net.Addr
 	// RemoteAddr returns the address of the peer.
 	RemoteAddr() net.Addr
+	// MigrateTo migrates the connection to a new net.PacketConn, see section 9 of RFC 9000.
+	// It sends a PATH_CHALLENGE on the new path, and blocks until the server responds, or until path validation times out.
+	// Until then, packets are sent on the old path. Once the new path is validated, all packets are sent on the new path,
+	// and the RTT estimate and the congestion controller are reset.
+	// Packets might still arrive on the old net.PacketConn, so it must not be closed while the session is in use.
+	// Only clients can migrate, and only after the handshake is confirmed, and if the server didn't disable active migration.
+	// The new path uses a connection ID that wasn't used before, so migrating fails if the server didn't provide an unused one.
+	// The server provides more connection IDs if Config.ActiveConnectionIDLimit is increased.
+	MigrateTo(net.PacketConn) error
 	// CloseWithError closes the connection with an error.
 	// The error string will be sent to the peer.
 	CloseWithError(ApplicationErrorCode, string) error
+	// CloseGracefully stops accepting new streams, and waits up to timeout for all open streams to complete,
+	// i.e. for all data to be sent and acknowledged, and all data to be received.
+	// Afterwards, it closes the connection with a NO_ERROR application error.
+	// Calls to Accept{Uni}Stream made after CloseGracefully return ErrSessionClosing.
+	// If streams are still open when the timeout expires, the connection is closed nevertheless,
+	// and ErrGracefulCloseTimeout is returned.
+	CloseGracefully(timeout time.Duration) error
 	// The context is cancelled when the session is closed.
 	// Warning: This API should not be considered stable and might change soon.
 	Context() context.Context
@@ -191,6 +319,97 @@ type Session interface {
 	// It blocks until the handshake completes.
 	// Warning: This API should not be considered stable and might change soon.
 	ConnectionState() ConnectionState
+	// PeerSupportsDatagrams says if the peer advertised support for DATAGRAM frames,
+	// by sending the max_datagram_frame_size transport parameter.
+	// Unlike ConnectionState().SupportsDatagrams, it doesn't depend on Config.EnableDatagrams.
+	// It blocks until the handshake completes.
+	PeerSupportsDatagrams() bool
+	// PeerTransportParameter returns the raw value of the transport parameter with the given ID, as sent by the peer.
+	// The second return value is false if the peer didn't send this transport parameter.
+	// It can be used to detect support for extensions that are not implemented by quic-go.
+	// It blocks until the handshake completes.
+	PeerTransportParameter(id uint64) ([]byte, bool)
+	// SupportedVersions returns the QUIC versions that the server offered in its Version Negotiation packet.
+	// It returns nil if no Version Negotiation packet was received, which is always the case for a server.
+	SupportedVersions() []VersionNumber
+	// CongestionControl returns the congestion control algorithm used by the session.
+	// Congestion controllers that don't advertise their algorithm are reported as congestion.ALGO_UNKNOWN.
+	CongestionControl() congestion.CongestionAlgo
+	// ConnectionStats returns statistics about the RTT estimation and the congestion controller.
+	// It can be called at any time, e.g. to periodically poll the values during a transfer.
+	ConnectionStats() ConnectionStats
+	// Timestamps returns the time when the session was created, when the handshake completed,
+	// and when the handshake was confirmed. The handshake timestamps are zero until the respective event occurs.
+	// For the server, the handshake is confirmed as soon as it completes.
+	// It can be called at any time.
+	Timestamps() (created, handshakeComplete, handshakeConfirmed time.Time)
+	// ReorderingStats returns statistics about reordering of received 0-RTT and 1-RTT packets.
+	// maxReorder is the largest number of packets that a packet arrived late by,
+	// and reorderedPackets is the number of packets that arrived after a packet with a higher packet number.
+	// It can be called at any time.
+	ReorderingStats() (maxReorder int, reorderedPackets uint64)
+	// SavedSyscalls returns the number of syscalls that were saved by sending multiple packets using a single syscall.
+	// It is always 0 if Config.DisableBatchedWrites is set, or if the platform doesn't support batched writes.
+	SavedSyscalls() uint64
+	// PaddingStats returns the number of bytes of PADDING frames sent and received on this session.
+	// This includes the padding of the client's Initial packets, as well as padding added to reach a minimum packet size.
+	// It can be called at any time.
+	PaddingStats() (sent, received protocol.ByteCount)
+	// AckStats returns the number of ACK frames sent and received on this session, across all encryption levels.
+	// It can be called at any time.
+	AckStats() (sent, received uint64)
+	// BytesSent returns the number of bytes of stream data sent on this session,
+	// and BytesAcked the number of bytes of stream data that the peer acknowledged.
+	// Unlike the number of bytes written to a stream, BytesAcked only counts data that actually arrived at the peer,
+	// which makes it suitable for tracking the progress of a large upload.
+	// Neither includes packet overhead or retransmissions. They only ever increase, and can be called at any time.
+	BytesSent() protocol.ByteCount
+	BytesAcked() protocol.ByteCount
+	// SetWriteDeadline sets a write deadline for the whole session.
+	// It applies to Write calls on all streams, including streams that are opened or accepted later,
+	// in addition to the deadline set on the individual stream: a Write times out at whichever deadline is earlier.
+	// Writes that are blocked when the deadline passes return with a net.Error with Timeout() == true.
+	// A zero value for t removes the session's write deadline.
+	SetWriteDeadline(t time.Time)
+	// LargestAcked returns the largest packet number that the peer acknowledged in the packet number space of the given encryption level.
+	// 0-RTT and 1-RTT share the application data packet number space.
+	// It returns protocol.InvalidPacketNumber if no packet in that space was acknowledged yet, or if the encryption level is invalid.
+	// It can be called at any time.
+	LargestAcked(protocol.EncryptionLevel) protocol.PacketNumber
+	// OpenStreams returns the IDs of all streams that are currently open, sorted by stream ID.
+	// This includes streams opened by the peer that were not yet accepted.
+	// A stream is removed from the list once it is completed, i.e. when both its send and its receive direction are done.
+	OpenStreams() []StreamID
+	// CongestionEvents returns a channel that delivers the events of the congestion controller as they happen:
+	// state transitions, changes of the congestion window and lost packets.
+	// It returns nil unless Config.CongestionEventBufferSize is set.
+	// Sending on the channel never blocks the session: if the buffer is full, the event is dropped,
+	// and counted by DroppedCongestionEvents.
+	// The channel is closed when the session is closed.
+	CongestionEvents() <-chan CongestionEvent
+	DroppedCongestionEvents() uint64
+	// NextTimeout returns the time when the session next needs to be serviced,
+	// e.g. to send an ACK, a probe packet or paced data, or because the idle timeout expires.
+	// If the returned time is in the past, the session needs to be serviced immediately.
+	// It returns the zero value of time.Time if the session hasn't started running yet.
+	NextTimeout() time.Time
+	// CurrentMTU returns the maximum size of the QUIC packets currently sent on this session.
+	// It starts at a conservative value, and increases as Path MTU Discovery finds larger packet sizes.
+	// It can be used to size application writes, e.g. to fill complete packets.
+	CurrentMTU() protocol.ByteCount
+	// ConnectionIDs returns the connection IDs currently used on this session.
+	// src is the connection ID the peer used to address the most recently received packet,
+	// and dest is the connection ID used to address the peer.
+	// Both change over the lifetime of the session, e.g. when the peer switches to a new connection ID after a migration,
+	// or when the peer's connection ID is retired.
+	// It is safe to call ConnectionIDs concurrently with the session's other methods.
+	ConnectionIDs() (src, dest ConnectionID)
+	// CanSendNow says if the session would send a packet right now,
+	// i.e. if neither the congestion controller nor the pacer prevent sending.
+	// It is based on the state of the congestion controller when the session last tried to send,
+	// and it becomes true once the pacer accrues enough budget for a full-size packet.
+	// It can be used by an external scheduler to decide when to write more data.
+	CanSendNow() bool
 
 	// SendMessage sends a message as a datagram.
 	// See https://datatracker.ietf.org/doc/draft-pauly-quic-datagram/.
@@ -215,6 +434,17 @@ type EarlySession interface {
 	NextSession() Session
 }
 
+// A Logger receives the log messages of a client or a server, and of all of its sessions.
+// It must be safe for concurrent use.
+type Logger interface {
+	// Debug says if debug messages are logged.
+	// If it returns false, debug messages that are expensive to generate are skipped.
+	Debug() bool
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
 // Config contains all configuration data needed for a QUIC server or client.
 type Config struct {
 	// The QUIC versions that can be negotiated.
@@ -228,8 +458,36 @@ type Config struct {
 	// If used for a server, or dialing on a packet conn, a 4 byte connection ID will be used.
 	// When dialing on a packet conn, the ConnectionIDLength value must be the same for every Dial call.
 	ConnectionIDLength int
+	// ConnectionIDGenerator generates the connection IDs used by this endpoint.
+	// It is called with the ConnectionIDLength, and must return a connection ID of exactly that length.
+	// This can be used to encode routing information into the connection ID, e.g. for load balancers.
+	// If not set, connection IDs are generated randomly.
+	ConnectionIDGenerator func(length int) (ConnectionID, error)
+	// OnConnectionIDChanged is called when the connection ID used to address the peer changes,
+	// e.g. when the client switches to the connection ID chosen by the server during the handshake,
+	// or because the peer retired it using a NEW_CONNECTION_ID frame.
+	// It is called synchronously from the session's run loop, and must not block.
+	OnConnectionIDChanged func(old, new ConnectionID)
+	// OnConnectionIDIssued and OnConnectionIDRetired are called when one of our connection IDs is added or retired,
+	// i.e. whenever the set of connection IDs that packets for this session can be routed with changes.
+	// They can be used to keep an external routing table in sync.
+	// They are called synchronously from the session's run loop, and must not block.
+	OnConnectionIDIssued  func(ConnectionID)
+	OnConnectionIDRetired func(ConnectionID)
+	// ActiveConnectionIDLimit is the number of connection IDs issued by the peer that we store,
+	// including the one that is currently in use. It is sent to the peer in the active_connection_id_limit transport parameter.
+	// A larger value allows switching to a new connection ID without waiting for the peer to issue one.
+	// It must be at least 2. If not set, it will default to 2.
+	// The number of connection IDs we issue is limited by the peer's value for this parameter.
+	ActiveConnectionIDLimit uint64
+	// MaxPathChallenges is the maximum number of PATH_CHALLENGE frames sent when validating a new path.
+	// A PATH_CHALLENGE that isn't answered within a PTO is retransmitted.
+	// Path validation fails if none of them is answered.
+	// If not set, it will default to 3.
+	MaxPathChallenges int
 	// HandshakeIdleTimeout is the idle timeout before completion of the handshake.
-	// Specifically, if we don't receive any packet from the peer within this time, the connection attempt is aborted.
+	// Specifically, if we don't receive any packet from the peer within this time, the connection attempt is aborted
+	// with a HandshakeTimeoutError.
 	// If this value is zero, the timeout is set to 5 seconds.
 	HandshakeIdleTimeout time.Duration
 	// MaxIdleTimeout is the maximum duration that may pass without any incoming network activity.
@@ -238,6 +496,11 @@ type Config struct {
 	// If the timeout is exceeded, the connection is closed.
 	// If this value is zero, the timeout is set to 30 seconds.
 	MaxIdleTimeout time.Duration
+	// DrainingTimeout is the time that a closed session is kept around,
+	// in order to retransmit the CONNECTION_CLOSE frame (when closed locally),
+	// or to absorb packets that were sent before the peer closed the connection.
+	// If not set, it will default to 5 seconds.
+	DrainingTimeout time.Duration
 	// AcceptToken determines if a Token is accepted.
 	// It is called with token = nil if the client didn't send a token.
 	// If not set, a default verification function is used:
@@ -246,6 +509,26 @@ type Config struct {
 	//   * else, that it was issued within the last 24 hours.
 	// This option is only valid for the server.
 	AcceptToken func(clientAddr net.Addr, token *Token) bool
+	// GetRetryToken and ValidateRetryToken replace the built-in generation and validation of Retry tokens,
+	// e.g. to use a custom HMAC-based scheme for address validation.
+	// GetRetryToken is called when a Retry is sent, and returns the token data for the client address.
+	// ValidateRetryToken is called with the token data when the client retries its connection attempt.
+	// The token data is encrypted and authenticated together with the connection IDs of the Retry.
+	// A connection attempt with a Retry token accepted by ValidateRetryToken doesn't consult AcceptToken.
+	// If the token is rejected, the connection attempt is closed with INVALID_TOKEN.
+	// Tokens that can't be authenticated, e.g. because they were modified by the client or issued by a different server,
+	// are handled like any other token that can't be decoded.
+	// Either both or none of them must be set. If not set, the built-in Retry tokens are used.
+	// These options are only valid for the server.
+	GetRetryToken      func(clientAddr net.Addr) ([]byte, error)
+	ValidateRetryToken func(clientAddr net.Addr, token []byte) bool
+	// RequireAddressValidation decides if a Retry is sent to validate the client's address.
+	// It is called for every Initial packet that doesn't carry a token accepted by AcceptToken.
+	// If it returns false, the handshake proceeds without address validation.
+	// If not set, a Retry is always sent in that case.
+	// An invalid Retry token is always rejected, regardless of this function.
+	// This option is only valid for the server.
+	RequireAddressValidation func(clientAddr net.Addr) bool
 	// The TokenStore stores tokens received from the server.
 	// Tokens are used to skip address validation on future connection attempts.
 	// The key used to store tokens is the ServerName from the tls.Config, if set
@@ -255,18 +538,24 @@ type Config struct {
 	// If the application is consuming data quickly enough, the flow control auto-tuning algorithm
 	// will increase the window up to MaxStreamReceiveWindow.
 	// If this value is zero, it will default to 512 KB.
+	// It must not be larger than MaxStreamReceiveWindow.
 	InitialStreamReceiveWindow uint64
 	// MaxStreamReceiveWindow is the maximum stream-level flow control window for receiving data.
 	// If this value is zero, it will default to 6 MB.
 	MaxStreamReceiveWindow uint64
-	// InitialConnectionReceiveWindow is the initial size of the stream-level flow control window for receiving data.
+	// InitialConnectionReceiveWindow is the initial size of the connection-level flow control window for receiving data.
 	// If the application is consuming data quickly enough, the flow control auto-tuning algorithm
 	// will increase the window up to MaxConnectionReceiveWindow.
-	// If this value is zero, it will default to 512 KB.
+	// If this value is zero, it will default to 768 KB.
+	// It must not be larger than MaxConnectionReceiveWindow.
 	InitialConnectionReceiveWindow uint64
 	// MaxConnectionReceiveWindow is the connection-level flow control window for receiving data.
 	// If this value is zero, it will default to 15 MB.
 	MaxConnectionReceiveWindow uint64
+	// DisableReceiveWindowAutoTuning disables auto-tuning of the stream- and connection-level receive windows.
+	// The windows then stay at InitialStreamReceiveWindow and InitialConnectionReceiveWindow,
+	// and MaxStreamReceiveWindow and MaxConnectionReceiveWindow are ignored.
+	DisableReceiveWindowAutoTuning bool
 	// AllowConnectionWindowIncrease is called every time the connection flow controller attempts
 	// to increase the connection flow control window.
 	// If set, the caller can prevent an increase of the window. Typically, it would do so to
@@ -274,6 +563,12 @@ type Config struct {
 	// To avoid deadlocks, it is not valid to call other functions on the session or on streams
 	// in this callback.
 	AllowConnectionWindowIncrease func(sess Session, delta uint64) bool
+	// MaxSendBufferBytes is the maximum amount of application data that is buffered for sending, summed over all streams of a session.
+	// This includes data that was not sent yet, as well as data that was sent, but not yet acknowledged by the peer.
+	// Once the limit is reached, Stream.Write blocks until the peer acknowledges data (or the write deadline expires).
+	// Data passed to a Write call that is blocked is sent out up to the limit.
+	// If not set, the amount of buffered data is only limited by flow control.
+	MaxSendBufferBytes int
 	// MaxIncomingStreams is the maximum number of concurrent bidirectional streams that a peer is allowed to open.
 	// Values above 2^60 are invalid.
 	// If not set, it will default to 100.
@@ -284,25 +579,211 @@ type Config struct {
 	// If not set, it will default to 100.
 	// If set to a negative value, it doesn't allow any unidirectional streams.
 	MaxIncomingUniStreams int64
+	// MaxTotalStreams is the maximum number of concurrent streams that the peers of all sessions of a server
+	// are allowed to open, in total. Bidirectional and unidirectional streams both count towards this limit.
+	// The limit is enforced by granting peers less credit to open streams (see MaxIncomingStreams),
+	// until streams of other sessions are completed. A stream counts towards this limit from the moment
+	// the peer is allowed to open it. It should therefore be considerably larger than MaxIncomingStreams.
+	// If not set, or set to a negative value, the number of streams is only limited per session.
+	// This option is only valid for the server.
+	MaxTotalStreams int64
+	// OnNewStream is called when the peer opens a new bidirectional stream,
+	// before the stream can be accepted using AcceptStream.
+	// It is called synchronously from the session's event loop, and must not block.
+	// It is only called for streams within the MaxIncomingStreams limit, and never for locally opened streams.
+	// It can be used to attach metadata to a stream, or to reject the stream early by canceling it.
+	OnNewStream func(str Stream)
+	// MaxEncryptionRate is the maximum number of packets per second that are sealed for a connection.
+	// When this budget is exhausted, sending is delayed until new packets can be sealed.
+	// This can be used to bound the CPU time spent on encryption.
+	// If not set, the rate is not limited.
+	MaxEncryptionRate uint64
+	// MaxStreamResetRate is the maximum number of RESET_STREAM and STOP_SENDING frames per second that the peer may send.
+	// Bursts of up to one second's worth of frames are allowed.
+	// If the peer exceeds this rate, the connection is closed with a PROTOCOL_VIOLATION error.
+	// This protects against peers that cause churn by resetting a large number of streams.
+	// If not set, the rate is not limited.
+	MaxStreamResetRate uint64
+	// AEADFactory creates the AEAD used to seal and open packets, e.g. to use a hardware-backed implementation.
+	// It is called with the TLS 1.3 cipher suite, and the key and iv derived for that cipher suite.
+	// The nonce passed to the AEAD is the left-padded packet number.
+	// The AEAD must XOR it with the iv to obtain the actual nonce, see RFC 9001, Section 5.3.
+	// It is used for 0-RTT, Handshake and 1-RTT packets. Initial packets always use the standard library.
+	// If not set, the AEAD from the standard library is used.
+	AEADFactory func(suite uint16, key, iv []byte) cipher.AEAD
 	// The StatelessResetKey is used to generate stateless reset tokens.
 	// If no key is configured, sending of stateless resets is disabled.
 	StatelessResetKey []byte
+	// OnUnknownConnectionID is called when a short header packet is received
+	// that can't be associated with any connection.
+	// It decides if a stateless reset is sent in response, or if the packet is dropped.
+	// It is called on a separate go routine for every such packet, and must be safe for concurrent use.
+	// If not set, a stateless reset is sent.
+	// This option is only valid for the server.
+	OnUnknownConnectionID func(connID ConnectionID, addr net.Addr) UnknownConnectionIDAction
 	// KeepAlive defines whether this peer will periodically send a packet to keep the connection alive.
 	KeepAlive bool
+	// KeepAlivePeriod is the period after which a keep-alive PING is sent if the connection is idle.
+	// Setting it enables keep-alives, even if KeepAlive is not set.
+	// It must be smaller than half the idle timeout negotiated with the peer.
+	// Larger values are reduced to half the idle timeout, and a warning is logged.
+	// If not set, and KeepAlive is set, a PING is sent after half the idle timeout (but at least every 20s).
+	KeepAlivePeriod time.Duration
+	// ReceiveBufferSize is the size (in bytes) of the kernel's receive buffer of the UDP socket.
+	// It is set when the packet conn is first used by a client or server. Only *net.UDPConn (and
+	// compatible connections) support setting the receive buffer size.
+	// If the kernel doesn't grant the requested size, a warning is logged.
+	// If not set, it will default to 2 MB.
+	ReceiveBufferSize int
+	// ReusePort sets the SO_REUSEPORT socket option on the UDP socket created by ListenAddr and ListenAddrEarly.
+	// This allows multiple processes to listen on the same UDP port, e.g. behind a load balancer.
+	// It is only supported on Linux and the BSDs (including macOS). On other platforms, listening fails.
+	// It has no effect when the packet conn is passed to Listen, or when dialing.
+	ReusePort bool
 	// DisablePathMTUDiscovery disables Path MTU Discovery (RFC 8899).
 	// Packets will then be at most 1252 (IPv4) / 1232 (IPv6) bytes in size.
 	// Note that if Path MTU discovery is causing issues on your system, please open a new issue
 	DisablePathMTUDiscovery bool
+	// DisableBatchedWrites disables sending multiple packets to the peer using a single syscall.
+	// By default, on Linux, packets that are ready to be sent at the same time are passed to the kernel
+	// using sendmmsg. On other platforms, or if this isn't possible for a connection, packets are always
+	// written one by one.
+	// Note that this is not a DisableGSO option: batched writes don't use UDP generic segmentation offload (GSO),
+	// which would require all packets of a batch to have the same size.
+	DisableBatchedWrites bool
+	// MaxCoalescedPackets is the maximum number of QUIC packets that are coalesced into a single UDP datagram.
+	// During the handshake, Initial, Handshake and 0-RTT / 1-RTT packets are coalesced into one datagram
+	// if they fit, which some middleboxes don't handle well.
+	// If not set, the number of packets is only limited by the size of the datagram.
+	// The limit doesn't apply to CONNECTION_CLOSE packets, which are sent at every available encryption level.
+	MaxCoalescedPackets int
 	// DisableVersionNegotiationPackets disables the sending of Version Negotiation packets.
 	// This can be useful if version information is exchanged out-of-band.
 	// It has no effect for a client.
 	DisableVersionNegotiationPackets bool
+	// DisableVersionNegotiation makes the client treat a Version Negotiation packet as a fatal error,
+	// instead of retrying the connection attempt with a different version.
+	// The connection attempt then fails with ErrVersionNegotiationDisabled.
+	// It has no effect for a server.
+	DisableVersionNegotiation bool
+	// MaxRetries is the maximum number of times a client creates a new session while dialing,
+	// e.g. when switching to a different QUIC version after receiving a Version Negotiation packet.
+	// If exceeded, Dial fails with ErrTooManyRetries.
+	// If not set, it will default to 3.
+	// If set to a negative value, no new sessions are created.
+	// It has no effect for a server.
+	MaxRetries int
 	// See https://datatracker.ietf.org/doc/draft-ietf-quic-datagram/.
 	// Datagrams will only be available when both peers enable datagram support.
 	EnableDatagrams bool
+	// RunLoopHook is called on the goroutine that runs the event loop of a session.
+	// It must call run, which blocks until the session is closed.
+	// It can be used to control the placement of this goroutine, e.g. by calling runtime.LockOSThread before calling run.
+	// If the hook returns without calling run, the event loop is run after the hook returned.
+	// The event loop is only run once: calling run again has no effect.
+	RunLoopHook func(run func())
+	// DeliverEmptyDatagrams makes the session deliver DATAGRAM frames without any payload to the application.
+	// By default, empty datagrams are dropped.
+	DeliverEmptyDatagrams bool
 	// CongestionControlAlgo is a field to select the congestion control algorithm.
+	// congestion.ALGO_RENO selects NewReno, and congestion.ALGO_CUBIC_RFC8312 selects CUBIC.
+	// For compatibility, congestion.ALGO_CUBIC behaves like congestion.ALGO_RENO.
+	// If not set, NewReno is used.
 	CongestionControlAlgo congestion.CongestionAlgo
-	Tracer                logging.Tracer
+	// HyStartConfig tunes the hybrid slow start (HyStart) algorithm used by the congestion controller.
+	// It can also be used to disable HyStart.
+	// If not set, the default parameters are used.
+	HyStartConfig HyStartConfig
+	// InitialCongestionWindowPackets is the initial congestion window, in packets.
+	// A larger initial window can speed up transfers on paths with a high bandwidth-delay product, e.g. satellite links.
+	// It is capped to 100 packets, which is 10 times the initial window recommended by RFC 9002.
+	// It has no effect on congestion.ALGO_LOCO, which never limits sending.
+	// If not set, it defaults to 32 packets.
+	InitialCongestionWindowPackets int
+	// InitialSlowStartThreshold is the initial slow start threshold (ssthresh) of the congestion controller, in bytes.
+	// Slow start is exited once the congestion window reaches this value, even if no packet was lost.
+	// This allows a more cautious ramp-up on paths that are known to have shallow buffers.
+	// It only applies to CUBIC and NewReno.
+	// If not set, slow start is only exited due to packet loss or by HyStart.
+	InitialSlowStartThreshold protocol.ByteCount
+	// LocoMaxBytesInFlight limits the number of bytes in flight when using congestion.ALGO_LOCO.
+	// The loco sender neither paces nor reacts to packet loss, so without a limit it keeps sending
+	// even if the peer stopped acknowledging packets, e.g. because its receive buffer is full.
+	// If not set, the number of bytes in flight is not limited.
+	LocoMaxBytesInFlight protocol.ByteCount
+	// MaxSendRate is the maximum rate at which packets are sent, in bits per second.
+	// It is enforced in addition to the pacing rate of the congestion controller.
+	// If not set, the send rate is only limited by the congestion controller.
+	MaxSendRate congestion.Bandwidth
+	// Clock is the clock used by the session, e.g. for loss detection, RTT measurement, congestion control, pacing and timers.
+	// It can be replaced to control the passage of time in tests.
+	// If not set, the system clock is used.
+	Clock congestion.Clock
+	// AckElicitingThreshold is the number of ack-eliciting packets that are received before an ACK is sent.
+	// Packets received out of order are still acknowledged immediately.
+	// It only applies to 1-RTT packets.
+	// If not set, it defaults to 2, as recommended by RFC 9000.
+	AckElicitingThreshold int
+	// CongestionLog, if set, receives a CSV line every time the state or the window of the congestion controller changes.
+	// The columns are: connection ID, timestamp (in microseconds since the Unix epoch), congestion state,
+	// congestion window (in bytes), bandwidth estimate (in bits per second) and smoothed RTT (in microseconds).
+	// The connection ID is the hex-encoded original destination connection ID,
+	// i.e. the connection ID that the Tracer is created for.
+	// It is a lightweight alternative to a Tracer. Writes happen synchronously on the session's run loop.
+	// If the Config is used for multiple sessions, the writer needs to be safe for concurrent use.
+	CongestionLog io.Writer
+	// OnCongestionCollapse, if set, is called when a loss event reduces the congestion window to its minimum value.
+	// Repeated losses driving the window down to the floor are a sign that the path might be broken.
+	// It is called again only after the window grew above the minimum and collapsed again.
+	// It only applies to the CUBIC and NewReno congestion controllers.
+	// It is called synchronously on the session's run loop, and must not block.
+	OnCongestionCollapse func()
+	// CongestionEventBufferSize enables Session.CongestionEvents, and sets the size of the channel's buffer.
+	// If the application doesn't consume the events fast enough, events are dropped once the buffer is full.
+	// If zero, Session.CongestionEvents returns nil.
+	CongestionEventBufferSize int
+	// PTOProbeStrategy determines what is sent when the probe timeout (PTO) expires.
+	// Retransmitting data (the default) recovers faster from tail losses, at the cost of sending data that might be redundant.
+	// Sending a PING avoids redundant retransmissions, at the cost of one more round trip until lost data is retransmitted.
+	PTOProbeStrategy PTOProbeStrategy
+	// RetransmissionOrder determines which stream's lost data is retransmitted first, when multiple streams have lost data.
+	// Streams with lost data are always scheduled before streams that only have new data to send,
+	// unless the round robin order (the default) is used.
+	RetransmissionOrder RetransmissionOrder
+	// PacketCapture, if set, is called with every UDP datagram that a session sends or receives:
+	// right before it is written to the connection, and right after it was read from the connection.
+	// The addr is the address of the peer. The datagram is passed without copying it.
+	// It is only valid for the duration of the call, and must not be modified.
+	// It can be used to capture the raw packets of a connection, without implementing a Tracer.
+	// It is called synchronously, and must be safe for concurrent use.
+	PacketCapture func(dir PacketDirection, data []byte, addr net.Addr)
+	// PacketLossSimulator, if set, is called for every packet that a session sends, and for every packet that it receives,
+	// before the packet is decrypted. If it returns true, the packet is dropped:
+	// A sent packet is accounted for by the loss detection and the congestion controller, but never written to the connection.
+	// Since packets can be coalesced into a single UDP datagram, the whole datagram is dropped if any of its packets is dropped.
+	// A received packet is discarded as if it had never arrived, i.e. it is neither processed nor acknowledged.
+	// Packets containing a CONNECTION_CLOSE frame and path probes are never dropped.
+	// The meaning of index depends on the direction:
+	// * for a sent packet, it is the packet number. Note that Initial, Handshake and application data packets
+	//   are numbered separately, so the same index is used once for each of these packet number spaces.
+	// * for a received packet, it counts the packets received by this session, starting at 0.
+	//   The packet number can't be used, since it is only known after decrypting the packet.
+	// It can be used to deterministically inject packet loss in tests. It must not be used in production.
+	// It is called synchronously on the session's run loop, and must not block.
+	PacketLossSimulator func(dir PacketDirection, index protocol.PacketNumber) bool
+	// OnDroppedPacket, if set, is called whenever a received packet is discarded,
+	// e.g. because it couldn't be parsed or decrypted, it was of an unexpected type,
+	// or it belonged to a different connection.
+	// The header is nil if the packet was dropped before its header was parsed.
+	// Packets that can't be associated with any session or server using the same net.PacketConn,
+	// e.g. because of an unknown connection ID, are only reported to the Tracer.
+	// It is called synchronously, and must be safe for concurrent use.
+	OnDroppedPacket func(reason DropReason, hdr *logging.Header)
+	Tracer          logging.Tracer
+	// Logger is used by the client or the server, and by all of its sessions.
+	// The client and the server prefix the messages with "client" or "server", respectively.
+	// If not set, the default logger is used, which is configured using the QUIC_GO_LOG_LEVEL environment variable.
+	Logger Logger
 }
 
 // ConnectionState records basic details about a QUIC connection
@@ -311,14 +792,35 @@ type ConnectionState struct {
 	SupportsDatagrams bool
 }
 
+// ConnectionStats contains statistics about the QUIC connection
+type ConnectionStats struct {
+	SmoothedRTT time.Duration
+	MinRTT      time.Duration
+	LatestRTT   time.Duration
+	// CongestionWindow is the current congestion window, in bytes
+	CongestionWindow uint64
+	// BytesInFlight is the number of bytes sent that were neither acknowledged nor declared lost
+	BytesInFlight uint64
+	// BandwidthEstimate is the bandwidth estimate of the congestion controller, in bits per second
+	BandwidthEstimate uint64
+	// Retries is the number of Retry packets accepted by the client.
+	// Since a client only accepts a single Retry, this is either 0 or 1. It is always 0 for the server.
+	Retries uint64
+}
+
 // A Listener for incoming QUIC connections
 type Listener interface {
 	// Close the server. All active sessions will be closed.
 	Close() error
 	// Addr returns the local network addr that the server is listening on.
+	// When listening on port 0, it returns the port that was assigned by the operating system.
 	Addr() net.Addr
 	// Accept returns new sessions. It should be called in a loop.
 	Accept(context.Context) (Session, error)
+	// Shutdown gracefully shuts down the server.
+	// It stops accepting new connections, and waits for all sessions to finish.
+	// If the context is canceled first, the remaining sessions are closed.
+	Shutdown(context.Context) error
 }
 
 // An EarlyListener listens for incoming QUIC connections,
@@ -327,7 +829,12 @@ type EarlyListener interface {
 	// Close the server. All active sessions will be closed.
 	Close() error
 	// Addr returns the local network addr that the server is listening on.
+	// When listening on port 0, it returns the port that was assigned by the operating system.
 	Addr() net.Addr
 	// Accept returns new early sessions. It should be called in a loop.
 	Accept(context.Context) (EarlySession, error)
+	// Shutdown gracefully shuts down the server.
+	// It stops accepting new connections, and waits for all sessions to finish.
+	// If the context is canceled first, the remaining sessions are closed.
+	Shutdown(context.Context) error
 }
@@ -17,7 +17,17 @@ func NewAckHandler(
 	logger utils.Logger,
 	version protocol.VersionNumber,
 	congestionAlgo congestion.CongestionAlgo,
+	hyStartConfig congestion.HyStartConfig,
+	initialCongestionWindowPackets int,
+	initialSlowStartThreshold,
+	locoMaxBytesInFlight protocol.ByteCount,
+	maxSendRate congestion.Bandwidth,
+	clock congestion.Clock,
+	ackElicitingThreshold int,
+	congestionLog *congestion.CongestionLog,
+	onCongestionCollapse func(),
+	probeWithPing bool,
 ) (SentPacketHandler, ReceivedPacketHandler) {
-	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, pers, tracer, logger, congestionAlgo)
-	return sph, newReceivedPacketHandler(sph, rttStats, logger, version)
+	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, pers, tracer, logger, congestionAlgo, hyStartConfig, initialCongestionWindowPackets, initialSlowStartThreshold, locoMaxBytesInFlight, maxSendRate, clock, congestionLog, onCongestionCollapse, probeWithPing)
+	return sph, newReceivedPacketHandler(sph, rttStats, clock, ackElicitingThreshold, logger, version)
 }
@@ -0,0 +1,164 @@
+package ackhandler
+
+import (
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
+	"github.com/BGrewell/quic-go/logging"
+)
+
+// The ecnTracker validates that the path supports ECN, see section 13.4.2 of RFC 9000.
+// When the connection starts, the first protocol.ECNTestingPackets 1-RTT packets are sent with an ECT(0) mark.
+// Only if the peer reports ECN counts for these packets that match what we sent, ECN is used for the rest of the connection.
+// If the marks are bleached or mangled on the path, or all testing packets are lost, packets are sent without ECN marks.
+type ecnTracker struct {
+	state logging.ECNState
+
+	numSentTesting, numLostTesting uint8
+	firstTestingPacket             protocol.PacketNumber
+	lastTestingPacket              protocol.PacketNumber
+
+	// the ECN counts reported by the peer in the last ACK frame
+	ect0, ect1, ecnce uint64
+
+	tracer logging.ConnectionTracer
+	logger utils.Logger
+}
+
+func newECNTracker(logger utils.Logger, tracer logging.ConnectionTracer) *ecnTracker {
+	t := &ecnTracker{
+		state:              logging.ECNStateTesting,
+		firstTestingPacket: protocol.InvalidPacketNumber,
+		lastTestingPacket:  protocol.InvalidPacketNumber,
+		tracer:             tracer,
+		logger:             logger,
+	}
+	if tracer != nil {
+		tracer.UpdatedECNState(logging.ECNStateTesting)
+	}
+	return t
+}
+
+// Mode returns the ECN marking that should be used for the next 1-RTT packet.
+func (t *ecnTracker) Mode() protocol.ECN {
+	//nolint:exhaustive // Packets are only marked when testing ECN, or when ECN validation succeeded.
+	switch t.state {
+	case logging.ECNStateTesting, logging.ECNStateCapable:
+		return protocol.ECT0
+	default:
+		return protocol.ECNNon
+	}
+}
+
+// SentPacket is called for every 1-RTT packet sent.
+func (t *ecnTracker) SentPacket(pn protocol.PacketNumber, ecn protocol.ECN) {
+	if ecn == protocol.ECNNon || t.state != logging.ECNStateTesting {
+		return
+	}
+	t.numSentTesting++
+	if t.firstTestingPacket == protocol.InvalidPacketNumber {
+		t.firstTestingPacket = pn
+	}
+	t.lastTestingPacket = pn
+	if t.numSentTesting >= protocol.ECNTestingPackets {
+		t.logger.Debugf("Sent %d ECN testing packets. Waiting for them to be acknowledged.", t.numSentTesting)
+		t.setState(logging.ECNStateUnknown)
+	}
+}
+
+// LostPacket is called for every 1-RTT packet declared lost.
+// If all testing packets are lost, the path is assumed to drop ECN-marked packets.
+func (t *ecnTracker) LostPacket(pn protocol.PacketNumber) {
+	if t.state != logging.ECNStateTesting && t.state != logging.ECNStateUnknown {
+		return
+	}
+	if !t.isTestingPacket(pn) {
+		return
+	}
+	t.numLostTesting++
+	if t.state == logging.ECNStateUnknown && t.numLostTesting >= t.numSentTesting {
+		t.logger.Debugf("All %d ECN testing packets were lost. Disabling ECN.", t.numSentTesting)
+		t.setState(logging.ECNStateFailed)
+	}
+}
+
+// HandleNewlyAcked validates the ECN counts of an ACK frame against the packets it newly acknowledges.
+// It must only be called for ACK frames that increase the largest acknowledged packet number,
+// since reordered ACK frames might report outdated counts.
+// It returns true if the peer reported an increase of the ECN-CE count, and ECN validation succeeded.
+func (t *ecnTracker) HandleNewlyAcked(packets []*Packet, ect0, ect1, ecnce uint64) (congested bool) {
+	if t.state == logging.ECNStateFailed {
+		// Keep track of the counts, so they can be used as a baseline if validation is restarted on a new path.
+		if ect0 >= t.ect0 && ect1 >= t.ect1 && ecnce >= t.ecnce {
+			t.ect0, t.ect1, t.ecnce = ect0, ect1, ecnce
+		}
+		return false
+	}
+
+	var numAckedECT0 uint64
+	var ackedTestingPacket bool
+	for _, p := range packets {
+		if p.ECN != protocol.ECT0 {
+			continue
+		}
+		numAckedECT0++
+		if t.isTestingPacket(p.PacketNumber) {
+			ackedTestingPacket = true
+		}
+	}
+
+	// ECN counts are cumulative, they can never decrease.
+	if ect0 < t.ect0 || ect1 < t.ect1 || ecnce < t.ecnce {
+		t.logger.Debugf("ECN counts decreased. Disabling ECN.")
+		t.setState(logging.ECNStateFailed)
+		return false
+	}
+	// We never send packets marked with ECT(1).
+	if ect1 > 0 {
+		t.logger.Debugf("Peer reported ECT(1) marked packets. Disabling ECN.")
+		t.setState(logging.ECNStateFailed)
+		return false
+	}
+	// Every ECT(0) marked packet has to be reported as either ECT(0) or ECN-CE.
+	// This also catches paths that bleach the ECN marks: the peer then doesn't report any ECN counts.
+	newECT0 := ect0 - t.ect0
+	newECNCE := ecnce - t.ecnce
+	if newECT0+newECNCE < numAckedECT0 {
+		t.logger.Debugf("ECN counts don't match the number of acknowledged ECT(0) marked packets. Disabling ECN.")
+		t.setState(logging.ECNStateFailed)
+		return false
+	}
+	t.ect0 = ect0
+	t.ect1 = ect1
+	t.ecnce = ecnce
+
+	if ackedTestingPacket && t.state != logging.ECNStateCapable {
+		t.logger.Debugf("ECN validation succeeded.")
+		t.setState(logging.ECNStateCapable)
+	}
+	return t.state == logging.ECNStateCapable && newECNCE > 0
+}
+
+// Reset restarts ECN validation. It is called when the connection is migrated to a new path,
+// since the new path might treat ECN marks differently than the old one.
+// The ECN counts reported by the peer are cumulative for the whole connection, so they are kept.
+func (t *ecnTracker) Reset() {
+	t.numSentTesting = 0
+	t.numLostTesting = 0
+	t.firstTestingPacket = protocol.InvalidPacketNumber
+	t.lastTestingPacket = protocol.InvalidPacketNumber
+	t.setState(logging.ECNStateTesting)
+}
+
+func (t *ecnTracker) isTestingPacket(pn protocol.PacketNumber) bool {
+	if t.firstTestingPacket == protocol.InvalidPacketNumber {
+		return false
+	}
+	return pn >= t.firstTestingPacket && pn <= t.lastTestingPacket
+}
+
+func (t *ecnTracker) setState(state logging.ECNState) {
+	t.state = state
+	if t.tracer != nil {
+		t.tracer.UpdatedECNState(state)
+	}
+}
@@ -0,0 +1,169 @@
+package ackhandler
+
+import (
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ECN tracker", func() {
+	var (
+		ecnTracker *ecnTracker
+		tracer     *mocklogging.MockConnectionTracer
+	)
+
+	getPackets := func(ecn protocol.ECN, pns ...protocol.PacketNumber) []*Packet {
+		var packets []*Packet
+		for _, pn := range pns {
+			packets = append(packets, &Packet{PacketNumber: pn, ECN: ecn})
+		}
+		return packets
+	}
+
+	// sendTestingPackets sends all testing packets, starting at packet number 0
+	sendTestingPackets := func() {
+		for i := 0; i < protocol.ECNTestingPackets; i++ {
+			Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+			if i == protocol.ECNTestingPackets-1 {
+				tracer.EXPECT().UpdatedECNState(logging.ECNStateUnknown)
+			}
+			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECT0)
+		}
+	}
+
+	BeforeEach(func() {
+		tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateTesting)
+		ecnTracker = newECNTracker(utils.DefaultLogger, tracer)
+	})
+
+	It("sends a limited number of testing packets", func() {
+		sendTestingPackets()
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+		ecnTracker.SentPacket(protocol.ECNTestingPackets, protocol.ECNNon)
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("enables ECN if the path preserves the marks", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 3, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 3, 4, 5, 6, 7, 8, 9), 10, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+	})
+
+	It("enables ECN before all testing packets were sent", func() {
+		for i := 0; i < 3; i++ {
+			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECT0)
+		}
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1), 2, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+	})
+
+	It("disables ECN if the path bleaches the marks", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 0, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+		// once validation failed, ECN counts are ignored
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 3, 4), 5, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("disables ECN if the peer reports less ECT(0) marked packets than were acknowledged", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 2, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("disables ECN if the peer reports ECT(1) marked packets", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 3, 1, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("disables ECN if the ECN counts decrease", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 3, 0, 0)).To(BeFalse())
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 3), 2, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("disables ECN if all testing packets are lost", func() {
+		sendTestingPackets()
+		for i := 0; i < protocol.ECNTestingPackets; i++ {
+			if i == protocol.ECNTestingPackets-1 {
+				tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+			}
+			ecnTracker.LostPacket(protocol.PacketNumber(i))
+		}
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	It("doesn't disable ECN if only some testing packets are lost", func() {
+		sendTestingPackets()
+		for i := 0; i < protocol.ECNTestingPackets-1; i++ {
+			ecnTracker.LostPacket(protocol.PacketNumber(i))
+		}
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, protocol.ECNTestingPackets-1), 1, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+	})
+
+	It("counts ECN-CE marks as valid", func() {
+		sendTestingPackets()
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 2, 0, 1)).To(BeTrue())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+		// no new CE marks
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 3, 4), 4, 0, 1)).To(BeFalse())
+	})
+
+	It("ignores unmarked packets", func() {
+		sendTestingPackets()
+		Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECNNon, 10, 11), 0, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+	})
+
+	Context("restarting validation", func() {
+		It("validates ECN again after a reset", func() {
+			sendTestingPackets()
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+			Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 3, 0, 0)).To(BeFalse())
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateTesting)
+			ecnTracker.Reset()
+			Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+			ecnTracker.SentPacket(10, protocol.ECT0)
+			ecnTracker.SentPacket(11, protocol.ECT0)
+			// the new path bleaches the marks
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+			Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 10, 11), 3, 0, 0)).To(BeFalse())
+			Expect(ecnTracker.Mode()).To(Equal(protocol.ECNNon))
+		})
+
+		It("uses the ECN counts reported after validation failed as a baseline", func() {
+			sendTestingPackets()
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateFailed)
+			Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 0, 1, 2), 0, 0, 0)).To(BeFalse())
+			// ECN-CE marks reported while ECN was disabled
+			Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 3), 0, 0, 5)).To(BeFalse())
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateTesting)
+			ecnTracker.Reset()
+			ecnTracker.SentPacket(20, protocol.ECT0)
+			tracer.EXPECT().UpdatedECNState(logging.ECNStateCapable)
+			// The ECN-CE count didn't increase since the reset, so this is not a congestion signal.
+			Expect(ecnTracker.HandleNewlyAcked(getPackets(protocol.ECT0, 20), 1, 0, 5)).To(BeFalse())
+			Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/wire"
 )
@@ -15,14 +16,26 @@ type Packet struct {
 	Length          protocol.ByteCount
 	EncryptionLevel protocol.EncryptionLevel
 	SendTime        time.Time
+	ECN             protocol.ECN
 
 	IsPathMTUProbePacket bool // We don't report the loss of Path MTU probe packets to the congestion controller.
+	IsPathProbePacket    bool // Path probe packets are sent on a new path. They don't affect the congestion controller and the RTT of the active path.
 
 	includedInBytesInFlight bool
 	declaredLost            bool
 	skippedPacket           bool
 }
 
+// Stats are statistics about the congestion controller and the RTT estimation
+type Stats struct {
+	SmoothedRTT       time.Duration
+	MinRTT            time.Duration
+	LatestRTT         time.Duration
+	CongestionWindow  protocol.ByteCount
+	BytesInFlight     protocol.ByteCount
+	BandwidthEstimate congestion.Bandwidth
+}
+
 // SentPacketHandler handles ACKs received for outgoing packets
 type SentPacketHandler interface {
 	// SentPacket may modify the packet
@@ -41,6 +54,12 @@ type SentPacketHandler interface {
 	// HasPacingBudget says if the pacer allows sending of a (full size) packet at this moment.
 	HasPacingBudget() bool
 	SetMaxDatagramSize(count protocol.ByteCount)
+	// ECNMode is the ECN marking that should be used for the next 1-RTT packet.
+	ECNMode() protocol.ECN
+	// MigratedPath is called when the connection switched to a new path.
+	// It resets the RTT estimate and the congestion controller, and restarts ECN validation.
+	// The max datagram size is reset to the value used on the new path.
+	MigratedPath(maxDatagramSize protocol.ByteCount)
 
 	// only to be called once the handshake is complete
 	QueueProbePacket(protocol.EncryptionLevel) bool /* was a packet queued */
@@ -50,6 +69,16 @@ type SentPacketHandler interface {
 
 	GetLossDetectionTimeout() time.Time
 	OnLossDetectionTimeout() error
+
+	// CongestionControl returns the congestion control algorithm in use.
+	CongestionControl() congestion.CongestionAlgo
+	// GetStats returns the latest statistics.
+	// It is safe to call this function concurrently with all other functions.
+	GetStats() Stats
+	// LargestAcked returns the largest packet number acknowledged by the peer in the packet number space of encLevel.
+	// It returns InvalidPacketNumber if no packet was acknowledged in that space yet.
+	// It is safe to call this function concurrently with all other functions.
+	LargestAcked(encLevel protocol.EncryptionLevel) protocol.PacketNumber
 }
 
 type sentPacketTracker interface {
@@ -65,4 +94,8 @@ type ReceivedPacketHandler interface {
 
 	GetAlarmTimeout() time.Time
 	GetAckFrame(encLevel protocol.EncryptionLevel, onlyIfQueued bool) *wire.AckFrame
+
+	// ReorderingStats returns statistics about reordering of 0-RTT and 1-RTT packets.
+	// It is safe to call this function concurrently with all other functions.
+	ReorderingStats() (maxReorder int, reorderedPackets uint64)
 }
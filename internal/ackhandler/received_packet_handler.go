@@ -2,8 +2,10 @@ package ackhandler
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
@@ -17,6 +19,10 @@ type receivedPacketHandler struct {
 	appDataPackets   *receivedPacketTracker
 
 	lowest1RTTPacket protocol.PacketNumber
+
+	statsMutex       sync.Mutex
+	maxReorder       protocol.PacketNumber
+	reorderedPackets uint64
 }
 
 var _ ReceivedPacketHandler = &receivedPacketHandler{}
@@ -24,14 +30,17 @@ var _ ReceivedPacketHandler = &receivedPacketHandler{}
 func newReceivedPacketHandler(
 	sentPackets sentPacketTracker,
 	rttStats *utils.RTTStats,
+	clock congestion.Clock,
+	ackElicitingThreshold int,
 	logger utils.Logger,
 	version protocol.VersionNumber,
 ) ReceivedPacketHandler {
 	return &receivedPacketHandler{
-		sentPackets:      sentPackets,
-		initialPackets:   newReceivedPacketTracker(rttStats, logger, version),
-		handshakePackets: newReceivedPacketTracker(rttStats, logger, version),
-		appDataPackets:   newReceivedPacketTracker(rttStats, logger, version),
+		sentPackets: sentPackets,
+		// Initial and Handshake packets are always acknowledged using the default threshold.
+		initialPackets:   newReceivedPacketTracker(rttStats, clock, protocol.DefaultAckElicitingThreshold, logger, version),
+		handshakePackets: newReceivedPacketTracker(rttStats, clock, protocol.DefaultAckElicitingThreshold, logger, version),
+		appDataPackets:   newReceivedPacketTracker(rttStats, clock, ackElicitingThreshold, logger, version),
 		lowest1RTTPacket: protocol.InvalidPacketNumber,
 	}
 }
@@ -54,18 +63,35 @@ func (h *receivedPacketHandler) ReceivedPacket(
 			return fmt.Errorf("received packet number %d on a 0-RTT packet after receiving %d on a 1-RTT packet", pn, h.lowest1RTTPacket)
 		}
 		h.appDataPackets.ReceivedPacket(pn, ecn, rcvTime, shouldInstigateAck)
+		h.updateReorderingStats()
 	case protocol.Encryption1RTT:
 		if h.lowest1RTTPacket == protocol.InvalidPacketNumber || pn < h.lowest1RTTPacket {
 			h.lowest1RTTPacket = pn
 		}
 		h.appDataPackets.IgnoreBelow(h.sentPackets.GetLowestPacketNotConfirmedAcked())
 		h.appDataPackets.ReceivedPacket(pn, ecn, rcvTime, shouldInstigateAck)
+		h.updateReorderingStats()
 	default:
 		panic(fmt.Sprintf("received packet with unknown encryption level: %s", encLevel))
 	}
 	return nil
 }
 
+// updateReorderingStats copies the reordering statistics of the application data packet number space,
+// such that they can be read concurrently.
+func (h *receivedPacketHandler) updateReorderingStats() {
+	h.statsMutex.Lock()
+	h.maxReorder = h.appDataPackets.maxReorder
+	h.reorderedPackets = h.appDataPackets.reorderedPackets
+	h.statsMutex.Unlock()
+}
+
+func (h *receivedPacketHandler) ReorderingStats() (maxReorder int, reorderedPackets uint64) {
+	h.statsMutex.Lock()
+	defer h.statsMutex.Unlock()
+	return int(h.maxReorder), h.reorderedPackets
+}
+
 func (h *receivedPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 	//nolint:exhaustive // 1-RTT packet number space is never dropped.
 	switch encLevel {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
@@ -22,6 +23,8 @@ var _ = Describe("Received Packet Handler", func() {
 		handler = newReceivedPacketHandler(
 			sentPackets,
 			&utils.RTTStats{},
+			congestion.DefaultClock{},
+			protocol.DefaultAckElicitingThreshold,
 			utils.DefaultLogger,
 			protocol.VersionWhatever,
 		)
@@ -65,6 +68,28 @@ var _ = Describe("Received Packet Handler", func() {
 		Expect(oneRTTAck.ECNCE).To(BeEquivalentTo(2))
 	})
 
+	It("uses the configured ack-eliciting threshold for 1-RTT packets only", func() {
+		handler = newReceivedPacketHandler(sentPackets, &utils.RTTStats{}, congestion.DefaultClock{}, 3, utils.DefaultLogger, protocol.VersionWhatever)
+		sentPackets.EXPECT().GetLowestPacketNotConfirmedAcked().AnyTimes()
+		sentPackets.EXPECT().ReceivedPacket(gomock.Any()).AnyTimes()
+		now := time.Now()
+		// the first packet is always acknowledged
+		Expect(handler.ReceivedPacket(1, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.Encryption1RTT, true)).ToNot(BeNil())
+		Expect(handler.ReceivedPacket(2, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(3, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.Encryption1RTT, true)).To(BeNil())
+		Expect(handler.ReceivedPacket(4, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.Encryption1RTT, true)).ToNot(BeNil())
+		// Handshake packets are acknowledged every 2 packets
+		Expect(handler.ReceivedPacket(1, protocol.ECNNon, protocol.EncryptionHandshake, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.EncryptionHandshake, true)).ToNot(BeNil())
+		Expect(handler.ReceivedPacket(2, protocol.ECNNon, protocol.EncryptionHandshake, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.EncryptionHandshake, true)).To(BeNil())
+		Expect(handler.ReceivedPacket(3, protocol.ECNNon, protocol.EncryptionHandshake, now, true)).To(Succeed())
+		Expect(handler.GetAckFrame(protocol.EncryptionHandshake, true)).ToNot(BeNil())
+	})
+
 	It("uses the same packet number space for 0-RTT and 1-RTT packets", func() {
 		sentPackets.EXPECT().GetLowestPacketNotConfirmedAcked().AnyTimes()
 		sentPackets.EXPECT().ReceivedPacket(protocol.Encryption0RTT)
@@ -96,6 +121,26 @@ var _ = Describe("Received Packet Handler", func() {
 		Expect(handler.ReceivedPacket(11, protocol.ECNNon, protocol.Encryption0RTT, sendTime, true)).To(Succeed())
 	})
 
+	It("reports reordering statistics for 0-RTT and 1-RTT packets", func() {
+		sentPackets.EXPECT().ReceivedPacket(gomock.Any()).AnyTimes()
+		sentPackets.EXPECT().GetLowestPacketNotConfirmedAcked().AnyTimes()
+		sendTime := time.Now()
+		// reordering of Initial packets is not reported
+		Expect(handler.ReceivedPacket(3, protocol.ECNNon, protocol.EncryptionInitial, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(1, protocol.ECNNon, protocol.EncryptionInitial, sendTime, true)).To(Succeed())
+		maxReorder, reordered := handler.ReorderingStats()
+		Expect(maxReorder).To(BeZero())
+		Expect(reordered).To(BeZero())
+		Expect(handler.ReceivedPacket(2, protocol.ECNNon, protocol.Encryption0RTT, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(1, protocol.ECNNon, protocol.Encryption0RTT, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(8, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(5, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(7, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		maxReorder, reordered = handler.ReorderingStats()
+		Expect(maxReorder).To(Equal(3))
+		Expect(reordered).To(BeEquivalentTo(3))
+	})
+
 	It("drops Initial packets", func() {
 		sentPackets.EXPECT().ReceivedPacket(gomock.Any()).Times(2)
 		sendTime := time.Now().Add(-time.Second)
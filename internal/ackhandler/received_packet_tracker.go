@@ -3,28 +3,34 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
 )
 
-// number of ack-eliciting packets received before sending an ack.
-const packetsBeforeAck = 2
-
 type receivedPacketTracker struct {
 	largestObserved             protocol.PacketNumber
 	ignoreBelow                 protocol.PacketNumber
 	largestObservedReceivedTime time.Time
 	ect0, ect1, ecnce           uint64
 
+	// maxReorder is the largest number of packets that a packet arrived late by.
+	// reorderedPackets is the number of packets that arrived after a packet with a higher packet number.
+	maxReorder       protocol.PacketNumber
+	reorderedPackets uint64
+
 	packetHistory *receivedPacketHistory
 
 	maxAckDelay time.Duration
 	rttStats    *utils.RTTStats
+	clock       congestion.Clock
 
 	hasNewAck bool // true as soon as we received an ack-eliciting new packet
 	ackQueued bool // true once we received more than 2 (or later in the connection 10) ack-eliciting packets
 
+	// number of ack-eliciting packets received before sending an ack
+	ackElicitingThreshold                   int
 	ackElicitingPacketsReceivedSinceLastAck int
 	ackAlarm                                time.Time
 	lastAck                                 *wire.AckFrame
@@ -36,15 +42,19 @@ type receivedPacketTracker struct {
 
 func newReceivedPacketTracker(
 	rttStats *utils.RTTStats,
+	clock congestion.Clock,
+	ackElicitingThreshold int,
 	logger utils.Logger,
 	version protocol.VersionNumber,
 ) *receivedPacketTracker {
 	return &receivedPacketTracker{
-		packetHistory: newReceivedPacketHistory(),
-		maxAckDelay:   protocol.MaxAckDelay,
-		rttStats:      rttStats,
-		logger:        logger,
-		version:       version,
+		packetHistory:         newReceivedPacketHistory(),
+		maxAckDelay:           protocol.MaxAckDelay,
+		ackElicitingThreshold: ackElicitingThreshold,
+		rttStats:              rttStats,
+		clock:                 clock,
+		logger:                logger,
+		version:               version,
 	}
 }
 
@@ -54,14 +64,22 @@ func (h *receivedPacketTracker) ReceivedPacket(packetNumber protocol.PacketNumbe
 	}
 
 	isMissing := h.isMissing(packetNumber)
+	largestObserved := h.largestObserved
 	if packetNumber >= h.largestObserved {
 		h.largestObserved = packetNumber
 		h.largestObservedReceivedTime = rcvTime
 	}
 
-	if isNew := h.packetHistory.ReceivedPacket(packetNumber); isNew && shouldInstigateAck {
+	isNew := h.packetHistory.ReceivedPacket(packetNumber)
+	if isNew && shouldInstigateAck {
 		h.hasNewAck = true
 	}
+	if isNew && packetNumber < largestObserved {
+		h.reorderedPackets++
+		if reorder := largestObserved - packetNumber; reorder > h.maxReorder {
+			h.maxReorder = reorder
+		}
+	}
 	if shouldInstigateAck {
 		h.maybeQueueAck(packetNumber, rcvTime, isMissing)
 	}
@@ -132,10 +150,10 @@ func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime
 		h.ackQueued = true
 	}
 
-	// send an ACK every 2 ack-eliciting packets
-	if h.ackElicitingPacketsReceivedSinceLastAck >= packetsBeforeAck {
+	// send an ACK every ackElicitingThreshold (by default: 2) ack-eliciting packets
+	if h.ackElicitingPacketsReceivedSinceLastAck >= h.ackElicitingThreshold {
 		if h.logger.Debug() {
-			h.logger.Debugf("\tQueueing ACK because packet %d packets were received after the last ACK (using initial threshold: %d).", h.ackElicitingPacketsReceivedSinceLastAck, packetsBeforeAck)
+			h.logger.Debugf("\tQueueing ACK because packet %d packets were received after the last ACK (using threshold: %d).", h.ackElicitingPacketsReceivedSinceLastAck, h.ackElicitingThreshold)
 		}
 		h.ackQueued = true
 	} else if h.ackAlarm.IsZero() {
@@ -161,7 +179,7 @@ func (h *receivedPacketTracker) GetAckFrame(onlyIfQueued bool) *wire.AckFrame {
 	if !h.hasNewAck {
 		return nil
 	}
-	now := time.Now()
+	now := h.clock.Now()
 	if onlyIfQueued {
 		if !h.ackQueued && (h.ackAlarm.IsZero() || h.ackAlarm.After(now)) {
 			return nil
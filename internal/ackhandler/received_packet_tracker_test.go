@@ -3,6 +3,7 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
@@ -19,7 +20,7 @@ var _ = Describe("Received Packet Tracker", func() {
 
 	BeforeEach(func() {
 		rttStats = &utils.RTTStats{}
-		tracker = newReceivedPacketTracker(rttStats, utils.DefaultLogger, protocol.VersionWhatever)
+		tracker = newReceivedPacketTracker(rttStats, congestion.DefaultClock{}, protocol.DefaultAckElicitingThreshold, utils.DefaultLogger, protocol.VersionWhatever)
 	})
 
 	Context("accepting packets", func() {
@@ -46,6 +47,22 @@ var _ = Describe("Received Packet Tracker", func() {
 			Expect(tracker.largestObserved).To(Equal(protocol.PacketNumber(5)))
 			Expect(tracker.largestObservedReceivedTime).To(Equal(timestamp))
 		})
+
+		It("tracks how far out of order packets arrive", func() {
+			for _, pn := range []protocol.PacketNumber{1, 2, 5, 3, 4, 10, 6, 11} {
+				tracker.ReceivedPacket(pn, protocol.ECNNon, time.Now(), true)
+			}
+			Expect(tracker.reorderedPackets).To(BeEquivalentTo(3))
+			Expect(tracker.maxReorder).To(Equal(protocol.PacketNumber(4)))
+		})
+
+		It("doesn't count duplicate packets as reordered", func() {
+			for _, pn := range []protocol.PacketNumber{1, 3, 2, 2, 1} {
+				tracker.ReceivedPacket(pn, protocol.ECNNon, time.Now(), true)
+			}
+			Expect(tracker.reorderedPackets).To(BeEquivalentTo(1))
+			Expect(tracker.maxReorder).To(Equal(protocol.PacketNumber(1)))
+		})
 	})
 
 	Context("ACKs", func() {
@@ -104,6 +121,39 @@ var _ = Describe("Received Packet Tracker", func() {
 				}
 			})
 
+			Context("with a configured ack-eliciting threshold", func() {
+				BeforeEach(func() {
+					tracker = newReceivedPacketTracker(rttStats, congestion.DefaultClock{}, 5, utils.DefaultLogger, protocol.VersionWhatever)
+				})
+
+				It("queues an ACK for every fifth ack-eliciting packet", func() {
+					receiveAndAck10Packets()
+					p := protocol.PacketNumber(11)
+					for i := 0; i <= 10; i++ {
+						for j := 0; j < 4; j++ {
+							tracker.ReceivedPacket(p, protocol.ECNNon, time.Time{}, true)
+							Expect(tracker.ackQueued).To(BeFalse())
+							p++
+						}
+						tracker.ReceivedPacket(p, protocol.ECNNon, time.Time{}, true)
+						Expect(tracker.ackQueued).To(BeTrue())
+						p++
+						// dequeue the ACK frame
+						Expect(tracker.GetAckFrame(true)).ToNot(BeNil())
+					}
+				})
+
+				It("queues an ACK immediately if packets are reordered", func() {
+					receiveAndAck10Packets()
+					// 11 is missing
+					tracker.ReceivedPacket(12, protocol.ECNNon, time.Now(), true)
+					Expect(tracker.ackQueued).To(BeTrue())
+					Expect(tracker.GetAckFrame(true)).ToNot(BeNil()) // ACK: 1-10, 12
+					tracker.ReceivedPacket(11, protocol.ECNNon, time.Now(), true)
+					Expect(tracker.ackQueued).To(BeTrue())
+				})
+			})
+
 			It("resets the counter when a non-queued ACK frame is generated", func() {
 				receiveAndAck10Packets()
 				rcvTime := time.Now()
@@ -3,6 +3,8 @@ package ackhandler
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/congestion"
@@ -77,8 +79,13 @@ type sentPacketHandler struct {
 
 	bytesInFlight protocol.ByteCount
 
+	// The largest acknowledged packet number of the Initial, Handshake and application data packet number space.
+	largestAcked [3]int64 // accessed atomically
+
 	congestion congestion.SendAlgorithmWithDebugInfos
 	rttStats   *utils.RTTStats
+	clock      congestion.Clock
+	ecnTracker *ecnTracker
 
 	// The number of times a PTO has been sent without receiving an ack.
 	ptoCount uint32
@@ -86,10 +93,16 @@ type sentPacketHandler struct {
 	// The number of PTO probe packets that should be sent.
 	// Only applies to the application-data packet number space.
 	numProbesToSend int
+	// If set, PTO probe packets only contain a PING frame, instead of retransmitting outstanding data.
+	probeWithPing bool
 
 	// The alarm timeout
 	alarm time.Time
 
+	// A snapshot of the statistics, so they can be read without accessing the run loop state.
+	statsMutex sync.Mutex
+	stats      Stats
+
 	perspective protocol.Perspective
 
 	tracer logging.ConnectionTracer
@@ -109,41 +122,70 @@ func newSentPacketHandler(
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	congestionAlgo congestion.CongestionAlgo,
+	hyStartConfig congestion.HyStartConfig,
+	initialCongestionWindowPackets int,
+	initialSlowStartThreshold,
+	locoMaxBytesInFlight protocol.ByteCount,
+	maxSendRate congestion.Bandwidth,
+	clock congestion.Clock,
+	congestionLog *congestion.CongestionLog,
+	onCongestionCollapse func(),
+	probeWithPing bool,
 ) *sentPacketHandler {
 	var congestionCtrl congestion.SendAlgorithmWithDebugInfos
 	switch congestionAlgo {
-	case congestion.ALGO_CUBIC:
+	case congestion.ALGO_CUBIC, congestion.ALGO_RENO, congestion.ALGO_CUBIC_RFC8312:
 		congestionCtrl = congestion.NewCubicSender(
-			congestion.DefaultClock{},
+			clock,
 			rttStats,
 			initialMaxDatagramSize,
-			true, // use Reno
+			congestionAlgo,
+			hyStartConfig,
+			initialCongestionWindowPackets,
+			initialSlowStartThreshold,
 			tracer,
+			congestionLog,
+			onCongestionCollapse,
 		)
 	case congestion.ALGO_LOCO:
 		congestionCtrl = congestion.NewLocoSender(
-			congestion.DefaultClock{},
+			clock,
 			rttStats,
 			initialMaxDatagramSize,
 			true, // use Reno
+			hyStartConfig,
+			initialCongestionWindowPackets,
+			locoMaxBytesInFlight,
 			tracer,
+			congestionLog,
 		)
 	default:
 		panic(fmt.Sprintf("Unknown congestion control algorithm %d", congestionAlgo))
 	}
+	if maxSendRate > 0 {
+		congestionCtrl = congestion.NewRateLimitedSender(congestionCtrl, clock, initialMaxDatagramSize, maxSendRate)
+	}
 
-	return &sentPacketHandler{
+	h := &sentPacketHandler{
 		peerCompletedAddressValidation: pers == protocol.PerspectiveServer,
 		peerAddressValidated:           pers == protocol.PerspectiveClient,
 		initialPackets:                 newPacketNumberSpace(initialPN, false, rttStats),
 		handshakePackets:               newPacketNumberSpace(0, false, rttStats),
 		appDataPackets:                 newPacketNumberSpace(0, true, rttStats),
 		rttStats:                       rttStats,
+		clock:                          clock,
 		congestion:                     congestionCtrl,
+		ecnTracker:                     newECNTracker(logger, tracer),
+		probeWithPing:                  probeWithPing,
 		perspective:                    pers,
 		tracer:                         tracer,
 		logger:                         logger,
 	}
+	for i := range h.largestAcked {
+		h.largestAcked[i] = int64(protocol.InvalidPacketNumber)
+	}
+	h.updateStats()
+	return h
 }
 
 func (h *sentPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
@@ -154,6 +196,7 @@ func (h *sentPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 		return
 	}
 	h.dropPackets(encLevel)
+	h.updateStats()
 }
 
 func (h *sentPacketHandler) removeFromBytesInFlight(p *Packet) {
@@ -245,9 +288,15 @@ func (h *sentPacketHandler) SentPacket(packet *Packet) {
 		h.dropPackets(protocol.EncryptionInitial)
 	}
 	isAckEliciting := h.sentPacketImpl(packet)
+	if packet.EncryptionLevel == protocol.Encryption1RTT {
+		h.ecnTracker.SentPacket(packet.PacketNumber, packet.ECN)
+	}
 	h.getPacketNumberSpace(packet.EncryptionLevel).history.SentPacket(packet, isAckEliciting)
-	if h.tracer != nil && isAckEliciting {
-		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
+	if isAckEliciting {
+		h.updateStats()
+		if h.tracer != nil {
+			h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.congestion.GetSlowStartThreshold(), h.packetsInFlight())
+		}
 	}
 	if isAckEliciting || !h.peerCompletedAddressValidation {
 		h.setLossDetectionTimer()
@@ -278,6 +327,9 @@ func (h *sentPacketHandler) sentPacketImpl(packet *Packet) bool /* is ack-elicit
 
 	pnSpace.largestSent = packet.PacketNumber
 	isAckEliciting := len(packet.Frames) > 0
+	if packet.IsPathProbePacket {
+		return isAckEliciting
+	}
 
 	if isAckEliciting {
 		pnSpace.lastAckElicitingPacketTime = packet.SendTime
@@ -304,6 +356,9 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	}
 
 	pnSpace.largestAcked = utils.MaxPacketNumber(pnSpace.largestAcked, largestAcked)
+	if i, ok := largestAckedIndex(encLevel); ok {
+		atomic.StoreInt64(&h.largestAcked[i], int64(pnSpace.largestAcked))
+	}
 
 	// Servers complete address validation when a protected packet is received.
 	if h.perspective == protocol.PerspectiveClient && !h.peerCompletedAddressValidation &&
@@ -321,7 +376,7 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	}
 	// update the RTT, if the largest acked is newly acknowledged
 	if len(ackedPackets) > 0 {
-		if p := ackedPackets[len(ackedPackets)-1]; p.PacketNumber == ack.LargestAcked() {
+		if p := ackedPackets[len(ackedPackets)-1]; p.PacketNumber == ack.LargestAcked() && !p.IsPathProbePacket {
 			// don't use the ack delay for Initial and Handshake packets
 			var ackDelay time.Duration
 			if encLevel == protocol.Encryption1RTT {
@@ -332,6 +387,11 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 				h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 			}
 			h.congestion.MaybeExitSlowStart()
+			if encLevel == protocol.Encryption1RTT && h.ecnTracker.HandleNewlyAcked(ackedPackets, ack.ECT0, ack.ECT1, ack.ECNCE) {
+				// The peer reported that a packet was marked with ECN-CE.
+				// The congestion controller reacts to this the same way as to a lost packet (see section 7.1 of RFC 9002).
+				h.congestion.OnPacketLost(p.PacketNumber, 0, priorInFlight)
+			}
 		}
 	}
 	if err := h.detectLostPackets(rcvTime, encLevel); err != nil {
@@ -357,8 +417,9 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	}
 	h.numProbesToSend = 0
 
+	h.updateStats()
 	if h.tracer != nil {
-		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
+		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.congestion.GetSlowStartThreshold(), h.packetsInFlight())
 	}
 
 	pnSpace.history.DeleteOldPackets(rcvTime)
@@ -467,7 +528,7 @@ func (h *sentPacketHandler) getPTOTimeAndSpace() (pto time.Time, encLevel protoc
 		if h.peerCompletedAddressValidation {
 			return
 		}
-		t := time.Now().Add(h.rttStats.PTO(false) << h.ptoCount)
+		t := h.clock.Now().Add(h.rttStats.PTO(false) << h.ptoCount)
 		if h.initialPackets != nil {
 			return t, protocol.EncryptionInitial, true
 		}
@@ -617,7 +678,10 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 			// the bytes in flight need to be reduced no matter if the frames in this packet will be retransmitted
 			h.removeFromBytesInFlight(p)
 			h.queueFramesForRetransmission(p)
-			if !p.IsPathMTUProbePacket {
+			if p.EncryptionLevel == protocol.Encryption1RTT {
+				h.ecnTracker.LostPacket(p.PacketNumber)
+			}
+			if !p.IsPathMTUProbePacket && !p.IsPathProbePacket {
 				h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
 			}
 		}
@@ -626,6 +690,7 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 }
 
 func (h *sentPacketHandler) OnLossDetectionTimeout() error {
+	defer h.updateStats()
 	defer h.setLossDetectionTimer()
 	earliestLossTime, encLevel := h.getLossTimeAndSpace()
 	if !earliestLossTime.IsZero() {
@@ -636,7 +701,13 @@ func (h *sentPacketHandler) OnLossDetectionTimeout() error {
 			h.tracer.LossTimerExpired(logging.TimerTypeACK, encLevel)
 		}
 		// Early retransmit or time loss detection
-		return h.detectLostPackets(time.Now(), encLevel)
+		if err := h.detectLostPackets(h.clock.Now(), encLevel); err != nil {
+			return err
+		}
+		if h.tracer != nil {
+			h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.congestion.GetSlowStartThreshold(), h.packetsInFlight())
+		}
+		return nil
 	}
 
 	// PTO
@@ -765,6 +836,66 @@ func (h *sentPacketHandler) SetMaxDatagramSize(s protocol.ByteCount) {
 	h.congestion.SetMaxDatagramSize(s)
 }
 
+func (h *sentPacketHandler) ECNMode() protocol.ECN {
+	return h.ecnTracker.Mode()
+}
+
+func (h *sentPacketHandler) MigratedPath(maxDatagramSize protocol.ByteCount) {
+	h.rttStats.OnConnectionMigration()
+	h.congestion.OnConnectionMigration(maxDatagramSize)
+	h.ecnTracker.Reset()
+	h.updateStats()
+}
+
+// updateStats takes a snapshot of the statistics.
+// It must be called every time one of the values might have changed.
+func (h *sentPacketHandler) updateStats() {
+	h.statsMutex.Lock()
+	h.stats = Stats{
+		SmoothedRTT:       h.rttStats.SmoothedRTT(),
+		MinRTT:            h.rttStats.MinRTT(),
+		LatestRTT:         h.rttStats.LatestRTT(),
+		CongestionWindow:  h.congestion.GetCongestionWindow(),
+		BytesInFlight:     h.bytesInFlight,
+		BandwidthEstimate: h.congestion.BandwidthEstimate(),
+	}
+	h.statsMutex.Unlock()
+}
+
+func (h *sentPacketHandler) GetStats() Stats {
+	h.statsMutex.Lock()
+	defer h.statsMutex.Unlock()
+	return h.stats
+}
+
+func (h *sentPacketHandler) LargestAcked(encLevel protocol.EncryptionLevel) protocol.PacketNumber {
+	i, ok := largestAckedIndex(encLevel)
+	if !ok {
+		return protocol.InvalidPacketNumber
+	}
+	return protocol.PacketNumber(atomic.LoadInt64(&h.largestAcked[i]))
+}
+
+func largestAckedIndex(encLevel protocol.EncryptionLevel) (int, bool) {
+	switch encLevel {
+	case protocol.EncryptionInitial:
+		return 0, true
+	case protocol.EncryptionHandshake:
+		return 1, true
+	case protocol.Encryption0RTT, protocol.Encryption1RTT:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+func (h *sentPacketHandler) CongestionControl() congestion.CongestionAlgo {
+	if r, ok := h.congestion.(congestion.AlgorithmReporter); ok {
+		return r.CongestionAlgo()
+	}
+	return congestion.ALGO_UNKNOWN
+}
+
 func (h *sentPacketHandler) isAmplificationLimited() bool {
 	if h.peerAddressValidated {
 		return false
@@ -773,6 +904,11 @@ func (h *sentPacketHandler) isAmplificationLimited() bool {
 }
 
 func (h *sentPacketHandler) QueueProbePacket(encLevel protocol.EncryptionLevel) bool {
+	// The Initial and Handshake probe packets always retransmit the crypto data,
+	// since a PING doesn't help the peer make progress with the handshake.
+	if h.probeWithPing && encLevel == protocol.Encryption1RTT {
+		return false
+	}
 	pnSpace := h.getPacketNumberSpace(encLevel)
 	p := pnSpace.history.FirstOutstanding()
 	if p == nil {
@@ -822,13 +958,13 @@ func (h *sentPacketHandler) ResetForRetry() error {
 	// Otherwise, we don't know which Initial the Retry was sent in response to.
 	if h.ptoCount == 0 {
 		// Don't set the RTT to a value lower than 5ms here.
-		now := time.Now()
+		now := h.clock.Now()
 		h.rttStats.UpdateRTT(utils.MaxDuration(minRTTAfterRetry, now.Sub(firstPacketSendTime)), 0, now)
 		if h.logger.Debug() {
 			h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 		}
 		if h.tracer != nil {
-			h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
+			h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.congestion.GetSlowStartThreshold(), h.packetsInFlight())
 		}
 	}
 	h.initialPackets = newPacketNumberSpace(h.initialPackets.pns.Pop(), false, h.rttStats)
@@ -842,6 +978,7 @@ func (h *sentPacketHandler) ResetForRetry() error {
 		}
 	}
 	h.ptoCount = 0
+	h.updateStats()
 	return nil
 }
 
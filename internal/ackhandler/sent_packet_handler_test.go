@@ -6,11 +6,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/mocks"
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
+	"github.com/BGrewell/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -29,7 +32,7 @@ var _ = Describe("SentPacketHandler", func() {
 	JustBeforeEach(func() {
 		lostPackets = nil
 		rttStats := utils.NewRTTStats()
-		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, perspective, nil, utils.DefaultLogger)
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, perspective, nil, utils.DefaultLogger, congestion.ALGO_CUBIC, congestion.HyStartConfig{}, 0, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
 		streamFrame = wire.StreamFrame{
 			StreamID: 5,
 			Data:     []byte{0x13, 0x37},
@@ -227,6 +230,35 @@ var _ = Describe("SentPacketHandler", func() {
 				Expect(handler.appDataPackets.largestAcked).To(Equal(protocol.PacketNumber(3)))
 				Expect(handler.bytesInFlight).To(Equal(protocol.ByteCount(6)))
 			})
+
+			It("reports the largest acked packet number per packet number space", func() {
+				Expect(handler.LargestAcked(protocol.EncryptionInitial)).To(Equal(protocol.InvalidPacketNumber))
+				Expect(handler.LargestAcked(protocol.EncryptionHandshake)).To(Equal(protocol.InvalidPacketNumber))
+				Expect(handler.LargestAcked(protocol.Encryption1RTT)).To(Equal(protocol.InvalidPacketNumber))
+				handler.SentPacket(handshakePacket(&Packet{PacketNumber: 0}))
+				handler.SentPacket(handshakePacket(&Packet{PacketNumber: 1}))
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 0, Largest: 0}}}, protocol.EncryptionHandshake, time.Now())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.LargestAcked(protocol.EncryptionHandshake)).To(Equal(protocol.PacketNumber(0)))
+				_, err = handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 0, Largest: 1}}}, protocol.EncryptionHandshake, time.Now())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.LargestAcked(protocol.EncryptionHandshake)).To(Equal(protocol.PacketNumber(1)))
+				Expect(handler.LargestAcked(protocol.Encryption1RTT)).To(Equal(protocol.InvalidPacketNumber))
+				_, err = handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 5}}}, protocol.Encryption1RTT, time.Now())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.LargestAcked(protocol.Encryption1RTT)).To(Equal(protocol.PacketNumber(5)))
+				Expect(handler.LargestAcked(protocol.Encryption0RTT)).To(Equal(protocol.PacketNumber(5)))
+				// an ACK for smaller packet numbers doesn't decrease the largest acked
+				_, err = handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 2}}}, protocol.Encryption1RTT, time.Now())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.LargestAcked(protocol.Encryption1RTT)).To(Equal(protocol.PacketNumber(5)))
+				Expect(handler.LargestAcked(protocol.EncryptionInitial)).To(Equal(protocol.InvalidPacketNumber))
+			})
+
+			It("doesn't report a largest acked packet number for invalid encryption levels", func() {
+				Expect(func() { handler.LargestAcked(protocol.EncryptionLevel(42)) }).ToNot(Panic())
+				Expect(handler.LargestAcked(protocol.EncryptionLevel(42))).To(Equal(protocol.InvalidPacketNumber))
+			})
 		})
 
 		Context("acks the right packets", func() {
@@ -482,11 +514,87 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 	})
 
+	It("updates the statistics", func() {
+		stats := handler.GetStats()
+		Expect(stats.BytesInFlight).To(BeZero())
+		Expect(stats.CongestionWindow).To(Equal(handler.congestion.GetCongestionWindow()))
+		handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 100, SendTime: time.Now().Add(-time.Second)}))
+		handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, Length: 200}))
+		Expect(handler.GetStats().BytesInFlight).To(Equal(protocol.ByteCount(300)))
+		ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+		_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		stats = handler.GetStats()
+		Expect(stats.BytesInFlight).To(Equal(protocol.ByteCount(200)))
+		Expect(stats.LatestRTT).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		Expect(stats.SmoothedRTT).To(Equal(handler.rttStats.SmoothedRTT()))
+		Expect(stats.MinRTT).To(Equal(handler.rttStats.MinRTT()))
+		Expect(stats.BandwidthEstimate).To(Equal(handler.congestion.BandwidthEstimate()))
+	})
+
+	It("reports the congestion control algorithm", func() {
+		Expect(handler.CongestionControl()).To(Equal(congestion.ALGO_CUBIC))
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_LOCO, congestion.HyStartConfig{}, 0, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.CongestionControl()).To(Equal(congestion.ALGO_UNKNOWN))
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_RENO, congestion.HyStartConfig{}, 0, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.CongestionControl()).To(Equal(congestion.ALGO_RENO))
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_CUBIC_RFC8312, congestion.HyStartConfig{}, 0, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.CongestionControl()).To(Equal(congestion.ALGO_CUBIC_RFC8312))
+	})
+
+	It("uses the configured initial congestion window", func() {
+		Expect(handler.congestion.GetCongestionWindow()).To(Equal(32 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_CUBIC, congestion.HyStartConfig{}, 100, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.congestion.GetCongestionWindow()).To(Equal(100 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_RENO, congestion.HyStartConfig{}, 100, 0, 0, 0, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.congestion.GetCongestionWindow()).To(Equal(100 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+	})
+
+	It("limits the bytes in flight of the loco sender, if configured", func() {
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_LOCO, congestion.HyStartConfig{}, 0, 0, 3, 0, congestion.DefaultClock{}, nil, nil, false)
+		handler.ReceivedPacket(protocol.EncryptionHandshake)
+		handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1}))
+		handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+		Expect(handler.SendMode()).To(Equal(SendAny))
+		handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3}))
+		Expect(handler.bytesInFlight).To(Equal(protocol.ByteCount(3)))
+		Expect(handler.SendMode()).To(Equal(SendAck))
+	})
+
+	It("limits the send rate, if a maximum send rate is configured", func() {
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_LOCO, congestion.HyStartConfig{}, 0, 0, 0, 100*congestion.BytesPerSecond, congestion.DefaultClock{}, nil, nil, false)
+		Expect(handler.HasPacingBudget()).To(BeTrue())
+		// the loco sender never limits pacing, so this is limited by the maximum send rate
+		for i := 0; i < 100; i++ {
+			handler.congestion.OnPacketSent(time.Now(), 0, protocol.PacketNumber(i), protocol.InitialPacketSizeIPv4, true)
+		}
+		Expect(handler.HasPacingBudget()).To(BeFalse())
+		Expect(handler.TimeUntilSend()).To(BeTemporally(">", time.Now()))
+	})
+
+	It("uses the clock for pacing", func() {
+		now := time.Now().Add(time.Hour)
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, congestion.ALGO_LOCO, congestion.HyStartConfig{}, 0, 0, 0, 100*congestion.BytesPerSecond, fixedClock(now), nil, nil, false)
+		for i := 0; i < 100; i++ {
+			handler.congestion.OnPacketSent(now, 0, protocol.PacketNumber(i), protocol.InitialPacketSizeIPv4, true)
+		}
+		Expect(handler.HasPacingBudget()).To(BeFalse())
+		Expect(handler.TimeUntilSend()).To(BeTemporally(">", now))
+		Expect(handler.TimeUntilSend()).To(BeTemporally("<", now.Add(time.Minute)))
+	})
+
 	Context("congestion", func() {
 		var cong *mocks.MockSendAlgorithmWithDebugInfos
 
+		It("reports an unknown algorithm for send algorithms that don't advertise it", func() {
+			Expect(handler.CongestionControl()).To(Equal(congestion.ALGO_UNKNOWN))
+		})
+
 		JustBeforeEach(func() {
 			cong = mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+			// the statistics snapshot is updated every time a packet is sent or acknowledged
+			cong.EXPECT().GetCongestionWindow().AnyTimes()
+			cong.EXPECT().BandwidthEstimate().AnyTimes()
 			handler.congestion = cong
 		})
 
@@ -522,6 +630,29 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("traces the congestion window and the slow start threshold", func() {
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			handler.tracer = tracer
+			tracer.EXPECT().SetLossTimer(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			tracer.EXPECT().LossTimerCanceled().AnyTimes()
+			tracer.EXPECT().AcknowledgedPacket(gomock.Any(), gomock.Any()).AnyTimes()
+			tracer.EXPECT().LostPacket(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			cong.EXPECT().GetSlowStartThreshold().Return(protocol.MaxByteCount).Times(2)
+			tracer.EXPECT().UpdatedMetrics(gomock.Any(), gomock.Any(), gomock.Any(), protocol.MaxByteCount, gomock.Any()).Times(2)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+			// lose packet 1, and acknowledge packet 2
+			cong.EXPECT().MaybeExitSlowStart()
+			cong.EXPECT().OnPacketLost(protocol.PacketNumber(1), gomock.Any(), gomock.Any())
+			cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), gomock.Any(), gomock.Any(), gomock.Any())
+			cong.EXPECT().GetSlowStartThreshold().Return(protocol.ByteCount(5000))
+			tracer.EXPECT().UpdatedMetrics(gomock.Any(), gomock.Any(), protocol.ByteCount(0), protocol.ByteCount(5000), gomock.Any())
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("doesn't call OnPacketAcked when a retransmitted packet is acked", func() {
 			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
@@ -541,6 +672,53 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("uses ECN, and reduces the congestion window when the peer reports ECN-CE marks", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			for i := protocol.PacketNumber(1); i <= 3; i++ {
+				Expect(handler.ECNMode()).To(Equal(protocol.ECT0))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: i, ECN: protocol.ECT0}))
+			}
+			gomock.InOrder(
+				cong.EXPECT().MaybeExitSlowStart(),
+				cong.EXPECT().OnPacketLost(protocol.PacketNumber(2), protocol.ByteCount(0), protocol.ByteCount(3)),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(3), gomock.Any()),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(3), gomock.Any()),
+			)
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 2}}, ECT0: 1, ECNCE: 1}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.ECNMode()).To(Equal(protocol.ECT0))
+		})
+
+		It("stops using ECN when the peer doesn't report ECN counts", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, ECN: protocol.ECT0}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, ECN: protocol.ECT0}))
+			cong.EXPECT().MaybeExitSlowStart()
+			cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 2}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.ECNMode()).To(Equal(protocol.ECNNon))
+		})
+
+		It("resets the RTT estimate and the congestion controller when the path changes", func() {
+			handler.rttStats.UpdateRTT(time.Second, 0, time.Now())
+			cong.EXPECT().OnConnectionMigration(protocol.ByteCount(1234))
+			handler.MigratedPath(1234)
+			Expect(handler.rttStats.SmoothedRTT()).To(BeZero())
+			Expect(handler.rttStats.MinRTT()).To(BeZero())
+			Expect(handler.GetStats().SmoothedRTT).To(BeZero())
+		})
+
+		It("restarts ECN validation when the path changes", func() {
+			handler.ecnTracker.state = logging.ECNStateFailed
+			Expect(handler.ECNMode()).To(Equal(protocol.ECNNon))
+			cong.EXPECT().OnConnectionMigration(protocol.ByteCount(1234))
+			handler.MigratedPath(1234)
+			Expect(handler.ECNMode()).To(Equal(protocol.ECT0))
+		})
+
 		It("doesn't call OnPacketLost when a Path MTU probe packet is lost", func() {
 			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			var mtuPacketDeclaredLost bool
@@ -563,6 +741,40 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.bytesInFlight).To(BeZero())
 		})
 
+		It("doesn't pass path probe packets to the congestion controller", func() {
+			// don't EXPECT any calls to OnPacketSent, OnPacketAcked or MaybeExitSlowStart
+			handler.SentPacket(ackElicitingPacket(&Packet{
+				PacketNumber:      1,
+				SendTime:          time.Now().Add(-time.Hour),
+				IsPathProbePacket: true,
+			}))
+			Expect(handler.bytesInFlight).To(BeZero())
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			// the RTT of the new path doesn't say anything about the active path
+			Expect(handler.rttStats.LatestRTT()).To(BeZero())
+		})
+
+		It("doesn't call OnPacketLost when a path probe packet is lost", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			handler.SentPacket(ackElicitingPacket(&Packet{
+				PacketNumber:      1,
+				SendTime:          time.Now().Add(-time.Hour),
+				IsPathProbePacket: true,
+			}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+			// lose packet 1, but don't EXPECT any calls to OnPacketLost()
+			gomock.InOrder(
+				cong.EXPECT().MaybeExitSlowStart(),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(1), gomock.Any()),
+			)
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.bytesInFlight).To(BeZero())
+		})
+
 		It("calls OnPacketAcked and OnPacketLost with the right bytes_in_flight value", func() {
 			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
 			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
@@ -623,7 +835,7 @@ var _ = Describe("SentPacketHandler", func() {
 
 		It("allows PTOs, even when congestion limited", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
-			// note that we don't EXPECT a call to GetCongestionWindow
+			// note that we don't EXPECT a call to CanSend
 			// that means retransmissions are sent without considering the congestion window
 			handler.numProbesToSend = 1
 			handler.ptoMode = SendPTOHandshake
@@ -674,6 +886,34 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(queued).To(BeFalse())
 		})
 
+		It("doesn't retransmit data when probing with a PING", func() {
+			handler.probeWithPing = true
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 10}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 11}))
+			bytesInFlight := handler.bytesInFlight
+			queued := handler.QueueProbePacket(protocol.Encryption1RTT)
+			Expect(queued).To(BeFalse())
+			Expect(lostPackets).To(BeEmpty())
+			Expect(handler.bytesInFlight).To(Equal(bytesInFlight))
+		})
+
+		It("retransmits Handshake data when probing with a PING", func() {
+			handler.probeWithPing = true
+			handler.SentPacket(handshakePacket(&Packet{PacketNumber: 10}))
+			handler.SentPacket(handshakePacket(&Packet{PacketNumber: 11}))
+			queued := handler.QueueProbePacket(protocol.EncryptionHandshake)
+			Expect(queued).To(BeTrue())
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{10}))
+		})
+
+		It("retransmits Initial data when probing with a PING", func() {
+			handler.probeWithPing = true
+			handler.SentPacket(initialPacket(&Packet{PacketNumber: 42}))
+			queued := handler.QueueProbePacket(protocol.EncryptionInitial)
+			Expect(queued).To(BeTrue())
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{42}))
+		})
+
 		It("implements exponential backoff", func() {
 			handler.peerAddressValidated = true
 			handler.SetHandshakeConfirmed()
@@ -1384,3 +1624,8 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 	})
 })
+
+// fixedClock is a congestion.Clock that always returns the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
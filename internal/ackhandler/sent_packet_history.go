@@ -65,7 +65,7 @@ func (h *sentPacketHistory) Iterate(cb func(*Packet) (cont bool, err error)) err
 func (h *sentPacketHistory) FirstOutstanding() *Packet {
 	for el := h.packetList.Front(); el != nil; el = el.Next() {
 		p := &el.Value
-		if !p.declaredLost && !p.skippedPacket && !p.IsPathMTUProbePacket {
+		if !p.declaredLost && !p.skippedPacket && !p.IsPathMTUProbePacket && !p.IsPathProbePacket {
 			return p
 		}
 	}
@@ -88,6 +88,11 @@ var _ = Describe("SentPacketHistory", func() {
 			Expect(front.PacketNumber).To(Equal(protocol.PacketNumber(2)))
 		})
 
+		It("doesn't regard path probe packets as outstanding", func() {
+			hist.SentPacket(&Packet{PacketNumber: 2, IsPathProbePacket: true}, true)
+			Expect(hist.FirstOutstanding()).To(BeNil())
+		})
+
 		It("doesn't regard path MTU packets as outstanding", func() {
 			hist.SentPacket(&Packet{PacketNumber: 2}, true)
 			hist.SentPacket(&Packet{PacketNumber: 4, IsPathMTUProbePacket: true}, true)
@@ -9,11 +9,13 @@ func _() {
 	_ = x[ALGO_UNKNOWN-0]
 	_ = x[ALGO_CUBIC-1]
 	_ = x[ALGO_LOCO-2]
+	_ = x[ALGO_RENO-3]
+	_ = x[ALGO_CUBIC_RFC8312-4]
 }
 
-const _CongestionAlgo_name = "ALGO_UNKNOWNALGO_CUBICALGO_LOCO"
+const _CongestionAlgo_name = "ALGO_UNKNOWNALGO_CUBICALGO_LOCOALGO_RENOALGO_CUBIC_RFC8312"
 
-var _CongestionAlgo_index = [...]uint8{0, 12, 22, 31}
+var _CongestionAlgo_index = [...]uint8{0, 12, 22, 31, 40, 58}
 
 func (i CongestionAlgo) String() string {
 	if i < 0 || i >= CongestionAlgo(len(_CongestionAlgo_index)-1) {
@@ -27,6 +29,17 @@ type CongestionAlgo int
 
 const (
 	ALGO_UNKNOWN CongestionAlgo = iota
+	// ALGO_CUBIC selects the cubic sender. Despite its name, it runs the cubic sender in Reno mode,
+	// i.e. it behaves like ALGO_RENO. This is kept for compatibility, ALGO_CUBIC_RFC8312 selects CUBIC.
 	ALGO_CUBIC
 	ALGO_LOCO
+	// ALGO_RENO is NewReno (RFC 6582), implemented by the cubic sender in Reno mode.
+	// Unlike ALGO_CUBIC_RFC8312, the window grows by one packet per round trip in congestion avoidance,
+	// independent of the time since the last loss event. On loss, the window is reduced by a factor of 0.7.
+	// Slow start (including HyStart) and pacing are the same as for ALGO_CUBIC_RFC8312.
+	ALGO_RENO
+	// ALGO_CUBIC_RFC8312 is CUBIC (RFC 8312).
+	// In congestion avoidance, the window grows along a cubic function of the time since the last loss event.
+	// On loss, the window is reduced by a factor of 0.7, with fast convergence for the window it returns to.
+	ALGO_CUBIC_RFC8312
 )
@@ -0,0 +1,61 @@
+package congestion
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/logging"
+)
+
+// A CongestionLog writes a CSV line every time the congestion state or the congestion window changes.
+// The columns are:
+// connection ID (hex-encoded), timestamp (in microseconds since the Unix epoch), state, congestion window (in bytes),
+// bandwidth estimate (in bits per second) and smoothed RTT (in microseconds).
+type CongestionLog struct {
+	w      io.Writer
+	clock  Clock
+	connID string
+
+	logged    bool
+	lastState logging.CongestionState
+	lastCwnd  protocol.ByteCount
+}
+
+// NewCongestionLog creates a new CongestionLog for the connection identified by connID.
+// Since the log writer might be shared between connections, every line starts with the connection ID.
+// It returns nil if w is nil. All methods can be called on a nil CongestionLog.
+func NewCongestionLog(w io.Writer, clock Clock, connID protocol.ConnectionID) *CongestionLog {
+	if w == nil {
+		return nil
+	}
+	return &CongestionLog{w: w, clock: clock, connID: fmt.Sprintf("%x", connID.Bytes())}
+}
+
+// Update writes a new line, if the state or the congestion window changed since the last call.
+func (l *CongestionLog) Update(state logging.CongestionState, cwnd protocol.ByteCount, bwe Bandwidth, rtt time.Duration) {
+	if l == nil || (l.logged && state == l.lastState && cwnd == l.lastCwnd) {
+		return
+	}
+	l.logged = true
+	l.lastState = state
+	l.lastCwnd = cwnd
+	// errors are ignored, the log is best effort
+	fmt.Fprintf(l.w, "%s,%d,%s,%d,%d,%d\n", l.connID, l.clock.Now().UnixNano()/1e3, congestionStateName(state), cwnd, bwe, rtt.Microseconds())
+}
+
+func congestionStateName(state logging.CongestionState) string {
+	switch state {
+	case logging.CongestionStateSlowStart:
+		return "slow_start"
+	case logging.CongestionStateCongestionAvoidance:
+		return "congestion_avoidance"
+	case logging.CongestionStateRecovery:
+		return "recovery"
+	case logging.CongestionStateApplicationLimited:
+		return "application_limited"
+	default:
+		return "unknown"
+	}
+}
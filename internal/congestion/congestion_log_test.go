@@ -0,0 +1,49 @@
+package congestion
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Congestion Log", func() {
+	It("doesn't create a log without a writer", func() {
+		l := NewCongestionLog(nil, DefaultClock{}, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		Expect(l).To(BeNil())
+		// doesn't panic
+		l.Update(logging.CongestionStateSlowStart, 1000, 0, 0)
+	})
+
+	It("writes CSV lines", func() {
+		buf := &bytes.Buffer{}
+		clock := mockClock(time.Unix(1600000000, 123456000))
+		l := NewCongestionLog(buf, &clock, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		l.Update(logging.CongestionStateSlowStart, 12000, 8*BytesPerSecond, 25*time.Millisecond)
+		clock.Advance(time.Millisecond)
+		l.Update(logging.CongestionStateRecovery, 8400, 16*BytesPerSecond, 30*time.Millisecond)
+		Expect(buf.String()).To(Equal(
+			"deadbeef,1600000000123456,slow_start,12000,64,25000\n" +
+				"deadbeef,1600000000124456,recovery,8400,128,30000\n",
+		))
+	})
+
+	It("only writes a line if the state or the congestion window changed", func() {
+		buf := &bytes.Buffer{}
+		l := NewCongestionLog(buf, DefaultClock{}, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		l.Update(logging.CongestionStateSlowStart, 12000, 0, 0)
+		Expect(buf.Len()).ToNot(BeZero())
+		buf.Reset()
+		l.Update(logging.CongestionStateSlowStart, 12000, 100, time.Second)
+		Expect(buf.Len()).To(BeZero())
+		l.Update(logging.CongestionStateSlowStart, 13000, 100, time.Second)
+		Expect(buf.String()).To(ContainSubstring(",slow_start,13000,"))
+		buf.Reset()
+		l.Update(logging.CongestionStateCongestionAvoidance, 13000, 100, time.Second)
+		Expect(buf.String()).To(ContainSubstring(",congestion_avoidance,13000,"))
+	})
+})
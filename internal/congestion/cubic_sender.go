@@ -17,8 +17,24 @@ const (
 	renoBeta                   = 0.7 // Reno backoff factor.
 	minCongestionWindowPackets = 2
 	initialCongestionWindow    = 32
+	// maxInitialCongestionWindow is the maximum configurable initial congestion window, in packets.
+	// This is 10 times the initial window recommended by RFC 9002.
+	maxInitialCongestionWindow = 100
 )
 
+// initialCongestionWindowSize returns the initial congestion window in bytes.
+// If numPackets is 0, the default initial congestion window is used.
+// It is capped to maxInitialCongestionWindow packets.
+func initialCongestionWindowSize(numPackets int, maxDatagramSize protocol.ByteCount) protocol.ByteCount {
+	if numPackets == 0 {
+		numPackets = initialCongestionWindow
+	}
+	if numPackets > maxInitialCongestionWindow {
+		numPackets = maxInitialCongestionWindow
+	}
+	return protocol.ByteCount(numPackets) * maxDatagramSize
+}
+
 type cubicSender struct {
 	hybridSlowStart HybridSlowStart
 	rttStats        *utils.RTTStats
@@ -27,6 +43,7 @@ type cubicSender struct {
 	clock           Clock
 
 	reno bool
+	algo CongestionAlgo // the algorithm reported by CongestionAlgo
 
 	// Track the largest packet that has been sent.
 	largestSentPacketNumber protocol.PacketNumber
@@ -52,66 +69,101 @@ type cubicSender struct {
 
 	initialCongestionWindow    protocol.ByteCount
 	initialMaxCongestionWindow protocol.ByteCount
+	initialSlowStartThreshold  protocol.ByteCount
 
 	maxDatagramSize protocol.ByteCount
 
-	lastState logging.CongestionState
-	tracer    logging.ConnectionTracer
+	lastState     logging.CongestionState
+	tracer        logging.ConnectionTracer
+	congestionLog *CongestionLog
+
+	onCongestionCollapse func()
 }
 
 var (
 	_ SendAlgorithm               = &cubicSender{}
 	_ SendAlgorithmWithDebugInfos = &cubicSender{}
+	_ AlgorithmReporter           = &cubicSender{}
 )
 
-// NewCubicSender makes a new cubic sender
+// NewCubicSender makes a new cubic sender.
+// If initialCongestionWindowPackets is 0, the default initial congestion window is used.
+// If initialSlowStartThreshold is 0, slow start is only exited due to packet loss or by HyStart.
+// algo is ALGO_CUBIC, ALGO_RENO or ALGO_CUBIC_RFC8312. Only ALGO_CUBIC_RFC8312 uses the CUBIC window growth.
 func NewCubicSender(
 	clock Clock,
 	rttStats *utils.RTTStats,
 	initialMaxDatagramSize protocol.ByteCount,
-	reno bool,
+	algo CongestionAlgo,
+	hyStartConfig HyStartConfig,
+	initialCongestionWindowPackets int,
+	initialSlowStartThreshold protocol.ByteCount,
 	tracer logging.ConnectionTracer,
+	congestionLog *CongestionLog,
+	onCongestionCollapse func(),
 ) *cubicSender {
-	return newCubicSender(
+	c := newCubicSender(
 		clock,
 		rttStats,
-		reno,
+		algo != ALGO_CUBIC_RFC8312,
+		hyStartConfig,
 		initialMaxDatagramSize,
-		initialCongestionWindow*initialMaxDatagramSize,
+		initialCongestionWindowSize(initialCongestionWindowPackets, initialMaxDatagramSize),
 		protocol.MaxCongestionWindowPackets*initialMaxDatagramSize,
+		initialSlowStartThreshold,
 		tracer,
+		congestionLog,
+		onCongestionCollapse,
 	)
+	c.algo = algo
+	return c
 }
 
 func newCubicSender(
 	clock Clock,
 	rttStats *utils.RTTStats,
 	reno bool,
+	hyStartConfig HyStartConfig,
 	initialMaxDatagramSize,
 	initialCongestionWindow,
-	initialMaxCongestionWindow protocol.ByteCount,
+	initialMaxCongestionWindow,
+	initialSlowStartThreshold protocol.ByteCount,
 	tracer logging.ConnectionTracer,
+	congestionLog *CongestionLog,
+	onCongestionCollapse func(),
 ) *cubicSender {
+	if initialSlowStartThreshold == 0 {
+		initialSlowStartThreshold = protocol.MaxByteCount
+	}
 	c := &cubicSender{
+		hybridSlowStart:            NewHybridSlowStart(hyStartConfig),
 		rttStats:                   rttStats,
 		largestSentPacketNumber:    protocol.InvalidPacketNumber,
 		largestAckedPacketNumber:   protocol.InvalidPacketNumber,
 		largestSentAtLastCutback:   protocol.InvalidPacketNumber,
 		initialCongestionWindow:    initialCongestionWindow,
 		initialMaxCongestionWindow: initialMaxCongestionWindow,
+		initialSlowStartThreshold:  initialSlowStartThreshold,
 		congestionWindow:           initialCongestionWindow,
-		slowStartThreshold:         protocol.MaxByteCount,
+		slowStartThreshold:         initialSlowStartThreshold,
 		cubic:                      NewCubic(clock),
 		clock:                      clock,
 		reno:                       reno,
+		algo:                       ALGO_CUBIC_RFC8312,
 		tracer:                     tracer,
+		congestionLog:              congestionLog,
 		maxDatagramSize:            initialMaxDatagramSize,
+		onCongestionCollapse:       onCongestionCollapse,
+	}
+	if reno {
+		c.algo = ALGO_RENO
 	}
 	c.pacer = newPacer(c.BandwidthEstimate)
 	if c.tracer != nil {
 		c.lastState = logging.CongestionStateSlowStart
 		c.tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
 	}
+	c.logCongestionState()
 	return c
 }
 
@@ -163,6 +215,10 @@ func (c *cubicSender) GetCongestionWindow() protocol.ByteCount {
 	return c.congestionWindow
 }
 
+func (c *cubicSender) GetSlowStartThreshold() protocol.ByteCount {
+	return c.slowStartThreshold
+}
+
 func (c *cubicSender) MaybeExitSlowStart() {
 	if c.InSlowStart() &&
 		c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
@@ -186,6 +242,7 @@ func (c *cubicSender) OnPacketAcked(
 	if c.InSlowStart() {
 		c.hybridSlowStart.OnPacketAcked(ackedPacketNumber)
 	}
+	c.logCongestionState()
 }
 
 func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
@@ -197,19 +254,25 @@ func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes
 	c.lastCutbackExitedSlowstart = c.InSlowStart()
 	c.maybeTraceStateChange(logging.CongestionStateRecovery)
 
+	minCwnd := c.minCongestionWindow()
+	wasAboveMinCwnd := c.congestionWindow > minCwnd
 	if c.reno {
 		c.congestionWindow = protocol.ByteCount(float64(c.congestionWindow) * renoBeta)
 	} else {
 		c.congestionWindow = c.cubic.CongestionWindowAfterPacketLoss(c.congestionWindow)
 	}
-	if minCwnd := c.minCongestionWindow(); c.congestionWindow < minCwnd {
+	if c.congestionWindow < minCwnd {
 		c.congestionWindow = minCwnd
 	}
+	if wasAboveMinCwnd && c.congestionWindow == minCwnd && c.onCongestionCollapse != nil {
+		c.onCongestionCollapse()
+	}
 	c.slowStartThreshold = c.congestionWindow
 	c.largestSentAtLastCutback = c.largestSentPacketNumber
 	// reset packet count from congestion avoidance mode. We start
 	// counting again when we're out of recovery.
 	c.numAckedPackets = 0
+	c.logCongestionState()
 }
 
 // Called when we receive an ack. Normal TCP tracks how many packets one ack
@@ -280,10 +343,18 @@ func (c *cubicSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
 	c.cubic.Reset()
 	c.slowStartThreshold = c.congestionWindow / 2
 	c.congestionWindow = c.minCongestionWindow()
+	c.logCongestionState()
+}
+
+// CongestionAlgo returns the congestion control algorithm implemented by this sender
+func (c *cubicSender) CongestionAlgo() CongestionAlgo {
+	return c.algo
 }
 
-// OnConnectionMigration is called when the connection is migrated (?)
-func (c *cubicSender) OnConnectionMigration() {
+// OnConnectionMigration resets the sender to its initial state, as if the connection was just started.
+func (c *cubicSender) OnConnectionMigration(maxDatagramSize protocol.ByteCount) {
+	c.maxDatagramSize = maxDatagramSize
+	c.pacer.SetMaxDatagramSize(maxDatagramSize)
 	c.hybridSlowStart.Restart()
 	c.largestSentPacketNumber = protocol.InvalidPacketNumber
 	c.largestAckedPacketNumber = protocol.InvalidPacketNumber
@@ -292,15 +363,24 @@ func (c *cubicSender) OnConnectionMigration() {
 	c.cubic.Reset()
 	c.numAckedPackets = 0
 	c.congestionWindow = c.initialCongestionWindow
-	c.slowStartThreshold = c.initialMaxCongestionWindow
+	c.slowStartThreshold = utils.MinByteCount(c.initialMaxCongestionWindow, c.initialSlowStartThreshold)
+	c.logCongestionState()
 }
 
 func (c *cubicSender) maybeTraceStateChange(new logging.CongestionState) {
-	if c.tracer == nil || new == c.lastState {
+	if new == c.lastState {
 		return
 	}
-	c.tracer.UpdatedCongestionState(new)
 	c.lastState = new
+	if c.tracer != nil {
+		c.tracer.UpdatedCongestionState(new)
+	}
+	c.logCongestionState()
+}
+
+// logCongestionState writes the current state to the congestion log, if one is set
+func (c *cubicSender) logCongestionState() {
+	c.congestionLog.Update(c.lastState, c.GetCongestionWindow(), c.BandwidthEstimate(), c.rttStats.SmoothedRTT())
 }
 
 func (c *cubicSender) SetMaxDatagramSize(s protocol.ByteCount) {
@@ -311,6 +391,7 @@ func (c *cubicSender) SetMaxDatagramSize(s protocol.ByteCount) {
 	c.maxDatagramSize = s
 	if cwndIsMinCwnd {
 		c.congestionWindow = c.minCongestionWindow()
+		c.logCongestionState()
 	}
 	c.pacer.SetMaxDatagramSize(s)
 }
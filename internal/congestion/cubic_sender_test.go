@@ -1,6 +1,9 @@
 package congestion
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
@@ -46,9 +49,13 @@ var _ = Describe("Cubic Sender", func() {
 			&clock,
 			rttStats,
 			true, /*reno*/
+			HyStartConfig{},
 			protocol.InitialPacketSizeIPv4,
 			initialCongestionWindowPackets*maxDatagramSize,
 			MaxCongestionWindow,
+			0,
+			nil,
+			nil,
 			nil,
 		)
 	})
@@ -96,6 +103,7 @@ var _ = Describe("Cubic Sender", func() {
 	It("has the right values at startup", func() {
 		// At startup make sure we are at the default.
 		Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP))
+		Expect(sender.GetSlowStartThreshold()).To(Equal(protocol.MaxByteCount))
 		// Make sure we can send.
 		Expect(sender.TimeUntilSend(0)).To(BeZero())
 		Expect(sender.CanSend(bytesInFlight)).To(BeTrue())
@@ -172,6 +180,7 @@ var _ = Describe("Cubic Sender", func() {
 		// We should now have fallen out of slow start with a reduced window.
 		expectedSendWindow = protocol.ByteCount(float32(expectedSendWindow) * renoBeta)
 		Expect(sender.GetCongestionWindow()).To(Equal(expectedSendWindow))
+		Expect(sender.GetSlowStartThreshold()).To(Equal(expectedSendWindow))
 
 		// Recovery phase. We need to ack every packet in the recovery window before
 		// we exit recovery.
@@ -318,7 +327,7 @@ var _ = Describe("Cubic Sender", func() {
 	It("tcp cubic reset epoch on quiescence", func() {
 		const maxCongestionWindow = 50
 		const maxCongestionWindowBytes = maxCongestionWindow * maxDatagramSize
-		sender = newCubicSender(&clock, rttStats, false, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, maxCongestionWindowBytes, nil)
+		sender = newCubicSender(&clock, rttStats, false, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, maxCongestionWindowBytes, 0, nil, nil, nil)
 
 		numSent := SendAvailableSendWindow()
 
@@ -451,7 +460,7 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(sender.slowStartThreshold).To(Equal(expectedSendWindow))
 
 		// Resets cwnd and slow start threshold on connection migrations.
-		sender.OnConnectionMigration()
+		sender.OnConnectionMigration(maxDatagramSize)
 		Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP))
 		Expect(sender.slowStartThreshold).To(Equal(MaxCongestionWindow))
 		Expect(sender.hybridSlowStart.Started()).To(BeFalse())
@@ -459,7 +468,7 @@ var _ = Describe("Cubic Sender", func() {
 
 	It("slow starts up to the maximum congestion window", func() {
 		const initialMaxCongestionWindow = protocol.MaxCongestionWindowPackets * initialMaxDatagramSize
-		sender = newCubicSender(&clock, rttStats, true, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, true, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, 0, nil, nil, nil)
 
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
 			sender.MaybeExitSlowStart()
@@ -468,13 +477,176 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(sender.GetCongestionWindow()).To(Equal(initialMaxCongestionWindow))
 	})
 
+	Context("with HyStart disabled", func() {
+		BeforeEach(func() {
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				true, /*reno*/
+				HyStartConfig{Disable: true},
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				0,
+				nil,
+				nil,
+				nil,
+			)
+		})
+
+		increaseRTT := func() {
+			rttStats.UpdateRTT(60*time.Millisecond, 0, clock.Now())
+			for i := 0; i < 16; i++ {
+				rttStats.UpdateRTT(100*time.Millisecond, 0, clock.Now())
+				sender.MaybeExitSlowStart()
+			}
+		}
+
+		It("doesn't exit slow start when the RTT increases", func() {
+			increaseRTT()
+			Expect(sender.InSlowStart()).To(BeTrue())
+			// make sure that HyStart would have exited slow start if it was enabled
+			sender = newCubicSender(&clock, rttStats, true, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, 0, nil, nil, nil)
+			increaseRTT()
+			Expect(sender.InSlowStart()).To(BeFalse())
+		})
+
+		It("exits slow start on packet loss", func() {
+			for i := 0; i < 10; i++ {
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			Expect(sender.InSlowStart()).To(BeTrue())
+			SendAvailableSendWindow()
+			LoseNPackets(1)
+			Expect(sender.InSlowStart()).To(BeFalse())
+		})
+
+		It("exits slow start when reaching the slow start threshold", func() {
+			for i := 0; i < 10; i++ {
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			cwnd := sender.GetCongestionWindow()
+			sender.OnRetransmissionTimeout(true)
+			Expect(sender.slowStartThreshold).To(Equal(cwnd / 2))
+			Expect(sender.InSlowStart()).To(BeTrue())
+			for sender.GetCongestionWindow() < cwnd/2 {
+				Expect(sender.InSlowStart()).To(BeTrue())
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			Expect(sender.InSlowStart()).To(BeFalse())
+		})
+	})
+
+	Context("with an initial slow start threshold", func() {
+		const initialSlowStartThreshold = 20 * maxDatagramSize
+
+		BeforeEach(func() {
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				false, /*reno*/
+				HyStartConfig{Disable: true},
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				initialSlowStartThreshold,
+				nil,
+				nil,
+				nil,
+			)
+		})
+
+		It("uses the initial slow start threshold", func() {
+			Expect(sender.GetSlowStartThreshold()).To(Equal(initialSlowStartThreshold))
+			Expect(sender.InSlowStart()).To(BeTrue())
+		})
+
+		It("exits slow start when reaching the threshold, without any packet loss", func() {
+			for sender.InSlowStart() {
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			Expect(sender.GetCongestionWindow()).To(Equal(initialSlowStartThreshold))
+			Expect(sender.GetSlowStartThreshold()).To(Equal(initialSlowStartThreshold))
+			// In congestion avoidance, the window grows much slower than in slow start.
+			for i := 0; i < 5; i++ {
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", initialSlowStartThreshold+maxDatagramSize))
+		})
+
+		It("resets to the initial slow start threshold after a connection migration", func() {
+			SendAvailableSendWindow()
+			LoseNPackets(1)
+			Expect(sender.GetSlowStartThreshold()).To(BeNumerically("<", initialSlowStartThreshold))
+			sender.OnConnectionMigration(maxDatagramSize)
+			Expect(sender.GetSlowStartThreshold()).To(Equal(initialSlowStartThreshold))
+		})
+
+		It("uses the default slow start threshold if not set", func() {
+			sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{Disable: true}, 0, 0, nil, nil, nil)
+			Expect(sender.GetSlowStartThreshold()).To(Equal(protocol.MaxByteCount))
+		})
+	})
+
+	It("uses the configured initial congestion window", func() {
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, 100, 0, nil, nil, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(100 * maxDatagramSize))
+		// the initial window is restored after a connection migration
+		SendAvailableSendWindow()
+		LoseNPackets(1)
+		Expect(sender.GetCongestionWindow()).To(BeNumerically("<", 100*maxDatagramSize))
+		sender.OnConnectionMigration(maxDatagramSize)
+		Expect(sender.GetCongestionWindow()).To(Equal(100 * maxDatagramSize))
+	})
+
+	It("uses the max datagram size of the new path after a connection migration", func() {
+		sender.SetMaxDatagramSize(maxDatagramSize + 100)
+		sender.OnConnectionMigration(maxDatagramSize - 100)
+		Expect(sender.maxDatagramSize).To(Equal(maxDatagramSize - 100))
+		Expect(sender.pacer.maxDatagramSize).To(Equal(maxDatagramSize - 100))
+		// the max datagram size can be increased again after the migration
+		sender.SetMaxDatagramSize(maxDatagramSize)
+		Expect(sender.maxDatagramSize).To(Equal(maxDatagramSize))
+	})
+
+	It("caps the initial congestion window", func() {
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, maxInitialCongestionWindow+1, 0, nil, nil, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(maxInitialCongestionWindow * maxDatagramSize))
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, 1e6, 0, nil, nil, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(maxInitialCongestionWindow * maxDatagramSize))
+		// the cap also applies after a connection migration
+		sender.OnConnectionMigration(maxDatagramSize)
+		Expect(sender.GetCongestionWindow()).To(Equal(maxInitialCongestionWindow * maxDatagramSize))
+	})
+
+	It("reports its congestion control algorithm", func() {
+		Expect(sender.CongestionAlgo()).To(Equal(ALGO_RENO))
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, 0, 0, nil, nil, nil)
+		Expect(sender.CongestionAlgo()).To(Equal(ALGO_CUBIC_RFC8312))
+	})
+
+	It("uses Reno mode for ALGO_CUBIC and ALGO_RENO", func() {
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC, HyStartConfig{}, 0, 0, nil, nil, nil)
+		Expect(sender.reno).To(BeTrue())
+		Expect(sender.CongestionAlgo()).To(Equal(ALGO_CUBIC))
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_RENO, HyStartConfig{}, 0, 0, nil, nil, nil)
+		Expect(sender.reno).To(BeTrue())
+		sender = NewCubicSender(&clock, rttStats, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, 0, 0, nil, nil, nil)
+		Expect(sender.reno).To(BeFalse())
+	})
+
 	It("doesn't allow reductions of the maximum packet size", func() {
 		Expect(func() { sender.SetMaxDatagramSize(initialMaxDatagramSize - 1) }).To(Panic())
 	})
 
 	It("slow starts up to maximum congestion window, if larger packets are sent", func() {
 		const initialMaxCongestionWindow = protocol.MaxCongestionWindowPackets * initialMaxDatagramSize
-		sender = newCubicSender(&clock, rttStats, true, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, true, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, 0, nil, nil, nil)
 		const packetSize = initialMaxDatagramSize + 100
 		sender.SetMaxDatagramSize(packetSize)
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
@@ -489,7 +661,7 @@ var _ = Describe("Cubic Sender", func() {
 
 	It("limit cwnd increase in congestion avoidance", func() {
 		// Enable Cubic.
-		sender = newCubicSender(&clock, rttStats, false, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, false, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, 0, nil, nil, nil)
 		numSent := SendAvailableSendWindow()
 
 		// Make sure we fall out of slow start.
@@ -523,4 +695,131 @@ var _ = Describe("Cubic Sender", func() {
 		AckNPackets(2)
 		Expect(sender.GetCongestionWindow()).To(Equal(savedCwnd + maxDatagramSize))
 	})
+
+	Context("congestion collapse", func() {
+		var collapses int
+
+		BeforeEach(func() {
+			collapses = 0
+			sender = newCubicSender(&clock, rttStats, true, HyStartConfig{}, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, 0, nil, nil, func() { collapses++ })
+		})
+
+		// every call is a separate loss event, since the lost packet was sent after the last cutback
+		loseNewPacket := func() {
+			sender.OnPacketSent(clock.Now(), 0, packetNumber, maxDatagramSize, true)
+			sender.OnPacketLost(packetNumber, maxDatagramSize, maxDatagramSize)
+			packetNumber++
+		}
+
+		ackNewPacket := func() {
+			sender.OnPacketSent(clock.Now(), 0, packetNumber, maxDatagramSize, true)
+			sender.OnPacketAcked(packetNumber, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+			packetNumber++
+		}
+
+		It("calls the callback when repeated losses reduce the congestion window to the minimum", func() {
+			for i := 0; i < 10; i++ {
+				loseNewPacket()
+				if sender.GetCongestionWindow() == sender.minCongestionWindow() {
+					break
+				}
+				Expect(collapses).To(BeZero())
+			}
+			Expect(sender.GetCongestionWindow()).To(Equal(sender.minCongestionWindow()))
+			Expect(collapses).To(Equal(1))
+			// further losses don't call the callback again, as long as the window stays at the minimum
+			loseNewPacket()
+			Expect(collapses).To(Equal(1))
+		})
+
+		It("calls the callback again when the window collapses again", func() {
+			for sender.GetCongestionWindow() > sender.minCongestionWindow() {
+				loseNewPacket()
+			}
+			Expect(collapses).To(Equal(1))
+			for sender.GetCongestionWindow() == sender.minCongestionWindow() {
+				ackNewPacket()
+			}
+			Expect(collapses).To(Equal(1))
+			for sender.GetCongestionWindow() > sender.minCongestionWindow() {
+				loseNewPacket()
+			}
+			Expect(collapses).To(Equal(2))
+		})
+
+		It("doesn't call the callback when a loss only reduces the window", func() {
+			loseNewPacket()
+			Expect(sender.GetCongestionWindow()).To(BeNumerically(">", sender.minCongestionWindow()))
+			Expect(collapses).To(BeZero())
+		})
+	})
+
+	Context("congestion log", func() {
+		var buf *bytes.Buffer
+
+		BeforeEach(func() {
+			buf = &bytes.Buffer{}
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				true, /*reno*/
+				HyStartConfig{},
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				0,
+				nil,
+				NewCongestionLog(buf, &clock, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}),
+				nil,
+			)
+		})
+
+		// readLog returns the state and the congestion window of every line written since the last call
+		readLog := func() [][]string {
+			var entries [][]string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				fields := strings.Split(line, ",")
+				ExpectWithOffset(1, fields).To(HaveLen(6))
+				ExpectWithOffset(1, fields[0]).To(Equal("deadbeef"))
+				entries = append(entries, fields[2:4])
+			}
+			buf.Reset()
+			return entries
+		}
+
+		It("logs the initial state", func() {
+			Expect(readLog()).To(Equal([][]string{{"slow_start", fmt.Sprint(defaultWindowTCP)}}))
+		})
+
+		It("logs when exiting slow start", func() {
+			readLog()
+			rttStats.UpdateRTT(60*time.Millisecond, 0, clock.Now())
+			for i := 0; i < 16; i++ {
+				rttStats.UpdateRTT(100*time.Millisecond, 0, clock.Now())
+				sender.MaybeExitSlowStart()
+			}
+			Expect(sender.InSlowStart()).To(BeFalse())
+			Expect(readLog()).To(Equal([][]string{{"congestion_avoidance", fmt.Sprint(defaultWindowTCP)}}))
+		})
+
+		It("logs the congestion window cutback on packet loss", func() {
+			for i := 0; i < 10; i++ {
+				SendAvailableSendWindow()
+				AckNPackets(2)
+			}
+			// every ACK increased the congestion window
+			Expect(readLog()).To(HaveLen(1 + 2*10))
+			cwnd := sender.GetCongestionWindow()
+			SendAvailableSendWindow()
+			LoseNPackets(1)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", cwnd))
+			Expect(readLog()).To(Equal([][]string{
+				{"recovery", fmt.Sprint(cwnd)},
+				{"recovery", fmt.Sprint(sender.GetCongestionWindow())},
+			}))
+			// further losses in the same loss event don't change the window
+			LoseNPackets(1)
+			Expect(buf.Len()).To(BeZero())
+		})
+	})
 })
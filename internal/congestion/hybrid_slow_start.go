@@ -22,8 +22,49 @@ const (
 	hybridStartDelayMaxThresholdUs = int64(16000)
 )
 
+// HyStartConfig is used to tune the hybrid slow start algorithm.
+// The zero value uses the default parameters.
+type HyStartConfig struct {
+	// Disable disables hybrid slow start.
+	// Slow start is then only exited when a packet is lost, or when the slow start threshold is reached.
+	Disable bool
+	// MinRTTSamples is the number of RTT samples taken at the beginning of every round
+	// to detect an increase of the RTT.
+	// If not set, it defaults to 8.
+	MinRTTSamples uint32
+	// MinRTTIncreaseThreshold is the lower bound of the RTT increase that causes slow start to be exited.
+	// If not set, it defaults to 4ms.
+	MinRTTIncreaseThreshold time.Duration
+	// MaxRTTIncreaseThreshold is the upper bound of the RTT increase that causes slow start to be exited.
+	// If not set, it defaults to 16ms.
+	MaxRTTIncreaseThreshold time.Duration
+}
+
+func (c *HyStartConfig) minRTTSamples() uint32 {
+	if c.MinRTTSamples == 0 {
+		return hybridStartMinSamples
+	}
+	return c.MinRTTSamples
+}
+
+func (c *HyStartConfig) minRTTIncreaseThresholdUs() int64 {
+	if c.MinRTTIncreaseThreshold == 0 {
+		return hybridStartDelayMinThresholdUs
+	}
+	return c.MinRTTIncreaseThreshold.Microseconds()
+}
+
+func (c *HyStartConfig) maxRTTIncreaseThresholdUs() int64 {
+	if c.MaxRTTIncreaseThreshold == 0 {
+		return hybridStartDelayMaxThresholdUs
+	}
+	return c.MaxRTTIncreaseThreshold.Microseconds()
+}
+
 // HybridSlowStart implements the TCP hybrid slow start algorithm
 type HybridSlowStart struct {
+	config HyStartConfig
+
 	endPacketNumber      protocol.PacketNumber
 	lastSentPacketNumber protocol.PacketNumber
 	started              bool
@@ -32,6 +73,11 @@ type HybridSlowStart struct {
 	hystartFound         bool
 }
 
+// NewHybridSlowStart creates a new HybridSlowStart using the given configuration.
+func NewHybridSlowStart(config HyStartConfig) HybridSlowStart {
+	return HybridSlowStart{config: config}
+}
+
 // StartReceiveRound is called for the start of each receive round (burst) in the slow start phase.
 func (s *HybridSlowStart) StartReceiveRound(lastSent protocol.PacketNumber) {
 	s.endPacketNumber = lastSent
@@ -51,6 +97,9 @@ func (s *HybridSlowStart) IsEndOfRound(ack protocol.PacketNumber) bool {
 // minRTT: is the lowest delay (RTT) we have seen during the session.
 // congestionWindow: the congestion window in packets.
 func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT time.Duration, congestionWindow protocol.ByteCount) bool {
+	if s.config.Disable {
+		return false
+	}
 	if !s.started {
 		// Time to start the hybrid slow start.
 		s.StartReceiveRound(s.lastSentPacketNumber)
@@ -64,19 +113,20 @@ func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT ti
 	// Note: we only look at the first few(8) packets in each burst, since we
 	// only want to compare the lowest RTT of the burst relative to previous
 	// bursts.
+	minSamples := s.config.minRTTSamples()
 	s.rttSampleCount++
-	if s.rttSampleCount <= hybridStartMinSamples {
+	if s.rttSampleCount <= minSamples {
 		if s.currentMinRTT == 0 || s.currentMinRTT > latestRTT {
 			s.currentMinRTT = latestRTT
 		}
 	}
 	// We only need to check this once per round.
-	if s.rttSampleCount == hybridStartMinSamples {
+	if s.rttSampleCount == minSamples {
 		// Divide minRTT by 8 to get a rtt increase threshold for exiting.
 		minRTTincreaseThresholdUs := int64(minRTT / time.Microsecond >> hybridStartDelayFactorExp)
-		// Ensure the rtt threshold is never less than 2ms or more than 16ms.
-		minRTTincreaseThresholdUs = utils.MinInt64(minRTTincreaseThresholdUs, hybridStartDelayMaxThresholdUs)
-		minRTTincreaseThreshold := time.Duration(utils.MaxInt64(minRTTincreaseThresholdUs, hybridStartDelayMinThresholdUs)) * time.Microsecond
+		// Ensure the rtt threshold is never less than 4ms or more than 16ms (unless configured otherwise).
+		minRTTincreaseThresholdUs = utils.MinInt64(minRTTincreaseThresholdUs, s.config.maxRTTIncreaseThresholdUs())
+		minRTTincreaseThreshold := time.Duration(utils.MaxInt64(minRTTincreaseThresholdUs, s.config.minRTTIncreaseThresholdUs())) * time.Microsecond
 
 		if s.currentMinRTT > (minRTT + minRTTincreaseThreshold) {
 			s.hystartFound = true
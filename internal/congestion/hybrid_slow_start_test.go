@@ -69,4 +69,52 @@ var _ = Describe("Hybrid slow start", func() {
 		// RTT provided.
 		Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeTrue())
 	})
+
+	It("never exits slow start when disabled", func() {
+		slowStart = NewHybridSlowStart(HyStartConfig{Disable: true})
+		rtt := 60 * time.Millisecond
+		slowStart.StartReceiveRound(1)
+		for n := 0; n < 100; n++ {
+			Expect(slowStart.ShouldExitSlowStart(2*rtt, rtt, 100)).To(BeFalse())
+		}
+	})
+
+	It("uses the configured number of RTT samples", func() {
+		slowStart = NewHybridSlowStart(HyStartConfig{MinRTTSamples: 2})
+		rtt := 60 * time.Millisecond
+		slowStart.StartReceiveRound(1)
+		Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeFalse())
+		Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeTrue())
+	})
+
+	It("uses the configured minimum RTT increase threshold", func() {
+		slowStart = NewHybridSlowStart(HyStartConfig{MinRTTIncreaseThreshold: 20 * time.Millisecond})
+		rtt := 60 * time.Millisecond
+		slowStart.StartReceiveRound(1)
+		// With the default threshold of 7.5ms, an increase of 10ms would trigger.
+		for n := 0; n < 8; n++ {
+			Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeFalse())
+		}
+		slowStart.StartReceiveRound(2)
+		for n := 1; n < 8; n++ {
+			Expect(slowStart.ShouldExitSlowStart(rtt+21*time.Millisecond, rtt, 100)).To(BeFalse())
+		}
+		Expect(slowStart.ShouldExitSlowStart(rtt+21*time.Millisecond, rtt, 100)).To(BeTrue())
+	})
+
+	It("uses the configured maximum RTT increase threshold", func() {
+		slowStart = NewHybridSlowStart(HyStartConfig{MaxRTTIncreaseThreshold: 40 * time.Millisecond})
+		rtt := 240 * time.Millisecond
+		slowStart.StartReceiveRound(1)
+		// With the default maximum threshold of 16ms, an increase of 20ms would trigger.
+		// The configured threshold is 240ms / 8 = 30ms.
+		for n := 0; n < 8; n++ {
+			Expect(slowStart.ShouldExitSlowStart(rtt+20*time.Millisecond, rtt, 100)).To(BeFalse())
+		}
+		slowStart.StartReceiveRound(2)
+		for n := 1; n < 8; n++ {
+			Expect(slowStart.ShouldExitSlowStart(rtt+31*time.Millisecond, rtt, 100)).To(BeFalse())
+		}
+		Expect(slowStart.ShouldExitSlowStart(rtt+31*time.Millisecond, rtt, 100)).To(BeTrue())
+	})
 })
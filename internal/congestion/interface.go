@@ -17,6 +17,10 @@ type SendAlgorithm interface {
 	OnPacketLost(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
 	OnRetransmissionTimeout(packetsRetransmitted bool)
 	SetMaxDatagramSize(protocol.ByteCount)
+	// OnConnectionMigration is called when the connection switched to a new path.
+	// The congestion state of the old path doesn't say anything about the new path.
+	// It is called with the max datagram size used on the new path, which might be smaller than on the old path.
+	OnConnectionMigration(maxDatagramSize protocol.ByteCount)
 }
 
 // A SendAlgorithmWithDebugInfos is a SendAlgorithm that exposes some debug infos
@@ -25,4 +29,12 @@ type SendAlgorithmWithDebugInfos interface {
 	InSlowStart() bool
 	InRecovery() bool
 	GetCongestionWindow() protocol.ByteCount
+	GetSlowStartThreshold() protocol.ByteCount
+	BandwidthEstimate() Bandwidth
+}
+
+// An AlgorithmReporter is a SendAlgorithm that advertises which congestion control algorithm it implements.
+// Send algorithms that don't implement it are reported as ALGO_UNKNOWN.
+type AlgorithmReporter interface {
+	CongestionAlgo() CongestionAlgo
 }
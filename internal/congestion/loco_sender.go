@@ -46,8 +46,12 @@ type locoSender struct {
 
 	maxDatagramSize protocol.ByteCount
 
-	lastState logging.CongestionState
-	tracer    logging.ConnectionTracer
+	// If set, no more packets are sent once this many bytes are in flight.
+	maxInFlight protocol.ByteCount
+
+	lastState     logging.CongestionState
+	tracer        logging.ConnectionTracer
+	congestionLog *CongestionLog
 }
 
 var (
@@ -55,22 +59,30 @@ var (
 	_ SendAlgorithmWithDebugInfos = &locoSender{}
 )
 
-// NewLocoSender makes a new loco sender
+// NewLocoSender makes a new loco sender.
+// If maxInFlight is 0, the number of bytes in flight is not limited.
 func NewLocoSender(
 	clock Clock,
 	rttStats *utils.RTTStats,
 	initialMaxDatagramSize protocol.ByteCount,
 	reno bool,
+	hyStartConfig HyStartConfig,
+	initialCongestionWindowPackets int,
+	maxInFlight protocol.ByteCount,
 	tracer logging.ConnectionTracer,
+	congestionLog *CongestionLog,
 ) *locoSender {
 	return newLocoSender(
 		clock,
 		rttStats,
 		reno,
+		hyStartConfig,
 		initialMaxDatagramSize,
-		initialCongestionWindow*initialMaxDatagramSize,
+		initialCongestionWindowSize(initialCongestionWindowPackets, initialMaxDatagramSize),
 		protocol.MaxCongestionWindowPackets*initialMaxDatagramSize,
+		maxInFlight,
 		tracer,
+		congestionLog,
 	)
 }
 
@@ -78,12 +90,16 @@ func newLocoSender(
 	clock Clock,
 	rttStats *utils.RTTStats,
 	reno bool,
+	hyStartConfig HyStartConfig,
 	initialMaxDatagramSize,
 	initialCongestionWindow,
-	initialMaxCongestionWindow protocol.ByteCount,
+	initialMaxCongestionWindow,
+	maxInFlight protocol.ByteCount,
 	tracer logging.ConnectionTracer,
+	congestionLog *CongestionLog,
 ) *locoSender {
 	l := &locoSender{
+		hybridSlowStart:            NewHybridSlowStart(hyStartConfig),
 		rttStats:                   rttStats,
 		largestSentPacketNumber:    protocol.InvalidPacketNumber,
 		largestAckedPacketNumber:   protocol.InvalidPacketNumber,
@@ -96,12 +112,15 @@ func newLocoSender(
 		clock:                      clock,
 		reno:                       reno,
 		tracer:                     tracer,
+		congestionLog:              congestionLog,
 		maxDatagramSize:            initialMaxDatagramSize,
+		maxInFlight:                maxInFlight,
 	}
 	if l.tracer != nil {
 		l.lastState = logging.CongestionStateSlowStart
 		l.tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
 	}
+	l.logCongestionState()
 	return l
 }
 
@@ -121,6 +140,10 @@ func (l *locoSender) maxCongestionWindow() protocol.ByteCount {
 	return l.maxDatagramSize * 10000
 }
 
+func (l *locoSender) GetSlowStartThreshold() protocol.ByteCount {
+	return l.slowStartThreshold
+}
+
 func (l *locoSender) minCongestionWindow() protocol.ByteCount {
 	// we don't allow any less than 1,000 packets in flight!
 	return l.maxDatagramSize * 10000
@@ -138,7 +161,8 @@ func (l *locoSender) OnPacketSent(
 
 func (l *locoSender) CanSend(bytesInFlight protocol.ByteCount) bool {
 	// send it!!
-	return true
+	// Unless we'd pile up an unbounded amount of data in a stalled receiver's buffer.
+	return l.maxInFlight == 0 || bytesInFlight < l.maxInFlight
 }
 
 func (l *locoSender) InRecovery() bool {
@@ -152,12 +176,16 @@ func (l *locoSender) InSlowStart() bool {
 }
 
 func (l *locoSender) GetCongestionWindow() protocol.ByteCount {
+	if l.maxInFlight > 0 {
+		return l.maxInFlight
+	}
 	// we'll just say it's 10,000 packets in flight
 	return l.maxDatagramSize * 10000
 }
 
 func (l *locoSender) MaybeExitSlowStart() {
-	// we don't care about any of this
+	// we don't care about any of this, we were never in slow start anyway
+	l.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
 }
 
 func (l *locoSender) OnPacketAcked(
@@ -171,6 +199,9 @@ func (l *locoSender) OnPacketAcked(
 
 func (l *locoSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
 	// we're like the USPS we don't lose anything and if we do we'll just deny it!!
+	// The tracer still gets to see a short recovery blip, so qlogs look like those of the other senders.
+	l.maybeTraceStateChange(logging.CongestionStateRecovery)
+	l.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
 }
 
 // Called when we receive an ack. Normal TCP tracks how many packets one ack
@@ -198,20 +229,36 @@ func (l *locoSender) BandwidthEstimate() Bandwidth {
 // OnRetransmissionTimeout is called on an retransmission timeout
 func (l *locoSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
 	// timeout's are for little kids
+	if !packetsRetransmitted {
+		return
+	}
+	l.maybeTraceStateChange(logging.CongestionStateRecovery)
+	l.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
 }
 
-// OnConnectionMigration is called when the connection is migrated (?)
-func (l *locoSender) OnConnectionMigration() {
+// OnConnectionMigration is called when the connection is migrated.
+// The loco sender only reacts to delay measurements, so apart from the datagram size, there's no state that needs to be reset.
+func (l *locoSender) OnConnectionMigration(maxDatagramSize protocol.ByteCount) {
+	l.maxDatagramSize = maxDatagramSize
 }
 
 func (l *locoSender) maybeTraceStateChange(new logging.CongestionState) {
-	if l.tracer == nil || new == l.lastState {
+	if new == l.lastState {
 		return
 	}
-	l.tracer.UpdatedCongestionState(new)
 	l.lastState = new
+	if l.tracer != nil {
+		l.tracer.UpdatedCongestionState(new)
+	}
+	l.logCongestionState()
+}
+
+// logCongestionState writes the current state to the congestion log, if one is set
+func (l *locoSender) logCongestionState() {
+	l.congestionLog.Update(l.lastState, l.GetCongestionWindow(), l.BandwidthEstimate(), l.rttStats.SmoothedRTT())
 }
 
 func (l *locoSender) SetMaxDatagramSize(s protocol.ByteCount) {
 	l.maxDatagramSize = s
+	l.logCongestionState()
 }
@@ -0,0 +1,95 @@
+package congestion
+
+import (
+	"github.com/golang/mock/gomock"
+
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
+	"github.com/BGrewell/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Loco Sender", func() {
+	var (
+		sender   *locoSender
+		mockCtrl *gomock.Controller
+		tracer   *mocklogging.MockConnectionTracer
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
+		tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateSlowStart)
+		clock := mockClock{}
+		sender = NewLocoSender(&clock, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 0, tracer, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("never limits sending", func() {
+		Expect(sender.CanSend(protocol.MaxByteCount)).To(BeTrue())
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+		Expect(sender.InSlowStart()).To(BeFalse())
+		Expect(sender.InRecovery()).To(BeFalse())
+	})
+
+	It("limits the number of bytes in flight, if configured", func() {
+		sender = NewLocoSender(DefaultClock{}, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 10*maxDatagramSize, nil, nil)
+		Expect(sender.CanSend(9 * maxDatagramSize)).To(BeTrue())
+		Expect(sender.CanSend(10 * maxDatagramSize)).To(BeFalse())
+		Expect(sender.GetCongestionWindow()).To(Equal(10 * maxDatagramSize))
+		// it still doesn't pace
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+		Expect(sender.TimeUntilSend(9 * maxDatagramSize)).To(BeZero())
+		// and it doesn't reduce the limit on packet loss
+		sender.OnPacketLost(1, maxDatagramSize, 10*maxDatagramSize)
+		Expect(sender.InRecovery()).To(BeFalse())
+		Expect(sender.CanSend(9 * maxDatagramSize)).To(BeTrue())
+	})
+
+	It("traces exiting slow start", func() {
+		tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateCongestionAvoidance)
+		sender.MaybeExitSlowStart()
+		// the state didn't change, so this one isn't traced
+		sender.MaybeExitSlowStart()
+	})
+
+	It("traces a recovery blip when a packet is lost", func() {
+		gomock.InOrder(
+			tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateRecovery),
+			tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateCongestionAvoidance),
+		)
+		sender.OnPacketLost(1, maxDatagramSize, 10*maxDatagramSize)
+	})
+
+	It("traces a recovery blip on a retransmission timeout", func() {
+		gomock.InOrder(
+			tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateRecovery),
+			tracer.EXPECT().UpdatedCongestionState(logging.CongestionStateCongestionAvoidance),
+		)
+		sender.OnRetransmissionTimeout(true)
+	})
+
+	It("doesn't trace a retransmission timeout if no packets were retransmitted", func() {
+		sender.OnRetransmissionTimeout(false)
+	})
+
+	It("uses the configured initial congestion window", func() {
+		// the loco sender doesn't limit sending, but it still keeps track of the congestion window
+		Expect(sender.congestionWindow).To(Equal(initialCongestionWindow * maxDatagramSize))
+		sender = NewLocoSender(DefaultClock{}, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 100, 0, nil, nil)
+		Expect(sender.congestionWindow).To(Equal(100 * maxDatagramSize))
+	})
+
+	It("works without a tracer", func() {
+		sender = NewLocoSender(DefaultClock{}, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 0, nil, nil)
+		sender.MaybeExitSlowStart()
+		sender.OnPacketLost(1, maxDatagramSize, 10*maxDatagramSize)
+		sender.OnRetransmissionTimeout(true)
+	})
+})
@@ -35,6 +35,20 @@ func newPacer(getBandwidth func() Bandwidth) *pacer {
 	return p
 }
 
+// newRateLimitingPacer creates a pacer that never exceeds the given rate.
+// Unlike the pacer used by the congestion controller, it doesn't send faster than the rate,
+// in order to enforce a hard limit.
+func newRateLimitingPacer(rate Bandwidth) *pacer {
+	// Bandwidth is in bits/s. We need the value in bytes/s.
+	bw := utils.MaxUint64(uint64(rate/BytesPerSecond), 1)
+	p := &pacer{
+		maxDatagramSize:      initialMaxDatagramSize,
+		getAdjustedBandwidth: func() uint64 { return bw },
+	}
+	p.budgetAtLastSent = p.maxBurstSize()
+	return p
+}
+
 func (p *pacer) SentPacket(sendTime time.Time, size protocol.ByteCount) {
 	budget := p.Budget(sendTime)
 	if size > budget {
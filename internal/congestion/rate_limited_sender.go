@@ -0,0 +1,83 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
+// The rateLimitedSender wraps a SendAlgorithm, and makes sure that the send rate never exceeds a fixed maximum.
+type rateLimitedSender struct {
+	SendAlgorithmWithDebugInfos
+
+	clock           Clock
+	pacer           *pacer
+	maxDatagramSize protocol.ByteCount
+}
+
+var (
+	_ SendAlgorithmWithDebugInfos = &rateLimitedSender{}
+	_ AlgorithmReporter           = &rateLimitedSender{}
+)
+
+// NewRateLimitedSender wraps a SendAlgorithm, such that it never sends faster than maxRate.
+func NewRateLimitedSender(
+	sender SendAlgorithmWithDebugInfos,
+	clock Clock,
+	initialMaxDatagramSize protocol.ByteCount,
+	maxRate Bandwidth,
+) SendAlgorithmWithDebugInfos {
+	s := &rateLimitedSender{
+		SendAlgorithmWithDebugInfos: sender,
+		clock:                       clock,
+		pacer:                       newRateLimitingPacer(maxRate),
+		maxDatagramSize:             initialMaxDatagramSize,
+	}
+	s.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	return s
+}
+
+// TimeUntilSend returns when the next packet should be sent.
+// This is the later of the time returned by the underlying SendAlgorithm and the rate limit.
+func (s *rateLimitedSender) TimeUntilSend(bytesInFlight protocol.ByteCount) time.Time {
+	t := s.SendAlgorithmWithDebugInfos.TimeUntilSend(bytesInFlight)
+	if limit := s.pacer.TimeUntilSend(); limit.After(t) {
+		return limit
+	}
+	return t
+}
+
+func (s *rateLimitedSender) HasPacingBudget() bool {
+	return s.pacer.Budget(s.clock.Now()) >= s.maxDatagramSize && s.SendAlgorithmWithDebugInfos.HasPacingBudget()
+}
+
+func (s *rateLimitedSender) OnPacketSent(
+	sentTime time.Time,
+	bytesInFlight protocol.ByteCount,
+	packetNumber protocol.PacketNumber,
+	bytes protocol.ByteCount,
+	isRetransmittable bool,
+) {
+	s.pacer.SentPacket(sentTime, bytes)
+	s.SendAlgorithmWithDebugInfos.OnPacketSent(sentTime, bytesInFlight, packetNumber, bytes, isRetransmittable)
+}
+
+func (s *rateLimitedSender) OnConnectionMigration(size protocol.ByteCount) {
+	s.maxDatagramSize = size
+	s.pacer.SetMaxDatagramSize(size)
+	s.SendAlgorithmWithDebugInfos.OnConnectionMigration(size)
+}
+
+func (s *rateLimitedSender) SetMaxDatagramSize(size protocol.ByteCount) {
+	s.maxDatagramSize = size
+	s.pacer.SetMaxDatagramSize(size)
+	s.SendAlgorithmWithDebugInfos.SetMaxDatagramSize(size)
+}
+
+// CongestionAlgo returns the congestion control algorithm implemented by the underlying SendAlgorithm.
+func (s *rateLimitedSender) CongestionAlgo() CongestionAlgo {
+	if r, ok := s.SendAlgorithmWithDebugInfos.(AlgorithmReporter); ok {
+		return r.CongestionAlgo()
+	}
+	return ALGO_UNKNOWN
+}
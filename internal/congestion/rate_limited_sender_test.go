@@ -0,0 +1,94 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+	"github.com/BGrewell/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate Limited Sender", func() {
+	const maxRate = 1000 * Bandwidth(maxDatagramSize) * BytesPerSecond // 1000 full-size packets per second
+
+	var clock *mockClock
+
+	BeforeEach(func() {
+		c := mockClock(time.Now())
+		clock = &c
+	})
+
+	// sendBytes sends packets until the given number of bytes has been sent.
+	// It advances the clock whenever the sender is pacing limited, and returns the time it took.
+	sendBytes := func(sender SendAlgorithmWithDebugInfos, total protocol.ByteCount) time.Duration {
+		start := clock.Now()
+		var pn protocol.PacketNumber
+		for sent := protocol.ByteCount(0); sent < total; sent += maxDatagramSize {
+			if !sender.HasPacingBudget() {
+				t := sender.TimeUntilSend(0)
+				ExpectWithOffset(1, t).To(BeTemporally(">", clock.Now()))
+				clock.Advance(t.Sub(clock.Now()))
+				ExpectWithOffset(1, sender.HasPacingBudget()).To(BeTrue())
+			}
+			sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+			pn++
+		}
+		return clock.Now().Sub(start)
+	}
+
+	It("limits the send rate of the loco sender", func() {
+		loco := NewLocoSender(clock, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 0, nil, nil)
+		sender := NewRateLimitedSender(loco, clock, maxDatagramSize, maxRate)
+		const total = 5000 * maxDatagramSize
+		elapsed := sendBytes(sender, total)
+		// allow for the initial burst
+		maxBurst := maxBurstSizePackets * maxDatagramSize
+		Expect(float64(total-maxBurst) / elapsed.Seconds()).To(BeNumerically("<=", float64(maxRate/BytesPerSecond)))
+		Expect(float64(total) / elapsed.Seconds()).To(BeNumerically(">", 0.95*float64(maxRate/BytesPerSecond)))
+	})
+
+	It("limits the send rate of the cubic sender", func() {
+		// use a small RTT, such that the cubic sender's pacing rate is higher than the rate limit
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(time.Millisecond, 0, clock.Now())
+		cubic := NewCubicSender(clock, rttStats, maxDatagramSize, ALGO_RENO, HyStartConfig{}, 0, 0, nil, nil, nil)
+		sender := NewRateLimitedSender(cubic, clock, maxDatagramSize, maxRate)
+		const total = 5000 * maxDatagramSize
+		elapsed := sendBytes(sender, total)
+		maxBurst := maxBurstSizePackets * maxDatagramSize
+		Expect(float64(total-maxBurst) / elapsed.Seconds()).To(BeNumerically("<=", float64(maxRate/BytesPerSecond)))
+	})
+
+	It("doesn't send faster than the underlying sender", func() {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(100*time.Millisecond, 0, clock.Now())
+		cubic := NewCubicSender(clock, rttStats, maxDatagramSize, ALGO_RENO, HyStartConfig{}, 0, 0, nil, nil, nil)
+		// use a high rate limit, such that the cubic sender's pacer is the limiting factor
+		sender := NewRateLimitedSender(cubic, clock, maxDatagramSize, 1000*maxRate)
+		for cubic.HasPacingBudget() {
+			Expect(sender.HasPacingBudget()).To(BeTrue())
+			sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		}
+		Expect(sender.HasPacingBudget()).To(BeFalse())
+		Expect(sender.TimeUntilSend(0)).To(Equal(cubic.TimeUntilSend(0)))
+	})
+
+	It("reports the congestion control algorithm of the underlying sender", func() {
+		cubic := NewCubicSender(clock, &utils.RTTStats{}, maxDatagramSize, ALGO_CUBIC_RFC8312, HyStartConfig{}, 0, 0, nil, nil, nil)
+		sender := NewRateLimitedSender(cubic, clock, maxDatagramSize, maxRate)
+		Expect(sender.(AlgorithmReporter).CongestionAlgo()).To(Equal(ALGO_CUBIC_RFC8312))
+		loco := NewLocoSender(clock, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 0, nil, nil)
+		sender = NewRateLimitedSender(loco, clock, maxDatagramSize, maxRate)
+		Expect(sender.(AlgorithmReporter).CongestionAlgo()).To(Equal(ALGO_UNKNOWN))
+	})
+
+	It("updates the max datagram size", func() {
+		loco := NewLocoSender(clock, &utils.RTTStats{}, maxDatagramSize, false, HyStartConfig{}, 0, 0, nil, nil)
+		sender := NewRateLimitedSender(loco, clock, maxDatagramSize, maxRate)
+		sender.SetMaxDatagramSize(maxDatagramSize + 100)
+		Expect(loco.maxDatagramSize).To(Equal(maxDatagramSize + 100))
+		Expect(sender.(*rateLimitedSender).pacer.maxDatagramSize).To(Equal(maxDatagramSize + 100))
+	})
+})
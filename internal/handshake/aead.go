@@ -9,9 +9,12 @@ import (
 	"github.com/BGrewell/quic-go/internal/utils"
 )
 
-func createAEAD(suite *qtls.CipherSuiteTLS13, trafficSecret []byte) cipher.AEAD {
-	key := hkdfExpandLabel(suite.Hash, trafficSecret, []byte{}, "quic key", suite.KeyLen)
-	iv := hkdfExpandLabel(suite.Hash, trafficSecret, []byte{}, "quic iv", suite.IVLen())
+func createAEAD(suite *qtls.CipherSuiteTLS13, trafficSecret []byte, aeadFactory AEADFactory, v protocol.VersionNumber) cipher.AEAD {
+	key := hkdfExpandLabel(suite.Hash, trafficSecret, []byte{}, hkdfKeyLabel(v), suite.KeyLen)
+	iv := hkdfExpandLabel(suite.Hash, trafficSecret, []byte{}, hkdfIVLabel(v), suite.IVLen())
+	if aeadFactory != nil {
+		return aeadFactory(suite.ID, key, iv)
+	}
 	return suite.AEAD(key, iv)
 }
 
@@ -115,10 +115,15 @@ type cryptoSetup struct {
 	clientHelloWritten     bool
 	clientHelloWrittenChan chan *wire.TransportParameters
 
-	rttStats *utils.RTTStats
+	rttStats    *utils.RTTStats
+	aeadFactory AEADFactory
 
 	tracer logging.ConnectionTracer
-	logger utils.Logger
+	// finds the handshake messages written by TLS, for tracing. Protected by the mutex.
+	sentMessages messageSplitter
+	// the encryption levels for which ReceivedKeys was traced, only accessed from the TLS go routine
+	receivedKeys map[protocol.EncryptionLevel]struct{}
+	logger       utils.Logger
 
 	perspective protocol.Perspective
 
@@ -162,6 +167,7 @@ func NewCryptoSetupClient(
 	tlsConf *tls.Config,
 	enable0RTT bool,
 	rttStats *utils.RTTStats,
+	aeadFactory AEADFactory,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	version protocol.VersionNumber,
@@ -175,6 +181,7 @@ func NewCryptoSetupClient(
 		tlsConf,
 		enable0RTT,
 		rttStats,
+		aeadFactory,
 		tracer,
 		logger,
 		protocol.PerspectiveClient,
@@ -196,6 +203,7 @@ func NewCryptoSetupServer(
 	tlsConf *tls.Config,
 	enable0RTT bool,
 	rttStats *utils.RTTStats,
+	aeadFactory AEADFactory,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	version protocol.VersionNumber,
@@ -209,6 +217,7 @@ func NewCryptoSetupServer(
 		tlsConf,
 		enable0RTT,
 		rttStats,
+		aeadFactory,
 		tracer,
 		logger,
 		protocol.PerspectiveServer,
@@ -227,6 +236,7 @@ func newCryptoSetup(
 	tlsConf *tls.Config,
 	enable0RTT bool,
 	rttStats *utils.RTTStats,
+	aeadFactory AEADFactory,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	perspective protocol.Perspective,
@@ -244,14 +254,16 @@ func newCryptoSetup(
 		initialSealer:             initialSealer,
 		initialOpener:             initialOpener,
 		handshakeStream:           handshakeStream,
-		aead:                      newUpdatableAEAD(rttStats, tracer, logger),
+		aead:                      newUpdatableAEAD(rttStats, aeadFactory, tracer, logger, version),
 		readEncLevel:              protocol.EncryptionInitial,
 		writeEncLevel:             protocol.EncryptionInitial,
 		runner:                    runner,
 		ourParams:                 tp,
 		paramsChan:                extHandler.TransportParameters(),
 		rttStats:                  rttStats,
+		aeadFactory:               aeadFactory,
 		tracer:                    tracer,
+		receivedKeys:              make(map[protocol.EncryptionLevel]struct{}),
 		logger:                    logger,
 		perspective:               perspective,
 		handshakeDone:             make(chan struct{}),
@@ -343,6 +355,9 @@ func (h *cryptoSetup) Close() error {
 func (h *cryptoSetup) HandleMessage(data []byte, encLevel protocol.EncryptionLevel) bool /* stream finished */ {
 	msgType := messageType(data[0])
 	h.logger.Debugf("Received %s message (%d bytes, encryption level: %s)", msgType, len(data), encLevel)
+	if h.tracer != nil {
+		h.tracer.ReceivedHandshakeMessage(logging.HandshakeMessageType(msgType))
+	}
 	if err := h.checkEncryptionLevel(msgType, encLevel); err != nil {
 		h.onError(alertUnexpectedMessage, err.Error())
 		return false
@@ -554,20 +569,18 @@ func (h *cryptoSetup) SetReadKey(encLevel qtls.EncryptionLevel, suite *qtls.Ciph
 			panic("Received 0-RTT read key for the client")
 		}
 		h.zeroRTTOpener = newLongHeaderOpener(
-			createAEAD(suite, trafficSecret),
-			newHeaderProtector(suite, trafficSecret, true),
+			createAEAD(suite, trafficSecret, h.aeadFactory, h.version),
+			newHeaderProtector(suite, trafficSecret, true, h.version),
 		)
 		h.mutex.Unlock()
 		h.logger.Debugf("Installed 0-RTT Read keys (using %s)", tls.CipherSuiteName(suite.ID))
-		if h.tracer != nil {
-			h.tracer.UpdatedKeyFromTLS(protocol.Encryption0RTT, h.perspective.Opposite())
-		}
+		h.updatedKeyFromTLS(protocol.Encryption0RTT, h.perspective.Opposite())
 		return
 	case qtls.EncryptionHandshake:
 		h.readEncLevel = protocol.EncryptionHandshake
 		h.handshakeOpener = newHandshakeOpener(
-			createAEAD(suite, trafficSecret),
-			newHeaderProtector(suite, trafficSecret, true),
+			createAEAD(suite, trafficSecret, h.aeadFactory, h.version),
+			newHeaderProtector(suite, trafficSecret, true, h.version),
 			h.dropInitialKeys,
 			h.perspective,
 		)
@@ -581,9 +594,7 @@ func (h *cryptoSetup) SetReadKey(encLevel qtls.EncryptionLevel, suite *qtls.Ciph
 		panic("unexpected read encryption level")
 	}
 	h.mutex.Unlock()
-	if h.tracer != nil {
-		h.tracer.UpdatedKeyFromTLS(h.readEncLevel, h.perspective.Opposite())
-	}
+	h.updatedKeyFromTLS(h.readEncLevel, h.perspective.Opposite())
 }
 
 func (h *cryptoSetup) SetWriteKey(encLevel qtls.EncryptionLevel, suite *qtls.CipherSuiteTLS13, trafficSecret []byte) {
@@ -594,20 +605,18 @@ func (h *cryptoSetup) SetWriteKey(encLevel qtls.EncryptionLevel, suite *qtls.Cip
 			panic("Received 0-RTT write key for the server")
 		}
 		h.zeroRTTSealer = newLongHeaderSealer(
-			createAEAD(suite, trafficSecret),
-			newHeaderProtector(suite, trafficSecret, true),
+			createAEAD(suite, trafficSecret, h.aeadFactory, h.version),
+			newHeaderProtector(suite, trafficSecret, true, h.version),
 		)
 		h.mutex.Unlock()
 		h.logger.Debugf("Installed 0-RTT Write keys (using %s)", tls.CipherSuiteName(suite.ID))
-		if h.tracer != nil {
-			h.tracer.UpdatedKeyFromTLS(protocol.Encryption0RTT, h.perspective)
-		}
+		h.updatedKeyFromTLS(protocol.Encryption0RTT, h.perspective)
 		return
 	case qtls.EncryptionHandshake:
 		h.writeEncLevel = protocol.EncryptionHandshake
 		h.handshakeSealer = newHandshakeSealer(
-			createAEAD(suite, trafficSecret),
-			newHeaderProtector(suite, trafficSecret, true),
+			createAEAD(suite, trafficSecret, h.aeadFactory, h.version),
+			newHeaderProtector(suite, trafficSecret, true, h.version),
 			h.dropInitialKeys,
 			h.perspective,
 		)
@@ -628,8 +637,20 @@ func (h *cryptoSetup) SetWriteKey(encLevel qtls.EncryptionLevel, suite *qtls.Cip
 		panic("unexpected write encryption level")
 	}
 	h.mutex.Unlock()
-	if h.tracer != nil {
-		h.tracer.UpdatedKeyFromTLS(h.writeEncLevel, h.perspective)
+	h.updatedKeyFromTLS(h.writeEncLevel, h.perspective)
+}
+
+// updatedKeyFromTLS traces the installation of a key.
+// ReceivedKeys is traced when the first key of an encryption level is installed.
+// It is only called from the TLS go routine.
+func (h *cryptoSetup) updatedKeyFromTLS(encLevel protocol.EncryptionLevel, pers protocol.Perspective) {
+	if h.tracer == nil {
+		return
+	}
+	h.tracer.UpdatedKeyFromTLS(encLevel, pers)
+	if _, ok := h.receivedKeys[encLevel]; !ok {
+		h.receivedKeys[encLevel] = struct{}{}
+		h.tracer.ReceivedKeys(encLevel)
 	}
 }
 
@@ -638,6 +659,10 @@ func (h *cryptoSetup) WriteRecord(p []byte) (int, error) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
+	if h.tracer != nil {
+		h.sentMessages.Write(p, h.traceSentHandshakeMessage)
+	}
+
 	//nolint:exhaustive // LS records can only be written for Initial and Handshake.
 	switch h.writeEncLevel {
 	case protocol.EncryptionInitial:
@@ -661,6 +686,10 @@ func (h *cryptoSetup) WriteRecord(p []byte) (int, error) {
 	}
 }
 
+func (h *cryptoSetup) traceSentHandshakeMessage(t messageType) {
+	h.tracer.SentHandshakeMessage(logging.HandshakeMessageType(t))
+}
+
 func (h *cryptoSetup) SendAlert(alert uint8) {
 	select {
 	case h.alertChan <- alert:
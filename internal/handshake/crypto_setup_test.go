@@ -2,20 +2,25 @@ package handshake
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"math/big"
+	"sync"
 	"time"
 
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
 	mocktls "github.com/BGrewell/quic-go/internal/mocks/tls"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
+	"github.com/BGrewell/quic-go/internal/qtls"
 	"github.com/BGrewell/quic-go/internal/testdata"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
+	"github.com/BGrewell/quic-go/logging"
 
 	"github.com/golang/mock/gomock"
 
@@ -98,6 +103,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			false,
 			&utils.RTTStats{},
 			nil,
+			nil,
 			utils.DefaultLogger.WithPrefix("server"),
 			protocol.VersionTLS,
 		)
@@ -124,6 +130,40 @@ var _ = Describe("Crypto Setup TLS", func() {
 		Eventually(done).Should(BeClosed())
 	})
 
+	It("uses the AEAD factory for Handshake keys", func() {
+		var token protocol.StatelessResetToken
+		var aeads []*countingAEAD
+		_, sInitialStream, sHandshakeStream := initStreams()
+		server := NewCryptoSetupServer(
+			sInitialStream,
+			sHandshakeStream,
+			protocol.ConnectionID{},
+			nil,
+			nil,
+			&wire.TransportParameters{StatelessResetToken: &token},
+			NewMockHandshakeRunner(mockCtrl),
+			testdata.GetTLSConfig(),
+			false,
+			&utils.RTTStats{},
+			func(suite uint16, key, iv []byte) cipher.AEAD {
+				a := &countingAEAD{AEAD: cipherSuites[0].AEAD(key, iv)}
+				aeads = append(aeads, a)
+				return a
+			},
+			nil,
+			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionTLS,
+		)
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		server.(*cryptoSetup).SetWriteKey(qtls.EncryptionHandshake, cipherSuites[0], secret)
+		Expect(aeads).To(HaveLen(1))
+		sealer, err := server.GetHandshakeSealer()
+		Expect(err).ToNot(HaveOccurred())
+		sealer.Seal(nil, []byte("foobar"), 42, []byte("ad"))
+		Expect(aeads[0].numSealed).To(Equal(1))
+	})
+
 	It("errors when a message is received at the wrong encryption level", func() {
 		sErrChan := make(chan error, 1)
 		_, sInitialStream, sHandshakeStream := initStreams()
@@ -142,6 +182,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			false,
 			&utils.RTTStats{},
 			nil,
+			nil,
 			utils.DefaultLogger.WithPrefix("server"),
 			protocol.VersionTLS,
 		)
@@ -183,6 +224,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			false,
 			&utils.RTTStats{},
 			nil,
+			nil,
 			utils.DefaultLogger.WithPrefix("server"),
 			protocol.VersionTLS,
 		)
@@ -218,6 +260,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			false,
 			&utils.RTTStats{},
 			nil,
+			nil,
 			utils.DefaultLogger.WithPrefix("server"),
 			protocol.VersionTLS,
 		)
@@ -335,6 +378,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				enable0RTT,
 				clientRTTStats,
 				nil,
+				nil,
 				utils.DefaultLogger.WithPrefix("client"),
 				protocol.VersionTLS,
 			)
@@ -362,6 +406,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				enable0RTT,
 				serverRTTStats,
 				nil,
+				nil,
 				utils.DefaultLogger.WithPrefix("server"),
 				protocol.VersionTLS,
 			)
@@ -432,6 +477,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				false,
 				&utils.RTTStats{},
 				nil,
+				nil,
 				utils.DefaultLogger.WithPrefix("client"),
 				protocol.VersionTLS,
 			)
@@ -475,6 +521,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				false,
 				&utils.RTTStats{},
 				nil,
+				nil,
 				utils.DefaultLogger.WithPrefix("client"),
 				protocol.VersionTLS,
 			)
@@ -500,6 +547,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				false,
 				&utils.RTTStats{},
 				nil,
+				nil,
 				utils.DefaultLogger.WithPrefix("server"),
 				protocol.VersionTLS,
 			)
@@ -516,6 +564,164 @@ var _ = Describe("Crypto Setup TLS", func() {
 			Expect(sTransportParametersRcvd.MaxIdleTimeout).To(Equal(sTransportParameters.MaxIdleTimeout))
 		})
 
+		It("traces when the keys of an encryption level become available", func() {
+			cChunkChan, cInitialStream, cHandshakeStream := initStreams()
+			cRunner := NewMockHandshakeRunner(mockCtrl)
+			cRunner.EXPECT().OnReceivedParams(gomock.Any())
+			cRunner.EXPECT().OnHandshakeComplete()
+			cTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			cTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+			cTracer.EXPECT().SentHandshakeMessage(gomock.Any()).AnyTimes()
+			cTracer.EXPECT().ReceivedHandshakeMessage(gomock.Any()).AnyTimes()
+			gomock.InOrder(
+				cTracer.EXPECT().ReceivedKeys(protocol.EncryptionHandshake),
+				cTracer.EXPECT().ReceivedKeys(protocol.Encryption1RTT),
+			)
+			client, _ := NewCryptoSetupClient(
+				cInitialStream,
+				cHandshakeStream,
+				protocol.ConnectionID{},
+				nil,
+				nil,
+				&wire.TransportParameters{},
+				cRunner,
+				clientConf,
+				false,
+				&utils.RTTStats{},
+				nil,
+				cTracer,
+				utils.DefaultLogger.WithPrefix("client"),
+				protocol.VersionTLS,
+			)
+
+			sChunkChan, sInitialStream, sHandshakeStream := initStreams()
+			sRunner := NewMockHandshakeRunner(mockCtrl)
+			sRunner.EXPECT().OnReceivedParams(gomock.Any())
+			sRunner.EXPECT().OnHandshakeComplete()
+			sTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			sTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+			sTracer.EXPECT().SentHandshakeMessage(gomock.Any()).AnyTimes()
+			sTracer.EXPECT().ReceivedHandshakeMessage(gomock.Any()).AnyTimes()
+			gomock.InOrder(
+				sTracer.EXPECT().ReceivedKeys(protocol.EncryptionHandshake),
+				sTracer.EXPECT().ReceivedKeys(protocol.Encryption1RTT),
+			)
+			var token protocol.StatelessResetToken
+			server := NewCryptoSetupServer(
+				sInitialStream,
+				sHandshakeStream,
+				protocol.ConnectionID{},
+				nil,
+				nil,
+				&wire.TransportParameters{StatelessResetToken: &token},
+				sRunner,
+				serverConf,
+				false,
+				&utils.RTTStats{},
+				nil,
+				sTracer,
+				utils.DefaultLogger.WithPrefix("server"),
+				protocol.VersionTLS,
+			)
+
+			handshake(client, cChunkChan, server, sChunkChan)
+		})
+
+		It("traces the handshake messages", func() {
+			type handshakeMessage struct {
+				sent    bool
+				msgType logging.HandshakeMessageType
+			}
+			newTracer := func() (*mocklogging.MockConnectionTracer, func() []handshakeMessage) {
+				var mutex sync.Mutex
+				var msgs []handshakeMessage
+				tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				tracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+				tracer.EXPECT().ReceivedKeys(gomock.Any()).AnyTimes()
+				tracer.EXPECT().SentHandshakeMessage(gomock.Any()).Do(func(t logging.HandshakeMessageType) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					msgs = append(msgs, handshakeMessage{sent: true, msgType: t})
+				}).AnyTimes()
+				tracer.EXPECT().ReceivedHandshakeMessage(gomock.Any()).Do(func(t logging.HandshakeMessageType) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					msgs = append(msgs, handshakeMessage{msgType: t})
+				}).AnyTimes()
+				return tracer, func() []handshakeMessage {
+					mutex.Lock()
+					defer mutex.Unlock()
+					return msgs
+				}
+			}
+
+			cChunkChan, cInitialStream, cHandshakeStream := initStreams()
+			cRunner := NewMockHandshakeRunner(mockCtrl)
+			cRunner.EXPECT().OnReceivedParams(gomock.Any())
+			cRunner.EXPECT().OnHandshakeComplete()
+			cTracer, getClientMessages := newTracer()
+			client, _ := NewCryptoSetupClient(
+				cInitialStream,
+				cHandshakeStream,
+				protocol.ConnectionID{},
+				nil,
+				nil,
+				&wire.TransportParameters{},
+				cRunner,
+				clientConf,
+				false,
+				&utils.RTTStats{},
+				nil,
+				cTracer,
+				utils.DefaultLogger.WithPrefix("client"),
+				protocol.VersionTLS,
+			)
+
+			sChunkChan, sInitialStream, sHandshakeStream := initStreams()
+			sRunner := NewMockHandshakeRunner(mockCtrl)
+			sRunner.EXPECT().OnReceivedParams(gomock.Any())
+			sRunner.EXPECT().OnHandshakeComplete()
+			sTracer, getServerMessages := newTracer()
+			var token protocol.StatelessResetToken
+			server := NewCryptoSetupServer(
+				sInitialStream,
+				sHandshakeStream,
+				protocol.ConnectionID{},
+				nil,
+				nil,
+				&wire.TransportParameters{StatelessResetToken: &token},
+				sRunner,
+				serverConf,
+				false,
+				&utils.RTTStats{},
+				nil,
+				sTracer,
+				utils.DefaultLogger.WithPrefix("server"),
+				protocol.VersionTLS,
+			)
+
+			handshake(client, cChunkChan, server, sChunkChan)
+			Expect(getClientMessages()).To(Equal([]handshakeMessage{
+				{sent: true, msgType: logging.HandshakeMessageClientHello},
+				{msgType: logging.HandshakeMessageServerHello},
+				{msgType: logging.HandshakeMessageEncryptedExtensions},
+				{msgType: logging.HandshakeMessageCertificate},
+				{msgType: logging.HandshakeMessageCertificateVerify},
+				{msgType: logging.HandshakeMessageFinished},
+				{sent: true, msgType: logging.HandshakeMessageFinished},
+				{msgType: logging.HandshakeMessageNewSessionTicket},
+			}))
+			Expect(getServerMessages()).To(Equal([]handshakeMessage{
+				{msgType: logging.HandshakeMessageClientHello},
+				{sent: true, msgType: logging.HandshakeMessageServerHello},
+				{sent: true, msgType: logging.HandshakeMessageEncryptedExtensions},
+				{sent: true, msgType: logging.HandshakeMessageCertificate},
+				{sent: true, msgType: logging.HandshakeMessageCertificateVerify},
+				{sent: true, msgType: logging.HandshakeMessageFinished},
+				{msgType: logging.HandshakeMessageFinished},
+			}))
+		})
+
 		Context("with session tickets", func() {
 			It("errors when the NewSessionTicket is sent at the wrong encryption level", func() {
 				cChunkChan, cInitialStream, cHandshakeStream := initStreams()
@@ -534,6 +740,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					false,
 					&utils.RTTStats{},
 					nil,
+					nil,
 					utils.DefaultLogger.WithPrefix("client"),
 					protocol.VersionTLS,
 				)
@@ -555,6 +762,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					false,
 					&utils.RTTStats{},
 					nil,
+					nil,
 					utils.DefaultLogger.WithPrefix("server"),
 					protocol.VersionTLS,
 				)
@@ -593,6 +801,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					false,
 					&utils.RTTStats{},
 					nil,
+					nil,
 					utils.DefaultLogger.WithPrefix("client"),
 					protocol.VersionTLS,
 				)
@@ -614,6 +823,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					false,
 					&utils.RTTStats{},
 					nil,
+					nil,
 					utils.DefaultLogger.WithPrefix("server"),
 					protocol.VersionTLS,
 				)
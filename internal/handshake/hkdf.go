@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 
 	"golang.org/x/crypto/hkdf"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
 )
 
 // hkdfExpandLabel HKDF expands a label.
@@ -27,3 +29,33 @@ func hkdfExpandLabel(hash crypto.Hash, secret, context []byte, label string, len
 	}
 	return out
 }
+
+// QUIC version 2 uses different labels to derive the packet protection keys, see section 3.3.2 of RFC 9369.
+
+func hkdfKeyLabel(v protocol.VersionNumber) string {
+	if v == protocol.Version2 {
+		return "quicv2 key"
+	}
+	return "quic key"
+}
+
+func hkdfIVLabel(v protocol.VersionNumber) string {
+	if v == protocol.Version2 {
+		return "quicv2 iv"
+	}
+	return "quic iv"
+}
+
+func hkdfHeaderProtectionLabel(v protocol.VersionNumber) string {
+	if v == protocol.Version2 {
+		return "quicv2 hp"
+	}
+	return "quic hp"
+}
+
+func hkdfKeyUpdateLabel(v protocol.VersionNumber) string {
+	if v == protocol.Version2 {
+		return "quicv2 ku"
+	}
+	return "quic ku"
+}
@@ -1,6 +1,7 @@
 package handshake
 
 import (
+	"crypto/cipher"
 	"errors"
 	"io"
 	"net"
@@ -23,6 +24,10 @@ var (
 	ErrDecryptionFailed = errors.New("decryption failed")
 )
 
+// An AEADFactory creates the AEAD for a TLS 1.3 cipher suite.
+// The nonce passed to the AEAD is the left-padded packet number, which the AEAD must XOR with the iv.
+type AEADFactory func(suite uint16, key, iv []byte) cipher.AEAD
+
 // ConnectionState contains information about the state of the connection.
 type ConnectionState = qtls.ConnectionState
 
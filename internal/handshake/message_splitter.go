@@ -0,0 +1,42 @@
+package handshake
+
+// The messageSplitter finds the handshake messages in the data written by TLS.
+// A single write might contain multiple handshake messages, and a handshake message might be split across multiple writes.
+// Every handshake message starts with a 4 byte header: the message type, followed by the 3 byte length of the message body.
+type messageSplitter struct {
+	hdr    [4]byte
+	hdrLen int // the number of header bytes of the current message read so far
+
+	msgType   messageType
+	remaining int // the number of bytes of the body of the current message that are still to be read
+}
+
+// Write consumes data, and calls onMessage for every handshake message that was completed.
+func (s *messageSplitter) Write(data []byte, onMessage func(messageType)) {
+	for len(data) > 0 {
+		if s.remaining > 0 {
+			n := s.remaining
+			if n > len(data) {
+				n = len(data)
+			}
+			data = data[n:]
+			s.remaining -= n
+			if s.remaining == 0 {
+				onMessage(s.msgType)
+			}
+			continue
+		}
+		n := copy(s.hdr[s.hdrLen:], data)
+		s.hdrLen += n
+		data = data[n:]
+		if s.hdrLen < len(s.hdr) {
+			return
+		}
+		s.hdrLen = 0
+		s.msgType = messageType(s.hdr[0])
+		s.remaining = int(s.hdr[1])<<16 | int(s.hdr[2])<<8 | int(s.hdr[3])
+		if s.remaining == 0 {
+			onMessage(s.msgType)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handshake
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Message Splitter", func() {
+	var (
+		s        messageSplitter
+		messages []messageType
+	)
+
+	onMessage := func(t messageType) { messages = append(messages, t) }
+
+	// message creates a handshake message with a body of bodyLen bytes
+	message := func(t messageType, bodyLen int) []byte {
+		return append([]byte{byte(t), byte(bodyLen >> 16), byte(bodyLen >> 8), byte(bodyLen)}, make([]byte, bodyLen)...)
+	}
+
+	BeforeEach(func() {
+		s = messageSplitter{}
+		messages = nil
+	})
+
+	It("finds a single message", func() {
+		s.Write(message(typeClientHello, 100), onMessage)
+		Expect(messages).To(Equal([]messageType{typeClientHello}))
+	})
+
+	It("finds multiple messages in a single write", func() {
+		var data []byte
+		data = append(data, message(typeEncryptedExtensions, 10)...)
+		data = append(data, message(typeCertificate, 1000)...)
+		data = append(data, message(typeFinished, 32)...)
+		s.Write(data, onMessage)
+		Expect(messages).To(Equal([]messageType{typeEncryptedExtensions, typeCertificate, typeFinished}))
+	})
+
+	It("finds messages split across multiple writes", func() {
+		data := append(message(typeServerHello, 90), message(typeFinished, 32)...)
+		// write the data byte by byte, such that both the header and the body are split
+		for i, b := range data {
+			s.Write([]byte{b}, onMessage)
+			switch {
+			case i < 93:
+				Expect(messages).To(BeEmpty())
+			case i < len(data)-1:
+				Expect(messages).To(Equal([]messageType{typeServerHello}))
+			}
+		}
+		Expect(messages).To(Equal([]messageType{typeServerHello, typeFinished}))
+	})
+
+	It("finds messages without a body", func() {
+		s.Write(append(message(typeNewSessionTicket, 0), message(typeFinished, 0)...), onMessage)
+		Expect(messages).To(Equal([]messageType{typeNewSessionTicket, typeFinished}))
+	})
+})
@@ -2,6 +2,7 @@ package handshake
 
 import (
 	"encoding/asn1"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -15,6 +16,10 @@ const (
 	tokenPrefixString
 )
 
+// customRetryTokenMarker is prepended to custom Retry tokens.
+// It allows the server to tell custom Retry tokens apart from other tokens without decrypting them.
+const customRetryTokenMarker byte = 0xc7
+
 // A Token is derived from the client address and can be used to verify the ownership of this address.
 type Token struct {
 	IsRetryToken bool
@@ -34,6 +39,20 @@ type token struct {
 	RetrySrcConnectionID     []byte
 }
 
+// A CustomRetryToken is a Retry token containing data provided by the application.
+type CustomRetryToken struct {
+	OriginalDestConnectionID protocol.ConnectionID
+	RetrySrcConnectionID     protocol.ConnectionID
+	Data                     []byte
+}
+
+// customRetryToken is the struct that is used for ASN1 serialization and deserialization of custom Retry tokens
+type customRetryToken struct {
+	OriginalDestConnectionID []byte
+	RetrySrcConnectionID     []byte
+	Data                     []byte
+}
+
 // A TokenGenerator generates tokens
 type TokenGenerator struct {
 	tokenProtector tokenProtector
@@ -112,6 +131,53 @@ func (g *TokenGenerator) DecodeToken(encrypted []byte) (*Token, error) {
 	return token, nil
 }
 
+// NewCustomRetryToken generates a new token for a Retry, containing data provided by the application.
+// The connection IDs are sealed together with the data, such that the client can't modify them.
+func (g *TokenGenerator) NewCustomRetryToken(
+	origDestConnID protocol.ConnectionID,
+	retrySrcConnID protocol.ConnectionID,
+	data []byte,
+) ([]byte, error) {
+	encoded, err := asn1.Marshal(customRetryToken{
+		OriginalDestConnectionID: origDestConnID,
+		RetrySrcConnectionID:     retrySrcConnID,
+		Data:                     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := g.tokenProtector.NewToken(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{customRetryTokenMarker}, token...), nil
+}
+
+// DecodeCustomRetryToken decodes a token generated by NewCustomRetryToken.
+// It returns an error if the token was not generated by this TokenGenerator, or if it was modified.
+func (g *TokenGenerator) DecodeCustomRetryToken(encrypted []byte) (*CustomRetryToken, error) {
+	if len(encrypted) == 0 || encrypted[0] != customRetryTokenMarker {
+		return nil, errors.New("not a custom Retry token")
+	}
+	data, err := g.tokenProtector.DecodeToken(encrypted[1:])
+	if err != nil {
+		return nil, err
+	}
+	t := &customRetryToken{}
+	rest, err := asn1.Unmarshal(data, t)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("rest when unpacking token: %d", len(rest))
+	}
+	return &CustomRetryToken{
+		OriginalDestConnectionID: protocol.ConnectionID(t.OriginalDestConnectionID),
+		RetrySrcConnectionID:     protocol.ConnectionID(t.RetrySrcConnectionID),
+		Data:                     t.Data,
+	}, nil
+}
+
 // encodeRemoteAddr encodes a remote address such that it can be saved in the token
 func encodeRemoteAddr(remoteAddr net.Addr) []byte {
 	if udpAddr, ok := remoteAddr.(*net.UDPAddr); ok {
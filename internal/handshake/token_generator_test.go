@@ -63,6 +63,44 @@ var _ = Describe("Token Generator", func() {
 		Expect(token.RetrySrcConnectionID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
 	})
 
+	It("saves the connection IDs and the data of custom Retry tokens", func() {
+		tokenEnc, err := tokenGen.NewCustomRetryToken(
+			protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef},
+			protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+			[]byte("foobar"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tokenEnc).ToNot(ContainSubstring("foobar"))
+		token, err := tokenGen.DecodeCustomRetryToken(tokenEnc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.OriginalDestConnectionID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}))
+		Expect(token.RetrySrcConnectionID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
+		Expect(token.Data).To(Equal([]byte("foobar")))
+	})
+
+	It("rejects random tokens that look like custom Retry tokens", func() {
+		tokenEnc := make([]byte, 100)
+		rand.Read(tokenEnc)
+		tokenEnc[0] = customRetryTokenMarker
+		_, err := tokenGen.DecodeCustomRetryToken(tokenEnc)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects modified custom Retry tokens", func() {
+		tokenEnc, err := tokenGen.NewCustomRetryToken(protocol.ConnectionID{1, 2, 3, 4}, protocol.ConnectionID{5, 6, 7, 8}, []byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		tokenEnc[len(tokenEnc)-1]++
+		_, err = tokenGen.DecodeCustomRetryToken(tokenEnc)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("doesn't decode regular tokens as custom Retry tokens", func() {
+		tokenEnc, err := tokenGen.NewRetryToken(&net.UDPAddr{}, protocol.ConnectionID{1, 2, 3, 4}, protocol.ConnectionID{5, 6, 7, 8})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = tokenGen.DecodeCustomRetryToken(tokenEnc)
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("rejects invalid tokens", func() {
 		_, err := tokenGen.DecodeToken([]byte("invalid token"))
 		Expect(err).To(HaveOccurred())
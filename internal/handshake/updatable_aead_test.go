@@ -1,6 +1,7 @@
 package handshake
 
 import (
+	"crypto/cipher"
 	"crypto/rand"
 	"crypto/tls"
 	"fmt"
@@ -17,10 +18,25 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type countingAEAD struct {
+	cipher.AEAD
+	numSealed, numOpened int
+}
+
+func (a *countingAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	a.numSealed++
+	return a.AEAD.Seal(dst, nonce, plaintext, additionalData)
+}
+
+func (a *countingAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	a.numOpened++
+	return a.AEAD.Open(dst, nonce, ciphertext, additionalData)
+}
+
 var _ = Describe("Updatable AEAD", func() {
 	It("ChaCha test vector from the draft", func() {
 		secret := splitHexString("9ac312a7f877468ebe69422748ad00a1 5443f18203a07d6060f688f30f21632b")
-		aead := newUpdatableAEAD(&utils.RTTStats{}, nil, nil)
+		aead := newUpdatableAEAD(&utils.RTTStats{}, nil, nil, nil, protocol.Version1)
 		chacha := cipherSuites[2]
 		Expect(chacha.ID).To(Equal(tls.TLS_CHACHA20_POLY1305_SHA256))
 		aead.SetWriteKey(chacha, secret)
@@ -35,6 +51,46 @@ var _ = Describe("Updatable AEAD", func() {
 		Expect(packet).To(Equal(splitHexString("4cfe4189655e5cd55c41f69080575d7999c25a5bfb")))
 	})
 
+	It("uses the AEAD factory", func() {
+		cs := cipherSuites[0]
+		var aeads []*countingAEAD
+		factory := func(suite uint16, key, iv []byte) cipher.AEAD {
+			Expect(suite).To(Equal(cs.ID))
+			Expect(key).To(HaveLen(cs.KeyLen))
+			Expect(iv).To(HaveLen(cs.IVLen()))
+			a := &countingAEAD{AEAD: cs.AEAD(key, iv)}
+			aeads = append(aeads, a)
+			return a
+		}
+		trafficSecret1 := make([]byte, 16)
+		trafficSecret2 := make([]byte, 16)
+		rand.Read(trafficSecret1)
+		rand.Read(trafficSecret2)
+		client := newUpdatableAEAD(&utils.RTTStats{}, factory, nil, utils.DefaultLogger, protocol.Version1)
+		server := newUpdatableAEAD(&utils.RTTStats{}, nil, nil, utils.DefaultLogger, protocol.Version1)
+		client.SetReadKey(cs, trafficSecret2)
+		client.SetWriteKey(cs, trafficSecret1)
+		server.SetReadKey(cs, trafficSecret1)
+		server.SetWriteKey(cs, trafficSecret2)
+		// the current and the next key phase, for both directions
+		Expect(aeads).To(HaveLen(4))
+		Expect(client.rcvAEAD).To(Equal(aeads[0]))
+		Expect(client.sendAEAD).To(Equal(aeads[2]))
+
+		msg := []byte("foobar")
+		encrypted := client.Seal(nil, msg, 0x1337, []byte("ad"))
+		Expect(aeads[2].numSealed).To(Equal(1))
+		opened, err := server.Open(nil, encrypted, time.Now(), 0x1337, protocol.KeyPhaseZero, []byte("ad"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opened).To(Equal(msg))
+
+		encrypted = server.Seal(nil, msg, 0x42, []byte("ad"))
+		opened, err = client.Open(nil, encrypted, time.Now(), 0x42, protocol.KeyPhaseZero, []byte("ad"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opened).To(Equal(msg))
+		Expect(aeads[0].numOpened).To(Equal(1))
+	})
+
 	for i := range cipherSuites {
 		cs := cipherSuites[i]
 
@@ -53,8 +109,8 @@ var _ = Describe("Updatable AEAD", func() {
 				rand.Read(trafficSecret2)
 
 				rttStats = utils.NewRTTStats()
-				client = newUpdatableAEAD(rttStats, nil, utils.DefaultLogger)
-				server = newUpdatableAEAD(rttStats, serverTracer, utils.DefaultLogger)
+				client = newUpdatableAEAD(rttStats, nil, nil, utils.DefaultLogger, protocol.Version1)
+				server = newUpdatableAEAD(rttStats, nil, serverTracer, utils.DefaultLogger, protocol.Version1)
 				client.SetReadKey(cs, trafficSecret2)
 				client.SetWriteKey(cs, trafficSecret1)
 				server.SetReadKey(cs, trafficSecret1)
@@ -103,3 +103,18 @@ func (mr *MockReceivedPacketHandlerMockRecorder) ReceivedPacket(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedPacket", reflect.TypeOf((*MockReceivedPacketHandler)(nil).ReceivedPacket), arg0, arg1, arg2, arg3, arg4)
 }
+
+// ReorderingStats mocks base method.
+func (m *MockReceivedPacketHandler) ReorderingStats() (int, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderingStats")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// ReorderingStats indicates an expected call of ReorderingStats.
+func (mr *MockReceivedPacketHandlerMockRecorder) ReorderingStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderingStats", reflect.TypeOf((*MockReceivedPacketHandler)(nil).ReorderingStats))
+}
@@ -9,6 +9,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	congestion "github.com/BGrewell/quic-go/internal/congestion"
 	protocol "github.com/BGrewell/quic-go/internal/protocol"
 )
 
@@ -35,6 +36,20 @@ func (m *MockSendAlgorithmWithDebugInfos) EXPECT() *MockSendAlgorithmWithDebugIn
 	return m.recorder
 }
 
+// BandwidthEstimate mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) BandwidthEstimate() congestion.Bandwidth {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BandwidthEstimate")
+	ret0, _ := ret[0].(congestion.Bandwidth)
+	return ret0
+}
+
+// BandwidthEstimate indicates an expected call of BandwidthEstimate.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) BandwidthEstimate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BandwidthEstimate", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).BandwidthEstimate))
+}
+
 // CanSend mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) CanSend(arg0 protocol.ByteCount) bool {
 	m.ctrl.T.Helper()
@@ -63,6 +78,20 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) GetCongestionWindow() *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).GetCongestionWindow))
 }
 
+// GetSlowStartThreshold mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) GetSlowStartThreshold() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSlowStartThreshold")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// GetSlowStartThreshold indicates an expected call of GetSlowStartThreshold.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) GetSlowStartThreshold() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSlowStartThreshold", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).GetSlowStartThreshold))
+}
+
 // HasPacingBudget mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) HasPacingBudget() bool {
 	m.ctrl.T.Helper()
@@ -117,6 +146,18 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) MaybeExitSlowStart() *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybeExitSlowStart", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).MaybeExitSlowStart))
 }
 
+// OnConnectionMigration mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnConnectionMigration(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnConnectionMigration", arg0)
+}
+
+// OnConnectionMigration indicates an expected call of OnConnectionMigration.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnConnectionMigration(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnConnectionMigration", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnConnectionMigration), arg0)
+}
+
 // OnPacketAcked mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) OnPacketAcked(arg0 protocol.PacketNumber, arg1, arg2 protocol.ByteCount, arg3 time.Time) {
 	m.ctrl.T.Helper()
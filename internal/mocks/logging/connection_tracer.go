@@ -87,6 +87,30 @@ func (mr *MockConnectionTracerMockRecorder) ClosedConnection(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClosedConnection", reflect.TypeOf((*MockConnectionTracer)(nil).ClosedConnection), arg0)
 }
 
+// ClosedStream mocks base method.
+func (m *MockConnectionTracer) ClosedStream(arg0 protocol.StreamID, arg1 logging.StreamCloseReason) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClosedStream", arg0, arg1)
+}
+
+// ClosedStream indicates an expected call of ClosedStream.
+func (mr *MockConnectionTracerMockRecorder) ClosedStream(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClosedStream", reflect.TypeOf((*MockConnectionTracer)(nil).ClosedStream), arg0, arg1)
+}
+
+// CompletedHandshake mocks base method.
+func (m *MockConnectionTracer) CompletedHandshake() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CompletedHandshake")
+}
+
+// CompletedHandshake indicates an expected call of CompletedHandshake.
+func (mr *MockConnectionTracerMockRecorder) CompletedHandshake() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletedHandshake", reflect.TypeOf((*MockConnectionTracer)(nil).CompletedHandshake))
+}
+
 // Debug mocks base method.
 func (m *MockConnectionTracer) Debug(arg0, arg1 string) {
 	m.ctrl.T.Helper()
@@ -183,6 +207,42 @@ func (mr *MockConnectionTracerMockRecorder) NegotiatedVersion(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NegotiatedVersion", reflect.TypeOf((*MockConnectionTracer)(nil).NegotiatedVersion), arg0, arg1, arg2)
 }
 
+// OpenedStream mocks base method.
+func (m *MockConnectionTracer) OpenedStream(arg0 protocol.StreamID, arg1 protocol.Perspective) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OpenedStream", arg0, arg1)
+}
+
+// OpenedStream indicates an expected call of OpenedStream.
+func (mr *MockConnectionTracerMockRecorder) OpenedStream(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenedStream", reflect.TypeOf((*MockConnectionTracer)(nil).OpenedStream), arg0, arg1)
+}
+
+// ReceivedHandshakeMessage mocks base method.
+func (m *MockConnectionTracer) ReceivedHandshakeMessage(arg0 logging.HandshakeMessageType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedHandshakeMessage", arg0)
+}
+
+// ReceivedHandshakeMessage indicates an expected call of ReceivedHandshakeMessage.
+func (mr *MockConnectionTracerMockRecorder) ReceivedHandshakeMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedHandshakeMessage", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedHandshakeMessage), arg0)
+}
+
+// ReceivedKeys mocks base method.
+func (m *MockConnectionTracer) ReceivedKeys(arg0 protocol.EncryptionLevel) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedKeys", arg0)
+}
+
+// ReceivedKeys indicates an expected call of ReceivedKeys.
+func (mr *MockConnectionTracerMockRecorder) ReceivedKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedKeys", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedKeys), arg0)
+}
+
 // ReceivedPacket mocks base method.
 func (m *MockConnectionTracer) ReceivedPacket(arg0 *wire.ExtendedHeader, arg1 protocol.ByteCount, arg2 []logging.Frame) {
 	m.ctrl.T.Helper()
@@ -243,6 +303,18 @@ func (mr *MockConnectionTracerMockRecorder) RestoredTransportParameters(arg0 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoredTransportParameters", reflect.TypeOf((*MockConnectionTracer)(nil).RestoredTransportParameters), arg0)
 }
 
+// SentHandshakeMessage mocks base method.
+func (m *MockConnectionTracer) SentHandshakeMessage(arg0 logging.HandshakeMessageType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SentHandshakeMessage", arg0)
+}
+
+// SentHandshakeMessage indicates an expected call of SentHandshakeMessage.
+func (mr *MockConnectionTracerMockRecorder) SentHandshakeMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentHandshakeMessage", reflect.TypeOf((*MockConnectionTracer)(nil).SentHandshakeMessage), arg0)
+}
+
 // SentPacket mocks base method.
 func (m *MockConnectionTracer) SentPacket(arg0 *wire.ExtendedHeader, arg1 protocol.ByteCount, arg2 *wire.AckFrame, arg3 []logging.Frame) {
 	m.ctrl.T.Helper()
@@ -291,6 +363,18 @@ func (mr *MockConnectionTracerMockRecorder) StartedConnection(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartedConnection", reflect.TypeOf((*MockConnectionTracer)(nil).StartedConnection), arg0, arg1, arg2, arg3)
 }
 
+// StartedHandshake mocks base method.
+func (m *MockConnectionTracer) StartedHandshake() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StartedHandshake")
+}
+
+// StartedHandshake indicates an expected call of StartedHandshake.
+func (mr *MockConnectionTracerMockRecorder) StartedHandshake() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartedHandshake", reflect.TypeOf((*MockConnectionTracer)(nil).StartedHandshake))
+}
+
 // UpdatedCongestionState mocks base method.
 func (m *MockConnectionTracer) UpdatedCongestionState(arg0 logging.CongestionState) {
 	m.ctrl.T.Helper()
@@ -303,6 +387,18 @@ func (mr *MockConnectionTracerMockRecorder) UpdatedCongestionState(arg0 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedCongestionState", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedCongestionState), arg0)
 }
 
+// UpdatedECNState mocks base method.
+func (m *MockConnectionTracer) UpdatedECNState(arg0 logging.ECNState) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatedECNState", arg0)
+}
+
+// UpdatedECNState indicates an expected call of UpdatedECNState.
+func (mr *MockConnectionTracerMockRecorder) UpdatedECNState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedECNState", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedECNState), arg0)
+}
+
 // UpdatedKey mocks base method.
 func (m *MockConnectionTracer) UpdatedKey(arg0 protocol.KeyPhase, arg1 bool) {
 	m.ctrl.T.Helper()
@@ -327,16 +423,28 @@ func (mr *MockConnectionTracerMockRecorder) UpdatedKeyFromTLS(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedKeyFromTLS", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedKeyFromTLS), arg0, arg1)
 }
 
+// UpdatedMTU mocks base method.
+func (m *MockConnectionTracer) UpdatedMTU(arg0 protocol.ByteCount, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatedMTU", arg0, arg1)
+}
+
+// UpdatedMTU indicates an expected call of UpdatedMTU.
+func (mr *MockConnectionTracerMockRecorder) UpdatedMTU(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedMTU", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedMTU), arg0, arg1)
+}
+
 // UpdatedMetrics mocks base method.
-func (m *MockConnectionTracer) UpdatedMetrics(arg0 *utils.RTTStats, arg1, arg2 protocol.ByteCount, arg3 int) {
+func (m *MockConnectionTracer) UpdatedMetrics(arg0 *utils.RTTStats, arg1, arg2, arg3 protocol.ByteCount, arg4 int) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "UpdatedMetrics", arg0, arg1, arg2, arg3)
+	m.ctrl.Call(m, "UpdatedMetrics", arg0, arg1, arg2, arg3, arg4)
 }
 
 // UpdatedMetrics indicates an expected call of UpdatedMetrics.
-func (mr *MockConnectionTracerMockRecorder) UpdatedMetrics(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockConnectionTracerMockRecorder) UpdatedMetrics(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedMetrics", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedMetrics), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedMetrics", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedMetrics), arg0, arg1, arg2, arg3, arg4)
 }
 
 // UpdatedPTOCount mocks base method.
@@ -8,9 +8,12 @@ import (
 	context "context"
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	quic "github.com/BGrewell/quic-go"
+	congestion "github.com/BGrewell/quic-go/internal/congestion"
+	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	qerr "github.com/BGrewell/quic-go/internal/qerr"
 )
 
@@ -67,6 +70,77 @@ func (mr *MockEarlySessionMockRecorder) AcceptUniStream(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptUniStream", reflect.TypeOf((*MockEarlySession)(nil).AcceptUniStream), arg0)
 }
 
+// AckStats mocks base method.
+func (m *MockEarlySession) AckStats() (uint64, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckStats")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// AckStats indicates an expected call of AckStats.
+func (mr *MockEarlySessionMockRecorder) AckStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckStats", reflect.TypeOf((*MockEarlySession)(nil).AckStats))
+}
+
+// BytesAcked mocks base method.
+func (m *MockEarlySession) BytesAcked() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BytesAcked")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// BytesAcked indicates an expected call of BytesAcked.
+func (mr *MockEarlySessionMockRecorder) BytesAcked() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BytesAcked", reflect.TypeOf((*MockEarlySession)(nil).BytesAcked))
+}
+
+// BytesSent mocks base method.
+func (m *MockEarlySession) BytesSent() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BytesSent")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// BytesSent indicates an expected call of BytesSent.
+func (mr *MockEarlySessionMockRecorder) BytesSent() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BytesSent", reflect.TypeOf((*MockEarlySession)(nil).BytesSent))
+}
+
+// CanSendNow mocks base method.
+func (m *MockEarlySession) CanSendNow() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CanSendNow")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CanSendNow indicates an expected call of CanSendNow.
+func (mr *MockEarlySessionMockRecorder) CanSendNow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanSendNow", reflect.TypeOf((*MockEarlySession)(nil).CanSendNow))
+}
+
+// CloseGracefully mocks base method.
+func (m *MockEarlySession) CloseGracefully(arg0 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseGracefully", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseGracefully indicates an expected call of CloseGracefully.
+func (mr *MockEarlySessionMockRecorder) CloseGracefully(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseGracefully", reflect.TypeOf((*MockEarlySession)(nil).CloseGracefully), arg0)
+}
+
 // CloseWithError mocks base method.
 func (m *MockEarlySession) CloseWithError(arg0 qerr.ApplicationErrorCode, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -81,6 +155,49 @@ func (mr *MockEarlySessionMockRecorder) CloseWithError(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseWithError", reflect.TypeOf((*MockEarlySession)(nil).CloseWithError), arg0, arg1)
 }
 
+// CongestionControl mocks base method.
+func (m *MockEarlySession) CongestionControl() congestion.CongestionAlgo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CongestionControl")
+	ret0, _ := ret[0].(congestion.CongestionAlgo)
+	return ret0
+}
+
+// CongestionControl indicates an expected call of CongestionControl.
+func (mr *MockEarlySessionMockRecorder) CongestionControl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CongestionControl", reflect.TypeOf((*MockEarlySession)(nil).CongestionControl))
+}
+
+// CongestionEvents mocks base method.
+func (m *MockEarlySession) CongestionEvents() <-chan quic.CongestionEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CongestionEvents")
+	ret0, _ := ret[0].(<-chan quic.CongestionEvent)
+	return ret0
+}
+
+// CongestionEvents indicates an expected call of CongestionEvents.
+func (mr *MockEarlySessionMockRecorder) CongestionEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CongestionEvents", reflect.TypeOf((*MockEarlySession)(nil).CongestionEvents))
+}
+
+// ConnectionIDs mocks base method.
+func (m *MockEarlySession) ConnectionIDs() (protocol.ConnectionID, protocol.ConnectionID) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectionIDs")
+	ret0, _ := ret[0].(protocol.ConnectionID)
+	ret1, _ := ret[1].(protocol.ConnectionID)
+	return ret0, ret1
+}
+
+// ConnectionIDs indicates an expected call of ConnectionIDs.
+func (mr *MockEarlySessionMockRecorder) ConnectionIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionIDs", reflect.TypeOf((*MockEarlySession)(nil).ConnectionIDs))
+}
+
 // ConnectionState mocks base method.
 func (m *MockEarlySession) ConnectionState() quic.ConnectionState {
 	m.ctrl.T.Helper()
@@ -95,6 +212,20 @@ func (mr *MockEarlySessionMockRecorder) ConnectionState() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionState", reflect.TypeOf((*MockEarlySession)(nil).ConnectionState))
 }
 
+// ConnectionStats mocks base method.
+func (m *MockEarlySession) ConnectionStats() quic.ConnectionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectionStats")
+	ret0, _ := ret[0].(quic.ConnectionStats)
+	return ret0
+}
+
+// ConnectionStats indicates an expected call of ConnectionStats.
+func (mr *MockEarlySessionMockRecorder) ConnectionStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionStats", reflect.TypeOf((*MockEarlySession)(nil).ConnectionStats))
+}
+
 // Context mocks base method.
 func (m *MockEarlySession) Context() context.Context {
 	m.ctrl.T.Helper()
@@ -109,6 +240,34 @@ func (mr *MockEarlySessionMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockEarlySession)(nil).Context))
 }
 
+// CurrentMTU mocks base method.
+func (m *MockEarlySession) CurrentMTU() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentMTU")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// CurrentMTU indicates an expected call of CurrentMTU.
+func (mr *MockEarlySessionMockRecorder) CurrentMTU() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentMTU", reflect.TypeOf((*MockEarlySession)(nil).CurrentMTU))
+}
+
+// DroppedCongestionEvents mocks base method.
+func (m *MockEarlySession) DroppedCongestionEvents() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DroppedCongestionEvents")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// DroppedCongestionEvents indicates an expected call of DroppedCongestionEvents.
+func (mr *MockEarlySessionMockRecorder) DroppedCongestionEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedCongestionEvents", reflect.TypeOf((*MockEarlySession)(nil).DroppedCongestionEvents))
+}
+
 // HandshakeComplete mocks base method.
 func (m *MockEarlySession) HandshakeComplete() context.Context {
 	m.ctrl.T.Helper()
@@ -123,6 +282,20 @@ func (mr *MockEarlySessionMockRecorder) HandshakeComplete() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandshakeComplete", reflect.TypeOf((*MockEarlySession)(nil).HandshakeComplete))
 }
 
+// LargestAcked mocks base method.
+func (m *MockEarlySession) LargestAcked(arg0 protocol.EncryptionLevel) protocol.PacketNumber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LargestAcked", arg0)
+	ret0, _ := ret[0].(protocol.PacketNumber)
+	return ret0
+}
+
+// LargestAcked indicates an expected call of LargestAcked.
+func (mr *MockEarlySessionMockRecorder) LargestAcked(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LargestAcked", reflect.TypeOf((*MockEarlySession)(nil).LargestAcked), arg0)
+}
+
 // LocalAddr mocks base method.
 func (m *MockEarlySession) LocalAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -137,6 +310,20 @@ func (mr *MockEarlySessionMockRecorder) LocalAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalAddr", reflect.TypeOf((*MockEarlySession)(nil).LocalAddr))
 }
 
+// MigrateTo mocks base method.
+func (m *MockEarlySession) MigrateTo(arg0 net.PacketConn) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateTo", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateTo indicates an expected call of MigrateTo.
+func (mr *MockEarlySessionMockRecorder) MigrateTo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateTo", reflect.TypeOf((*MockEarlySession)(nil).MigrateTo), arg0)
+}
+
 // NextSession mocks base method.
 func (m *MockEarlySession) NextSession() quic.Session {
 	m.ctrl.T.Helper()
@@ -151,6 +338,20 @@ func (mr *MockEarlySessionMockRecorder) NextSession() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSession", reflect.TypeOf((*MockEarlySession)(nil).NextSession))
 }
 
+// NextTimeout mocks base method.
+func (m *MockEarlySession) NextTimeout() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextTimeout")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// NextTimeout indicates an expected call of NextTimeout.
+func (mr *MockEarlySessionMockRecorder) NextTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextTimeout", reflect.TypeOf((*MockEarlySession)(nil).NextTimeout))
+}
+
 // OpenStream mocks base method.
 func (m *MockEarlySession) OpenStream() (quic.Stream, error) {
 	m.ctrl.T.Helper()
@@ -181,6 +382,20 @@ func (mr *MockEarlySessionMockRecorder) OpenStreamSync(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreamSync", reflect.TypeOf((*MockEarlySession)(nil).OpenStreamSync), arg0)
 }
 
+// OpenStreams mocks base method.
+func (m *MockEarlySession) OpenStreams() []protocol.StreamID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenStreams")
+	ret0, _ := ret[0].([]protocol.StreamID)
+	return ret0
+}
+
+// OpenStreams indicates an expected call of OpenStreams.
+func (mr *MockEarlySessionMockRecorder) OpenStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreams", reflect.TypeOf((*MockEarlySession)(nil).OpenStreams))
+}
+
 // OpenUniStream mocks base method.
 func (m *MockEarlySession) OpenUniStream() (quic.SendStream, error) {
 	m.ctrl.T.Helper()
@@ -211,6 +426,50 @@ func (mr *MockEarlySessionMockRecorder) OpenUniStreamSync(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenUniStreamSync", reflect.TypeOf((*MockEarlySession)(nil).OpenUniStreamSync), arg0)
 }
 
+// PaddingStats mocks base method.
+func (m *MockEarlySession) PaddingStats() (protocol.ByteCount, protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaddingStats")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	ret1, _ := ret[1].(protocol.ByteCount)
+	return ret0, ret1
+}
+
+// PaddingStats indicates an expected call of PaddingStats.
+func (mr *MockEarlySessionMockRecorder) PaddingStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaddingStats", reflect.TypeOf((*MockEarlySession)(nil).PaddingStats))
+}
+
+// PeerSupportsDatagrams mocks base method.
+func (m *MockEarlySession) PeerSupportsDatagrams() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerSupportsDatagrams")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// PeerSupportsDatagrams indicates an expected call of PeerSupportsDatagrams.
+func (mr *MockEarlySessionMockRecorder) PeerSupportsDatagrams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerSupportsDatagrams", reflect.TypeOf((*MockEarlySession)(nil).PeerSupportsDatagrams))
+}
+
+// PeerTransportParameter mocks base method.
+func (m *MockEarlySession) PeerTransportParameter(arg0 uint64) ([]byte, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerTransportParameter", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// PeerTransportParameter indicates an expected call of PeerTransportParameter.
+func (mr *MockEarlySessionMockRecorder) PeerTransportParameter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerTransportParameter", reflect.TypeOf((*MockEarlySession)(nil).PeerTransportParameter), arg0)
+}
+
 // ReceiveMessage mocks base method.
 func (m *MockEarlySession) ReceiveMessage() ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -240,6 +499,35 @@ func (mr *MockEarlySessionMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockEarlySession)(nil).RemoteAddr))
 }
 
+// ReorderingStats mocks base method.
+func (m *MockEarlySession) ReorderingStats() (int, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderingStats")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// ReorderingStats indicates an expected call of ReorderingStats.
+func (mr *MockEarlySessionMockRecorder) ReorderingStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderingStats", reflect.TypeOf((*MockEarlySession)(nil).ReorderingStats))
+}
+
+// SavedSyscalls mocks base method.
+func (m *MockEarlySession) SavedSyscalls() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavedSyscalls")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// SavedSyscalls indicates an expected call of SavedSyscalls.
+func (mr *MockEarlySessionMockRecorder) SavedSyscalls() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavedSyscalls", reflect.TypeOf((*MockEarlySession)(nil).SavedSyscalls))
+}
+
 // SendMessage mocks base method.
 func (m *MockEarlySession) SendMessage(arg0 []byte) error {
 	m.ctrl.T.Helper()
@@ -253,3 +541,45 @@ func (mr *MockEarlySessionMockRecorder) SendMessage(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockEarlySession)(nil).SendMessage), arg0)
 }
+
+// SetWriteDeadline mocks base method.
+func (m *MockEarlySession) SetWriteDeadline(arg0 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWriteDeadline", arg0)
+}
+
+// SetWriteDeadline indicates an expected call of SetWriteDeadline.
+func (mr *MockEarlySessionMockRecorder) SetWriteDeadline(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDeadline", reflect.TypeOf((*MockEarlySession)(nil).SetWriteDeadline), arg0)
+}
+
+// SupportedVersions mocks base method.
+func (m *MockEarlySession) SupportedVersions() []protocol.VersionNumber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SupportedVersions")
+	ret0, _ := ret[0].([]protocol.VersionNumber)
+	return ret0
+}
+
+// SupportedVersions indicates an expected call of SupportedVersions.
+func (mr *MockEarlySessionMockRecorder) SupportedVersions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SupportedVersions", reflect.TypeOf((*MockEarlySession)(nil).SupportedVersions))
+}
+
+// Timestamps mocks base method.
+func (m *MockEarlySession) Timestamps() (time.Time, time.Time, time.Time) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Timestamps")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(time.Time)
+	return ret0, ret1, ret2
+}
+
+// Timestamps indicates an expected call of Timestamps.
+func (mr *MockEarlySessionMockRecorder) Timestamps() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Timestamps", reflect.TypeOf((*MockEarlySession)(nil).Timestamps))
+}
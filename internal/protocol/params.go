@@ -42,6 +42,9 @@ const DefaultMaxIncomingStreams = 100
 // DefaultMaxIncomingUniStreams is the maximum number of unidirectional streams that a peer may open
 const DefaultMaxIncomingUniStreams = 100
 
+// DefaultMaxRetries is the maximum number of times a client creates a new session when dialing a server
+const DefaultMaxRetries = 3
+
 // MaxServerUnprocessedPackets is the max number of packets stored in the server that are not yet processed.
 const MaxServerUnprocessedPackets = 1024
 
@@ -65,6 +68,12 @@ const TokenValidity = 24 * time.Hour
 // RetryTokenValidity is the duration that a retry token is considered valid
 const RetryTokenValidity = 10 * time.Second
 
+// MaxFileTokenStoreTokensPerOrigin is the maximum number of tokens that the file token store saves per origin.
+const MaxFileTokenStoreTokensPerOrigin = 4
+
+// ECNTestingPackets is the number of packets sent with an ECT(0) mark to test if the path supports ECN.
+const ECNTestingPackets = 10
+
 // MaxOutstandingSentPackets is maximum number of packets saved for retransmission.
 // When reached, it imposes a soft limit on sending new packets:
 // Sending ACKs and retransmission is still allowed, but now new regular packets can be sent.
@@ -122,6 +131,9 @@ const RetiredConnectionIDDeleteTimeout = 5 * time.Second
 // 2. it reduces the head-of-line blocking, when a packet is lost
 const MinStreamFrameSize ByteCount = 128
 
+// DefaultStreamPriority is the weight that is assigned to a newly opened stream.
+const DefaultStreamPriority uint8 = 16
+
 // MaxPostHandshakeCryptoFrameSize is the maximum size of CRYPTO frames
 // we send after the handshake completes.
 const MaxPostHandshakeCryptoFrameSize = 1000
@@ -155,8 +167,12 @@ const MinPacingDelay = time.Millisecond
 // if no other value is configured.
 const DefaultConnectionIDLength = 4
 
-// MaxActiveConnectionIDs is the number of connection IDs that we're storing.
-const MaxActiveConnectionIDs = 4
+// DefaultMaxPathChallenges is the maximum number of PATH_CHALLENGE frames sent when validating a new path
+const DefaultMaxPathChallenges = 3
+
+// MinActiveConnectionIDLimit is the minimum value of the active_connection_id_limit transport parameter.
+// See section 18.2 of RFC 9000.
+const MinActiveConnectionIDLimit = 2
 
 // MaxIssuedConnectionIDs is the maximum number of connection IDs that we're issuing at the same time.
 const MaxIssuedConnectionIDs = 6
@@ -172,6 +188,9 @@ const AckDelayExponent = 3
 // The loss detection timer will not be set to a value smaller than granularity.
 const TimerGranularity = time.Millisecond
 
+// DefaultAckElicitingThreshold is the number of ack-eliciting packets received before an ACK is sent.
+const DefaultAckElicitingThreshold = 2
+
 // MaxAckDelay is the maximum time by which we delay sending ACKs.
 const MaxAckDelay = 25 * time.Millisecond
 
@@ -23,6 +23,9 @@ const (
 	VersionUnknown  VersionNumber = math.MaxUint32
 	VersionDraft29  VersionNumber = 0xff00001d
 	Version1        VersionNumber = 0x1
+	// Version2 is QUIC version 2 (RFC 9369).
+	// Only its key derivation (the Initial salt and the HKDF labels) is implemented, it is therefore not a supported version.
+	Version2 VersionNumber = 0x6b3343cf
 )
 
 // SupportedVersions lists the versions that the server supports
@@ -50,6 +53,8 @@ func (vn VersionNumber) String() string {
 		return "draft-29"
 	case Version1:
 		return "v1"
+	case Version2:
+		return "v2"
 	default:
 		if vn.isGQUIC() {
 			return fmt.Sprintf("gQUIC %d", vn.toGQUICVersion())
@@ -3,6 +3,7 @@ package qerr
 import (
 	"errors"
 	"net"
+	"os"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
 	. "github.com/onsi/ginkgo"
@@ -86,6 +87,8 @@ var _ = Describe("QUIC Errors", func() {
 			Expect(nerr.Timeout()).To(BeTrue())
 			Expect(nerr.Temporary()).To(BeFalse())
 			Expect(err.Error()).To(Equal("timeout: no recent network activity"))
+			// make sure idle timeouts can be distinguished from stream deadlines
+			Expect(errors.Is(err, os.ErrDeadlineExceeded)).To(BeFalse())
 		})
 	})
 
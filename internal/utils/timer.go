@@ -10,11 +10,21 @@ type Timer struct {
 	t        *time.Timer
 	read     bool
 	deadline time.Time
+	now      func() time.Time
 }
 
 // NewTimer creates a new timer that is not set
 func NewTimer() *Timer {
-	return &Timer{t: time.NewTimer(time.Duration(math.MaxInt64))}
+	return NewTimerWithClock(time.Now)
+}
+
+// NewTimerWithClock creates a new timer that is not set.
+// Deadlines are interpreted relative to the time returned by now.
+func NewTimerWithClock(now func() time.Time) *Timer {
+	return &Timer{
+		t:   time.NewTimer(time.Duration(math.MaxInt64)),
+		now: now,
+	}
 }
 
 // Chan returns the channel of the wrapped timer
@@ -35,7 +45,7 @@ func (t *Timer) Reset(deadline time.Time) {
 		<-t.t.C
 	}
 	if !deadline.IsZero() {
-		t.t.Reset(time.Until(deadline))
+		t.t.Reset(deadline.Sub(t.now()))
 	}
 
 	t.read = false
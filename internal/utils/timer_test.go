@@ -21,6 +21,13 @@ var _ = Describe("Timer", func() {
 		Eventually(t.Chan()).Should(Receive())
 	})
 
+	It("interprets the deadline relative to the clock", func() {
+		now := time.Now().Add(time.Hour)
+		t := NewTimerWithClock(func() time.Time { return now })
+		t.Reset(now.Add(d))
+		Eventually(t.Chan()).Should(Receive())
+	})
+
 	It("works multiple times with reading", func() {
 		t := NewTimer()
 		for i := 0; i < 10; i++ {
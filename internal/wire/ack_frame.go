@@ -94,12 +94,14 @@ func parseAckFrame(r *bytes.Reader, ackDelayExponent uint8, _ protocol.VersionNu
 		return nil, errInvalidAckRanges
 	}
 
-	// parse (and skip) the ECN section
+	// parse the ECN section
 	if ecn {
-		for i := 0; i < 3; i++ {
-			if _, err := quicvarint.Read(r); err != nil {
+		for _, c := range []*uint64{&frame.ECT0, &frame.ECT1, &frame.ECNCE} {
+			n, err := quicvarint.Read(r)
+			if err != nil {
 				return nil, err
 			}
+			*c = n
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
@@ -16,6 +17,9 @@ type frameParser struct {
 	supportsDatagrams bool
 
 	version protocol.VersionNumber
+
+	paddingBytes uint64 // accessed atomically
+	acksReceived uint64 // accessed atomically
 }
 
 // NewFrameParser creates a new frame parser.
@@ -29,12 +33,15 @@ func NewFrameParser(supportsDatagrams bool, v protocol.VersionNumber) FrameParse
 // ParseNext parses the next frame.
 // It skips PADDING frames.
 func (p *frameParser) ParseNext(r *bytes.Reader, encLevel protocol.EncryptionLevel) (Frame, error) {
+	var paddingLen uint64
 	for r.Len() != 0 {
 		typeByte, _ := r.ReadByte()
 		if typeByte == 0x0 { // PADDING frame
+			paddingLen++
 			continue
 		}
 		r.UnreadByte()
+		p.addPadding(paddingLen)
 
 		f, err := p.parseFrame(r, typeByte, encLevel)
 		if err != nil {
@@ -46,9 +53,16 @@ func (p *frameParser) ParseNext(r *bytes.Reader, encLevel protocol.EncryptionLev
 		}
 		return f, nil
 	}
+	p.addPadding(paddingLen)
 	return nil, nil
 }
 
+func (p *frameParser) addPadding(l uint64) {
+	if l > 0 {
+		atomic.AddUint64(&p.paddingBytes, l)
+	}
+}
+
 func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protocol.EncryptionLevel) (Frame, error) {
 	var frame Frame
 	var err error
@@ -64,6 +78,9 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 				ackDelayExponent = protocol.DefaultAckDelayExponent
 			}
 			frame, err = parseAckFrame(r, ackDelayExponent, p.version)
+			if err == nil {
+				atomic.AddUint64(&p.acksReceived, 1)
+			}
 		case 0x4:
 			frame, err = parseResetStreamFrame(r, p.version)
 		case 0x5:
@@ -141,3 +158,11 @@ func (p *frameParser) isAllowedAtEncLevel(f Frame, encLevel protocol.EncryptionL
 func (p *frameParser) SetAckDelayExponent(exp uint8) {
 	p.ackDelayExponent = exp
 }
+
+func (p *frameParser) PaddingBytes() protocol.ByteCount {
+	return protocol.ByteCount(atomic.LoadUint64(&p.paddingBytes))
+}
+
+func (p *frameParser) AcksReceived() uint64 {
+	return atomic.LoadUint64(&p.acksReceived)
+}
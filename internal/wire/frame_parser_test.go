@@ -43,6 +43,21 @@ var _ = Describe("Frame parsing", func() {
 		Expect(r.Len()).To(BeZero())
 	})
 
+	It("counts the bytes of PADDING frames", func() {
+		buf.Write([]byte{0, 0}) // PADDING frames
+		(&PingFrame{}).Write(buf, versionIETFFrames)
+		buf.Write([]byte{0, 0, 0}) // PADDING frames
+		r := bytes.NewReader(buf.Bytes())
+		f, err := parser.ParseNext(r, protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f).To(Equal(&PingFrame{}))
+		Expect(parser.PaddingBytes()).To(BeEquivalentTo(2))
+		f, err = parser.ParseNext(r, protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f).To(BeNil())
+		Expect(parser.PaddingBytes()).To(BeEquivalentTo(5))
+	})
+
 	It("unpacks ACK frames", func() {
 		f := &AckFrame{AckRanges: []AckRange{{Smallest: 1, Largest: 0x13}}}
 		err := f.Write(buf, versionIETFFrames)
@@ -54,6 +69,20 @@ var _ = Describe("Frame parsing", func() {
 		Expect(frame.(*AckFrame).LargestAcked()).To(Equal(protocol.PacketNumber(0x13)))
 	})
 
+	It("counts the ACK frames", func() {
+		Expect(parser.AcksReceived()).To(BeZero())
+		f := &AckFrame{AckRanges: []AckRange{{Smallest: 1, Largest: 0x13}}}
+		Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+		(&PingFrame{}).Write(buf, versionIETFFrames)
+		Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+		r := bytes.NewReader(buf.Bytes())
+		for r.Len() > 0 {
+			_, err := parser.ParseNext(r, protocol.EncryptionHandshake)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(parser.AcksReceived()).To(BeEquivalentTo(2))
+	})
+
 	It("uses the custom ack delay exponent for 1RTT packets", func() {
 		parser.SetAckDelayExponent(protocol.AckDelayExponent + 2)
 		f := &AckFrame{
@@ -61,6 +61,16 @@ func Is0RTTPacket(b []byte) bool {
 
 var ErrUnsupportedVersion = errors.New("unsupported version")
 
+// A TruncatedPacketError is returned when a long header packet is shorter than the length in its header.
+type TruncatedPacketError struct {
+	Declared int // the length of the payload (including the packet number), as declared in the header
+	Actual   int // the number of bytes following the header
+}
+
+func (e *TruncatedPacketError) Error() string {
+	return fmt.Sprintf("packet length (%d bytes) is smaller than the expected length (%d bytes)", e.Actual, e.Declared)
+}
+
 // The Header is the version independent part of the header
 type Header struct {
 	IsLongHeader bool
@@ -93,7 +103,7 @@ func ParsePacket(data []byte, shortHeaderConnIDLen int) (*Header, []byte /* pack
 	var rest []byte
 	if hdr.IsLongHeader {
 		if protocol.ByteCount(len(data)) < hdr.ParsedLen()+hdr.Length {
-			return nil, nil, nil, fmt.Errorf("packet length (%d bytes) is smaller than the expected length (%d bytes)", len(data)-int(hdr.ParsedLen()), hdr.Length)
+			return nil, nil, nil, &TruncatedPacketError{Declared: int(hdr.Length), Actual: len(data) - int(hdr.ParsedLen())}
 		}
 		packetLen := int(hdr.ParsedLen() + hdr.Length)
 		rest = data[packetLen:]
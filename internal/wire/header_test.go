@@ -3,6 +3,7 @@ package wire
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
 
 	"github.com/BGrewell/quic-go/internal/protocol"
@@ -389,7 +390,7 @@ var _ = Describe("Header Parsing", func() {
 					PacketNumberLen: 2,
 				}).Write(buf, versionIETFFrames)).To(Succeed())
 				_, _, _, err := ParsePacket(buf.Bytes(), 4)
-				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(&TruncatedPacketError{Declared: 3, Actual: 2}))
 				Expect(err.Error()).To(ContainSubstring("packet length (2 bytes) is smaller than the expected length (3 bytes)"))
 			})
 
@@ -409,6 +410,10 @@ var _ = Describe("Header Parsing", func() {
 				buf.Write(make([]byte, 500-2 /* for packet number length */))
 				_, _, _, err := ParsePacket(buf.Bytes(), 4)
 				Expect(err).To(MatchError("packet length (500 bytes) is smaller than the expected length (1000 bytes)"))
+				var truncatedErr *TruncatedPacketError
+				Expect(errors.As(err, &truncatedErr)).To(BeTrue())
+				Expect(truncatedErr.Declared).To(Equal(1000))
+				Expect(truncatedErr.Actual).To(Equal(500))
 			})
 		})
 	})
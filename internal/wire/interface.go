@@ -16,4 +16,10 @@ type Frame interface {
 type FrameParser interface {
 	ParseNext(*bytes.Reader, protocol.EncryptionLevel) (Frame, error)
 	SetAckDelayExponent(uint8)
+	// PaddingBytes returns the number of bytes of PADDING frames skipped so far.
+	// It is safe to call concurrently.
+	PaddingBytes() protocol.ByteCount
+	// AcksReceived returns the number of ACK frames parsed so far.
+	// It is safe to call concurrently.
+	AcksReceived() uint64
 }
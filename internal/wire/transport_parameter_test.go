@@ -342,6 +342,35 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.InitialMaxStreamDataBidiRemote).To(Equal(protocol.ByteCount(0x42)))
 	})
 
+	It("returns the raw values of the parameters", func() {
+		b := &bytes.Buffer{}
+		quicvarint.Write(b, uint64(maxDatagramFrameSizeParameterID))
+		quicvarint.Write(b, uint64(quicvarint.Len(0x1337)))
+		quicvarint.Write(b, 0x1337)
+		quicvarint.Write(b, uint64(disableActiveMigrationParameterID))
+		quicvarint.Write(b, 0)
+		// write an unknown parameter
+		quicvarint.Write(b, 0x42)
+		quicvarint.Write(b, 6)
+		b.Write([]byte("foobar"))
+		addInitialSourceConnectionID(b)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(Succeed())
+		val, ok := p.RawValue(uint64(maxDatagramFrameSizeParameterID))
+		Expect(ok).To(BeTrue())
+		expected := &bytes.Buffer{}
+		quicvarint.Write(expected, 0x1337)
+		Expect(val).To(Equal(expected.Bytes()))
+		val, ok = p.RawValue(uint64(disableActiveMigrationParameterID))
+		Expect(ok).To(BeTrue())
+		Expect(val).To(BeEmpty())
+		val, ok = p.RawValue(0x42)
+		Expect(ok).To(BeTrue())
+		Expect(val).To(Equal([]byte("foobar")))
+		_, ok = p.RawValue(uint64(initialMaxDataParameterID))
+		Expect(ok).To(BeFalse())
+	})
+
 	It("rejects duplicate parameters", func() {
 		b := &bytes.Buffer{}
 		// write first parameter
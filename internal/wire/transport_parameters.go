@@ -85,6 +85,9 @@ type TransportParameters struct {
 	ActiveConnectionIDLimit uint64
 
 	MaxDatagramFrameSize protocol.ByteCount
+
+	// the raw values of all transport parameters, as they were received from the peer
+	rawValues map[transportParameterID][]byte
 }
 
 // Unmarshal the transport parameters
@@ -125,6 +128,14 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 			return fmt.Errorf("remaining length (%d) smaller than parameter length (%d)", r.Len(), paramLen)
 		}
 		parameterIDs = append(parameterIDs, paramID)
+		if !fromSessionTicket {
+			if p.rawValues == nil {
+				p.rawValues = make(map[transportParameterID][]byte)
+			}
+			val := make([]byte, paramLen)
+			r.ReadAt(val, r.Size()-int64(r.Len()))
+			p.rawValues[paramID] = val
+		}
 		switch paramID {
 		case maxIdleTimeoutParameterID,
 			maxUDPPayloadSizeParameterID,
@@ -206,6 +217,19 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 	return nil
 }
 
+// RawValue returns the value of the transport parameter with the given ID, as it was received from the peer.
+// This includes transport parameters that are not understood by this implementation.
+// The second return value is false if the peer didn't send this transport parameter.
+func (p *TransportParameters) RawValue(id uint64) ([]byte, bool) {
+	val, ok := p.rawValues[transportParameterID(id)]
+	if !ok {
+		return nil, false
+	}
+	b := make([]byte, len(val))
+	copy(b, val)
+	return b, true
+}
+
 func (p *TransportParameters) readPreferredAddress(r *bytes.Reader, expectedLen int) error {
 	remainingLen := r.Len()
 	pa := &PreferredAddress{}
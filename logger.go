@@ -0,0 +1,51 @@
+package quic
+
+import (
+	"strings"
+
+	"github.com/BGrewell/quic-go/internal/utils"
+)
+
+// loggerAdapter adapts a Logger to the logger that is used internally.
+// The log level is determined by the Logger.
+type loggerAdapter struct {
+	logger Logger
+	prefix string // prepended to the format string, with % escaped
+}
+
+var _ utils.Logger = &loggerAdapter{}
+
+// newLogger returns the logger that is used internally for the Logger of the Config.
+func newLogger(l Logger) utils.Logger {
+	if l == nil {
+		return utils.DefaultLogger
+	}
+	if ul, ok := l.(utils.Logger); ok {
+		return ul
+	}
+	return &loggerAdapter{logger: l}
+}
+
+func (l *loggerAdapter) SetLogLevel(utils.LogLevel) {}
+func (l *loggerAdapter) SetLogTimeFormat(string)    {}
+
+func (l *loggerAdapter) WithPrefix(prefix string) utils.Logger {
+	return &loggerAdapter{
+		logger: l.logger,
+		prefix: l.prefix + strings.ReplaceAll(prefix, "%", "%%") + " ",
+	}
+}
+
+func (l *loggerAdapter) Debug() bool { return l.logger.Debug() }
+
+func (l *loggerAdapter) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf(l.prefix+format, args...)
+}
+
+func (l *loggerAdapter) Infof(format string, args ...interface{}) {
+	l.logger.Infof(l.prefix+format, args...)
+}
+
+func (l *loggerAdapter) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf(l.prefix+format, args...)
+}
@@ -0,0 +1,32 @@
+package quic
+
+import (
+	"github.com/BGrewell/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger", func() {
+	It("uses the default logger, if no logger is set", func() {
+		Expect(newLogger(nil)).To(Equal(utils.DefaultLogger))
+	})
+
+	It("uses loggers that implement the internal interface directly", func() {
+		l := utils.DefaultLogger.WithPrefix("foobar")
+		Expect(newLogger(l)).To(Equal(l))
+	})
+
+	It("adds prefixes", func() {
+		rl := newRecordingLogger()
+		l := newLogger(rl)
+		l.Infof("foo %d", 1)
+		l.WithPrefix("client").Debugf("bar %d", 2)
+		l.WithPrefix("client").WithPrefix("100%").Errorf("baz %d", 3)
+		Expect(rl.Lines()).To(Equal([]string{"foo 1", "client bar 2", "client 100% baz 3"}))
+	})
+
+	It("says if debug logging is enabled", func() {
+		Expect(newLogger(newRecordingLogger()).WithPrefix("client").Debug()).To(BeTrue())
+	})
+})
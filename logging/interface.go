@@ -116,11 +116,26 @@ type ConnectionTracer interface {
 	ReceivedPacket(hdr *ExtendedHeader, size ByteCount, frames []Frame)
 	BufferedPacket(PacketType)
 	DroppedPacket(PacketType, ByteCount, PacketDropReason)
-	UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFlight ByteCount, packetsInFlight int)
+	UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFlight, ssthresh ByteCount, packetsInFlight int)
 	AcknowledgedPacket(EncryptionLevel, PacketNumber)
 	LostPacket(EncryptionLevel, PacketNumber, PacketLossReason)
 	UpdatedCongestionState(CongestionState)
+	UpdatedECNState(ECNState)
+	// UpdatedMTU is called when MTU discovery increases the MTU, and when MTU discovery finishes.
+	UpdatedMTU(mtu ByteCount, done bool)
 	UpdatedPTOCount(value uint32)
+	OpenedStream(id StreamID, initiator Perspective)
+	ClosedStream(id StreamID, reason StreamCloseReason)
+	// StartedHandshake is called when the session starts the TLS handshake.
+	StartedHandshake()
+	// ReceivedKeys is called when the first key of the 0-RTT, Handshake or 1-RTT encryption level is installed.
+	ReceivedKeys(EncryptionLevel)
+	// CompletedHandshake is called when the TLS handshake completes.
+	CompletedHandshake()
+	// SentHandshakeMessage is called when TLS writes a handshake message.
+	SentHandshakeMessage(HandshakeMessageType)
+	// ReceivedHandshakeMessage is called when a handshake message is passed to TLS.
+	ReceivedHandshakeMessage(HandshakeMessageType)
 	UpdatedKeyFromTLS(EncryptionLevel, Perspective)
 	UpdatedKey(generation KeyPhase, remote bool)
 	DroppedEncryptionLevel(EncryptionLevel)
@@ -3,217 +3,265 @@ package logging
 import (
 	"context"
 	"net"
+	"runtime/debug"
 	"time"
+
+	"github.com/BGrewell/quic-go/internal/utils"
 )
 
+// A Logger is used to log panics of multiplexed tracers.
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+// dispatch calls call for the indices 0 to n-1.
+// A panic is logged, and the call continues with the next index, such that the remaining tracers are still called.
+// Recovering is set up once per event, not once per tracer.
+func dispatch(logger Logger, n int, call func(int)) {
+	for i := 0; i < n; {
+		i = dispatchFrom(logger, i, n, call)
+	}
+}
+
+// dispatchFrom returns the index after the one that panicked, or n if no panic occurred.
+func dispatchFrom(logger Logger, i, n int, call func(int)) (next int) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("tracer panicked: %v\n%s", r, debug.Stack())
+			next = i + 1
+		}
+	}()
+	for ; i < n; i++ {
+		call(i)
+	}
+	return n
+}
+
+func nonNilTracers(tracers []Tracer) []Tracer {
+	var ts []Tracer
+	for _, t := range tracers {
+		if t != nil {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+func nonNilConnectionTracers(tracers []ConnectionTracer) []ConnectionTracer {
+	var ts []ConnectionTracer
+	for _, t := range tracers {
+		if t != nil {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
 type tracerMultiplexer struct {
 	tracers []Tracer
+	logger  Logger
 }
 
 var _ Tracer = &tracerMultiplexer{}
 
 // NewMultiplexedTracer creates a new tracer that multiplexes events to multiple tracers.
+// Nil tracers are ignored. A panic in one of the tracers is logged, and doesn't prevent the others from being called.
 func NewMultiplexedTracer(tracers ...Tracer) Tracer {
+	return NewMultiplexedTracerWithLogger(utils.DefaultLogger, tracers...)
+}
+
+// NewMultiplexedTracerWithLogger is like NewMultiplexedTracer, but logs panics of tracers to logger.
+func NewMultiplexedTracerWithLogger(logger Logger, tracers ...Tracer) Tracer {
+	tracers = nonNilTracers(tracers)
 	if len(tracers) == 0 {
 		return nil
 	}
 	if len(tracers) == 1 {
 		return tracers[0]
 	}
-	return &tracerMultiplexer{tracers}
+	return &tracerMultiplexer{tracers: tracers, logger: logger}
 }
 
 func (m *tracerMultiplexer) TracerForConnection(ctx context.Context, p Perspective, odcid ConnectionID) ConnectionTracer {
 	var connTracers []ConnectionTracer
-	for _, t := range m.tracers {
-		if ct := t.TracerForConnection(ctx, p, odcid); ct != nil {
+	dispatch(m.logger, len(m.tracers), func(i int) {
+		if ct := m.tracers[i].TracerForConnection(ctx, p, odcid); ct != nil {
 			connTracers = append(connTracers, ct)
 		}
-	}
-	return NewMultiplexedConnectionTracer(connTracers...)
+	})
+	return NewMultiplexedConnectionTracerWithLogger(m.logger, connTracers...)
 }
 
 func (m *tracerMultiplexer) SentPacket(remote net.Addr, hdr *Header, size ByteCount, frames []Frame) {
-	for _, t := range m.tracers {
-		t.SentPacket(remote, hdr, size, frames)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].SentPacket(remote, hdr, size, frames) })
 }
 
 func (m *tracerMultiplexer) DroppedPacket(remote net.Addr, typ PacketType, size ByteCount, reason PacketDropReason) {
-	for _, t := range m.tracers {
-		t.DroppedPacket(remote, typ, size, reason)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].DroppedPacket(remote, typ, size, reason) })
 }
 
 type connTracerMultiplexer struct {
 	tracers []ConnectionTracer
+	logger  Logger
 }
 
 var _ ConnectionTracer = &connTracerMultiplexer{}
 
 // NewMultiplexedConnectionTracer creates a new connection tracer that multiplexes events to multiple tracers.
+// Nil tracers are ignored. A panic in one of the tracers is logged, and doesn't prevent the others from being called.
 func NewMultiplexedConnectionTracer(tracers ...ConnectionTracer) ConnectionTracer {
+	return NewMultiplexedConnectionTracerWithLogger(utils.DefaultLogger, tracers...)
+}
+
+// NewMultiplexedConnectionTracerWithLogger is like NewMultiplexedConnectionTracer, but logs panics of tracers to logger.
+// quic-go uses the logger of the connection.
+func NewMultiplexedConnectionTracerWithLogger(logger Logger, tracers ...ConnectionTracer) ConnectionTracer {
+	tracers = nonNilConnectionTracers(tracers)
 	if len(tracers) == 0 {
 		return nil
 	}
 	if len(tracers) == 1 {
 		return tracers[0]
 	}
-	return &connTracerMultiplexer{tracers: tracers}
+	return &connTracerMultiplexer{tracers: tracers, logger: logger}
 }
 
 func (m *connTracerMultiplexer) StartedConnection(local, remote net.Addr, srcConnID, destConnID ConnectionID) {
-	for _, t := range m.tracers {
-		t.StartedConnection(local, remote, srcConnID, destConnID)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].StartedConnection(local, remote, srcConnID, destConnID) })
 }
 
 func (m *connTracerMultiplexer) NegotiatedVersion(chosen VersionNumber, clientVersions, serverVersions []VersionNumber) {
-	for _, t := range m.tracers {
-		t.NegotiatedVersion(chosen, clientVersions, serverVersions)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].NegotiatedVersion(chosen, clientVersions, serverVersions) })
 }
 
 func (m *connTracerMultiplexer) ClosedConnection(e error) {
-	for _, t := range m.tracers {
-		t.ClosedConnection(e)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ClosedConnection(e) })
 }
 
 func (m *connTracerMultiplexer) SentTransportParameters(tp *TransportParameters) {
-	for _, t := range m.tracers {
-		t.SentTransportParameters(tp)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].SentTransportParameters(tp) })
 }
 
 func (m *connTracerMultiplexer) ReceivedTransportParameters(tp *TransportParameters) {
-	for _, t := range m.tracers {
-		t.ReceivedTransportParameters(tp)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedTransportParameters(tp) })
 }
 
 func (m *connTracerMultiplexer) RestoredTransportParameters(tp *TransportParameters) {
-	for _, t := range m.tracers {
-		t.RestoredTransportParameters(tp)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].RestoredTransportParameters(tp) })
 }
 
 func (m *connTracerMultiplexer) SentPacket(hdr *ExtendedHeader, size ByteCount, ack *AckFrame, frames []Frame) {
-	for _, t := range m.tracers {
-		t.SentPacket(hdr, size, ack, frames)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].SentPacket(hdr, size, ack, frames) })
 }
 
 func (m *connTracerMultiplexer) ReceivedVersionNegotiationPacket(hdr *Header, versions []VersionNumber) {
-	for _, t := range m.tracers {
-		t.ReceivedVersionNegotiationPacket(hdr, versions)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedVersionNegotiationPacket(hdr, versions) })
 }
 
 func (m *connTracerMultiplexer) ReceivedRetry(hdr *Header) {
-	for _, t := range m.tracers {
-		t.ReceivedRetry(hdr)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedRetry(hdr) })
 }
 
 func (m *connTracerMultiplexer) ReceivedPacket(hdr *ExtendedHeader, size ByteCount, frames []Frame) {
-	for _, t := range m.tracers {
-		t.ReceivedPacket(hdr, size, frames)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedPacket(hdr, size, frames) })
 }
 
 func (m *connTracerMultiplexer) BufferedPacket(typ PacketType) {
-	for _, t := range m.tracers {
-		t.BufferedPacket(typ)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].BufferedPacket(typ) })
 }
 
 func (m *connTracerMultiplexer) DroppedPacket(typ PacketType, size ByteCount, reason PacketDropReason) {
-	for _, t := range m.tracers {
-		t.DroppedPacket(typ, size, reason)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].DroppedPacket(typ, size, reason) })
 }
 
 func (m *connTracerMultiplexer) UpdatedCongestionState(state CongestionState) {
-	for _, t := range m.tracers {
-		t.UpdatedCongestionState(state)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedCongestionState(state) })
 }
 
-func (m *connTracerMultiplexer) UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFLight ByteCount, packetsInFlight int) {
-	for _, t := range m.tracers {
-		t.UpdatedMetrics(rttStats, cwnd, bytesInFLight, packetsInFlight)
-	}
+func (m *connTracerMultiplexer) UpdatedECNState(state ECNState) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedECNState(state) })
+}
+
+func (m *connTracerMultiplexer) UpdatedMTU(mtu ByteCount, done bool) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedMTU(mtu, done) })
+}
+
+func (m *connTracerMultiplexer) UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFLight, ssthresh ByteCount, packetsInFlight int) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedMetrics(rttStats, cwnd, bytesInFLight, ssthresh, packetsInFlight) })
 }
 
 func (m *connTracerMultiplexer) AcknowledgedPacket(encLevel EncryptionLevel, pn PacketNumber) {
-	for _, t := range m.tracers {
-		t.AcknowledgedPacket(encLevel, pn)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].AcknowledgedPacket(encLevel, pn) })
 }
 
 func (m *connTracerMultiplexer) LostPacket(encLevel EncryptionLevel, pn PacketNumber, reason PacketLossReason) {
-	for _, t := range m.tracers {
-		t.LostPacket(encLevel, pn, reason)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].LostPacket(encLevel, pn, reason) })
 }
 
 func (m *connTracerMultiplexer) UpdatedPTOCount(value uint32) {
-	for _, t := range m.tracers {
-		t.UpdatedPTOCount(value)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedPTOCount(value) })
+}
+
+func (m *connTracerMultiplexer) OpenedStream(id StreamID, initiator Perspective) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].OpenedStream(id, initiator) })
+}
+
+func (m *connTracerMultiplexer) ClosedStream(id StreamID, reason StreamCloseReason) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ClosedStream(id, reason) })
+}
+
+func (m *connTracerMultiplexer) StartedHandshake() {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].StartedHandshake() })
+}
+
+func (m *connTracerMultiplexer) ReceivedKeys(encLevel EncryptionLevel) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedKeys(encLevel) })
+}
+
+func (m *connTracerMultiplexer) CompletedHandshake() {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].CompletedHandshake() })
+}
+
+func (m *connTracerMultiplexer) SentHandshakeMessage(typ HandshakeMessageType) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].SentHandshakeMessage(typ) })
+}
+
+func (m *connTracerMultiplexer) ReceivedHandshakeMessage(typ HandshakeMessageType) {
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].ReceivedHandshakeMessage(typ) })
 }
 
 func (m *connTracerMultiplexer) UpdatedKeyFromTLS(encLevel EncryptionLevel, perspective Perspective) {
-	for _, t := range m.tracers {
-		t.UpdatedKeyFromTLS(encLevel, perspective)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedKeyFromTLS(encLevel, perspective) })
 }
 
 func (m *connTracerMultiplexer) UpdatedKey(generation KeyPhase, remote bool) {
-	for _, t := range m.tracers {
-		t.UpdatedKey(generation, remote)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].UpdatedKey(generation, remote) })
 }
 
 func (m *connTracerMultiplexer) DroppedEncryptionLevel(encLevel EncryptionLevel) {
-	for _, t := range m.tracers {
-		t.DroppedEncryptionLevel(encLevel)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].DroppedEncryptionLevel(encLevel) })
 }
 
 func (m *connTracerMultiplexer) DroppedKey(generation KeyPhase) {
-	for _, t := range m.tracers {
-		t.DroppedKey(generation)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].DroppedKey(generation) })
 }
 
 func (m *connTracerMultiplexer) SetLossTimer(typ TimerType, encLevel EncryptionLevel, exp time.Time) {
-	for _, t := range m.tracers {
-		t.SetLossTimer(typ, encLevel, exp)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].SetLossTimer(typ, encLevel, exp) })
 }
 
 func (m *connTracerMultiplexer) LossTimerExpired(typ TimerType, encLevel EncryptionLevel) {
-	for _, t := range m.tracers {
-		t.LossTimerExpired(typ, encLevel)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].LossTimerExpired(typ, encLevel) })
 }
 
 func (m *connTracerMultiplexer) LossTimerCanceled() {
-	for _, t := range m.tracers {
-		t.LossTimerCanceled()
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].LossTimerCanceled() })
 }
 
 func (m *connTracerMultiplexer) Debug(name, msg string) {
-	for _, t := range m.tracers {
-		t.Debug(name, msg)
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].Debug(name, msg) })
 }
 
 func (m *connTracerMultiplexer) Close() {
-	for _, t := range m.tracers {
-		t.Close()
-	}
+	dispatch(m.logger, len(m.tracers), func(i int) { m.tracers[i].Close() })
 }
@@ -3,7 +3,9 @@ package logging
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"testing"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/wire"
@@ -12,6 +14,12 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type recordingLogger struct{ messages []string }
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
 var _ = Describe("Tracing", func() {
 	Context("Tracer", func() {
 		It("returns a nil tracer if no tracers are passed in", func() {
@@ -24,6 +32,12 @@ var _ = Describe("Tracing", func() {
 			Expect(tracer).To(BeAssignableToTypeOf(&MockTracer{}))
 		})
 
+		It("ignores nil tracers", func() {
+			Expect(NewMultiplexedTracer(nil, nil)).To(BeNil())
+			tr := NewMockTracer(mockCtrl)
+			Expect(NewMultiplexedTracer(nil, tr, nil)).To(Equal(tr))
+		})
+
 		Context("tracing events", func() {
 			var (
 				tracer   Tracer
@@ -55,6 +69,19 @@ var _ = Describe("Tracing", func() {
 				tr.LossTimerCanceled()
 			})
 
+			It("calls the other tracers when one of them panics", func() {
+				logger := &recordingLogger{}
+				tracer := NewMultiplexedTracerWithLogger(logger, tr1, tr2)
+				remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1)}
+				tr1.EXPECT().DroppedPacket(remote, PacketTypeInitial, ByteCount(1337), PacketDropHeaderParseError).Do(func(net.Addr, PacketType, ByteCount, PacketDropReason) {
+					panic("foobar")
+				})
+				tr2.EXPECT().DroppedPacket(remote, PacketTypeInitial, ByteCount(1337), PacketDropHeaderParseError)
+				tracer.DroppedPacket(remote, PacketTypeInitial, 1337, PacketDropHeaderParseError)
+				Expect(logger.messages).To(HaveLen(1))
+				Expect(logger.messages[0]).To(ContainSubstring("tracer panicked: foobar"))
+			})
+
 			It("handles tracers that return a nil ConnectionTracer", func() {
 				ctx := context.Background()
 				ctr1 := NewMockConnectionTracer(mockCtrl)
@@ -103,6 +130,43 @@ var _ = Describe("Tracing", func() {
 			tracer = NewMultiplexedConnectionTracer(tr1, tr2)
 		})
 
+		It("ignores nil tracers", func() {
+			Expect(NewMultiplexedConnectionTracer(nil, nil)).To(BeNil())
+			Expect(NewMultiplexedConnectionTracer(nil, tr1, nil)).To(Equal(tr1))
+			tracer := NewMultiplexedConnectionTracer(tr1, nil, tr2)
+			tr1.EXPECT().LossTimerCanceled()
+			tr2.EXPECT().LossTimerCanceled()
+			tracer.LossTimerCanceled()
+		})
+
+		It("calls the other tracers when one of them panics", func() {
+			logger := &recordingLogger{}
+			tracer := NewMultiplexedConnectionTracerWithLogger(logger, tr1, tr2)
+			tr1.EXPECT().UpdatedPTOCount(uint32(42)).Do(func(uint32) { panic("foobar") })
+			tr2.EXPECT().UpdatedPTOCount(uint32(42))
+			tracer.UpdatedPTOCount(42)
+			Expect(logger.messages).To(HaveLen(1))
+			Expect(logger.messages[0]).To(ContainSubstring("tracer panicked: foobar"))
+		})
+
+		It("continues after multiple tracers panic", func() {
+			logger := &recordingLogger{}
+			tr3 := NewMockConnectionTracer(mockCtrl)
+			tracer := NewMultiplexedConnectionTracerWithLogger(logger, tr1, tr2, tr3)
+			tr1.EXPECT().UpdatedPTOCount(uint32(42)).Do(func(uint32) { panic("foo") })
+			tr2.EXPECT().UpdatedPTOCount(uint32(42)).Do(func(uint32) { panic("bar") })
+			tr3.EXPECT().UpdatedPTOCount(uint32(42))
+			tracer.UpdatedPTOCount(42)
+			Expect(logger.messages).To(HaveLen(2))
+			Expect(logger.messages[0]).To(ContainSubstring("tracer panicked: foo"))
+			Expect(logger.messages[1]).To(ContainSubstring("tracer panicked: bar"))
+		})
+
+		It("doesn't allocate when dispatching events", func() {
+			tracer := NewMultiplexedConnectionTracer(NullConnectionTracer, NullConnectionTracer)
+			Expect(testing.AllocsPerRun(100, func() { tracer.UpdatedPTOCount(42) })).To(BeZero())
+		})
+
 		It("trace the ConnectionStarted event", func() {
 			local := &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4)}
 			remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1)}
@@ -188,12 +252,24 @@ var _ = Describe("Tracing", func() {
 			tracer.UpdatedCongestionState(CongestionStateRecovery)
 		})
 
+		It("traces the UpdatedECNState event", func() {
+			tr1.EXPECT().UpdatedECNState(ECNStateCapable)
+			tr2.EXPECT().UpdatedECNState(ECNStateCapable)
+			tracer.UpdatedECNState(ECNStateCapable)
+		})
+
+		It("traces the UpdatedMTU event", func() {
+			tr1.EXPECT().UpdatedMTU(ByteCount(1400), true)
+			tr2.EXPECT().UpdatedMTU(ByteCount(1400), true)
+			tracer.UpdatedMTU(1400, true)
+		})
+
 		It("traces the UpdatedMetrics event", func() {
 			rttStats := &RTTStats{}
 			rttStats.UpdateRTT(time.Second, 0, time.Now())
-			tr1.EXPECT().UpdatedMetrics(rttStats, ByteCount(1337), ByteCount(42), 13)
-			tr2.EXPECT().UpdatedMetrics(rttStats, ByteCount(1337), ByteCount(42), 13)
-			tracer.UpdatedMetrics(rttStats, 1337, 42, 13)
+			tr1.EXPECT().UpdatedMetrics(rttStats, ByteCount(1337), ByteCount(42), ByteCount(2000), 13)
+			tr2.EXPECT().UpdatedMetrics(rttStats, ByteCount(1337), ByteCount(42), ByteCount(2000), 13)
+			tracer.UpdatedMetrics(rttStats, 1337, 42, 2000, 13)
 		})
 
 		It("traces the AcknowledgedPacket event", func() {
@@ -214,6 +290,48 @@ var _ = Describe("Tracing", func() {
 			tracer.UpdatedPTOCount(88)
 		})
 
+		It("traces the OpenedStream event", func() {
+			tr1.EXPECT().OpenedStream(StreamID(4), PerspectiveServer)
+			tr2.EXPECT().OpenedStream(StreamID(4), PerspectiveServer)
+			tracer.OpenedStream(4, PerspectiveServer)
+		})
+
+		It("traces the ClosedStream event", func() {
+			tr1.EXPECT().ClosedStream(StreamID(4), StreamCloseReasonConnectionClosed)
+			tr2.EXPECT().ClosedStream(StreamID(4), StreamCloseReasonConnectionClosed)
+			tracer.ClosedStream(4, StreamCloseReasonConnectionClosed)
+		})
+
+		It("traces the StartedHandshake event", func() {
+			tr1.EXPECT().StartedHandshake()
+			tr2.EXPECT().StartedHandshake()
+			tracer.StartedHandshake()
+		})
+
+		It("traces the ReceivedKeys event", func() {
+			tr1.EXPECT().ReceivedKeys(EncryptionHandshake)
+			tr2.EXPECT().ReceivedKeys(EncryptionHandshake)
+			tracer.ReceivedKeys(EncryptionHandshake)
+		})
+
+		It("traces the CompletedHandshake event", func() {
+			tr1.EXPECT().CompletedHandshake()
+			tr2.EXPECT().CompletedHandshake()
+			tracer.CompletedHandshake()
+		})
+
+		It("traces the SentHandshakeMessage event", func() {
+			tr1.EXPECT().SentHandshakeMessage(HandshakeMessageClientHello)
+			tr2.EXPECT().SentHandshakeMessage(HandshakeMessageClientHello)
+			tracer.SentHandshakeMessage(HandshakeMessageClientHello)
+		})
+
+		It("traces the ReceivedHandshakeMessage event", func() {
+			tr1.EXPECT().ReceivedHandshakeMessage(HandshakeMessageServerHello)
+			tr2.EXPECT().ReceivedHandshakeMessage(HandshakeMessageServerHello)
+			tracer.ReceivedHandshakeMessage(HandshakeMessageServerHello)
+		})
+
 		It("traces the UpdatedKeyFromTLS event", func() {
 			tr1.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
 			tr2.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
@@ -92,3 +92,52 @@ const (
 	// CongestionStateApplicationLimited means that the congestion controller is application limited
 	CongestionStateApplicationLimited
 )
+
+// ECNState is the state of the ECN validation of a path
+type ECNState uint8
+
+const (
+	// ECNStateTesting means that packets are sent with ECT(0) marks to test if the path supports ECN
+	ECNStateTesting ECNState = iota
+	// ECNStateUnknown means that all testing packets were sent, and we're waiting for them to be acknowledged
+	ECNStateUnknown
+	// ECNStateFailed means that ECN validation failed, and packets are sent without ECN marks
+	ECNStateFailed
+	// ECNStateCapable means that the path supports ECN, and packets are sent with ECT(0) marks
+	ECNStateCapable
+)
+
+// StreamCloseReason is the reason why a stream was closed
+type StreamCloseReason uint8
+
+const (
+	// StreamCloseReasonCompleted is used when both directions of the stream were completed (or canceled)
+	StreamCloseReasonCompleted StreamCloseReason = iota
+	// StreamCloseReasonConnectionClosed is used when the stream was still open when the connection was closed
+	StreamCloseReasonConnectionClosed
+	// StreamCloseReason0RTTRejected is used when the stream was opened in 0-RTT, and the server rejected 0-RTT
+	StreamCloseReason0RTTRejected
+)
+
+// HandshakeMessageType is the type of a TLS handshake message.
+// The values are the message types defined by TLS 1.3 (RFC 8446).
+type HandshakeMessageType uint8
+
+const (
+	// HandshakeMessageClientHello is a ClientHello
+	HandshakeMessageClientHello HandshakeMessageType = 1
+	// HandshakeMessageServerHello is a ServerHello
+	HandshakeMessageServerHello HandshakeMessageType = 2
+	// HandshakeMessageNewSessionTicket is a NewSessionTicket
+	HandshakeMessageNewSessionTicket HandshakeMessageType = 4
+	// HandshakeMessageEncryptedExtensions is an EncryptedExtensions
+	HandshakeMessageEncryptedExtensions HandshakeMessageType = 8
+	// HandshakeMessageCertificate is a Certificate
+	HandshakeMessageCertificate HandshakeMessageType = 11
+	// HandshakeMessageCertificateRequest is a CertificateRequest
+	HandshakeMessageCertificateRequest HandshakeMessageType = 13
+	// HandshakeMessageCertificateVerify is a CertificateVerify
+	HandshakeMessageCertificateVerify HandshakeMessageType = 15
+	// HandshakeMessageFinished is a Finished
+	HandshakeMessageFinished HandshakeMessageType = 20
+)
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: conn.go
+
+// Package quic is a generated GoMock package.
+package quic
+
+import (
+	net "net"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBatchWriter is a mock of BatchWriter interface.
+type MockBatchWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchWriterMockRecorder
+}
+
+// MockBatchWriterMockRecorder is the mock recorder for MockBatchWriter.
+type MockBatchWriterMockRecorder struct {
+	mock *MockBatchWriter
+}
+
+// NewMockBatchWriter creates a new mock instance.
+func NewMockBatchWriter(ctrl *gomock.Controller) *MockBatchWriter {
+	mock := &MockBatchWriter{ctrl: ctrl}
+	mock.recorder = &MockBatchWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchWriter) EXPECT() *MockBatchWriterMockRecorder {
+	return m.recorder
+}
+
+// WritePackets mocks base method.
+func (m *MockBatchWriter) WritePackets(packets [][]byte, addr net.Addr, oob []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePackets", packets, addr, oob)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WritePackets indicates an expected call of WritePackets.
+func (mr *MockBatchWriterMockRecorder) WritePackets(packets, addr, oob interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePackets", reflect.TypeOf((*MockBatchWriter)(nil).WritePackets), packets, addr, oob)
+}
@@ -7,11 +7,11 @@ package quic
 import (
 	reflect "reflect"
 
-	gomock "github.com/golang/mock/gomock"
 	ackhandler "github.com/BGrewell/quic-go/internal/ackhandler"
 	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	qerr "github.com/BGrewell/quic-go/internal/qerr"
 	wire "github.com/BGrewell/quic-go/internal/wire"
+	gomock "github.com/golang/mock/gomock"
 )
 
 // MockPacker is a mock of Packer interface.
@@ -95,18 +95,18 @@ func (mr *MockPackerMockRecorder) PackApplicationClose(arg0 interface{}) *gomock
 }
 
 // PackCoalescedPacket mocks base method.
-func (m *MockPacker) PackCoalescedPacket() (*coalescedPacket, error) {
+func (m *MockPacker) PackCoalescedPacket(onlyAck bool) (*coalescedPacket, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PackCoalescedPacket")
+	ret := m.ctrl.Call(m, "PackCoalescedPacket", onlyAck)
 	ret0, _ := ret[0].(*coalescedPacket)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PackCoalescedPacket indicates an expected call of PackCoalescedPacket.
-func (mr *MockPackerMockRecorder) PackCoalescedPacket() *gomock.Call {
+func (mr *MockPackerMockRecorder) PackCoalescedPacket(onlyAck interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackCoalescedPacket", reflect.TypeOf((*MockPacker)(nil).PackCoalescedPacket))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackCoalescedPacket", reflect.TypeOf((*MockPacker)(nil).PackCoalescedPacket), onlyAck)
 }
 
 // PackConnectionClose mocks base method.
@@ -154,6 +154,35 @@ func (mr *MockPackerMockRecorder) PackPacket() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackPacket", reflect.TypeOf((*MockPacker)(nil).PackPacket))
 }
 
+// PackPathProbePacket mocks base method.
+func (m *MockPacker) PackPathProbePacket(connID protocol.ConnectionID, frame ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PackPathProbePacket", connID, frame, size)
+	ret0, _ := ret[0].(*packedPacket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PackPathProbePacket indicates an expected call of PackPathProbePacket.
+func (mr *MockPackerMockRecorder) PackPathProbePacket(connID, frame, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackPathProbePacket", reflect.TypeOf((*MockPacker)(nil).PackPathProbePacket), connID, frame, size)
+}
+
+// PaddingBytes mocks base method.
+func (m *MockPacker) PaddingBytes() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaddingBytes")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// PaddingBytes indicates an expected call of PaddingBytes.
+func (mr *MockPackerMockRecorder) PaddingBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaddingBytes", reflect.TypeOf((*MockPacker)(nil).PaddingBytes))
+}
+
 // SetMaxPacketSize mocks base method.
 func (m *MockPacker) SetMaxPacketSize(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -8,8 +8,10 @@ import (
 	context "context"
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	congestion "github.com/BGrewell/quic-go/internal/congestion"
 	protocol "github.com/BGrewell/quic-go/internal/protocol"
 )
 
@@ -66,6 +68,77 @@ func (mr *MockQuicSessionMockRecorder) AcceptUniStream(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptUniStream", reflect.TypeOf((*MockQuicSession)(nil).AcceptUniStream), arg0)
 }
 
+// AckStats mocks base method.
+func (m *MockQuicSession) AckStats() (uint64, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckStats")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// AckStats indicates an expected call of AckStats.
+func (mr *MockQuicSessionMockRecorder) AckStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckStats", reflect.TypeOf((*MockQuicSession)(nil).AckStats))
+}
+
+// BytesAcked mocks base method.
+func (m *MockQuicSession) BytesAcked() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BytesAcked")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// BytesAcked indicates an expected call of BytesAcked.
+func (mr *MockQuicSessionMockRecorder) BytesAcked() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BytesAcked", reflect.TypeOf((*MockQuicSession)(nil).BytesAcked))
+}
+
+// BytesSent mocks base method.
+func (m *MockQuicSession) BytesSent() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BytesSent")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// BytesSent indicates an expected call of BytesSent.
+func (mr *MockQuicSessionMockRecorder) BytesSent() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BytesSent", reflect.TypeOf((*MockQuicSession)(nil).BytesSent))
+}
+
+// CanSendNow mocks base method.
+func (m *MockQuicSession) CanSendNow() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CanSendNow")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CanSendNow indicates an expected call of CanSendNow.
+func (mr *MockQuicSessionMockRecorder) CanSendNow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanSendNow", reflect.TypeOf((*MockQuicSession)(nil).CanSendNow))
+}
+
+// CloseGracefully mocks base method.
+func (m *MockQuicSession) CloseGracefully(timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseGracefully", timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseGracefully indicates an expected call of CloseGracefully.
+func (mr *MockQuicSessionMockRecorder) CloseGracefully(timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseGracefully", reflect.TypeOf((*MockQuicSession)(nil).CloseGracefully), timeout)
+}
+
 // CloseWithError mocks base method.
 func (m *MockQuicSession) CloseWithError(arg0 ApplicationErrorCode, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -80,6 +153,49 @@ func (mr *MockQuicSessionMockRecorder) CloseWithError(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseWithError", reflect.TypeOf((*MockQuicSession)(nil).CloseWithError), arg0, arg1)
 }
 
+// CongestionControl mocks base method.
+func (m *MockQuicSession) CongestionControl() congestion.CongestionAlgo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CongestionControl")
+	ret0, _ := ret[0].(congestion.CongestionAlgo)
+	return ret0
+}
+
+// CongestionControl indicates an expected call of CongestionControl.
+func (mr *MockQuicSessionMockRecorder) CongestionControl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CongestionControl", reflect.TypeOf((*MockQuicSession)(nil).CongestionControl))
+}
+
+// CongestionEvents mocks base method.
+func (m *MockQuicSession) CongestionEvents() <-chan CongestionEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CongestionEvents")
+	ret0, _ := ret[0].(<-chan CongestionEvent)
+	return ret0
+}
+
+// CongestionEvents indicates an expected call of CongestionEvents.
+func (mr *MockQuicSessionMockRecorder) CongestionEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CongestionEvents", reflect.TypeOf((*MockQuicSession)(nil).CongestionEvents))
+}
+
+// ConnectionIDs mocks base method.
+func (m *MockQuicSession) ConnectionIDs() (ConnectionID, ConnectionID) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectionIDs")
+	ret0, _ := ret[0].(ConnectionID)
+	ret1, _ := ret[1].(ConnectionID)
+	return ret0, ret1
+}
+
+// ConnectionIDs indicates an expected call of ConnectionIDs.
+func (mr *MockQuicSessionMockRecorder) ConnectionIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionIDs", reflect.TypeOf((*MockQuicSession)(nil).ConnectionIDs))
+}
+
 // ConnectionState mocks base method.
 func (m *MockQuicSession) ConnectionState() ConnectionState {
 	m.ctrl.T.Helper()
@@ -94,6 +210,20 @@ func (mr *MockQuicSessionMockRecorder) ConnectionState() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionState", reflect.TypeOf((*MockQuicSession)(nil).ConnectionState))
 }
 
+// ConnectionStats mocks base method.
+func (m *MockQuicSession) ConnectionStats() ConnectionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectionStats")
+	ret0, _ := ret[0].(ConnectionStats)
+	return ret0
+}
+
+// ConnectionStats indicates an expected call of ConnectionStats.
+func (mr *MockQuicSessionMockRecorder) ConnectionStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionStats", reflect.TypeOf((*MockQuicSession)(nil).ConnectionStats))
+}
+
 // Context mocks base method.
 func (m *MockQuicSession) Context() context.Context {
 	m.ctrl.T.Helper()
@@ -108,6 +238,34 @@ func (mr *MockQuicSessionMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockQuicSession)(nil).Context))
 }
 
+// CurrentMTU mocks base method.
+func (m *MockQuicSession) CurrentMTU() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentMTU")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// CurrentMTU indicates an expected call of CurrentMTU.
+func (mr *MockQuicSessionMockRecorder) CurrentMTU() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentMTU", reflect.TypeOf((*MockQuicSession)(nil).CurrentMTU))
+}
+
+// DroppedCongestionEvents mocks base method.
+func (m *MockQuicSession) DroppedCongestionEvents() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DroppedCongestionEvents")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// DroppedCongestionEvents indicates an expected call of DroppedCongestionEvents.
+func (mr *MockQuicSessionMockRecorder) DroppedCongestionEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedCongestionEvents", reflect.TypeOf((*MockQuicSession)(nil).DroppedCongestionEvents))
+}
+
 // GetVersion mocks base method.
 func (m *MockQuicSession) GetVersion() protocol.VersionNumber {
 	m.ctrl.T.Helper()
@@ -136,6 +294,20 @@ func (mr *MockQuicSessionMockRecorder) HandshakeComplete() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandshakeComplete", reflect.TypeOf((*MockQuicSession)(nil).HandshakeComplete))
 }
 
+// LargestAcked mocks base method.
+func (m *MockQuicSession) LargestAcked(arg0 protocol.EncryptionLevel) protocol.PacketNumber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LargestAcked", arg0)
+	ret0, _ := ret[0].(protocol.PacketNumber)
+	return ret0
+}
+
+// LargestAcked indicates an expected call of LargestAcked.
+func (mr *MockQuicSessionMockRecorder) LargestAcked(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LargestAcked", reflect.TypeOf((*MockQuicSession)(nil).LargestAcked), arg0)
+}
+
 // LocalAddr mocks base method.
 func (m *MockQuicSession) LocalAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -150,6 +322,20 @@ func (mr *MockQuicSessionMockRecorder) LocalAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalAddr", reflect.TypeOf((*MockQuicSession)(nil).LocalAddr))
 }
 
+// MigrateTo mocks base method.
+func (m *MockQuicSession) MigrateTo(arg0 net.PacketConn) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateTo", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateTo indicates an expected call of MigrateTo.
+func (mr *MockQuicSessionMockRecorder) MigrateTo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateTo", reflect.TypeOf((*MockQuicSession)(nil).MigrateTo), arg0)
+}
+
 // NextSession mocks base method.
 func (m *MockQuicSession) NextSession() Session {
 	m.ctrl.T.Helper()
@@ -164,6 +350,20 @@ func (mr *MockQuicSessionMockRecorder) NextSession() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSession", reflect.TypeOf((*MockQuicSession)(nil).NextSession))
 }
 
+// NextTimeout mocks base method.
+func (m *MockQuicSession) NextTimeout() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextTimeout")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// NextTimeout indicates an expected call of NextTimeout.
+func (mr *MockQuicSessionMockRecorder) NextTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextTimeout", reflect.TypeOf((*MockQuicSession)(nil).NextTimeout))
+}
+
 // OpenStream mocks base method.
 func (m *MockQuicSession) OpenStream() (Stream, error) {
 	m.ctrl.T.Helper()
@@ -194,6 +394,20 @@ func (mr *MockQuicSessionMockRecorder) OpenStreamSync(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreamSync", reflect.TypeOf((*MockQuicSession)(nil).OpenStreamSync), arg0)
 }
 
+// OpenStreams mocks base method.
+func (m *MockQuicSession) OpenStreams() []StreamID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenStreams")
+	ret0, _ := ret[0].([]StreamID)
+	return ret0
+}
+
+// OpenStreams indicates an expected call of OpenStreams.
+func (mr *MockQuicSessionMockRecorder) OpenStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreams", reflect.TypeOf((*MockQuicSession)(nil).OpenStreams))
+}
+
 // OpenUniStream mocks base method.
 func (m *MockQuicSession) OpenUniStream() (SendStream, error) {
 	m.ctrl.T.Helper()
@@ -224,6 +438,50 @@ func (mr *MockQuicSessionMockRecorder) OpenUniStreamSync(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenUniStreamSync", reflect.TypeOf((*MockQuicSession)(nil).OpenUniStreamSync), arg0)
 }
 
+// PaddingStats mocks base method.
+func (m *MockQuicSession) PaddingStats() (protocol.ByteCount, protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaddingStats")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	ret1, _ := ret[1].(protocol.ByteCount)
+	return ret0, ret1
+}
+
+// PaddingStats indicates an expected call of PaddingStats.
+func (mr *MockQuicSessionMockRecorder) PaddingStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaddingStats", reflect.TypeOf((*MockQuicSession)(nil).PaddingStats))
+}
+
+// PeerSupportsDatagrams mocks base method.
+func (m *MockQuicSession) PeerSupportsDatagrams() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerSupportsDatagrams")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// PeerSupportsDatagrams indicates an expected call of PeerSupportsDatagrams.
+func (mr *MockQuicSessionMockRecorder) PeerSupportsDatagrams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerSupportsDatagrams", reflect.TypeOf((*MockQuicSession)(nil).PeerSupportsDatagrams))
+}
+
+// PeerTransportParameter mocks base method.
+func (m *MockQuicSession) PeerTransportParameter(id uint64) ([]byte, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerTransportParameter", id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// PeerTransportParameter indicates an expected call of PeerTransportParameter.
+func (mr *MockQuicSessionMockRecorder) PeerTransportParameter(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerTransportParameter", reflect.TypeOf((*MockQuicSession)(nil).PeerTransportParameter), id)
+}
+
 // ReceiveMessage mocks base method.
 func (m *MockQuicSession) ReceiveMessage() ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -253,6 +511,35 @@ func (mr *MockQuicSessionMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockQuicSession)(nil).RemoteAddr))
 }
 
+// ReorderingStats mocks base method.
+func (m *MockQuicSession) ReorderingStats() (int, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderingStats")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// ReorderingStats indicates an expected call of ReorderingStats.
+func (mr *MockQuicSessionMockRecorder) ReorderingStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderingStats", reflect.TypeOf((*MockQuicSession)(nil).ReorderingStats))
+}
+
+// SavedSyscalls mocks base method.
+func (m *MockQuicSession) SavedSyscalls() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavedSyscalls")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// SavedSyscalls indicates an expected call of SavedSyscalls.
+func (mr *MockQuicSessionMockRecorder) SavedSyscalls() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavedSyscalls", reflect.TypeOf((*MockQuicSession)(nil).SavedSyscalls))
+}
+
 // SendMessage mocks base method.
 func (m *MockQuicSession) SendMessage(arg0 []byte) error {
 	m.ctrl.T.Helper()
@@ -267,6 +554,48 @@ func (mr *MockQuicSessionMockRecorder) SendMessage(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockQuicSession)(nil).SendMessage), arg0)
 }
 
+// SetWriteDeadline mocks base method.
+func (m *MockQuicSession) SetWriteDeadline(t time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWriteDeadline", t)
+}
+
+// SetWriteDeadline indicates an expected call of SetWriteDeadline.
+func (mr *MockQuicSessionMockRecorder) SetWriteDeadline(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDeadline", reflect.TypeOf((*MockQuicSession)(nil).SetWriteDeadline), t)
+}
+
+// SupportedVersions mocks base method.
+func (m *MockQuicSession) SupportedVersions() []VersionNumber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SupportedVersions")
+	ret0, _ := ret[0].([]VersionNumber)
+	return ret0
+}
+
+// SupportedVersions indicates an expected call of SupportedVersions.
+func (mr *MockQuicSessionMockRecorder) SupportedVersions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SupportedVersions", reflect.TypeOf((*MockQuicSession)(nil).SupportedVersions))
+}
+
+// Timestamps mocks base method.
+func (m *MockQuicSession) Timestamps() (time.Time, time.Time, time.Time) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Timestamps")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(time.Time)
+	return ret0, ret1, ret2
+}
+
+// Timestamps indicates an expected call of Timestamps.
+func (mr *MockQuicSessionMockRecorder) Timestamps() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Timestamps", reflect.TypeOf((*MockQuicSession)(nil).Timestamps))
+}
+
 // destroy mocks base method.
 func (m *MockQuicSession) destroy(arg0 error) {
 	m.ctrl.T.Helper()
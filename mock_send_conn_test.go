@@ -8,6 +8,7 @@ import (
 	net "net"
 	reflect "reflect"
 
+	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -76,16 +77,45 @@ func (mr *MockSendConnMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockSendConn)(nil).RemoteAddr))
 }
 
+// WithRemoteAddr mocks base method.
+func (m *MockSendConn) WithRemoteAddr(arg0 net.Addr, arg1 *packetInfo) sendConn {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithRemoteAddr", arg0, arg1)
+	ret0, _ := ret[0].(sendConn)
+	return ret0
+}
+
+// WithRemoteAddr indicates an expected call of WithRemoteAddr.
+func (mr *MockSendConnMockRecorder) WithRemoteAddr(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRemoteAddr", reflect.TypeOf((*MockSendConn)(nil).WithRemoteAddr), arg0, arg1)
+}
+
 // Write mocks base method.
-func (m *MockSendConn) Write(arg0 []byte) error {
+func (m *MockSendConn) Write(arg0 []byte, arg1 protocol.ECN) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Write", arg0)
+	ret := m.ctrl.Call(m, "Write", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Write indicates an expected call of Write.
-func (mr *MockSendConnMockRecorder) Write(arg0 interface{}) *gomock.Call {
+func (mr *MockSendConnMockRecorder) Write(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockSendConn)(nil).Write), arg0, arg1)
+}
+
+// WritePackets mocks base method.
+func (m *MockSendConn) WritePackets(arg0 [][]byte, arg1 protocol.ECN) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePackets", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WritePackets indicates an expected call of WritePackets.
+func (mr *MockSendConnMockRecorder) WritePackets(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockSendConn)(nil).Write), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePackets", reflect.TypeOf((*MockSendConn)(nil).WritePackets), arg0, arg1)
 }
@@ -38,6 +38,20 @@ func (m *MockSendStreamI) EXPECT() *MockSendStreamIMockRecorder {
 	return m.recorder
 }
 
+// BufferedBytes mocks base method.
+func (m *MockSendStreamI) BufferedBytes() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BufferedBytes")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// BufferedBytes indicates an expected call of BufferedBytes.
+func (mr *MockSendStreamIMockRecorder) BufferedBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BufferedBytes", reflect.TypeOf((*MockSendStreamI)(nil).BufferedBytes))
+}
+
 // CancelWrite mocks base method.
 func (m *MockSendStreamI) CancelWrite(arg0 StreamErrorCode) {
 	m.ctrl.T.Helper()
@@ -78,6 +92,32 @@ func (mr *MockSendStreamIMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockSendStreamI)(nil).Context))
 }
 
+// Flush mocks base method.
+func (m *MockSendStreamI) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockSendStreamIMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockSendStreamI)(nil).Flush))
+}
+
+// SetPriority mocks base method.
+func (m *MockSendStreamI) SetPriority(weight uint8) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPriority", weight)
+}
+
+// SetPriority indicates an expected call of SetPriority.
+func (mr *MockSendStreamIMockRecorder) SetPriority(weight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockSendStreamI)(nil).SetPriority), weight)
+}
+
 // SetWriteDeadline mocks base method.
 func (m *MockSendStreamI) SetWriteDeadline(t time.Time) error {
 	m.ctrl.T.Helper()
@@ -106,6 +146,35 @@ func (mr *MockSendStreamIMockRecorder) StreamID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamID", reflect.TypeOf((*MockSendStreamI)(nil).StreamID))
 }
 
+// TryWrite mocks base method.
+func (m *MockSendStreamI) TryWrite(p []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryWrite", p)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryWrite indicates an expected call of TryWrite.
+func (mr *MockSendStreamIMockRecorder) TryWrite(p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryWrite", reflect.TypeOf((*MockSendStreamI)(nil).TryWrite), p)
+}
+
+// Writable mocks base method.
+func (m *MockSendStreamI) Writable() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Writable")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Writable indicates an expected call of Writable.
+func (mr *MockSendStreamIMockRecorder) Writable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Writable", reflect.TypeOf((*MockSendStreamI)(nil).Writable))
+}
+
 // Write mocks base method.
 func (m *MockSendStreamI) Write(p []byte) (int, error) {
 	m.ctrl.T.Helper()
@@ -133,6 +202,20 @@ func (mr *MockSendStreamIMockRecorder) closeForShutdown(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeForShutdown", reflect.TypeOf((*MockSendStreamI)(nil).closeForShutdown), arg0)
 }
 
+// getPriority mocks base method.
+func (m *MockSendStreamI) getPriority() uint8 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getPriority")
+	ret0, _ := ret[0].(uint8)
+	return ret0
+}
+
+// getPriority indicates an expected call of getPriority.
+func (mr *MockSendStreamIMockRecorder) getPriority() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getPriority", reflect.TypeOf((*MockSendStreamI)(nil).getPriority))
+}
+
 // handleStopSendingFrame mocks base method.
 func (m *MockSendStreamI) handleStopSendingFrame(arg0 *wire.StopSendingFrame) {
 	m.ctrl.T.Helper()
@@ -159,6 +242,20 @@ func (mr *MockSendStreamIMockRecorder) hasData() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "hasData", reflect.TypeOf((*MockSendStreamI)(nil).hasData))
 }
 
+// hasRetransmission mocks base method.
+func (m *MockSendStreamI) hasRetransmission() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "hasRetransmission")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// hasRetransmission indicates an expected call of hasRetransmission.
+func (mr *MockSendStreamIMockRecorder) hasRetransmission() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "hasRetransmission", reflect.TypeOf((*MockSendStreamI)(nil).hasRetransmission))
+}
+
 // popStreamFrame mocks base method.
 func (m *MockSendStreamI) popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool) {
 	m.ctrl.T.Helper()
@@ -174,6 +271,18 @@ func (mr *MockSendStreamIMockRecorder) popStreamFrame(maxBytes interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "popStreamFrame", reflect.TypeOf((*MockSendStreamI)(nil).popStreamFrame), maxBytes)
 }
 
+// setSessionWriteDeadline mocks base method.
+func (m *MockSendStreamI) setSessionWriteDeadline(arg0 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setSessionWriteDeadline", arg0)
+}
+
+// setSessionWriteDeadline indicates an expected call of setSessionWriteDeadline.
+func (mr *MockSendStreamIMockRecorder) setSessionWriteDeadline(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setSessionWriteDeadline", reflect.TypeOf((*MockSendStreamI)(nil).setSessionWriteDeadline), arg0)
+}
+
 // updateSendWindow mocks base method.
 func (m *MockSendStreamI) updateSendWindow(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -7,6 +7,7 @@ package quic
 import (
 	reflect "reflect"
 
+	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -73,16 +74,42 @@ func (mr *MockSenderMockRecorder) Run() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockSender)(nil).Run))
 }
 
+// SavedSyscalls mocks base method.
+func (m *MockSender) SavedSyscalls() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavedSyscalls")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// SavedSyscalls indicates an expected call of SavedSyscalls.
+func (mr *MockSenderMockRecorder) SavedSyscalls() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavedSyscalls", reflect.TypeOf((*MockSender)(nil).SavedSyscalls))
+}
+
 // Send mocks base method.
-func (m *MockSender) Send(p *packetBuffer) {
+func (m *MockSender) Send(p *packetBuffer, ecn protocol.ECN) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Send", p)
+	m.ctrl.Call(m, "Send", p, ecn)
 }
 
 // Send indicates an expected call of Send.
-func (mr *MockSenderMockRecorder) Send(p interface{}) *gomock.Call {
+func (mr *MockSenderMockRecorder) Send(p, ecn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSender)(nil).Send), p, ecn)
+}
+
+// SetConn mocks base method.
+func (m *MockSender) SetConn(arg0 sendConn) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConn", arg0)
+}
+
+// SetConn indicates an expected call of SetConn.
+func (mr *MockSenderMockRecorder) SetConn(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSender)(nil).Send), p)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConn", reflect.TypeOf((*MockSender)(nil).SetConn), arg0)
 }
 
 // WouldBlock mocks base method.
@@ -38,6 +38,20 @@ func (m *MockStreamI) EXPECT() *MockStreamIMockRecorder {
 	return m.recorder
 }
 
+// BufferedBytes mocks base method.
+func (m *MockStreamI) BufferedBytes() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BufferedBytes")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// BufferedBytes indicates an expected call of BufferedBytes.
+func (mr *MockStreamIMockRecorder) BufferedBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BufferedBytes", reflect.TypeOf((*MockStreamI)(nil).BufferedBytes))
+}
+
 // CancelRead mocks base method.
 func (m *MockStreamI) CancelRead(arg0 StreamErrorCode) {
 	m.ctrl.T.Helper()
@@ -90,6 +104,20 @@ func (mr *MockStreamIMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockStreamI)(nil).Context))
 }
 
+// Flush mocks base method.
+func (m *MockStreamI) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockStreamIMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockStreamI)(nil).Flush))
+}
+
 // Read mocks base method.
 func (m *MockStreamI) Read(p []byte) (int, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +147,18 @@ func (mr *MockStreamIMockRecorder) SetDeadline(t interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeadline", reflect.TypeOf((*MockStreamI)(nil).SetDeadline), t)
 }
 
+// SetPriority mocks base method.
+func (m *MockStreamI) SetPriority(weight uint8) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPriority", weight)
+}
+
+// SetPriority indicates an expected call of SetPriority.
+func (mr *MockStreamIMockRecorder) SetPriority(weight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockStreamI)(nil).SetPriority), weight)
+}
+
 // SetReadDeadline mocks base method.
 func (m *MockStreamI) SetReadDeadline(t time.Time) error {
 	m.ctrl.T.Helper()
@@ -161,6 +201,35 @@ func (mr *MockStreamIMockRecorder) StreamID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamID", reflect.TypeOf((*MockStreamI)(nil).StreamID))
 }
 
+// TryWrite mocks base method.
+func (m *MockStreamI) TryWrite(p []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryWrite", p)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryWrite indicates an expected call of TryWrite.
+func (mr *MockStreamIMockRecorder) TryWrite(p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryWrite", reflect.TypeOf((*MockStreamI)(nil).TryWrite), p)
+}
+
+// Writable mocks base method.
+func (m *MockStreamI) Writable() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Writable")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Writable indicates an expected call of Writable.
+func (mr *MockStreamIMockRecorder) Writable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Writable", reflect.TypeOf((*MockStreamI)(nil).Writable))
+}
+
 // Write mocks base method.
 func (m *MockStreamI) Write(p []byte) (int, error) {
 	m.ctrl.T.Helper()
@@ -188,6 +257,20 @@ func (mr *MockStreamIMockRecorder) closeForShutdown(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeForShutdown", reflect.TypeOf((*MockStreamI)(nil).closeForShutdown), arg0)
 }
 
+// getPriority mocks base method.
+func (m *MockStreamI) getPriority() uint8 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getPriority")
+	ret0, _ := ret[0].(uint8)
+	return ret0
+}
+
+// getPriority indicates an expected call of getPriority.
+func (mr *MockStreamIMockRecorder) getPriority() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getPriority", reflect.TypeOf((*MockStreamI)(nil).getPriority))
+}
+
 // getWindowUpdate mocks base method.
 func (m *MockStreamI) getWindowUpdate() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -256,6 +339,20 @@ func (mr *MockStreamIMockRecorder) hasData() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "hasData", reflect.TypeOf((*MockStreamI)(nil).hasData))
 }
 
+// hasRetransmission mocks base method.
+func (m *MockStreamI) hasRetransmission() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "hasRetransmission")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// hasRetransmission indicates an expected call of hasRetransmission.
+func (mr *MockStreamIMockRecorder) hasRetransmission() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "hasRetransmission", reflect.TypeOf((*MockStreamI)(nil).hasRetransmission))
+}
+
 // popStreamFrame mocks base method.
 func (m *MockStreamI) popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool) {
 	m.ctrl.T.Helper()
@@ -271,6 +368,18 @@ func (mr *MockStreamIMockRecorder) popStreamFrame(maxBytes interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "popStreamFrame", reflect.TypeOf((*MockStreamI)(nil).popStreamFrame), maxBytes)
 }
 
+// setSessionWriteDeadline mocks base method.
+func (m *MockStreamI) setSessionWriteDeadline(arg0 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setSessionWriteDeadline", arg0)
+}
+
+// setSessionWriteDeadline indicates an expected call of setSessionWriteDeadline.
+func (mr *MockStreamIMockRecorder) setSessionWriteDeadline(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setSessionWriteDeadline", reflect.TypeOf((*MockStreamI)(nil).setSessionWriteDeadline), arg0)
+}
+
 // updateSendWindow mocks base method.
 func (m *MockStreamI) updateSendWindow(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
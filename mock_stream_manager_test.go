@@ -7,6 +7,7 @@ package quic
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	protocol "github.com/BGrewell/quic-go/internal/protocol"
@@ -122,6 +123,18 @@ func (mr *MockStreamManagerMockRecorder) GetOrOpenSendStream(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrOpenSendStream", reflect.TypeOf((*MockStreamManager)(nil).GetOrOpenSendStream), arg0)
 }
 
+// GrantStreamCredit mocks base method.
+func (m *MockStreamManager) GrantStreamCredit() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GrantStreamCredit")
+}
+
+// GrantStreamCredit indicates an expected call of GrantStreamCredit.
+func (mr *MockStreamManagerMockRecorder) GrantStreamCredit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantStreamCredit", reflect.TypeOf((*MockStreamManager)(nil).GrantStreamCredit))
+}
+
 // HandleMaxStreamsFrame mocks base method.
 func (m *MockStreamManager) HandleMaxStreamsFrame(arg0 *wire.MaxStreamsFrame) {
 	m.ctrl.T.Helper()
@@ -134,6 +147,35 @@ func (mr *MockStreamManagerMockRecorder) HandleMaxStreamsFrame(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleMaxStreamsFrame", reflect.TypeOf((*MockStreamManager)(nil).HandleMaxStreamsFrame), arg0)
 }
 
+// MaxIncomingStreams mocks base method.
+func (m *MockStreamManager) MaxIncomingStreams() (protocol.StreamNum, protocol.StreamNum) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxIncomingStreams")
+	ret0, _ := ret[0].(protocol.StreamNum)
+	ret1, _ := ret[1].(protocol.StreamNum)
+	return ret0, ret1
+}
+
+// MaxIncomingStreams indicates an expected call of MaxIncomingStreams.
+func (mr *MockStreamManagerMockRecorder) MaxIncomingStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxIncomingStreams", reflect.TypeOf((*MockStreamManager)(nil).MaxIncomingStreams))
+}
+
+// NumOpenStreams mocks base method.
+func (m *MockStreamManager) NumOpenStreams() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumOpenStreams")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// NumOpenStreams indicates an expected call of NumOpenStreams.
+func (mr *MockStreamManagerMockRecorder) NumOpenStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumOpenStreams", reflect.TypeOf((*MockStreamManager)(nil).NumOpenStreams))
+}
+
 // OpenStream mocks base method.
 func (m *MockStreamManager) OpenStream() (Stream, error) {
 	m.ctrl.T.Helper()
@@ -164,6 +206,20 @@ func (mr *MockStreamManagerMockRecorder) OpenStreamSync(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreamSync", reflect.TypeOf((*MockStreamManager)(nil).OpenStreamSync), arg0)
 }
 
+// OpenStreams mocks base method.
+func (m *MockStreamManager) OpenStreams() []protocol.StreamID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenStreams")
+	ret0, _ := ret[0].([]protocol.StreamID)
+	return ret0
+}
+
+// OpenStreams indicates an expected call of OpenStreams.
+func (mr *MockStreamManagerMockRecorder) OpenStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStreams", reflect.TypeOf((*MockStreamManager)(nil).OpenStreams))
+}
+
 // OpenUniStream mocks base method.
 func (m *MockStreamManager) OpenUniStream() (SendStream, error) {
 	m.ctrl.T.Helper()
@@ -206,6 +262,30 @@ func (mr *MockStreamManagerMockRecorder) ResetFor0RTT() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFor0RTT", reflect.TypeOf((*MockStreamManager)(nil).ResetFor0RTT))
 }
 
+// SetWriteDeadline mocks base method.
+func (m *MockStreamManager) SetWriteDeadline(arg0 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWriteDeadline", arg0)
+}
+
+// SetWriteDeadline indicates an expected call of SetWriteDeadline.
+func (mr *MockStreamManagerMockRecorder) SetWriteDeadline(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDeadline", reflect.TypeOf((*MockStreamManager)(nil).SetWriteDeadline), arg0)
+}
+
+// StopAccepting mocks base method.
+func (m *MockStreamManager) StopAccepting() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StopAccepting")
+}
+
+// StopAccepting indicates an expected call of StopAccepting.
+func (mr *MockStreamManagerMockRecorder) StopAccepting() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopAccepting", reflect.TypeOf((*MockStreamManager)(nil).StopAccepting))
+}
+
 // UpdateLimits mocks base method.
 func (m *MockStreamManager) UpdateLimits(arg0 *wire.TransportParameters) {
 	m.ctrl.T.Helper()
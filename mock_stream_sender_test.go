@@ -7,9 +7,9 @@ package quic
 import (
 	reflect "reflect"
 
-	gomock "github.com/golang/mock/gomock"
 	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	wire "github.com/BGrewell/quic-go/internal/wire"
+	gomock "github.com/golang/mock/gomock"
 )
 
 // MockStreamSender is a mock of StreamSender interface.
@@ -35,6 +35,20 @@ func (m *MockStreamSender) EXPECT() *MockStreamSenderMockRecorder {
 	return m.recorder
 }
 
+// flush mocks base method.
+func (m *MockStreamSender) flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// flush indicates an expected call of flush.
+func (mr *MockStreamSenderMockRecorder) flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "flush", reflect.TypeOf((*MockStreamSender)(nil).flush))
+}
+
 // onHasStreamData mocks base method.
 func (m *MockStreamSender) onHasStreamData(arg0 protocol.StreamID) {
 	m.ctrl.T.Helper()
@@ -47,6 +61,18 @@ func (mr *MockStreamSenderMockRecorder) onHasStreamData(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onHasStreamData", reflect.TypeOf((*MockStreamSender)(nil).onHasStreamData), arg0)
 }
 
+// onHasStreamRetransmission mocks base method.
+func (m *MockStreamSender) onHasStreamRetransmission(id protocol.StreamID, priority uint8) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "onHasStreamRetransmission", id, priority)
+}
+
+// onHasStreamRetransmission indicates an expected call of onHasStreamRetransmission.
+func (mr *MockStreamSenderMockRecorder) onHasStreamRetransmission(id, priority interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onHasStreamRetransmission", reflect.TypeOf((*MockStreamSender)(nil).onHasStreamRetransmission), id, priority)
+}
+
 // onStreamCompleted mocks base method.
 func (m *MockStreamSender) onStreamCompleted(arg0 protocol.StreamID) {
 	m.ctrl.T.Helper()
@@ -59,6 +85,42 @@ func (mr *MockStreamSenderMockRecorder) onStreamCompleted(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onStreamCompleted", reflect.TypeOf((*MockStreamSender)(nil).onStreamCompleted), arg0)
 }
 
+// onStreamCreditAvailable mocks base method.
+func (m *MockStreamSender) onStreamCreditAvailable() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "onStreamCreditAvailable")
+}
+
+// onStreamCreditAvailable indicates an expected call of onStreamCreditAvailable.
+func (mr *MockStreamSenderMockRecorder) onStreamCreditAvailable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onStreamCreditAvailable", reflect.TypeOf((*MockStreamSender)(nil).onStreamCreditAvailable))
+}
+
+// onStreamDataAcked mocks base method.
+func (m *MockStreamSender) onStreamDataAcked(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "onStreamDataAcked", arg0)
+}
+
+// onStreamDataAcked indicates an expected call of onStreamDataAcked.
+func (mr *MockStreamSenderMockRecorder) onStreamDataAcked(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onStreamDataAcked", reflect.TypeOf((*MockStreamSender)(nil).onStreamDataAcked), arg0)
+}
+
+// onStreamDataSent mocks base method.
+func (m *MockStreamSender) onStreamDataSent(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "onStreamDataSent", arg0)
+}
+
+// onStreamDataSent indicates an expected call of onStreamDataSent.
+func (mr *MockStreamSenderMockRecorder) onStreamDataSent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onStreamDataSent", reflect.TypeOf((*MockStreamSender)(nil).onStreamDataSent), arg0)
+}
+
 // queueControlFrame mocks base method.
 func (m *MockStreamSender) queueControlFrame(arg0 wire.Frame) {
 	m.ctrl.T.Helper()
@@ -5,8 +5,10 @@
 package quic
 
 import (
+	net "net"
 	reflect "reflect"
 
+	protocol "github.com/BGrewell/quic-go/internal/protocol"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -45,6 +47,20 @@ func (mr *MockUnknownPacketHandlerMockRecorder) handlePacket(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handlePacket", reflect.TypeOf((*MockUnknownPacketHandler)(nil).handlePacket), arg0)
 }
 
+// handleUnknownConnectionID mocks base method.
+func (m *MockUnknownPacketHandler) handleUnknownConnectionID(arg0 protocol.ConnectionID, arg1 net.Addr) UnknownConnectionIDAction {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "handleUnknownConnectionID", arg0, arg1)
+	ret0, _ := ret[0].(UnknownConnectionIDAction)
+	return ret0
+}
+
+// handleUnknownConnectionID indicates an expected call of handleUnknownConnectionID.
+func (mr *MockUnknownPacketHandlerMockRecorder) handleUnknownConnectionID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handleUnknownConnectionID", reflect.TypeOf((*MockUnknownPacketHandler)(nil).handleUnknownConnectionID), arg0, arg1)
+}
+
 // setCloseError mocks base method.
 func (m *MockUnknownPacketHandler) setCloseError(arg0 error) {
 	m.ctrl.T.Helper()
@@ -23,5 +23,6 @@ package quic
 //go:generate sh -c "./mockgen_private.sh quic mock_packet_handler_manager_test.go github.com/BGrewell/quic-go packetHandlerManager"
 //go:generate sh -c "./mockgen_private.sh quic mock_multiplexer_test.go github.com/BGrewell/quic-go multiplexer"
 //go:generate sh -c "./mockgen_private.sh quic mock_batch_conn_test.go github.com/BGrewell/quic-go batchConn"
+//go:generate sh -c "./mockgen_private.sh quic mock_batch_writer_test.go github.com/BGrewell/quic-go batchWriter"
 //go:generate sh -c "mockgen -package quic -self_package github.com/BGrewell/quic-go -destination mock_token_store_test.go github.com/BGrewell/quic-go TokenStore"
 //go:generate sh -c "mockgen -package quic -self_package github.com/BGrewell/quic-go -destination mock_packetconn_test.go net PacketConn"
@@ -4,9 +4,11 @@ import (
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/internal/wire"
+	"github.com/BGrewell/quic-go/logging"
 )
 
 type mtuDiscoverer interface {
@@ -27,20 +29,30 @@ type mtuFinder struct {
 	lastProbeTime time.Time
 	probeInFlight bool
 	mtuIncreased  func(protocol.ByteCount)
+	tracer        logging.ConnectionTracer
 
 	rttStats *utils.RTTStats
+	clock    congestion.Clock
 	current  protocol.ByteCount
 	max      protocol.ByteCount // the maximum value, as advertised by the peer (or our maximum size buffer)
 }
 
 var _ mtuDiscoverer = &mtuFinder{}
 
-func newMTUDiscoverer(rttStats *utils.RTTStats, start, max protocol.ByteCount, mtuIncreased func(protocol.ByteCount)) mtuDiscoverer {
+func newMTUDiscoverer(
+	rttStats *utils.RTTStats,
+	clock congestion.Clock,
+	start, max protocol.ByteCount,
+	mtuIncreased func(protocol.ByteCount),
+	tracer logging.ConnectionTracer,
+) mtuDiscoverer {
 	return &mtuFinder{
 		current:       start,
 		rttStats:      rttStats,
-		lastProbeTime: time.Now(), // to make sure the first probe packet is not sent immediately
+		clock:         clock,
+		lastProbeTime: clock.Now(), // to make sure the first probe packet is not sent immediately
 		mtuIncreased:  mtuIncreased,
+		tracer:        tracer,
 		max:           max,
 	}
 }
@@ -67,18 +79,24 @@ func (f *mtuFinder) NextProbeTime() time.Time {
 
 func (f *mtuFinder) GetPing() (ackhandler.Frame, protocol.ByteCount) {
 	size := (f.max + f.current) / 2
-	f.lastProbeTime = time.Now()
+	f.lastProbeTime = f.clock.Now()
 	f.probeInFlight = true
 	return ackhandler.Frame{
 		Frame: &wire.PingFrame{},
 		OnLost: func(wire.Frame) {
 			f.probeInFlight = false
 			f.max = size
+			if f.tracer != nil && f.done() {
+				f.tracer.UpdatedMTU(f.current, true)
+			}
 		},
 		OnAcked: func(wire.Frame) {
 			f.probeInFlight = false
 			f.current = size
 			f.mtuIncreased(size)
+			if f.tracer != nil {
+				f.tracer.UpdatedMTU(size, f.done())
+			}
 		},
 	}, size
 }
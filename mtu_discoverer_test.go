@@ -4,10 +4,13 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/BGrewell/quic-go/internal/congestion"
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
 	"github.com/BGrewell/quic-go/internal/protocol"
 
 	"github.com/BGrewell/quic-go/internal/utils"
 
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -30,7 +33,7 @@ var _ = Describe("MTU Discoverer", func() {
 		rttStats = &utils.RTTStats{}
 		rttStats.SetInitialRTT(rtt)
 		Expect(rttStats.SmoothedRTT()).To(Equal(rtt))
-		d = newMTUDiscoverer(rttStats, startMTU, maxMTU, func(s protocol.ByteCount) { discoveredMTU = s })
+		d = newMTUDiscoverer(rttStats, congestion.DefaultClock{}, startMTU, maxMTU, func(s protocol.ByteCount) { discoveredMTU = s }, nil)
 		now = time.Now()
 		_ = discoveredMTU
 	})
@@ -82,13 +85,43 @@ var _ = Describe("MTU Discoverer", func() {
 		Expect(d.NextProbeTime()).To(BeZero())
 	})
 
+	It("traces MTU increases", func() {
+		tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+		d = newMTUDiscoverer(rttStats, congestion.DefaultClock{}, startMTU, maxMTU, func(protocol.ByteCount) {}, tracer)
+		ping, _ := d.GetPing()
+		tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1500), false)
+		ping.OnAcked(ping.Frame)
+	})
+
+	It("traces the final MTU when discovery finishes", func() {
+		tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+		d = newMTUDiscoverer(rttStats, congestion.DefaultClock{}, startMTU, maxMTU, func(protocol.ByteCount) {}, tracer)
+		gomock.InOrder(
+			tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1500), false),
+			tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1750), false),
+			tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1875), false),
+			tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1937), false),
+			tracer.EXPECT().UpdatedMTU(protocol.ByteCount(1937), true),
+		)
+		t := time.Now().Add(5 * rtt)
+		for d.ShouldSendProbe(t) {
+			ping, size := d.GetPing()
+			if size <= 1950 {
+				ping.OnAcked(ping.Frame)
+			} else {
+				ping.OnLost(ping.Frame)
+			}
+			t = t.Add(5 * rtt)
+		}
+	})
+
 	It("finds the MTU", func() {
 		const rep = 3000
 		var maxDiff protocol.ByteCount
 		for i := 0; i < rep; i++ {
 			max := protocol.ByteCount(rand.Intn(int(3000-startMTU))) + startMTU + 1
 			currentMTU := startMTU
-			d := newMTUDiscoverer(rttStats, startMTU, max, func(s protocol.ByteCount) { currentMTU = s })
+			d := newMTUDiscoverer(rttStats, congestion.DefaultClock{}, startMTU, max, func(s protocol.ByteCount) { currentMTU = s }, nil)
 			now := time.Now()
 			realMTU := protocol.ByteCount(rand.Intn(int(max-startMTU))) + startMTU
 			t := now.Add(mtuProbeDelay * rtt)
@@ -20,7 +20,7 @@ var _ = Describe("Multiplexer", func() {
 		conn := NewMockPacketConn(mockCtrl)
 		conn.EXPECT().ReadFrom(gomock.Any()).Do(func([]byte) { <-(make(chan struct{})) }).MaxTimes(1)
 		conn.EXPECT().LocalAddr().Return(&net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234})
-		_, err := getMultiplexer().AddConn(conn, 8, nil, nil)
+		_, err := getMultiplexer().AddConn(conn, 8, nil, 0, nil)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -30,10 +30,10 @@ var _ = Describe("Multiplexer", func() {
 		pconn.EXPECT().ReadFrom(gomock.Any()).Do(func([]byte) { <-(make(chan struct{})) }).MaxTimes(1)
 		conn := testConn{PacketConn: pconn}
 		tracer := mocklogging.NewMockTracer(mockCtrl)
-		_, err := getMultiplexer().AddConn(conn, 8, []byte("foobar"), tracer)
+		_, err := getMultiplexer().AddConn(conn, 8, []byte("foobar"), 0, tracer)
 		Expect(err).ToNot(HaveOccurred())
 		conn.counter++
-		_, err = getMultiplexer().AddConn(conn, 8, []byte("foobar"), tracer)
+		_, err = getMultiplexer().AddConn(conn, 8, []byte("foobar"), 0, tracer)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(getMultiplexer().(*connMultiplexer).conns).To(HaveLen(1))
 	})
@@ -42,9 +42,9 @@ var _ = Describe("Multiplexer", func() {
 		conn := NewMockPacketConn(mockCtrl)
 		conn.EXPECT().ReadFrom(gomock.Any()).Do(func([]byte) { <-(make(chan struct{})) }).MaxTimes(1)
 		conn.EXPECT().LocalAddr().Return(&net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}).Times(2)
-		_, err := getMultiplexer().AddConn(conn, 5, nil, nil)
+		_, err := getMultiplexer().AddConn(conn, 5, nil, 0, nil)
 		Expect(err).ToNot(HaveOccurred())
-		_, err = getMultiplexer().AddConn(conn, 6, nil, nil)
+		_, err = getMultiplexer().AddConn(conn, 6, nil, 0, nil)
 		Expect(err).To(MatchError("cannot use 6 byte connection IDs on a connection that is already using 5 byte connction IDs"))
 	})
 
@@ -52,9 +52,9 @@ var _ = Describe("Multiplexer", func() {
 		conn := NewMockPacketConn(mockCtrl)
 		conn.EXPECT().ReadFrom(gomock.Any()).Do(func([]byte) { <-(make(chan struct{})) }).MaxTimes(1)
 		conn.EXPECT().LocalAddr().Return(&net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}).Times(2)
-		_, err := getMultiplexer().AddConn(conn, 7, []byte("foobar"), nil)
+		_, err := getMultiplexer().AddConn(conn, 7, []byte("foobar"), 0, nil)
 		Expect(err).ToNot(HaveOccurred())
-		_, err = getMultiplexer().AddConn(conn, 7, []byte("raboof"), nil)
+		_, err = getMultiplexer().AddConn(conn, 7, []byte("raboof"), 0, nil)
 		Expect(err).To(MatchError("cannot use different stateless reset keys on the same packet conn"))
 	})
 
@@ -62,9 +62,9 @@ var _ = Describe("Multiplexer", func() {
 		conn := NewMockPacketConn(mockCtrl)
 		conn.EXPECT().ReadFrom(gomock.Any()).Do(func([]byte) { <-(make(chan struct{})) }).MaxTimes(1)
 		conn.EXPECT().LocalAddr().Return(&net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}).Times(2)
-		_, err := getMultiplexer().AddConn(conn, 7, nil, mocklogging.NewMockTracer(mockCtrl))
+		_, err := getMultiplexer().AddConn(conn, 7, nil, 0, mocklogging.NewMockTracer(mockCtrl))
 		Expect(err).ToNot(HaveOccurred())
-		_, err = getMultiplexer().AddConn(conn, 7, nil, mocklogging.NewMockTracer(mockCtrl))
+		_, err = getMultiplexer().AddConn(conn, 7, nil, 0, mocklogging.NewMockTracer(mockCtrl))
 		Expect(err).To(MatchError("cannot use different tracers on the same packet conn"))
 	})
 })
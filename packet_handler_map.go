@@ -84,30 +84,38 @@ type packetHandlerMap struct {
 
 var _ packetHandlerManager = &packetHandlerMap{}
 
-func setReceiveBuffer(c net.PacketConn, logger utils.Logger) error {
+// setReceiveBuffer increases the receive buffer of the conn to (at least) desiredSize bytes.
+func setReceiveBuffer(c net.PacketConn, desiredSize int, logger utils.Logger) error {
 	conn, ok := c.(interface{ SetReadBuffer(int) error })
 	if !ok {
 		return errors.New("connection doesn't allow setting of receive buffer size. Not a *net.UDPConn?")
 	}
-	size, err := inspectReadBuffer(c)
+	return increaseReceiveBuffer(conn.SetReadBuffer, func() (int, error) { return inspectReadBuffer(c) }, desiredSize, logger)
+}
+
+// increaseReceiveBuffer increases the receive buffer to (at least) desiredSize bytes.
+// It uses set to change the size of the buffer, and inspect to read the size granted by the kernel.
+func increaseReceiveBuffer(set func(int) error, inspect func() (int, error), desiredSize int, logger utils.Logger) error {
+	size, err := inspect()
 	if err != nil {
 		return fmt.Errorf("failed to determine receive buffer size: %w", err)
 	}
-	if size >= protocol.DesiredReceiveBufferSize {
-		logger.Debugf("Conn has receive buffer of %d kiB (wanted: at least %d kiB)", size/1024, protocol.DesiredReceiveBufferSize/1024)
+	if size >= desiredSize {
+		logger.Debugf("Conn has receive buffer of %d kiB (wanted: at least %d kiB)", size/1024, desiredSize/1024)
+		return nil
 	}
-	if err := conn.SetReadBuffer(protocol.DesiredReceiveBufferSize); err != nil {
+	if err := set(desiredSize); err != nil {
 		return fmt.Errorf("failed to increase receive buffer size: %w", err)
 	}
-	newSize, err := inspectReadBuffer(c)
+	newSize, err := inspect()
 	if err != nil {
 		return fmt.Errorf("failed to determine receive buffer size: %w", err)
 	}
 	if newSize == size {
-		return fmt.Errorf("failed to increase receive buffer size (wanted: %d kiB, got %d kiB)", protocol.DesiredReceiveBufferSize/1024, newSize/1024)
+		return fmt.Errorf("failed to increase receive buffer size (wanted: %d kiB, got %d kiB)", desiredSize/1024, newSize/1024)
 	}
-	if newSize < protocol.DesiredReceiveBufferSize {
-		return fmt.Errorf("failed to sufficiently increase receive buffer size (was: %d kiB, wanted: %d kiB, got: %d kiB)", size/1024, protocol.DesiredReceiveBufferSize/1024, newSize/1024)
+	if newSize < desiredSize {
+		return fmt.Errorf("failed to sufficiently increase receive buffer size (was: %d kiB, wanted: %d kiB, got: %d kiB)", size/1024, desiredSize/1024, newSize/1024)
 	}
 	logger.Debugf("Increased receive buffer size to %d kiB", newSize/1024)
 	return nil
@@ -120,10 +128,14 @@ func newPacketHandlerMap(
 	c net.PacketConn,
 	connIDLen int,
 	statelessResetKey []byte,
+	receiveBufferSize int,
 	tracer logging.Tracer,
 	logger utils.Logger,
 ) (packetHandlerManager, error) {
-	if err := setReceiveBuffer(c, logger); err != nil {
+	if receiveBufferSize == 0 {
+		receiveBufferSize = protocol.DesiredReceiveBufferSize
+	}
+	if err := setReceiveBuffer(c, receiveBufferSize, logger); err != nil {
 		if !strings.Contains(err.Error(), "use of closed network connection") {
 			receiveBufferWarningOnce.Do(func() {
 				if disable, _ := strconv.ParseBool(os.Getenv("QUIC_GO_DISABLE_RECEIVE_BUFFER_WARNING")); disable {
@@ -241,13 +253,18 @@ func (h *packetHandlerMap) Retire(id protocol.ConnectionID) {
 	})
 }
 
-func (h *packetHandlerMap) ReplaceWithClosed(id protocol.ConnectionID, handler packetHandler) {
+// ReplaceWithClosed replaces the session for a connection ID with a closed session.
+// The closed session is removed after the timeout. If the timeout is 0, a default value is used.
+func (h *packetHandlerMap) ReplaceWithClosed(id protocol.ConnectionID, handler packetHandler, timeout time.Duration) {
+	if timeout == 0 {
+		timeout = h.deleteRetiredSessionsAfter
+	}
 	h.mutex.Lock()
 	h.handlers[string(id)] = packetHandlerMapEntry{packetHandler: handler}
 	h.mutex.Unlock()
 	h.logger.Debugf("Replacing session for connection ID %s with a closed session.", id)
 
-	time.AfterFunc(h.deleteRetiredSessionsAfter, func() {
+	time.AfterFunc(timeout, func() {
 		h.mutex.Lock()
 		handler.shutdown()
 		delete(h.handlers, string(id))
@@ -351,9 +368,7 @@ func (h *packetHandlerMap) handlePacket(p *receivedPacket) {
 	connID, err := wire.ParseConnectionID(p.data, h.connIDLen)
 	if err != nil {
 		h.logger.Debugf("error parsing connection ID on packet from %s: %s", p.remoteAddr, err)
-		if h.tracer != nil {
-			h.tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropHeaderParseError)
-		}
+		h.droppedPacket(p, logging.PacketTypeNotDetermined, logging.PacketDropHeaderParseError)
 		p.buffer.MaybeRelease()
 		return
 	}
@@ -377,15 +392,17 @@ func (h *packetHandlerMap) handlePacket(p *receivedPacket) {
 		}
 	}
 	if p.data[0]&0x80 == 0 {
-		go h.maybeSendStatelessReset(p, connID)
+		go h.handleUnknownConnectionID(h.server, p, connID)
 		return
 	}
 	if h.server == nil { // no server set
 		h.logger.Debugf("received a packet with an unexpected connection ID %s", connID)
+		h.droppedPacket(p, logging.PacketTypeNotDetermined, logging.PacketDropUnknownConnectionID)
 		return
 	}
 	if wire.Is0RTTPacket(p.data) {
 		if h.numZeroRTTEntries >= protocol.Max0RTTQueues {
+			h.droppedPacket(p, logging.PacketType0RTT, logging.PacketDropDOSPrevention)
 			return
 		}
 		h.numZeroRTTEntries++
@@ -417,6 +434,12 @@ func (h *packetHandlerMap) handlePacket(p *receivedPacket) {
 	h.server.handlePacket(p)
 }
 
+func (h *packetHandlerMap) droppedPacket(p *receivedPacket, typ logging.PacketType, reason logging.PacketDropReason) {
+	if h.tracer != nil {
+		h.tracer.DroppedPacket(p.remoteAddr, typ, p.Size(), reason)
+	}
+}
+
 func (h *packetHandlerMap) maybeHandleStatelessReset(data []byte) bool {
 	// stateless resets are always short header packets
 	if data[0]&0x80 != 0 {
@@ -453,6 +476,17 @@ func (h *packetHandlerMap) GetStatelessResetToken(connID protocol.ConnectionID)
 	return token
 }
 
+func (h *packetHandlerMap) handleUnknownConnectionID(server unknownPacketHandler, p *receivedPacket, connID protocol.ConnectionID) {
+	// The packet is dropped, even if we respond with a stateless reset.
+	h.droppedPacket(p, logging.PacketType1RTT, logging.PacketDropUnknownConnectionID)
+	if server != nil && server.handleUnknownConnectionID(connID, p.remoteAddr) == UnknownConnectionIDDrop {
+		h.logger.Debugf("Dropping packet with unknown connection ID %s from %s.", connID, p.remoteAddr)
+		p.buffer.Release()
+		return
+	}
+	h.maybeSendStatelessReset(p, connID)
+}
+
 func (h *packetHandlerMap) maybeSendStatelessReset(p *receivedPacket, connID protocol.ConnectionID) {
 	defer p.buffer.Release()
 	if !h.statelessResetEnabled {
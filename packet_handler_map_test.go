@@ -72,7 +72,7 @@ var _ = Describe("Packet Handler Map", func() {
 			}
 			return copy(b, p.data), p.addr, p.err
 		}).AnyTimes()
-		phm, err := newPacketHandlerMap(conn, connIDLen, statelessResetKey, tracer, utils.DefaultLogger)
+		phm, err := newPacketHandlerMap(conn, connIDLen, statelessResetKey, 0, tracer, utils.DefaultLogger)
 		Expect(err).ToNot(HaveOccurred())
 		handler = phm.(*packetHandlerMap)
 	})
@@ -165,6 +165,7 @@ var _ = Describe("Packet Handler Map", func() {
 				connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
 				handler.Add(connID, NewMockPacketHandler(mockCtrl))
 				handler.Remove(connID)
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketTypeNotDetermined, gomock.Any(), logging.PacketDropUnknownConnectionID)
 				handler.handlePacket(&receivedPacket{data: getPacket(connID)})
 				// don't EXPECT any calls to handlePacket of the MockPacketHandler
 			})
@@ -176,6 +177,7 @@ var _ = Describe("Packet Handler Map", func() {
 				handler.Add(connID, sess)
 				handler.Retire(connID)
 				time.Sleep(scaleDuration(30 * time.Millisecond))
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketTypeNotDetermined, gomock.Any(), logging.PacketDropUnknownConnectionID)
 				handler.handlePacket(&receivedPacket{data: getPacket(connID)})
 				// don't EXPECT any calls to handlePacket of the MockPacketHandler
 			})
@@ -194,9 +196,46 @@ var _ = Describe("Packet Handler Map", func() {
 				Eventually(handled).Should(BeClosed())
 			})
 
-			It("drops packets for unknown receivers", func() {
+			It("removes closed sessions after the default timeout", func() {
+				handler.deleteRetiredSessionsAfter = scaleDuration(10 * time.Millisecond)
+				connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+				closed := make(chan struct{})
+				sess := NewMockPacketHandler(mockCtrl)
+				sess.EXPECT().shutdown().Do(func() { close(closed) })
+				handler.ReplaceWithClosed(connID, sess, 0)
+				Eventually(closed).Should(BeClosed())
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketTypeNotDetermined, gomock.Any(), logging.PacketDropUnknownConnectionID)
+				handler.handlePacket(&receivedPacket{data: getPacket(connID)})
+				// don't EXPECT any calls to handlePacket of the MockPacketHandler
+			})
+
+			It("removes closed sessions after the draining timeout", func() {
+				handler.deleteRetiredSessionsAfter = time.Hour
 				connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+				closed := make(chan struct{})
+				sess := NewMockPacketHandler(mockCtrl)
+				sess.EXPECT().handlePacket(gomock.Any())
+				sess.EXPECT().shutdown().Do(func() { close(closed) })
+				start := time.Now()
+				handler.ReplaceWithClosed(connID, sess, scaleDuration(50*time.Millisecond))
+				// packets are passed to the closed session until the draining timeout expires
 				handler.handlePacket(&receivedPacket{data: getPacket(connID)})
+				Eventually(closed).Should(BeClosed())
+				Expect(time.Since(start)).To(BeNumerically(">=", scaleDuration(50*time.Millisecond)))
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketTypeNotDetermined, gomock.Any(), logging.PacketDropUnknownConnectionID)
+				handler.handlePacket(&receivedPacket{data: getPacket(connID)})
+			})
+
+			It("drops packets for unknown receivers", func() {
+				addr := &net.UDPAddr{IP: net.IPv4(9, 8, 7, 6), Port: 1234}
+				connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+				p := getPacket(connID)
+				tracer.EXPECT().DroppedPacket(addr, logging.PacketTypeNotDetermined, protocol.ByteCount(len(p)), logging.PacketDropUnknownConnectionID)
+				handler.handlePacket(&receivedPacket{
+					buffer:     getPacketBuffer(),
+					remoteAddr: addr,
+					data:       p,
+				})
 			})
 
 			It("closes the packet handlers when reading from the conn fails", func() {
@@ -270,6 +309,7 @@ var _ = Describe("Packet Handler Map", func() {
 				// don't EXPECT any calls to server.handlePacket
 				handler.SetServer(server)
 				handler.CloseServer()
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketTypeNotDetermined, gomock.Any(), logging.PacketDropUnknownConnectionID)
 				handler.handlePacket(&receivedPacket{data: p})
 			})
 		})
@@ -322,6 +362,7 @@ var _ = Describe("Packet Handler Map", func() {
 				}
 				// We're already storing the maximum number of queues. This packet will be dropped.
 				connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9}
+				tracer.EXPECT().DroppedPacket(nil, logging.PacketType0RTT, gomock.Any(), logging.PacketDropDOSPrevention)
 				handler.handlePacket(&receivedPacket{data: getPacketWithPacketType(connID, protocol.PacketType0RTT, 1)})
 				// Don't EXPECT any handlePacket() calls.
 				sess := NewMockPacketHandler(mockCtrl)
@@ -429,6 +470,7 @@ var _ = Describe("Packet Handler Map", func() {
 					packetHandler.EXPECT().destroy(gomock.Any()).Do(func(error) {
 						close(done)
 					}).AnyTimes()
+					tracer.EXPECT().DroppedPacket(gomock.Any(), logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
 					packetChan <- packetToRead{data: append([]byte{0x40} /* short header packet */, token[:15]...)}
 					Consistently(done).ShouldNot(BeClosed())
 				})
@@ -456,6 +498,7 @@ var _ = Describe("Packet Handler Map", func() {
 						Expect(b[0] & 0x80).To(BeZero()) // short header packet
 						Expect(b).To(HaveLen(protocol.MinStatelessResetSize))
 					})
+					tracer.EXPECT().DroppedPacket(addr, logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
 					handler.handlePacket(&receivedPacket{
 						buffer:     getPacketBuffer(),
 						remoteAddr: addr,
@@ -467,11 +510,57 @@ var _ = Describe("Packet Handler Map", func() {
 				It("doesn't send stateless resets for small packets", func() {
 					addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
 					p := append([]byte{40}, make([]byte, protocol.MinStatelessResetSize-2)...)
+					tracer.EXPECT().DroppedPacket(addr, logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
+					handler.handlePacket(&receivedPacket{
+						buffer:     getPacketBuffer(),
+						remoteAddr: addr,
+						data:       p,
+					})
+					// make sure there are no Write calls on the packet conn
+					time.Sleep(50 * time.Millisecond)
+				})
+
+				It("asks the server if a stateless reset should be sent", func() {
+					addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+					connID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0x42}
+					p := append([]byte{0x40}, connID...)
+					p = append(p, make([]byte, 100)...)
+					server := NewMockUnknownPacketHandler(mockCtrl)
+					server.EXPECT().handleUnknownConnectionID(connID, addr).Return(UnknownConnectionIDSendStatelessReset)
+					handler.SetServer(server)
+					done := make(chan struct{})
+					conn.EXPECT().WriteTo(gomock.Any(), addr).Do(func(b []byte, _ net.Addr) {
+						defer close(done)
+						Expect(b).To(HaveLen(protocol.MinStatelessResetSize))
+					})
+					tracer.EXPECT().DroppedPacket(addr, logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
+					handler.handlePacket(&receivedPacket{
+						buffer:     getPacketBuffer(),
+						remoteAddr: addr,
+						data:       p,
+					})
+					Eventually(done).Should(BeClosed())
+				})
+
+				It("drops packets if the server says so", func() {
+					addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+					connID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0x42}
+					p := append([]byte{0x40}, connID...)
+					p = append(p, make([]byte, 100)...)
+					server := NewMockUnknownPacketHandler(mockCtrl)
+					called := make(chan struct{})
+					server.EXPECT().handleUnknownConnectionID(connID, addr).DoAndReturn(func(protocol.ConnectionID, net.Addr) UnknownConnectionIDAction {
+						close(called)
+						return UnknownConnectionIDDrop
+					})
+					handler.SetServer(server)
+					tracer.EXPECT().DroppedPacket(addr, logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
 					handler.handlePacket(&receivedPacket{
 						buffer:     getPacketBuffer(),
 						remoteAddr: addr,
 						data:       p,
 					})
+					Eventually(called).Should(BeClosed())
 					// make sure there are no Write calls on the packet conn
 					time.Sleep(50 * time.Millisecond)
 				})
@@ -481,6 +570,7 @@ var _ = Describe("Packet Handler Map", func() {
 				It("doesn't send stateless resets", func() {
 					addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
 					p := append([]byte{40}, make([]byte, 100)...)
+					tracer.EXPECT().DroppedPacket(addr, logging.PacketType1RTT, gomock.Any(), logging.PacketDropUnknownConnectionID)
 					handler.handlePacket(&receivedPacket{
 						buffer:     getPacketBuffer(),
 						remoteAddr: addr,
@@ -493,3 +583,80 @@ var _ = Describe("Packet Handler Map", func() {
 		})
 	})
 })
+
+// fakeReceiveBuffer emulates the kernel's handling of SO_RCVBUF on Linux:
+// the requested size is capped at net.core.rmem_max, and then doubled.
+type fakeReceiveBuffer struct {
+	size    int
+	rmemMax int
+}
+
+func (b *fakeReceiveBuffer) set(size int) error {
+	if size > b.rmemMax {
+		size = b.rmemMax
+	}
+	b.size = 2 * size
+	return nil
+}
+
+func (b *fakeReceiveBuffer) inspect() (int, error) { return b.size, nil }
+
+// readBufferRecordingConn records the receive buffer size it was asked for
+type readBufferRecordingConn struct {
+	*net.UDPConn
+	requested []int
+}
+
+func (c *readBufferRecordingConn) SetReadBuffer(size int) error {
+	c.requested = append(c.requested, size)
+	return nil
+}
+
+var _ = Describe("Setting the receive buffer size", func() {
+	It("enlarges the receive buffer", func() {
+		buf := &fakeReceiveBuffer{size: 200 << 10, rmemMax: 4 << 20}
+		Expect(increaseReceiveBuffer(buf.set, buf.inspect, 2<<20, utils.DefaultLogger)).To(Succeed())
+		Expect(buf.size).To(Equal(4 << 20))
+	})
+
+	It("doesn't shrink the receive buffer", func() {
+		buf := &fakeReceiveBuffer{size: 4 << 20, rmemMax: 8 << 20}
+		Expect(increaseReceiveBuffer(buf.set, buf.inspect, 1<<20, utils.DefaultLogger)).To(Succeed())
+		Expect(buf.size).To(Equal(4 << 20))
+	})
+
+	It("returns an error if the kernel clamps the requested size", func() {
+		buf := &fakeReceiveBuffer{size: 200 << 10, rmemMax: 512 << 10}
+		err := increaseReceiveBuffer(buf.set, buf.inspect, 2<<20, utils.DefaultLogger)
+		Expect(err).To(MatchError("failed to sufficiently increase receive buffer size (was: 200 kiB, wanted: 2048 kiB, got: 1024 kiB)"))
+	})
+
+	It("returns an error if the kernel doesn't increase the size at all", func() {
+		buf := &fakeReceiveBuffer{size: 400 << 10, rmemMax: 200 << 10}
+		err := increaseReceiveBuffer(buf.set, buf.inspect, 2<<20, utils.DefaultLogger)
+		Expect(err).To(MatchError("failed to increase receive buffer size (wanted: 2048 kiB, got 400 kiB)"))
+	})
+
+	It("returns an error if setting the size fails", func() {
+		err := increaseReceiveBuffer(
+			func(int) error { return errors.New("test err") },
+			func() (int, error) { return 1 << 10, nil },
+			2<<20,
+			utils.DefaultLogger,
+		)
+		Expect(err).To(MatchError("failed to increase receive buffer size: test err"))
+	})
+
+	It("requests the configured size for the conn", func() {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		size, err := inspectReadBuffer(udpConn)
+		if err != nil || size == 0 {
+			Skip("reading the receive buffer size is not supported on this platform")
+		}
+		conn := &readBufferRecordingConn{UDPConn: udpConn}
+		Expect(setReceiveBuffer(conn, 2*size, utils.DefaultLogger)).ToNot(Succeed())
+		Expect(conn.requested).To(Equal([]int{2 * size}))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
@@ -16,7 +17,7 @@ import (
 )
 
 type packer interface {
-	PackCoalescedPacket() (*coalescedPacket, error)
+	PackCoalescedPacket(onlyAck bool) (*coalescedPacket, error)
 	PackPacket() (*packedPacket, error)
 	MaybePackProbePacket(protocol.EncryptionLevel) (*packedPacket, error)
 	MaybePackAckPacket(handshakeConfirmed bool) (*packedPacket, error)
@@ -25,9 +26,14 @@ type packer interface {
 
 	SetMaxPacketSize(protocol.ByteCount)
 	PackMTUProbePacket(ping ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error)
+	PackPathProbePacket(connID protocol.ConnectionID, frame ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error)
 
 	HandleTransportParameters(*wire.TransportParameters)
 	SetToken([]byte)
+
+	// PaddingBytes returns the number of bytes of PADDING frames in all packets packed so far.
+	// It is safe to call concurrently.
+	PaddingBytes() protocol.ByteCount
 }
 
 type sealer interface {
@@ -52,7 +58,8 @@ type packetContents struct {
 
 	length protocol.ByteCount
 
-	isMTUProbePacket bool
+	isMTUProbePacket  bool
+	isPathProbePacket bool
 }
 
 type coalescedPacket struct {
@@ -108,6 +115,7 @@ func (p *packetContents) ToAckHandlerPacket(now time.Time, q *retransmissionQueu
 		EncryptionLevel:      encLevel,
 		SendTime:             now,
 		IsPathMTUProbePacket: p.isMTUProbePacket,
+		IsPathProbePacket:    p.isPathProbePacket,
 	}
 }
 
@@ -167,7 +175,10 @@ type packetPacker struct {
 	retransmissionQueue *retransmissionQueue
 
 	maxPacketSize          protocol.ByteCount
+	maxCoalescedPackets    int // 0 means that the number of coalesced packets is only limited by the packet size
 	numNonAckElicitingAcks int
+
+	paddingBytes uint64 // accessed atomically
 }
 
 var _ packer = &packetPacker{}
@@ -184,6 +195,7 @@ func newPacketPacker(
 	framer frameSource,
 	acks ackFrameSource,
 	datagramQueue *datagramQueue,
+	maxCoalescedPackets int,
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
 ) *packetPacker {
@@ -201,6 +213,7 @@ func newPacketPacker(
 		acks:                acks,
 		pnManager:           packetNumberManager,
 		maxPacketSize:       getMaxPacketSize(remoteAddr),
+		maxCoalescedPackets: maxCoalescedPackets,
 	}
 }
 
@@ -229,12 +242,14 @@ func (p *packetPacker) packConnectionClose(
 	var hdrs [4]*wire.ExtendedHeader
 	var payloads [4]*payload
 	var size protocol.ByteCount
-	var numPackets uint8
+	var numPackets int
 	encLevels := [4]protocol.EncryptionLevel{protocol.EncryptionInitial, protocol.EncryptionHandshake, protocol.Encryption0RTT, protocol.Encryption1RTT}
 	for i, encLevel := range encLevels {
 		if p.perspective == protocol.PerspectiveServer && encLevel == protocol.Encryption0RTT {
 			continue
 		}
+		// CONNECTION_CLOSE packets are not subject to the coalescing limit:
+		// the peer might not be able to decrypt all of them, so we send one at every available encryption level.
 		ccf := &wire.ConnectionCloseFrame{
 			IsApplicationError: isApplicationError,
 			ErrorCode:          errorCode,
@@ -366,8 +381,10 @@ func (p *packetPacker) initialPaddingLen(frames []ackhandler.Frame, size protoco
 
 // PackCoalescedPacket packs a new packet.
 // It packs an Initial / Handshake if there is data to send in these packet number spaces.
+// If onlyAck is set, the packets only contain ACK frames. ACKs for different packet number spaces
+// are coalesced into a single datagram.
 // It should only be called before the handshake is confirmed.
-func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
+func (p *packetPacker) PackCoalescedPacket(onlyAck bool) (*coalescedPacket, error) {
 	maxPacketSize := p.maxPacketSize
 	if p.perspective == protocol.PerspectiveClient {
 		maxPacketSize = protocol.MinInitialPacketSize
@@ -382,7 +399,7 @@ func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
 	}
 	var size protocol.ByteCount
 	if initialSealer != nil {
-		initialHdr, initialPayload = p.maybeGetCryptoPacket(maxPacketSize-protocol.ByteCount(initialSealer.Overhead()), size, protocol.EncryptionInitial)
+		initialHdr, initialPayload = p.maybeGetCryptoPacket(maxPacketSize-protocol.ByteCount(initialSealer.Overhead()), protocol.EncryptionInitial, onlyAck)
 		if initialPayload != nil {
 			size += p.packetLength(initialHdr, initialPayload) + protocol.ByteCount(initialSealer.Overhead())
 			numPackets++
@@ -391,14 +408,14 @@ func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
 
 	// Add a Handshake packet.
 	var handshakeSealer sealer
-	if size < maxPacketSize-protocol.MinCoalescedPacketSize {
+	if size < maxPacketSize-protocol.MinCoalescedPacketSize && p.canCoalesce(numPackets) {
 		var err error
 		handshakeSealer, err = p.cryptoSetup.GetHandshakeSealer()
 		if err != nil && err != handshake.ErrKeysDropped && err != handshake.ErrKeysNotYetAvailable {
 			return nil, err
 		}
 		if handshakeSealer != nil {
-			handshakeHdr, handshakePayload = p.maybeGetCryptoPacket(maxPacketSize-size-protocol.ByteCount(handshakeSealer.Overhead()), size, protocol.EncryptionHandshake)
+			handshakeHdr, handshakePayload = p.maybeGetCryptoPacket(maxPacketSize-size-protocol.ByteCount(handshakeSealer.Overhead()), protocol.EncryptionHandshake, onlyAck)
 			if handshakePayload != nil {
 				s := p.packetLength(handshakeHdr, handshakePayload) + protocol.ByteCount(handshakeSealer.Overhead())
 				size += s
@@ -410,9 +427,9 @@ func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
 	// Add a 0-RTT / 1-RTT packet.
 	var appDataSealer sealer
 	appDataEncLevel := protocol.Encryption1RTT
-	if size < maxPacketSize-protocol.MinCoalescedPacketSize {
+	if size < maxPacketSize-protocol.MinCoalescedPacketSize && p.canCoalesce(numPackets) {
 		var err error
-		appDataSealer, appDataHdr, appDataPayload = p.maybeGetAppDataPacket(maxPacketSize-size, size)
+		appDataSealer, appDataHdr, appDataPayload = p.maybeGetAppDataPacket(maxPacketSize-size, size, onlyAck)
 		if err != nil {
 			return nil, err
 		}
@@ -461,10 +478,15 @@ func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
 	return packet, nil
 }
 
+// canCoalesce says if another packet can be coalesced into a datagram that already contains numPackets packets.
+func (p *packetPacker) canCoalesce(numPackets int) bool {
+	return p.maxCoalescedPackets == 0 || numPackets < p.maxCoalescedPackets
+}
+
 // PackPacket packs a packet in the application data packet number space.
 // It should be called after the handshake is confirmed.
 func (p *packetPacker) PackPacket() (*packedPacket, error) {
-	sealer, hdr, payload := p.maybeGetAppDataPacket(p.maxPacketSize, 0)
+	sealer, hdr, payload := p.maybeGetAppDataPacket(p.maxPacketSize, 0, false)
 	if payload == nil {
 		return nil, nil
 	}
@@ -483,7 +505,15 @@ func (p *packetPacker) PackPacket() (*packedPacket, error) {
 	}, nil
 }
 
-func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize, currentSize protocol.ByteCount, encLevel protocol.EncryptionLevel) (*wire.ExtendedHeader, *payload) {
+func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize protocol.ByteCount, encLevel protocol.EncryptionLevel, onlyAck bool) (*wire.ExtendedHeader, *payload) {
+	if onlyAck {
+		ack := p.acks.GetAckFrame(encLevel, true)
+		if ack == nil {
+			return nil, nil
+		}
+		return p.getLongHeader(encLevel), &payload{ack: ack, length: ack.Length(p.version)}
+	}
+
 	var s cryptoStream
 	var hasRetransmission bool
 	//nolint:exhaustive // Initial and Handshake are the only two encryption levels here.
@@ -497,10 +527,7 @@ func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize, currentSize protocol.
 	}
 
 	hasData := s.HasData()
-	var ack *wire.AckFrame
-	if encLevel == protocol.EncryptionInitial || currentSize == 0 {
-		ack = p.acks.GetAckFrame(encLevel, !hasRetransmission && !hasData)
-	}
+	ack := p.acks.GetAckFrame(encLevel, !hasRetransmission && !hasData)
 	if !hasData && !hasRetransmission && ack == nil {
 		// nothing to send
 		return nil, nil
@@ -540,7 +567,7 @@ func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize, currentSize protocol.
 	return hdr, &payload
 }
 
-func (p *packetPacker) maybeGetAppDataPacket(maxPacketSize, currentSize protocol.ByteCount) (sealer, *wire.ExtendedHeader, *payload) {
+func (p *packetPacker) maybeGetAppDataPacket(maxPacketSize, currentSize protocol.ByteCount, onlyAck bool) (sealer, *wire.ExtendedHeader, *payload) {
 	var sealer sealer
 	var encLevel protocol.EncryptionLevel
 	var hdr *wire.ExtendedHeader
@@ -562,6 +589,18 @@ func (p *packetPacker) maybeGetAppDataPacket(maxPacketSize, currentSize protocol
 		hdr = p.getLongHeader(protocol.Encryption0RTT)
 	}
 
+	if onlyAck {
+		// 0-RTT packets can't contain ACK frames.
+		if encLevel != protocol.Encryption1RTT || currentSize > 0 {
+			return nil, nil, nil
+		}
+		ack := p.acks.GetAckFrame(protocol.Encryption1RTT, true)
+		if ack == nil {
+			return nil, nil, nil
+		}
+		return sealer, hdr, &payload{ack: ack, length: ack.Length(p.version)}
+	}
+
 	maxPayloadSize := maxPacketSize - hdr.GetLength(p.version) - protocol.ByteCount(sealer.Overhead())
 	payload := p.maybeGetAppDataPacketWithEncLevel(maxPayloadSize, encLevel == protocol.Encryption1RTT && currentSize == 0)
 	return sealer, hdr, payload
@@ -661,14 +700,14 @@ func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel) (
 		if err != nil {
 			return nil, err
 		}
-		hdr, payload = p.maybeGetCryptoPacket(p.maxPacketSize-protocol.ByteCount(sealer.Overhead()), 0, protocol.EncryptionInitial)
+		hdr, payload = p.maybeGetCryptoPacket(p.maxPacketSize-protocol.ByteCount(sealer.Overhead()), protocol.EncryptionInitial, false)
 	case protocol.EncryptionHandshake:
 		var err error
 		sealer, err = p.cryptoSetup.GetHandshakeSealer()
 		if err != nil {
 			return nil, err
 		}
-		hdr, payload = p.maybeGetCryptoPacket(p.maxPacketSize-protocol.ByteCount(sealer.Overhead()), 0, protocol.EncryptionHandshake)
+		hdr, payload = p.maybeGetCryptoPacket(p.maxPacketSize-protocol.ByteCount(sealer.Overhead()), protocol.EncryptionHandshake, false)
 	case protocol.Encryption1RTT:
 		oneRTTSealer, err := p.cryptoSetup.Get1RTTSealer()
 		if err != nil {
@@ -700,22 +739,48 @@ func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel) (
 }
 
 func (p *packetPacker) PackMTUProbePacket(ping ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error) {
-	payload := &payload{
-		frames: []ackhandler.Frame{ping},
-		length: ping.Length(p.version),
+	sealer, err := p.cryptoSetup.Get1RTTSealer()
+	if err != nil {
+		return nil, err
 	}
-	buffer := getPacketBuffer()
+	packet, err := p.packPaddedPacket(p.getShortHeader(sealer.KeyPhase()), sealer, ping, size, true)
+	if err != nil {
+		return nil, err
+	}
+	packet.isMTUProbePacket = true
+	return packet, nil
+}
+
+// PackPathProbePacket packs a 1-RTT packet that is used to probe a new path, see section 8.2 of RFC 9000.
+// The packet only contains the PATH_CHALLENGE or PATH_RESPONSE frame, and is padded to size.
+// It is sent with connID, since the connection ID used on the active path must not be used on a new path.
+func (p *packetPacker) PackPathProbePacket(connID protocol.ConnectionID, frame ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error) {
 	sealer, err := p.cryptoSetup.Get1RTTSealer()
 	if err != nil {
 		return nil, err
 	}
-	hdr := p.getShortHeader(sealer.KeyPhase())
+	packet, err := p.packPaddedPacket(p.newShortHeader(connID, sealer.KeyPhase()), sealer, frame, size, false)
+	if err != nil {
+		return nil, err
+	}
+	packet.isPathProbePacket = true
+	return packet, nil
+}
+
+func (p *packetPacker) packPaddedPacket(hdr *wire.ExtendedHeader, sealer sealer, frame ackhandler.Frame, size protocol.ByteCount, isMTUProbePacket bool) (*packedPacket, error) {
+	payload := &payload{
+		frames: []ackhandler.Frame{frame},
+		length: frame.Length(p.version),
+	}
+	buffer := getPacketBuffer()
 	padding := size - p.packetLength(hdr, payload) - protocol.ByteCount(sealer.Overhead())
-	contents, err := p.appendPacket(buffer, hdr, payload, padding, protocol.Encryption1RTT, sealer, true)
+	if padding < 0 {
+		padding = 0
+	}
+	contents, err := p.appendPacket(buffer, hdr, payload, padding, protocol.Encryption1RTT, sealer, isMTUProbePacket)
 	if err != nil {
 		return nil, err
 	}
-	contents.isMTUProbePacket = true
 	return &packedPacket{
 		buffer:         buffer,
 		packetContents: contents,
@@ -758,11 +823,15 @@ func (p *packetPacker) getSealerAndHeader(encLevel protocol.EncryptionLevel) (se
 }
 
 func (p *packetPacker) getShortHeader(kp protocol.KeyPhaseBit) *wire.ExtendedHeader {
+	return p.newShortHeader(p.getDestConnID(), kp)
+}
+
+func (p *packetPacker) newShortHeader(connID protocol.ConnectionID, kp protocol.KeyPhaseBit) *wire.ExtendedHeader {
 	pn, pnLen := p.pnManager.PeekPacketNumber(protocol.Encryption1RTT)
 	hdr := &wire.ExtendedHeader{}
 	hdr.PacketNumber = pn
 	hdr.PacketNumberLen = pnLen
-	hdr.DestConnectionID = p.getDestConnID()
+	hdr.DestConnectionID = connID
 	hdr.KeyPhase = kp
 	return hdr
 }
@@ -838,6 +907,7 @@ func (p *packetPacker) appendPacket(buffer *packetBuffer, header *wire.ExtendedH
 	}
 	if paddingLen > 0 {
 		buf.Write(make([]byte, paddingLen))
+		atomic.AddUint64(&p.paddingBytes, uint64(paddingLen))
 	}
 	for _, frame := range payload.frames {
 		if err := frame.Write(buf, p.version); err != nil {
@@ -892,3 +962,7 @@ func (p *packetPacker) HandleTransportParameters(params *wire.TransportParameter
 		p.maxPacketSize = utils.MinByteCount(p.maxPacketSize, params.MaxUDPPayloadSize)
 	}
 }
+
+func (p *packetPacker) PaddingBytes() protocol.ByteCount {
+	return protocol.ByteCount(atomic.LoadUint64(&p.paddingBytes))
+}
@@ -105,6 +105,7 @@ var _ = Describe("Packet packer", func() {
 			framer,
 			ackFramer,
 			datagramQueue,
+			0,
 			protocol.PerspectiveServer,
 			version,
 		)
@@ -191,7 +192,7 @@ var _ = Describe("Packet packer", func() {
 			expectAppendControlFrames()
 			f := &wire.StreamFrame{Data: []byte{0xde, 0xca, 0xfb, 0xad}}
 			expectAppendStreamFrames(ackhandler.Frame{Frame: f})
-			p, err := packer.PackCoalescedPacket()
+			p, err := packer.PackCoalescedPacket(false)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(p).ToNot(BeNil())
 			Expect(p.packets).To(HaveLen(1))
@@ -300,7 +301,7 @@ var _ = Describe("Packet packer", func() {
 				framer.EXPECT().AppendStreamFrames(gomock.Any(), gomock.Any()).DoAndReturn(func(frames []ackhandler.Frame, _ protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount) {
 					return frames, 0
 				})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(p).ToNot(BeNil())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
@@ -396,6 +397,25 @@ var _ = Describe("Packet packer", func() {
 				Expect(ccf.ReasonPhrase).To(Equal("test error"))
 			})
 
+			It("coalesces CONNECTION_CLOSE packets, even if the number of coalesced packets is limited", func() {
+				packer.maxCoalescedPackets = 1
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(1), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(1))
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(2), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(2))
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(3), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(3))
+				sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				p, err := packer.PackConnectionClose(&qerr.TransportError{ErrorCode: qerr.ProtocolViolation})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.packets).To(HaveLen(3))
+				Expect(p.packets[0].header.Type).To(Equal(protocol.PacketTypeInitial))
+				Expect(p.packets[1].header.Type).To(Equal(protocol.PacketTypeHandshake))
+				Expect(p.packets[2].header.IsLongHeader).To(BeFalse())
+			})
+
 			It("packs a CONNECTION_CLOSE in all available encryption levels, as a client", func() {
 				packer.perspective = protocol.PerspectiveClient
 				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(1), protocol.PacketNumberLen2)
@@ -610,7 +630,7 @@ var _ = Describe("Packet packer", func() {
 				packer.retransmissionQueue.AddHandshake(&wire.PingFrame{})
 				handshakeStream.EXPECT().HasData()
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, false)
-				packet, err := packer.PackCoalescedPacket()
+				packet, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packet).ToNot(BeNil())
 				Expect(packet.packets).To(HaveLen(1))
@@ -910,7 +930,7 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().GetInitialSealer().Return(nil, handshake.ErrKeysDropped)
 				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p).ToNot(BeNil())
 				parsePacket(p.buffer.Data)
@@ -929,7 +949,7 @@ var _ = Describe("Packet packer", func() {
 				initialStream.EXPECT().PopCryptoFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) *wire.CryptoFrame {
 					return &wire.CryptoFrame{Offset: 0x42, Data: []byte("initial")}
 				})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeNumerically(">=", protocol.MinInitialPacketSize))
 				Expect(p.buffer.Len()).To(BeEquivalentTo(maxPacketSize))
@@ -958,7 +978,7 @@ var _ = Describe("Packet packer", func() {
 					Expect(f.Length(packer.version)).To(Equal(size))
 					return f
 				})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].frames).To(HaveLen(1))
@@ -976,7 +996,7 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, false)
-				// don't EXPECT any calls for a Handshake ACK frame
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, false)
 				initialStream.EXPECT().HasData().Return(true).Times(2)
 				initialStream.EXPECT().PopCryptoFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) *wire.CryptoFrame {
 					return &wire.CryptoFrame{Offset: 0x42, Data: []byte("initial")}
@@ -985,7 +1005,7 @@ var _ = Describe("Packet packer", func() {
 				handshakeStream.EXPECT().PopCryptoFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) *wire.CryptoFrame {
 					return &wire.CryptoFrame{Offset: 0x1337, Data: []byte("handshake")}
 				})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeEquivalentTo(packer.maxPacketSize))
 				Expect(p.packets).To(HaveLen(2))
@@ -1010,14 +1030,14 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, false)
-				// don't EXPECT any calls for a Handshake ACK frame
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, false)
 				initialStream.EXPECT().HasData().Return(true).Times(2)
 				initialStream.EXPECT().PopCryptoFrame(gomock.Any()).DoAndReturn(func(size protocol.ByteCount) *wire.CryptoFrame {
 					return &wire.CryptoFrame{Offset: 0x42, Data: []byte("initial")}
 				})
 				handshakeStream.EXPECT().HasData()
 				packer.retransmissionQueue.AddHandshake(&wire.PingFrame{})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeEquivalentTo(packer.maxPacketSize))
 				Expect(p.packets).To(HaveLen(2))
@@ -1042,11 +1062,12 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, gomock.Any())
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, gomock.Any())
 				initialStream.EXPECT().HasData()
 				handshakeStream.EXPECT().HasData()
 				packer.retransmissionQueue.AddInitial(&wire.PingFrame{})
 				packer.retransmissionQueue.AddHandshake(&wire.PingFrame{})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeEquivalentTo(packer.maxPacketSize))
 				Expect(p.packets).To(HaveLen(2))
@@ -1079,7 +1100,7 @@ var _ = Describe("Packet packer", func() {
 				expectAppendStreamFrames()
 				framer.EXPECT().HasData().Return(true)
 				packer.retransmissionQueue.AddAppData(&wire.PingFrame{})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeEquivalentTo(packer.maxPacketSize))
 				Expect(p.packets).To(HaveLen(2))
@@ -1114,7 +1135,7 @@ var _ = Describe("Packet packer", func() {
 				})
 				expectAppendControlFrames()
 				expectAppendStreamFrames(ackhandler.Frame{Frame: &wire.StreamFrame{Data: []byte("foobar")}})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeNumerically(">=", protocol.MinInitialPacketSize))
 				Expect(p.buffer.Len()).To(BeEquivalentTo(maxPacketSize))
@@ -1148,7 +1169,7 @@ var _ = Describe("Packet packer", func() {
 				})
 				expectAppendControlFrames()
 				expectAppendStreamFrames(ackhandler.Frame{Frame: &wire.StreamFrame{Data: []byte("foobar")}})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.buffer.Len()).To(BeNumerically("<", 100))
 				Expect(p.packets).To(HaveLen(2))
@@ -1182,7 +1203,7 @@ var _ = Describe("Packet packer", func() {
 					Expect(f.Length(packer.version)).To(Equal(s))
 					return f
 				})
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionHandshake))
@@ -1200,7 +1221,7 @@ var _ = Describe("Packet packer", func() {
 				packer.retransmissionQueue.AddHandshake(&wire.PingFrame{})
 				handshakeStream.EXPECT().HasData()
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, false)
-				packet, err := packer.PackCoalescedPacket()
+				packet, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packet).ToNot(BeNil())
 				Expect(packet.packets).To(HaveLen(1))
@@ -1239,7 +1260,7 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, false)
 				initialStream.EXPECT().HasData()
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
@@ -1256,7 +1277,7 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x42))
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].ack).To(Equal(ack))
@@ -1268,7 +1289,7 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				initialStream.EXPECT().HasData()
 				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true)
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p).To(BeNil())
 			})
@@ -1284,12 +1305,161 @@ var _ = Describe("Packet packer", func() {
 				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
 				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42))
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].ack).To(Equal(ack))
 			})
 
+			It("coalesces Initial and Handshake packets containing only an ACK", func() {
+				initialAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+				handshakeAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 5}}}
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true).Return(initialAck)
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true).Return(handshakeAck)
+				initialStream.EXPECT().HasData().Times(2)
+				handshakeStream.EXPECT().HasData().Times(2)
+				sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+				sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24))
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42))
+				p, err := packer.PackCoalescedPacket(false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.packets).To(HaveLen(2))
+				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
+				Expect(p.packets[0].ack).To(Equal(initialAck))
+				Expect(p.packets[1].EncryptionLevel()).To(Equal(protocol.EncryptionHandshake))
+				Expect(p.packets[1].ack).To(Equal(handshakeAck))
+				hdrs := parsePacket(p.buffer.Data)
+				Expect(hdrs).To(HaveLen(2))
+				Expect(hdrs[0].Type).To(Equal(protocol.PacketTypeInitial))
+				Expect(hdrs[1].Type).To(Equal(protocol.PacketTypeHandshake))
+			})
+
+			It("doesn't coalesce more packets than configured", func() {
+				packer.maxCoalescedPackets = 1
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true).Return(ack)
+				initialStream.EXPECT().HasData().Times(2)
+				sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x42))
+				p, err := packer.PackCoalescedPacket(false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.packets).To(HaveLen(1))
+				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
+				Expect(parsePacket(p.buffer.Data)).To(HaveLen(1))
+			})
+
+			It("coalesces up to the configured number of packets", func() {
+				packer.maxCoalescedPackets = 2
+				initialAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+				handshakeAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 5}}}
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true).Return(initialAck)
+				ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true).Return(handshakeAck)
+				initialStream.EXPECT().HasData().Times(2)
+				handshakeStream.EXPECT().HasData().Times(2)
+				sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+				sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+				// don't EXPECT any calls to Get1RTTSealer, a third packet would exceed the limit
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24))
+				pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42))
+				p, err := packer.PackCoalescedPacket(false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.packets).To(HaveLen(2))
+				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
+				Expect(p.packets[1].EncryptionLevel()).To(Equal(protocol.EncryptionHandshake))
+				Expect(parsePacket(p.buffer.Data)).To(HaveLen(2))
+			})
+
+			Context("packing ACK-only packets", func() {
+				It("coalesces the ACKs for Initial and Handshake into a single datagram, and pads it (for the client)", func() {
+					packer.perspective = protocol.PerspectiveClient
+					initialAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+					handshakeAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 5}}}
+					// don't EXPECT any calls to the crypto streams
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true).Return(initialAck)
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true).Return(handshakeAck)
+					sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
+					sealingManager.EXPECT().Get0RTTSealer().Return(nil, handshake.ErrKeysDropped)
+					pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24))
+					pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42))
+					p, err := packer.PackCoalescedPacket(true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.buffer.Len()).To(BeEquivalentTo(packer.maxPacketSize))
+					Expect(p.packets).To(HaveLen(2))
+					Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
+					Expect(p.packets[0].ack).To(Equal(initialAck))
+					Expect(p.packets[0].frames).To(BeEmpty())
+					Expect(p.packets[1].EncryptionLevel()).To(Equal(protocol.EncryptionHandshake))
+					Expect(p.packets[1].ack).To(Equal(handshakeAck))
+					Expect(p.packets[1].frames).To(BeEmpty())
+					hdrs := parsePacket(p.buffer.Data)
+					Expect(hdrs).To(HaveLen(2))
+					Expect(hdrs[0].Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(hdrs[1].Type).To(Equal(protocol.PacketTypeHandshake))
+				})
+
+				It("coalesces the ACKs for Initial and Handshake into a single datagram, and doesn't pad it (for the server)", func() {
+					initialAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+					handshakeAck := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 5}}}
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true).Return(initialAck)
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true).Return(handshakeAck)
+					sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().Get1RTTSealer().Return(nil, handshake.ErrKeysNotYetAvailable)
+					pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(0x24))
+					pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42))
+					p, err := packer.PackCoalescedPacket(true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.buffer.Len()).To(BeNumerically("<", 100))
+					Expect(p.packets).To(HaveLen(2))
+					Expect(p.packets[0].ack).To(Equal(initialAck))
+					Expect(p.packets[1].ack).To(Equal(handshakeAck))
+					Expect(parsePacket(p.buffer.Data)).To(HaveLen(2))
+				})
+
+				It("packs a 1-RTT ACK, if there are no ACKs to send for Initial and Handshake", func() {
+					ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 10, Largest: 20}}}
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true).Return(ack)
+					sealingManager.EXPECT().GetInitialSealer().Return(nil, handshake.ErrKeysDropped)
+					sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					p, err := packer.PackCoalescedPacket(true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.packets).To(HaveLen(1))
+					Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.Encryption1RTT))
+					Expect(p.packets[0].ack).To(Equal(ack))
+					Expect(p.packets[0].frames).To(BeEmpty())
+				})
+
+				It("doesn't pack anything if there are no ACKs to send", func() {
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionInitial, true)
+					ackFramer.EXPECT().GetAckFrame(protocol.EncryptionHandshake, true)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true)
+					sealingManager.EXPECT().GetInitialSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().GetHandshakeSealer().Return(getSealer(), nil)
+					sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					p, err := packer.PackCoalescedPacket(true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).To(BeNil())
+				})
+			})
+
 			for _, pers := range []protocol.Perspective{protocol.PerspectiveServer, protocol.PerspectiveClient} {
 				perspective := pers
 
@@ -1307,7 +1477,7 @@ var _ = Describe("Packet packer", func() {
 					initialStream.EXPECT().HasData().Return(true).Times(2)
 					initialStream.EXPECT().PopCryptoFrame(gomock.Any()).Return(f)
 					packer.perspective = protocol.PerspectiveClient
-					p, err := packer.PackCoalescedPacket()
+					p, err := packer.PackCoalescedPacket(false)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(p.buffer.Len()).To(BeNumerically(">=", protocol.MinInitialPacketSize))
 					Expect(p.buffer.Len()).To(BeEquivalentTo(maxPacketSize))
@@ -1316,6 +1486,9 @@ var _ = Describe("Packet packer", func() {
 					Expect(p.packets[0].frames).To(HaveLen(1))
 					cf := p.packets[0].frames[0].Frame.(*wire.CryptoFrame)
 					Expect(cf.Data).To(Equal([]byte("foobar")))
+					// everything that's not header, CRYPTO frame or AEAD overhead is padding
+					hdrLen := p.packets[0].header.GetLength(packer.version)
+					Expect(packer.PaddingBytes()).To(Equal(p.buffer.Len() - hdrLen - cf.Length(packer.version) - 7))
 				})
 			}
 
@@ -1333,7 +1506,7 @@ var _ = Describe("Packet packer", func() {
 				initialStream.EXPECT().PopCryptoFrame(gomock.Any()).Return(f)
 				packer.version = protocol.VersionTLS
 				packer.perspective = protocol.PerspectiveClient
-				p, err := packer.PackCoalescedPacket()
+				p, err := packer.PackCoalescedPacket(false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.packets).To(HaveLen(1))
 				Expect(p.packets[0].ack).To(Equal(ack))
@@ -1494,6 +1667,23 @@ var _ = Describe("Packet packer", func() {
 				Expect(p.buffer.Data).To(HaveLen(int(probePacketSize)))
 				Expect(p.packetContents.isMTUProbePacket).To(BeTrue())
 			})
+
+			It("packs a path probe packet", func() {
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x43), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x43))
+				f := ackhandler.Frame{Frame: &wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}}
+				connID := protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad}
+				p, err := packer.PackPathProbePacket(connID, f, protocol.MinInitialPacketSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.length).To(BeEquivalentTo(protocol.MinInitialPacketSize))
+				Expect(p.header.IsLongHeader).To(BeFalse())
+				Expect(p.header.DestConnectionID).To(Equal(connID))
+				Expect(p.EncryptionLevel()).To(Equal(protocol.Encryption1RTT))
+				Expect(p.frames).To(Equal([]ackhandler.Frame{f}))
+				Expect(p.packetContents.isMTUProbePacket).To(BeFalse())
+				Expect(p.packetContents.isPathProbePacket).To(BeTrue())
+			})
 		})
 	})
 })
@@ -147,7 +147,7 @@ func (e eventConnectionClosed) MarshalJSONObject(enc *gojay.Encoder) {
 		enc.StringKey("owner", owner.String())
 		enc.StringKey("connection_code", transportError(transportErr.ErrorCode).String())
 		enc.StringKey("reason", transportErr.ErrorMessage)
-	case errors.As(e.e, &versionNegotiationErr):
+	case errors.As(e.e, &versionNegotiationErr), errors.Is(e.e, quic.ErrVersionNegotiationDisabled):
 		enc.StringKey("owner", ownerRemote.String())
 		enc.StringKey("trigger", "version_negotiation")
 	}
@@ -261,9 +261,10 @@ type metrics struct {
 	LatestRTT   time.Duration
 	RTTVariance time.Duration
 
-	CongestionWindow protocol.ByteCount
-	BytesInFlight    protocol.ByteCount
-	PacketsInFlight  int
+	CongestionWindow   protocol.ByteCount
+	BytesInFlight      protocol.ByteCount
+	SlowStartThreshold protocol.ByteCount
+	PacketsInFlight    int
 }
 
 type eventMetricsUpdated struct {
@@ -295,6 +296,10 @@ func (e eventMetricsUpdated) MarshalJSONObject(enc *gojay.Encoder) {
 	if e.Last == nil || e.Last.BytesInFlight != e.Current.BytesInFlight {
 		enc.Uint64Key("bytes_in_flight", uint64(e.Current.BytesInFlight))
 	}
+	// the slow start threshold is infinite until the first congestion event
+	if (e.Last == nil || e.Last.SlowStartThreshold != e.Current.SlowStartThreshold) && e.Current.SlowStartThreshold != protocol.MaxByteCount {
+		enc.Uint64Key("ssthresh", uint64(e.Current.SlowStartThreshold))
+	}
 	if e.Last == nil || e.Last.PacketsInFlight != e.Current.PacketsInFlight {
 		enc.Uint64KeyOmitEmpty("packets_in_flight", uint64(e.Current.PacketsInFlight))
 	}
@@ -312,6 +317,38 @@ func (e eventUpdatedPTO) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.Uint32Key("pto_count", e.Value)
 }
 
+type eventStreamOpened struct {
+	StreamID protocol.StreamID
+	Owner    owner
+}
+
+func (e eventStreamOpened) Category() category { return categoryTransport }
+func (e eventStreamOpened) Name() string       { return "stream_state_updated" }
+func (e eventStreamOpened) IsNil() bool        { return false }
+
+func (e eventStreamOpened) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("stream_id", int64(e.StreamID))
+	enc.StringKey("stream_type", streamType(e.StreamID.Type()).String())
+	enc.StringKey("owner", e.Owner.String())
+	enc.StringKey("new", "open")
+}
+
+type eventStreamClosed struct {
+	StreamID protocol.StreamID
+	Trigger  streamCloseReason
+}
+
+func (e eventStreamClosed) Category() category { return categoryTransport }
+func (e eventStreamClosed) Name() string       { return "stream_state_updated" }
+func (e eventStreamClosed) IsNil() bool        { return false }
+
+func (e eventStreamClosed) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("stream_id", int64(e.StreamID))
+	enc.StringKey("stream_type", streamType(e.StreamID.Type()).String())
+	enc.StringKey("new", "closed")
+	enc.StringKey("trigger", e.Trigger.String())
+}
+
 type eventPacketLost struct {
 	PacketType   logging.PacketType
 	PacketNumber protocol.PacketNumber
@@ -515,6 +552,63 @@ func (e eventCongestionStateUpdated) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKey("new", e.state.String())
 }
 
+type eventECNStateUpdated struct {
+	state ecnState
+}
+
+func (e eventECNStateUpdated) Category() category { return categoryRecovery }
+func (e eventECNStateUpdated) Name() string       { return "ecn_state_updated" }
+func (e eventECNStateUpdated) IsNil() bool        { return false }
+
+func (e eventECNStateUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("new", e.state.String())
+}
+
+type eventMTUUpdated struct {
+	mtu  protocol.ByteCount
+	done bool
+}
+
+func (e eventMTUUpdated) Category() category { return categoryConnectivity }
+func (e eventMTUUpdated) Name() string       { return "mtu_updated" }
+func (e eventMTUUpdated) IsNil() bool        { return false }
+
+func (e eventMTUUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("new", int64(e.mtu))
+	enc.BoolKey("done", e.done)
+}
+
+type eventConnectionStateUpdated struct {
+	state connectionState
+}
+
+func (e eventConnectionStateUpdated) Category() category { return categoryTransport }
+func (e eventConnectionStateUpdated) Name() string       { return "connection_state_updated" }
+func (e eventConnectionStateUpdated) IsNil() bool        { return false }
+
+func (e eventConnectionStateUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("new", e.state.String())
+}
+
+// The qlog draft doesn't define events for TLS handshake messages.
+type eventHandshakeMessage struct {
+	sent    bool
+	msgType handshakeMessageType
+}
+
+func (e eventHandshakeMessage) Category() category { return categorySecurity }
+func (e eventHandshakeMessage) Name() string {
+	if e.sent {
+		return "handshake_message_sent"
+	}
+	return "handshake_message_received"
+}
+func (e eventHandshakeMessage) IsNil() bool { return false }
+
+func (e eventHandshakeMessage) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("message_type", e.msgType.String())
+}
+
 type eventGeneric struct {
 	name string
 	msg  string
@@ -47,7 +47,11 @@ func init() {
 	}
 }
 
-const eventChanSize = 50
+const (
+	eventChanSize = 50
+	// the maximum number of bytes of encoded events that are written to the io.WriteCloser at once
+	maxBufferedBytes = 1 << 14
+)
 
 type tracer struct {
 	getLogWriter func(p logging.Perspective, connectionID []byte) io.WriteCloser
@@ -125,18 +129,28 @@ func (t *connectionTracer) run() {
 	if _, err := t.w.Write(buf.Bytes()); err != nil {
 		t.encodeErr = err
 	}
-	enc = gojay.NewEncoder(t.w)
+	buf.Reset()
 	for ev := range t.events {
-		if t.encodeErr != nil { // if encoding failed, just continue draining the event channel
+		if t.encodeErr != nil { // if writing failed, just continue draining the event channel
 			continue
 		}
 		if err := enc.Encode(ev); err != nil {
-			t.encodeErr = err
+			// Only this event is lost. The events that were already encoded are kept in the buffer.
+			// The encoder keeps returning the error, so a new one is needed for the next events.
+			log.Printf("encoding qlog event %s failed: %s\n", ev.Name(), err)
+			enc = gojay.NewEncoder(buf)
+		} else if err := buf.WriteByte('\n'); err != nil {
+			panic(fmt.Sprintf("qlog encoding into a bytes.Buffer failed: %s", err))
+		}
+		// If more events are already queued, encode them first and write them all at once.
+		// This saves a lot of Write calls when tracing a busy connection.
+		if (len(t.events) > 0 && buf.Len() < maxBufferedBytes) || buf.Len() == 0 {
 			continue
 		}
-		if _, err := t.w.Write([]byte{'\n'}); err != nil {
+		if _, err := t.w.Write(buf.Bytes()); err != nil {
 			t.encodeErr = err
 		}
+		buf.Reset()
 	}
 }
 
@@ -352,15 +366,16 @@ func (t *connectionTracer) DroppedPacket(pt logging.PacketType, size protocol.By
 	t.mutex.Unlock()
 }
 
-func (t *connectionTracer) UpdatedMetrics(rttStats *utils.RTTStats, cwnd, bytesInFlight protocol.ByteCount, packetsInFlight int) {
+func (t *connectionTracer) UpdatedMetrics(rttStats *utils.RTTStats, cwnd, bytesInFlight, ssthresh protocol.ByteCount, packetsInFlight int) {
 	m := &metrics{
-		MinRTT:           rttStats.MinRTT(),
-		SmoothedRTT:      rttStats.SmoothedRTT(),
-		LatestRTT:        rttStats.LatestRTT(),
-		RTTVariance:      rttStats.MeanDeviation(),
-		CongestionWindow: cwnd,
-		BytesInFlight:    bytesInFlight,
-		PacketsInFlight:  packetsInFlight,
+		MinRTT:             rttStats.MinRTT(),
+		SmoothedRTT:        rttStats.SmoothedRTT(),
+		LatestRTT:          rttStats.LatestRTT(),
+		RTTVariance:        rttStats.MeanDeviation(),
+		CongestionWindow:   cwnd,
+		BytesInFlight:      bytesInFlight,
+		SlowStartThreshold: ssthresh,
+		PacketsInFlight:    packetsInFlight,
 	}
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventMetricsUpdated{
@@ -389,12 +404,68 @@ func (t *connectionTracer) UpdatedCongestionState(state logging.CongestionState)
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) UpdatedECNState(state logging.ECNState) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventECNStateUpdated{state: ecnState(state)})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedMTU(mtu logging.ByteCount, done bool) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventMTUUpdated{mtu: mtu, done: done})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedPTOCount(value uint32) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventUpdatedPTO{Value: value})
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) OpenedStream(id protocol.StreamID, initiator protocol.Perspective) {
+	o := ownerLocal
+	if initiator != t.perspective {
+		o = ownerRemote
+	}
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventStreamOpened{StreamID: id, Owner: o})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) ClosedStream(id protocol.StreamID, reason logging.StreamCloseReason) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventStreamClosed{StreamID: id, Trigger: streamCloseReason(reason)})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) StartedHandshake() {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventConnectionStateUpdated{state: connectionStateHandshakeStarted})
+	t.mutex.Unlock()
+}
+
+// ReceivedKeys doesn't record anything.
+// The installation of keys is already recorded by UpdatedKeyFromTLS.
+func (t *connectionTracer) ReceivedKeys(protocol.EncryptionLevel) {}
+
+func (t *connectionTracer) CompletedHandshake() {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventConnectionStateUpdated{state: connectionStateHandshakeComplete})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) SentHandshakeMessage(typ logging.HandshakeMessageType) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventHandshakeMessage{sent: true, msgType: handshakeMessageType(typ)})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) ReceivedHandshakeMessage(typ logging.HandshakeMessageType) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventHandshakeMessage{msgType: handshakeMessageType(typ)})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedKeyFromTLS(encLevel protocol.EncryptionLevel, pers protocol.Perspective) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventKeyUpdated{
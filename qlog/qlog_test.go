@@ -17,6 +17,7 @@ import (
 	"github.com/BGrewell/quic-go/internal/utils"
 	"github.com/BGrewell/quic-go/logging"
 
+	"github.com/francoispqt/gojay"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -44,6 +45,28 @@ func (w *limitedWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+type blockingWriter struct {
+	io.WriteCloser
+	unblock chan struct{}
+	writes  int
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.writes++
+	return w.WriteCloser.Write(p)
+}
+
+// eventUnencodable is an event that can't be encoded
+type eventUnencodable struct{}
+
+func (eventUnencodable) Category() category { return categoryTransport }
+func (eventUnencodable) Name() string       { return "unencodable" }
+func (eventUnencodable) IsNil() bool        { return false }
+func (eventUnencodable) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.AddInterfaceKey("foo", struct{}{})
+}
+
 type entry struct {
 	Time  time.Time
 	Name  string
@@ -76,6 +99,50 @@ var _ = Describe("Tracing", func() {
 		Expect(b.String()).To(ContainSubstring("writer full"))
 	})
 
+	It("writes queued events at once", func() {
+		buf := &bytes.Buffer{}
+		w := &blockingWriter{WriteCloser: nopWriteCloser(buf), unblock: make(chan struct{})}
+		t := NewConnectionTracer(w, protocol.PerspectiveServer, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		// The tracer is blocked writing the header, so all these events are queued.
+		for i := uint32(0); i < eventChanSize; i++ {
+			t.UpdatedPTOCount(i)
+		}
+		close(w.unblock)
+		t.Close()
+		Expect(w.writes).To(Equal(2)) // one for the header, one for all events
+		lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), []byte{'\n'})
+		Expect(lines).To(HaveLen(eventChanSize + 1))
+		for _, l := range lines {
+			Expect(json.Valid(l)).To(BeTrue())
+		}
+	})
+
+	It("keeps the other events if an event can't be encoded", func() {
+		buf := &bytes.Buffer{}
+		w := &blockingWriter{WriteCloser: nopWriteCloser(buf), unblock: make(chan struct{})}
+		t := NewConnectionTracer(w, protocol.PerspectiveServer, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		// The tracer is blocked writing the header, so all these events are queued.
+		t.UpdatedPTOCount(1)
+		t.UpdatedPTOCount(2)
+		t.(*connectionTracer).recordEvent(time.Now(), eventUnencodable{})
+		t.UpdatedPTOCount(3)
+
+		b := &bytes.Buffer{}
+		log.SetOutput(b)
+		defer log.SetOutput(os.Stdout)
+		close(w.unblock)
+		t.Close()
+		Expect(b.String()).To(ContainSubstring("encoding qlog event unencodable failed"))
+		lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), []byte{'\n'})
+		Expect(lines).To(HaveLen(4)) // the header, and 3 events
+		for i, l := range lines[1:] {
+			ev := make(map[string]interface{})
+			Expect(json.Unmarshal(l, &ev)).To(Succeed())
+			Expect(ev).To(HaveKeyWithValue("name", "recovery:metrics_updated"))
+			Expect(ev["data"]).To(HaveKeyWithValue("pto_count", float64(i+1)))
+		}
+	})
+
 	Context("connection tracer", func() {
 		var (
 			tracer logging.ConnectionTracer
@@ -242,6 +309,16 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("trigger", "version_negotiation"))
 			})
 
+			It("records connection closing due to a Version Negotiation packet, when version negotiation is disabled", func() {
+				tracer.ClosedConnection(quic.ErrVersionNegotiationDisabled)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("transport:connection_closed"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(2))
+				Expect(ev).To(HaveKeyWithValue("owner", "remote"))
+				Expect(ev).To(HaveKeyWithValue("trigger", "version_negotiation"))
+			})
+
 			It("records application errors", func() {
 				tracer.ClosedConnection(&quic.ApplicationError{
 					Remote:       true,
@@ -616,6 +693,7 @@ var _ = Describe("Tracing", func() {
 					rttStats,
 					4321,
 					1234,
+					2000,
 					42,
 				)
 				entry := exportAndParseSingle()
@@ -630,9 +708,18 @@ var _ = Describe("Tracing", func() {
 				Expect(time.Duration(ev["rtt_variance"].(float64)) * time.Millisecond).To(BeNumerically("~", rttStats.MeanDeviation(), time.Millisecond))
 				Expect(ev).To(HaveKeyWithValue("congestion_window", float64(4321)))
 				Expect(ev).To(HaveKeyWithValue("bytes_in_flight", float64(1234)))
+				Expect(ev).To(HaveKeyWithValue("ssthresh", float64(2000)))
 				Expect(ev).To(HaveKeyWithValue("packets_in_flight", float64(42)))
 			})
 
+			It("doesn't log the slow start threshold if it is infinite", func() {
+				tracer.UpdatedMetrics(utils.NewRTTStats(), 4321, 1234, protocol.MaxByteCount, 42)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("recovery:metrics_updated"))
+				Expect(entry.Event).ToNot(HaveKey("ssthresh"))
+				Expect(entry.Event).To(HaveKeyWithValue("congestion_window", float64(4321)))
+			})
+
 			It("only logs the diff between two metrics updates", func() {
 				now := time.Now()
 				rttStats := utils.NewRTTStats()
@@ -652,25 +739,28 @@ var _ = Describe("Tracing", func() {
 					rttStats,
 					4321,
 					1234,
+					2000,
 					42,
 				)
 				tracer.UpdatedMetrics(
 					rttStats2,
 					4321,
 					12345, // changed
+					2000,
 					42,
 				)
 				entries := exportAndParse()
 				Expect(entries).To(HaveLen(2))
 				Expect(entries[0].Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
 				Expect(entries[0].Name).To(Equal("recovery:metrics_updated"))
-				Expect(entries[0].Event).To(HaveLen(7))
+				Expect(entries[0].Event).To(HaveLen(8))
 				Expect(entries[1].Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
 				Expect(entries[1].Name).To(Equal("recovery:metrics_updated"))
 				ev := entries[1].Event
 				Expect(ev).ToNot(HaveKey("min_rtt"))
 				Expect(ev).ToNot(HaveKey("congestion_window"))
 				Expect(ev).ToNot(HaveKey("packets_in_flight"))
+				Expect(ev).ToNot(HaveKey("ssthresh"))
 				Expect(ev).To(HaveKeyWithValue("bytes_in_flight", float64(12345)))
 				Expect(ev).To(HaveKeyWithValue("smoothed_rtt", float64(15)))
 			})
@@ -698,6 +788,23 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("new", "congestion_avoidance"))
 			})
 
+			It("records ECN state updates", func() {
+				tracer.UpdatedECNState(logging.ECNStateCapable)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:ecn_state_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("new", "capable"))
+			})
+
+			It("records MTU updates", func() {
+				tracer.UpdatedMTU(1337, true)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("connectivity:mtu_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("new", float64(1337)))
+				Expect(entry.Event).To(HaveKeyWithValue("done", true))
+			})
+
 			It("records PTO changes", func() {
 				tracer.UpdatedPTOCount(42)
 				entry := exportAndParseSingle()
@@ -706,6 +813,62 @@ var _ = Describe("Tracing", func() {
 				Expect(entry.Event).To(HaveKeyWithValue("pto_count", float64(42)))
 			})
 
+			It("records opened streams", func() {
+				tracer.OpenedStream(4, protocol.PerspectiveClient)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("transport:stream_state_updated"))
+				ev := entry.Event
+				Expect(ev).To(HaveKeyWithValue("stream_id", float64(4)))
+				Expect(ev).To(HaveKeyWithValue("stream_type", "bidirectional"))
+				Expect(ev).To(HaveKeyWithValue("owner", "remote"))
+				Expect(ev).To(HaveKeyWithValue("new", "open"))
+			})
+
+			It("records closed streams", func() {
+				tracer.ClosedStream(6, logging.StreamCloseReasonCompleted)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("transport:stream_state_updated"))
+				ev := entry.Event
+				Expect(ev).To(HaveKeyWithValue("stream_id", float64(6)))
+				Expect(ev).To(HaveKeyWithValue("stream_type", "unidirectional"))
+				Expect(ev).To(HaveKeyWithValue("new", "closed"))
+				Expect(ev).To(HaveKeyWithValue("trigger", "completed"))
+			})
+
+			It("records the start of the handshake", func() {
+				tracer.StartedHandshake()
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("transport:connection_state_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("new", "handshake_started"))
+			})
+
+			It("records the completion of the handshake", func() {
+				tracer.CompletedHandshake()
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("transport:connection_state_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("new", "handshake_complete"))
+			})
+
+			It("records sent handshake messages", func() {
+				tracer.SentHandshakeMessage(logging.HandshakeMessageEncryptedExtensions)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("security:handshake_message_sent"))
+				Expect(entry.Event).To(HaveKeyWithValue("message_type", "encrypted_extensions"))
+			})
+
+			It("records received handshake messages", func() {
+				tracer.ReceivedHandshakeMessage(logging.HandshakeMessageFinished)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("security:handshake_message_received"))
+				Expect(entry.Event).To(HaveKeyWithValue("message_type", "finished"))
+			})
+
 			It("records TLS key updates", func() {
 				tracer.UpdatedKeyFromTLS(protocol.EncryptionHandshake, protocol.PerspectiveClient)
 				entry := exportAndParseSingle()
@@ -156,6 +156,49 @@ func (t keyType) String() string {
 	}
 }
 
+type connectionState uint8
+
+const (
+	connectionStateHandshakeStarted connectionState = iota
+	connectionStateHandshakeComplete
+)
+
+func (s connectionState) String() string {
+	switch s {
+	case connectionStateHandshakeStarted:
+		return "handshake_started"
+	case connectionStateHandshakeComplete:
+		return "handshake_complete"
+	default:
+		return "unknown connection state"
+	}
+}
+
+type handshakeMessageType logging.HandshakeMessageType
+
+func (t handshakeMessageType) String() string {
+	switch logging.HandshakeMessageType(t) {
+	case logging.HandshakeMessageClientHello:
+		return "client_hello"
+	case logging.HandshakeMessageServerHello:
+		return "server_hello"
+	case logging.HandshakeMessageNewSessionTicket:
+		return "new_session_ticket"
+	case logging.HandshakeMessageEncryptedExtensions:
+		return "encrypted_extensions"
+	case logging.HandshakeMessageCertificate:
+		return "certificate"
+	case logging.HandshakeMessageCertificateRequest:
+		return "certificate_request"
+	case logging.HandshakeMessageCertificateVerify:
+		return "certificate_verify"
+	case logging.HandshakeMessageFinished:
+		return "finished"
+	default:
+		return "unknown handshake message type"
+	}
+}
+
 type keyUpdateTrigger uint8
 
 const (
@@ -318,3 +361,35 @@ func (s congestionState) String() string {
 		return "unknown congestion state"
 	}
 }
+
+type ecnState logging.ECNState
+
+func (s ecnState) String() string {
+	switch logging.ECNState(s) {
+	case logging.ECNStateTesting:
+		return "testing"
+	case logging.ECNStateUnknown:
+		return "unknown"
+	case logging.ECNStateFailed:
+		return "failed"
+	case logging.ECNStateCapable:
+		return "capable"
+	default:
+		return "unknown ECN state"
+	}
+}
+
+type streamCloseReason logging.StreamCloseReason
+
+func (r streamCloseReason) String() string {
+	switch logging.StreamCloseReason(r) {
+	case logging.StreamCloseReasonCompleted:
+		return "completed"
+	case logging.StreamCloseReasonConnectionClosed:
+		return "connection_closed"
+	case logging.StreamCloseReason0RTTRejected:
+		return "0rtt_rejected"
+	default:
+		return "unknown close reason"
+	}
+}
@@ -40,6 +40,18 @@ var _ = Describe("Types", func() {
 		Expect(packetType(logging.PacketTypeNotDetermined).String()).To(BeEmpty())
 	})
 
+	It("has a string representation for the handshake message type", func() {
+		Expect(handshakeMessageType(logging.HandshakeMessageClientHello).String()).To(Equal("client_hello"))
+		Expect(handshakeMessageType(logging.HandshakeMessageServerHello).String()).To(Equal("server_hello"))
+		Expect(handshakeMessageType(logging.HandshakeMessageNewSessionTicket).String()).To(Equal("new_session_ticket"))
+		Expect(handshakeMessageType(logging.HandshakeMessageEncryptedExtensions).String()).To(Equal("encrypted_extensions"))
+		Expect(handshakeMessageType(logging.HandshakeMessageCertificate).String()).To(Equal("certificate"))
+		Expect(handshakeMessageType(logging.HandshakeMessageCertificateRequest).String()).To(Equal("certificate_request"))
+		Expect(handshakeMessageType(logging.HandshakeMessageCertificateVerify).String()).To(Equal("certificate_verify"))
+		Expect(handshakeMessageType(logging.HandshakeMessageFinished).String()).To(Equal("finished"))
+		Expect(handshakeMessageType(42).String()).To(Equal("unknown handshake message type"))
+	})
+
 	It("has a string representation for the packet drop reason", func() {
 		Expect(packetDropReason(logging.PacketDropKeyUnavailable).String()).To(Equal("key_unavailable"))
 		Expect(packetDropReason(logging.PacketDropUnknownConnectionID).String()).To(Equal("unknown_connection_id"))
@@ -127,4 +139,17 @@ var _ = Describe("Types", func() {
 		Expect(congestionState(logging.CongestionStateApplicationLimited).String()).To(Equal("application_limited"))
 		Expect(congestionState(logging.CongestionStateRecovery).String()).To(Equal("recovery"))
 	})
+
+	It("has a string representation for the ECN state", func() {
+		Expect(ecnState(logging.ECNStateTesting).String()).To(Equal("testing"))
+		Expect(ecnState(logging.ECNStateUnknown).String()).To(Equal("unknown"))
+		Expect(ecnState(logging.ECNStateFailed).String()).To(Equal("failed"))
+		Expect(ecnState(logging.ECNStateCapable).String()).To(Equal("capable"))
+	})
+
+	It("has a string representation for stream close reasons", func() {
+		Expect(streamCloseReason(logging.StreamCloseReasonCompleted).String()).To(Equal("completed"))
+		Expect(streamCloseReason(logging.StreamCloseReasonConnectionClosed).String()).To(Equal("connection_closed"))
+		Expect(streamCloseReason(logging.StreamCloseReason0RTTRejected).String()).To(Equal("0rtt_rejected"))
+	})
 })
@@ -0,0 +1,101 @@
+package quic
+
+import (
+	"sync"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
+// The sendBufferLimiter limits the amount of data that is buffered for sending on all streams of a session.
+// Data is accounted for from the moment a stream takes it from the application,
+// until it is acknowledged by the peer (or the stream is canceled).
+// It is safe for concurrent use.
+type sendBufferLimiter struct {
+	mutex sync.Mutex
+
+	maxBytes protocol.ByteCount
+	bytes    protocol.ByteCount
+
+	unblockChan chan struct{} // closed when the limiter is not full any more
+	onUnblocked []func()      // called when the limiter is not full any more
+}
+
+func newSendBufferLimiter(maxBytes protocol.ByteCount) *sendBufferLimiter {
+	return &sendBufferLimiter{maxBytes: maxBytes}
+}
+
+// Add accounts for data that was taken from the application.
+// It doesn't check the limit: the caller is expected to only add data if the limiter is not full.
+func (l *sendBufferLimiter) Add(n protocol.ByteCount) {
+	l.mutex.Lock()
+	l.bytes += n
+	l.mutex.Unlock()
+}
+
+// Remove is called when buffered data was acknowledged, or doesn't need to be sent any more.
+func (l *sendBufferLimiter) Remove(n protocol.ByteCount) {
+	l.mutex.Lock()
+	l.bytes -= n
+	if l.bytes >= l.maxBytes {
+		l.mutex.Unlock()
+		return
+	}
+	if l.unblockChan != nil {
+		close(l.unblockChan)
+		l.unblockChan = nil
+	}
+	callbacks := l.onUnblocked
+	l.onUnblocked = nil
+	l.mutex.Unlock()
+
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// NotifyWhenUnblocked calls f once the limiter is not full any more.
+// If the limiter is not full, f is called right away.
+// f is called without holding the limiter's mutex, and must not block.
+func (l *sendBufferLimiter) NotifyWhenUnblocked(f func()) {
+	l.mutex.Lock()
+	if l.bytes < l.maxBytes {
+		l.mutex.Unlock()
+		f()
+		return
+	}
+	l.onUnblocked = append(l.onUnblocked, f)
+	l.mutex.Unlock()
+}
+
+// Available returns the number of bytes that can be added before the limit is reached.
+func (l *sendBufferLimiter) Available() protocol.ByteCount {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.bytes >= l.maxBytes {
+		return 0
+	}
+	return l.maxBytes - l.bytes
+}
+
+// BlockedChan returns nil if the limiter is not full.
+// Otherwise, it returns a channel that is closed as soon as the limiter is not full any more.
+func (l *sendBufferLimiter) BlockedChan() <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.bytes < l.maxBytes {
+		return nil
+	}
+	if l.unblockChan == nil {
+		l.unblockChan = make(chan struct{})
+	}
+	return l.unblockChan
+}
+
+// Bytes returns the number of bytes that are currently buffered.
+func (l *sendBufferLimiter) Bytes() protocol.ByteCount {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.bytes
+}
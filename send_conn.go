@@ -2,11 +2,21 @@ package quic
 
 import (
 	"net"
+	"sync"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
 )
 
 // A sendConn allows sending using a simple Write() on a non-connected packet conn.
 type sendConn interface {
-	Write([]byte) error
+	// Write writes a packet, marked with the given ECN codepoint.
+	Write([]byte, protocol.ECN) error
+	// WritePackets writes multiple packets, all marked with the given ECN codepoint, using as few syscalls as possible.
+	// It returns the number of syscalls saved compared to writing every packet using Write.
+	WritePackets([][]byte, protocol.ECN) (int, error)
+	// WithRemoteAddr returns a sendConn that sends packets to a different remote address, using the same connection.
+	// The packet info is taken from a packet received from that address. It is nil if packet info isn't available.
+	WithRemoteAddr(net.Addr, *packetInfo) sendConn
 	Close() error
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
@@ -18,24 +28,54 @@ type sconn struct {
 	remoteAddr net.Addr
 	info       *packetInfo
 	oob        []byte
+	oobECT0    []byte // oob, followed by the control message for ECT(0) marked packets
+
+	// The batchWriter is reset on the send queue's goroutine, see writePackets.
+	batchWriterMutex sync.Mutex
+	batchWriter      batchWriter // nil if packets can't be sent in batches
 }
 
 var _ sendConn = &sconn{}
 
-func newSendConn(c connection, remote net.Addr, info *packetInfo) sendConn {
+func newSendConn(c connection, remote net.Addr, info *packetInfo, bw batchWriter) sendConn {
+	oob := info.OOB()
 	return &sconn{
-		connection: c,
-		remoteAddr: remote,
-		info:       info,
-		oob:        info.OOB(),
+		connection:  c,
+		remoteAddr:  remote,
+		info:        info,
+		oob:         oob,
+		oobECT0:     append(append([]byte{}, oob...), ecnControlMessage(protocol.ECT0, remote)...),
+		batchWriter: bw,
 	}
 }
 
-func (c *sconn) Write(p []byte) error {
-	_, err := c.WritePacket(p, c.remoteAddr, c.oob)
+func (c *sconn) Write(p []byte, ecn protocol.ECN) error {
+	_, err := c.WritePacket(p, c.remoteAddr, c.oobFor(ecn))
 	return err
 }
 
+func (c *sconn) WritePackets(packets [][]byte, ecn protocol.ECN) (int, error) {
+	return writePackets(&c.batchWriterMutex, &c.batchWriter, packets, c.remoteAddr, c.oobFor(ecn), func(p []byte) error { return c.Write(p, ecn) })
+}
+
+func (c *sconn) oobFor(ecn protocol.ECN) []byte {
+	switch ecn {
+	case protocol.ECNNon:
+		return c.oob
+	case protocol.ECT0:
+		return c.oobECT0
+	default:
+		return append(append([]byte{}, c.oob...), ecnControlMessage(ecn, c.remoteAddr)...)
+	}
+}
+
+func (c *sconn) WithRemoteAddr(remote net.Addr, info *packetInfo) sendConn {
+	c.batchWriterMutex.Lock()
+	bw := c.batchWriter
+	c.batchWriterMutex.Unlock()
+	return newSendConn(c.connection, remote, info, bw)
+}
+
 func (c *sconn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
@@ -56,19 +96,130 @@ type spconn struct {
 	net.PacketConn
 
 	remoteAddr net.Addr
+	oobECT0    []byte // nil if ECN marks can't be set on this connection
+
+	// The batchWriter is reset on the send queue's goroutine, see writePackets.
+	batchWriterMutex sync.Mutex
+	batchWriter      batchWriter // nil if packets can't be sent in batches
 }
 
 var _ sendConn = &spconn{}
 
-func newSendPconn(c net.PacketConn, remote net.Addr) sendConn {
-	return &spconn{PacketConn: c, remoteAddr: remote}
+func newSendPconn(c net.PacketConn, remote net.Addr, bw batchWriter) sendConn {
+	conn := &spconn{PacketConn: c, remoteAddr: remote, batchWriter: bw}
+	if _, ok := c.(OOBCapablePacketConn); ok {
+		conn.oobECT0 = ecnControlMessage(protocol.ECT0, remote)
+	}
+	return conn
 }
 
-func (c *spconn) Write(p []byte) error {
-	_, err := c.WriteTo(p, c.remoteAddr)
+// Write writes a packet.
+// If the underlying PacketConn doesn't allow setting ECN marks, the packet is sent without ECN marks.
+// In that case, ECN validation will fail, and the session stops using ECN.
+func (c *spconn) Write(p []byte, ecn protocol.ECN) error {
+	oob := c.oobFor(ecn)
+	if oob == nil {
+		_, err := c.WriteTo(p, c.remoteAddr)
+		return err
+	}
+	_, _, err := c.PacketConn.(OOBCapablePacketConn).WriteMsgUDP(p, oob, c.remoteAddr.(*net.UDPAddr))
 	return err
 }
 
+func (c *spconn) WritePackets(packets [][]byte, ecn protocol.ECN) (int, error) {
+	return writePackets(&c.batchWriterMutex, &c.batchWriter, packets, c.remoteAddr, c.oobFor(ecn), func(p []byte) error { return c.Write(p, ecn) })
+}
+
+func (c *spconn) oobFor(ecn protocol.ECN) []byte {
+	switch {
+	case ecn == protocol.ECNNon:
+		return nil
+	case ecn == protocol.ECT0:
+		return c.oobECT0
+	default:
+		if _, ok := c.PacketConn.(OOBCapablePacketConn); !ok {
+			return nil
+		}
+		return ecnControlMessage(ecn, c.remoteAddr)
+	}
+}
+
+// WithRemoteAddr returns a sendConn for a different remote address.
+// The packet info is ignored, since it's only available for connections created by a Listener.
+func (c *spconn) WithRemoteAddr(remote net.Addr, _ *packetInfo) sendConn {
+	c.batchWriterMutex.Lock()
+	bw := c.batchWriter
+	c.batchWriterMutex.Unlock()
+	return newSendPconn(c.PacketConn, remote, bw)
+}
+
 func (c *spconn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
+
+// equalAddr says if two addresses are equal.
+// UDP addresses are compared without allocating, since this is done for every received packet.
+func equalAddr(a, b net.Addr) bool {
+	ua, ok := a.(*net.UDPAddr)
+	ub, ok2 := b.(*net.UDPAddr)
+	if ok && ok2 {
+		return ua.Port == ub.Port && ua.Zone == ub.Zone && ua.IP.Equal(ub.IP)
+	}
+	return a.Network() == b.Network() && a.String() == b.String()
+}
+
+// A captureConn passes every packet to a capture function, before sending it on the underlying sendConn.
+// It is used if Config.PacketCapture is set.
+type captureConn struct {
+	sendConn
+
+	capture func(PacketDirection, []byte, net.Addr)
+}
+
+var _ sendConn = &captureConn{}
+
+func newCaptureConn(c sendConn, capture func(PacketDirection, []byte, net.Addr)) sendConn {
+	return &captureConn{sendConn: c, capture: capture}
+}
+
+func (c *captureConn) Write(p []byte, ecn protocol.ECN) error {
+	c.capture(PacketDirectionSent, p, c.RemoteAddr())
+	return c.sendConn.Write(p, ecn)
+}
+
+func (c *captureConn) WritePackets(packets [][]byte, ecn protocol.ECN) (int, error) {
+	for _, p := range packets {
+		c.capture(PacketDirectionSent, p, c.RemoteAddr())
+	}
+	return c.sendConn.WritePackets(packets, ecn)
+}
+
+func (c *captureConn) WithRemoteAddr(remote net.Addr, info *packetInfo) sendConn {
+	return newCaptureConn(c.sendConn.WithRemoteAddr(remote, info), c.capture)
+}
+
+// writePackets sends the packets using the batchWriter.
+// If *bw is nil, or if it isn't able to send packets to addr, the packets are sent one by one using write.
+// In the latter case, *bw is set to nil, such that batching isn't attempted again.
+// *bw is only accessed while holding mutex, since WithRemoteAddr reads it from a different goroutine.
+// Errors due to packets that are too large are ignored, see sendQueue.Run.
+func writePackets(mutex *sync.Mutex, bw *batchWriter, packets [][]byte, addr net.Addr, oob []byte, write func([]byte) error) (int, error) {
+	mutex.Lock()
+	w := *bw
+	mutex.Unlock()
+	if w != nil {
+		syscalls, err := w.WritePackets(packets, addr, oob)
+		if err != errBatchWriteUnsupported {
+			return len(packets) - syscalls, err
+		}
+		mutex.Lock()
+		*bw = nil
+		mutex.Unlock()
+	}
+	for _, p := range packets {
+		if err := write(p); err != nil && !isMsgSizeErr(err) {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
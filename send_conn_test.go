@@ -1,8 +1,13 @@
 package quic
 
 import (
+	"errors"
 	"net"
+	"testing"
 
+	"github.com/BGrewell/quic-go/internal/protocol"
+
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -17,18 +22,92 @@ var _ = Describe("Connection (for sending packets)", func() {
 	BeforeEach(func() {
 		addr = &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
 		packetConn = NewMockPacketConn(mockCtrl)
-		c = newSendPconn(packetConn, addr)
+		c = newSendPconn(packetConn, addr, nil)
 	})
 
 	It("writes", func() {
 		packetConn.EXPECT().WriteTo([]byte("foobar"), addr)
-		Expect(c.Write([]byte("foobar"))).To(Succeed())
+		Expect(c.Write([]byte("foobar"), protocol.ECNNon)).To(Succeed())
+	})
+
+	It("writes multiple packets one by one, if it can't send them in a batch", func() {
+		gomock.InOrder(
+			packetConn.EXPECT().WriteTo([]byte("foo"), addr),
+			packetConn.EXPECT().WriteTo([]byte("bar"), addr),
+		)
+		saved, err := c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(saved).To(BeZero())
+	})
+
+	It("writes multiple packets in a batch", func() {
+		bw := NewMockBatchWriter(mockCtrl)
+		c = newSendPconn(packetConn, addr, bw)
+		packets := [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}
+		bw.EXPECT().WritePackets(packets, addr, nil).Return(1, nil)
+		saved, err := c.WritePackets(packets, protocol.ECNNon)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(saved).To(Equal(2))
+	})
+
+	It("returns errors that occur when writing a batch", func() {
+		bw := NewMockBatchWriter(mockCtrl)
+		c = newSendPconn(packetConn, addr, bw)
+		testErr := errors.New("test error")
+		bw.EXPECT().WritePackets(gomock.Any(), addr, nil).Return(1, testErr)
+		_, err := c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+		Expect(err).To(MatchError(testErr))
+	})
+
+	It("falls back to writing packets one by one, if batching is not supported", func() {
+		bw := NewMockBatchWriter(mockCtrl)
+		c = newSendPconn(packetConn, addr, bw)
+		bw.EXPECT().WritePackets(gomock.Any(), addr, nil).Return(0, errBatchWriteUnsupported)
+		packetConn.EXPECT().WriteTo(gomock.Any(), addr).Times(2)
+		saved, err := c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(saved).To(BeZero())
+		// batching is not attempted again
+		packetConn.EXPECT().WriteTo(gomock.Any(), addr).Times(2)
+		_, err = c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("falls back to writing packets one by one while the connection is used for a different remote address", func() {
+		bw := NewMockBatchWriter(mockCtrl)
+		c = newSendPconn(packetConn, addr, bw)
+		bw.EXPECT().WritePackets(gomock.Any(), addr, nil).Return(0, errBatchWriteUnsupported).MaxTimes(1)
+		packetConn.EXPECT().WriteTo(gomock.Any(), addr).Times(2)
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			_, err := c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+			Expect(err).ToNot(HaveOccurred())
+		}()
+		newConn := c.WithRemoteAddr(&net.UDPAddr{IP: net.IPv4(192, 168, 100, 201), Port: 1338}, nil)
+		Expect(newConn).ToNot(BeNil())
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends packets without ECN marks, if the PacketConn can't send control messages", func() {
+		packetConn.EXPECT().WriteTo([]byte("foobar"), addr)
+		Expect(c.Write([]byte("foobar"), protocol.ECT0)).To(Succeed())
 	})
 
 	It("gets the remote address", func() {
 		Expect(c.RemoteAddr().String()).To(Equal("192.168.100.200:1337"))
 	})
 
+	It("sends packets to a different remote address", func() {
+		newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 201), Port: 1338}
+		newConn := c.WithRemoteAddr(newAddr, nil)
+		Expect(newConn.RemoteAddr()).To(Equal(newAddr))
+		packetConn.EXPECT().WriteTo([]byte("foobar"), newAddr)
+		Expect(newConn.Write([]byte("foobar"), protocol.ECNNon)).To(Succeed())
+		Expect(c.RemoteAddr()).To(Equal(addr))
+	})
+
 	It("gets the local address", func() {
 		addr := &net.UDPAddr{
 			IP:   net.IPv4(192, 168, 0, 1),
@@ -42,4 +121,69 @@ var _ = Describe("Connection (for sending packets)", func() {
 		packetConn.EXPECT().Close()
 		Expect(c.Close()).To(Succeed())
 	})
+
+	Context("capturing packets", func() {
+		type capturedPacket struct {
+			dir  PacketDirection
+			data []byte
+			addr net.Addr
+		}
+		var captured []capturedPacket
+
+		BeforeEach(func() {
+			captured = nil
+			c = newCaptureConn(c, func(dir PacketDirection, data []byte, addr net.Addr) {
+				captured = append(captured, capturedPacket{dir: dir, data: append([]byte{}, data...), addr: addr})
+			})
+		})
+
+		It("captures packets before writing them", func() {
+			packetConn.EXPECT().WriteTo([]byte("foobar"), addr).Do(func([]byte, net.Addr) {
+				Expect(captured).To(HaveLen(1))
+			})
+			Expect(c.Write([]byte("foobar"), protocol.ECNNon)).To(Succeed())
+			Expect(captured).To(Equal([]capturedPacket{{dir: PacketDirectionSent, data: []byte("foobar"), addr: addr}}))
+		})
+
+		It("captures packets written in a batch", func() {
+			packetConn.EXPECT().WriteTo(gomock.Any(), addr).Times(2)
+			_, err := c.WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECNNon)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(captured).To(Equal([]capturedPacket{
+				{dir: PacketDirectionSent, data: []byte("foo"), addr: addr},
+				{dir: PacketDirectionSent, data: []byte("bar"), addr: addr},
+			}))
+		})
+
+		It("captures packets sent to a different remote address", func() {
+			newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 201), Port: 1338}
+			newConn := c.WithRemoteAddr(newAddr, nil)
+			packetConn.EXPECT().WriteTo([]byte("foobar"), newAddr)
+			Expect(newConn.Write([]byte("foobar"), protocol.ECNNon)).To(Succeed())
+			Expect(captured).To(Equal([]capturedPacket{{dir: PacketDirectionSent, data: []byte("foobar"), addr: newAddr}}))
+		})
+	})
+
+	Context("comparing addresses", func() {
+		It("compares UDP addresses", func() {
+			a := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
+			Expect(equalAddr(a, &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337})).To(BeTrue())
+			Expect(equalAddr(a, &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200).To4(), Port: 1337})).To(BeTrue())
+			Expect(equalAddr(a, &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1338})).To(BeFalse())
+			Expect(equalAddr(a, &net.UDPAddr{IP: net.IPv4(192, 168, 100, 201), Port: 1337})).To(BeFalse())
+		})
+
+		It("doesn't allocate when comparing UDP addresses", func() {
+			a := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
+			b := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 201), Port: 1337}
+			Expect(testing.AllocsPerRun(100, func() { equalAddr(a, b) })).To(BeZero())
+		})
+
+		It("compares other addresses", func() {
+			a := &net.IPAddr{IP: net.IPv4(192, 168, 100, 200)}
+			Expect(equalAddr(a, &net.IPAddr{IP: net.IPv4(192, 168, 100, 200)})).To(BeTrue())
+			Expect(equalAddr(a, &net.IPAddr{IP: net.IPv4(192, 168, 100, 201)})).To(BeFalse())
+			Expect(equalAddr(a, &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200)})).To(BeFalse())
+		})
+	})
 })
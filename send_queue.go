@@ -1,23 +1,44 @@
 package quic
 
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
 type sender interface {
-	Send(p *packetBuffer)
+	Send(p *packetBuffer, ecn protocol.ECN)
 	Run() error
 	WouldBlock() bool
 	Available() <-chan struct{}
 	Close()
+	// SetConn replaces the connection used to send packets, e.g. when the connection is migrated to a new path.
+	// Packets that are still waiting in the queue are sent on the new connection.
+	SetConn(sendConn)
+	// SavedSyscalls returns the number of syscalls that were saved by sending packets in batches.
+	SavedSyscalls() uint64
 }
 
 type sendQueue struct {
-	queue       chan *packetBuffer
+	savedSyscalls uint64 // accessed atomically
+
+	queue       chan queueEntry
 	closeCalled chan struct{} // runStopped when Close() is called
 	runStopped  chan struct{} // runStopped when the run loop returns
 	available   chan struct{}
-	conn        sendConn
+
+	connMutex sync.Mutex
+	conn      sendConn
 }
 
 var _ sender = &sendQueue{}
 
+type queueEntry struct {
+	buf *packetBuffer
+	ecn protocol.ECN
+}
+
 const sendQueueCapacity = 8
 
 func newSendQueue(conn sendConn) sender {
@@ -26,16 +47,16 @@ func newSendQueue(conn sendConn) sender {
 		runStopped:  make(chan struct{}),
 		closeCalled: make(chan struct{}),
 		available:   make(chan struct{}, 1),
-		queue:       make(chan *packetBuffer, sendQueueCapacity),
+		queue:       make(chan queueEntry, sendQueueCapacity),
 	}
 }
 
 // Send sends out a packet. It's guaranteed to not block.
 // Callers need to make sure that there's actually space in the send queue by calling WouldBlock.
 // Otherwise Send will panic.
-func (h *sendQueue) Send(p *packetBuffer) {
+func (h *sendQueue) Send(p *packetBuffer, ecn protocol.ECN) {
 	select {
-	case h.queue <- p:
+	case h.queue <- queueEntry{buf: p, ecn: ecn}:
 	case <-h.runStopped:
 	default:
 		panic("sendQueue.Send would have blocked")
@@ -62,17 +83,10 @@ func (h *sendQueue) Run() error {
 			h.closeCalled = nil // prevent this case from being selected again
 			// make sure that all queued packets are actually sent out
 			shouldClose = true
-		case p := <-h.queue:
-			if err := h.conn.Write(p.Data); err != nil {
-				// This additional check enables:
-				// 1. Checking for "datagram too large" message from the kernel, as such,
-				// 2. Path MTU discovery,and
-				// 3. Eventual detection of loss PingFrame.
-				if !isMsgSizeErr(err) {
-					return err
-				}
+		case e := <-h.queue:
+			if err := h.sendBatches(e); err != nil {
+				return err
 			}
-			p.Release()
 			select {
 			case h.available <- struct{}{}:
 			default:
@@ -81,6 +95,75 @@ func (h *sendQueue) Run() error {
 	}
 }
 
+// sendBatches sends e, as well as all packets that are already waiting in the queue.
+// Only packets with the same ECN marking are sent in the same batch.
+func (h *sendQueue) sendBatches(e queueEntry) error {
+	for {
+		packets, next, ok := h.dequeueBatch(e)
+		if err := h.write(packets, e.ecn); err != nil {
+			// This additional check enables:
+			// 1. Checking for "datagram too large" message from the kernel, as such,
+			// 2. Path MTU discovery,and
+			// 3. Eventual detection of loss PingFrame.
+			if !isMsgSizeErr(err) {
+				return err
+			}
+		}
+		for _, p := range packets {
+			p.Release()
+		}
+		if !ok {
+			return nil
+		}
+		e = next
+	}
+}
+
+// dequeueBatch returns the packet of e, as well as all packets with the same ECN marking that are already waiting in the queue.
+// If it dequeues a packet with a different ECN marking, this packet is returned as next, and ok is true.
+func (h *sendQueue) dequeueBatch(e queueEntry) (packets []*packetBuffer, next queueEntry, ok bool) {
+	packets = []*packetBuffer{e.buf}
+	for len(packets) < sendQueueCapacity {
+		select {
+		case n := <-h.queue:
+			if n.ecn != e.ecn {
+				return packets, n, true
+			}
+			packets = append(packets, n.buf)
+		default:
+			return packets, queueEntry{}, false
+		}
+	}
+	return packets, queueEntry{}, false
+}
+
+func (h *sendQueue) write(packets []*packetBuffer, ecn protocol.ECN) error {
+	h.connMutex.Lock()
+	conn := h.conn
+	h.connMutex.Unlock()
+
+	if len(packets) == 1 {
+		return conn.Write(packets[0].Data, ecn)
+	}
+	data := make([][]byte, len(packets))
+	for i, p := range packets {
+		data[i] = p.Data
+	}
+	saved, err := conn.WritePackets(data, ecn)
+	atomic.AddUint64(&h.savedSyscalls, uint64(saved))
+	return err
+}
+
+func (h *sendQueue) SetConn(conn sendConn) {
+	h.connMutex.Lock()
+	h.conn = conn
+	h.connMutex.Unlock()
+}
+
+func (h *sendQueue) SavedSyscalls() uint64 {
+	return atomic.LoadUint64(&h.savedSyscalls)
+}
+
 func (h *sendQueue) Close() {
 	close(h.closeCalled)
 	// wait until the run loop returned
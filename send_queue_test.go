@@ -3,6 +3,8 @@ package quic
 import (
 	"errors"
 
+	"github.com/BGrewell/quic-go/internal/protocol"
+
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -26,10 +28,76 @@ var _ = Describe("Send Queue", func() {
 
 	It("sends a packet", func() {
 		p := getPacket([]byte("foobar"))
-		q.Send(p)
+		q.Send(p, protocol.ECT0)
+
+		written := make(chan struct{})
+		c.EXPECT().Write([]byte("foobar"), protocol.ECT0).Do(func([]byte, protocol.ECN) { close(written) })
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends packets on a new connection", func() {
+		q.Send(getPacket([]byte("foo")), protocol.ECNNon)
+		newConn := NewMockSendConn(mockCtrl)
+		q.SetConn(newConn)
+
+		written := make(chan struct{})
+		newConn.EXPECT().Write([]byte("foo"), protocol.ECNNon).Do(func([]byte, protocol.ECN) { close(written) })
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends multiple packets in a batch", func() {
+		q.Send(getPacket([]byte("foo")), protocol.ECNNon)
+		q.Send(getPacket([]byte("bar")), protocol.ECNNon)
+		q.Send(getPacket([]byte("baz")), protocol.ECNNon)
+
+		written := make(chan struct{})
+		c.EXPECT().WritePackets([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, protocol.ECNNon).DoAndReturn(func([][]byte, protocol.ECN) (int, error) {
+			close(written)
+			return 2, nil
+		})
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		Eventually(q.SavedSyscalls).Should(BeEquivalentTo(2))
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("only sends packets with the same ECN marking in a batch", func() {
+		q.Send(getPacket([]byte("foo")), protocol.ECT0)
+		q.Send(getPacket([]byte("bar")), protocol.ECT0)
+		q.Send(getPacket([]byte("baz")), protocol.ECNNon)
+		q.Send(getPacket([]byte("qux")), protocol.ECT0)
 
 		written := make(chan struct{})
-		c.EXPECT().Write([]byte("foobar")).Do(func([]byte) { close(written) })
+		gomock.InOrder(
+			c.EXPECT().WritePackets([][]byte{[]byte("foo"), []byte("bar")}, protocol.ECT0).Return(1, nil),
+			c.EXPECT().Write([]byte("baz"), protocol.ECNNon),
+			c.EXPECT().Write([]byte("qux"), protocol.ECT0).Do(func([]byte, protocol.ECN) { close(written) }),
+		)
 		done := make(chan struct{})
 		go func() {
 			defer GinkgoRecover()
@@ -45,19 +113,19 @@ var _ = Describe("Send Queue", func() {
 	It("panics when Send() is called although there's no space in the queue", func() {
 		for i := 0; i < sendQueueCapacity; i++ {
 			Expect(q.WouldBlock()).To(BeFalse())
-			q.Send(getPacket([]byte("foobar")))
+			q.Send(getPacket([]byte("foobar")), protocol.ECNNon)
 		}
 		Expect(q.WouldBlock()).To(BeTrue())
-		Expect(func() { q.Send(getPacket([]byte("raboof"))) }).To(Panic())
+		Expect(func() { q.Send(getPacket([]byte("raboof")), protocol.ECNNon) }).To(Panic())
 	})
 
 	It("signals when sending is possible again", func() {
 		Expect(q.WouldBlock()).To(BeFalse())
-		q.Send(getPacket([]byte("foobar1")))
+		q.Send(getPacket([]byte("foobar1")), protocol.ECNNon)
 		Consistently(q.Available()).ShouldNot(Receive())
 
 		// now start sending out packets. This should free up queue space.
-		c.EXPECT().Write(gomock.Any()).MinTimes(1).MaxTimes(2)
+		c.EXPECT().Write(gomock.Any(), gomock.Any()).MinTimes(1).MaxTimes(2)
 		done := make(chan struct{})
 		go func() {
 			defer GinkgoRecover()
@@ -67,7 +135,7 @@ var _ = Describe("Send Queue", func() {
 
 		Eventually(q.Available()).Should(Receive())
 		Expect(q.WouldBlock()).To(BeFalse())
-		Expect(func() { q.Send(getPacket([]byte("foobar2"))) }).ToNot(Panic())
+		Expect(func() { q.Send(getPacket([]byte("foobar2")), protocol.ECNNon) }).ToNot(Panic())
 
 		q.Close()
 		Eventually(done).Should(BeClosed())
@@ -83,15 +151,15 @@ var _ = Describe("Send Queue", func() {
 
 		// the run loop exits if there is a write error
 		testErr := errors.New("test error")
-		c.EXPECT().Write(gomock.Any()).Return(testErr)
-		q.Send(getPacket([]byte("foobar")))
+		c.EXPECT().Write(gomock.Any(), gomock.Any()).Return(testErr)
+		q.Send(getPacket([]byte("foobar")), protocol.ECNNon)
 		Eventually(done).Should(BeClosed())
 
 		sent := make(chan struct{})
 		go func() {
 			defer GinkgoRecover()
-			q.Send(getPacket([]byte("raboof")))
-			q.Send(getPacket([]byte("quux")))
+			q.Send(getPacket([]byte("raboof")), protocol.ECNNon)
+			q.Send(getPacket([]byte("quux")), protocol.ECNNon)
 			close(sent)
 		}()
 
@@ -100,7 +168,7 @@ var _ = Describe("Send Queue", func() {
 
 	It("blocks Close() until the packet has been sent out", func() {
 		written := make(chan []byte)
-		c.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p })
+		c.EXPECT().Write(gomock.Any(), gomock.Any()).Do(func(p []byte, _ protocol.ECN) { written <- p })
 		done := make(chan struct{})
 		go func() {
 			defer GinkgoRecover()
@@ -108,7 +176,7 @@ var _ = Describe("Send Queue", func() {
 			close(done)
 		}()
 
-		q.Send(getPacket([]byte("foobar")))
+		q.Send(getPacket([]byte("foobar")), protocol.ECNNon)
 
 		closed := make(chan struct{})
 		go func() {
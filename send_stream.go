@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
@@ -18,15 +19,20 @@ type sendStreamI interface {
 	SendStream
 	handleStopSendingFrame(*wire.StopSendingFrame)
 	hasData() bool
+	hasRetransmission() bool
 	popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool)
 	closeForShutdown(error)
 	updateSendWindow(protocol.ByteCount)
+	getPriority() uint8
+	setSessionWriteDeadline(time.Time)
 }
 
 type sendStream struct {
 	mutex sync.Mutex
 
 	numOutstandingFrames int64
+	numFramesPopped      uint64             // used by Flush to detect if sending makes progress
+	bytesOutstanding     protocol.ByteCount // data sent in STREAM frames that were neither acknowledged nor declared lost
 	retransmissionQueue  []*wire.StreamFrame
 
 	ctx       context.Context
@@ -36,6 +42,7 @@ type sendStream struct {
 	sender   streamSender
 
 	writeOffset protocol.ByteCount
+	priority    uint8
 
 	cancelWriteErr      error
 	closeForShutdownErr error
@@ -49,11 +56,19 @@ type sendStream struct {
 	dataForWriting []byte // during a Write() call, this slice is the part of p that still needs to be sent out
 	nextFrame      *wire.StreamFrame
 
-	writeChan chan struct{}
-	deadline  time.Time
+	writeChan       chan struct{}
+	writableChan    chan struct{} // signaled when TryWrite can write again, after it was blocked
+	deadline        time.Time
+	sessionDeadline time.Time // set by Session.SetWriteDeadline, applies in addition to deadline
 
 	flowController flowcontrol.StreamFlowController
 
+	sendBufferLimiter *sendBufferLimiter // nil if Config.MaxSendBufferBytes is not set
+	sendBufferBytes   protocol.ByteCount // the bytes of this stream that are accounted for at the sendBufferLimiter
+
+	tryWriteBlocked      int32 // set when TryWrite didn't write all data, accessed atomically
+	waitingForSendBuffer int32 // set when we're registered at the sendBufferLimiter, accessed atomically
+
 	version protocol.VersionNumber
 }
 
@@ -66,14 +81,18 @@ func newSendStream(
 	streamID protocol.StreamID,
 	sender streamSender,
 	flowController flowcontrol.StreamFlowController,
+	sendBufferLimiter *sendBufferLimiter,
 	version protocol.VersionNumber,
 ) *sendStream {
 	s := &sendStream{
-		streamID:       streamID,
-		sender:         sender,
-		flowController: flowController,
-		writeChan:      make(chan struct{}, 1),
-		version:        version,
+		streamID:          streamID,
+		sender:            sender,
+		flowController:    flowController,
+		sendBufferLimiter: sendBufferLimiter,
+		writeChan:         make(chan struct{}, 1),
+		writableChan:      make(chan struct{}, 1),
+		priority:          protocol.DefaultStreamPriority,
+		version:           version,
 	}
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	return s
@@ -87,17 +106,8 @@ func (s *sendStream) Write(p []byte) (int, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if s.finishedWriting {
-		return 0, fmt.Errorf("write on closed stream %d", s.streamID)
-	}
-	if s.canceledWrite {
-		return 0, s.cancelWriteErr
-	}
-	if s.closeForShutdownErr != nil {
-		return 0, s.closeForShutdownErr
-	}
-	if !s.deadline.IsZero() && !time.Now().Before(s.deadline) {
-		return 0, errDeadline
+	if err := s.checkWrite(); err != nil {
+		return 0, err
 	}
 	if len(p) == 0 {
 		return 0, nil
@@ -113,31 +123,23 @@ func (s *sendStream) Write(p []byte) (int, error) {
 	for {
 		var copied bool
 		var deadline time.Time
+		var deadlineChan <-chan time.Time
+		// sendBufferBlocked is non-nil if we can't buffer any more data, since the send buffer limit is reached
+		sendBufferBlocked := s.sendBufferBlockedChan()
 		// As soon as dataForWriting becomes smaller than a certain size x, we copy all the data to a STREAM frame (s.nextFrame),
 		// which can the be popped the next time we assemble a packet.
 		// This allows us to return Write() when all data but x bytes have been sent out.
 		// When the user now calls Close(), this is much more likely to happen before we popped that last STREAM frame,
 		// allowing us to set the FIN bit on that frame (instead of sending an empty STREAM frame with FIN).
-		if s.canBufferStreamFrame() && len(s.dataForWriting) > 0 {
-			if s.nextFrame == nil {
-				f := wire.GetStreamFrame()
-				f.Offset = s.writeOffset
-				f.StreamID = s.streamID
-				f.DataLenPresent = true
-				f.Data = f.Data[:len(s.dataForWriting)]
-				copy(f.Data, s.dataForWriting)
-				s.nextFrame = f
-			} else {
-				l := len(s.nextFrame.Data)
-				s.nextFrame.Data = s.nextFrame.Data[:l+len(s.dataForWriting)]
-				copy(s.nextFrame.Data[l:], s.dataForWriting)
-			}
+		if s.canBufferStreamFrame() && len(s.dataForWriting) > 0 && sendBufferBlocked == nil {
+			s.addToSendBuffer(protocol.ByteCount(len(s.dataForWriting)))
+			s.bufferStreamFrameData(s.dataForWriting)
 			s.dataForWriting = nil
 			bytesWritten = len(p)
 			copied = true
 		} else {
 			bytesWritten = len(p) - len(s.dataForWriting)
-			deadline = s.deadline
+			deadline = s.writeDeadline()
 			if !deadline.IsZero() {
 				if !time.Now().Before(deadline) {
 					s.dataForWriting = nil
@@ -148,6 +150,7 @@ func (s *sendStream) Write(p []byte) (int, error) {
 					defer deadlineTimer.Stop()
 				}
 				deadlineTimer.Reset(deadline)
+				deadlineChan = deadlineTimer.Chan()
 			}
 			if s.dataForWriting == nil || s.canceledWrite || s.closedForShutdown {
 				break
@@ -163,14 +166,11 @@ func (s *sendStream) Write(p []byte) (int, error) {
 			s.mutex.Lock()
 			break
 		}
-		if deadline.IsZero() {
-			<-s.writeChan
-		} else {
-			select {
-			case <-s.writeChan:
-			case <-deadlineTimer.Chan():
-				deadlineTimer.SetRead()
-			}
+		select {
+		case <-s.writeChan:
+		case <-sendBufferBlocked:
+		case <-deadlineChan:
+			deadlineTimer.SetRead()
 		}
 		s.mutex.Lock()
 	}
@@ -186,6 +186,188 @@ func (s *sendStream) Write(p []byte) (int, error) {
 	return bytesWritten, nil
 }
 
+func (s *sendStream) TryWrite(p []byte) (int, error) {
+	s.mutex.Lock()
+	if err := s.checkWrite(); err != nil {
+		s.mutex.Unlock()
+		return 0, err
+	}
+	if len(p) == 0 {
+		s.mutex.Unlock()
+		return 0, nil
+	}
+	var n protocol.ByteCount
+	// Don't interfere with a Write call that is blocked.
+	if s.dataForWriting == nil {
+		// Accept as much data as the peer allows us to send right away, but at least one packet worth of data.
+		limit := utils.MaxByteCount(s.flowController.SendWindowSize(), protocol.MaxPacketBufferSize)
+		if s.nextFrame != nil {
+			limit -= utils.MinByteCount(limit, s.nextFrame.DataLen())
+		}
+		n = limit
+		if s.sendBufferLimiter != nil {
+			n = utils.MinByteCount(n, s.sendBufferLimiter.Available())
+		}
+		n = utils.MinByteCount(n, protocol.ByteCount(len(p)))
+	}
+	if n > 0 {
+		s.addToSendBuffer(n)
+		s.bufferStreamFrameData(p[:n])
+	}
+	blocked := n < protocol.ByteCount(len(p))
+	var blockedBySendBuffer bool
+	if blocked {
+		atomic.StoreInt32(&s.tryWriteBlocked, 1)
+		blockedBySendBuffer = s.sendBufferLimiter != nil && s.sendBufferLimiter.Available() == 0
+	}
+	s.mutex.Unlock()
+
+	if blockedBySendBuffer {
+		s.waitForSendBuffer()
+	}
+	if n == 0 {
+		return 0, ErrWouldBlock
+	}
+	s.sender.onHasStreamData(s.streamID) // must be called without holding the mutex
+	return int(n), nil
+}
+
+func (s *sendStream) Writable() <-chan struct{} {
+	return s.writableChan
+}
+
+// signalWritable signals the writableChan, if the last call to TryWrite was blocked.
+// It doesn't acquire the mutex, and can therefore be called from the sendBufferLimiter.
+func (s *sendStream) signalWritable() {
+	if !atomic.CompareAndSwapInt32(&s.tryWriteBlocked, 1, 0) {
+		return
+	}
+	select {
+	case s.writableChan <- struct{}{}:
+	default:
+	}
+}
+
+// onWritable is called when the stream can accept more data from TryWrite.
+// If the send buffer limit is reached, the signal is deferred until the sendBufferLimiter has room again.
+func (s *sendStream) onWritable() {
+	if atomic.LoadInt32(&s.tryWriteBlocked) == 0 {
+		return
+	}
+	if s.sendBufferLimiter != nil && s.sendBufferLimiter.Available() == 0 {
+		s.waitForSendBuffer()
+		return
+	}
+	s.signalWritable()
+}
+
+// waitForSendBuffer makes the sendBufferLimiter signal the writableChan once it's not full any more.
+func (s *sendStream) waitForSendBuffer() {
+	if !atomic.CompareAndSwapInt32(&s.waitingForSendBuffer, 0, 1) {
+		return
+	}
+	s.sendBufferLimiter.NotifyWhenUnblocked(func() {
+		atomic.StoreInt32(&s.waitingForSendBuffer, 0)
+		s.signalWritable()
+	})
+}
+
+// checkWrite returns the error that a call to Write or TryWrite returns before writing any data.
+// It must be called with the mutex held.
+func (s *sendStream) checkWrite() error {
+	if s.finishedWriting {
+		return fmt.Errorf("write on closed stream %d", s.streamID)
+	}
+	if s.canceledWrite {
+		return s.cancelWriteErr
+	}
+	if s.closeForShutdownErr != nil {
+		return s.closeForShutdownErr
+	}
+	if deadline := s.writeDeadline(); !deadline.IsZero() && !time.Now().Before(deadline) {
+		return errDeadline
+	}
+	return nil
+}
+
+// bufferStreamFrameData appends data to the STREAM frame that is popped the next time we assemble a packet.
+// If the data doesn't fit into a pooled frame, the frame is replaced by a frame that is not taken from the pool.
+// It must be called with the mutex held.
+func (s *sendStream) bufferStreamFrameData(data []byte) {
+	if s.nextFrame == nil {
+		var f *wire.StreamFrame
+		if protocol.ByteCount(len(data)) <= protocol.MaxPacketBufferSize {
+			f = wire.GetStreamFrame()
+			f.Data = f.Data[:len(data)]
+		} else {
+			f = &wire.StreamFrame{Data: make([]byte, len(data))}
+		}
+		f.Offset = s.writeOffset
+		f.StreamID = s.streamID
+		f.DataLenPresent = true
+		copy(f.Data, data)
+		s.nextFrame = f
+		return
+	}
+	l := len(s.nextFrame.Data)
+	if l+len(data) > cap(s.nextFrame.Data) {
+		newCap := 2 * cap(s.nextFrame.Data)
+		if newCap < l+len(data) {
+			newCap = l + len(data)
+		}
+		f := &wire.StreamFrame{
+			StreamID:       s.nextFrame.StreamID,
+			Offset:         s.nextFrame.Offset,
+			DataLenPresent: true,
+			Data:           make([]byte, l, newCap),
+		}
+		copy(f.Data, s.nextFrame.Data)
+		s.nextFrame.PutBack()
+		s.nextFrame = f
+	}
+	s.nextFrame.Data = s.nextFrame.Data[:l+len(data)]
+	copy(s.nextFrame.Data[l:], data)
+}
+
+func (s *sendStream) Flush() error {
+	var (
+		flushErr        error
+		flushed         bool
+		numFramesPopped uint64
+	)
+	for {
+		s.mutex.Lock()
+		if s.canceledWrite {
+			s.mutex.Unlock()
+			return s.cancelWriteErr
+		}
+		if s.closeForShutdownErr != nil {
+			s.mutex.Unlock()
+			return s.closeForShutdownErr
+		}
+		hasNewData := s.nextFrame != nil || len(s.dataForWriting) > 0
+		hasUnsentData := hasNewData || len(s.retransmissionQueue) > 0 || (s.finishedWriting && !s.finSent)
+		// Retransmissions are not subject to flow control.
+		flowControlBlocked := hasNewData && len(s.retransmissionQueue) == 0 && s.flowController.SendWindowSize() == 0
+		// If the session didn't send any frame of this stream, flushing again won't help.
+		madeProgress := !flushed || s.numFramesPopped != numFramesPopped
+		numFramesPopped = s.numFramesPopped
+		s.mutex.Unlock()
+
+		if !hasUnsentData {
+			return nil
+		}
+		if flushErr != nil {
+			return flushErr
+		}
+		if flowControlBlocked || !madeProgress {
+			return ErrSendBlocked
+		}
+		flushErr = s.sender.flush()
+		flushed = true
+	}
+}
+
 func (s *sendStream) canBufferStreamFrame() bool {
 	var l protocol.ByteCount
 	if s.nextFrame != nil {
@@ -200,7 +382,9 @@ func (s *sendStream) popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Fr
 	s.mutex.Lock()
 	f, hasMoreData := s.popNewOrRetransmittedStreamFrame(maxBytes)
 	if f != nil {
+		s.numFramesPopped++
 		s.numOutstandingFrames++
+		s.bytesOutstanding += f.DataLen()
 	}
 	s.mutex.Unlock()
 
@@ -252,10 +436,23 @@ func (s *sendStream) popNewOrRetransmittedStreamFrame(maxBytes protocol.ByteCoun
 		return nil, true
 	}
 
+	// Data in the nextFrame was already accounted for when it was written.
+	// Data taken from dataForWriting is only accounted for when it is packed into a STREAM frame.
+	takesNewData := s.nextFrame == nil
+	if takesNewData && s.sendBufferLimiter != nil {
+		sendWindow = utils.MinByteCount(sendWindow, s.sendBufferLimiter.Available())
+		if sendWindow == 0 {
+			return nil, true
+		}
+	}
 	f, hasMoreData := s.popNewStreamFrame(maxBytes, sendWindow)
 	if dataLen := f.DataLen(); dataLen > 0 {
 		s.writeOffset += f.DataLen()
 		s.flowController.AddBytesSent(f.DataLen())
+		s.sender.onStreamDataSent(dataLen)
+		if takesNewData {
+			s.addToSendBuffer(dataLen)
+		}
 	}
 	f.Fin = s.finishedWriting && s.dataForWriting == nil && s.nextFrame == nil && !s.finSent
 	if f.Fin {
@@ -271,16 +468,23 @@ func (s *sendStream) popNewStreamFrame(maxBytes, sendWindow protocol.ByteCount)
 
 		maxDataLen := utils.MinByteCount(sendWindow, nextFrame.MaxDataLen(maxBytes, s.version))
 		if nextFrame.DataLen() > maxDataLen {
-			s.nextFrame = wire.GetStreamFrame()
+			if remaining := nextFrame.DataLen() - maxDataLen; remaining <= protocol.MaxPacketBufferSize {
+				s.nextFrame = wire.GetStreamFrame()
+				s.nextFrame.Data = s.nextFrame.Data[:remaining]
+				copy(s.nextFrame.Data, nextFrame.Data[maxDataLen:])
+			} else {
+				// Data buffered by TryWrite can exceed the size of a pooled frame.
+				s.nextFrame = &wire.StreamFrame{Data: nextFrame.Data[maxDataLen:]}
+			}
 			s.nextFrame.StreamID = s.streamID
 			s.nextFrame.Offset = s.writeOffset + maxDataLen
-			s.nextFrame.Data = s.nextFrame.Data[:nextFrame.DataLen()-maxDataLen]
 			s.nextFrame.DataLenPresent = true
-			copy(s.nextFrame.Data, nextFrame.Data[maxDataLen:])
 			nextFrame.Data = nextFrame.Data[:maxDataLen]
 		} else {
 			s.signalWrite()
 		}
+		// sending (parts of) the nextFrame makes room for data written by TryWrite
+		s.onWritable()
 		return nextFrame, s.nextFrame != nil || s.dataForWriting != nil
 	}
 
@@ -326,6 +530,13 @@ func (s *sendStream) hasData() bool {
 	return hasData
 }
 
+// hasRetransmission says if the stream has lost data that needs to be retransmitted
+func (s *sendStream) hasRetransmission() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.retransmissionQueue) > 0
+}
+
 func (s *sendStream) getDataForWriting(f *wire.StreamFrame, maxBytes protocol.ByteCount) {
 	if protocol.ByteCount(len(s.dataForWriting)) <= maxBytes {
 		f.Data = f.Data[:len(s.dataForWriting)]
@@ -343,17 +554,22 @@ func (s *sendStream) getDataForWriting(f *wire.StreamFrame, maxBytes protocol.By
 }
 
 func (s *sendStream) frameAcked(f wire.Frame) {
-	f.(*wire.StreamFrame).PutBack()
+	sf := f.(*wire.StreamFrame)
+	dataLen := sf.DataLen()
+	sf.PutBack()
+	s.sender.onStreamDataAcked(dataLen)
 
 	s.mutex.Lock()
 	if s.canceledWrite {
 		s.mutex.Unlock()
 		return
 	}
+	s.bytesOutstanding -= dataLen
 	s.numOutstandingFrames--
 	if s.numOutstandingFrames < 0 {
 		panic("numOutStandingFrames negative")
 	}
+	s.removeFromSendBuffer(dataLen)
 	newlyCompleted := s.isNewlyCompleted()
 	s.mutex.Unlock()
 
@@ -380,13 +596,15 @@ func (s *sendStream) queueRetransmission(f wire.Frame) {
 		return
 	}
 	s.retransmissionQueue = append(s.retransmissionQueue, sf)
+	s.bytesOutstanding -= sf.DataLen()
 	s.numOutstandingFrames--
 	if s.numOutstandingFrames < 0 {
 		panic("numOutStandingFrames negative")
 	}
+	priority := s.priority
 	s.mutex.Unlock()
 
-	s.sender.onHasStreamData(s.streamID)
+	s.sender.onHasStreamRetransmission(s.streamID, priority)
 }
 
 func (s *sendStream) Close() error {
@@ -422,11 +640,14 @@ func (s *sendStream) cancelWriteImpl(errorCode qerr.StreamErrorCode, writeErr er
 	s.canceledWrite = true
 	s.cancelWriteErr = writeErr
 	s.numOutstandingFrames = 0
+	s.bytesOutstanding = 0
 	s.retransmissionQueue = nil
+	s.removeFromSendBuffer(s.sendBufferBytes)
 	newlyCompleted := s.isNewlyCompleted()
 	s.mutex.Unlock()
 
 	s.signalWrite()
+	s.signalWritable()
 	s.sender.queueControlFrame(&wire.ResetStreamFrame{
 		StreamID:  s.streamID,
 		FinalSize: s.writeOffset,
@@ -455,6 +676,39 @@ func (s *sendStream) handleStopSendingFrame(frame *wire.StopSendingFrame) {
 	})
 }
 
+func (s *sendStream) BufferedBytes() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return uint64(s.unsentBytes() + s.bytesOutstanding)
+}
+
+func (s *sendStream) SetPriority(weight uint8) {
+	s.mutex.Lock()
+	s.priority = weight
+	s.mutex.Unlock()
+}
+
+func (s *sendStream) getPriority() uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.priority
+}
+
+// unsentBytes returns the number of bytes that were written, but not sent yet.
+// This includes data that was declared lost and needs to be retransmitted.
+func (s *sendStream) unsentBytes() protocol.ByteCount {
+	l := protocol.ByteCount(len(s.dataForWriting))
+	if s.nextFrame != nil {
+		l += s.nextFrame.DataLen()
+	}
+	for _, f := range s.retransmissionQueue {
+		l += f.DataLen()
+	}
+	return l
+}
+
 func (s *sendStream) Context() context.Context {
 	return s.ctx
 }
@@ -467,6 +721,50 @@ func (s *sendStream) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+func (s *sendStream) setSessionWriteDeadline(t time.Time) {
+	s.mutex.Lock()
+	s.sessionDeadline = t
+	s.mutex.Unlock()
+	s.signalWrite()
+}
+
+// writeDeadline returns the earlier of the stream's and the session's write deadline.
+// It must be called with the mutex held.
+func (s *sendStream) writeDeadline() time.Time {
+	return utils.MinNonZeroTime(s.deadline, s.sessionDeadline)
+}
+
+// sendBufferBlockedChan returns a channel that is closed when the send buffer limit allows buffering more data,
+// or nil if data can be buffered right away.
+// It must be called with the mutex held.
+func (s *sendStream) sendBufferBlockedChan() <-chan struct{} {
+	if s.sendBufferLimiter == nil {
+		return nil
+	}
+	return s.sendBufferLimiter.BlockedChan()
+}
+
+// addToSendBuffer accounts for data taken from the application.
+// It must be called with the mutex held.
+func (s *sendStream) addToSendBuffer(n protocol.ByteCount) {
+	if s.sendBufferLimiter == nil || s.canceledWrite || s.closedForShutdown {
+		return
+	}
+	s.sendBufferBytes += n
+	s.sendBufferLimiter.Add(n)
+}
+
+// removeFromSendBuffer is called when data was acknowledged, or doesn't need to be sent any more.
+// It must be called with the mutex held.
+func (s *sendStream) removeFromSendBuffer(n protocol.ByteCount) {
+	if s.sendBufferLimiter == nil {
+		return
+	}
+	n = utils.MinByteCount(n, s.sendBufferBytes)
+	s.sendBufferBytes -= n
+	s.sendBufferLimiter.Remove(n)
+}
+
 // CloseForShutdown closes a stream abruptly.
 // It makes Write unblock (and return the error) immediately.
 // The peer will NOT be informed about this: the stream is closed without sending a FIN or RST.
@@ -475,8 +773,10 @@ func (s *sendStream) closeForShutdown(err error) {
 	s.ctxCancel()
 	s.closedForShutdown = true
 	s.closeForShutdownErr = err
+	s.removeFromSendBuffer(s.sendBufferBytes)
 	s.mutex.Unlock()
 	s.signalWrite()
+	s.signalWritable()
 }
 
 // signalWrite performs a non-blocking send on the writeChan
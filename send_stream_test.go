@@ -32,7 +32,7 @@ var _ = Describe("Send Stream", func() {
 	BeforeEach(func() {
 		mockSender = NewMockStreamSender(mockCtrl)
 		mockFC = mocks.NewMockStreamFlowController(mockCtrl)
-		str = newSendStream(streamID, mockSender, mockFC, protocol.VersionWhatever)
+		str = newSendStream(streamID, mockSender, mockFC, nil, protocol.VersionWhatever)
 
 		timeout := scaleDuration(250 * time.Millisecond)
 		strWithTimeout = gbytes.TimeoutWriter(str, timeout)
@@ -85,6 +85,7 @@ var _ = Describe("Send Stream", func() {
 			waitForWrite()
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
 			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
 			f := frame.Frame.(*wire.StreamFrame)
 			Expect(f.Data).To(Equal([]byte("foobar")))
@@ -109,6 +110,7 @@ var _ = Describe("Send Stream", func() {
 			waitForWrite()
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(3)).Times(2)
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(3)).Times(2)
 			frame, _ := str.popStreamFrame(expectedFrameHeaderLen(0) + 3)
 			f := frame.Frame.(*wire.StreamFrame)
 			Expect(f.Offset).To(BeZero())
@@ -141,6 +143,7 @@ var _ = Describe("Send Stream", func() {
 			Eventually(done).Should(BeClosed()) // both Write calls returned without any data having been dequeued yet
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
 			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
 			f := frame.Frame.(*wire.StreamFrame)
 			Expect(f.Offset).To(BeZero())
@@ -152,6 +155,7 @@ var _ = Describe("Send Stream", func() {
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(5)
 			var totalBytesSent protocol.ByteCount
 			mockFC.EXPECT().AddBytesSent(gomock.Any()).Do(func(l protocol.ByteCount) { totalBytesSent += l }).Times(5)
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).Times(5)
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -186,12 +190,14 @@ var _ = Describe("Send Stream", func() {
 			waitForWrite()
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(2))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(2))
 			frame, hasMoreData := str.popStreamFrame(expectedFrameHeaderLen(0) + 2)
 			Expect(hasMoreData).To(BeTrue())
 			f := frame.Frame.(*wire.StreamFrame)
 			Expect(f.DataLen()).To(Equal(protocol.ByteCount(2)))
 			Consistently(done).ShouldNot(BeClosed())
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(1))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(1))
 			frame, hasMoreData = str.popStreamFrame(expectedFrameHeaderLen(1) + 1)
 			Expect(hasMoreData).To(BeTrue())
 			f = frame.Frame.(*wire.StreamFrame)
@@ -214,12 +220,14 @@ var _ = Describe("Send Stream", func() {
 			waitForWrite()
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(2))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(2))
 			frame, hasMoreData := str.popStreamFrame(expectedFrameHeaderLen(0) + 2)
 			Expect(hasMoreData).To(BeTrue())
 			f := frame.Frame.(*wire.StreamFrame)
 			Expect(f.Data).To(Equal([]byte("fo")))
 			Consistently(done).ShouldNot(BeClosed())
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(4))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(4))
 			frame, hasMoreData = str.popStreamFrame(expectedFrameHeaderLen(2) + 4)
 			Expect(hasMoreData).To(BeTrue())
 			f = frame.Frame.(*wire.StreamFrame)
@@ -246,6 +254,7 @@ var _ = Describe("Send Stream", func() {
 			waitForWrite()
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 			mockFC.EXPECT().AddBytesSent(gomock.Any()).Times(2)
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).Times(2)
 			frame, hasMoreData := str.popStreamFrame(50)
 			Expect(frame).ToNot(BeNil())
 			Expect(frame.Frame.(*wire.StreamFrame).Fin).To(BeFalse())
@@ -263,7 +272,9 @@ var _ = Describe("Send Stream", func() {
 			frameHeaderSize := protocol.ByteCount(4)
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(1))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(1))
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(2))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(2))
 			s := []byte("foo")
 			done := make(chan struct{})
 			go func() {
@@ -345,6 +356,7 @@ var _ = Describe("Send Stream", func() {
 				// first pop a STREAM frame of the maximum size allowed by flow control
 				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(3))
 				mockFC.EXPECT().AddBytesSent(protocol.ByteCount(3))
+				mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(3))
 				f, hasMoreData := str.popStreamFrame(expectedFrameHeaderLen(0) + 3)
 				Expect(f).ToNot(BeNil())
 				Expect(hasMoreData).To(BeTrue())
@@ -402,6 +414,7 @@ var _ = Describe("Send Stream", func() {
 			It("returns the number of bytes written, when the deadline expires", func() {
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				str.SetWriteDeadline(deadline)
 				var n int
@@ -426,6 +439,7 @@ var _ = Describe("Send Stream", func() {
 			It("doesn't pop any data after the deadline expired", func() {
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				str.SetWriteDeadline(deadline)
 				writeReturned := make(chan struct{})
@@ -516,6 +530,238 @@ var _ = Describe("Send Stream", func() {
 				str.closeForShutdown(errors.New("test done"))
 				Eventually(done).Should(BeClosed())
 			})
+
+			It("unblocks at the session's write deadline", func() {
+				mockSender.EXPECT().onHasStreamData(streamID)
+				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
+				str.setSessionWriteDeadline(deadline)
+				n, err := strWithTimeout.Write(getData(5000))
+				Expect(err).To(MatchError(errDeadline))
+				Expect(n).To(BeZero())
+				Expect(time.Now()).To(BeTemporally("~", deadline, scaleDuration(20*time.Millisecond)))
+			})
+
+			It("uses the earlier of the stream's and the session's write deadline", func() {
+				mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+				deadline1 := time.Now().Add(scaleDuration(50 * time.Millisecond))
+				deadline2 := time.Now().Add(scaleDuration(100 * time.Millisecond))
+				str.SetWriteDeadline(deadline2)
+				str.setSessionWriteDeadline(deadline1)
+				_, err := strWithTimeout.Write(getData(5000))
+				Expect(err).To(MatchError(errDeadline))
+				Expect(time.Now()).To(BeTemporally("~", deadline1, scaleDuration(20*time.Millisecond)))
+				// remove the session's write deadline, the stream's deadline still applies
+				str.setSessionWriteDeadline(time.Time{})
+				_, err = strWithTimeout.Write(getData(5000))
+				Expect(err).To(MatchError(errDeadline))
+				Expect(time.Now()).To(BeTemporally("~", deadline2, scaleDuration(20*time.Millisecond)))
+			})
+
+			It("unblocks when the session's write deadline is changed to the past", func() {
+				mockSender.EXPECT().onHasStreamData(streamID)
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					_, err := str.Write(getData(5000))
+					Expect(err).To(MatchError(errDeadline))
+					close(done)
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+				str.setSessionWriteDeadline(time.Now().Add(-time.Hour))
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
+		Context("non-blocking writes", func() {
+			It("writes data that can be buffered right away", func() {
+				mockSender.EXPECT().onHasStreamData(streamID)
+				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+				n, err := str.TryWrite([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(Equal(6))
+				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+				mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+				mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
+				frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+				Expect(frame).ToNot(BeNil())
+				Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foobar")))
+			})
+
+			It("buffers one packet worth of data when blocked by flow control, and returns ErrWouldBlock when the buffer is full", func() {
+				mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(0)).Times(3)
+				n, err := str.TryWrite([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(Equal(6))
+				n, err = str.TryWrite(getData(5000))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(BeEquivalentTo(protocol.MaxPacketBufferSize - 6))
+				n, err = str.TryWrite(getData(5000))
+				Expect(err).To(MatchError(ErrWouldBlock))
+				Expect(n).To(BeZero())
+				// once the data was sent, we can write again
+				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+				mockFC.EXPECT().AddBytesSent(protocol.MaxPacketBufferSize)
+				mockSender.EXPECT().onStreamDataSent(protocol.MaxPacketBufferSize)
+				frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+				Expect(frame).ToNot(BeNil())
+				Expect(frame.Frame.(*wire.StreamFrame).Data).To(HaveLen(int(protocol.MaxPacketBufferSize)))
+				mockSender.EXPECT().onHasStreamData(streamID)
+				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(0))
+				n, err = str.TryWrite(getData(5000))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(BeEquivalentTo(protocol.MaxPacketBufferSize))
+			})
+
+			It("writes data up to the send window", func() {
+				mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(4000)).Times(3)
+				data := getData(5000)
+				n, err := str.TryWrite(data[:1000])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(Equal(1000))
+				n, err = str.TryWrite(data[1000:])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(Equal(3000))
+				_, err = str.TryWrite(data[4000:])
+				Expect(err).To(MatchError(ErrWouldBlock))
+				// pop the data in multiple frames
+				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(4000)).Times(3)
+				mockFC.EXPECT().AddBytesSent(gomock.Any()).Times(3)
+				mockSender.EXPECT().onStreamDataSent(gomock.Any()).Times(3)
+				var frames []*wire.StreamFrame
+				for _, maxBytes := range []protocol.ByteCount{1000, protocol.MaxPacketBufferSize, protocol.MaxByteCount} {
+					frame, _ := str.popStreamFrame(maxBytes)
+					Expect(frame).ToNot(BeNil())
+					frames = append(frames, frame.Frame.(*wire.StreamFrame))
+				}
+				Expect(frames[0].Offset).To(BeZero())
+				Expect(frames[1].Offset).To(Equal(frames[0].DataLen()))
+				Expect(frames[2].Offset).To(Equal(frames[0].DataLen() + frames[1].DataLen()))
+				var popped []byte
+				for _, f := range frames {
+					popped = append(popped, f.Data...)
+				}
+				Expect(popped).To(Equal(data[:4000]))
+				Expect(str.nextFrame).To(BeNil())
+			})
+
+			It("returns ErrWouldBlock while a Write call is blocked", func() {
+				mockSender.EXPECT().onHasStreamData(streamID)
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					_, err := str.Write(getData(5000))
+					Expect(err).To(MatchError("test done"))
+				}()
+				waitForWrite()
+				_, err := str.TryWrite([]byte("foobar"))
+				Expect(err).To(MatchError(ErrWouldBlock))
+				str.closeForShutdown(errors.New("test done"))
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("doesn't write anything for empty slices", func() {
+				n, err := str.TryWrite(nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(BeZero())
+				Expect(str.nextFrame).To(BeNil())
+			})
+
+			It("returns an error when the deadline has expired", func() {
+				str.SetWriteDeadline(time.Now().Add(-time.Second))
+				n, err := str.TryWrite([]byte("foobar"))
+				Expect(err).To(MatchError(errDeadline))
+				Expect(n).To(BeZero())
+			})
+
+			It("returns an error after the stream has been closed", func() {
+				mockSender.EXPECT().onHasStreamData(streamID)
+				str.Close()
+				_, err := str.TryWrite([]byte("foobar"))
+				Expect(err).To(MatchError("write on closed stream 1337"))
+			})
+
+			It("returns an error after the stream has been canceled", func() {
+				mockSender.EXPECT().queueControlFrame(gomock.Any())
+				mockSender.EXPECT().onStreamCompleted(streamID)
+				str.CancelWrite(1234)
+				_, err := str.TryWrite([]byte("foobar"))
+				Expect(err).To(MatchError("Write on stream 1337 canceled with error code 1234"))
+			})
+
+			Context("writability notifications", func() {
+				BeforeEach(func() {
+					mockFC.EXPECT().SendWindowSize().Return(protocol.MaxPacketBufferSize).AnyTimes()
+					mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+					mockSender.EXPECT().onStreamDataSent(gomock.Any()).AnyTimes()
+				})
+
+				It("signals when data can be written after a partial write", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					n, err := str.TryWrite(getData(5000))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(protocol.MaxPacketBufferSize))
+					Expect(str.Writable()).ToNot(Receive())
+					frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+					Expect(frame).ToNot(BeNil())
+					Expect(str.Writable()).To(Receive())
+				})
+
+				It("signals when data can be written after TryWrite returned ErrWouldBlock", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.TryWrite(getData(protocol.MaxPacketBufferSize))
+					Expect(err).ToNot(HaveOccurred())
+					_, err = str.TryWrite([]byte("foobar"))
+					Expect(err).To(MatchError(ErrWouldBlock))
+					frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+					Expect(frame).ToNot(BeNil())
+					Expect(str.Writable()).To(Receive())
+				})
+
+				It("doesn't signal if all data was written", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.TryWrite([]byte("foobar"))
+					Expect(err).ToNot(HaveOccurred())
+					frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+					Expect(frame).ToNot(BeNil())
+					Expect(str.Writable()).ToNot(Receive())
+				})
+
+				It("is edge-triggered", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.TryWrite(getData(5000))
+					Expect(err).ToNot(HaveOccurred())
+					// pop the data in two frames
+					frame, _ := str.popStreamFrame(500)
+					Expect(frame).ToNot(BeNil())
+					frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+					Expect(frame).ToNot(BeNil())
+					Expect(str.Writable()).To(Receive())
+					Expect(str.Writable()).ToNot(Receive())
+				})
+
+				It("signals when the stream is canceled", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.TryWrite(getData(5000))
+					Expect(err).ToNot(HaveOccurred())
+					mockSender.EXPECT().queueControlFrame(gomock.Any())
+					mockSender.EXPECT().onStreamCompleted(streamID)
+					str.CancelWrite(1234)
+					Expect(str.Writable()).To(Receive())
+					_, err = str.TryWrite([]byte("foobar"))
+					Expect(err).To(MatchError("Write on stream 1337 canceled with error code 1234"))
+				})
+
+				It("signals when the stream is closed for shutdown", func() {
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.TryWrite(getData(5000))
+					Expect(err).ToNot(HaveOccurred())
+					str.closeForShutdown(errors.New("shutdown"))
+					Expect(str.Writable()).To(Receive())
+				})
+			})
 		})
 
 		Context("closing", func() {
@@ -546,6 +792,7 @@ var _ = Describe("Send Stream", func() {
 				Expect(str.Close()).To(Succeed())
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
 				mockFC.EXPECT().AddBytesSent(gomock.Any()).Times(2)
+				mockSender.EXPECT().onStreamDataSent(gomock.Any()).Times(2)
 				frame, _ := str.popStreamFrame(3 + frameHeaderLen)
 				Expect(frame).ToNot(BeNil())
 				f := frame.Frame.(*wire.StreamFrame)
@@ -572,6 +819,7 @@ var _ = Describe("Send Stream", func() {
 				for i := 1; i <= 5; i++ {
 					mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 					mockFC.EXPECT().AddBytesSent(gomock.Any())
+					mockSender.EXPECT().onStreamDataSent(gomock.Any())
 					if i == 5 {
 						Eventually(done).Should(BeClosed())
 					}
@@ -622,6 +870,7 @@ var _ = Describe("Send Stream", func() {
 			It("doesn't get data for writing if an error occurred", func() {
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				mockSender.EXPECT().onHasStreamData(streamID)
 				done := make(chan struct{})
 				go func() {
@@ -698,6 +947,7 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onStreamCompleted(streamID).MaxTimes(1)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).MaxTimes(1)
 				mockFC.EXPECT().AddBytesSent(gomock.Any()).MaxTimes(1)
+				mockSender.EXPECT().onStreamDataSent(gomock.Any()).MaxTimes(1)
 				errChan := make(chan error)
 				go func() {
 					defer GinkgoRecover()
@@ -720,6 +970,7 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onHasStreamData(streamID)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				writeReturned := make(chan struct{})
 				var n int
 				go func() {
@@ -743,6 +994,7 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onHasStreamData(streamID)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				writeReturned := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
@@ -766,6 +1018,7 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onHasStreamData(streamID)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				writeReturned := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
@@ -790,6 +1043,7 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onHasStreamData(streamID)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
+				mockSender.EXPECT().onStreamDataSent(gomock.Any())
 				writeReturned := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
@@ -802,6 +1056,7 @@ var _ = Describe("Send Stream", func() {
 				Expect(frame).ToNot(BeNil())
 				mockSender.EXPECT().onStreamCompleted(streamID)
 				str.CancelWrite(1234)
+				mockSender.EXPECT().onStreamDataAcked(gomock.Any())
 				frame.OnAcked(frame.Frame)
 			})
 
@@ -900,7 +1155,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
 			str.queueRetransmission(f)
 			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
 			Expect(frame).ToNot(BeNil())
@@ -910,6 +1165,17 @@ var _ = Describe("Send Stream", func() {
 			Expect(f.DataLenPresent).To(BeTrue())
 		})
 
+		It("says if it has retransmissions", func() {
+			str.numOutstandingFrames = 1
+			Expect(str.hasRetransmission()).To(BeFalse())
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
+			str.queueRetransmission(&wire.StreamFrame{Data: []byte("foobar"), Offset: 0x42})
+			Expect(str.hasRetransmission()).To(BeTrue())
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.hasRetransmission()).To(BeFalse())
+		})
+
 		It("splits a retransmission", func() {
 			str.numOutstandingFrames = 1
 			sf := &wire.StreamFrame{
@@ -917,7 +1183,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
 			str.queueRetransmission(sf)
 			frame, hasMoreData := str.popStreamFrame(sf.Length(str.version) - 3)
 			Expect(frame).ToNot(BeNil())
@@ -941,7 +1207,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
 			str.queueRetransmission(f)
 			frame, hasMoreData := str.popStreamFrame(2)
 			Expect(hasMoreData).To(BeTrue())
@@ -952,6 +1218,7 @@ var _ = Describe("Send Stream", func() {
 			mockSender.EXPECT().onHasStreamData(streamID)
 			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(9999))
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -966,7 +1233,7 @@ var _ = Describe("Send Stream", func() {
 			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foobar")))
 
 			// now lose the frame
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
 			frame.OnLost(frame.Frame)
 			newFrame, _ := str.popStreamFrame(protocol.MaxByteCount)
 			Expect(newFrame).ToNot(BeNil())
@@ -977,6 +1244,7 @@ var _ = Describe("Send Stream", func() {
 			mockSender.EXPECT().onHasStreamData(streamID)
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -992,20 +1260,366 @@ var _ = Describe("Send Stream", func() {
 				mockSender.EXPECT().onStreamCompleted(streamID),
 			)
 			str.CancelWrite(9876)
-			// don't EXPECT any calls to onHasStreamData
+			// don't EXPECT any calls to onHasStreamRetransmission
 			f.OnLost(f.Frame)
 			Expect(str.retransmissionQueue).To(BeEmpty())
 		})
 	})
 
+	Context("priorities", func() {
+		It("uses the default priority", func() {
+			Expect(str.getPriority()).To(Equal(protocol.DefaultStreamPriority))
+		})
+
+		It("sets the priority", func() {
+			str.SetPriority(100)
+			Expect(str.getPriority()).To(Equal(uint8(100)))
+		})
+	})
+
+	Context("buffered bytes", func() {
+		It("counts data that can't be sent because the peer doesn't increase the flow control window", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(4))
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(4))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(4))
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				_, err := strWithTimeout.Write([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				close(done)
+			}()
+			waitForWrite()
+			Eventually(done).Should(BeClosed())
+			Expect(str.BufferedBytes()).To(BeEquivalentTo(6))
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foob")))
+			// the peer is stalled, the flow control window is used up
+			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(0))
+			mockFC.EXPECT().IsNewlyBlocked().Return(true, protocol.ByteCount(4))
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			f, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(f).To(BeNil())
+			Expect(str.BufferedBytes()).To(BeEquivalentTo(6))
+			Expect(str.unsentBytes()).To(Equal(protocol.ByteCount(2)))
+			Expect(str.bytesOutstanding).To(Equal(protocol.ByteCount(4)))
+			// acknowledge the frame
+			mockSender.EXPECT().onStreamDataAcked(protocol.ByteCount(4))
+			frame.OnAcked(frame.Frame)
+			Expect(str.BufferedBytes()).To(BeEquivalentTo(2))
+			Expect(str.unsentBytes()).To(Equal(protocol.ByteCount(2)))
+		})
+
+		It("counts lost data as unsent", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				_, err := strWithTimeout.Write([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				close(done)
+			}()
+			waitForWrite()
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Eventually(done).Should(BeClosed())
+			Expect(str.BufferedBytes()).To(BeEquivalentTo(6))
+			Expect(str.unsentBytes()).To(BeZero())
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
+			frame.OnLost(frame.Frame)
+			Expect(str.BufferedBytes()).To(BeEquivalentTo(6))
+			Expect(str.unsentBytes()).To(Equal(protocol.ByteCount(6)))
+			frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.unsentBytes()).To(BeZero())
+			mockSender.EXPECT().onStreamDataAcked(protocol.ByteCount(6))
+			frame.OnAcked(frame.Frame)
+			Expect(str.BufferedBytes()).To(BeZero())
+		})
+
+		It("doesn't count any data after the stream was canceled", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				_, err := strWithTimeout.Write([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				close(done)
+			}()
+			waitForWrite()
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Eventually(done).Should(BeClosed())
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			str.CancelWrite(1234)
+			Expect(str.BufferedBytes()).To(BeZero())
+		})
+	})
+
+	Context("send buffer limit", func() {
+		var limiter *sendBufferLimiter
+
+		BeforeEach(func() {
+			limiter = newSendBufferLimiter(10)
+			str = newSendStream(streamID, mockSender, mockFC, limiter, protocol.VersionWhatever)
+			strWithTimeout = gbytes.TimeoutWriter(str, scaleDuration(250*time.Millisecond))
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
+			mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).AnyTimes()
+			mockSender.EXPECT().onStreamDataAcked(gomock.Any()).AnyTimes()
+		})
+
+		It("accounts for written data until it is acknowledged", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(6)))
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(6)))
+			// a lost frame still needs to be retransmitted
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
+			frame.OnLost(frame.Frame)
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(6)))
+			frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			frame.OnAcked(frame.Frame)
+			Expect(limiter.Bytes()).To(BeZero())
+		})
+
+		It("blocks Write when the limit is reached, until data is acknowledged", func() {
+			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			_, err := strWithTimeout.Write([]byte("foobarfoobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(12)))
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := str.Write([]byte("baz"))
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			Consistently(done).ShouldNot(BeClosed())
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foobarfoobar")))
+			Consistently(done).ShouldNot(BeClosed())
+			frame.OnAcked(frame.Frame)
+			Eventually(done).Should(BeClosed())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(3)))
+		})
+
+		It("only sends data up to the limit", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := str.Write(getData(5000))
+				Expect(err).To(MatchError("Write on stream 1337 canceled with error code 1234"))
+			}()
+			waitForWrite()
+			frame, hasMoreData := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal(getData(10)))
+			Expect(hasMoreData).To(BeTrue())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(10)))
+			f, hasMoreData := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(f).To(BeNil())
+			Expect(hasMoreData).To(BeTrue())
+			frame.OnAcked(frame.Frame)
+			Expect(limiter.Bytes()).To(BeZero())
+			frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal(getDataAtOffset(10, 10)))
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(10)))
+			// canceling the stream removes all its data from the send buffer
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			str.CancelWrite(1234)
+			Eventually(done).Should(BeClosed())
+			Expect(limiter.Bytes()).To(BeZero())
+		})
+
+		It("unblocks Write at the deadline, when the limit is reached", func() {
+			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			_, err := strWithTimeout.Write(getData(10))
+			Expect(err).ToNot(HaveOccurred())
+			deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
+			str.SetWriteDeadline(deadline)
+			n, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).To(MatchError(errDeadline))
+			Expect(n).To(BeZero())
+			Expect(time.Now()).To(BeTemporally("~", deadline, scaleDuration(20*time.Millisecond)))
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(10)))
+		})
+
+		It("only accepts data up to the limit in non-blocking writes", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			n, err := str.TryWrite(getData(20))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(10))
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(10)))
+			_, err = str.TryWrite(getData(20))
+			Expect(err).To(MatchError(ErrWouldBlock))
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			frame.OnAcked(frame.Frame)
+			mockSender.EXPECT().onHasStreamData(streamID)
+			n, err = str.TryWrite(getData(20))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(10))
+		})
+
+		It("signals writability once the send buffer limit allows writing more data", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			n, err := str.TryWrite(getData(20))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(10))
+			// sending the data doesn't make room in the send buffer
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.Writable()).ToNot(Receive())
+			frame.OnAcked(frame.Frame)
+			Expect(str.Writable()).To(Receive())
+		})
+
+		It("signals all streams that are blocked by the send buffer limit", func() {
+			str2 := newSendStream(streamID+4, mockSender, mockFC, limiter, protocol.VersionWhatever)
+			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			_, err := str.TryWrite(getData(8))
+			Expect(err).ToNot(HaveOccurred())
+			n, err := str.TryWrite(getData(protocol.MaxPacketBufferSize))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(2))
+			// the other stream is blocked, since the send buffer is full
+			n, err = str2.TryWrite(getData(20))
+			Expect(err).To(MatchError(ErrWouldBlock))
+			Expect(n).To(BeZero())
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.Writable()).ToNot(Receive())
+			Expect(str2.Writable()).ToNot(Receive())
+			frame.OnAcked(frame.Frame)
+			Expect(str.Writable()).To(Receive())
+			Expect(str2.Writable()).To(Receive())
+		})
+
+		It("removes the data from the send buffer when the stream is closed for shutdown", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limiter.Bytes()).To(Equal(protocol.ByteCount(6)))
+			str.closeForShutdown(errors.New("shutdown"))
+			Expect(limiter.Bytes()).To(BeZero())
+		})
+	})
+
+	Context("flushing", func() {
+		It("doesn't flush if there's no data", func() {
+			Expect(str.Flush()).To(Succeed())
+		})
+
+		It("flushes buffered data", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			mockSender.EXPECT().onStreamDataSent(protocol.ByteCount(6))
+			var frame *ackhandler.Frame
+			mockSender.EXPECT().flush().DoAndReturn(func() error {
+				frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+				return nil
+			})
+			Expect(str.Flush()).To(Succeed())
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foobar")))
+		})
+
+		It("flushes a FIN", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			Expect(str.Close()).To(Succeed())
+			var frame *ackhandler.Frame
+			mockSender.EXPECT().flush().DoAndReturn(func() error {
+				frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+				return nil
+			})
+			Expect(str.Flush()).To(Succeed())
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.Frame.(*wire.StreamFrame).Fin).To(BeTrue())
+		})
+
+		It("returns ErrSendBlocked if the flow control window is used up", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(0))
+			Expect(str.Flush()).To(MatchError(ErrSendBlocked))
+		})
+
+		It("returns the error from the session, if the data couldn't be sent", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
+			mockSender.EXPECT().flush().Return(ErrSendBlocked)
+			Expect(str.Flush()).To(MatchError(ErrSendBlocked))
+		})
+
+		It("returns ErrSendBlocked if the session doesn't send any data", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).Times(2)
+			mockSender.EXPECT().flush()
+			Expect(str.Flush()).To(MatchError(ErrSendBlocked))
+		})
+
+		It("keeps flushing as long as data is sent", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
+			mockFC.EXPECT().AddBytesSent(gomock.Any()).Times(2)
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).Times(2)
+			var frames []*ackhandler.Frame
+			mockSender.EXPECT().flush().DoAndReturn(func() error {
+				frame, _ := str.popStreamFrame(expectedFrameHeaderLen(protocol.ByteCount(3*len(frames))) + 3)
+				frames = append(frames, frame)
+				return nil
+			}).Times(2)
+			Expect(str.Flush()).To(Succeed())
+			Expect(frames).To(HaveLen(2))
+		})
+
+		It("returns the error if the stream was canceled", func() {
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			str.CancelWrite(1234)
+			Expect(str.Flush()).To(MatchError("Write on stream 1337 canceled with error code 1234"))
+		})
+	})
+
 	Context("determining when a stream is completed", func() {
 		BeforeEach(func() {
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
 			mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).AnyTimes()
 		})
 
 		It("says when a stream is completed", func() {
 			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onStreamDataAcked(gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -1046,6 +1660,7 @@ var _ = Describe("Send Stream", func() {
 
 		It("says when a stream is completed, if Close() is called before popping the frame", func() {
 			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			mockSender.EXPECT().onStreamDataAcked(protocol.ByteCount(100))
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -1068,6 +1683,7 @@ var _ = Describe("Send Stream", func() {
 
 		It("doesn't say it's completed when there are frames waiting to be retransmitted", func() {
 			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onStreamDataAcked(gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -1097,7 +1713,7 @@ var _ = Describe("Send Stream", func() {
 			for _, f := range frames[1:] {
 				f.OnAcked(f.Frame)
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, protocol.DefaultStreamPriority)
 			frames[0].OnLost(frames[0].Frame)
 
 			// get the retransmission and acknowledge it
@@ -1114,10 +1730,14 @@ var _ = Describe("Send Stream", func() {
 		It("retransmits data until everything has been acknowledged", func() {
 			const dataLen = 1 << 22 // 4 MB
 			mockSender.EXPECT().onHasStreamData(streamID).AnyTimes()
+			mockSender.EXPECT().onHasStreamRetransmission(streamID, gomock.Any()).AnyTimes()
 			mockFC.EXPECT().SendWindowSize().DoAndReturn(func() protocol.ByteCount {
 				return protocol.ByteCount(mrand.Intn(500)) + 50
 			}).AnyTimes()
 			mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+			mockSender.EXPECT().onStreamDataSent(gomock.Any()).AnyTimes()
+			var bytesAcked protocol.ByteCount
+			mockSender.EXPECT().onStreamDataAcked(gomock.Any()).Do(func(n protocol.ByteCount) { bytesAcked += n }).AnyTimes()
 
 			data := make([]byte, dataLen)
 			_, err := mrand.Read(data)
@@ -1154,6 +1774,8 @@ var _ = Describe("Send Stream", func() {
 				}
 			}
 			Expect(received).To(Equal(data))
+			// data is only counted once, no matter how often it was retransmitted
+			Expect(bytesAcked).To(Equal(protocol.ByteCount(dataLen)))
 		})
 	})
 })
@@ -30,6 +30,7 @@ type packetHandler interface {
 
 type unknownPacketHandler interface {
 	handlePacket(*receivedPacket)
+	handleUnknownConnectionID(protocol.ConnectionID, net.Addr) UnknownConnectionIDAction
 	setCloseError(error)
 }
 
@@ -62,11 +63,14 @@ type baseServer struct {
 	config  *Config
 
 	conn connection
+	// Used to send packets in batches, see Config.DisableBatchedWrites. nil if that's not possible.
+	batchWriter batchWriter
 	// If the server is started with ListenAddr, we create a packet conn.
 	// If it is started with Listen, we take a packet conn as a parameter.
 	createdPacketConn bool
 
 	tokenGenerator *handshake.TokenGenerator
+	streamLimiter  *streamLimiter // limits the number of incoming streams across all sessions, may be nil
 
 	sessionHandler packetHandlerManager
 
@@ -86,6 +90,7 @@ type baseServer struct {
 		*tls.Config,
 		*handshake.TokenGenerator,
 		bool, /* enable 0-RTT */
+		*streamLimiter,
 		logging.ConnectionTracer,
 		uint64,
 		utils.Logger,
@@ -97,6 +102,13 @@ type baseServer struct {
 	closed      bool
 	running     chan struct{} // closed as soon as run() returns
 
+	// set when Shutdown is called. No new sessions are created after that.
+	shuttingDown bool
+	// the number of sessions whose run loop hasn't returned yet
+	numRunningSessions int
+	// closed when shutting down, as soon as all sessions have finished
+	sessionsFinished chan struct{}
+
 	sessionQueue    chan quicSession
 	sessionQueueLen int32 // to be used as an atomic
 
@@ -137,7 +149,7 @@ func listenAddr(addr string, tlsConf *tls.Config, config *Config, acceptEarly bo
 	if err != nil {
 		return nil, err
 	}
-	conn, err := net.ListenUDP("udp", udpAddr)
+	conn, err := listenUDP(udpAddr, config != nil && config.ReusePort)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +161,20 @@ func listenAddr(addr string, tlsConf *tls.Config, config *Config, acceptEarly bo
 	return serv, nil
 }
 
+// listenUDP creates the UDP socket for listenAddr.
+// If reusePort is set, SO_REUSEPORT is set on the socket before binding it.
+func listenUDP(addr *net.UDPAddr, reusePort bool) (*net.UDPConn, error) {
+	if !reusePort {
+		return net.ListenUDP("udp", addr)
+	}
+	lc := net.ListenConfig{Control: setReusePort}
+	conn, err := lc.ListenPacket(context.Background(), "udp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UDPConn), nil
+}
+
 // Listen listens for QUIC connections on a given net.PacketConn. If the
 // PacketConn satisfies the OOBCapablePacketConn interface (as a net.UDPConn
 // does), ECN and packet info support will be enabled. In this case, ReadMsgUDP
@@ -187,7 +213,7 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		}
 	}
 
-	sessionHandler, err := getMultiplexer().AddConn(conn, config.ConnectionIDLength, config.StatelessResetKey, config.Tracer)
+	sessionHandler, err := getMultiplexer().AddConn(conn, config.ConnectionIDLength, config.StatelessResetKey, config.ReceiveBufferSize, config.Tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -199,8 +225,13 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 	if err != nil {
 		return nil, err
 	}
+	var bw batchWriter
+	if !config.DisableBatchedWrites {
+		bw = newBatchWriter(conn)
+	}
 	s := &baseServer{
 		conn:                c,
+		batchWriter:         bw,
 		tlsConf:             tlsConf,
 		config:              config,
 		tokenGenerator:      tokenGenerator,
@@ -210,9 +241,12 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		running:             make(chan struct{}),
 		receivedPackets:     make(chan *receivedPacket, protocol.MaxServerUnprocessedPackets),
 		newSession:          newSession,
-		logger:              utils.DefaultLogger.WithPrefix("server"),
+		logger:              newLogger(config.Logger).WithPrefix("server"),
 		acceptEarlySessions: acceptEarly,
 	}
+	if config.MaxTotalStreams > 0 {
+		s.streamLimiter = newStreamLimiter(config.MaxTotalStreams)
+	}
 	go s.run()
 	sessionHandler.SetServer(s)
 	s.logger.Debugf("Listening for %s connections on %s", conn.LocalAddr().Network(), conn.LocalAddr().String())
@@ -301,6 +335,60 @@ func (s *baseServer) Close() error {
 	return nil
 }
 
+// Shutdown gracefully shuts down the server.
+// It stops accepting new connections, and waits until all sessions have finished,
+// including sessions that were still handshaking when Shutdown was called.
+// Sessions that complete the handshake are still returned by Accept.
+// If the context is canceled before all sessions have finished, the remaining sessions are closed,
+// and the context's error is returned.
+// In any case, the server is closed when Shutdown returns.
+func (s *baseServer) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	if !s.shuttingDown {
+		s.shuttingDown = true
+		s.sessionsFinished = make(chan struct{})
+		if s.numRunningSessions == 0 {
+			close(s.sessionsFinished)
+		}
+	}
+	sessionsFinished := s.sessionsFinished
+	s.mutex.Unlock()
+
+	select {
+	case <-sessionsFinished:
+		return s.Close()
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+// addRunningSession registers a new session, unless the server is shutting down.
+func (s *baseServer) addRunningSession() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+	s.numRunningSessions++
+	return true
+}
+
+func (s *baseServer) removeRunningSession() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.numRunningSessions--
+	if s.shuttingDown && s.numRunningSessions == 0 {
+		close(s.sessionsFinished)
+	}
+}
+
 func (s *baseServer) setCloseError(e error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -312,6 +400,13 @@ func (s *baseServer) setCloseError(e error) {
 	close(s.errorChan)
 }
 
+func (s *baseServer) handleUnknownConnectionID(connID protocol.ConnectionID, addr net.Addr) UnknownConnectionIDAction {
+	if s.config.OnUnknownConnectionID == nil {
+		return UnknownConnectionIDSendStatelessReset
+	}
+	return s.config.OnUnknownConnectionID(connID, addr)
+}
+
 // Addr returns the server's network address
 func (s *baseServer) Addr() net.Addr {
 	return s.conn.LocalAddr()
@@ -322,27 +417,32 @@ func (s *baseServer) handlePacket(p *receivedPacket) {
 	case s.receivedPackets <- p:
 	default:
 		s.logger.Debugf("Dropping packet from %s (%d bytes). Server receive queue full.", p.remoteAddr, p.Size())
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropDOSPrevention)
-		}
+		s.droppedPacket(p, logging.PacketTypeNotDetermined, logging.PacketDropDOSPrevention, nil)
+	}
+}
+
+// droppedPacket reports a dropped packet to the tracer and to Config.OnDroppedPacket.
+// The header is nil if the packet was dropped before its header was parsed.
+func (s *baseServer) droppedPacket(p *receivedPacket, typ logging.PacketType, reason logging.PacketDropReason, hdr *wire.Header) {
+	if s.config.Tracer != nil {
+		s.config.Tracer.DroppedPacket(p.remoteAddr, typ, p.Size(), reason)
+	}
+	if s.config.OnDroppedPacket != nil {
+		s.config.OnDroppedPacket(reason, hdr)
 	}
 }
 
 func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer still in use? */ {
 	if wire.IsVersionNegotiationPacket(p.data) {
 		s.logger.Debugf("Dropping Version Negotiation packet.")
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(p, logging.PacketTypeVersionNegotiation, logging.PacketDropUnexpectedPacket, nil)
 		return false
 	}
 	// If we're creating a new session, the packet will be passed to the session.
 	// The header will then be parsed again.
 	hdr, _, _, err := wire.ParsePacket(p.data, s.config.ConnectionIDLength)
 	if err != nil && err != wire.ErrUnsupportedVersion {
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropHeaderParseError)
-		}
+		s.droppedPacket(p, logging.PacketTypeNotDetermined, logging.PacketDropHeaderParseError, nil)
 		s.logger.Debugf("Error parsing packet: %s", err)
 		return false
 	}
@@ -352,18 +452,14 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 	}
 	if hdr.Type == protocol.PacketTypeInitial && p.Size() < protocol.MinInitialPacketSize {
 		s.logger.Debugf("Dropping a packet that is too small to be a valid Initial (%d bytes)", p.Size())
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(p, logging.PacketTypeInitial, logging.PacketDropUnexpectedPacket, hdr)
 		return false
 	}
 	// send a Version Negotiation Packet if the client is speaking a different protocol version
 	if !protocol.IsSupportedVersion(s.config.Versions, hdr.Version) {
 		if p.Size() < protocol.MinUnknownVersionPacketSize {
 			s.logger.Debugf("Dropping a packet with an unknown version that is too small (%d bytes)", p.Size())
-			if s.config.Tracer != nil {
-				s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropUnexpectedPacket)
-			}
+			s.droppedPacket(p, logging.PacketTypeNotDetermined, logging.PacketDropUnexpectedPacket, hdr)
 			return false
 		}
 		if !s.config.DisableVersionNegotiationPackets {
@@ -376,9 +472,7 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 		// There's little point in sending a Stateless Reset, since the client
 		// might not have received the token yet.
 		s.logger.Debugf("Dropping long header packet of type %s (%d bytes)", hdr.Type, len(p.data))
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(p, logging.PacketTypeFromHeader(hdr), logging.PacketDropUnexpectedPacket, hdr)
 		return false
 	}
 
@@ -392,23 +486,41 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 	return true
 }
 
+func (s *baseServer) requireAddressValidation(addr net.Addr) bool {
+	if s.config.RequireAddressValidation == nil {
+		return true
+	}
+	return s.config.RequireAddressValidation(addr)
+}
+
 func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) error {
 	if len(hdr.Token) == 0 && hdr.DestConnectionID.Len() < protocol.MinConnectionIDLenInitial {
 		p.buffer.Release()
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(p, logging.PacketTypeInitial, logging.PacketDropUnexpectedPacket, hdr)
 		return errors.New("too short connection ID")
 	}
 
 	var (
 		token          *Token
 		retrySrcConnID *protocol.ConnectionID
+		// set if the token was accepted by Config.ValidateRetryToken
+		customRetryTokenValid bool
+		// set if the token is a custom Retry token that was rejected
+		customRetryTokenInvalid bool
 	)
 	origDestConnID := hdr.DestConnectionID
 	if len(hdr.Token) > 0 {
-		c, err := s.tokenGenerator.DecodeToken(hdr.Token)
-		if err == nil {
+		// Tokens that can't be authenticated might have been issued by a different server.
+		// They are treated like any other token we can't decode.
+		if c, err := s.decodeCustomRetryToken(hdr.Token); err == nil {
+			if s.validateCustomRetryToken(p.remoteAddr, hdr, c) {
+				customRetryTokenValid = true
+				origDestConnID = c.OriginalDestConnectionID
+				retrySrcConnID = &c.RetrySrcConnectionID
+			} else {
+				customRetryTokenInvalid = true
+			}
+		} else if c, err := s.tokenGenerator.DecodeToken(hdr.Token); err == nil {
 			token = &Token{
 				IsRetryToken: c.IsRetryToken,
 				RemoteAddr:   c.RemoteAddr,
@@ -420,10 +532,14 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			}
 		}
 	}
-	if !s.config.AcceptToken(p.remoteAddr, token) {
+	// An invalid Retry token is always rejected.
+	// Otherwise, RequireAddressValidation decides if the client's address needs to be validated first.
+	if customRetryTokenInvalid || (!customRetryTokenValid && !s.config.AcceptToken(p.remoteAddr, token) &&
+		((token != nil && token.IsRetryToken) || s.requireAddressValidation(p.remoteAddr))) {
 		go func() {
 			defer p.buffer.Release()
-			if token != nil && token.IsRetryToken {
+			// The client must not accept a second Retry, so we can only close the connection.
+			if customRetryTokenInvalid || (token != nil && token.IsRetryToken) {
 				if err := s.maybeSendInvalidToken(p, hdr); err != nil {
 					s.logger.Debugf("Error sending INVALID_TOKEN error: %s", err)
 				}
@@ -447,8 +563,20 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return nil
 	}
 
-	connID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+	if !s.addRunningSession() {
+		s.logger.Debugf("Rejecting new connection. Server is shutting down.")
+		go func() {
+			defer p.buffer.Release()
+			if err := s.sendConnectionRefused(p.remoteAddr, hdr, p.info); err != nil {
+				s.logger.Debugf("Error rejecting connection: %s", err)
+			}
+		}()
+		return nil
+	}
+
+	connID, err := s.config.generateConnectionID(s.config.ConnectionIDLength)
 	if err != nil {
+		s.removeRunningSession()
 		return err
 	}
 	s.logger.Debugf("Changing connection ID to %s.", connID)
@@ -469,7 +597,7 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			)
 		}
 		sess = s.newSession(
-			newSendConn(s.conn, p.remoteAddr, p.info),
+			newSendConn(s.conn, p.remoteAddr, p.info, s.batchWriter),
 			s.sessionHandler,
 			origDestConnID,
 			retrySrcConnID,
@@ -481,6 +609,7 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			s.tlsConf,
 			s.tokenGenerator,
 			s.acceptEarlySessions,
+			s.streamLimiter,
 			tracer,
 			tracingID,
 			s.logger,
@@ -489,9 +618,13 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		sess.handlePacket(p)
 		return sess
 	}); !added {
+		s.removeRunningSession()
 		return nil
 	}
-	go sess.run()
+	go func() {
+		defer s.removeRunningSession()
+		s.config.runLoop(func() { sess.run() })
+	}()
 	go s.handleNewSession(sess)
 	if sess == nil {
 		p.buffer.Release()
@@ -532,11 +665,16 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header, info *pack
 	// Log the Initial packet now.
 	// If no Retry is sent, the packet will be logged by the session.
 	(&wire.ExtendedHeader{Header: *hdr}).Log(s.logger)
-	srcConnID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+	srcConnID, err := s.config.generateConnectionID(s.config.ConnectionIDLength)
 	if err != nil {
 		return err
 	}
-	token, err := s.tokenGenerator.NewRetryToken(remoteAddr, hdr.DestConnectionID, srcConnID)
+	var token []byte
+	if s.config.GetRetryToken != nil {
+		token, err = s.newCustomRetryToken(remoteAddr, hdr.DestConnectionID, srcConnID)
+	} else {
+		token, err = s.tokenGenerator.NewRetryToken(remoteAddr, hdr.DestConnectionID, srcConnID)
+	}
 	if err != nil {
 		return err
 	}
@@ -569,6 +707,36 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header, info *pack
 	return err
 }
 
+// newCustomRetryToken creates a Retry token using Config.GetRetryToken.
+// The token data is sealed together with the connection IDs,
+// since they're needed to complete the handshake after the client retried.
+func (s *baseServer) newCustomRetryToken(remoteAddr net.Addr, origDestConnID, retrySrcConnID protocol.ConnectionID) ([]byte, error) {
+	data, err := s.config.GetRetryToken(remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenGenerator.NewCustomRetryToken(origDestConnID, retrySrcConnID, data)
+}
+
+// validateCustomRetryToken validates a Retry token created by newCustomRetryToken, using Config.ValidateRetryToken.
+// It returns the connection IDs saved in the token.
+// decodeCustomRetryToken decodes a custom Retry token.
+// It only succeeds for tokens that were generated by this server.
+func (s *baseServer) decodeCustomRetryToken(token []byte) (*handshake.CustomRetryToken, error) {
+	if s.config.ValidateRetryToken == nil {
+		return nil, errors.New("custom Retry tokens not used")
+	}
+	return s.tokenGenerator.DecodeCustomRetryToken(token)
+}
+
+func (s *baseServer) validateCustomRetryToken(remoteAddr net.Addr, hdr *wire.Header, t *handshake.CustomRetryToken) bool {
+	// The client uses the Source Connection ID of the Retry as the Destination Connection ID.
+	if !t.RetrySrcConnectionID.Equal(hdr.DestConnectionID) {
+		return false
+	}
+	return s.config.ValidateRetryToken(remoteAddr, t.Data)
+}
+
 func (s *baseServer) maybeSendInvalidToken(p *receivedPacket, hdr *wire.Header) error {
 	// Only send INVALID_TOKEN if we can unprotect the packet.
 	// This makes sure that we won't send it for packets that were corrupted.
@@ -576,18 +744,14 @@ func (s *baseServer) maybeSendInvalidToken(p *receivedPacket, hdr *wire.Header)
 	data := p.data[:hdr.ParsedLen()+hdr.Length]
 	extHdr, err := unpackHeader(opener, hdr, data, hdr.Version)
 	if err != nil {
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropHeaderParseError)
-		}
+		s.droppedPacket(p, logging.PacketTypeInitial, logging.PacketDropHeaderParseError, hdr)
 		// don't return the error here. Just drop the packet.
 		return nil
 	}
 	hdrLen := extHdr.ParsedLen()
 	if _, err := opener.Open(data[hdrLen:hdrLen], data[hdrLen:], extHdr.PacketNumber, data[:hdrLen]); err != nil {
 		// don't return the error here. Just drop the packet.
-		if s.config.Tracer != nil {
-			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropPayloadDecryptError)
-		}
+		s.droppedPacket(p, logging.PacketTypeInitial, logging.PacketDropPayloadDecryptError, hdr)
 		return nil
 	}
 	if s.logger.Debug() {
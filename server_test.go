@@ -132,6 +132,38 @@ var _ = Describe("Server", func() {
 		Expect(ln.Close()).To(Succeed())
 	})
 
+	It("uses the configured logger", func() {
+		logger := newRecordingLogger()
+		ln, err := Listen(conn, tlsConf, &Config{Logger: logger})
+		Expect(err).ToNot(HaveOccurred())
+		ln.(*baseServer).logger.Debugf("foobar")
+		Expect(logger.Lines()).To(ContainElement("server foobar"))
+		Expect(logger.Lines()).To(ContainElement(HavePrefix("server Listening for udp connections")))
+		// stop the listener
+		Expect(ln.Close()).To(Succeed())
+	})
+
+	It("doesn't send packets in batches if batched writes are disabled", func() {
+		ln, err := ListenAddr("127.0.0.1:0", tlsConf, &Config{DisableBatchedWrites: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ln.(*baseServer).batchWriter).To(BeNil())
+		// stop the listener
+		Expect(ln.Close()).To(Succeed())
+	})
+
+	It("limits the total number of streams, if configured", func() {
+		ln, err := ListenAddr("127.0.0.1:0", tlsConf, &Config{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ln.(*baseServer).streamLimiter).To(BeNil())
+		Expect(ln.Close()).To(Succeed())
+		ln, err = ListenAddr("127.0.0.1:0", tlsConf, &Config{MaxTotalStreams: 1000})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ln.(*baseServer).streamLimiter).ToNot(BeNil())
+		Expect(ln.(*baseServer).streamLimiter.maxStreams).To(BeEquivalentTo(1000))
+		// stop the listener
+		Expect(ln.Close()).To(Succeed())
+	})
+
 	It("setups with the right values", func() {
 		supportedVersions := []protocol.VersionNumber{protocol.VersionTLS}
 		acceptToken := func(_ net.Addr, _ *Token) bool { return true }
@@ -166,6 +198,29 @@ var _ = Describe("Server", func() {
 		Expect(ln.Close()).To(Succeed())
 	})
 
+	It("returns the port assigned by the operating system when listening on port 0", func() {
+		ln, err := ListenAddr("127.0.0.1:0", tlsConf, &Config{})
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		addr, ok := ln.Addr().(*net.UDPAddr)
+		Expect(ok).To(BeTrue())
+		Expect(addr.Port).ToNot(BeZero())
+		// the port is actually in use
+		_, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: addr.Port})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns the address of the packet conn it was created with", func() {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		ln, err := Listen(conn, tlsConf, &Config{})
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		Expect(ln.Addr().String()).To(Equal(conn.LocalAddr().String()))
+		Expect(ln.Addr().(*net.UDPAddr).Port).ToNot(BeZero())
+	})
+
 	It("errors if given an invalid address", func() {
 		addr := "127.0.0.1"
 		_, err := ListenAddr(addr, tlsConf, &Config{})
@@ -239,6 +294,61 @@ var _ = Describe("Server", func() {
 				time.Sleep(50 * time.Millisecond)
 			})
 
+			It("reports dropped packets to the OnDroppedPacket callback", func() {
+				type droppedPacket struct {
+					reason DropReason
+					hdr    *logging.Header
+				}
+				dropped := make(chan droppedPacket, 2)
+				serv.config.OnDroppedPacket = func(reason DropReason, hdr *logging.Header) {
+					dropped <- droppedPacket{reason: reason, hdr: hdr}
+				}
+				p := getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeHandshake,
+					DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+					Version:          serv.config.Versions[0],
+				}, []byte("invalid"))
+				tracer.EXPECT().DroppedPacket(p.remoteAddr, logging.PacketTypeHandshake, p.Size(), logging.PacketDropUnexpectedPacket)
+				serv.handlePacket(p)
+				var d droppedPacket
+				Eventually(dropped).Should(Receive(&d))
+				Expect(d.reason).To(Equal(logging.PacketDropUnexpectedPacket))
+				Expect(d.hdr).ToNot(BeNil())
+				Expect(d.hdr.Type).To(Equal(protocol.PacketTypeHandshake))
+				Expect(d.hdr.DestConnectionID).To(Equal(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}))
+				// packets that can't be parsed are reported without a header
+				p = getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+					Version:          serv.config.Versions[0],
+				}, nil)
+				p.data = p.data[:len(p.data)-1]
+				tracer.EXPECT().DroppedPacket(p.remoteAddr, logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropHeaderParseError)
+				serv.handlePacket(p)
+				Eventually(dropped).Should(Receive(&d))
+				Expect(d.reason).To(Equal(logging.PacketDropHeaderParseError))
+				Expect(d.hdr).To(BeNil())
+			})
+
+			It("sends stateless resets for unknown connection IDs, if no callback is set", func() {
+				addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+				Expect(serv.handleUnknownConnectionID(protocol.ConnectionID{1, 2, 3, 4}, addr)).To(Equal(UnknownConnectionIDSendStatelessReset))
+			})
+
+			It("consults the callback for unknown connection IDs", func() {
+				addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+				var calledWith protocol.ConnectionID
+				serv.config.OnUnknownConnectionID = func(connID ConnectionID, a net.Addr) UnknownConnectionIDAction {
+					Expect(a).To(Equal(addr))
+					calledWith = connID
+					return UnknownConnectionIDDrop
+				}
+				Expect(serv.handleUnknownConnectionID(protocol.ConnectionID{1, 2, 3, 4}, addr)).To(Equal(UnknownConnectionIDDrop))
+				Expect(calledWith).To(Equal(protocol.ConnectionID{1, 2, 3, 4}))
+			})
+
 			It("decodes the token from the Token field", func() {
 				raddr := &net.UDPAddr{
 					IP:   net.IPv4(192, 168, 13, 37),
@@ -337,6 +447,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					enable0RTT bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -576,6 +687,314 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			Context("using RequireAddressValidation", func() {
+				var hdr *wire.Header
+
+				BeforeEach(func() {
+					serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return false }
+					hdr = &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+					}
+				})
+
+				It("sends a Retry, if address validation is required", func() {
+					var addr net.Addr
+					serv.config.RequireAddressValidation = func(a net.Addr) bool {
+						addr = a
+						return true
+					}
+					packet := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+					raddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
+					packet.remoteAddr = raddr
+					tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), nil)
+					done := make(chan struct{})
+					conn.EXPECT().WriteTo(gomock.Any(), raddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+						defer close(done)
+						Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeRetry))
+						return len(b), nil
+					})
+					serv.handlePacket(packet)
+					Eventually(done).Should(BeClosed())
+					Expect(addr).To(Equal(raddr))
+				})
+
+				It("creates a session, if address validation is not required", func() {
+					serv.config.RequireAddressValidation = func(net.Addr) bool { return false }
+					packet := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+					phm.EXPECT().AddWithConnID(hdr.DestConnectionID, gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+						phm.EXPECT().GetStatelessResetToken(gomock.Any())
+						fn()
+						return true
+					})
+					tracer.EXPECT().TracerForConnection(gomock.Any(), protocol.PerspectiveServer, hdr.DestConnectionID)
+					run := make(chan struct{})
+					sess := NewMockQuicSession(mockCtrl)
+					serv.newSession = func(
+						_ sendConn,
+						_ sessionRunner,
+						origDestConnID protocol.ConnectionID,
+						retrySrcConnID *protocol.ConnectionID,
+						_ protocol.ConnectionID,
+						_ protocol.ConnectionID,
+						_ protocol.ConnectionID,
+						_ protocol.StatelessResetToken,
+						_ *Config,
+						_ *tls.Config,
+						_ *handshake.TokenGenerator,
+						_ bool,
+						_ *streamLimiter,
+						_ logging.ConnectionTracer,
+						_ uint64,
+						_ utils.Logger,
+						_ protocol.VersionNumber,
+					) quicSession {
+						Expect(origDestConnID).To(Equal(hdr.DestConnectionID))
+						Expect(retrySrcConnID).To(BeNil())
+						sess.EXPECT().handlePacket(packet)
+						sess.EXPECT().run().Do(func() { close(run) })
+						sess.EXPECT().Context().Return(context.Background())
+						sess.EXPECT().HandshakeComplete().Return(context.Background())
+						return sess
+					}
+					serv.handlePacket(packet)
+					Eventually(run).Should(BeClosed())
+					// make sure no Retry is sent
+					time.Sleep(scaleDuration(20 * time.Millisecond))
+				})
+			})
+
+			Context("using custom Retry tokens", func() {
+				BeforeEach(func() {
+					serv.config.AcceptToken = func(_ net.Addr, token *Token) bool { return token != nil }
+					serv.config.GetRetryToken = func(net.Addr) ([]byte, error) { return []byte("foobar"), nil }
+					serv.config.ValidateRetryToken = func(_ net.Addr, token []byte) bool { return string(token) == "foobar" }
+				})
+
+				expectInvalidToken := func(packet *receivedPacket) {
+					tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ net.Addr, replyHdr *logging.Header, _ logging.ByteCount, frames []logging.Frame) {
+						Expect(replyHdr.Type).To(Equal(protocol.PacketTypeInitial))
+						Expect(frames).To(HaveLen(1))
+						Expect(frames[0]).To(BeAssignableToTypeOf(&logging.ConnectionCloseFrame{}))
+						Expect(frames[0].(*logging.ConnectionCloseFrame).ErrorCode).To(BeEquivalentTo(qerr.InvalidToken))
+					})
+					done := make(chan struct{})
+					conn.EXPECT().WriteTo(gomock.Any(), gomock.Any()).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+						defer close(done)
+						Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeInitial))
+						return len(b), nil
+					})
+					serv.handlePacket(packet)
+					Eventually(done).Should(BeClosed())
+				}
+
+				expectRetry := func(packet *receivedPacket) {
+					tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), nil)
+					done := make(chan struct{})
+					conn.EXPECT().WriteTo(gomock.Any(), gomock.Any()).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+						defer close(done)
+						Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeRetry))
+						return len(b), nil
+					})
+					serv.handlePacket(packet)
+					Eventually(done).Should(BeClosed())
+				}
+
+				It("uses GetRetryToken when sending a Retry", func() {
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+					}
+					packet := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+					raddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
+					packet.remoteAddr = raddr
+					var addr net.Addr
+					serv.config.GetRetryToken = func(a net.Addr) ([]byte, error) {
+						addr = a
+						return []byte("foobar"), nil
+					}
+					tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), nil)
+					done := make(chan struct{})
+					conn.EXPECT().WriteTo(gomock.Any(), raddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+						defer close(done)
+						replyHdr := parseHeader(b)
+						Expect(replyHdr.Type).To(Equal(protocol.PacketTypeRetry))
+						token, err := serv.tokenGenerator.DecodeCustomRetryToken(replyHdr.Token)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(token.OriginalDestConnectionID).To(Equal(hdr.DestConnectionID))
+						Expect(token.RetrySrcConnectionID).To(Equal(replyHdr.SrcConnectionID))
+						Expect(token.Data).To(Equal([]byte("foobar")))
+						return len(b), nil
+					})
+					serv.handlePacket(packet)
+					Eventually(done).Should(BeClosed())
+					Expect(addr).To(Equal(raddr))
+				})
+
+				It("sends an INVALID_TOKEN error when the token is rejected by ValidateRetryToken", func() {
+					serv.config.ValidateRetryToken = func(net.Addr, []byte) bool { return false }
+					token, err := serv.tokenGenerator.NewCustomRetryToken(
+						protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+						protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						[]byte("foobar"),
+					)
+					Expect(err).ToNot(HaveOccurred())
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            token,
+					}
+					expectInvalidToken(getPacket(hdr, make([]byte, protocol.MinInitialPacketSize)))
+				})
+
+				It("sends a Retry for tokens that were modified by the client", func() {
+					serv.config.ValidateRetryToken = func(net.Addr, []byte) bool {
+						Fail("ValidateRetryToken should not be called")
+						return false
+					}
+					token, err := serv.tokenGenerator.NewCustomRetryToken(
+						protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+						protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						[]byte("foobar"),
+					)
+					Expect(err).ToNot(HaveOccurred())
+					token[len(token)-1] ^= 0x1
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            token,
+					}
+					expectRetry(getPacket(hdr, make([]byte, protocol.MinInitialPacketSize)))
+				})
+
+				It("rejects tokens that were issued for a different Retry", func() {
+					serv.config.ValidateRetryToken = func(net.Addr, []byte) bool {
+						Fail("ValidateRetryToken should not be called")
+						return false
+					}
+					token, err := serv.tokenGenerator.NewCustomRetryToken(
+						protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+						protocol.ConnectionID{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+						[]byte("foobar"),
+					)
+					Expect(err).ToNot(HaveOccurred())
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            token,
+					}
+					expectInvalidToken(getPacket(hdr, make([]byte, protocol.MinInitialPacketSize)))
+				})
+
+				It("sends a Retry for tokens that aren't custom Retry tokens", func() {
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            []byte("a token from a different server"),
+					}
+					expectRetry(getPacket(hdr, make([]byte, protocol.MinInitialPacketSize)))
+				})
+
+				It("sends a Retry for random tokens that start like a custom Retry token", func() {
+					serv.config.ValidateRetryToken = func(net.Addr, []byte) bool {
+						Fail("ValidateRetryToken should not be called")
+						return false
+					}
+					token := make([]byte, 100)
+					rand.Read(token)
+					token[0] = 0xc7
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            token,
+					}
+					expectRetry(getPacket(hdr, make([]byte, protocol.MinInitialPacketSize)))
+				})
+
+				It("creates a session when the token is accepted by ValidateRetryToken", func() {
+					token, err := serv.tokenGenerator.NewCustomRetryToken(
+						protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+						protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						[]byte("foobar"),
+					)
+					Expect(err).ToNot(HaveOccurred())
+					hdr := &wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+						DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+						Version:          protocol.VersionTLS,
+						Token:            token,
+					}
+					p := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+					serv.config.AcceptToken = func(net.Addr, *Token) bool {
+						Fail("AcceptToken should not be called")
+						return false
+					}
+					run := make(chan struct{})
+					phm.EXPECT().AddWithConnID(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, gomock.Any(), gomock.Any()).DoAndReturn(func(_, c protocol.ConnectionID, fn func() packetHandler) bool {
+						phm.EXPECT().GetStatelessResetToken(gomock.Any())
+						fn()
+						return true
+					})
+					tracer.EXPECT().TracerForConnection(gomock.Any(), protocol.PerspectiveServer, protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde})
+					sess := NewMockQuicSession(mockCtrl)
+					serv.newSession = func(
+						_ sendConn,
+						_ sessionRunner,
+						origDestConnID protocol.ConnectionID,
+						retrySrcConnID *protocol.ConnectionID,
+						clientDestConnID protocol.ConnectionID,
+						_ protocol.ConnectionID,
+						_ protocol.ConnectionID,
+						_ protocol.StatelessResetToken,
+						_ *Config,
+						_ *tls.Config,
+						_ *handshake.TokenGenerator,
+						_ bool,
+						_ *streamLimiter,
+						_ logging.ConnectionTracer,
+						_ uint64,
+						_ utils.Logger,
+						_ protocol.VersionNumber,
+					) quicSession {
+						Expect(origDestConnID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
+						Expect(retrySrcConnID).To(Equal(&protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+						Expect(clientDestConnID).To(Equal(hdr.DestConnectionID))
+						sess.EXPECT().handlePacket(p)
+						sess.EXPECT().run().Do(func() { close(run) })
+						sess.EXPECT().Context().Return(context.Background())
+						sess.EXPECT().HandshakeComplete().Return(context.Background())
+						return sess
+					}
+					serv.handlePacket(p)
+					Eventually(run).Should(BeClosed())
+					// make sure there are no Write calls on the packet conn
+					time.Sleep(50 * time.Millisecond)
+				})
+			})
+
 			It("creates a session, if no Token is required", func() {
 				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
 				hdr := &wire.Header{
@@ -616,6 +1035,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					enable0RTT bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -652,6 +1072,59 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			It("uses the ConnectionIDGenerator for new sessions", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.ConnectionIDLength = 6
+				serv.config.ConnectionIDGenerator = func(l int) (ConnectionID, error) {
+					Expect(l).To(Equal(6))
+					return ConnectionID{0xc, 0xa, 0xf, 0xe, 0, 1}, nil
+				}
+				hdr := &wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+					DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+					Version:          protocol.VersionTLS,
+				}
+				p := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+				run := make(chan struct{})
+				phm.EXPECT().AddWithConnID(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, protocol.ConnectionID{0xc, 0xa, 0xf, 0xe, 0, 1}, gomock.Any()).DoAndReturn(func(_, c protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(protocol.ConnectionID{0xc, 0xa, 0xf, 0xe, 0, 1})
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(gomock.Any(), protocol.PerspectiveServer, gomock.Any())
+				sess := NewMockQuicSession(mockCtrl)
+				serv.newSession = func(
+					_ sendConn,
+					_ sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					srcConnID protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ bool,
+					_ *streamLimiter,
+					_ logging.ConnectionTracer,
+					_ uint64,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					Expect(srcConnID).To(Equal(protocol.ConnectionID{0xc, 0xa, 0xf, 0xe, 0, 1}))
+					sess.EXPECT().handlePacket(p)
+					sess.EXPECT().run().Do(func() { close(run) })
+					sess.EXPECT().Context().Return(context.Background())
+					sess.EXPECT().HandshakeComplete().Return(context.Background())
+					return sess
+				}
+				serv.handlePacket(p)
+				Eventually(run).Should(BeClosed())
+			})
+
 			It("drops packets if the receive queue is full", func() {
 				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
 					phm.EXPECT().GetStatelessResetToken(gomock.Any())
@@ -676,6 +1149,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					_ bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -730,6 +1204,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					_ bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -761,6 +1236,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					_ bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -833,6 +1309,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					_ bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -945,6 +1422,7 @@ var _ = Describe("Server", func() {
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
 					_ bool,
+					_ *streamLimiter,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
@@ -971,6 +1449,140 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 		})
+
+		Context("shutting down", func() {
+			// startSession creates a new session, whose run loop only returns when stopRun is closed.
+			// It returns as soon as the run loop was started.
+			startSession := func(handshakeCtx context.Context, stopRun <-chan struct{}) *MockQuicSession {
+				sess := NewMockQuicSession(mockCtrl)
+				running := make(chan struct{})
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.newSession = func(
+					_ sendConn,
+					_ sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ bool,
+					_ *streamLimiter,
+					_ logging.ConnectionTracer,
+					_ uint64,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx)
+					sess.EXPECT().run().Do(func() {
+						close(running)
+						<-stopRun
+					})
+					sess.EXPECT().Context().Return(context.Background())
+					return sess
+				}
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(gomock.Any(), protocol.PerspectiveServer, gomock.Any())
+				serv.handlePacket(getInitialWithRandomDestConnID())
+				Eventually(running).Should(BeClosed())
+				return sess
+			}
+
+			// isShuttingDown says if Shutdown was called, and is waiting for the sessions to finish
+			isShuttingDown := func() bool {
+				serv.mutex.Lock()
+				defer serv.mutex.Unlock()
+				return serv.shuttingDown && !serv.closed
+			}
+
+			It("returns immediately if there are no sessions", func() {
+				phm.EXPECT().CloseServer()
+				Expect(serv.Shutdown(context.Background())).To(Succeed())
+				_, err := serv.Accept(context.Background())
+				Expect(err).To(MatchError("server closed"))
+			})
+
+			It("waits for running sessions to finish", func() {
+				handshakeCtx, completeHandshake := context.WithCancel(context.Background())
+				stopRun := make(chan struct{})
+				sess := startSession(handshakeCtx, stopRun)
+
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					Expect(serv.Shutdown(context.Background())).To(Succeed())
+					close(done)
+				}()
+				Eventually(isShuttingDown).Should(BeTrue())
+				Expect(done).ToNot(BeClosed())
+
+				// sessions that complete the handshake are still accepted
+				completeHandshake()
+				s, err := serv.Accept(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(s).To(Equal(sess))
+				Expect(isShuttingDown()).To(BeTrue())
+				Expect(done).ToNot(BeClosed())
+
+				phm.EXPECT().CloseServer()
+				close(stopRun)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("rejects new connection attempts while shutting down", func() {
+				stopRun := make(chan struct{})
+				defer close(stopRun)
+				startSession(context.Background(), stopRun)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go func() {
+					defer GinkgoRecover()
+					serv.Shutdown(ctx)
+				}()
+				Eventually(isShuttingDown).Should(BeTrue())
+
+				p := getInitialWithRandomDestConnID()
+				hdr, _, _, err := wire.ParsePacket(p.data, 0)
+				Expect(err).ToNot(HaveOccurred())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					rejectHdr := parseHeader(b)
+					Expect(rejectHdr.Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(rejectHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+					Expect(rejectHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("closes the server when the context is canceled", func() {
+				stopRun := make(chan struct{})
+				defer close(stopRun)
+				startSession(context.Background(), stopRun)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				errChan := make(chan error, 1)
+				go func() { errChan <- serv.Shutdown(ctx) }()
+				Eventually(isShuttingDown).Should(BeTrue())
+				phm.EXPECT().CloseServer()
+				cancel()
+				Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+				_, err := serv.Accept(context.Background())
+				Expect(err).To(MatchError("server closed"))
+			})
+		})
 	})
 
 	Context("server accepting sessions that haven't completed the handshake", func() {
@@ -1019,6 +1631,7 @@ var _ = Describe("Server", func() {
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
 				enable0RTT bool,
+				_ *streamLimiter,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -1062,6 +1675,7 @@ var _ = Describe("Server", func() {
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
 				_ bool,
+				_ *streamLimiter,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
@@ -1126,6 +1740,7 @@ var _ = Describe("Server", func() {
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
 				_ bool,
+				_ *streamLimiter,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
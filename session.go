@@ -3,6 +3,7 @@ package quic
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/flowcontrol"
 	"github.com/BGrewell/quic-go/internal/handshake"
 	"github.com/BGrewell/quic-go/internal/logutils"
@@ -46,8 +48,14 @@ type streamManager interface {
 	UpdateLimits(*wire.TransportParameters)
 	HandleMaxStreamsFrame(*wire.MaxStreamsFrame)
 	CloseWithError(error)
+	SetWriteDeadline(time.Time)
 	ResetFor0RTT()
 	UseResetMaps()
+	StopAccepting()
+	NumOpenStreams() int
+	OpenStreams() []protocol.StreamID
+	MaxIncomingStreams() (bidi, uni protocol.StreamNum)
+	GrantStreamCredit()
 }
 
 type cryptoStreamHandler interface {
@@ -95,7 +103,7 @@ type sessionRunner interface {
 	GetStatelessResetToken(protocol.ConnectionID) protocol.StatelessResetToken
 	Retire(protocol.ConnectionID)
 	Remove(protocol.ConnectionID)
-	ReplaceWithClosed(protocol.ConnectionID, packetHandler)
+	ReplaceWithClosed(protocol.ConnectionID, packetHandler, time.Duration)
 	AddResetToken(protocol.StatelessResetToken, packetHandler)
 	RemoveResetToken(protocol.StatelessResetToken)
 }
@@ -118,9 +126,37 @@ type closeError struct {
 	immediate bool
 }
 
+// A migrationRequest is a request to migrate the session to a new connection, see Session.MigrateTo.
+type migrationRequest struct {
+	conn    net.PacketConn
+	errChan chan error
+}
+
+// A pathValidation validates a new path, see section 8.2 of RFC 9000.
+// The client validates the path it's migrating to, see Session.MigrateTo.
+// The server validates a new client address before it follows the client to that address.
+type pathValidation struct {
+	data          [8]byte // the data sent in the PATH_CHALLENGE frame
+	challengeSent bool
+	numChallenges int // the number of PATH_CHALLENGE frames sent, limited by Config.MaxPathChallenges
+	conn          sendConn
+	deadline      time.Time // when the PATH_CHALLENGE is retransmitted, or the path validation is abandoned
+
+	// only set for the client
+	runner  sessionRunner         // the packet handlers of the new connection
+	connID  protocol.ConnectionID // the connection ID used on the new path
+	errChan chan error
+
+	// only used by the server
+	// Until the client's new address is validated, the server sends at most 3 times
+	// the number of bytes it received from that address, see section 9.3 of RFC 9000.
+	bytesReceived, bytesSent protocol.ByteCount
+}
+
 type errCloseForRecreating struct {
-	nextPacketNumber protocol.PacketNumber
-	nextVersion      protocol.VersionNumber
+	nextPacketNumber        protocol.PacketNumber
+	nextVersion             protocol.VersionNumber
+	serverSupportedVersions []protocol.VersionNumber // set if the session is recreated due to a Version Negotiation packet
 }
 
 func (e *errCloseForRecreating) Error() string {
@@ -145,6 +181,9 @@ type session struct {
 	version     protocol.VersionNumber
 	config      *Config
 
+	// connMutex protects conn, which is replaced when the connection is migrated to a new path.
+	// It only needs to be held when accessing conn from outside the run loop.
+	connMutex sync.Mutex
 	conn      sendConn
 	sendQueue sender
 
@@ -163,6 +202,7 @@ type session struct {
 	connFlowController    flowcontrol.ConnectionFlowController
 	tokenStoreKey         string                    // only set for the client
 	tokenGenerator        *handshake.TokenGenerator // only set for the server
+	streamLimiter         *streamLimiter            // only set for the server, if Config.MaxTotalStreams is set
 
 	unpacker      unpacker
 	frameParser   wire.FrameParser
@@ -194,17 +234,48 @@ type session struct {
 	handshakeConfirmed    bool
 
 	receivedRetry       bool
+	numRetries          uint32 // accessed atomically, so it can be read by ConnectionStats
+	acksSent            uint64 // accessed atomically, so it can be read by AckStats
+	streamBytesSent     uint64 // accessed atomically, so it can be read by BytesSent
+	streamBytesAcked    uint64 // accessed atomically, so it can be read by BytesAcked
 	versionNegotiated   bool
 	receivedFirstPacket bool
 
+	serverSupportedVersions []protocol.VersionNumber // the versions offered in the server's Version Negotiation packet
+
 	idleTimeout         time.Duration
 	sessionCreationTime time.Time
+	// the times when the handshake completed and was confirmed, read by Timestamps
+	timestampsMutex        sync.Mutex
+	handshakeCompleteTime  time.Time
+	handshakeConfirmedTime time.Time
 	// The idle timeout is set based on the max of the time we received the last packet...
 	lastPacketReceivedTime time.Time
 	// ... and the time we sent a new ack-eliciting packet after receiving a packet.
 	firstAckElicitingPacketAfterIdleSentTime time.Time
 	// pacingDeadline is the time when the next packet should be sent
 	pacingDeadline time.Time
+	// encryptionRateLimiter limits the rate at which packets are sealed, if Config.MaxEncryptionRate is set
+	encryptionRateLimiter *encryptionRateLimiter
+	// streamResetLimiter limits the rate of RESET_STREAM and STOP_SENDING frames, if Config.MaxStreamResetRate is set
+	streamResetLimiter *streamResetLimiter
+	// numPacketsReceived numbers the received packets passed to Config.PacketLossSimulator
+	numPacketsReceived protocol.PacketNumber
+	// nextTimeout is the deadline the timer was last set to
+	nextTimeoutMutex sync.Mutex
+	nextTimeout      time.Time
+	// currentMTU is the maximum packet size currently used by the packer
+	currentMTUMutex sync.Mutex
+	currentMTU      protocol.ByteCount
+	// the connection IDs returned by ConnectionIDs.
+	// They are only accessed from the run loop, which publishes a snapshot to connIDs whenever one of them changes.
+	currentSrcConnID  protocol.ConnectionID // the connection ID the peer used on the last packet it sent us
+	currentDestConnID protocol.ConnectionID // the connection ID we use on the packets we send
+	connIDs           atomic.Value          // *connIDsSnapshot
+	// the state of the congestion controller and the pacer at the end of sendPackets, read by CanSendNow
+	canSendMutex          sync.Mutex
+	congestionAllowsSend  bool
+	pacingAllowsSendAfter time.Time
 
 	peerParams *wire.TransportParameters
 
@@ -216,6 +287,31 @@ type session struct {
 
 	datagramQueue *datagramQueue
 
+	// flushRequests are sent by streams to make the run loop send packets right away.
+	// pendingFlushes are answered once the run loop tried to send.
+	flushRequests  chan chan error
+	pendingFlushes []chan error
+
+	// streamCompleted is signaled (non-blocking) every time a stream is completed.
+	// It is used by CloseGracefully to wait for the open streams.
+	streamCompleted chan struct{}
+	// streamCreditAvailable is signaled (non-blocking) when the streamLimiter has credit for new incoming streams.
+	streamCreditAvailable chan struct{}
+
+	// Connection migration, see section 9 of RFC 9000.
+	runners           *sessionRunners // the client adds a runner for every path it migrates to
+	pathRunner        sessionRunner   // the runner of the active path, only used by the client
+	migrationRequests chan *migrationRequest
+	pathValidation    *pathValidation // set while a new path is validated
+	// The server only follows the client to a new address when it receives the packet with
+	// the largest packet number that contains non-probing frames from that address.
+	largestRcvdNonProbingPacket protocol.PacketNumber
+	// probedPath is set while handling a 1-RTT packet that the server received from a new client address.
+	probedPath *pathValidation
+
+	// congestionEvents is set if Config.CongestionEventBufferSize is set
+	congestionEvents *congestionEventTracer
+
 	logID  string
 	tracer logging.ConnectionTracer
 	logger utils.Logger
@@ -228,6 +324,8 @@ var (
 	deadlineSendImmediately              = time.Time{}.Add(42 * time.Millisecond) // any value > time.Time{} and before time.Now() is fine
 )
 
+var errSessionClosed = errors.New("session closed")
+
 var newSession = func(
 	conn sendConn,
 	runner sessionRunner,
@@ -241,6 +339,7 @@ var newSession = func(
 	tlsConf *tls.Config,
 	tokenGenerator *handshake.TokenGenerator,
 	enable0RTT bool,
+	streamLimiter *streamLimiter,
 	tracer logging.ConnectionTracer,
 	tracingID uint64,
 	logger utils.Logger,
@@ -251,7 +350,10 @@ var newSession = func(
 		config:                conf,
 		handshakeDestConnID:   destConnID,
 		srcConnIDLen:          srcConnID.Len(),
+		currentSrcConnID:      srcConnID,
+		currentDestConnID:     destConnID,
 		tokenGenerator:        tokenGenerator,
+		streamLimiter:         streamLimiter,
 		oneRTTStream:          newCryptoStream(),
 		perspective:           protocol.PerspectiveServer,
 		handshakeCompleteChan: make(chan struct{}),
@@ -259,13 +361,21 @@ var newSession = func(
 		logger:                logger,
 		version:               v,
 	}
+	s.publishConnIDs()
 	if origDestConnID != nil {
 		s.logID = origDestConnID.String()
 	} else {
 		s.logID = destConnID.String()
 	}
+	// the connection ID that the Tracer is created for
+	odcid := clientDestConnID
+	if origDestConnID.Len() > 0 {
+		odcid = origDestConnID
+	}
+	s.runners = newSessionRunners(runner, s.config.OnConnectionIDIssued, s.config.OnConnectionIDRetired)
 	s.connIDManager = newConnIDManager(
 		destConnID,
+		s.config.ActiveConnectionIDLimit,
 		func(token protocol.StatelessResetToken) { runner.AddResetToken(token, s) },
 		runner.RemoveResetToken,
 		s.queueControlFrame,
@@ -273,11 +383,12 @@ var newSession = func(
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		clientDestConnID,
-		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
-		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
-		runner.ReplaceWithClosed,
+		s.config.generateConnectionID,
+		func(connID protocol.ConnectionID) { s.runners.Add(connID, s) },
+		s.runners.GetStatelessResetToken,
+		s.runners.Remove,
+		s.runners.Retire,
+		s.runners.ReplaceWithClosed,
 		s.queueControlFrame,
 		s.version,
 	)
@@ -292,23 +403,34 @@ var newSession = func(
 		s.logger,
 		s.version,
 		s.config.CongestionControlAlgo,
+		s.config.HyStartConfig,
+		s.config.InitialCongestionWindowPackets,
+		s.config.InitialSlowStartThreshold,
+		s.config.LocoMaxBytesInFlight,
+		s.config.MaxSendRate,
+		s.config.Clock,
+		s.config.AckElicitingThreshold,
+		congestion.NewCongestionLog(s.config.CongestionLog, s.config.Clock, odcid),
+		s.config.OnCongestionCollapse,
+		s.config.PTOProbeStrategy == PTOProbeSendPing,
 	)
 	initialStream := newCryptoStream()
 	handshakeStream := newCryptoStream()
+	// The streamLimiter might grant less credit than configured.
+	maxBidiStreamNum, maxUniStreamNum := s.streamsMap.MaxIncomingStreams()
 	params := &wire.TransportParameters{
 		InitialMaxStreamDataBidiLocal:   protocol.ByteCount(s.config.InitialStreamReceiveWindow),
 		InitialMaxStreamDataBidiRemote:  protocol.ByteCount(s.config.InitialStreamReceiveWindow),
 		InitialMaxStreamDataUni:         protocol.ByteCount(s.config.InitialStreamReceiveWindow),
 		InitialMaxData:                  protocol.ByteCount(s.config.InitialConnectionReceiveWindow),
 		MaxIdleTimeout:                  s.config.MaxIdleTimeout,
-		MaxBidiStreamNum:                protocol.StreamNum(s.config.MaxIncomingStreams),
-		MaxUniStreamNum:                 protocol.StreamNum(s.config.MaxIncomingUniStreams),
+		MaxBidiStreamNum:                maxBidiStreamNum,
+		MaxUniStreamNum:                 maxUniStreamNum,
 		MaxAckDelay:                     protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:                protocol.AckDelayExponent,
-		DisableActiveMigration:          true,
 		StatelessResetToken:             &statelessResetToken,
 		OriginalDestinationConnectionID: origDestConnID,
-		ActiveConnectionIDLimit:         protocol.MaxActiveConnectionIDs,
+		ActiveConnectionIDLimit:         s.config.ActiveConnectionIDLimit,
 		InitialSourceConnectionID:       srcConnID,
 		RetrySourceConnectionID:         retrySrcConnID,
 	}
@@ -330,13 +452,14 @@ var newSession = func(
 			onError:          s.closeLocal,
 			dropKeys:         s.dropEncryptionLevel,
 			onHandshakeComplete: func() {
-				runner.Retire(clientDestConnID)
+				s.runners.Retire(clientDestConnID)
 				close(s.handshakeCompleteChan)
 			},
 		},
 		tlsConf,
 		enable0RTT,
 		s.rttStats,
+		s.config.AEADFactory,
 		tracer,
 		logger,
 		s.version,
@@ -344,7 +467,7 @@ var newSession = func(
 	s.cryptoStreamHandler = cs
 	s.packer = newPacketPacker(
 		srcConnID,
-		s.connIDManager.Get,
+		s.getDestConnID,
 		initialStream,
 		handshakeStream,
 		s.sentPacketHandler,
@@ -354,6 +477,7 @@ var newSession = func(
 		s.framer,
 		s.receivedPacketHandler,
 		s.datagramQueue,
+		s.config.MaxCoalescedPackets,
 		s.perspective,
 		s.version,
 	)
@@ -373,39 +497,48 @@ var newClientSession = func(
 	initialPacketNumber protocol.PacketNumber,
 	enable0RTT bool,
 	hasNegotiatedVersion bool,
+	serverSupportedVersions []protocol.VersionNumber,
 	tracer logging.ConnectionTracer,
 	tracingID uint64,
 	logger utils.Logger,
 	v protocol.VersionNumber,
 ) quicSession {
 	s := &session{
-		conn:                  conn,
-		config:                conf,
-		origDestConnID:        destConnID,
-		handshakeDestConnID:   destConnID,
-		srcConnIDLen:          srcConnID.Len(),
-		perspective:           protocol.PerspectiveClient,
-		handshakeCompleteChan: make(chan struct{}),
-		logID:                 destConnID.String(),
-		logger:                logger,
-		tracer:                tracer,
-		versionNegotiated:     hasNegotiatedVersion,
-		version:               v,
-	}
+		conn:                    conn,
+		config:                  conf,
+		origDestConnID:          destConnID,
+		handshakeDestConnID:     destConnID,
+		srcConnIDLen:            srcConnID.Len(),
+		currentSrcConnID:        srcConnID,
+		currentDestConnID:       destConnID,
+		perspective:             protocol.PerspectiveClient,
+		handshakeCompleteChan:   make(chan struct{}),
+		logID:                   destConnID.String(),
+		logger:                  logger,
+		tracer:                  tracer,
+		versionNegotiated:       hasNegotiatedVersion,
+		serverSupportedVersions: serverSupportedVersions,
+		version:                 v,
+	}
+	s.publishConnIDs()
+	s.runners = newSessionRunners(runner, s.config.OnConnectionIDIssued, s.config.OnConnectionIDRetired)
+	s.pathRunner = runner
 	s.connIDManager = newConnIDManager(
 		destConnID,
-		func(token protocol.StatelessResetToken) { runner.AddResetToken(token, s) },
-		runner.RemoveResetToken,
+		s.config.ActiveConnectionIDLimit,
+		func(token protocol.StatelessResetToken) { s.runners.AddResetToken(token, s) },
+		s.runners.RemoveResetToken,
 		s.queueControlFrame,
 	)
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		nil,
-		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
-		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
-		runner.ReplaceWithClosed,
+		s.config.generateConnectionID,
+		func(connID protocol.ConnectionID) { s.runners.Add(connID, s) },
+		s.runners.GetStatelessResetToken,
+		s.runners.Remove,
+		s.runners.Retire,
+		s.runners.ReplaceWithClosed,
 		s.queueControlFrame,
 		s.version,
 	)
@@ -420,6 +553,16 @@ var newClientSession = func(
 		s.logger,
 		s.version,
 		s.config.CongestionControlAlgo,
+		s.config.HyStartConfig,
+		s.config.InitialCongestionWindowPackets,
+		s.config.InitialSlowStartThreshold,
+		s.config.LocoMaxBytesInFlight,
+		s.config.MaxSendRate,
+		s.config.Clock,
+		s.config.AckElicitingThreshold,
+		congestion.NewCongestionLog(s.config.CongestionLog, s.config.Clock, destConnID),
+		s.config.OnCongestionCollapse,
+		s.config.PTOProbeStrategy == PTOProbeSendPing,
 	)
 	initialStream := newCryptoStream()
 	handshakeStream := newCryptoStream()
@@ -434,7 +577,7 @@ var newClientSession = func(
 		MaxAckDelay:                    protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:               protocol.AckDelayExponent,
 		DisableActiveMigration:         true,
-		ActiveConnectionIDLimit:        protocol.MaxActiveConnectionIDs,
+		ActiveConnectionIDLimit:        s.config.ActiveConnectionIDLimit,
 		InitialSourceConnectionID:      srcConnID,
 	}
 	if s.config.EnableDatagrams {
@@ -459,6 +602,7 @@ var newClientSession = func(
 		tlsConf,
 		enable0RTT,
 		s.rttStats,
+		s.config.AEADFactory,
 		tracer,
 		logger,
 		s.version,
@@ -469,7 +613,7 @@ var newClientSession = func(
 	s.unpacker = newPacketUnpacker(cs, s.version)
 	s.packer = newPacketPacker(
 		srcConnID,
-		s.connIDManager.Get,
+		s.getDestConnID,
 		initialStream,
 		handshakeStream,
 		s.sentPacketHandler,
@@ -479,6 +623,7 @@ var newClientSession = func(
 		s.framer,
 		s.receivedPacketHandler,
 		s.datagramQueue,
+		s.config.MaxCoalescedPackets,
 		s.perspective,
 		s.version,
 	)
@@ -496,13 +641,32 @@ var newClientSession = func(
 }
 
 func (s *session) preSetup() {
+	if s.config.PacketCapture != nil {
+		s.conn = newCaptureConn(s.conn, s.config.PacketCapture)
+	}
+	if s.config.CongestionEventBufferSize > 0 {
+		s.congestionEvents = newCongestionEventTracer(s.config.CongestionEventBufferSize)
+		s.tracer = logging.NewMultiplexedConnectionTracerWithLogger(s.logger, s.tracer, s.congestionEvents)
+	}
 	s.sendQueue = newSendQueue(s.conn)
 	s.retransmissionQueue = newRetransmissionQueue(s.version)
 	s.frameParser = wire.NewFrameParser(s.config.EnableDatagrams, s.version)
+	if s.config.MaxEncryptionRate > 0 {
+		s.encryptionRateLimiter = newEncryptionRateLimiter(s.config.MaxEncryptionRate)
+	}
+	if s.config.MaxStreamResetRate > 0 {
+		s.streamResetLimiter = newStreamResetLimiter(s.config.MaxStreamResetRate)
+	}
 	s.rttStats = &utils.RTTStats{}
+	s.currentMTU = getMaxPacketSize(s.conn.RemoteAddr())
+	maxConnectionReceiveWindow := s.config.MaxConnectionReceiveWindow
+	if s.config.DisableReceiveWindowAutoTuning {
+		// the window can't grow if its maximum is the initial size
+		maxConnectionReceiveWindow = s.config.InitialConnectionReceiveWindow
+	}
 	s.connFlowController = flowcontrol.NewConnectionFlowController(
 		protocol.ByteCount(s.config.InitialConnectionReceiveWindow),
-		protocol.ByteCount(s.config.MaxConnectionReceiveWindow),
+		protocol.ByteCount(maxConnectionReceiveWindow),
 		s.onHasConnectionWindowUpdate,
 		func(size protocol.ByteCount) bool {
 			if s.config.AllowConnectionWindowIncrease == nil {
@@ -514,21 +678,34 @@ func (s *session) preSetup() {
 		s.logger,
 	)
 	s.earlySessionReadyChan = make(chan struct{})
+	var sendBufferLimiter *sendBufferLimiter
+	if s.config.MaxSendBufferBytes > 0 {
+		sendBufferLimiter = newSendBufferLimiter(protocol.ByteCount(s.config.MaxSendBufferBytes))
+	}
 	s.streamsMap = newStreamsMap(
 		s,
 		s.newFlowController,
 		uint64(s.config.MaxIncomingStreams),
 		uint64(s.config.MaxIncomingUniStreams),
+		s.config.OnNewStream,
+		s.streamLimiter,
+		sendBufferLimiter,
 		s.perspective,
+		s.tracer,
 		s.version,
 	)
-	s.framer = newFramer(s.streamsMap, s.version)
+	s.framer = newFramer(s.streamsMap, s.config.RetransmissionOrder, s.version)
 	s.receivedPackets = make(chan *receivedPacket, protocol.MaxSessionUnprocessedPackets)
 	s.closeChan = make(chan closeError, 1)
 	s.sendingScheduled = make(chan struct{}, 1)
+	s.migrationRequests = make(chan *migrationRequest)
+	s.flushRequests = make(chan chan error)
+	s.streamCompleted = make(chan struct{}, 1)
+	s.streamCreditAvailable = make(chan struct{}, 1)
+	s.largestRcvdNonProbingPacket = protocol.InvalidPacketNumber
 	s.handshakeCtx, s.handshakeCtxCancel = context.WithCancel(context.Background())
 
-	now := time.Now()
+	now := s.config.Clock.Now()
 	s.lastPacketReceivedTime = now
 	s.sessionCreationTime = now
 
@@ -542,8 +719,11 @@ func (s *session) preSetup() {
 func (s *session) run() error {
 	defer s.ctxCancel()
 
-	s.timer = utils.NewTimer()
+	s.timer = utils.NewTimerWithClock(s.config.Clock.Now)
 
+	if s.tracer != nil {
+		s.tracer.StartedHandshake()
+	}
 	go s.cryptoStreamHandler.RunHandshake()
 	go func() {
 		if err := s.sendQueue.Run(); err != nil {
@@ -612,6 +792,12 @@ runLoop:
 				// We do all the interesting stuff after the switch statement, so
 				// nothing to see here.
 			case <-sendQueueAvailable:
+			case req := <-s.migrationRequests:
+				s.handleMigrationRequest(req)
+			case errChan := <-s.flushRequests:
+				s.pendingFlushes = append(s.pendingFlushes, errChan)
+			case <-s.streamCreditAvailable:
+				s.streamsMap.GrantStreamCredit()
 			case firstPacket := <-s.receivedPackets:
 				wasProcessed := s.handlePacketImpl(firstPacket)
 				// Don't set timers and send packets if the packet made us close the session.
@@ -653,7 +839,7 @@ runLoop:
 			}
 		}
 
-		now := time.Now()
+		now := s.config.Clock.Now()
 		if timeout := s.sentPacketHandler.GetLossDetectionTimeout(); !timeout.IsZero() && timeout.Before(now) {
 			// This could cause packets to be retransmitted.
 			// Check it before trying to send packets.
@@ -662,6 +848,10 @@ runLoop:
 			}
 		}
 
+		if s.pathValidation != nil && !now.Before(s.pathValidation.deadline) {
+			s.onPathValidationTimeout()
+		}
+
 		if keepAliveTime := s.nextKeepAliveTime(); !keepAliveTime.IsZero() && !now.Before(keepAliveTime) {
 			// send a PING frame since there is no activity in the session
 			s.logger.Debugf("Sending a keep-alive PING to keep the connection alive.")
@@ -670,24 +860,35 @@ runLoop:
 		} else if !s.handshakeComplete && now.Sub(s.sessionCreationTime) >= s.config.handshakeTimeout() {
 			s.destroyImpl(qerr.ErrHandshakeTimeout)
 			continue
-		} else {
-			idleTimeoutStartTime := s.idleTimeoutStartTime()
-			if (!s.handshakeComplete && now.Sub(idleTimeoutStartTime) >= s.config.HandshakeIdleTimeout) ||
-				(s.handshakeComplete && now.Sub(idleTimeoutStartTime) >= s.idleTimeout) {
-				s.destroyImpl(qerr.ErrIdleTimeout)
+		} else if idleTimeoutStartTime := s.idleTimeoutStartTime(); !s.handshakeComplete {
+			// An idle timeout before completion of the handshake is reported as a handshake timeout,
+			// so that it can be told apart from a connection that went idle after the handshake.
+			if now.Sub(idleTimeoutStartTime) >= s.config.HandshakeIdleTimeout {
+				s.destroyImpl(qerr.ErrHandshakeTimeout)
 				continue
 			}
+		} else if now.Sub(idleTimeoutStartTime) >= s.idleTimeout {
+			s.destroyImpl(qerr.ErrIdleTimeout)
+			continue
 		}
 
 		if s.sendQueue.WouldBlock() {
 			// The send queue is still busy sending out packets.
 			// Wait until there's space to enqueue new packets.
 			sendQueueAvailable = s.sendQueue.Available()
+			s.answerFlushRequests(ErrSendBlocked)
 			continue
 		}
 		if err := s.sendPackets(); err != nil {
 			s.closeLocal(err)
 		}
+		if len(s.pendingFlushes) > 0 {
+			if s.CanSendNow() {
+				s.answerFlushRequests(nil)
+			} else {
+				s.answerFlushRequests(ErrSendBlocked)
+			}
+		}
 		if s.sendQueue.WouldBlock() {
 			sendQueueAvailable = s.sendQueue.Available()
 		} else {
@@ -696,6 +897,10 @@ runLoop:
 	}
 
 	s.handleCloseError(&closeErr)
+	s.answerFlushRequests(errSessionClosed)
+	if s.pathValidation != nil && s.pathValidation.errChan != nil {
+		s.pathValidation.errChan <- errSessionClosed
+	}
 	if e := (&errCloseForRecreating{}); !errors.As(closeErr.err, &e) && s.tracer != nil {
 		s.tracer.Close()
 	}
@@ -730,10 +935,33 @@ func (s *session) ConnectionState() ConnectionState {
 	}
 }
 
+func (s *session) PeerSupportsDatagrams() bool {
+	<-s.handshakeCtx.Done()
+	// The handshake context is also cancelled when the session is closed before the handshake completed.
+	return s.peerParams != nil && s.supportsDatagrams()
+}
+
+func (s *session) PeerTransportParameter(id uint64) ([]byte, bool) {
+	<-s.handshakeCtx.Done()
+	if s.peerParams == nil {
+		return nil, false
+	}
+	return s.peerParams.RawValue(id)
+}
+
+func (s *session) SupportedVersions() []protocol.VersionNumber {
+	if s.serverSupportedVersions == nil {
+		return nil
+	}
+	versions := make([]protocol.VersionNumber, len(s.serverSupportedVersions))
+	copy(versions, s.serverSupportedVersions)
+	return versions
+}
+
 // Time when the next keep-alive packet should be sent.
 // It returns a zero time if no keep-alive should be sent.
 func (s *session) nextKeepAliveTime() time.Time {
-	if !s.config.KeepAlive || s.keepAlivePingSent || !s.firstAckElicitingPacketAfterIdleSentTime.IsZero() {
+	if (!s.config.KeepAlive && s.config.KeepAlivePeriod == 0) || s.keepAlivePingSent || !s.firstAckElicitingPacketAfterIdleSentTime.IsZero() {
 		return time.Time{}
 	}
 	return s.lastPacketReceivedTime.Add(s.keepAliveInterval)
@@ -768,7 +996,13 @@ func (s *session) maybeResetTimer() {
 	if !s.pacingDeadline.IsZero() {
 		deadline = utils.MinTime(deadline, s.pacingDeadline)
 	}
+	if s.pathValidation != nil {
+		deadline = utils.MinTime(deadline, s.pathValidation.deadline)
+	}
 
+	s.nextTimeoutMutex.Lock()
+	s.nextTimeout = deadline
+	s.nextTimeoutMutex.Unlock()
 	s.timer.Reset(deadline)
 }
 
@@ -778,6 +1012,12 @@ func (s *session) idleTimeoutStartTime() time.Time {
 
 func (s *session) handleHandshakeComplete() {
 	s.handshakeComplete = true
+	s.timestampsMutex.Lock()
+	s.handshakeCompleteTime = s.config.Clock.Now()
+	s.timestampsMutex.Unlock()
+	if s.tracer != nil {
+		s.tracer.CompletedHandshake()
+	}
 	s.handshakeCompleteChan = nil // prevent this case from ever being selected again
 	defer s.handshakeCtxCancel()
 	// Once the handshake completes, we have derived 1-RTT keys.
@@ -814,25 +1054,63 @@ func (s *session) handleHandshakeComplete() {
 
 func (s *session) handleHandshakeConfirmed() {
 	s.handshakeConfirmed = true
+	s.timestampsMutex.Lock()
+	s.handshakeConfirmedTime = s.config.Clock.Now()
+	s.timestampsMutex.Unlock()
 	s.sentPacketHandler.SetHandshakeConfirmed()
 	s.cryptoStreamHandler.SetHandshakeConfirmed()
 
 	if !s.config.DisablePathMTUDiscovery {
-		maxPacketSize := s.peerParams.MaxUDPPayloadSize
-		if maxPacketSize == 0 {
-			maxPacketSize = protocol.MaxByteCount
-		}
-		maxPacketSize = utils.MinByteCount(maxPacketSize, protocol.MaxPacketBufferSize)
-		s.mtuDiscoverer = newMTUDiscoverer(
-			s.rttStats,
-			getMaxPacketSize(s.conn.RemoteAddr()),
-			maxPacketSize,
-			func(size protocol.ByteCount) {
-				s.sentPacketHandler.SetMaxDatagramSize(size)
-				s.packer.SetMaxPacketSize(size)
-			},
-		)
+		s.startMTUDiscovery()
+	}
+}
+
+// startMTUDiscovery starts Path MTU Discovery on the current path.
+func (s *session) startMTUDiscovery() {
+	maxPacketSize := s.peerParams.MaxUDPPayloadSize
+	if maxPacketSize == 0 {
+		maxPacketSize = protocol.MaxByteCount
+	}
+	maxPacketSize = utils.MinByteCount(maxPacketSize, protocol.MaxPacketBufferSize)
+	var d mtuDiscoverer
+	d = newMTUDiscoverer(
+		s.rttStats,
+		s.config.Clock,
+		getMaxPacketSize(s.conn.RemoteAddr()),
+		maxPacketSize,
+		func(size protocol.ByteCount) {
+			// Ignore acknowledgements for probe packets sent on a previous path.
+			if s.mtuDiscoverer == d {
+				s.setMaxPacketSize(size)
+			}
+		},
+		s.tracer,
+	)
+	s.mtuDiscoverer = d
+}
+
+// getDestConnID returns the connection ID to use on the next packet.
+// It is called by the packer, and keeps track of the connection ID for ConnectionIDs.
+func (s *session) getDestConnID() protocol.ConnectionID {
+	connID := s.connIDManager.Get()
+	oldConnID := s.currentDestConnID
+	if connID.Equal(oldConnID) {
+		return connID
+	}
+	s.currentDestConnID = connID
+	s.publishConnIDs()
+	if s.config.OnConnectionIDChanged != nil {
+		s.config.OnConnectionIDChanged(oldConnID, connID)
 	}
+	return connID
+}
+
+func (s *session) setMaxPacketSize(size protocol.ByteCount) {
+	s.sentPacketHandler.SetMaxDatagramSize(size)
+	s.packer.SetMaxPacketSize(size)
+	s.currentMTUMutex.Lock()
+	s.currentMTU = size
+	s.currentMTUMutex.Unlock()
 }
 
 func (s *session) handlePacketImpl(rp *receivedPacket) bool {
@@ -856,29 +1134,23 @@ func (s *session) handlePacketImpl(rp *receivedPacket) bool {
 
 		hdr, packetData, rest, err := wire.ParsePacket(p.data, s.srcConnIDLen)
 		if err != nil {
-			if s.tracer != nil {
-				dropReason := logging.PacketDropHeaderParseError
-				if err == wire.ErrUnsupportedVersion {
-					dropReason = logging.PacketDropUnsupportedVersion
-				}
-				s.tracer.DroppedPacket(logging.PacketTypeNotDetermined, protocol.ByteCount(len(data)), dropReason)
+			dropReason := logging.PacketDropHeaderParseError
+			if err == wire.ErrUnsupportedVersion {
+				dropReason = logging.PacketDropUnsupportedVersion
 			}
+			s.droppedPacket(logging.PacketTypeNotDetermined, protocol.ByteCount(len(data)), dropReason, nil)
 			s.logger.Debugf("error parsing packet: %s", err)
 			break
 		}
 
 		if hdr.IsLongHeader && hdr.Version != s.version {
-			if s.tracer != nil {
-				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnexpectedVersion)
-			}
+			s.droppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnexpectedVersion, hdr)
 			s.logger.Debugf("Dropping packet with version %x. Expected %x.", hdr.Version, s.version)
 			break
 		}
 
 		if counter > 0 && !hdr.DestConnectionID.Equal(lastConnID) {
-			if s.tracer != nil {
-				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnknownConnectionID)
-			}
+			s.droppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnknownConnectionID, hdr)
 			s.logger.Debugf("coalesced packet has different destination connection ID: %s, expected %s", hdr.DestConnectionID, lastConnID)
 			break
 		}
@@ -920,27 +1192,30 @@ func (s *session) handleSinglePacket(p *receivedPacket, hdr *wire.Header) bool /
 	// The server can change the source connection ID with the first Handshake packet.
 	// After this, all packets with a different source connection have to be ignored.
 	if s.receivedFirstPacket && hdr.IsLongHeader && hdr.Type == protocol.PacketTypeInitial && !hdr.SrcConnectionID.Equal(s.handshakeDestConnID) {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeInitial, p.Size(), logging.PacketDropUnknownConnectionID)
-		}
+		s.droppedPacket(logging.PacketTypeInitial, p.Size(), logging.PacketDropUnknownConnectionID, hdr)
 		s.logger.Debugf("Dropping Initial packet (%d bytes) with unexpected source connection ID: %s (expected %s)", p.Size(), hdr.SrcConnectionID, s.handshakeDestConnID)
 		return false
 	}
 	// drop 0-RTT packets, if we are a client
 	if s.perspective == protocol.PerspectiveClient && hdr.Type == protocol.PacketType0RTT {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketType0RTT, p.Size(), logging.PacketDropKeyUnavailable)
-		}
+		s.droppedPacket(logging.PacketType0RTT, p.Size(), logging.PacketDropKeyUnavailable, hdr)
 		return false
 	}
 
+	if s.config.PacketLossSimulator != nil {
+		index := s.numPacketsReceived
+		s.numPacketsReceived++
+		if s.simulatePacketLoss(PacketDirectionReceived, index) {
+			s.logger.Debugf("Simulating loss of received %s packet (%d bytes).", hdr.PacketType(), p.Size())
+			return false
+		}
+	}
+
 	packet, err := s.unpacker.Unpack(hdr, p.rcvTime, p.data)
 	if err != nil {
 		switch err {
 		case handshake.ErrKeysDropped:
-			if s.tracer != nil {
-				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropKeyUnavailable)
-			}
+			s.droppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropKeyUnavailable, hdr)
 			s.logger.Debugf("Dropping %s packet (%d bytes) because we already dropped the keys.", hdr.PacketType(), p.Size())
 		case handshake.ErrKeysNotYetAvailable:
 			// Sealer for this encryption level not yet available.
@@ -954,17 +1229,13 @@ func (s *session) handleSinglePacket(p *receivedPacket, hdr *wire.Header) bool /
 			})
 		case handshake.ErrDecryptionFailed:
 			// This might be a packet injected by an attacker. Drop it.
-			if s.tracer != nil {
-				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropPayloadDecryptError)
-			}
+			s.droppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropPayloadDecryptError, hdr)
 			s.logger.Debugf("Dropping %s packet (%d bytes) that could not be unpacked. Error: %s", hdr.PacketType(), p.Size(), err)
 		default:
 			var headerErr *headerParseError
 			if errors.As(err, &headerErr) {
 				// This might be a packet injected by an attacker. Drop it.
-				if s.tracer != nil {
-					s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropHeaderParseError)
-				}
+				s.droppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropHeaderParseError, hdr)
 				s.logger.Debugf("Dropping %s packet (%d bytes) for which we couldn't unpack the header. Error: %s", hdr.PacketType(), p.Size(), err)
 			} else {
 				// This is an error returned by the AEAD (other than ErrDecryptionFailed).
@@ -982,12 +1253,22 @@ func (s *session) handleSinglePacket(p *receivedPacket, hdr *wire.Header) bool /
 
 	if s.receivedPacketHandler.IsPotentiallyDuplicate(packet.packetNumber, packet.encryptionLevel) {
 		s.logger.Debugf("Dropping (potentially) duplicate packet.")
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropDuplicate)
-		}
+		s.droppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropDuplicate, hdr)
 		return false
 	}
 
+	if !hdr.DestConnectionID.Equal(s.currentSrcConnID) {
+		s.currentSrcConnID = hdr.DestConnectionID
+		s.publishConnIDs()
+	}
+
+	// The client might have migrated to a new address, see section 9 of RFC 9000.
+	if s.perspective == protocol.PerspectiveServer && s.handshakeConfirmed && packet.encryptionLevel == protocol.Encryption1RTT &&
+		p.remoteAddr != nil && !equalAddr(p.remoteAddr, s.conn.RemoteAddr()) {
+		s.probedPath = s.getClientAddressValidation(p)
+		defer func() { s.probedPath = nil }()
+	}
+
 	if err := s.handleUnpackedPacket(packet, p.ecn, p.rcvTime, p.Size()); err != nil {
 		s.closeLocal(err)
 		return false
@@ -995,26 +1276,31 @@ func (s *session) handleSinglePacket(p *receivedPacket, hdr *wire.Header) bool /
 	return true
 }
 
+// droppedPacket reports a dropped packet to the tracer and to Config.OnDroppedPacket.
+// The header is nil if the packet was dropped before its header was parsed.
+func (s *session) droppedPacket(typ logging.PacketType, size protocol.ByteCount, reason logging.PacketDropReason, hdr *wire.Header) {
+	if s.tracer != nil {
+		s.tracer.DroppedPacket(typ, size, reason)
+	}
+	if s.config.OnDroppedPacket != nil {
+		s.config.OnDroppedPacket(reason, hdr)
+	}
+}
+
 func (s *session) handleRetryPacket(hdr *wire.Header, data []byte) bool /* was this a valid Retry */ {
 	if s.perspective == protocol.PerspectiveServer {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket, hdr)
 		s.logger.Debugf("Ignoring Retry.")
 		return false
 	}
 	if s.receivedFirstPacket {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket, hdr)
 		s.logger.Debugf("Ignoring Retry, since we already received a packet.")
 		return false
 	}
 	destConnID := s.connIDManager.Get()
 	if hdr.SrcConnectionID.Equal(destConnID) {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropUnexpectedPacket, hdr)
 		s.logger.Debugf("Ignoring Retry, since the server didn't change the Source Connection ID.")
 		return false
 	}
@@ -1027,9 +1313,7 @@ func (s *session) handleRetryPacket(hdr *wire.Header, data []byte) bool /* was t
 
 	tag := handshake.GetRetryIntegrityTag(data[:len(data)-16], destConnID, hdr.Version)
 	if !bytes.Equal(data[len(data)-16:], tag[:]) {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropPayloadDecryptError)
-		}
+		s.droppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropPayloadDecryptError, hdr)
 		s.logger.Debugf("Ignoring spoofed Retry. Integrity Tag doesn't match.")
 		return false
 	}
@@ -1044,6 +1328,7 @@ func (s *session) handleRetryPacket(hdr *wire.Header, data []byte) bool /* was t
 	}
 	newDestConnID := hdr.SrcConnectionID
 	s.receivedRetry = true
+	atomic.AddUint32(&s.numRetries, 1)
 	if err := s.sentPacketHandler.ResetForRetry(); err != nil {
 		s.closeLocal(err)
 		return false
@@ -1060,26 +1345,20 @@ func (s *session) handleRetryPacket(hdr *wire.Header, data []byte) bool /* was t
 func (s *session) handleVersionNegotiationPacket(p *receivedPacket) {
 	if s.perspective == protocol.PerspectiveServer || // servers never receive version negotiation packets
 		s.receivedFirstPacket || s.versionNegotiated { // ignore delayed / duplicated version negotiation packets
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedPacket)
-		}
+		s.droppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedPacket, nil)
 		return
 	}
 
 	hdr, supportedVersions, err := wire.ParseVersionNegotiationPacket(bytes.NewReader(p.data))
 	if err != nil {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropHeaderParseError)
-		}
+		s.droppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropHeaderParseError, nil)
 		s.logger.Debugf("Error parsing Version Negotiation packet: %s", err)
 		return
 	}
 
 	for _, v := range supportedVersions {
 		if v == s.version {
-			if s.tracer != nil {
-				s.tracer.DroppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedVersion)
-			}
+			s.droppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedVersion, hdr)
 			// The Version Negotiation packet contains the version that we offered.
 			// This might be a packet sent by an attacker, or it was corrupted.
 			return
@@ -1090,6 +1369,11 @@ func (s *session) handleVersionNegotiationPacket(p *receivedPacket) {
 	if s.tracer != nil {
 		s.tracer.ReceivedVersionNegotiationPacket(hdr, supportedVersions)
 	}
+	if s.config.DisableVersionNegotiation {
+		s.logger.Infof("Version negotiation is disabled.")
+		s.destroyImpl(ErrVersionNegotiationDisabled)
+		return
+	}
 	newVersion, ok := protocol.ChooseSupportedVersion(s.config.Versions, supportedVersions)
 	if !ok {
 		s.destroyImpl(&VersionNegotiationError{
@@ -1106,8 +1390,9 @@ func (s *session) handleVersionNegotiationPacket(p *receivedPacket) {
 	s.logger.Infof("Switching to QUIC version %s.", newVersion)
 	nextPN, _ := s.sentPacketHandler.PeekPacketNumber(protocol.EncryptionInitial)
 	s.destroyImpl(&errCloseForRecreating{
-		nextPacketNumber: nextPN,
-		nextVersion:      newVersion,
+		nextPacketNumber:        nextPN,
+		nextVersion:             newVersion,
+		serverSupportedVersions: supportedVersions,
 	})
 }
 
@@ -1172,7 +1457,7 @@ func (s *session) handleUnpackedPacket(
 	// If we're not tracing, this slice will always remain empty.
 	var frames []wire.Frame
 	r := bytes.NewReader(packet.data)
-	var isAckEliciting bool
+	var isAckEliciting, isNonProbing bool
 	for {
 		frame, err := s.frameParser.ParseNext(r, packet.encryptionLevel)
 		if err != nil {
@@ -1184,6 +1469,9 @@ func (s *session) handleUnpackedPacket(
 		if ackhandler.IsFrameAckEliciting(frame) {
 			isAckEliciting = true
 		}
+		if !isProbingFrame(frame) {
+			isNonProbing = true
+		}
 		// Only process frames now if we're not logging.
 		// If we're logging, we need to make sure that the packet_received event is logged first.
 		if s.tracer == nil {
@@ -1208,9 +1496,36 @@ func (s *session) handleUnpackedPacket(
 		}
 	}
 
+	if isNonProbing && packet.encryptionLevel == protocol.Encryption1RTT && packet.packetNumber > s.largestRcvdNonProbingPacket {
+		s.largestRcvdNonProbingPacket = packet.packetNumber
+		// Only follow the client to its new address once the address is validated.
+		if s.probedPath != nil && !s.probedPath.challengeSent {
+			s.logger.Debugf("Client sent a non-probing packet from %s. Validating the new address.", s.probedPath.conn.RemoteAddr())
+			if err := s.sendPathChallenge(s.probedPath); err != nil {
+				s.logger.Debugf("Error sending PATH_CHALLENGE to %s: %s", s.probedPath.conn.RemoteAddr(), err)
+			}
+		}
+		// The packet with the largest packet number was sent from the current address.
+		// The packets from the new address were reordered, or the client moved back, see section 9.3 of RFC 9000.
+		if s.perspective == protocol.PerspectiveServer && s.probedPath == nil && s.pathValidation != nil && s.pathValidation.challengeSent {
+			s.abandonPathValidation(errors.New("received a non-probing packet with a larger packet number from the current address"))
+		}
+	}
+
 	return s.receivedPacketHandler.ReceivedPacket(packet.packetNumber, ecn, packet.encryptionLevel, rcvTime, isAckEliciting)
 }
 
+// isProbingFrame says if a frame is a probing frame, see section 9.1 of RFC 9000.
+// Packets that only contain probing frames don't cause the server to migrate to the client's new address.
+func isProbingFrame(f wire.Frame) bool {
+	switch f.(type) {
+	case *wire.PathChallengeFrame, *wire.PathResponseFrame, *wire.NewConnectionIDFrame:
+		return true
+	default:
+		return false
+	}
+}
+
 func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, destConnID protocol.ConnectionID) error {
 	var err error
 	wire.LogFrame(s.logger, f, false)
@@ -1240,8 +1555,7 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 	case *wire.PathChallengeFrame:
 		s.handlePathChallengeFrame(frame)
 	case *wire.PathResponseFrame:
-		// since we don't send PATH_CHALLENGEs, we don't expect PATH_RESPONSEs
-		err = errors.New("unexpected PATH_RESPONSE frame")
+		s.handlePathResponseFrame(frame)
 	case *wire.NewTokenFrame:
 		err = s.handleNewTokenFrame(frame)
 	case *wire.NewConnectionIDFrame:
@@ -1260,14 +1574,18 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 
 // handlePacket is called by the server with a new packet
 func (s *session) handlePacket(p *receivedPacket) {
+	// Packets are passed to the session right after they are read from the connection.
+	// Timestamp them using the session's clock, so that all timing uses the same clock.
+	p.rcvTime = s.config.Clock.Now()
+	if s.config.PacketCapture != nil {
+		s.config.PacketCapture(PacketDirectionReceived, p.data, p.remoteAddr)
+	}
 	// Discard packets once the amount of queued packets is larger than
 	// the channel size, protocol.MaxSessionUnprocessedPackets
 	select {
 	case s.receivedPackets <- p:
 	default:
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropDOSPrevention)
-		}
+		s.droppedPacket(logging.PacketTypeNotDetermined, p.Size(), logging.PacketDropDOSPrevention, nil)
 	}
 }
 
@@ -1336,6 +1654,9 @@ func (s *session) handleMaxStreamsFrame(frame *wire.MaxStreamsFrame) {
 }
 
 func (s *session) handleResetStreamFrame(frame *wire.ResetStreamFrame) error {
+	if err := s.checkStreamResetRate(); err != nil {
+		return err
+	}
 	str, err := s.streamsMap.GetOrOpenReceiveStream(frame.StreamID)
 	if err != nil {
 		return err
@@ -1348,6 +1669,9 @@ func (s *session) handleResetStreamFrame(frame *wire.ResetStreamFrame) error {
 }
 
 func (s *session) handleStopSendingFrame(frame *wire.StopSendingFrame) error {
+	if err := s.checkStreamResetRate(); err != nil {
+		return err
+	}
 	str, err := s.streamsMap.GetOrOpenSendStream(frame.StreamID)
 	if err != nil {
 		return err
@@ -1360,10 +1684,108 @@ func (s *session) handleStopSendingFrame(frame *wire.StopSendingFrame) error {
 	return nil
 }
 
+func (s *session) checkStreamResetRate() error {
+	if s.streamResetLimiter == nil || s.streamResetLimiter.ReceivedFrame(s.config.Clock.Now()) {
+		return nil
+	}
+	return &qerr.TransportError{
+		ErrorCode:    qerr.ProtocolViolation,
+		ErrorMessage: "too many RESET_STREAM and STOP_SENDING frames",
+	}
+}
+
 func (s *session) handlePathChallengeFrame(frame *wire.PathChallengeFrame) {
+	// The PATH_RESPONSE has to be sent on the path that the PATH_CHALLENGE was received on.
+	if s.probedPath != nil {
+		if err := s.sendPathProbe(s.probedPath, &wire.PathResponseFrame{Data: frame.Data}); err != nil {
+			s.logger.Debugf("Error sending PATH_RESPONSE to %s: %s", s.probedPath.conn.RemoteAddr(), err)
+		}
+		return
+	}
 	s.queueControlFrame(&wire.PathResponseFrame{Data: frame.Data})
 }
 
+func (s *session) handlePathResponseFrame(frame *wire.PathResponseFrame) {
+	// PATH_RESPONSE frames might arrive after the path validation was abandoned,
+	// or might be duplicated by the network.
+	if s.pathValidation == nil || !s.pathValidation.challengeSent || frame.Data != s.pathValidation.data {
+		s.logger.Debugf("Ignoring PATH_RESPONSE that doesn't match an outstanding PATH_CHALLENGE.")
+		return
+	}
+	pv := s.pathValidation
+	s.pathValidation = nil
+	if s.perspective == protocol.PerspectiveServer {
+		s.logger.Infof("Validated the client's new address. Migrating from %s to %s.", s.conn.RemoteAddr(), pv.conn.RemoteAddr())
+		s.probedPath = nil
+		s.switchPath(pv.conn)
+		return
+	}
+	s.logger.Infof("Validated new path. Migrating from %s to %s.", s.conn.LocalAddr(), pv.conn.LocalAddr())
+	s.connIDManager.SwitchToNewPath()
+	s.switchPath(pv.conn)
+	// The server now sends on the new path. Packets arriving on the old path are dropped.
+	s.removeRunner(s.pathRunner)
+	s.pathRunner = pv.runner
+	pv.errChan <- nil
+}
+
+// getClientAddressValidation returns the path validation for the new client address that a packet was received from.
+// The server only validates one new address at a time. A packet from yet another address replaces the path validation.
+func (s *session) getClientAddressValidation(p *receivedPacket) *pathValidation {
+	pv := s.pathValidation
+	if pv == nil || !equalAddr(pv.conn.RemoteAddr(), p.remoteAddr) {
+		// Wait for a non-probing packet from the new address, see section 9.3 of RFC 9000.
+		pv = &pathValidation{
+			conn:     s.conn.WithRemoteAddr(p.remoteAddr, p.info),
+			deadline: s.config.Clock.Now().Add(time.Duration(s.config.MaxPathChallenges) * s.pathChallengeTimeout()),
+		}
+		rand.Read(pv.data[:])
+		s.pathValidation = pv
+	}
+	pv.bytesReceived += p.Size()
+	return pv
+}
+
+// pathChallengeTimeout is the time after which an unanswered PATH_CHALLENGE is retransmitted.
+// The RTT of the new path is unknown. Use the PTO derived from the default initial RTT,
+// unless the current path has a higher PTO, see section 8.2.4 of RFC 9000.
+func (s *session) pathChallengeTimeout() time.Duration {
+	return utils.MaxDuration(s.rttStats.PTO(true), utils.NewRTTStats().PTO(true))
+}
+
+// sendPathChallenge sends a PATH_CHALLENGE on the path that is validated.
+// All PATH_CHALLENGE frames of a path validation carry the same data,
+// such that a PATH_RESPONSE to any of them completes the validation.
+func (s *session) sendPathChallenge(pv *pathValidation) error {
+	pv.challengeSent = true
+	pv.numChallenges++
+	pv.deadline = s.config.Clock.Now().Add(s.pathChallengeTimeout())
+	return s.sendPathProbe(pv, &wire.PathChallengeFrame{Data: pv.data})
+}
+
+// onPathValidationTimeout retransmits the PATH_CHALLENGE,
+// or abandons the path validation once Config.MaxPathChallenges PATH_CHALLENGE frames were sent.
+func (s *session) onPathValidationTimeout() {
+	pv := s.pathValidation
+	if !pv.challengeSent {
+		s.abandonPathValidation(errors.New("path validation timed out"))
+		return
+	}
+	if pv.numChallenges >= s.config.MaxPathChallenges {
+		s.abandonPathValidation(fmt.Errorf("path validation failed: no response to %d PATH_CHALLENGE frames", pv.numChallenges))
+		return
+	}
+	if err := s.sendPathChallenge(pv); err != nil {
+		// The server might be blocked by the anti-amplification limit.
+		// It retries when the next PATH_CHALLENGE is due.
+		if s.perspective == protocol.PerspectiveServer {
+			s.logger.Debugf("Error sending PATH_CHALLENGE to %s: %s", pv.conn.RemoteAddr(), err)
+			return
+		}
+		s.abandonPathValidation(err)
+	}
+}
+
 func (s *session) handleNewTokenFrame(frame *wire.NewTokenFrame) error {
 	if s.perspective == protocol.PerspectiveServer {
 		return &qerr.TransportError{
@@ -1419,6 +1841,10 @@ func (s *session) handleDatagramFrame(f *wire.DatagramFrame) error {
 			ErrorMessage: "DATAGRAM frame too large",
 		}
 	}
+	if len(f.Data) == 0 && !s.config.DeliverEmptyDatagrams {
+		s.logger.Debugf("Dropping empty DATAGRAM frame")
+		return nil
+	}
 	s.datagramQueue.HandleDatagramFrame(f)
 	return nil
 }
@@ -1475,6 +1901,23 @@ func (s *session) CloseWithError(code ApplicationErrorCode, desc string) error {
 	return nil
 }
 
+func (s *session) CloseGracefully(timeout time.Duration) error {
+	s.streamsMap.StopAccepting()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for s.streamsMap.NumOpenStreams() > 0 {
+		select {
+		case <-s.streamCompleted:
+		case <-s.ctx.Done():
+			return nil
+		case <-timer.C:
+			s.CloseWithError(0, "")
+			return ErrGracefulCloseTimeout
+		}
+	}
+	return s.CloseWithError(0, "")
+}
+
 func (s *session) handleCloseError(closeErr *closeError) {
 	e := closeErr.err
 	if e == nil {
@@ -1497,6 +1940,7 @@ func (s *session) handleCloseError(closeErr *closeError) {
 		errors.Is(e, qerr.ErrHandshakeTimeout),
 		errors.As(e, &statelessResetErr),
 		errors.As(e, &versionNegotiationErr),
+		errors.Is(e, ErrVersionNegotiationDisabled),
 		errors.As(e, &recreateErr),
 		errors.As(e, &applicationErr),
 		errors.As(e, &transportErr):
@@ -1519,7 +1963,7 @@ func (s *session) handleCloseError(closeErr *closeError) {
 
 	// If this is a remote close we're done here
 	if closeErr.remote {
-		s.connIDGenerator.ReplaceWithClosed(newClosedRemoteSession(s.perspective))
+		s.connIDGenerator.ReplaceWithClosed(newClosedRemoteSession(s.perspective), s.config.DrainingTimeout)
 		return
 	}
 	if closeErr.immediate {
@@ -1531,7 +1975,7 @@ func (s *session) handleCloseError(closeErr *closeError) {
 		s.logger.Debugf("Error sending CONNECTION_CLOSE: %s", err)
 	}
 	cs := newClosedLocalSession(s.conn, connClosePacket, s.perspective, s.logger)
-	s.connIDGenerator.ReplaceWithClosed(cs)
+	s.connIDGenerator.ReplaceWithClosed(cs, s.config.DrainingTimeout)
 }
 
 func (s *session) dropEncryptionLevel(encLevel protocol.EncryptionLevel) {
@@ -1619,6 +2063,14 @@ func (s *session) applyTransportParameters() {
 	// Our local idle timeout will always be > 0.
 	s.idleTimeout = utils.MinNonZeroDuration(s.config.MaxIdleTimeout, params.MaxIdleTimeout)
 	s.keepAliveInterval = utils.MinDuration(s.idleTimeout/2, protocol.MaxKeepAliveInterval)
+	if period := s.config.KeepAlivePeriod; period > 0 {
+		if period < s.idleTimeout/2 {
+			s.keepAliveInterval = period
+		} else {
+			s.keepAliveInterval = s.idleTimeout / 2
+			s.logger.Infof("Config.KeepAlivePeriod (%s) is not smaller than half the idle timeout (%s). Using a keep-alive period of %s.", period, s.idleTimeout, s.keepAliveInterval)
+		}
+	}
 	s.streamsMap.UpdateLimits(params)
 	s.packer.HandleTransportParameters(params)
 	s.frameParser.SetAckDelayExponent(params.AckDelayExponent)
@@ -1639,8 +2091,18 @@ func (s *session) sendPackets() error {
 	s.pacingDeadline = time.Time{}
 
 	var sentPacket bool // only used in for packets sent in send mode SendAny
+	lastSendMode := ackhandler.SendNone
+	var sendQueueBlocked bool
+	// Take a snapshot of the send state for CanSendNow.
+	defer func() {
+		s.canSendMutex.Lock()
+		s.congestionAllowsSend = lastSendMode == ackhandler.SendAny && !sendQueueBlocked
+		s.pacingAllowsSendAfter = s.pacingDeadline
+		s.canSendMutex.Unlock()
+	}()
 	for {
 		sendMode := s.sentPacketHandler.SendMode()
+		lastSendMode = sendMode
 		if sendMode == ackhandler.SendAny && s.handshakeComplete && !s.sentPacketHandler.HasPacingBudget() {
 			deadline := s.sentPacketHandler.TimeUntilSend()
 			if deadline.IsZero() {
@@ -1655,6 +2117,12 @@ func (s *session) sendPackets() error {
 			}
 			sendMode = ackhandler.SendAck
 		}
+		if sendMode != ackhandler.SendNone && s.encryptionRateLimiter != nil && !s.encryptionRateLimiter.HasBudget(s.config.Clock.Now()) {
+			// We're not allowed to seal any more packets right now.
+			// This applies to all packets, including ACK-only and probe packets.
+			s.pacingDeadline = s.encryptionRateLimiter.TimeUntilSend()
+			return nil
+		}
 		switch sendMode {
 		case ackhandler.SendNone:
 			return nil
@@ -1696,12 +2164,26 @@ func (s *session) sendPackets() error {
 			return nil
 		}
 		if s.sendQueue.WouldBlock() {
+			sendQueueBlocked = true
 			return nil
 		}
 	}
 }
 
 func (s *session) maybeSendAckOnlyPacket() error {
+	// Before the handshake is confirmed, ACKs for all packet number spaces are sent in a single datagram.
+	if !s.handshakeConfirmed {
+		packet, err := s.packer.PackCoalescedPacket(true)
+		if err != nil {
+			return err
+		}
+		if packet == nil {
+			return nil
+		}
+		s.sendPackedCoalescedPacket(packet, s.config.Clock.Now())
+		return nil
+	}
+
 	packet, err := s.packer.MaybePackAckPacket(s.handshakeConfirmed)
 	if err != nil {
 		return err
@@ -1709,7 +2191,7 @@ func (s *session) maybeSendAckOnlyPacket() error {
 	if packet == nil {
 		return nil
 	}
-	s.sendPackedPacket(packet, time.Now())
+	s.sendPackedPacket(packet, s.config.Clock.Now())
 	return nil
 }
 
@@ -1751,7 +2233,7 @@ func (s *session) sendProbePacket(encLevel protocol.EncryptionLevel) error {
 	if packet == nil || packet.packetContents == nil {
 		return fmt.Errorf("session BUG: couldn't pack %s probe packet", encLevel)
 	}
-	s.sendPackedPacket(packet, time.Now())
+	s.sendPackedPacket(packet, s.config.Clock.Now())
 	return nil
 }
 
@@ -1761,21 +2243,13 @@ func (s *session) sendPacket() (bool, error) {
 	}
 	s.windowUpdateQueue.QueueAll()
 
-	now := time.Now()
+	now := s.config.Clock.Now()
 	if !s.handshakeConfirmed {
-		packet, err := s.packer.PackCoalescedPacket()
+		packet, err := s.packer.PackCoalescedPacket(false)
 		if err != nil || packet == nil {
 			return false, err
 		}
-		s.logCoalescedPacket(packet)
-		for _, p := range packet.packets {
-			if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && p.IsAckEliciting() {
-				s.firstAckElicitingPacketAfterIdleSentTime = now
-			}
-			s.sentPacketHandler.SentPacket(p.ToAckHandlerPacket(now, s.retransmissionQueue))
-		}
-		s.connIDManager.SentPacket()
-		s.sendQueue.Send(packet.buffer)
+		s.sendPackedCoalescedPacket(packet, now)
 		return true, nil
 	}
 	if !s.config.DisablePathMTUDiscovery && s.mtuDiscoverer.ShouldSendProbe(now) {
@@ -1794,14 +2268,69 @@ func (s *session) sendPacket() (bool, error) {
 	return true, nil
 }
 
+func (s *session) sendPackedCoalescedPacket(packet *coalescedPacket, now time.Time) {
+	s.logCoalescedPacket(packet)
+	for _, p := range packet.packets {
+		if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && p.IsAckEliciting() {
+			s.firstAckElicitingPacketAfterIdleSentTime = now
+		}
+		s.sentPacketHandler.SentPacket(p.ToAckHandlerPacket(now, s.retransmissionQueue))
+		if s.encryptionRateLimiter != nil {
+			s.encryptionRateLimiter.SealedPacket(now)
+		}
+	}
+	s.connIDManager.SentPacket()
+	var drop bool
+	for _, p := range packet.packets {
+		if s.simulatePacketLoss(PacketDirectionSent, p.header.PacketNumber) {
+			drop = true
+		}
+	}
+	if drop {
+		s.logger.Debugf("Simulating loss of sent coalesced packet.")
+		packet.buffer.Release()
+		return
+	}
+	for _, p := range packet.packets {
+		if p.ack != nil {
+			atomic.AddUint64(&s.acksSent, 1)
+		}
+	}
+	s.sendQueue.Send(packet.buffer, protocol.ECNNon)
+}
+
 func (s *session) sendPackedPacket(packet *packedPacket, now time.Time) {
 	if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && packet.IsAckEliciting() {
 		s.firstAckElicitingPacketAfterIdleSentTime = now
 	}
 	s.logPacket(packet)
-	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(now, s.retransmissionQueue))
+	// Only 1-RTT packets are marked, since ECN is only validated in the application data packet number space.
+	ecn := protocol.ECNNon
+	if packet.EncryptionLevel() == protocol.Encryption1RTT {
+		ecn = s.sentPacketHandler.ECNMode()
+	}
+	p := packet.ToAckHandlerPacket(now, s.retransmissionQueue)
+	p.ECN = ecn
+	s.sentPacketHandler.SentPacket(p)
+	if s.encryptionRateLimiter != nil {
+		s.encryptionRateLimiter.SealedPacket(now)
+	}
 	s.connIDManager.SentPacket()
-	s.sendQueue.Send(packet.buffer)
+	if s.simulatePacketLoss(PacketDirectionSent, packet.header.PacketNumber) {
+		s.logger.Debugf("Simulating loss of sent packet %d.", packet.header.PacketNumber)
+		packet.buffer.Release()
+		return
+	}
+	if packet.ack != nil {
+		atomic.AddUint64(&s.acksSent, 1)
+	}
+	s.sendQueue.Send(packet.buffer, ecn)
+}
+
+// simulatePacketLoss says if the PacketLossSimulator drops a packet.
+// For sent packets, the index is the packet number. For received packets, it is the number of packets received before.
+func (s *session) simulatePacketLoss(dir PacketDirection, index protocol.PacketNumber) bool {
+	return s.config.PacketLossSimulator != nil && s.config.PacketLossSimulator(dir, index)
 }
 
 func (s *session) sendConnectionClose(e error) ([]byte, error) {
@@ -1823,7 +2352,7 @@ func (s *session) sendConnectionClose(e error) ([]byte, error) {
 		return nil, err
 	}
 	s.logCoalescedPacket(packet)
-	return packet.buffer.Data, s.conn.Write(packet.buffer.Data)
+	return packet.buffer.Data, s.conn.Write(packet.buffer.Data, protocol.ECNNon)
 }
 
 func (s *session) logPacketContents(p *packetContents) {
@@ -1904,11 +2433,15 @@ func (s *session) newFlowController(id protocol.StreamID) flowcontrol.StreamFlow
 			initialSendWindow = s.peerParams.InitialMaxStreamDataBidiLocal
 		}
 	}
+	maxStreamReceiveWindow := s.config.MaxStreamReceiveWindow
+	if s.config.DisableReceiveWindowAutoTuning {
+		maxStreamReceiveWindow = s.config.InitialStreamReceiveWindow
+	}
 	return flowcontrol.NewStreamFlowController(
 		id,
 		s.connFlowController,
 		protocol.ByteCount(s.config.InitialStreamReceiveWindow),
-		protocol.ByteCount(s.config.MaxStreamReceiveWindow),
+		protocol.ByteCount(maxStreamReceiveWindow),
 		initialSendWindow,
 		s.onHasStreamWindowUpdate,
 		s.rttStats,
@@ -1929,9 +2462,7 @@ func (s *session) tryQueueingUndecryptablePacket(p *receivedPacket, hdr *wire.He
 		panic("shouldn't queue undecryptable packets after handshake completion")
 	}
 	if len(s.undecryptablePackets)+1 > protocol.MaxUndecryptablePackets {
-		if s.tracer != nil {
-			s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropDOSPrevention)
-		}
+		s.droppedPacket(logging.PacketTypeFromHeader(hdr), p.Size(), logging.PacketDropDOSPrevention, hdr)
 		s.logger.Infof("Dropping undecryptable packet (%d bytes). Undecryptable packet queue full.", p.Size())
 		return
 	}
@@ -1962,9 +2493,34 @@ func (s *session) onHasStreamData(id protocol.StreamID) {
 	s.scheduleSending()
 }
 
+func (s *session) onHasStreamRetransmission(id protocol.StreamID, priority uint8) {
+	s.framer.AddRetransmittingStream(id, priority)
+	s.scheduleSending()
+}
+
 func (s *session) onStreamCompleted(id protocol.StreamID) {
 	if err := s.streamsMap.DeleteStream(id); err != nil {
 		s.closeLocal(err)
+		return
+	}
+	select {
+	case s.streamCompleted <- struct{}{}:
+	default:
+	}
+}
+
+func (s *session) onStreamDataSent(n protocol.ByteCount) {
+	atomic.AddUint64(&s.streamBytesSent, uint64(n))
+}
+
+func (s *session) onStreamDataAcked(n protocol.ByteCount) {
+	atomic.AddUint64(&s.streamBytesAcked, uint64(n))
+}
+
+func (s *session) onStreamCreditAvailable() {
+	select {
+	case s.streamCreditAvailable <- struct{}{}:
+	default:
 	}
 }
 
@@ -1983,17 +2539,309 @@ func (s *session) ReceiveMessage() ([]byte, error) {
 }
 
 func (s *session) LocalAddr() net.Addr {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
 	return s.conn.LocalAddr()
 }
 
 func (s *session) RemoteAddr() net.Addr {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
 	return s.conn.RemoteAddr()
 }
 
+// MigrateTo migrates the session to a new connection.
+// It blocks until the new path is validated, or until path validation fails.
+func (s *session) MigrateTo(conn net.PacketConn) error {
+	if s.perspective == protocol.PerspectiveServer {
+		return errors.New("only the client can migrate a connection")
+	}
+	req := &migrationRequest{conn: conn, errChan: make(chan error, 1)}
+	select {
+	case s.migrationRequests <- req:
+	case <-s.ctx.Done():
+		return errSessionClosed
+	}
+	// Once the run loop accepted the request, it always reports the outcome.
+	return <-req.errChan
+}
+
+func (s *session) handleMigrationRequest(req *migrationRequest) {
+	if !s.handshakeConfirmed {
+		req.errChan <- errors.New("can't migrate before the handshake is confirmed")
+		return
+	}
+	if s.peerParams.DisableActiveMigration {
+		req.errChan <- errors.New("the peer disabled active migration")
+		return
+	}
+	if s.pathValidation != nil {
+		req.errChan <- errors.New("already migrating to a new connection")
+		return
+	}
+	// Using the same connection ID on both paths would allow an observer to link them.
+	destConnID, resetToken, ok := s.connIDManager.GetForNewPath()
+	if !ok {
+		req.errChan <- errors.New("the peer didn't provide an unused connection ID")
+		return
+	}
+	runner, err := getMultiplexer().AddConn(req.conn, s.config.ConnectionIDLength, s.config.StatelessResetKey, s.config.ReceiveBufferSize, s.config.Tracer)
+	if err != nil {
+		s.connIDManager.AbandonNewPath()
+		req.errChan <- err
+		return
+	}
+	// Until the path is validated, the peer continues sending on the old path.
+	// Packets are handled no matter which connection they arrive on.
+	for _, connID := range s.connIDGenerator.ActiveConnIDs() {
+		runner.Add(connID, s)
+	}
+	if token := s.connIDManager.StatelessResetToken(); token != nil {
+		runner.AddResetToken(*token, s)
+	}
+	runner.AddResetToken(resetToken, s)
+	s.runners.AddRunner(runner)
+
+	var bw batchWriter
+	if !s.config.DisableBatchedWrites {
+		bw = newBatchWriter(req.conn)
+	}
+	var conn sendConn = newSendPconn(req.conn, s.conn.RemoteAddr(), bw)
+	if s.config.PacketCapture != nil {
+		conn = newCaptureConn(conn, s.config.PacketCapture)
+	}
+	pv := &pathValidation{
+		conn:    conn,
+		runner:  runner,
+		connID:  destConnID,
+		errChan: req.errChan,
+	}
+	rand.Read(pv.data[:])
+	s.pathValidation = pv
+	s.logger.Debugf("Probing new path %s -> %s.", pv.conn.LocalAddr(), pv.conn.RemoteAddr())
+	if err := s.sendPathChallenge(pv); err != nil {
+		s.abandonPathValidation(err)
+	}
+}
+
+// abandonPathValidation stops validating a new path.
+// The client stops using the connection that it tried to migrate to.
+func (s *session) abandonPathValidation(e error) {
+	pv := s.pathValidation
+	s.pathValidation = nil
+	if s.perspective == protocol.PerspectiveServer {
+		s.logger.Debugf("Abandoning validation of client address %s: %s", pv.conn.RemoteAddr(), e)
+		return
+	}
+	s.logger.Debugf("Abandoning path validation for %s: %s", pv.conn.LocalAddr(), e)
+	// This removes the stateless reset token of the new path from all runners, so it is called before removing the runner.
+	s.connIDManager.AbandonNewPath()
+	s.removeRunner(pv.runner)
+	pv.errChan <- e
+}
+
+// removeRunner stops handling packets received on the connection of a runner.
+func (s *session) removeRunner(runner sessionRunner) {
+	s.runners.RemoveRunner(runner)
+	for _, connID := range s.connIDGenerator.ActiveConnIDs() {
+		runner.Remove(connID)
+	}
+	if token := s.connIDManager.StatelessResetToken(); token != nil {
+		runner.RemoveResetToken(*token)
+	}
+}
+
+// switchPath makes conn the active path.
+// The RTT estimate, the congestion state, the ECN validation result and the MTU of the old path
+// don't say anything about the new path, so they are reset.
+func (s *session) switchPath(conn sendConn) {
+	s.connMutex.Lock()
+	s.conn = conn
+	s.connMutex.Unlock()
+	s.sendQueue.SetConn(conn)
+
+	maxPacketSize := getMaxPacketSize(conn.RemoteAddr())
+	if s.peerParams != nil && s.peerParams.MaxUDPPayloadSize != 0 {
+		maxPacketSize = utils.MinByteCount(maxPacketSize, s.peerParams.MaxUDPPayloadSize)
+	}
+	s.sentPacketHandler.MigratedPath(maxPacketSize)
+	s.packer.SetMaxPacketSize(maxPacketSize)
+	s.currentMTUMutex.Lock()
+	s.currentMTU = maxPacketSize
+	s.currentMTUMutex.Unlock()
+	if s.mtuDiscoverer != nil {
+		s.startMTUDiscovery()
+	}
+}
+
+var errAmplificationLimit = errors.New("anti-amplification limit reached")
+
+// sendPathProbe sends a packet containing a PATH_CHALLENGE or a PATH_RESPONSE frame on a path that's not the active path.
+// The packet is written directly, since the send queue only sends on the active path.
+func (s *session) sendPathProbe(pv *pathValidation, f wire.Frame) error {
+	size := protocol.ByteCount(protocol.MinInitialPacketSize)
+	// The server hasn't validated the client's new address yet.
+	// The packet is only padded as far as the anti-amplification limit allows.
+	amplificationLimited := s.perspective == protocol.PerspectiveServer
+	if amplificationLimited {
+		size = utils.MinByteCount(size, 3*pv.bytesReceived-pv.bytesSent)
+	}
+	// The client uses a new connection ID on the path it's migrating to.
+	// The server may keep using the current connection ID for the client's new address, see section 9.5 of RFC 9000.
+	connID := pv.connID
+	if s.perspective == protocol.PerspectiveServer {
+		connID = s.getDestConnID()
+	}
+	// PATH_CHALLENGE and PATH_RESPONSE frames are not retransmitted, see section 13.3 of RFC 9000.
+	packet, err := s.packer.PackPathProbePacket(connID, ackhandler.Frame{Frame: f, OnLost: func(wire.Frame) {}}, size)
+	if err != nil {
+		return err
+	}
+	defer packet.buffer.Release()
+	if amplificationLimited {
+		if l := protocol.ByteCount(packet.buffer.Len()); pv.bytesSent+l > 3*pv.bytesReceived {
+			return errAmplificationLimit
+		}
+		pv.bytesSent += protocol.ByteCount(packet.buffer.Len())
+	}
+	s.logPacket(packet)
+	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(s.config.Clock.Now(), s.retransmissionQueue))
+	return pv.conn.Write(packet.buffer.Data, protocol.ECNNon)
+}
+
 func (s *session) getPerspective() protocol.Perspective {
 	return s.perspective
 }
 
+func (s *session) CongestionControl() congestion.CongestionAlgo {
+	return s.sentPacketHandler.CongestionControl()
+}
+
+func (s *session) ConnectionStats() ConnectionStats {
+	stats := s.sentPacketHandler.GetStats()
+	return ConnectionStats{
+		SmoothedRTT:       stats.SmoothedRTT,
+		MinRTT:            stats.MinRTT,
+		LatestRTT:         stats.LatestRTT,
+		CongestionWindow:  uint64(stats.CongestionWindow),
+		BytesInFlight:     uint64(stats.BytesInFlight),
+		BandwidthEstimate: uint64(stats.BandwidthEstimate),
+		Retries:           uint64(atomic.LoadUint32(&s.numRetries)),
+	}
+}
+
+func (s *session) Timestamps() (created, handshakeComplete, handshakeConfirmed time.Time) {
+	s.timestampsMutex.Lock()
+	defer s.timestampsMutex.Unlock()
+
+	return s.sessionCreationTime, s.handshakeCompleteTime, s.handshakeConfirmedTime
+}
+
+func (s *session) ReorderingStats() (maxReorder int, reorderedPackets uint64) {
+	return s.receivedPacketHandler.ReorderingStats()
+}
+
+func (s *session) SavedSyscalls() uint64 {
+	return s.sendQueue.SavedSyscalls()
+}
+
+func (s *session) PaddingStats() (sent, received protocol.ByteCount) {
+	return s.packer.PaddingBytes(), s.frameParser.PaddingBytes()
+}
+
+func (s *session) AckStats() (sent, received uint64) {
+	return atomic.LoadUint64(&s.acksSent), s.frameParser.AcksReceived()
+}
+
+func (s *session) SetWriteDeadline(t time.Time) {
+	s.streamsMap.SetWriteDeadline(t)
+}
+
+func (s *session) OpenStreams() []StreamID {
+	return s.streamsMap.OpenStreams()
+}
+
+func (s *session) BytesSent() protocol.ByteCount {
+	return protocol.ByteCount(atomic.LoadUint64(&s.streamBytesSent))
+}
+
+func (s *session) BytesAcked() protocol.ByteCount {
+	return protocol.ByteCount(atomic.LoadUint64(&s.streamBytesAcked))
+}
+
+func (s *session) LargestAcked(encLevel protocol.EncryptionLevel) protocol.PacketNumber {
+	return s.sentPacketHandler.LargestAcked(encLevel)
+}
+
+func (s *session) CongestionEvents() <-chan CongestionEvent {
+	if s.congestionEvents == nil {
+		return nil
+	}
+	return s.congestionEvents.Events()
+}
+
+func (s *session) DroppedCongestionEvents() uint64 {
+	if s.congestionEvents == nil {
+		return 0
+	}
+	return s.congestionEvents.Dropped()
+}
+
+func (s *session) NextTimeout() time.Time {
+	s.nextTimeoutMutex.Lock()
+	defer s.nextTimeoutMutex.Unlock()
+
+	return s.nextTimeout
+}
+
+func (s *session) CurrentMTU() protocol.ByteCount {
+	s.currentMTUMutex.Lock()
+	defer s.currentMTUMutex.Unlock()
+
+	return s.currentMTU
+}
+
+type connIDsSnapshot struct {
+	src, dest protocol.ConnectionID
+}
+
+// publishConnIDs makes the current connection IDs available to ConnectionIDs.
+// A new snapshot is stored on every change, so that it can be read without holding a lock.
+func (s *session) publishConnIDs() {
+	s.connIDs.Store(&connIDsSnapshot{src: s.currentSrcConnID, dest: s.currentDestConnID})
+}
+
+func (s *session) ConnectionIDs() (src, dest ConnectionID) {
+	ids := s.connIDs.Load().(*connIDsSnapshot)
+	return ids.src, ids.dest
+}
+
+func (s *session) CanSendNow() bool {
+	s.canSendMutex.Lock()
+	defer s.canSendMutex.Unlock()
+
+	return s.congestionAllowsSend && !s.config.Clock.Now().Before(s.pacingAllowsSendAfter)
+}
+
+// flush makes the run loop send packets right away.
+// It returns once the run loop tried to send.
+func (s *session) flush() error {
+	errChan := make(chan error, 1)
+	select {
+	case s.flushRequests <- errChan:
+	case <-s.ctx.Done():
+		return errSessionClosed
+	}
+	return <-errChan
+}
+
+func (s *session) answerFlushRequests(err error) {
+	for _, errChan := range s.pendingFlushes {
+		errChan <- err
+	}
+	s.pendingFlushes = s.pendingFlushes[:0]
+}
+
 func (s *session) GetVersion() protocol.VersionNumber {
 	return s.version
 }
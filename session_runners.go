@@ -0,0 +1,106 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+)
+
+// The sessionRunners register a session with the packet handlers of multiple connections.
+// When a client migrates to a new connection, packets might arrive on both the old and the new connection.
+// It must only be used from the session's run loop.
+type sessionRunners struct {
+	runners []sessionRunner
+	// onConnIDIssued is called when one of our connection IDs was added to all runners,
+	// onConnIDRetired when one of them is retired, removed or replaced.
+	// They might be nil.
+	onConnIDIssued  func(protocol.ConnectionID)
+	onConnIDRetired func(protocol.ConnectionID)
+}
+
+var _ sessionRunner = &sessionRunners{}
+
+func newSessionRunners(runner sessionRunner, onConnIDIssued, onConnIDRetired func(protocol.ConnectionID)) *sessionRunners {
+	return &sessionRunners{
+		runners:         []sessionRunner{runner},
+		onConnIDIssued:  onConnIDIssued,
+		onConnIDRetired: onConnIDRetired,
+	}
+}
+
+// AddRunner adds the packet handlers of a new connection.
+// Connection IDs and stateless reset tokens that were added before need to be added to the new runner by the caller.
+func (r *sessionRunners) AddRunner(runner sessionRunner) {
+	r.runners = append(r.runners, runner)
+}
+
+// RemoveRunner removes the packet handlers of a connection.
+// Connection IDs and stateless reset tokens need to be removed from the runner by the caller.
+func (r *sessionRunners) RemoveRunner(runner sessionRunner) {
+	for i, rr := range r.runners {
+		if rr == runner {
+			r.runners = append(r.runners[:i], r.runners[i+1:]...)
+			return
+		}
+	}
+}
+
+// Add adds the connection ID to all runners.
+// It returns false if the connection ID was already used by any of them.
+func (r *sessionRunners) Add(connID protocol.ConnectionID, handler packetHandler) bool {
+	added := true
+	for _, runner := range r.runners {
+		if !runner.Add(connID, handler) {
+			added = false
+		}
+	}
+	if added && r.onConnIDIssued != nil {
+		r.onConnIDIssued(connID)
+	}
+	return added
+}
+
+// GetStatelessResetToken returns the token of the first runner.
+// All runners of a session use the same stateless reset key, and therefore generate the same tokens.
+func (r *sessionRunners) GetStatelessResetToken(connID protocol.ConnectionID) protocol.StatelessResetToken {
+	return r.runners[0].GetStatelessResetToken(connID)
+}
+
+func (r *sessionRunners) Retire(connID protocol.ConnectionID) {
+	for _, runner := range r.runners {
+		runner.Retire(connID)
+	}
+	r.retired(connID)
+}
+
+func (r *sessionRunners) Remove(connID protocol.ConnectionID) {
+	for _, runner := range r.runners {
+		runner.Remove(connID)
+	}
+	r.retired(connID)
+}
+
+func (r *sessionRunners) ReplaceWithClosed(connID protocol.ConnectionID, handler packetHandler, timeout time.Duration) {
+	for _, runner := range r.runners {
+		runner.ReplaceWithClosed(connID, handler, timeout)
+	}
+	r.retired(connID)
+}
+
+func (r *sessionRunners) retired(connID protocol.ConnectionID) {
+	if r.onConnIDRetired != nil {
+		r.onConnIDRetired(connID)
+	}
+}
+
+func (r *sessionRunners) AddResetToken(token protocol.StatelessResetToken, handler packetHandler) {
+	for _, runner := range r.runners {
+		runner.AddResetToken(token, handler)
+	}
+}
+
+func (r *sessionRunners) RemoveResetToken(token protocol.StatelessResetToken) {
+	for _, runner := range r.runners {
+		runner.RemoveResetToken(token)
+	}
+}
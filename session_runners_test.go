@@ -0,0 +1,111 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Runners", func() {
+	var (
+		runners          *sessionRunners
+		runner1, runner2 *MockSessionRunner
+		handler          *MockPacketHandler
+	)
+
+	BeforeEach(func() {
+		runner1 = NewMockSessionRunner(mockCtrl)
+		runner2 = NewMockSessionRunner(mockCtrl)
+		handler = NewMockPacketHandler(mockCtrl)
+		runners = newSessionRunners(runner1, nil, nil)
+		runners.AddRunner(runner2)
+	})
+
+	It("adds connection IDs to all runners", func() {
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().Add(connID, handler).Return(true)
+		runner2.EXPECT().Add(connID, handler).Return(true)
+		Expect(runners.Add(connID, handler)).To(BeTrue())
+	})
+
+	It("reports if a connection ID couldn't be added to one of the runners", func() {
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().Add(connID, handler).Return(true)
+		runner2.EXPECT().Add(connID, handler).Return(false)
+		Expect(runners.Add(connID, handler)).To(BeFalse())
+	})
+
+	It("retires, removes and replaces connection IDs on all runners", func() {
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().Retire(connID)
+		runner2.EXPECT().Retire(connID)
+		runners.Retire(connID)
+		runner1.EXPECT().Remove(connID)
+		runner2.EXPECT().Remove(connID)
+		runners.Remove(connID)
+		runner1.EXPECT().ReplaceWithClosed(connID, handler, time.Second)
+		runner2.EXPECT().ReplaceWithClosed(connID, handler, time.Second)
+		runners.ReplaceWithClosed(connID, handler, time.Second)
+	})
+
+	It("reports issued, retired and removed connection IDs", func() {
+		var issued, retired []protocol.ConnectionID
+		runners = newSessionRunners(
+			runner1,
+			func(connID protocol.ConnectionID) { issued = append(issued, connID) },
+			func(connID protocol.ConnectionID) { retired = append(retired, connID) },
+		)
+		connID1 := protocol.ConnectionID{1, 2, 3, 4}
+		connID2 := protocol.ConnectionID{5, 6, 7, 8}
+		connID3 := protocol.ConnectionID{9, 10, 11, 12}
+		runner1.EXPECT().Add(connID1, handler).Return(true)
+		runners.Add(connID1, handler)
+		runner1.EXPECT().Retire(connID1)
+		runners.Retire(connID1)
+		runner1.EXPECT().Remove(connID2)
+		runners.Remove(connID2)
+		runner1.EXPECT().ReplaceWithClosed(connID3, handler, time.Second)
+		runners.ReplaceWithClosed(connID3, handler, time.Second)
+		Expect(issued).To(Equal([]protocol.ConnectionID{connID1}))
+		Expect(retired).To(Equal([]protocol.ConnectionID{connID1, connID2, connID3}))
+	})
+
+	It("doesn't report connection IDs that couldn't be added to all runners", func() {
+		runners = newSessionRunners(
+			runner1,
+			func(protocol.ConnectionID) { Fail("didn't expect the connection ID to be issued") },
+			nil,
+		)
+		runners.AddRunner(runner2)
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().Add(connID, handler).Return(true)
+		runner2.EXPECT().Add(connID, handler).Return(false)
+		Expect(runners.Add(connID, handler)).To(BeFalse())
+	})
+
+	It("adds and removes stateless reset tokens on all runners", func() {
+		token := protocol.StatelessResetToken{1, 2, 3}
+		runner1.EXPECT().AddResetToken(token, handler)
+		runner2.EXPECT().AddResetToken(token, handler)
+		runners.AddResetToken(token, handler)
+		runner1.EXPECT().RemoveResetToken(token)
+		runner2.EXPECT().RemoveResetToken(token)
+		runners.RemoveResetToken(token)
+	})
+
+	It("gets stateless reset tokens from the first runner", func() {
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().GetStatelessResetToken(connID).Return(protocol.StatelessResetToken{4, 5, 6})
+		Expect(runners.GetStatelessResetToken(connID)).To(Equal(protocol.StatelessResetToken{4, 5, 6}))
+	})
+
+	It("removes runners", func() {
+		runners.RemoveRunner(runner2)
+		connID := protocol.ConnectionID{1, 2, 3, 4}
+		runner1.EXPECT().Remove(connID)
+		runners.Remove(connID)
+	})
+})
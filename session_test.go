@@ -11,9 +11,12 @@ import (
 	"net"
 	"runtime/pprof"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BGrewell/quic-go/internal/ackhandler"
+	"github.com/BGrewell/quic-go/internal/congestion"
 	"github.com/BGrewell/quic-go/internal/handshake"
 	"github.com/BGrewell/quic-go/internal/mocks"
 	mockackhandler "github.com/BGrewell/quic-go/internal/mocks/ackhandler"
@@ -43,6 +46,26 @@ func areClosedSessionsRunning() bool {
 	return strings.Contains(b.String(), "quic-go.(*closedLocalSession).run")
 }
 
+// A manualClock is a congestion.Clock that only advances when told to.
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newManualClock(now time.Time) *manualClock { return &manualClock{now: now} }
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	c.mutex.Unlock()
+}
+
 var _ = Describe("Session", func() {
 	var (
 		sess          *session
@@ -72,8 +95,8 @@ var _ = Describe("Session", func() {
 	}
 
 	expectReplaceWithClosed := func() {
-		sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any()).MaxTimes(1)
-		sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+		sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any(), gomock.Any()).MaxTimes(1)
+		sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 			Expect(s).To(BeAssignableToTypeOf(&closedLocalSession{}))
 			s.shutdown()
 			Eventually(areClosedSessionsRunning).Should(BeFalse())
@@ -93,7 +116,9 @@ var _ = Describe("Session", func() {
 		tracer.EXPECT().NegotiatedVersion(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1)
 		tracer.EXPECT().SentTransportParameters(gomock.Any())
 		tracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+		tracer.EXPECT().StartedHandshake().MaxTimes(1)
 		tracer.EXPECT().UpdatedCongestionState(gomock.Any())
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateTesting)
 		sess = newSession(
 			mconn,
 			sessionRunner,
@@ -107,6 +132,7 @@ var _ = Describe("Session", func() {
 			nil, // tls.Config
 			tokenGenerator,
 			false,
+			nil,
 			tracer,
 			1234,
 			utils.DefaultLogger,
@@ -274,6 +300,45 @@ var _ = Describe("Session", func() {
 			})
 		})
 
+		Context("limiting the rate of RESET_STREAM and STOP_SENDING frames", func() {
+			BeforeEach(func() {
+				sess.streamResetLimiter = newStreamResetLimiter(10)
+			})
+
+			It("closes the connection when the peer floods RESET_STREAM frames", func() {
+				streamManager.EXPECT().GetOrOpenReceiveStream(gomock.Any()).Return(nil, nil).Times(10)
+				for i := 0; i < 10; i++ {
+					Expect(sess.handleFrame(&wire.ResetStreamFrame{StreamID: protocol.StreamID(4 * i)}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				}
+				err := sess.handleFrame(&wire.ResetStreamFrame{StreamID: 40}, protocol.Encryption1RTT, protocol.ConnectionID{})
+				Expect(err).To(MatchError(&qerr.TransportError{
+					ErrorCode:    qerr.ProtocolViolation,
+					ErrorMessage: "too many RESET_STREAM and STOP_SENDING frames",
+				}))
+			})
+
+			It("counts RESET_STREAM and STOP_SENDING frames towards the same limit", func() {
+				streamManager.EXPECT().GetOrOpenReceiveStream(gomock.Any()).Return(nil, nil).Times(5)
+				streamManager.EXPECT().GetOrOpenSendStream(gomock.Any()).Return(nil, nil).Times(5)
+				for i := 0; i < 5; i++ {
+					Expect(sess.handleFrame(&wire.ResetStreamFrame{StreamID: protocol.StreamID(4 * i)}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+					Expect(sess.handleFrame(&wire.StopSendingFrame{StreamID: protocol.StreamID(4 * i)}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				}
+				err := sess.handleFrame(&wire.StopSendingFrame{StreamID: 40}, protocol.Encryption1RTT, protocol.ConnectionID{})
+				var transportErr *qerr.TransportError
+				Expect(errors.As(err, &transportErr)).To(BeTrue())
+				Expect(transportErr.ErrorCode).To(Equal(qerr.ProtocolViolation))
+			})
+
+			It("doesn't limit the rate if MaxStreamResetRate is not set", func() {
+				sess.streamResetLimiter = nil
+				streamManager.EXPECT().GetOrOpenReceiveStream(gomock.Any()).Return(nil, nil).Times(1000)
+				for i := 0; i < 1000; i++ {
+					Expect(sess.handleFrame(&wire.ResetStreamFrame{StreamID: protocol.StreamID(4 * i)}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				}
+			})
+		})
+
 		It("handles NEW_CONNECTION_ID frames", func() {
 			Expect(sess.handleFrame(&wire.NewConnectionIDFrame{
 				SequenceNumber: 10,
@@ -287,9 +352,9 @@ var _ = Describe("Session", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("rejects PATH_RESPONSE frames", func() {
+		It("ignores PATH_RESPONSE frames that don't match a PATH_CHALLENGE", func() {
 			err := sess.handleFrame(&wire.PathResponseFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}, protocol.Encryption1RTT, protocol.ConnectionID{})
-			Expect(err).To(MatchError("unexpected PATH_RESPONSE frame"))
+			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("handles PATH_CHALLENGE frames", func() {
@@ -300,6 +365,29 @@ var _ = Describe("Session", func() {
 			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.PathResponseFrame{Data: data}}}))
 		})
 
+		Context("handling DATAGRAM frames", func() {
+			BeforeEach(func() {
+				sess.datagramQueue = newDatagramQueue(func() {}, utils.DefaultLogger)
+			})
+
+			It("delivers DATAGRAM frames", func() {
+				Expect(sess.handleFrame(&wire.DatagramFrame{Data: []byte("foobar")}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				Expect(sess.ReceiveMessage()).To(Equal([]byte("foobar")))
+			})
+
+			It("drops empty DATAGRAM frames by default", func() {
+				Expect(sess.handleFrame(&wire.DatagramFrame{}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				Expect(sess.datagramQueue.rcvQueue).To(BeEmpty())
+			})
+
+			It("delivers empty DATAGRAM frames, if configured to do so", func() {
+				sess.config.DeliverEmptyDatagrams = true
+				Expect(sess.handleFrame(&wire.DatagramFrame{}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				Expect(sess.ReceiveMessage()).To(BeEmpty())
+				Expect(sess.datagramQueue.rcvQueue).To(BeEmpty())
+			})
+		})
+
 		It("rejects NEW_TOKEN frames", func() {
 			err := sess.handleNewTokenFrame(&wire.NewTokenFrame{})
 			Expect(err).To(HaveOccurred())
@@ -329,10 +417,10 @@ var _ = Describe("Session", func() {
 				ErrorMessage: "foobar",
 			}
 			streamManager.EXPECT().CloseWithError(expectedErr)
-			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
 			})
-			sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+			sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
 			})
 			cryptoSetup.EXPECT().Close()
@@ -360,10 +448,10 @@ var _ = Describe("Session", func() {
 				ErrorMessage: "foobar",
 			}
 			streamManager.EXPECT().CloseWithError(testErr)
-			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
 			})
-			sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+			sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
 			})
 			cryptoSetup.EXPECT().Close()
@@ -399,6 +487,115 @@ var _ = Describe("Session", func() {
 		Expect(sess.GetVersion()).To(Equal(protocol.VersionNumber(4242)))
 	})
 
+	It("returns the connection statistics", func() {
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().GetStats().Return(ackhandler.Stats{
+			SmoothedRTT:       10 * time.Millisecond,
+			MinRTT:            5 * time.Millisecond,
+			LatestRTT:         12 * time.Millisecond,
+			CongestionWindow:  12345,
+			BytesInFlight:     1234,
+			BandwidthEstimate: 42 * congestion.BytesPerSecond,
+		})
+		sess.sentPacketHandler = sph
+		Expect(sess.ConnectionStats()).To(Equal(ConnectionStats{
+			SmoothedRTT:       10 * time.Millisecond,
+			MinRTT:            5 * time.Millisecond,
+			LatestRTT:         12 * time.Millisecond,
+			CongestionWindow:  12345,
+			BytesInFlight:     1234,
+			BandwidthEstimate: 42 * 8,
+		}))
+	})
+
+	It("returns the reordering statistics", func() {
+		rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+		rph.EXPECT().ReorderingStats().Return(7, uint64(42))
+		sess.receivedPacketHandler = rph
+		maxReorder, reordered := sess.ReorderingStats()
+		Expect(maxReorder).To(Equal(7))
+		Expect(reordered).To(BeEquivalentTo(42))
+	})
+
+	It("returns the number of saved syscalls", func() {
+		sender := NewMockSender(mockCtrl)
+		sender.EXPECT().SavedSyscalls().Return(uint64(42))
+		sess.sendQueue = sender
+		Expect(sess.SavedSyscalls()).To(BeEquivalentTo(42))
+	})
+
+	It("returns the padding stats", func() {
+		packer.EXPECT().PaddingBytes().Return(protocol.ByteCount(1000))
+		_, err := sess.frameParser.ParseNext(bytes.NewReader(make([]byte, 42)), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		sent, received := sess.PaddingStats()
+		Expect(sent).To(Equal(protocol.ByteCount(1000)))
+		Expect(received).To(Equal(protocol.ByteCount(42)))
+	})
+
+	It("counts the stream data sent and acknowledged", func() {
+		Expect(sess.BytesSent()).To(BeZero())
+		Expect(sess.BytesAcked()).To(BeZero())
+		sess.onStreamDataSent(1000)
+		sess.onStreamDataSent(337)
+		sess.onStreamDataAcked(42)
+		Expect(sess.BytesSent()).To(Equal(protocol.ByteCount(1337)))
+		Expect(sess.BytesAcked()).To(Equal(protocol.ByteCount(42)))
+	})
+
+	It("returns the largest acked packet number", func() {
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().LargestAcked(protocol.EncryptionHandshake).Return(protocol.PacketNumber(3))
+		sph.EXPECT().LargestAcked(protocol.Encryption1RTT).Return(protocol.InvalidPacketNumber)
+		sess.sentPacketHandler = sph
+		Expect(sess.LargestAcked(protocol.EncryptionHandshake)).To(Equal(protocol.PacketNumber(3)))
+		Expect(sess.LargestAcked(protocol.Encryption1RTT)).To(Equal(protocol.InvalidPacketNumber))
+	})
+
+	It("returns the ACK stats", func() {
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().ECNMode().AnyTimes()
+		sph.EXPECT().SentPacket(gomock.Any()).AnyTimes()
+		sess.sentPacketHandler = sph
+		sender := NewMockSender(mockCtrl)
+		sender.EXPECT().Send(gomock.Any(), gomock.Any()).Times(3)
+		sess.sendQueue = sender
+		tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		sess.config.PacketLossSimulator = func(_ PacketDirection, pn protocol.PacketNumber) bool { return pn == 3 }
+		ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 1}}}
+		p1 := getPacket(1)
+		p1.ack = ack
+		sess.sendPackedPacket(p1, time.Now())
+		// packets without an ACK frame
+		sess.sendPackedPacket(getPacket(2), time.Now())
+		// ACK frames in packets that are dropped are not counted
+		p3 := getPacket(3)
+		p3.ack = ack
+		sess.sendPackedPacket(p3, time.Now())
+		sess.sendPackedCoalescedPacket(&coalescedPacket{
+			buffer: getPacketBuffer(),
+			packets: []*packetContents{
+				{header: &wire.ExtendedHeader{Header: wire.Header{IsLongHeader: true, Type: protocol.PacketTypeInitial}, PacketNumber: 4}, ack: ack},
+				{header: &wire.ExtendedHeader{Header: wire.Header{IsLongHeader: true, Type: protocol.PacketTypeHandshake}, PacketNumber: 5}, ack: ack},
+			},
+		}, time.Now())
+		b := &bytes.Buffer{}
+		Expect((&wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 3}}}).Write(b, sess.version)).To(Succeed())
+		_, err := sess.frameParser.ParseNext(bytes.NewReader(b.Bytes()), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		sent, received := sess.AckStats()
+		Expect(sent).To(BeEquivalentTo(3))
+		Expect(received).To(BeEquivalentTo(1))
+	})
+
+	It("tells its congestion control algorithm", func() {
+		Expect(sess.CongestionControl()).To(Equal(congestion.ALGO_RENO))
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().CongestionControl().Return(congestion.ALGO_UNKNOWN)
+		sess.sentPacketHandler = sph
+		Expect(sess.CongestionControl()).To(Equal(congestion.ALGO_UNKNOWN))
+	})
+
 	Context("closing", func() {
 		var (
 			runErr         chan error
@@ -440,7 +637,7 @@ var _ = Describe("Session", func() {
 				Expect(e.ErrorMessage).To(BeEmpty())
 				return &coalescedPacket{buffer: buffer}, nil
 			})
-			mconn.EXPECT().Write([]byte("connection close"))
+			mconn.EXPECT().Write([]byte("connection close"), gomock.Any())
 			gomock.InOrder(
 				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
 					var appErr *ApplicationError
@@ -455,13 +652,88 @@ var _ = Describe("Session", func() {
 			Expect(sess.Context().Done()).To(BeClosed())
 		})
 
+		Context("closing gracefully", func() {
+			expectClose := func() {
+				streamManager.EXPECT().CloseWithError(&qerr.ApplicationError{})
+				expectReplaceWithClosed()
+				cryptoSetup.EXPECT().Close()
+				packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+				mconn.EXPECT().Write(gomock.Any(), gomock.Any())
+				tracer.EXPECT().ClosedConnection(gomock.Any())
+				tracer.EXPECT().Close()
+			}
+
+			It("closes immediately if there are no open streams", func() {
+				sess.handshakeComplete = true
+				runSession()
+				streamManager.EXPECT().StopAccepting()
+				streamManager.EXPECT().NumOpenStreams().Return(0)
+				expectClose()
+				Expect(sess.CloseGracefully(time.Hour)).To(Succeed())
+				Eventually(areSessionsRunning).Should(BeFalse())
+				Expect(sess.Context().Done()).To(BeClosed())
+			})
+
+			It("waits for the open streams to complete", func() {
+				sess.handshakeComplete = true
+				runSession()
+				streamManager.EXPECT().StopAccepting()
+				gomock.InOrder(
+					streamManager.EXPECT().NumOpenStreams().Return(1),
+					streamManager.EXPECT().NumOpenStreams().Return(0),
+				)
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					Expect(sess.CloseGracefully(time.Hour)).To(Succeed())
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+				Expect(sess.Context().Done()).ToNot(BeClosed())
+				expectClose()
+				streamManager.EXPECT().DeleteStream(protocol.StreamID(4))
+				sess.onStreamCompleted(4)
+				Eventually(done).Should(BeClosed())
+				Eventually(areSessionsRunning).Should(BeFalse())
+			})
+
+			It("closes the session when the timeout expires", func() {
+				sess.handshakeComplete = true
+				runSession()
+				streamManager.EXPECT().StopAccepting()
+				streamManager.EXPECT().NumOpenStreams().Return(1)
+				expectClose()
+				Expect(sess.CloseGracefully(scaleDuration(20 * time.Millisecond))).To(MatchError(ErrGracefulCloseTimeout))
+				Eventually(areSessionsRunning).Should(BeFalse())
+				Expect(sess.Context().Done()).To(BeClosed())
+			})
+		})
+
+		It("uses the configured draining timeout for the closed session", func() {
+			sess.config.DrainingTimeout = 1337 * time.Millisecond
+			runSession()
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			sessionRunner.EXPECT().ReplaceWithClosed(clientDestConnID, gomock.Any(), 1337*time.Millisecond)
+			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any(), 1337*time.Millisecond).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
+				s.shutdown()
+				Eventually(areClosedSessionsRunning).Should(BeFalse())
+			})
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.shutdown()
+			Eventually(areSessionsRunning).Should(BeFalse())
+		})
+
 		It("only closes once", func() {
 			runSession()
 			streamManager.EXPECT().CloseWithError(gomock.Any())
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.shutdown()
@@ -480,7 +752,7 @@ var _ = Describe("Session", func() {
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
 			packer.EXPECT().PackApplicationClose(expectedErr).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			gomock.InOrder(
 				tracer.EXPECT().ClosedConnection(expectedErr),
 				tracer.EXPECT().Close(),
@@ -501,7 +773,7 @@ var _ = Describe("Session", func() {
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
 			packer.EXPECT().PackConnectionClose(expectedErr).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			gomock.InOrder(
 				tracer.EXPECT().ClosedConnection(expectedErr),
 				tracer.EXPECT().Close(),
@@ -550,7 +822,7 @@ var _ = Describe("Session", func() {
 				close(returned)
 			}()
 			Consistently(returned).ShouldNot(BeClosed())
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.shutdown()
@@ -564,7 +836,7 @@ var _ = Describe("Session", func() {
 			runSession()
 			cryptoSetup.EXPECT().Close()
 			streamManager.EXPECT().CloseWithError(gomock.Any())
-			sessionRunner.EXPECT().ReplaceWithClosed(gomock.Any(), gomock.Any()).AnyTimes()
+			sessionRunner.EXPECT().ReplaceWithClosed(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			buf := &bytes.Buffer{}
 			hdr := &wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
@@ -589,7 +861,7 @@ var _ = Describe("Session", func() {
 			// don't EXPECT any calls to packer.PackPacket()
 			sess.handlePacket(&receivedPacket{
 				rcvTime:    time.Now(),
-				remoteAddr: &net.UDPAddr{},
+				remoteAddr: remoteAddr,
 				buffer:     getPacketBuffer(),
 				data:       buf.Bytes(),
 			})
@@ -600,9 +872,10 @@ var _ = Describe("Session", func() {
 		It("closes when the sendQueue encounters an error", func() {
 			sess.handshakeConfirmed = true
 			conn := NewMockSendConn(mockCtrl)
-			conn.EXPECT().Write(gomock.Any()).Return(io.ErrClosedPipe).AnyTimes()
+			conn.EXPECT().Write(gomock.Any(), gomock.Any()).Return(io.ErrClosedPipe).AnyTimes()
 			sess.sendQueue = newSendQueue(conn)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().Return(time.Now().Add(time.Hour)).AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
@@ -732,6 +1005,38 @@ var _ = Describe("Session", func() {
 			Expect(sess.handlePacketImpl(p)).To(BeFalse())
 		})
 
+		It("reports dropped packets to the OnDroppedPacket callback", func() {
+			var droppedReason DropReason
+			var droppedHdr *logging.Header
+			sess.config.OnDroppedPacket = func(reason DropReason, hdr *logging.Header) {
+				droppedReason = reason
+				droppedHdr = hdr
+			}
+			origSupportedVersions := make([]protocol.VersionNumber, len(protocol.SupportedVersions))
+			copy(origSupportedVersions, protocol.SupportedVersions)
+			defer func() {
+				protocol.SupportedVersions = origSupportedVersions
+			}()
+
+			protocol.SupportedVersions = append(protocol.SupportedVersions, sess.version+1)
+			p := getPacket(&wire.ExtendedHeader{
+				Header: wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeHandshake,
+					DestConnectionID: destConnID,
+					SrcConnectionID:  srcConnID,
+					Version:          sess.version + 1,
+				},
+				PacketNumberLen: protocol.PacketNumberLen2,
+			}, nil)
+			tracer.EXPECT().DroppedPacket(logging.PacketTypeHandshake, p.Size(), logging.PacketDropUnexpectedVersion)
+			Expect(sess.handlePacketImpl(p)).To(BeFalse())
+			Expect(droppedReason).To(Equal(logging.PacketDropUnexpectedVersion))
+			Expect(droppedHdr).ToNot(BeNil())
+			Expect(droppedHdr.Version).To(Equal(sess.version + 1))
+			Expect(droppedHdr.DestConnectionID).To(Equal(destConnID))
+		})
+
 		It("informs the ReceivedPacketHandler about non-ack-eliciting packets", func() {
 			hdr := &wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
@@ -759,6 +1064,43 @@ var _ = Describe("Session", func() {
 			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 		})
 
+		It("reports the connection ID the peer used on the last packet", func() {
+			src, _ := sess.ConnectionIDs()
+			Expect(src).To(Equal(srcConnID))
+			newConnID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0xca, 0xfe, 0x13, 0x37}
+			hdr := &wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: newConnID},
+				PacketNumber:    0x37,
+				PacketNumberLen: protocol.PacketNumberLen1,
+			}
+			packet := getPacket(hdr, nil)
+			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
+				packetNumber:    0x1337,
+				encryptionLevel: protocol.Encryption1RTT,
+				hdr:             hdr,
+				data:            []byte{0}, // one PADDING frame
+			}, nil)
+			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPacket(hdr, protocol.ByteCount(len(packet.data)), []logging.Frame{})
+			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+			src, _ = sess.ConnectionIDs()
+			Expect(src).To(Equal(newConnID))
+		})
+
+		It("doesn't update the connection ID for packets that can't be unpacked", func() {
+			hdr := &wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0xca, 0xfe, 0x13, 0x37}},
+				PacketNumber:    0x37,
+				PacketNumberLen: protocol.PacketNumberLen1,
+			}
+			packet := getPacket(hdr, nil)
+			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, handshake.ErrDecryptionFailed)
+			tracer.EXPECT().DroppedPacket(logging.PacketType1RTT, gomock.Any(), logging.PacketDropPayloadDecryptError)
+			Expect(sess.handlePacketImpl(packet)).To(BeFalse())
+			src, _ := sess.ConnectionIDs()
+			Expect(src).To(Equal(srcConnID))
+		})
+
 		It("informs the ReceivedPacketHandler about ack-eliciting packets", func() {
 			hdr := &wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
@@ -808,6 +1150,31 @@ var _ = Describe("Session", func() {
 			Expect(sess.handlePacketImpl(packet)).To(BeFalse())
 		})
 
+		It("drops packets dropped by the PacketLossSimulator", func() {
+			hdr := &wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: srcConnID},
+				PacketNumber:    0x37,
+				PacketNumberLen: protocol.PacketNumberLen1,
+			}
+			var dirs []PacketDirection
+			var nums []protocol.PacketNumber
+			sess.config.PacketLossSimulator = func(d PacketDirection, num protocol.PacketNumber) bool {
+				dirs = append(dirs, d)
+				nums = append(nums, num)
+				return num == 0
+			}
+			// The first packet is dropped before it is unpacked.
+			// Don't EXPECT any calls to the unpacker or the received packet handler.
+			sess.receivedPacketHandler = mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+			Expect(sess.handlePacketImpl(getPacket(hdr, nil))).To(BeFalse())
+			// The second packet is processed.
+			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, handshake.ErrDecryptionFailed)
+			tracer.EXPECT().DroppedPacket(gomock.Any(), gomock.Any(), logging.PacketDropPayloadDecryptError)
+			Expect(sess.handlePacketImpl(getPacket(hdr, nil))).To(BeFalse())
+			Expect(dirs).To(Equal([]PacketDirection{PacketDirectionReceived, PacketDirectionReceived}))
+			Expect(nums).To(Equal([]protocol.PacketNumber{0, 1}))
+		})
+
 		It("drops a packet when unpacking fails", func() {
 			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, handshake.ErrDecryptionFailed)
 			streamManager.EXPECT().CloseWithError(gomock.Any())
@@ -836,7 +1203,7 @@ var _ = Describe("Session", func() {
 			// make the go routine return
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sess.closeLocal(errors.New("close"))
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
@@ -856,7 +1223,7 @@ var _ = Describe("Session", func() {
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(hdr *wire.ExtendedHeader, _ protocol.ByteCount, _ []logging.Frame) {
 			}).Times(3)
-			packer.EXPECT().PackCoalescedPacket() // only expect a single call
+			packer.EXPECT().PackCoalescedPacket(false) // only expect a single call
 
 			for i := 0; i < 3; i++ {
 				sess.handlePacket(getPacket(&wire.ExtendedHeader{
@@ -880,7 +1247,7 @@ var _ = Describe("Session", func() {
 			expectReplaceWithClosed()
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sess.closeLocal(errors.New("close"))
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
@@ -901,7 +1268,7 @@ var _ = Describe("Session", func() {
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(hdr *wire.ExtendedHeader, _ protocol.ByteCount, _ []logging.Frame) {
 			}).Times(3)
-			packer.EXPECT().PackCoalescedPacket().Times(3) // only expect a single call
+			packer.EXPECT().PackCoalescedPacket(false).Times(3) // only expect a single call
 
 			for i := 0; i < 3; i++ {
 				sess.handlePacket(getPacket(&wire.ExtendedHeader{
@@ -925,7 +1292,7 @@ var _ = Describe("Session", func() {
 			expectReplaceWithClosed()
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sess.closeLocal(errors.New("close"))
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
@@ -946,7 +1313,7 @@ var _ = Describe("Session", func() {
 				close(done)
 			}()
 			expectReplaceWithClosed()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			packet := getPacket(&wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
 				PacketNumberLen: protocol.PacketNumberLen1,
@@ -979,7 +1346,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sess.shutdown()
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
@@ -1000,7 +1367,7 @@ var _ = Describe("Session", func() {
 				close(done)
 			}()
 			expectReplaceWithClosed()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			packet := getPacket(&wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
 				PacketNumberLen: protocol.PacketNumberLen1,
@@ -1031,7 +1398,7 @@ var _ = Describe("Session", func() {
 				close(done)
 			}()
 			expectReplaceWithClosed()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.handlePacket(getPacket(&wire.ExtendedHeader{
@@ -1106,7 +1473,25 @@ var _ = Describe("Session", func() {
 		})
 
 		Context("updating the remote address", func() {
-			It("doesn't support connection migration", func() {
+			newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 100), Port: 4321}
+
+			getPacketFromNewAddr := func(pn protocol.PacketNumber, data []byte) *receivedPacket {
+				unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
+					packetNumber:    pn,
+					encryptionLevel: protocol.Encryption1RTT,
+					hdr:             &wire.ExtendedHeader{PacketNumber: pn},
+					data:            data,
+				}, nil)
+				packet := getPacket(&wire.ExtendedHeader{
+					Header:          wire.Header{DestConnectionID: srcConnID},
+					PacketNumber:    pn,
+					PacketNumberLen: protocol.PacketNumberLen1,
+				}, nil)
+				packet.remoteAddr = newAddr
+				return packet
+			}
+
+			It("doesn't migrate before the handshake is confirmed", func() {
 				unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
 					encryptionLevel: protocol.Encryption1RTT,
 					hdr:             &wire.ExtendedHeader{},
@@ -1121,6 +1506,196 @@ var _ = Describe("Session", func() {
 				tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
 				Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 			})
+
+			Context("after the handshake is confirmed", func() {
+				var (
+					newConn *MockSendConn
+					sender  *MockSender
+					sph     *mockackhandler.MockSentPacketHandler
+				)
+
+				BeforeEach(func() {
+					sess.handshakeConfirmed = true
+					newConn = NewMockSendConn(mockCtrl)
+					newConn.EXPECT().RemoteAddr().Return(newAddr).AnyTimes()
+					newConn.EXPECT().LocalAddr().Return(localAddr).AnyTimes()
+					sender = NewMockSender(mockCtrl)
+					sess.sendQueue = sender
+					sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+					sph.EXPECT().ReceivedBytes(gomock.Any()).AnyTimes()
+					sess.sentPacketHandler = sph
+					tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				})
+
+				// expectPathProbe expects a packet to be sent on the new path. If frame is not nil, it checks the frame.
+				expectPathProbe := func(size protocol.ByteCount, frame wire.Frame) {
+					packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), size).DoAndReturn(func(_ protocol.ConnectionID, f ackhandler.Frame, _ protocol.ByteCount) (*packedPacket, error) {
+						if frame != nil {
+							Expect(f.Frame).To(Equal(frame))
+						}
+						buffer := getPacketBuffer()
+						buffer.Data = append(buffer.Data, []byte("foobar")...)
+						return &packedPacket{
+							buffer:         buffer,
+							packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 42}, length: 6},
+						}, nil
+					})
+					sph.EXPECT().SentPacket(gomock.Any())
+					tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+					newConn.EXPECT().Write([]byte("foobar"), protocol.ECNNon)
+				}
+
+				It("validates the client's new address before following the client", func() {
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
+					// The packet is only padded as far as the anti-amplification limit allows.
+					size := 3 * protocol.ByteCount(len(packet.data))
+					packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), size).DoAndReturn(func(_ protocol.ConnectionID, f ackhandler.Frame, _ protocol.ByteCount) (*packedPacket, error) {
+						Expect(f.Frame).To(BeAssignableToTypeOf(&wire.PathChallengeFrame{}))
+						Expect(f.Frame.(*wire.PathChallengeFrame).Data).To(Equal(sess.pathValidation.data))
+						buffer := getPacketBuffer()
+						buffer.Data = append(buffer.Data, []byte("foobar")...)
+						return &packedPacket{
+							buffer:         buffer,
+							packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 42}, length: 6},
+						}, nil
+					})
+					sph.EXPECT().SentPacket(gomock.Any())
+					tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+					newConn.EXPECT().Write([]byte("foobar"), protocol.ECNNon)
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+
+					// the client responds to the PATH_CHALLENGE
+					b := &bytes.Buffer{}
+					Expect((&wire.PathResponseFrame{Data: sess.pathValidation.data}).Write(b, sess.version)).To(Succeed())
+					packet = getPacketFromNewAddr(11, b.Bytes())
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
+					sender.EXPECT().SetConn(newConn)
+					sph.EXPECT().MigratedPath(protocol.ByteCount(protocol.InitialPacketSizeIPv4))
+					packer.EXPECT().SetMaxPacketSize(protocol.ByteCount(protocol.InitialPacketSizeIPv4))
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(newAddr))
+					Expect(sess.pathValidation).To(BeNil())
+				})
+
+				It("doesn't follow the client if the PATH_RESPONSE doesn't match", func() {
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+					packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(protocol.ConnectionID, ackhandler.Frame, protocol.ByteCount) (*packedPacket, error) {
+						buffer := getPacketBuffer()
+						buffer.Data = append(buffer.Data, []byte("foobar")...)
+						return &packedPacket{
+							buffer:         buffer,
+							packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 42}, length: 6},
+						}, nil
+					})
+					sph.EXPECT().SentPacket(gomock.Any())
+					tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+					newConn.EXPECT().Write(gomock.Any(), gomock.Any())
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+
+					data := sess.pathValidation.data
+					data[0]++
+					b := &bytes.Buffer{}
+					Expect((&wire.PathResponseFrame{Data: data}).Write(b, sess.version)).To(Succeed())
+					Expect(sess.handlePacketImpl(getPacketFromNewAddr(11, b.Bytes()))).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+
+				It("doesn't exceed the anti-amplification limit on the unvalidated path", func() {
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
+					limit := 3 * protocol.ByteCount(len(packet.data))
+					packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), limit).DoAndReturn(func(protocol.ConnectionID, ackhandler.Frame, protocol.ByteCount) (*packedPacket, error) {
+						// the packet is larger than the limit, even without any padding
+						buffer := getPacketBuffer()
+						buffer.Data = append(buffer.Data, make([]byte, limit+1)...)
+						return &packedPacket{
+							buffer:         buffer,
+							packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 42}, length: limit + 1},
+						}, nil
+					})
+					// the packet is not sent
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.pathValidation.bytesSent).To(BeZero())
+				})
+
+				It("abandons the validation of the client's new address after a timeout", func() {
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+					expectPathProbe(3*protocol.ByteCount(len(packet.data)), nil)
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.pathValidation).ToNot(BeNil())
+					sess.abandonPathValidation(errors.New("timeout"))
+					Expect(sess.pathValidation).To(BeNil())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+
+				It("retransmits the PATH_CHALLENGE and gives up after Config.MaxPathChallenges PATH_CHALLENGE frames", func() {
+					sess.config.MaxPathChallenges = 2
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+					limit := 3 * protocol.ByteCount(len(packet.data))
+					expectPathProbe(limit, nil)
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					data := sess.pathValidation.data
+					// the client doesn't respond
+					expectPathProbe(limit-6, &wire.PathChallengeFrame{Data: data}) // the first probe packet was 6 bytes
+					sess.onPathValidationTimeout()
+					Expect(sess.pathValidation).ToNot(BeNil())
+					Expect(sess.pathValidation.numChallenges).To(Equal(2))
+					sess.onPathValidationTimeout()
+					Expect(sess.pathValidation).To(BeNil())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+
+				It("doesn't follow the client if it receives a later non-probing packet from the current address", func() {
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn).Times(2)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+					expectPathProbe(3*protocol.ByteCount(len(packet.data)), nil)
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					data := sess.pathValidation.data
+
+					// the client sends a packet with a larger packet number from its current address
+					packet = getPacketFromNewAddr(11, []byte{0x1}) // one PING frame
+					packet.remoteAddr = remoteAddr
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.pathValidation).To(BeNil())
+
+					// the PATH_RESPONSE arrives late
+					b := &bytes.Buffer{}
+					Expect((&wire.PathResponseFrame{Data: data}).Write(b, sess.version)).To(Succeed())
+					Expect(sess.handlePacketImpl(getPacketFromNewAddr(12, b.Bytes()))).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+
+				It("doesn't migrate for reordered packets", func() {
+					sess.largestRcvdNonProbingPacket = 20
+					packet := getPacketFromNewAddr(10, []byte{0x1}) // one PING frame
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+
+				It("responds to PATH_CHALLENGE frames on the new path, without migrating", func() {
+					b := &bytes.Buffer{}
+					Expect((&wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}).Write(b, sess.version)).To(Succeed())
+					packet := getPacketFromNewAddr(10, b.Bytes())
+					mconn.EXPECT().WithRemoteAddr(newAddr, nil).Return(newConn)
+					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
+					expectPathProbe(3*protocol.ByteCount(len(packet.data)), &wire.PathResponseFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}})
+					Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+					Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
+				})
+			})
 		})
 
 		Context("coalesced packets", func() {
@@ -1258,7 +1833,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sender.EXPECT().Close()
@@ -1279,6 +1854,7 @@ var _ = Describe("Session", func() {
 		It("sends packets", func() {
 			sess.handshakeConfirmed = true
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1291,7 +1867,65 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
 			sent := make(chan struct{})
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any()).Do(func(packet *packetBuffer) { close(sent) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
+			tracer.EXPECT().SentPacket(p.header, p.buffer.Len(), nil, []logging.Frame{})
+			sess.scheduleSending()
+			Eventually(sent).Should(BeClosed())
+		})
+
+		It("doesn't send packets dropped by the PacketLossSimulator", func() {
+			sess.handshakeConfirmed = true
+			var simulated []protocol.PacketNumber
+			sess.config.PacketLossSimulator = func(dir PacketDirection, pn protocol.PacketNumber) bool {
+				Expect(dir).To(Equal(PacketDirectionSent))
+				simulated = append(simulated, pn)
+				return pn == 1
+			}
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			// the dropped packet is still passed to the sent packet handler, such that it can be detected as lost
+			sph.EXPECT().SentPacket(gomock.Any()).Times(2)
+			sess.sentPacketHandler = sph
+			runSession()
+			p1 := getPacket(1)
+			p2 := getPacket(2)
+			packer.EXPECT().PackPacket().Return(p1, nil)
+			packer.EXPECT().PackPacket().Return(p2, nil)
+			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
+			sent := make(chan struct{})
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(b *packetBuffer, _ protocol.ECN) {
+				Expect(b.Data).To(Equal(p2.buffer.Data))
+				close(sent)
+			})
+			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			sess.scheduleSending()
+			Eventually(sent).Should(BeClosed())
+			Expect(simulated).To(Equal([]protocol.PacketNumber{1, 2}))
+		})
+
+		It("marks 1-RTT packets with the ECN codepoint returned by the sent packet handler", func() {
+			sess.handshakeConfirmed = true
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().Return(protocol.ECT0).AnyTimes()
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sph.EXPECT().SentPacket(gomock.Any()).Do(func(p *ackhandler.Packet) {
+				Expect(p.ECN).To(Equal(protocol.ECT0))
+			})
+			sess.sentPacketHandler = sph
+			runSession()
+			p := getPacket(1)
+			packer.EXPECT().PackPacket().Return(p, nil)
+			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
+			sent := make(chan struct{})
+			sender.EXPECT().WouldBlock().AnyTimes()
+			sender.EXPECT().Send(gomock.Any(), protocol.ECT0).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
 			tracer.EXPECT().SentPacket(p.header, p.buffer.Len(), nil, []logging.Frame{})
 			sess.scheduleSending()
 			Eventually(sent).Should(BeClosed())
@@ -1307,21 +1941,61 @@ var _ = Describe("Session", func() {
 		})
 
 		It("sends ACK only packets", func() {
+			sess.handshakeConfirmed = true
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAck)
 			done := make(chan struct{})
-			packer.EXPECT().MaybePackAckPacket(false).Do(func(bool) { close(done) })
+			packer.EXPECT().MaybePackAckPacket(true).Do(func(bool) { close(done) })
 			sess.sentPacketHandler = sph
 			runSession()
 			sess.scheduleSending()
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("sends ACKs for all packet number spaces in a single datagram, before the handshake is confirmed", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAck)
+			sph.EXPECT().SentPacket(gomock.Any()).Times(2)
+			sess.sentPacketHandler = sph
+			buffer := getPacketBuffer()
+			buffer.Data = append(buffer.Data, []byte("foobar")...)
+			packer.EXPECT().PackCoalescedPacket(true).Return(&coalescedPacket{
+				buffer: buffer,
+				packets: []*packetContents{
+					{
+						header: &wire.ExtendedHeader{
+							Header:       wire.Header{IsLongHeader: true, Type: protocol.PacketTypeInitial},
+							PacketNumber: 13,
+						},
+						ack:    &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 10}}},
+						length: 3,
+					},
+					{
+						header: &wire.ExtendedHeader{
+							Header:       wire.Header{IsLongHeader: true, Type: protocol.PacketTypeHandshake},
+							PacketNumber: 37,
+						},
+						ack:    &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 5}}},
+						length: 3,
+					},
+				},
+			}, nil)
+			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			sent := make(chan struct{})
+			sender.EXPECT().Send(buffer, protocol.ECNNon).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
+			runSession()
+			sess.scheduleSending()
+			Eventually(sent).Should(BeClosed())
+		})
+
 		It("adds a BLOCKED frame when it is connection-level flow control blocked", func() {
 			sess.handshakeConfirmed = true
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1337,7 +2011,7 @@ var _ = Describe("Session", func() {
 			sess.connFlowController = fc
 			runSession()
 			sent := make(chan struct{})
-			sender.EXPECT().Send(gomock.Any()).Do(func(packet *packetBuffer) { close(sent) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
 			tracer.EXPECT().SentPacket(p.header, p.length, nil, []logging.Frame{})
 			sess.scheduleSending()
 			Eventually(sent).Should(BeClosed())
@@ -1380,6 +2054,7 @@ var _ = Describe("Session", func() {
 
 				It("sends a probe packet", func() {
 					sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+					sph.EXPECT().ECNMode().AnyTimes()
 					sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 					sph.EXPECT().TimeUntilSend().AnyTimes()
 					sph.EXPECT().SendMode().Return(sendMode)
@@ -1393,7 +2068,7 @@ var _ = Describe("Session", func() {
 					sess.sentPacketHandler = sph
 					runSession()
 					sent := make(chan struct{})
-					sender.EXPECT().Send(gomock.Any()).Do(func(packet *packetBuffer) { close(sent) })
+					sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
 					tracer.EXPECT().SentPacket(p.header, p.length, gomock.Any(), gomock.Any())
 					sess.scheduleSending()
 					Eventually(sent).Should(BeClosed())
@@ -1401,6 +2076,7 @@ var _ = Describe("Session", func() {
 
 				It("sends a PING as a probe packet", func() {
 					sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+					sph.EXPECT().ECNMode().AnyTimes()
 					sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 					sph.EXPECT().TimeUntilSend().AnyTimes()
 					sph.EXPECT().SendMode().Return(sendMode)
@@ -1414,7 +2090,7 @@ var _ = Describe("Session", func() {
 					sess.sentPacketHandler = sph
 					runSession()
 					sent := make(chan struct{})
-					sender.EXPECT().Send(gomock.Any()).Do(func(packet *packetBuffer) { close(sent) })
+					sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(sent) })
 					tracer.EXPECT().SentPacket(p.header, p.length, gomock.Any(), gomock.Any())
 					sess.scheduleSending()
 					Eventually(sent).Should(BeClosed())
@@ -1435,6 +2111,7 @@ var _ = Describe("Session", func() {
 		BeforeEach(func() {
 			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sess.handshakeConfirmed = true
 			sess.handshakeComplete = true
@@ -1450,7 +2127,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sender.EXPECT().Close()
@@ -1467,7 +2144,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackPacket().Return(getPacket(10), nil)
 			packer.EXPECT().PackPacket().Return(getPacket(11), nil)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any()).Times(2)
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Times(2)
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1484,7 +2161,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackPacket().Return(getPacket(10), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any())
+			sender.EXPECT().Send(gomock.Any(), gomock.Any())
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1501,7 +2178,7 @@ var _ = Describe("Session", func() {
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny)
 			packer.EXPECT().MaybePackAckPacket(gomock.Any()).Return(getPacket(10), nil)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any())
+			sender.EXPECT().Send(gomock.Any(), gomock.Any())
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1520,7 +2197,7 @@ var _ = Describe("Session", func() {
 			sph.EXPECT().SendMode().Return(ackhandler.SendAck)
 			packer.EXPECT().PackPacket().Return(getPacket(100), nil)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any())
+			sender.EXPECT().Send(gomock.Any(), gomock.Any())
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1547,7 +2224,7 @@ var _ = Describe("Session", func() {
 			)
 			written := make(chan struct{}, 2)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { written <- struct{}{} }).Times(2)
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} }).Times(2)
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1559,18 +2236,93 @@ var _ = Describe("Session", func() {
 			Eventually(written, 2*pacingDelay).Should(HaveLen(2))
 		})
 
-		It("sends multiple packets at once", func() {
-			sph.EXPECT().SentPacket(gomock.Any()).Times(3)
-			sph.EXPECT().HasPacingBudget().Return(true).Times(3)
-			sph.EXPECT().HasPacingBudget()
-			sph.EXPECT().TimeUntilSend().Return(time.Now().Add(time.Hour))
-			sph.EXPECT().SendMode().Return(ackhandler.SendAny).Times(4)
-			packer.EXPECT().PackPacket().Return(getPacket(1000), nil)
-			packer.EXPECT().PackPacket().Return(getPacket(1001), nil)
-			packer.EXPECT().PackPacket().Return(getPacket(1002), nil)
-			written := make(chan struct{}, 3)
-			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { written <- struct{}{} }).Times(3)
+		It("reports if it can send right now, under a constrained pacer", func() {
+			pacingDelay := scaleDuration(100 * time.Millisecond)
+			var pacingDeadline time.Time
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().DoAndReturn(func() bool {
+				return !pacingDeadline.IsZero() && !time.Now().Before(pacingDeadline)
+			}).AnyTimes()
+			sph.EXPECT().TimeUntilSend().DoAndReturn(func() time.Time { return pacingDeadline }).AnyTimes()
+			packer.EXPECT().MaybePackAckPacket(true).AnyTimes()
+			packer.EXPECT().PackPacket().AnyTimes()
+			sender.EXPECT().WouldBlock().AnyTimes()
+			Expect(sess.CanSendNow()).To(BeFalse())
+			pacingDeadline = time.Now().Add(pacingDelay)
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			sess.scheduleSending()
+			Consistently(sess.CanSendNow, pacingDelay/2).Should(BeFalse())
+			Eventually(sess.CanSendNow, 2*pacingDelay).Should(BeTrue())
+			Expect(time.Now()).To(BeTemporally(">=", pacingDeadline))
+		})
+
+		It("reports that it can't send right now when congestion limited", func() {
+			sph.EXPECT().SendMode().Return(ackhandler.SendAck).AnyTimes()
+			packer.EXPECT().MaybePackAckPacket(true).AnyTimes()
+			sender.EXPECT().WouldBlock().AnyTimes()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			sess.scheduleSending()
+			Consistently(sess.CanSendNow, scaleDuration(50*time.Millisecond)).Should(BeFalse())
+		})
+
+		It("sends packets when a stream is flushed", func() {
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sph.EXPECT().SentPacket(gomock.Any())
+			packets := make(chan *packedPacket, 1)
+			packer.EXPECT().PackPacket().DoAndReturn(func() (*packedPacket, error) {
+				select {
+				case p := <-packets:
+					return p, nil
+				default:
+					return nil, nil
+				}
+			}).AnyTimes()
+			written := make(chan struct{}, 1)
+			sender.EXPECT().WouldBlock().AnyTimes()
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} })
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			packets <- getPacket(1000)
+			Expect(sess.flush()).To(Succeed())
+			Expect(written).To(HaveLen(1))
+		})
+
+		It("reports that flushing is blocked when congestion limited", func() {
+			sph.EXPECT().SendMode().Return(ackhandler.SendAck).AnyTimes()
+			packer.EXPECT().MaybePackAckPacket(true).AnyTimes()
+			sender.EXPECT().WouldBlock().AnyTimes()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			Expect(sess.flush()).To(MatchError(ErrSendBlocked))
+		})
+
+		It("sends multiple packets at once", func() {
+			sph.EXPECT().SentPacket(gomock.Any()).Times(3)
+			sph.EXPECT().HasPacingBudget().Return(true).Times(3)
+			sph.EXPECT().HasPacingBudget()
+			sph.EXPECT().TimeUntilSend().Return(time.Now().Add(time.Hour))
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).Times(4)
+			packer.EXPECT().PackPacket().Return(getPacket(1000), nil)
+			packer.EXPECT().PackPacket().Return(getPacket(1001), nil)
+			packer.EXPECT().PackPacket().Return(getPacket(1002), nil)
+			written := make(chan struct{}, 3)
+			sender.EXPECT().WouldBlock().AnyTimes()
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} }).Times(3)
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1580,6 +2332,47 @@ var _ = Describe("Session", func() {
 			Eventually(written).Should(HaveLen(3))
 		})
 
+		It("limits the rate at which packets are sealed", func() {
+			const rate = 200 // packets per second, i.e. one packet every 5ms
+			clock := newManualClock(time.Now())
+			sess.config.Clock = clock
+			sess.encryptionRateLimiter = newEncryptionRateLimiter(rate)
+			var pn protocol.PacketNumber
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sph.EXPECT().SentPacket(gomock.Any()).AnyTimes()
+			packer.EXPECT().PackPacket().DoAndReturn(func() (*packedPacket, error) {
+				pn++
+				return getPacket(pn), nil
+			}).AnyTimes()
+			var numSent int32
+			sender.EXPECT().WouldBlock().AnyTimes()
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) {
+				atomic.AddInt32(&numSent, 1)
+			}).AnyTimes()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			getNumSent := func() int32 { return atomic.LoadInt32(&numSent) }
+			// the burst allows sending a single packet
+			sess.scheduleSending()
+			Eventually(getNumSent).Should(BeEquivalentTo(1))
+			// Advance the clock in steps of half the interval between two packets.
+			// A packet can only be sent after every second step.
+			// If too many packets are sent, the count overshoots and doesn't match in the next step.
+			expected := 1
+			for i := 1; i <= 20; i++ {
+				clock.Advance(5 * time.Millisecond / 2)
+				sess.scheduleSending()
+				if i%2 == 0 {
+					expected++
+				}
+				Eventually(getNumSent).Should(BeEquivalentTo(expected))
+			}
+		})
+
 		It("doesn't try to send if the send queue is full", func() {
 			available := make(chan struct{}, 1)
 			sender.EXPECT().WouldBlock().Return(true)
@@ -1599,7 +2392,7 @@ var _ = Describe("Session", func() {
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			packer.EXPECT().PackPacket().Return(getPacket(1000), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { close(written) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { close(written) })
 			available <- struct{}{}
 			Eventually(written).Should(BeClosed())
 		})
@@ -1622,7 +2415,7 @@ var _ = Describe("Session", func() {
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			packer.EXPECT().PackPacket().Return(getPacket(1000), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { close(written) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { close(written) })
 
 			sess.scheduleSending()
 			time.Sleep(scaleDuration(50 * time.Millisecond))
@@ -1638,7 +2431,7 @@ var _ = Describe("Session", func() {
 			written := make(chan struct{}, 1)
 			sender.EXPECT().WouldBlock()
 			sender.EXPECT().WouldBlock().Return(true).Times(2)
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { written <- struct{}{} })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} })
 			go func() {
 				defer GinkgoRecover()
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
@@ -1657,7 +2450,7 @@ var _ = Describe("Session", func() {
 			sender.EXPECT().WouldBlock().AnyTimes()
 			packer.EXPECT().PackPacket().Return(getPacket(1001), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { written <- struct{}{} })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} })
 			available <- struct{}{}
 			Eventually(written).Should(Receive())
 
@@ -1691,7 +2484,7 @@ var _ = Describe("Session", func() {
 			sph.EXPECT().SendMode().Return(ackhandler.SendNone)
 			written := make(chan struct{}, 1)
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sender.EXPECT().Send(gomock.Any()).DoAndReturn(func(p *packetBuffer) { written <- struct{}{} })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(*packetBuffer, protocol.ECN) { written <- struct{}{} })
 			gomock.InOrder(
 				mtuDiscoverer.EXPECT().NextProbeTime(),
 				mtuDiscoverer.EXPECT().ShouldSendProbe(gomock.Any()).Return(true),
@@ -1727,7 +2520,7 @@ var _ = Describe("Session", func() {
 			streamManager.EXPECT().CloseWithError(gomock.Any())
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sender.EXPECT().Close()
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
@@ -1737,6 +2530,7 @@ var _ = Describe("Session", func() {
 
 		It("sends when scheduleSending is called", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1755,7 +2549,7 @@ var _ = Describe("Session", func() {
 			time.Sleep(50 * time.Millisecond)
 			// only EXPECT calls after scheduleSending is called
 			written := make(chan struct{})
-			sender.EXPECT().Send(gomock.Any()).Do(func(*packetBuffer) { close(written) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(written) })
 			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			sess.scheduleSending()
 			Eventually(written).Should(BeClosed())
@@ -1765,6 +2559,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackPacket().Return(getPacket(1234), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().ECNMode().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
@@ -1779,7 +2574,7 @@ var _ = Describe("Session", func() {
 			sess.receivedPacketHandler = rph
 
 			written := make(chan struct{})
-			sender.EXPECT().Send(gomock.Any()).Do(func(*packetBuffer) { close(written) })
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Do(func(*packetBuffer, protocol.ECN) { close(written) })
 			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			go func() {
 				defer GinkgoRecover()
@@ -1797,7 +2592,7 @@ var _ = Describe("Session", func() {
 		sess.sentPacketHandler = sph
 		buffer := getPacketBuffer()
 		buffer.Data = append(buffer.Data, []byte("foobar")...)
-		packer.EXPECT().PackCoalescedPacket().Return(&coalescedPacket{
+		packer.EXPECT().PackCoalescedPacket(false).Return(&coalescedPacket{
 			buffer: buffer,
 			packets: []*packetContents{
 				{
@@ -1822,7 +2617,7 @@ var _ = Describe("Session", func() {
 				},
 			},
 		}, nil)
-		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 		sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1849,7 +2644,7 @@ var _ = Describe("Session", func() {
 		)
 
 		sent := make(chan struct{})
-		mconn.EXPECT().Write([]byte("foobar")).Do(func([]byte) { close(sent) })
+		mconn.EXPECT().Write([]byte("foobar"), gomock.Any()).Do(func([]byte, protocol.ECN) { close(sent) })
 
 		go func() {
 			defer GinkgoRecover()
@@ -1865,7 +2660,7 @@ var _ = Describe("Session", func() {
 		expectReplaceWithClosed()
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		sess.shutdown()
@@ -1873,7 +2668,7 @@ var _ = Describe("Session", func() {
 	})
 
 	It("cancels the HandshakeComplete context when the handshake completes", func() {
-		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 		finishHandshake := make(chan struct{})
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
 		sess.sentPacketHandler = sph
@@ -1888,6 +2683,7 @@ var _ = Describe("Session", func() {
 			cryptoSetup.EXPECT().RunHandshake()
 			cryptoSetup.EXPECT().SetHandshakeConfirmed()
 			cryptoSetup.EXPECT().GetSessionTicket()
+			tracer.EXPECT().CompletedHandshake()
 			close(sess.handshakeCompleteChan)
 			sess.run()
 		}()
@@ -1900,7 +2696,47 @@ var _ = Describe("Session", func() {
 		expectReplaceWithClosed()
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
+		tracer.EXPECT().ClosedConnection(gomock.Any())
+		tracer.EXPECT().Close()
+		sess.shutdown()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+	})
+
+	It("records when the handshake completes and is confirmed", func() {
+		packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sess.sentPacketHandler = sph
+		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+		sph.EXPECT().TimeUntilSend().AnyTimes()
+		sph.EXPECT().SendMode().AnyTimes()
+		sph.EXPECT().SetHandshakeConfirmed()
+		sessionRunner.EXPECT().Retire(clientDestConnID)
+		created, handshakeComplete, handshakeConfirmed := sess.Timestamps()
+		Expect(created).ToNot(BeZero())
+		Expect(handshakeComplete).To(BeZero())
+		Expect(handshakeConfirmed).To(BeZero())
+		go func() {
+			defer GinkgoRecover()
+			cryptoSetup.EXPECT().RunHandshake()
+			cryptoSetup.EXPECT().SetHandshakeConfirmed()
+			cryptoSetup.EXPECT().GetSessionTicket()
+			tracer.EXPECT().CompletedHandshake()
+			close(sess.handshakeCompleteChan)
+			sess.run()
+		}()
+		Eventually(sess.HandshakeComplete().Done()).Should(BeClosed())
+		created2, handshakeComplete, handshakeConfirmed := sess.Timestamps()
+		Expect(created2).To(Equal(created))
+		Expect(handshakeComplete).To(BeTemporally(">=", created))
+		// for the server, the handshake is confirmed when it completes
+		Expect(handshakeConfirmed).To(BeTemporally(">=", handshakeComplete))
+		// make sure the go routine returns
+		streamManager.EXPECT().CloseWithError(gomock.Any())
+		expectReplaceWithClosed()
+		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+		cryptoSetup.EXPECT().Close()
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		sess.shutdown()
@@ -1909,7 +2745,7 @@ var _ = Describe("Session", func() {
 
 	It("sends a session ticket when the handshake completes", func() {
 		const size = protocol.MaxPostHandshakeCryptoFrameSize * 3 / 2
-		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 		finishHandshake := make(chan struct{})
 		sessionRunner.EXPECT().Retire(clientDestConnID)
 		go func() {
@@ -1918,6 +2754,7 @@ var _ = Describe("Session", func() {
 			cryptoSetup.EXPECT().RunHandshake()
 			cryptoSetup.EXPECT().SetHandshakeConfirmed()
 			cryptoSetup.EXPECT().GetSessionTicket().Return(make([]byte, size), nil)
+			tracer.EXPECT().CompletedHandshake()
 			close(sess.handshakeCompleteChan)
 			sess.run()
 		}()
@@ -1945,7 +2782,7 @@ var _ = Describe("Session", func() {
 		expectReplaceWithClosed()
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		sess.shutdown()
@@ -1953,7 +2790,7 @@ var _ = Describe("Session", func() {
 	})
 
 	It("doesn't cancel the HandshakeComplete context when the handshake fails", func() {
-		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 		streamManager.EXPECT().CloseWithError(gomock.Any())
 		expectReplaceWithClosed()
 		packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
@@ -1967,7 +2804,7 @@ var _ = Describe("Session", func() {
 		}()
 		handshakeCtx := sess.HandshakeComplete()
 		Consistently(handshakeCtx.Done()).ShouldNot(BeClosed())
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		sess.closeLocal(errors.New("handshake error"))
 		Consistently(handshakeCtx.Done()).ShouldNot(BeClosed())
 		Eventually(sess.Context().Done()).Should(BeClosed())
@@ -1975,13 +2812,14 @@ var _ = Describe("Session", func() {
 
 	It("sends a HANDSHAKE_DONE frame when the handshake completes", func() {
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().ECNMode().AnyTimes()
 		sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 		sph.EXPECT().TimeUntilSend().AnyTimes()
 		sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
 		sph.EXPECT().SetHandshakeConfirmed()
 		sph.EXPECT().SentPacket(gomock.Any())
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 		sess.sentPacketHandler = sph
 		done := make(chan struct{})
@@ -2004,7 +2842,8 @@ var _ = Describe("Session", func() {
 			cryptoSetup.EXPECT().RunHandshake()
 			cryptoSetup.EXPECT().SetHandshakeConfirmed()
 			cryptoSetup.EXPECT().GetSessionTicket()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
+			tracer.EXPECT().CompletedHandshake()
 			close(sess.handshakeCompleteChan)
 			sess.run()
 		}()
@@ -2032,7 +2871,7 @@ var _ = Describe("Session", func() {
 		expectReplaceWithClosed()
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		sess.shutdown()
@@ -2056,7 +2895,7 @@ var _ = Describe("Session", func() {
 		expectReplaceWithClosed()
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		Expect(sess.CloseWithError(0x1337, testErr.Error())).To(Succeed())
@@ -2076,7 +2915,7 @@ var _ = Describe("Session", func() {
 			}
 			streamManager.EXPECT().UpdateLimits(params)
 			packer.EXPECT().HandleTransportParameters(params)
-			packer.EXPECT().PackCoalescedPacket().MaxTimes(3)
+			packer.EXPECT().PackCoalescedPacket(false).MaxTimes(3)
 			Expect(sess.earlySessionReady()).ToNot(BeClosed())
 			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
 			sessionRunner.EXPECT().Add(gomock.Any(), sess).Times(2)
@@ -2084,6 +2923,58 @@ var _ = Describe("Session", func() {
 			sess.handleTransportParameters(params)
 			Expect(sess.earlySessionReady()).To(BeClosed())
 		})
+
+		It("allows reading the transport parameters sent by the peer, once the handshake completes", func() {
+			data := (&wire.TransportParameters{
+				InitialMaxData:            0x5000,
+				ActiveConnectionIDLimit:   3,
+				MaxDatagramFrameSize:      1000,
+				InitialSourceConnectionID: destConnID,
+			}).Marshal(protocol.PerspectiveClient)
+			params := &wire.TransportParameters{}
+			Expect(params.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+			streamManager.EXPECT().UpdateLimits(params)
+			packer.EXPECT().HandleTransportParameters(params)
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).Times(2)
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.handleTransportParameters(params)
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				Expect(sess.PeerSupportsDatagrams()).To(BeTrue())
+				val, ok := sess.PeerTransportParameter(0x20) // max_datagram_frame_size
+				Expect(ok).To(BeTrue())
+				Expect(val).To(Equal([]byte{0x43, 0xe8})) // varint encoding of 1000
+				_, ok = sess.PeerTransportParameter(0x1337)
+				Expect(ok).To(BeFalse())
+				close(done)
+			}()
+			Consistently(done).ShouldNot(BeClosed())
+			sess.handshakeCtxCancel()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("says that the peer doesn't support datagrams, if it didn't send the max_datagram_frame_size", func() {
+			data := (&wire.TransportParameters{
+				ActiveConnectionIDLimit:   3,
+				MaxDatagramFrameSize:      protocol.InvalidByteCount,
+				InitialSourceConnectionID: destConnID,
+			}).Marshal(protocol.PerspectiveClient)
+			params := &wire.TransportParameters{}
+			Expect(params.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+			streamManager.EXPECT().UpdateLimits(params)
+			packer.EXPECT().HandleTransportParameters(params)
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).Times(2)
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.handleTransportParameters(params)
+			sess.handshakeCtxCancel()
+			Expect(sess.PeerSupportsDatagrams()).To(BeFalse())
+			_, ok := sess.PeerTransportParameter(0x20)
+			Expect(ok).To(BeFalse())
+		})
 	})
 
 	Context("keep-alives", func() {
@@ -2117,7 +3008,7 @@ var _ = Describe("Session", func() {
 			streamManager.EXPECT().CloseWithError(gomock.Any())
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.shutdown()
@@ -2128,7 +3019,7 @@ var _ = Describe("Session", func() {
 			setRemoteIdleTimeout(5 * time.Second)
 			sess.lastPacketReceivedTime = time.Now().Add(-5 * time.Second / 2)
 			sent := make(chan struct{})
-			packer.EXPECT().PackCoalescedPacket().Do(func() (*packedPacket, error) {
+			packer.EXPECT().PackCoalescedPacket(false).Do(func(bool) (*packedPacket, error) {
 				close(sent)
 				return nil, nil
 			})
@@ -2141,7 +3032,7 @@ var _ = Describe("Session", func() {
 			setRemoteIdleTimeout(time.Hour)
 			sess.lastPacketReceivedTime = time.Now().Add(-protocol.MaxKeepAliveInterval).Add(-time.Millisecond)
 			sent := make(chan struct{})
-			packer.EXPECT().PackCoalescedPacket().Do(func() (*packedPacket, error) {
+			packer.EXPECT().PackCoalescedPacket(false).Do(func(bool) (*packedPacket, error) {
 				close(sent)
 				return nil, nil
 			})
@@ -2149,6 +3040,56 @@ var _ = Describe("Session", func() {
 			Eventually(sent).Should(BeClosed())
 		})
 
+		It("sends a PING after the configured keep-alive period", func() {
+			sess.config.KeepAlive = false
+			sess.config.KeepAlivePeriod = time.Second
+			setRemoteIdleTimeout(5 * time.Second)
+			Expect(sess.keepAliveInterval).To(Equal(time.Second))
+			sess.lastPacketReceivedTime = time.Now().Add(-time.Second)
+			sent := make(chan struct{})
+			packer.EXPECT().PackCoalescedPacket(false).Do(func(bool) (*packedPacket, error) {
+				close(sent)
+				return nil, nil
+			})
+			runSession()
+			Eventually(sent).Should(BeClosed())
+			Expect(sess.framer.HasData()).To(BeTrue())
+		})
+
+		It("doesn't time out while keep-alive PINGs are sent", func() {
+			clock := newManualClock(time.Now())
+			sess.config.Clock = clock
+			sess.config.KeepAlivePeriod = 10 * time.Second
+			sess.config.MaxIdleTimeout = 50 * time.Second
+			setRemoteIdleTimeout(50 * time.Second)
+			sess.lastPacketReceivedTime = clock.Now()
+			var pings int32
+			packer.EXPECT().PackCoalescedPacket(false).DoAndReturn(func(bool) (*coalescedPacket, error) {
+				if sess.keepAlivePingSent {
+					// the peer acknowledges every keep-alive PING
+					sess.lastPacketReceivedTime = clock.Now()
+					sess.keepAlivePingSent = false
+					atomic.AddInt32(&pings, 1)
+				}
+				return nil, nil
+			}).AnyTimes()
+			runSession()
+			// 10 keep-alive periods add up to twice the idle timeout
+			for i := 1; i <= 10; i++ {
+				clock.Advance(10 * time.Second)
+				sess.scheduleSending()
+				Eventually(func() int32 { return atomic.LoadInt32(&pings) }).Should(BeEquivalentTo(i))
+			}
+			Expect(sess.Context().Done()).ToNot(BeClosed())
+		})
+
+		It("reduces the keep-alive period to half the idle timeout", func() {
+			sess.config.KeepAlivePeriod = 10 * time.Second
+			setRemoteIdleTimeout(5 * time.Second)
+			Expect(sess.keepAliveInterval).To(Equal(5 * time.Second / 2))
+			runSession()
+		})
+
 		It("doesn't send a PING packet if keep-alive is disabled", func() {
 			setRemoteIdleTimeout(5 * time.Second)
 			sess.config.KeepAlive = false
@@ -2253,19 +3194,21 @@ var _ = Describe("Session", func() {
 			// make the go routine return
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			sess.shutdown()
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
 
-		It("closes the session due to the idle timeout before handshake", func() {
+		It("closes the session with a handshake timeout error when the idle timeout fires before the handshake completes", func() {
 			sess.config.HandshakeIdleTimeout = 0
-			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 			sessionRunner.EXPECT().Remove(gomock.Any()).AnyTimes()
 			cryptoSetup.EXPECT().Close()
 			gomock.InOrder(
 				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
-					Expect(e).To(MatchError(&IdleTimeoutError{}))
+					Expect(e).To(MatchError(&HandshakeTimeoutError{}))
+					idleTimeout := &IdleTimeoutError{}
+					Expect(errors.As(e, &idleTimeout)).To(BeFalse())
 				}),
 				tracer.EXPECT().Close(),
 			)
@@ -2279,14 +3222,14 @@ var _ = Describe("Session", func() {
 				nerr, ok := err.(net.Error)
 				Expect(ok).To(BeTrue())
 				Expect(nerr.Timeout()).To(BeTrue())
-				Expect(err).To(MatchError(qerr.ErrIdleTimeout))
+				Expect(err).To(MatchError(qerr.ErrHandshakeTimeout))
 				close(done)
 			}()
 			Eventually(done).Should(BeClosed())
 		})
 
-		It("closes the session due to the idle timeout after handshake", func() {
-			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		It("closes the session with an idle timeout error when the idle timeout fires after the handshake completed", func() {
+			packer.EXPECT().PackCoalescedPacket(false).AnyTimes()
 			gomock.InOrder(
 				sessionRunner.EXPECT().Retire(clientDestConnID),
 				sessionRunner.EXPECT().Remove(gomock.Any()),
@@ -2295,6 +3238,8 @@ var _ = Describe("Session", func() {
 			gomock.InOrder(
 				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
 					Expect(e).To(MatchError(&IdleTimeoutError{}))
+					handshakeTimeout := &HandshakeTimeoutError{}
+					Expect(errors.As(e, &handshakeTimeout)).To(BeFalse())
 				}),
 				tracer.EXPECT().Close(),
 			)
@@ -2305,6 +3250,7 @@ var _ = Describe("Session", func() {
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
 				cryptoSetup.EXPECT().GetSessionTicket().MaxTimes(1)
 				cryptoSetup.EXPECT().SetHandshakeConfirmed().MaxTimes(1)
+				tracer.EXPECT().CompletedHandshake()
 				close(sess.handshakeCompleteChan)
 				err := sess.run()
 				nerr, ok := err.(net.Error)
@@ -2330,7 +3276,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 			expectReplaceWithClosed()
 			cryptoSetup.EXPECT().Close()
-			mconn.EXPECT().Write(gomock.Any())
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.shutdown()
@@ -2338,6 +3284,259 @@ var _ = Describe("Session", func() {
 		})
 	})
 
+	Context("next timeout", func() {
+		var (
+			sph        *mockackhandler.MockSentPacketHandler
+			rph        *mockackhandler.MockReceivedPacketHandler
+			lossTime   time.Time
+			ackAlarm   time.Time
+			idleExpiry time.Time
+		)
+
+		BeforeEach(func() {
+			sess.timer = utils.NewTimer()
+			sess.handshakeComplete = true
+			sess.idleTimeout = time.Minute
+			sess.lastPacketReceivedTime = time.Now()
+			idleExpiry = sess.lastPacketReceivedTime.Add(time.Minute)
+			lossTime = time.Time{}
+			ackAlarm = time.Time{}
+			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetLossDetectionTimeout().DoAndReturn(func() time.Time { return lossTime }).AnyTimes()
+			sess.sentPacketHandler = sph
+			rph = mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+			rph.EXPECT().GetAlarmTimeout().DoAndReturn(func() time.Time { return ackAlarm }).AnyTimes()
+			sess.receivedPacketHandler = rph
+		})
+
+		AfterEach(func() {
+			sess.timer.Stop()
+		})
+
+		It("returns the zero value before the timer was set", func() {
+			Expect(sess.NextTimeout()).To(BeZero())
+		})
+
+		It("returns the idle timeout, if no other timer is set", func() {
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(idleExpiry))
+		})
+
+		It("returns the ACK timer", func() {
+			ackAlarm = time.Now().Add(10 * time.Millisecond)
+			lossTime = time.Now().Add(20 * time.Millisecond)
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(ackAlarm))
+		})
+
+		It("returns the loss detection timer", func() {
+			ackAlarm = time.Now().Add(20 * time.Millisecond)
+			lossTime = time.Now().Add(10 * time.Millisecond)
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(lossTime))
+		})
+
+		It("returns the pacing deadline", func() {
+			ackAlarm = time.Now().Add(20 * time.Millisecond)
+			lossTime = time.Now().Add(20 * time.Millisecond)
+			sess.pacingDeadline = time.Now().Add(10 * time.Millisecond)
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(sess.pacingDeadline))
+		})
+
+		It("returns the keep-alive time", func() {
+			sess.config.KeepAlive = true
+			sess.keepAliveInterval = 5 * time.Second
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(sess.lastPacketReceivedTime.Add(5 * time.Second)))
+		})
+
+		It("is updated when the timers change", func() {
+			ackAlarm = time.Now().Add(10 * time.Millisecond)
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(ackAlarm))
+			ackAlarm = time.Time{}
+			sess.maybeResetTimer()
+			Expect(sess.NextTimeout()).To(Equal(idleExpiry))
+		})
+	})
+
+	Context("receive window auto-tuning", func() {
+		const initialWindow protocol.ByteCount = 1000
+
+		BeforeEach(func() {
+			sess.config.InitialStreamReceiveWindow = uint64(initialWindow)
+			sess.config.MaxStreamReceiveWindow = uint64(10 * initialWindow)
+			sess.peerParams = &wire.TransportParameters{}
+			sess.rttStats.UpdateRTT(time.Second, 0, time.Now())
+		})
+
+		// readFast reads 60% of the initial window well within one RTT, and returns the offset of the window update
+		readFast := func() protocol.ByteCount {
+			fc := sess.newFlowController(protocol.StreamID(4))
+			Expect(fc.UpdateHighestReceived(600, false)).To(Succeed())
+			fc.AddBytesRead(600)
+			return fc.GetWindowUpdate()
+		}
+
+		It("increases the stream receive window when the application reads fast", func() {
+			Expect(readFast()).To(BeNumerically(">", 600+initialWindow))
+		})
+
+		It("doesn't increase the stream receive window when auto-tuning is disabled", func() {
+			sess.config.DisableReceiveWindowAutoTuning = true
+			Expect(readFast()).To(Equal(600 + initialWindow))
+		})
+	})
+
+	It("lists the open streams", func() {
+		streamManager.EXPECT().OpenStreams().Return([]protocol.StreamID{0, 3, 4})
+		Expect(sess.OpenStreams()).To(Equal([]StreamID{0, 3, 4}))
+	})
+
+	It("reports the connection ID used to address the peer", func() {
+		_, dest := sess.ConnectionIDs()
+		Expect(dest).To(Equal(destConnID))
+		newConnID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}
+		sess.connIDManager.ChangeInitialConnID(newConnID)
+		_, dest = sess.ConnectionIDs()
+		Expect(dest).To(Equal(destConnID))
+		// the connection ID is updated when it's used for the next packet
+		Expect(sess.getDestConnID()).To(Equal(newConnID))
+		_, dest = sess.ConnectionIDs()
+		Expect(dest).To(Equal(newConnID))
+	})
+
+	It("calls the OnConnectionIDChanged callback when the connection ID used to address the peer changes", func() {
+		type change struct{ old, new protocol.ConnectionID }
+		var changes []change
+		sess.config.OnConnectionIDChanged = func(old, new ConnectionID) {
+			changes = append(changes, change{old: old, new: new})
+		}
+		Expect(sess.getDestConnID()).To(Equal(destConnID))
+		Expect(changes).To(BeEmpty())
+		newConnID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}
+		sess.connIDManager.ChangeInitialConnID(newConnID)
+		Expect(sess.getDestConnID()).To(Equal(newConnID))
+		Expect(changes).To(Equal([]change{{old: destConnID, new: newConnID}}))
+		// the callback is only called once per change
+		Expect(sess.getDestConnID()).To(Equal(newConnID))
+		Expect(changes).To(HaveLen(1))
+	})
+
+	It("calls the OnConnectionIDIssued and OnConnectionIDRetired callbacks when one of our connection IDs is added or retired", func() {
+		var issued, retired []protocol.ConnectionID
+		sess.runners.onConnIDIssued = func(connID protocol.ConnectionID) { issued = append(issued, connID) }
+		sess.runners.onConnIDRetired = func(connID protocol.ConnectionID) { retired = append(retired, connID) }
+		var connIDs []protocol.ConnectionID
+		sessionRunner.EXPECT().Add(gomock.Any(), sess).DoAndReturn(func(connID protocol.ConnectionID, _ packetHandler) bool {
+			connIDs = append(connIDs, connID)
+			return true
+		}).Times(2)
+		sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
+		Expect(sess.connIDGenerator.SetMaxActiveConnIDs(2)).To(Succeed())
+		Expect(connIDs).To(HaveLen(1))
+		Expect(issued).To(Equal(connIDs))
+		Expect(retired).To(BeEmpty())
+		sessionRunner.EXPECT().Retire(connIDs[0])
+		Expect(sess.handleRetireConnectionIDFrame(&wire.RetireConnectionIDFrame{SequenceNumber: 1}, srcConnID)).To(Succeed())
+		Expect(connIDs).To(HaveLen(2))
+		Expect(issued).To(Equal(connIDs))
+		Expect(retired).To(Equal([]protocol.ConnectionID{connIDs[0]}))
+	})
+
+	Context("congestion events", func() {
+		It("doesn't export congestion events by default", func() {
+			Expect(sess.CongestionEvents()).To(BeNil())
+			Expect(sess.DroppedCongestionEvents()).To(BeZero())
+		})
+
+		It("exports congestion events, while still passing them to the tracer", func() {
+			tr := mocklogging.NewMockConnectionTracer(mockCtrl)
+			tr.EXPECT().SentTransportParameters(gomock.Any())
+			tr.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+			tr.EXPECT().UpdatedCongestionState(logging.CongestionStateSlowStart)
+			tr.EXPECT().UpdatedECNState(logging.ECNStateTesting)
+			s := newSession(
+				mconn,
+				sessionRunner,
+				nil,
+				nil,
+				clientDestConnID,
+				destConnID,
+				srcConnID,
+				protocol.StatelessResetToken{},
+				populateServerConfig(&Config{DisablePathMTUDiscovery: true, CongestionEventBufferSize: 10}),
+				nil, // tls.Config
+				nil,
+				false,
+				nil,
+				tr,
+				1234,
+				utils.DefaultLogger,
+				protocol.VersionTLS,
+			).(*session)
+			Expect(s.CongestionEvents()).ToNot(BeNil())
+			var event CongestionEvent
+			Expect(s.CongestionEvents()).To(Receive(&event))
+			Expect(event.Type).To(Equal(CongestionEventStateUpdated))
+			Expect(event.State).To(Equal(logging.CongestionStateSlowStart))
+			tr.EXPECT().Close()
+			s.tracer.Close()
+			Expect(s.CongestionEvents()).To(BeClosed())
+		})
+	})
+
+	Context("current MTU", func() {
+		var sph *mockackhandler.MockSentPacketHandler
+
+		BeforeEach(func() {
+			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			sess.config.DisablePathMTUDiscovery = false
+			sess.peerParams = &wire.TransportParameters{MaxUDPPayloadSize: 1500}
+			sph.EXPECT().SetHandshakeConfirmed()
+			cryptoSetup.EXPECT().SetHandshakeConfirmed()
+		})
+
+		It("reports the initial packet size", func() {
+			Expect(sess.CurrentMTU()).To(Equal(getMaxPacketSize(remoteAddr)))
+			sess.handleHandshakeConfirmed()
+			Expect(sess.CurrentMTU()).To(Equal(getMaxPacketSize(remoteAddr)))
+		})
+
+		It("doesn't start Path MTU Discovery when it is disabled", func() {
+			sess.config.DisablePathMTUDiscovery = true
+			// don't EXPECT any calls to SetMaxDatagramSize
+			sess.handleHandshakeConfirmed()
+			Expect(sess.mtuDiscoverer).To(BeNil())
+			Expect(sess.CurrentMTU()).To(Equal(getMaxPacketSize(remoteAddr)))
+		})
+
+		It("reports the packet size found by Path MTU Discovery", func() {
+			sess.handleHandshakeConfirmed()
+			ping, size := sess.mtuDiscoverer.GetPing()
+			Expect(size).To(BeNumerically(">", getMaxPacketSize(remoteAddr)))
+			sph.EXPECT().SetMaxDatagramSize(size)
+			packer.EXPECT().SetMaxPacketSize(size)
+			tracer.EXPECT().UpdatedMTU(size, false)
+			ping.OnAcked(ping.Frame)
+			Expect(sess.CurrentMTU()).To(Equal(size))
+		})
+
+		It("doesn't change the packet size when a probe packet is lost", func() {
+			sess.handleHandshakeConfirmed()
+			ping, size := sess.mtuDiscoverer.GetPing()
+			sph.EXPECT().SetMaxDatagramSize(size)
+			packer.EXPECT().SetMaxPacketSize(size)
+			tracer.EXPECT().UpdatedMTU(size, false)
+			ping.OnAcked(ping.Frame)
+			ping, _ = sess.mtuDiscoverer.GetPing()
+			ping.OnLost(ping.Frame)
+			Expect(sess.CurrentMTU()).To(Equal(size))
+		})
+	})
+
 	It("stores up to MaxSessionUnprocessedPackets packets", func() {
 		done := make(chan struct{})
 		tracer.EXPECT().DroppedPacket(logging.PacketTypeNotDetermined, logging.ByteCount(6), logging.PacketDropDOSPrevention).Do(func(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
@@ -2350,6 +3549,28 @@ var _ = Describe("Session", func() {
 		Eventually(done).Should(BeClosed())
 	})
 
+	It("timestamps received packets using the clock", func() {
+		now := time.Now().Add(-time.Hour)
+		sess.config.Clock = newManualClock(now)
+		sess.handlePacket(&receivedPacket{data: []byte("foobar"), rcvTime: time.Now()})
+		var p *receivedPacket
+		Expect(sess.receivedPackets).To(Receive(&p))
+		Expect(p.rcvTime).To(Equal(now))
+	})
+
+	It("passes received packets to the packet capture", func() {
+		var capturedData []byte
+		var capturedAddr net.Addr
+		sess.config.PacketCapture = func(dir PacketDirection, data []byte, addr net.Addr) {
+			Expect(dir).To(Equal(PacketDirectionReceived))
+			capturedData = append([]byte{}, data...)
+			capturedAddr = addr
+		}
+		sess.handlePacket(&receivedPacket{data: []byte("foobar"), remoteAddr: remoteAddr})
+		Expect(capturedData).To(Equal([]byte("foobar")))
+		Expect(capturedAddr).To(Equal(remoteAddr))
+	})
+
 	Context("getting streams", func() {
 		It("opens streams", func() {
 			mstr := NewMockStreamI(mockCtrl)
@@ -2402,6 +3623,12 @@ var _ = Describe("Session", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(str).To(Equal(mstr))
 		})
+
+		It("sets the write deadline on the streams", func() {
+			deadline := time.Now().Add(time.Minute)
+			streamManager.EXPECT().SetWriteDeadline(deadline)
+			sess.SetWriteDeadline(deadline)
+		})
 	})
 
 	It("returns the local address", func() {
@@ -2423,6 +3650,7 @@ var _ = Describe("Client Session", func() {
 		tracer        *mocklogging.MockConnectionTracer
 		tlsConf       *tls.Config
 		quicConf      *Config
+		sentParams    *wire.TransportParameters
 	)
 	srcConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
 	destConnID := protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 1}
@@ -2437,7 +3665,7 @@ var _ = Describe("Client Session", func() {
 	}
 
 	expectReplaceWithClosed := func() {
-		sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+		sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 			s.shutdown()
 			Eventually(areClosedSessionsRunning).Should(BeFalse())
 		})
@@ -2460,9 +3688,11 @@ var _ = Describe("Client Session", func() {
 		sessionRunner = NewMockSessionRunner(mockCtrl)
 		tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
 		tracer.EXPECT().NegotiatedVersion(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1)
-		tracer.EXPECT().SentTransportParameters(gomock.Any())
+		tracer.EXPECT().SentTransportParameters(gomock.Any()).Do(func(p *wire.TransportParameters) { sentParams = p })
 		tracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+		tracer.EXPECT().StartedHandshake().MaxTimes(1)
 		tracer.EXPECT().UpdatedCongestionState(gomock.Any())
+		tracer.EXPECT().UpdatedECNState(logging.ECNStateTesting)
 		sess = newClientSession(
 			mconn,
 			sessionRunner,
@@ -2473,6 +3703,7 @@ var _ = Describe("Client Session", func() {
 			42, // initial packet number
 			false,
 			false,
+			nil,
 			tracer,
 			1234,
 			utils.DefaultLogger,
@@ -2484,6 +3715,32 @@ var _ = Describe("Client Session", func() {
 		sess.cryptoStreamHandler = cryptoSetup
 	})
 
+	It("advertises the default active_connection_id_limit", func() {
+		Expect(sentParams.ActiveConnectionIDLimit).To(BeEquivalentTo(protocol.MinActiveConnectionIDLimit))
+	})
+
+	Context("with a configured active_connection_id_limit", func() {
+		BeforeEach(func() {
+			quicConf = populateClientConfig(&Config{ActiveConnectionIDLimit: 7}, true)
+		})
+
+		It("advertises the limit, and accepts that many connection IDs from the server", func() {
+			Expect(sentParams.ActiveConnectionIDLimit).To(BeEquivalentTo(7))
+			for i := uint8(1); i < 7; i++ {
+				Expect(sess.connIDManager.Add(&wire.NewConnectionIDFrame{
+					SequenceNumber:      uint64(i),
+					ConnectionID:        protocol.ConnectionID{i, i, i, i},
+					StatelessResetToken: protocol.StatelessResetToken{i},
+				})).To(Succeed())
+			}
+			Expect(sess.connIDManager.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      7,
+				ConnectionID:        protocol.ConnectionID{7, 7, 7, 7},
+				StatelessResetToken: protocol.StatelessResetToken{7},
+			})).To(MatchError(&qerr.TransportError{ErrorCode: qerr.ConnectionIDLimitError}))
+		})
+	})
+
 	It("changes the connection ID when receiving the first packet from the server", func() {
 		unpacker := NewMockUnpacker(mockCtrl)
 		unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(hdr *wire.Header, _ time.Time, data []byte) (*unpackedPacket, error) {
@@ -2517,7 +3774,7 @@ var _ = Describe("Client Session", func() {
 		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
 		expectReplaceWithClosed()
 		cryptoSetup.EXPECT().Close()
-		mconn.EXPECT().Write(gomock.Any())
+		mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ClosedConnection(gomock.Any())
 		tracer.EXPECT().Close()
 		sess.shutdown()
@@ -2561,6 +3818,23 @@ var _ = Describe("Client Session", func() {
 		Expect(sess.handleHandshakeDoneFrame()).To(Succeed())
 	})
 
+	It("records when the handshake is confirmed", func() {
+		sess.peerParams = &wire.TransportParameters{}
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sess.sentPacketHandler = sph
+		packer.EXPECT().HandleTransportParameters(gomock.Any())
+		tracer.EXPECT().CompletedHandshake()
+		sess.handleHandshakeComplete()
+		created, handshakeComplete, handshakeConfirmed := sess.Timestamps()
+		Expect(handshakeComplete).To(BeTemporally(">=", created))
+		Expect(handshakeConfirmed).To(BeZero())
+		sph.EXPECT().SetHandshakeConfirmed()
+		cryptoSetup.EXPECT().SetHandshakeConfirmed()
+		Expect(sess.handleHandshakeDoneFrame()).To(Succeed())
+		_, _, handshakeConfirmed = sess.Timestamps()
+		Expect(handshakeConfirmed).To(BeTemporally(">=", handshakeComplete))
+	})
+
 	It("interprets an ACK for 1-RTT packets as confirmation of the handshake", func() {
 		sess.peerParams = &wire.TransportParameters{}
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
@@ -2573,6 +3847,219 @@ var _ = Describe("Client Session", func() {
 		Expect(sess.handleAckFrame(ack, protocol.Encryption1RTT)).To(Succeed())
 	})
 
+	Context("migrating to a new connection", func() {
+		var (
+			mockMultiplexer *MockMultiplexer
+			origMultiplexer multiplexer
+			newConn         *MockPacketConn
+			newRunner       *MockPacketHandlerManager
+			sph             *mockackhandler.MockSentPacketHandler
+		)
+		newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 100), Port: 4321}
+		newDestConnID := protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad}
+		newResetToken := protocol.StatelessResetToken{0xde, 0xad, 0xbe, 0xef}
+
+		BeforeEach(func() {
+			mockMultiplexer = NewMockMultiplexer(mockCtrl)
+			origMultiplexer = getMultiplexer() // makes sure that the multiplexer is initialized
+			connMuxer = mockMultiplexer
+			newConn = NewMockPacketConn(mockCtrl)
+			newConn.EXPECT().LocalAddr().Return(newAddr).AnyTimes()
+			newRunner = NewMockPacketHandlerManager(mockCtrl)
+		})
+
+		AfterEach(func() {
+			connMuxer = origMultiplexer
+		})
+
+		JustBeforeEach(func() {
+			sess.handshakeConfirmed = true
+			sess.peerParams = &wire.TransportParameters{}
+			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			// the server issued a connection ID that can be used on the new path
+			Expect(sess.connIDManager.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      1,
+				ConnectionID:        newDestConnID,
+				StatelessResetToken: newResetToken,
+			})).To(Succeed())
+		})
+
+		migrate := func() <-chan error {
+			req := &migrationRequest{conn: newConn, errChan: make(chan error, 1)}
+			sess.handleMigrationRequest(req)
+			return req.errChan
+		}
+
+		// expectPathChallenge sets the expectations for sending a PATH_CHALLENGE on the new connection,
+		// and returns the data of the PATH_CHALLENGE frame
+		expectPathChallenge := func() *[8]byte {
+			var data [8]byte
+			mockMultiplexer.EXPECT().AddConn(newConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(newRunner, nil)
+			newRunner.EXPECT().Add(srcConnID, sess).Return(true)
+			newRunner.EXPECT().AddResetToken(newResetToken, sess)
+			packer.EXPECT().PackPathProbePacket(newDestConnID, gomock.Any(), protocol.ByteCount(protocol.MinInitialPacketSize)).DoAndReturn(func(_ protocol.ConnectionID, f ackhandler.Frame, _ protocol.ByteCount) (*packedPacket, error) {
+				Expect(f.Frame).To(BeAssignableToTypeOf(&wire.PathChallengeFrame{}))
+				data = f.Frame.(*wire.PathChallengeFrame).Data
+				buffer := getPacketBuffer()
+				buffer.Data = append(buffer.Data, []byte("foobar")...)
+				return &packedPacket{
+					buffer:         buffer,
+					packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 42}, length: 6},
+				}, nil
+			})
+			sph.EXPECT().SentPacket(gomock.Any())
+			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			newConn.EXPECT().WriteTo([]byte("foobar"), &net.UDPAddr{})
+			return &data
+		}
+
+		It("only allows clients to migrate", func() {
+			sess.perspective = protocol.PerspectiveServer
+			Expect(sess.MigrateTo(newConn)).To(MatchError("only the client can migrate a connection"))
+		})
+
+		It("refuses to migrate before the handshake is confirmed", func() {
+			sess.handshakeConfirmed = false
+			Eventually(migrate()).Should(Receive(MatchError("can't migrate before the handshake is confirmed")))
+		})
+
+		It("refuses to migrate if the peer disabled active migration", func() {
+			sess.peerParams.DisableActiveMigration = true
+			Eventually(migrate()).Should(Receive(MatchError("the peer disabled active migration")))
+		})
+
+		It("refuses to migrate if the peer didn't provide an unused connection ID", func() {
+			sess.connIDManager.queue.Init()
+			Eventually(migrate()).Should(Receive(MatchError("the peer didn't provide an unused connection ID")))
+			Expect(sess.pathValidation).To(BeNil())
+		})
+
+		It("migrates once the new path is validated", func() {
+			data := expectPathChallenge()
+			errChan := migrate()
+			Expect(sess.pathValidation).ToNot(BeNil())
+			Consistently(errChan).ShouldNot(Receive())
+			// packets are still sent on the old path
+			Expect(sess.LocalAddr()).To(Equal(&net.UDPAddr{}))
+
+			sender := NewMockSender(mockCtrl)
+			sess.sendQueue = sender
+			sender.EXPECT().SetConn(gomock.Any())
+			maxPacketSize := getMaxPacketSize(&net.UDPAddr{})
+			sph.EXPECT().MigratedPath(maxPacketSize)
+			packer.EXPECT().SetMaxPacketSize(maxPacketSize)
+			sessionRunner.EXPECT().AddResetToken(newResetToken, sess)
+			newRunner.EXPECT().AddResetToken(newResetToken, sess)
+			// the old connection is not used any more
+			sessionRunner.EXPECT().Remove(srcConnID)
+			sessionRunner.EXPECT().RemoveResetToken(newResetToken)
+			Expect(sess.handleFrame(&wire.PathResponseFrame{Data: *data}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Eventually(errChan).Should(Receive(BeNil()))
+			Expect(sess.pathValidation).To(BeNil())
+			Expect(sess.LocalAddr()).To(Equal(newAddr))
+			Expect(sess.runners.runners).To(HaveLen(1))
+			Expect(sess.runners.runners[0]).To(Equal(newRunner))
+			Expect(sess.pathRunner).To(Equal(newRunner))
+			// the connection ID used on the old path is retired
+			Expect(sess.connIDManager.Get()).To(Equal(newDestConnID))
+			frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+			Expect(frames).To(ContainElement(ackhandler.Frame{Frame: &wire.RetireConnectionIDFrame{SequenceNumber: 0}}))
+		})
+
+		It("restarts Path MTU Discovery on the new path", func() {
+			sess.startMTUDiscovery()
+			oldMTUDiscoverer := sess.mtuDiscoverer
+			data := expectPathChallenge()
+			migrate()
+
+			sender := NewMockSender(mockCtrl)
+			sess.sendQueue = sender
+			sender.EXPECT().SetConn(gomock.Any())
+			maxPacketSize := getMaxPacketSize(&net.UDPAddr{})
+			sph.EXPECT().MigratedPath(maxPacketSize)
+			packer.EXPECT().SetMaxPacketSize(maxPacketSize)
+			sessionRunner.EXPECT().AddResetToken(newResetToken, sess)
+			newRunner.EXPECT().AddResetToken(newResetToken, sess)
+			sessionRunner.EXPECT().Remove(srcConnID)
+			sessionRunner.EXPECT().RemoveResetToken(newResetToken)
+			Expect(sess.handleFrame(&wire.PathResponseFrame{Data: *data}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Expect(sess.CurrentMTU()).To(Equal(maxPacketSize))
+			Expect(sess.mtuDiscoverer).ToNot(BeNil())
+			Expect(sess.mtuDiscoverer).ToNot(BeIdenticalTo(oldMTUDiscoverer))
+			// Acknowledgements for probe packets sent on the old path don't change the packet size.
+			oldMTUDiscoverer.(*mtuFinder).mtuIncreased(maxPacketSize + 100)
+			Expect(sess.CurrentMTU()).To(Equal(maxPacketSize))
+		})
+
+		It("ignores PATH_RESPONSE frames that don't match the PATH_CHALLENGE", func() {
+			data := expectPathChallenge()
+			errChan := migrate()
+			data[0]++
+			Expect(sess.handleFrame(&wire.PathResponseFrame{Data: *data}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Consistently(errChan).ShouldNot(Receive())
+			Expect(sess.pathValidation).ToNot(BeNil())
+		})
+
+		It("refuses to migrate while a path validation is in progress", func() {
+			expectPathChallenge()
+			migrate()
+			Eventually(migrate()).Should(Receive(MatchError("already migrating to a new connection")))
+		})
+
+		It("retransmits the PATH_CHALLENGE and fails after Config.MaxPathChallenges PATH_CHALLENGE frames", func() {
+			sess.config.MaxPathChallenges = 3
+			data := expectPathChallenge()
+			errChan := migrate()
+			// the new path is unresponsive
+			for i := 0; i < 2; i++ {
+				packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ protocol.ConnectionID, f ackhandler.Frame, _ protocol.ByteCount) (*packedPacket, error) {
+					Expect(f.Frame).To(Equal(&wire.PathChallengeFrame{Data: *data}))
+					buffer := getPacketBuffer()
+					buffer.Data = append(buffer.Data, []byte("foobar")...)
+					return &packedPacket{
+						buffer:         buffer,
+						packetContents: &packetContents{header: &wire.ExtendedHeader{PacketNumber: 43}, length: 6},
+					}, nil
+				})
+				sph.EXPECT().SentPacket(gomock.Any())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				newConn.EXPECT().WriteTo([]byte("foobar"), &net.UDPAddr{})
+				deadline := sess.pathValidation.deadline
+				sess.onPathValidationTimeout()
+				Expect(sess.pathValidation.deadline).To(BeTemporally(">=", deadline))
+				Expect(errChan).ToNot(Receive())
+			}
+			Expect(sess.pathValidation.numChallenges).To(Equal(3))
+			sessionRunner.EXPECT().RemoveResetToken(newResetToken)
+			newRunner.EXPECT().RemoveResetToken(newResetToken)
+			newRunner.EXPECT().Remove(srcConnID)
+			sess.onPathValidationTimeout()
+			Eventually(errChan).Should(Receive(MatchError("path validation failed: no response to 3 PATH_CHALLENGE frames")))
+			Expect(sess.pathValidation).To(BeNil())
+			Expect(sess.LocalAddr()).To(Equal(&net.UDPAddr{}))
+		})
+
+		It("stops using the new connection when path validation fails", func() {
+			expectPathChallenge()
+			errChan := migrate()
+			sessionRunner.EXPECT().RemoveResetToken(newResetToken)
+			newRunner.EXPECT().RemoveResetToken(newResetToken)
+			newRunner.EXPECT().Remove(srcConnID)
+			sess.abandonPathValidation(errors.New("path validation timed out"))
+			Eventually(errChan).Should(Receive(MatchError("path validation timed out")))
+			Expect(sess.pathValidation).To(BeNil())
+			Expect(sess.LocalAddr()).To(Equal(&net.UDPAddr{}))
+			// the connection ID used on the new path is retired
+			frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+			Expect(frames).To(ContainElement(ackhandler.Frame{Frame: &wire.RetireConnectionIDFrame{SequenceNumber: 1}}))
+			// connection IDs are now only added to the original connection
+			connID := protocol.ConnectionID{1, 3, 3, 7}
+			sessionRunner.EXPECT().Add(connID, sess).Return(true)
+			Expect(sess.runners.Add(connID, sess)).To(BeTrue())
+		})
+	})
+
 	Context("handling tokens", func() {
 		var mockTokenStore *MockTokenStore
 
@@ -2629,6 +4116,19 @@ var _ = Describe("Client Session", func() {
 			recreateErr := err.(*errCloseForRecreating)
 			Expect(recreateErr.nextVersion).To(Equal(protocol.VersionNumber(4321)))
 			Expect(recreateErr.nextPacketNumber).To(Equal(protocol.PacketNumber(128)))
+			Expect(recreateErr.serverSupportedVersions).To(And(
+				ContainElement(protocol.VersionNumber(4321)),
+				ContainElement(protocol.VersionNumber(1337)),
+			))
+		})
+
+		It("returns a copy of the versions offered by the server", func() {
+			Expect(sess.SupportedVersions()).To(BeNil())
+			sess.serverSupportedVersions = []protocol.VersionNumber{4321, 1337}
+			versions := sess.SupportedVersions()
+			Expect(versions).To(Equal([]protocol.VersionNumber{4321, 1337}))
+			versions[0] = 42
+			Expect(sess.SupportedVersions()).To(Equal([]protocol.VersionNumber{4321, 1337}))
 		})
 
 		It("it closes when no matching version is found", func() {
@@ -2657,6 +4157,31 @@ var _ = Describe("Client Session", func() {
 			Expect(err.Error()).To(ContainSubstring("no compatible QUIC version found"))
 		})
 
+		It("closes with ErrVersionNegotiationDisabled, if version negotiation is disabled", func() {
+			sess.config.Versions = []protocol.VersionNumber{1234, 4321}
+			sess.config.DisableVersionNegotiation = true
+			errChan := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				errChan <- sess.run()
+			}()
+			sessionRunner.EXPECT().Remove(srcConnID).MaxTimes(1)
+			gomock.InOrder(
+				tracer.EXPECT().ReceivedVersionNegotiationPacket(gomock.Any(), gomock.Any()),
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
+					Expect(e).To(MatchError(ErrVersionNegotiationDisabled))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			cryptoSetup.EXPECT().Close()
+			// the server offers a version that we support, but we must not switch to it
+			Expect(sess.handlePacketImpl(getVNP(4321, 1337))).To(BeFalse())
+			var err error
+			Eventually(errChan).Should(Receive(&err))
+			Expect(err).To(MatchError(ErrVersionNegotiationDisabled))
+		})
+
 		It("ignores Version Negotiation packets that offer the current version", func() {
 			p := getVNP(sess.version)
 			tracer.EXPECT().DroppedPacket(logging.PacketTypeVersionNegotiation, p.Size(), logging.PacketDropUnexpectedVersion)
@@ -2700,6 +4225,7 @@ var _ = Describe("Client Session", func() {
 			sess.sentPacketHandler = sph
 			sph.EXPECT().ResetForRetry()
 			sph.EXPECT().ReceivedBytes(gomock.Any())
+			sph.EXPECT().GetStats().AnyTimes()
 			cryptoSetup.EXPECT().ChangeConnectionID(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
 			packer.EXPECT().SetToken([]byte("foobar"))
 			tracer.EXPECT().ReceivedRetry(gomock.Any()).Do(func(hdr *wire.Header) {
@@ -2707,7 +4233,34 @@ var _ = Describe("Client Session", func() {
 				Expect(hdr.SrcConnectionID).To(Equal(retryHdr.SrcConnectionID))
 				Expect(hdr.Token).To(Equal(retryHdr.Token))
 			})
+			Expect(sess.ConnectionStats().Retries).To(BeZero())
+			Expect(sess.handlePacketImpl(getPacket(retryHdr, getRetryTag(retryHdr)))).To(BeTrue())
+			// packets sent after the Retry use the connection ID chosen by the server
+			Expect(sess.connIDManager.Get()).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}))
+			Expect(sess.handshakeDestConnID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}))
+			Expect(sess.ConnectionStats().Retries).To(BeEquivalentTo(1))
+		})
+
+		It("ignores a second Retry packet", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			sph.EXPECT().ResetForRetry()
+			sph.EXPECT().ReceivedBytes(gomock.Any()).Times(2)
+			sph.EXPECT().GetStats().AnyTimes()
+			cryptoSetup.EXPECT().ChangeConnectionID(gomock.Any())
+			packer.EXPECT().SetToken(gomock.Any())
+			tracer.EXPECT().ReceivedRetry(gomock.Any())
 			Expect(sess.handlePacketImpl(getPacket(retryHdr, getRetryTag(retryHdr)))).To(BeTrue())
+			// The second Retry uses a different connection ID.
+			// Its integrity tag is calculated using the connection ID from the first Retry.
+			retryHdr.SrcConnectionID = protocol.ConnectionID{0xc0, 0xff, 0xee}
+			retryHdr.Token = []byte("raboof")
+			buf := &bytes.Buffer{}
+			Expect(retryHdr.Write(buf, sess.version)).To(Succeed())
+			tag := handshake.GetRetryIntegrityTag(buf.Bytes(), protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}, sess.version)
+			Expect(sess.handlePacketImpl(getPacket(retryHdr, tag[:]))).To(BeFalse())
+			Expect(sess.connIDManager.Get()).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}))
+			Expect(sess.ConnectionStats().Retries).To(BeEquivalentTo(1))
 		})
 
 		It("ignores Retry packets after receiving a regular packet", func() {
@@ -2752,7 +4305,7 @@ var _ = Describe("Client Session", func() {
 
 		expectClose := func(applicationClose bool) {
 			if !closed {
-				sessionRunner.EXPECT().ReplaceWithClosed(gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
+				sessionRunner.EXPECT().ReplaceWithClosed(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler, _ time.Duration) {
 					Expect(s).To(BeAssignableToTypeOf(&closedLocalSession{}))
 					s.shutdown()
 				})
@@ -2762,7 +4315,7 @@ var _ = Describe("Client Session", func() {
 					packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil).MaxTimes(1)
 				}
 				cryptoSetup.EXPECT().Close()
-				mconn.EXPECT().Write(gomock.Any())
+				mconn.EXPECT().Write(gomock.Any(), gomock.Any())
 				gomock.InOrder(
 					tracer.EXPECT().ClosedConnection(gomock.Any()),
 					tracer.EXPECT().Close(),
@@ -2789,9 +4342,10 @@ var _ = Describe("Client Session", func() {
 				},
 			}
 			packer.EXPECT().HandleTransportParameters(gomock.Any())
-			packer.EXPECT().PackCoalescedPacket().MaxTimes(1)
+			packer.EXPECT().PackCoalescedPacket(false).MaxTimes(1)
 			tracer.EXPECT().ReceivedTransportParameters(params)
 			sess.handleTransportParameters(params)
+			tracer.EXPECT().CompletedHandshake()
 			sess.handleHandshakeComplete()
 			// make sure the connection ID is not retired
 			cf, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
@@ -2813,6 +4367,7 @@ var _ = Describe("Client Session", func() {
 			packer.EXPECT().HandleTransportParameters(gomock.Any())
 			tracer.EXPECT().ReceivedTransportParameters(params)
 			sess.handleTransportParameters(params)
+			tracer.EXPECT().CompletedHandshake()
 			sess.handleHandshakeComplete()
 			Expect(sess.idleTimeout).To(Equal(18 * time.Second))
 			expectClose(true)
@@ -25,8 +25,22 @@ var errDeadline net.Error = &deadlineError{}
 type streamSender interface {
 	queueControlFrame(wire.Frame)
 	onHasStreamData(protocol.StreamID)
+	// onHasStreamRetransmission is called when a STREAM frame was lost and its data needs to be retransmitted.
+	// It must be called without holding the stream's mutex.
+	onHasStreamRetransmission(id protocol.StreamID, priority uint8)
 	// must be called without holding the mutex that is acquired by closeForShutdown
 	onStreamCompleted(protocol.StreamID)
+	// onStreamDataSent is called when stream data is sent for the first time, retransmissions are not reported.
+	// onStreamDataAcked is called when the peer acknowledges stream data.
+	// They must not block.
+	onStreamDataSent(protocol.ByteCount)
+	onStreamDataAcked(protocol.ByteCount)
+	// onStreamCreditAvailable is called when credit for new incoming streams is available, see streamLimiter.
+	// It must not block.
+	onStreamCreditAvailable()
+	// flush makes the session send packets right away.
+	// It blocks until the session tried to send, and must be called without holding the stream's mutex.
+	flush() error
 }
 
 // Each of the both stream halves gets its own uniStreamSender.
@@ -59,9 +73,12 @@ type streamI interface {
 	getWindowUpdate() protocol.ByteCount
 	// for sending
 	hasData() bool
+	hasRetransmission() bool
 	handleStopSendingFrame(*wire.StopSendingFrame)
 	popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool)
 	updateSendWindow(protocol.ByteCount)
+	getPriority() uint8
+	setSessionWriteDeadline(time.Time)
 }
 
 var (
@@ -90,6 +107,7 @@ var _ Stream = &stream{}
 func newStream(streamID protocol.StreamID,
 	sender streamSender,
 	flowController flowcontrol.StreamFlowController,
+	sendBufferLimiter *sendBufferLimiter,
 	version protocol.VersionNumber,
 ) *stream {
 	s := &stream{sender: sender, version: version}
@@ -102,7 +120,7 @@ func newStream(streamID protocol.StreamID,
 			s.completedMutex.Unlock()
 		},
 	}
-	s.sendStream = *newSendStream(streamID, senderForSendStream, flowController, version)
+	s.sendStream = *newSendStream(streamID, senderForSendStream, flowController, sendBufferLimiter, version)
 	senderForReceiveStream := &uniStreamSender{
 		streamSender: sender,
 		onStreamCompletedImpl: func() {
@@ -0,0 +1,145 @@
+package quic
+
+import "sync"
+
+// A streamCreditWaiter is notified when a streamLimiter has credit available again.
+// onStreamCreditAvailable must not block, and must not call back into the streamLimiter.
+type streamCreditWaiter interface {
+	onStreamCreditAvailable()
+}
+
+// The streamLimiter limits the total number of streams that the peers of all sessions of a server can open.
+// It doesn't close sessions whose peer opens too many streams. Instead, it limits the credit that peers are granted,
+// using the initial_max_streams transport parameters and MAX_STREAMS frames.
+// A stream counts towards the limit from the moment the peer is granted credit to open it, until it is completed.
+// Streams maps that were granted less credit than they asked for wait in a queue, and are notified in turn
+// when credit becomes available. This prevents a single session from using up all the credit.
+// It is safe for concurrent use.
+type streamLimiter struct {
+	mutex sync.Mutex
+
+	maxStreams int64
+	numCredits int64                // the number of streams that peers may open or have opened, and that are not yet completed
+	waiters    []streamCreditWaiter // waiting for credit to become available
+}
+
+func newStreamLimiter(maxStreams int64) *streamLimiter {
+	return &streamLimiter{maxStreams: maxStreams}
+}
+
+// Reserve grants credit for up to n streams, and returns the number of streams granted.
+// If less than n streams are granted, w is queued, and notified when it's its turn to be granted credit.
+// As long as another waiter is ahead of w in the queue, no credit is granted to w.
+func (l *streamLimiter) Reserve(w streamCreditWaiter, n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.waiters) > 0 && l.waiters[0] != w {
+		l.addWaiter(w)
+		return 0
+	}
+	granted := n
+	if available := l.maxStreams - l.numCredits; granted > available {
+		granted = available
+	}
+	l.numCredits += granted
+	if granted < n {
+		// Once it was granted credit, w moves to the end of the queue, giving the other waiters their turn.
+		if granted > 0 {
+			l.removeWaiter(w)
+		}
+		l.addWaiter(w)
+		return granted
+	}
+	l.removeWaiter(w)
+	if len(l.waiters) > 0 && l.numCredits < l.maxStreams {
+		l.waiters[0].onStreamCreditAvailable()
+	}
+	return granted
+}
+
+// Release returns the credit for n streams, either because the streams were completed,
+// or because the session was closed before the peer opened them.
+func (l *streamLimiter) Release(n int64) {
+	if n <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.numCredits -= n
+	if len(l.waiters) > 0 {
+		l.waiters[0].onStreamCreditAvailable()
+	}
+}
+
+// RemoveWaiter stops notifying w. It is called when a session is closed.
+func (l *streamLimiter) RemoveWaiter(w streamCreditWaiter) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.removeWaiter(w) && len(l.waiters) > 0 && l.numCredits < l.maxStreams {
+		l.waiters[0].onStreamCreditAvailable()
+	}
+}
+
+// NumCredits returns the number of streams that peers may open or have opened, and that are not yet completed.
+func (l *streamLimiter) NumCredits() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.numCredits
+}
+
+func (l *streamLimiter) addWaiter(w streamCreditWaiter) {
+	for _, waiter := range l.waiters {
+		if waiter == w {
+			return
+		}
+	}
+	l.waiters = append(l.waiters, w)
+}
+
+func (l *streamLimiter) removeWaiter(w streamCreditWaiter) bool {
+	for i, waiter := range l.waiters {
+		if waiter == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// A streamCredit limits the credit that is granted to the peer to open streams.
+type streamCredit interface {
+	// Grant returns the number of the n requested streams that the peer may open.
+	Grant(n uint64) uint64
+	// Release returns the credit for n streams.
+	Release(n uint64)
+	// Close is called when the streams map is closed, after the credit was released.
+	Close()
+}
+
+// limitedStreamCredit grants the credit of an incoming streams map, as far as the streamLimiter allows.
+// Every incoming streams map waits for credit on its own.
+type limitedStreamCredit struct {
+	streamCreditWaiter // notified when the incoming streams map can be granted credit
+
+	limiter *streamLimiter
+}
+
+var _ streamCredit = &limitedStreamCredit{}
+
+func (c *limitedStreamCredit) Grant(n uint64) uint64 {
+	return uint64(c.limiter.Reserve(c, int64(n)))
+}
+
+func (c *limitedStreamCredit) Release(n uint64) {
+	c.limiter.Release(int64(n))
+}
+
+func (c *limitedStreamCredit) Close() {
+	c.limiter.RemoveWaiter(c)
+}
@@ -0,0 +1,75 @@
+package quic
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type countingStreamCreditWaiter struct{ notified int }
+
+func (w *countingStreamCreditWaiter) onStreamCreditAvailable() { w.notified++ }
+
+var _ = Describe("Stream Limiter", func() {
+	var (
+		l      *streamLimiter
+		w1, w2 *countingStreamCreditWaiter
+	)
+
+	BeforeEach(func() {
+		l = newStreamLimiter(10)
+		w1 = &countingStreamCreditWaiter{}
+		w2 = &countingStreamCreditWaiter{}
+	})
+
+	It("grants credit up to the limit", func() {
+		Expect(l.Reserve(w1, 6)).To(BeEquivalentTo(6))
+		Expect(l.Reserve(w2, 6)).To(BeEquivalentTo(4))
+		Expect(l.NumCredits()).To(BeEquivalentTo(10))
+		Expect(l.Reserve(w1, 1)).To(BeZero())
+	})
+
+	It("notifies a waiter when credit is released", func() {
+		Expect(l.Reserve(w1, 12)).To(BeEquivalentTo(10))
+		Expect(w1.notified).To(BeZero())
+		l.Release(3)
+		Expect(w1.notified).To(Equal(1))
+		Expect(l.NumCredits()).To(BeEquivalentTo(7))
+		Expect(l.Reserve(w1, 2)).To(BeEquivalentTo(2))
+		// w1 got all the credit it asked for, and is not notified any more
+		l.Release(1)
+		Expect(w1.notified).To(Equal(1))
+	})
+
+	It("grants credit to waiters in turn", func() {
+		Expect(l.Reserve(w1, 12)).To(BeEquivalentTo(10))
+		Expect(l.Reserve(w2, 5)).To(BeZero())
+		l.Release(1)
+		Expect(w1.notified).To(Equal(1))
+		Expect(w2.notified).To(BeZero())
+		// w1 is first in line, and is granted the credit...
+		Expect(l.Reserve(w1, 2)).To(BeEquivalentTo(1))
+		// ... but is still short on credit, so it has to wait for w2
+		l.Release(1)
+		Expect(w2.notified).To(Equal(1))
+		Expect(l.Reserve(w1, 1)).To(BeZero())
+		Expect(l.Reserve(w2, 5)).To(BeEquivalentTo(1))
+	})
+
+	It("doesn't grant credit to streams maps that don't wait, while others are waiting", func() {
+		Expect(l.Reserve(w1, 12)).To(BeEquivalentTo(10))
+		l.Release(2)
+		Expect(l.Reserve(w2, 1)).To(BeZero())
+		Expect(l.Reserve(w1, 2)).To(BeEquivalentTo(2))
+	})
+
+	It("notifies the next waiter when a waiter is removed", func() {
+		Expect(l.Reserve(w1, 12)).To(BeEquivalentTo(10))
+		Expect(l.Reserve(w2, 1)).To(BeZero())
+		l.Release(10)
+		Expect(w1.notified).To(Equal(1))
+		// the session of w1 is closed
+		l.RemoveWaiter(w1)
+		Expect(w2.notified).To(Equal(1))
+		Expect(l.Reserve(w2, 1)).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,19 @@
+package quic
+
+import "time"
+
+// The streamResetLimiter limits the number of RESET_STREAM and STOP_SENDING frames that are processed per second.
+// It allows bursts of up to one second's worth of frames.
+type streamResetLimiter struct {
+	*tokenBucket
+}
+
+func newStreamResetLimiter(rate uint64) *streamResetLimiter {
+	return &streamResetLimiter{tokenBucket: newTokenBucket(rate, time.Second)}
+}
+
+// ReceivedFrame must be called for every RESET_STREAM and STOP_SENDING frame received.
+// It returns false if the frame exceeds the rate limit.
+func (l *streamResetLimiter) ReceivedFrame(now time.Time) bool {
+	return l.Take(now)
+}
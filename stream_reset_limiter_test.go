@@ -0,0 +1,67 @@
+package quic
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Reset Limiter", func() {
+	It("allows a burst of one second's worth of frames", func() {
+		l := newStreamResetLimiter(100)
+		now := time.Now()
+		for i := 0; i < 100; i++ {
+			Expect(l.ReceivedFrame(now)).To(BeTrue())
+		}
+		Expect(l.ReceivedFrame(now)).To(BeFalse())
+	})
+
+	It("replenishes the budget over time", func() {
+		l := newStreamResetLimiter(10)
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			Expect(l.ReceivedFrame(now)).To(BeTrue())
+		}
+		Expect(l.ReceivedFrame(now.Add(99 * time.Millisecond))).To(BeFalse())
+		Expect(l.ReceivedFrame(now.Add(100 * time.Millisecond))).To(BeTrue())
+		Expect(l.ReceivedFrame(now.Add(100 * time.Millisecond))).To(BeFalse())
+	})
+
+	It("doesn't accumulate more budget than the maximum burst size", func() {
+		l := newStreamResetLimiter(2)
+		now := time.Now()
+		Expect(l.ReceivedFrame(now)).To(BeTrue())
+		now = now.Add(24 * time.Hour)
+		Expect(l.ReceivedFrame(now)).To(BeTrue())
+		Expect(l.ReceivedFrame(now)).To(BeTrue())
+		Expect(l.ReceivedFrame(now)).To(BeFalse())
+	})
+
+	It("keeps the rate under the limit", func() {
+		const rate = 1000
+		l := newStreamResetLimiter(rate)
+		start := time.Now()
+		now := start
+		var allowed int
+		// Receive a frame every 100us, which is ten times the allowed rate.
+		for now.Sub(start) < 5*time.Second {
+			if l.ReceivedFrame(now) {
+				allowed++
+			}
+			now = now.Add(100 * time.Microsecond)
+		}
+		Expect(allowed).To(BeNumerically("<=", 6*rate))
+		Expect(allowed).To(BeNumerically(">=", 5*rate))
+	})
+
+	It("doesn't overflow for very large rates", func() {
+		l := newStreamResetLimiter(math.MaxUint64)
+		now := time.Now()
+		for i := 0; i < 1000; i++ {
+			Expect(l.ReceivedFrame(now)).To(BeTrue())
+			now = now.Add(time.Microsecond)
+		}
+	})
+})
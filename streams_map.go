@@ -5,12 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/BGrewell/quic-go/internal/flowcontrol"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
 	"github.com/BGrewell/quic-go/internal/wire"
+	"github.com/BGrewell/quic-go/logging"
 )
 
 type streamError struct {
@@ -50,9 +53,15 @@ type streamsMap struct {
 
 	maxIncomingBidiStreams uint64
 	maxIncomingUniStreams  uint64
+	onNewStream            func(Stream) // called for every incoming bidirectional stream, may be nil
+	// limits the number of incoming streams across all sessions of a server, may be nil
+	streamLimiter *streamLimiter
+	// limits the data buffered for sending on all streams of this session, may be nil
+	sendBufferLimiter *sendBufferLimiter
 
 	sender            streamSender
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController
+	tracer            logging.ConnectionTracer
 
 	mutex               sync.Mutex
 	outgoingBidiStreams *outgoingBidiStreamsMap
@@ -60,6 +69,14 @@ type streamsMap struct {
 	incomingBidiStreams *incomingBidiStreamsMap
 	incomingUniStreams  *incomingUniStreamsMap
 	reset               bool
+	closing             bool // set when the session is closing gracefully
+	// closeReason is traced for the streams that are still open when the maps are closed
+	closeReason logging.StreamCloseReason
+
+	// The write deadline set by Session.SetWriteDeadline, applied to every stream we can send on.
+	// It has its own mutex, since it is read when streams are created, while the streams maps are locked.
+	writeDeadlineMutex sync.Mutex
+	writeDeadline      time.Time
 }
 
 var _ streamManager = &streamsMap{}
@@ -69,7 +86,11 @@ func newStreamsMap(
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController,
 	maxIncomingBidiStreams uint64,
 	maxIncomingUniStreams uint64,
+	onNewStream func(Stream),
+	streamLimiter *streamLimiter,
+	sendBufferLimiter *sendBufferLimiter,
 	perspective protocol.Perspective,
+	tracer logging.ConnectionTracer,
 	version protocol.VersionNumber,
 ) streamManager {
 	m := &streamsMap{
@@ -77,7 +98,11 @@ func newStreamsMap(
 		newFlowController:      newFlowController,
 		maxIncomingBidiStreams: maxIncomingBidiStreams,
 		maxIncomingUniStreams:  maxIncomingUniStreams,
+		onNewStream:            onNewStream,
+		streamLimiter:          streamLimiter,
+		sendBufferLimiter:      sendBufferLimiter,
 		sender:                 sender,
+		tracer:                 tracer,
 		version:                version,
 	}
 	m.initMaps()
@@ -85,38 +110,107 @@ func newStreamsMap(
 }
 
 func (m *streamsMap) initMaps() {
+	var onNewBidiStream func(streamI)
+	if m.onNewStream != nil {
+		onNewBidiStream = func(str streamI) { m.onNewStream(str) }
+	}
+	var bidiCredit, uniCredit streamCredit
+	if m.streamLimiter != nil {
+		bidiCredit = &limitedStreamCredit{streamCreditWaiter: m, limiter: m.streamLimiter}
+		uniCredit = &limitedStreamCredit{streamCreditWaiter: m, limiter: m.streamLimiter}
+	}
 	m.outgoingBidiStreams = newOutgoingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, m.perspective)
-			return newStream(id, m.sender, m.newFlowController(id), m.version)
+			m.traceOpenedStream(id)
+			str := newStream(id, m.sender, m.newFlowController(id), m.sendBufferLimiter, m.version)
+			m.applyWriteDeadline(str)
+			return str
 		},
 		m.sender.queueControlFrame,
+		func(num protocol.StreamNum) {
+			m.traceClosedStream(num.StreamID(protocol.StreamTypeBidi, m.perspective), m.closeReason)
+		},
 	)
 	m.incomingBidiStreams = newIncomingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, m.perspective.Opposite())
-			return newStream(id, m.sender, m.newFlowController(id), m.version)
+			m.traceOpenedStream(id)
+			str := newStream(id, m.sender, m.newFlowController(id), m.sendBufferLimiter, m.version)
+			m.applyWriteDeadline(str)
+			return str
 		},
 		m.maxIncomingBidiStreams,
 		m.sender.queueControlFrame,
+		func(num protocol.StreamNum) {
+			m.traceClosedStream(num.StreamID(protocol.StreamTypeBidi, m.perspective.Opposite()), m.closeReason)
+		},
+		onNewBidiStream,
+		bidiCredit,
 	)
 	m.outgoingUniStreams = newOutgoingUniStreamsMap(
 		func(num protocol.StreamNum) sendStreamI {
 			id := num.StreamID(protocol.StreamTypeUni, m.perspective)
-			return newSendStream(id, m.sender, m.newFlowController(id), m.version)
+			m.traceOpenedStream(id)
+			str := newSendStream(id, m.sender, m.newFlowController(id), m.sendBufferLimiter, m.version)
+			m.applyWriteDeadline(str)
+			return str
 		},
 		m.sender.queueControlFrame,
+		func(num protocol.StreamNum) {
+			m.traceClosedStream(num.StreamID(protocol.StreamTypeUni, m.perspective), m.closeReason)
+		},
 	)
 	m.incomingUniStreams = newIncomingUniStreamsMap(
 		func(num protocol.StreamNum) receiveStreamI {
 			id := num.StreamID(protocol.StreamTypeUni, m.perspective.Opposite())
+			m.traceOpenedStream(id)
 			return newReceiveStream(id, m.sender, m.newFlowController(id), m.version)
 		},
 		m.maxIncomingUniStreams,
 		m.sender.queueControlFrame,
+		func(num protocol.StreamNum) {
+			m.traceClosedStream(num.StreamID(protocol.StreamTypeUni, m.perspective.Opposite()), m.closeReason)
+		},
+		nil,
+		uniCredit,
 	)
 }
 
+func (m *streamsMap) traceOpenedStream(id protocol.StreamID) {
+	if m.tracer != nil {
+		m.tracer.OpenedStream(id, id.InitiatedBy())
+	}
+}
+
+func (m *streamsMap) traceClosedStream(id protocol.StreamID, reason logging.StreamCloseReason) {
+	if m.tracer != nil {
+		m.tracer.ClosedStream(id, reason)
+	}
+}
+
+// onStreamCreditAvailable is called by the streamLimiter, when credit for new incoming streams is available.
+// The credit is granted from the session's run loop, see GrantStreamCredit.
+func (m *streamsMap) onStreamCreditAvailable() {
+	m.sender.onStreamCreditAvailable()
+}
+
+// GrantStreamCredit grants the peer credit for new streams, if it was held back by the streamLimiter.
+func (m *streamsMap) GrantStreamCredit() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.incomingBidiStreams.GrantCredit()
+	m.incomingUniStreams.GrantCredit()
+}
+
+// MaxIncomingStreams returns the limits on the number of streams that the peer is allowed to open.
+// The session uses them for its transport parameters. The streamLimiter might grant less than the configured limits.
+func (m *streamsMap) MaxIncomingStreams() (bidi, uni protocol.StreamNum) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.incomingBidiStreams.MaxStream(), m.incomingUniStreams.MaxStream()
+}
+
 func (m *streamsMap) OpenStream() (Stream, error) {
 	m.mutex.Lock()
 	reset := m.reset
@@ -168,11 +262,15 @@ func (m *streamsMap) OpenUniStreamSync(ctx context.Context) (SendStream, error)
 func (m *streamsMap) AcceptStream(ctx context.Context) (Stream, error) {
 	m.mutex.Lock()
 	reset := m.reset
+	closing := m.closing
 	mm := m.incomingBidiStreams
 	m.mutex.Unlock()
 	if reset {
 		return nil, Err0RTTRejected
 	}
+	if closing {
+		return nil, ErrSessionClosing
+	}
 	str, err := mm.AcceptStream(ctx)
 	return str, convertStreamError(err, protocol.StreamTypeBidi, m.perspective.Opposite())
 }
@@ -180,16 +278,28 @@ func (m *streamsMap) AcceptStream(ctx context.Context) (Stream, error) {
 func (m *streamsMap) AcceptUniStream(ctx context.Context) (ReceiveStream, error) {
 	m.mutex.Lock()
 	reset := m.reset
+	closing := m.closing
 	mm := m.incomingUniStreams
 	m.mutex.Unlock()
 	if reset {
 		return nil, Err0RTTRejected
 	}
+	if closing {
+		return nil, ErrSessionClosing
+	}
 	str, err := mm.AcceptStream(ctx)
 	return str, convertStreamError(err, protocol.StreamTypeUni, m.perspective.Opposite())
 }
 
 func (m *streamsMap) DeleteStream(id protocol.StreamID) error {
+	if err := m.deleteStream(id); err != nil {
+		return err
+	}
+	m.traceClosedStream(id, logging.StreamCloseReasonCompleted)
+	return nil
+}
+
+func (m *streamsMap) deleteStream(id protocol.StreamID) error {
 	num := id.StreamNum()
 	switch id.Type() {
 	case protocol.StreamTypeUni:
@@ -290,7 +400,73 @@ func (m *streamsMap) UpdateLimits(p *wire.TransportParameters) {
 	m.outgoingUniStreams.SetMaxStream(p.MaxUniStreamNum)
 }
 
+// StopAccepting is used when the session is closed gracefully.
+// All current and future calls to Accept{Uni}Stream return ErrSessionClosing.
+func (m *streamsMap) StopAccepting() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.closing = true
+	m.incomingBidiStreams.StopAccepting(ErrSessionClosing)
+	m.incomingUniStreams.StopAccepting(ErrSessionClosing)
+}
+
+// NumOpenStreams returns the number of streams that the application opened or accepted,
+// and that are not yet completed.
+func (m *streamsMap) NumOpenStreams() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.outgoingBidiStreams.NumStreams() +
+		m.outgoingUniStreams.NumStreams() +
+		m.incomingBidiStreams.NumAcceptedStreams() +
+		m.incomingUniStreams.NumAcceptedStreams()
+}
+
+// OpenStreams returns the IDs of all streams that are not yet completed, sorted by stream ID.
+// In contrast to NumOpenStreams, this includes incoming streams that were not yet accepted.
+func (m *streamsMap) OpenStreams() []protocol.StreamID {
+	// The streams maps are replaced when 0-RTT is rejected.
+	// Holding the lock makes sure that all streams are taken from the same set of maps.
+	m.mutex.Lock()
+	var ids []protocol.StreamID
+	m.outgoingBidiStreams.ForEachStream(func(str streamI) { ids = append(ids, str.StreamID()) })
+	m.outgoingUniStreams.ForEachStream(func(str sendStreamI) { ids = append(ids, str.StreamID()) })
+	m.incomingBidiStreams.ForEachStream(func(str streamI) { ids = append(ids, str.StreamID()) })
+	m.incomingUniStreams.ForEachStream(func(str receiveStreamI) { ids = append(ids, str.StreamID()) })
+	m.mutex.Unlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// SetWriteDeadline sets the write deadline of the session.
+// It applies to all streams that we can send on, including streams that are opened later.
+func (m *streamsMap) SetWriteDeadline(t time.Time) {
+	m.writeDeadlineMutex.Lock()
+	m.writeDeadline = t
+	m.writeDeadlineMutex.Unlock()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.outgoingBidiStreams.ForEachStream(func(str streamI) { str.setSessionWriteDeadline(t) })
+	m.outgoingUniStreams.ForEachStream(func(str sendStreamI) { str.setSessionWriteDeadline(t) })
+	m.incomingBidiStreams.ForEachStream(func(str streamI) { str.setSessionWriteDeadline(t) })
+}
+
+func (m *streamsMap) applyWriteDeadline(str sendStreamI) {
+	m.writeDeadlineMutex.Lock()
+	deadline := m.writeDeadline
+	m.writeDeadlineMutex.Unlock()
+	if !deadline.IsZero() {
+		str.setSessionWriteDeadline(deadline)
+	}
+}
+
 func (m *streamsMap) CloseWithError(err error) {
+	m.closeWithError(err, logging.StreamCloseReasonConnectionClosed)
+}
+
+func (m *streamsMap) closeWithError(err error, reason logging.StreamCloseReason) {
+	m.closeReason = reason
 	m.outgoingBidiStreams.CloseWithError(err)
 	m.outgoingUniStreams.CloseWithError(err)
 	m.incomingBidiStreams.CloseWithError(err)
@@ -302,11 +478,12 @@ func (m *streamsMap) CloseWithError(err error) {
 // 2. reset to their initial state, such that we can immediately process new incoming stream data.
 // Afterwards, calls to Open{Uni}Stream{Sync} / Accept{Uni}Stream will continue to return the error,
 // until UseResetMaps() has been called.
+// The streams that were open are traced as closed, with StreamCloseReason0RTTRejected.
 func (m *streamsMap) ResetFor0RTT() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	m.reset = true
-	m.CloseWithError(Err0RTTRejected)
+	m.closeWithError(Err0RTTRejected, logging.StreamCloseReason0RTTRejected)
 	m.initMaps()
 }
 
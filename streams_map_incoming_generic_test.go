@@ -32,12 +32,30 @@ func (s *mockGenericStream) updateSendWindow(limit protocol.ByteCount) {
 	s.sendWindow = limit
 }
 
+type fakeStreamCredit struct {
+	available, released uint64
+	closed              bool
+}
+
+func (c *fakeStreamCredit) Grant(n uint64) uint64 {
+	if n > c.available {
+		n = c.available
+	}
+	c.available -= n
+	return n
+}
+
+func (c *fakeStreamCredit) Release(n uint64) { c.released += n }
+func (c *fakeStreamCredit) Close()           { c.closed = true }
+
 var _ = Describe("Streams Map (incoming)", func() {
 	var (
 		m              *incomingItemsMap
 		newItemCounter int
 		mockSender     *MockStreamSender
 		maxNumStreams  uint64
+		streamOpened   func(item)
+		credit         streamCredit
 	)
 
 	// check that the frame can be serialized and deserialized
@@ -49,7 +67,11 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(f).To(Equal(frame))
 	}
 
-	BeforeEach(func() { maxNumStreams = 5 })
+	BeforeEach(func() {
+		maxNumStreams = 5
+		streamOpened = nil
+		credit = nil
+	})
 
 	JustBeforeEach(func() {
 		newItemCounter = 0
@@ -61,6 +83,9 @@ var _ = Describe("Streams Map (incoming)", func() {
 			},
 			maxNumStreams,
 			mockSender.queueControlFrame,
+			func(protocol.StreamNum) {},
+			streamOpened,
+			credit,
 		)
 	})
 
@@ -70,6 +95,55 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(newItemCounter).To(Equal(4))
 	})
 
+	Context("calling the callback for opened streams", func() {
+		var opened []item
+
+		BeforeEach(func() {
+			opened = nil
+			streamOpened = func(str item) {
+				// The callback is called without holding the mutex.
+				// Otherwise, iterating over the streams would deadlock.
+				var found bool
+				m.ForEachStream(func(s item) {
+					if s == str {
+						found = true
+					}
+				})
+				Expect(found).To(BeTrue())
+				// The stream is not yet queued for AcceptStream.
+				Expect(str.(*mockGenericStream).num).To(BeNumerically(">=", m.nextStreamToQueue))
+				opened = append(opened, str)
+			}
+		})
+
+		It("calls the callback for every opened stream, in order", func() {
+			_, err := m.GetOrOpenStream(3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opened).To(HaveLen(3))
+			for i, str := range opened {
+				Expect(str.(*mockGenericStream).num).To(Equal(protocol.StreamNum(i + 1)))
+			}
+			// Once the callback returned, the streams can be accepted.
+			ctx, cancel := context.WithTimeout(context.Background(), scaleDuration(20*time.Millisecond))
+			defer cancel()
+			for _, str := range opened {
+				accepted, err := m.AcceptStream(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accepted).To(Equal(str))
+			}
+		})
+
+		It("doesn't call the callback for streams that were already opened", func() {
+			_, err := m.GetOrOpenStream(2)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = m.GetOrOpenStream(1)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = m.GetOrOpenStream(3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opened).To(HaveLen(3))
+		})
+	})
+
 	It("starts opening streams at the right position", func() {
 		// like the test above, but with 2 calls to GetOrOpenStream
 		_, err := m.GetOrOpenStream(2)
@@ -120,6 +194,28 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(acceptedStr.(*mockGenericStream).num).To(Equal(protocol.StreamNum(1)))
 	})
 
+	It("unblocks all AcceptStream calls waiting for streams that were opened at once", func() {
+		strChan := make(chan item, 5)
+		for i := 0; i < 5; i++ {
+			go func() {
+				defer GinkgoRecover()
+				str, err := m.AcceptStream(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				strChan <- str
+			}()
+		}
+		Consistently(strChan).ShouldNot(Receive())
+		_, err := m.GetOrOpenStream(5)
+		Expect(err).ToNot(HaveOccurred())
+		nums := make(map[protocol.StreamNum]struct{})
+		for i := 0; i < 5; i++ {
+			var str item
+			Eventually(strChan).Should(Receive(&str))
+			nums[str.(*mockGenericStream).num] = struct{}{}
+		}
+		Expect(nums).To(HaveLen(5))
+	})
+
 	It("unblocks AcceptStream when the context is canceled", func() {
 		ctx, cancel := context.WithCancel(context.Background())
 		done := make(chan struct{})
@@ -155,6 +251,48 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(err).To(MatchError(testErr))
 	})
 
+	It("unblocks AcceptStream when it stops accepting", func() {
+		testErr := errors.New("test error")
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			_, err := m.AcceptStream(context.Background())
+			Expect(err).To(MatchError(testErr))
+			close(done)
+		}()
+		Consistently(done).ShouldNot(BeClosed())
+		m.StopAccepting(testErr)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("doesn't accept streams after it stopped accepting", func() {
+		testErr := errors.New("test error")
+		m.StopAccepting(testErr)
+		m.StopAccepting(errors.New("another error")) // the first error is kept
+		str, err := m.GetOrOpenStream(1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str).ToNot(BeNil())
+		_, err = m.AcceptStream(context.Background())
+		Expect(err).To(MatchError(testErr))
+	})
+
+	It("counts the accepted streams", func() {
+		_, err := m.GetOrOpenStream(3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.NumAcceptedStreams()).To(BeZero())
+		_, err = m.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = m.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.NumAcceptedStreams()).To(Equal(2))
+		mockSender.EXPECT().queueControlFrame(gomock.Any())
+		Expect(m.DeleteStream(1)).To(Succeed())
+		Expect(m.NumAcceptedStreams()).To(Equal(1))
+		// deleting a stream that wasn't accepted yet doesn't change the count
+		Expect(m.DeleteStream(3)).To(Succeed())
+		Expect(m.NumAcceptedStreams()).To(Equal(1))
+	})
+
 	It("closes all streams when CloseWithError is called", func() {
 		str1, err := m.GetOrOpenStream(1)
 		Expect(err).ToNot(HaveOccurred())
@@ -265,6 +403,54 @@ var _ = Describe("Streams Map (incoming)", func() {
 		})
 	})
 
+	Context("limiting the credit", func() {
+		var c *fakeStreamCredit
+
+		BeforeEach(func() {
+			c = &fakeStreamCredit{available: 3}
+			credit = c
+		})
+
+		It("only allows the peer to open as many streams as the credit allows", func() {
+			Expect(m.MaxStream()).To(Equal(protocol.StreamNum(3)))
+			_, err := m.GetOrOpenStream(4)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(streamError).TestError()).To(MatchError("peer tried to open stream 4 (current limit: 3)"))
+		})
+
+		It("holds back MAX_STREAMS frames until credit is available", func() {
+			_, err := m.GetOrOpenStream(3)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = m.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			// no MAX_STREAMS frame is sent, since there's no credit available
+			Expect(m.DeleteStream(1)).To(Succeed())
+			Expect(c.released).To(BeEquivalentTo(1))
+			c.available = 10
+			mockSender.EXPECT().queueControlFrame(gomock.Any()).Do(func(f wire.Frame) {
+				// the peer is allowed to have up to 5 streams open at the same time
+				Expect(f.(*wire.MaxStreamsFrame).MaxStreamNum).To(Equal(protocol.StreamNum(6)))
+			})
+			m.GrantCredit()
+			Expect(c.available).To(BeEquivalentTo(7))
+			// all credit was granted, no need to send another MAX_STREAMS frame
+			m.GrantCredit()
+		})
+
+		It("releases the credit when it is closed", func() {
+			_, err := m.GetOrOpenStream(2)
+			Expect(err).ToNot(HaveOccurred())
+			m.CloseWithError(errors.New("test done"))
+			// 2 streams were opened, 1 stream could still have been opened
+			Expect(c.released).To(BeEquivalentTo(3))
+			Expect(c.closed).To(BeTrue())
+			// no credit is granted after the map was closed
+			c.available = 10
+			m.GrantCredit()
+			Expect(c.available).To(BeEquivalentTo(10))
+		})
+	})
+
 	Context("randomized tests", func() {
 		const num = 1000
 
@@ -27,30 +27,56 @@ type incomingUniStreamsMap struct {
 
 	nextStreamToAccept protocol.StreamNum // the next stream that will be returned by AcceptStream()
 	nextStreamToOpen   protocol.StreamNum // the highest stream that the peer opened
+	nextStreamToQueue  protocol.StreamNum // streams below this number can be returned by AcceptStream()
 	maxStream          protocol.StreamNum // the highest stream that the peer is allowed to open
 	maxNumStreams      uint64             // maximum number of streams
+	credit             streamCredit       // limits the credit granted to the peer, may be nil
 
 	newStream        func(protocol.StreamNum) receiveStreamI
 	queueMaxStreamID func(*wire.MaxStreamsFrame)
+	streamClosed     func(protocol.StreamNum) // called for every stream that is still open when the map is closed
+	streamOpened     func(receiveStreamI)     // called for every stream opened by the peer, without holding the mutex, may be nil
 
-	closeErr error
+	acceptErr         error         // set when the map stopped accepting streams
+	stopAcceptingChan chan struct{} // closed when the map stopped accepting streams
+	closeErr          error
 }
 
 func newIncomingUniStreamsMap(
 	newStream func(protocol.StreamNum) receiveStreamI,
 	maxStreams uint64,
 	queueControlFrame func(wire.Frame),
+	streamClosed func(protocol.StreamNum),
+	streamOpened func(receiveStreamI),
+	credit streamCredit,
 ) *incomingUniStreamsMap {
-	return &incomingUniStreamsMap{
+	m := &incomingUniStreamsMap{
 		newStreamChan:      make(chan struct{}, 1),
+		stopAcceptingChan:  make(chan struct{}),
 		streams:            make(map[protocol.StreamNum]receiveStreamIEntry),
 		maxStream:          protocol.StreamNum(maxStreams),
 		maxNumStreams:      maxStreams,
 		newStream:          newStream,
 		nextStreamToOpen:   1,
+		nextStreamToQueue:  1,
 		nextStreamToAccept: 1,
 		queueMaxStreamID:   func(f *wire.MaxStreamsFrame) { queueControlFrame(f) },
+		streamClosed:       streamClosed,
+		streamOpened:       streamOpened,
+		credit:             credit,
 	}
+	if credit != nil {
+		m.maxStream = protocol.StreamNum(credit.Grant(maxStreams))
+	}
+	return m
+}
+
+// MaxStream returns the highest stream that the peer is allowed to open.
+// The session uses it for its transport parameters, before the peer can open any streams.
+func (m *incomingUniStreamsMap) MaxStream() protocol.StreamNum {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.maxStream
 }
 
 func (m *incomingUniStreamsMap) AcceptStream(ctx context.Context) (receiveStreamI, error) {
@@ -70,9 +96,13 @@ func (m *incomingUniStreamsMap) AcceptStream(ctx context.Context) (receiveStream
 			m.mutex.Unlock()
 			return nil, m.closeErr
 		}
+		if m.acceptErr != nil {
+			m.mutex.Unlock()
+			return nil, m.acceptErr
+		}
 		var ok bool
 		entry, ok = m.streams[num]
-		if ok {
+		if ok && num < m.nextStreamToQueue {
 			break
 		}
 		m.mutex.Unlock()
@@ -80,10 +110,19 @@ func (m *incomingUniStreamsMap) AcceptStream(ctx context.Context) (receiveStream
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-m.newStreamChan:
+		case <-m.stopAcceptingChan:
 		}
 		m.mutex.Lock()
 	}
 	m.nextStreamToAccept++
+	// A single signal is sent for multiple streams queued at once.
+	// If there are more streams waiting to be accepted, pass it on to the next waiting AcceptStream call.
+	if m.nextStreamToAccept < m.nextStreamToQueue {
+		select {
+		case m.newStreamChan <- struct{}{}:
+		default:
+		}
+	}
 	// If this stream was completed before being accepted, we can delete it now.
 	if entry.shouldDelete {
 		if err := m.deleteStream(num); err != nil {
@@ -95,6 +134,45 @@ func (m *incomingUniStreamsMap) AcceptStream(ctx context.Context) (receiveStream
 	return entry.stream, nil
 }
 
+// StopAccepting makes all current and future calls to AcceptStream return err.
+// Streams opened by the peer are still handled, but they are never returned to the application.
+func (m *incomingUniStreamsMap) StopAccepting(err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.acceptErr != nil {
+		return
+	}
+	m.acceptErr = err
+	close(m.stopAcceptingChan)
+}
+
+// NumAcceptedStreams returns the number of streams that were accepted, and are not yet completed.
+func (m *incomingUniStreamsMap) NumAcceptedStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var n int
+	for num := range m.streams {
+		if num < m.nextStreamToAccept {
+			n++
+		}
+	}
+	return n
+}
+
+// ForEachStream calls f for every stream that was opened by the peer, and is not yet completed.
+// This includes streams that were not yet accepted.
+func (m *incomingUniStreamsMap) ForEachStream(f func(receiveStreamI)) {
+	m.mutex.RLock()
+	for _, entry := range m.streams {
+		if !entry.shouldDelete {
+			f(entry.stream)
+		}
+	}
+	m.mutex.RUnlock()
+}
+
 func (m *incomingUniStreamsMap) GetOrOpenStream(num protocol.StreamNum) (receiveStreamI, error) {
 	m.mutex.RLock()
 	if num > m.maxStream {
@@ -122,19 +200,46 @@ func (m *incomingUniStreamsMap) GetOrOpenStream(num protocol.StreamNum) (receive
 	// no need to check the two error conditions from above again
 	// * maxStream can only increase, so if the id was valid before, it definitely is valid now
 	// * highestStream is only modified by this function
+	var opened []receiveStreamI
 	for newNum := m.nextStreamToOpen; newNum <= num; newNum++ {
-		m.streams[newNum] = receiveStreamIEntry{stream: m.newStream(newNum)}
-		select {
-		case m.newStreamChan <- struct{}{}:
-		default:
+		str := m.newStream(newNum)
+		m.streams[newNum] = receiveStreamIEntry{stream: str}
+		if m.streamOpened != nil {
+			opened = append(opened, str)
 		}
 	}
 	m.nextStreamToOpen = num + 1
 	entry := m.streams[num]
+	if m.streamOpened == nil {
+		m.queueStreams(num)
+		m.mutex.Unlock()
+		return entry.stream, nil
+	}
+	m.mutex.Unlock()
+
+	// The streams are only queued for AcceptStream after the callback was called.
+	// The mutex is not held while calling the callback, since the callback might call back into the map.
+	for _, str := range opened {
+		m.streamOpened(str)
+	}
+	m.mutex.Lock()
+	m.queueStreams(num)
 	m.mutex.Unlock()
 	return entry.stream, nil
 }
 
+// queueStreams allows AcceptStream to return all streams up to num.
+func (m *incomingUniStreamsMap) queueStreams(num protocol.StreamNum) {
+	if num < m.nextStreamToQueue {
+		return
+	}
+	m.nextStreamToQueue = num + 1
+	select {
+	case m.newStreamChan <- struct{}{}:
+	default:
+	}
+}
+
 func (m *incomingUniStreamsMap) DeleteStream(num protocol.StreamNum) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -166,26 +271,63 @@ func (m *incomingUniStreamsMap) deleteStream(num protocol.StreamNum) error {
 	}
 
 	delete(m.streams, num)
-	// queue a MAX_STREAM_ID frame, giving the peer the option to open a new stream
-	if m.maxNumStreams > uint64(len(m.streams)) {
-		maxStream := m.nextStreamToOpen + protocol.StreamNum(m.maxNumStreams-uint64(len(m.streams))) - 1
-		// Never send a value larger than protocol.MaxStreamCount.
-		if maxStream <= protocol.MaxStreamCount {
-			m.maxStream = maxStream
-			m.queueMaxStreamID(&wire.MaxStreamsFrame{
-				Type:         protocol.StreamTypeUni,
-				MaxStreamNum: m.maxStream,
-			})
-		}
+	if m.credit != nil {
+		m.credit.Release(1)
 	}
+	m.updateMaxStream()
 	return nil
 }
 
+// GrantCredit tries to grant the peer the credit that was held back,
+// because the credit limited the number of streams.
+func (m *incomingUniStreamsMap) GrantCredit() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.updateMaxStream()
+}
+
+// updateMaxStream queues a MAX_STREAMS frame, giving the peer the option to open new streams,
+// up to maxNumStreams concurrent streams, as far as the credit allows.
+func (m *incomingUniStreamsMap) updateMaxStream() {
+	if m.closeErr != nil || m.maxNumStreams <= uint64(len(m.streams)) {
+		return
+	}
+	maxStream := m.nextStreamToOpen + protocol.StreamNum(m.maxNumStreams-uint64(len(m.streams))) - 1
+	// Never send a value larger than protocol.MaxStreamCount.
+	if maxStream > protocol.MaxStreamCount {
+		return
+	}
+	if maxStream <= m.maxStream {
+		return
+	}
+	if m.credit != nil {
+		maxStream = m.maxStream + protocol.StreamNum(m.credit.Grant(uint64(maxStream-m.maxStream)))
+		if maxStream == m.maxStream {
+			return
+		}
+	}
+	m.maxStream = maxStream
+	m.queueMaxStreamID(&wire.MaxStreamsFrame{
+		Type:         protocol.StreamTypeUni,
+		MaxStreamNum: m.maxStream,
+	})
+}
+
 func (m *incomingUniStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
-	for _, entry := range m.streams {
+	for num, entry := range m.streams {
 		entry.stream.closeForShutdown(err)
+		// Streams queued for deletion were already completed.
+		if !entry.shouldDelete {
+			m.streamClosed(num)
+		}
+	}
+	// Return the credit for the streams that are still open, and for the streams the peer didn't open yet.
+	if m.credit != nil {
+		m.credit.Release(uint64(len(m.streams)) + uint64(m.maxStream+1-m.nextStreamToOpen))
+		m.credit.Close()
 	}
 	m.mutex.Unlock()
 	close(m.newStreamChan)
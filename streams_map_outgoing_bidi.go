@@ -27,6 +27,7 @@ type outgoingBidiStreamsMap struct {
 
 	newStream            func(protocol.StreamNum) streamI
 	queueStreamIDBlocked func(*wire.StreamsBlockedFrame)
+	streamClosed         func(protocol.StreamNum) // called for every stream that is still open when the map is closed
 
 	closeErr error
 }
@@ -34,6 +35,7 @@ type outgoingBidiStreamsMap struct {
 func newOutgoingBidiStreamsMap(
 	newStream func(protocol.StreamNum) streamI,
 	queueControlFrame func(wire.Frame),
+	streamClosed func(protocol.StreamNum),
 ) *outgoingBidiStreamsMap {
 	return &outgoingBidiStreamsMap{
 		streams:              make(map[protocol.StreamNum]streamI),
@@ -42,6 +44,7 @@ func newOutgoingBidiStreamsMap(
 		nextStream:           1,
 		newStream:            newStream,
 		queueStreamIDBlocked: func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streamClosed:         streamClosed,
 	}
 }
 
@@ -151,6 +154,14 @@ func (m *outgoingBidiStreamsMap) GetStream(num protocol.StreamNum) (streamI, err
 	return s, nil
 }
 
+// NumStreams returns the number of streams that were opened, and are not yet completed.
+func (m *outgoingBidiStreamsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.streams)
+}
+
 func (m *outgoingBidiStreamsMap) DeleteStream(num protocol.StreamNum) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -191,6 +202,15 @@ func (m *outgoingBidiStreamsMap) UpdateSendWindow(limit protocol.ByteCount) {
 	m.mutex.Unlock()
 }
 
+// ForEachStream calls f for every stream that was opened, and is not yet completed.
+func (m *outgoingBidiStreamsMap) ForEachStream(f func(streamI)) {
+	m.mutex.RLock()
+	for _, str := range m.streams {
+		f(str)
+	}
+	m.mutex.RUnlock()
+}
+
 // unblockOpenSync unblocks the next OpenStreamSync go-routine to open a new stream
 func (m *outgoingBidiStreamsMap) unblockOpenSync() {
 	if len(m.openQueue) == 0 {
@@ -214,8 +234,9 @@ func (m *outgoingBidiStreamsMap) unblockOpenSync() {
 func (m *outgoingBidiStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
-	for _, str := range m.streams {
+	for num, str := range m.streams {
 		str.closeForShutdown(err)
+		m.streamClosed(num)
 	}
 	for _, c := range m.openQueue {
 		if c != nil {
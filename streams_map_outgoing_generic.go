@@ -25,6 +25,7 @@ type outgoingItemsMap struct {
 
 	newStream            func(protocol.StreamNum) item
 	queueStreamIDBlocked func(*wire.StreamsBlockedFrame)
+	streamClosed         func(protocol.StreamNum) // called for every stream that is still open when the map is closed
 
 	closeErr error
 }
@@ -32,6 +33,7 @@ type outgoingItemsMap struct {
 func newOutgoingItemsMap(
 	newStream func(protocol.StreamNum) item,
 	queueControlFrame func(wire.Frame),
+	streamClosed func(protocol.StreamNum),
 ) *outgoingItemsMap {
 	return &outgoingItemsMap{
 		streams:              make(map[protocol.StreamNum]item),
@@ -40,6 +42,7 @@ func newOutgoingItemsMap(
 		nextStream:           1,
 		newStream:            newStream,
 		queueStreamIDBlocked: func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streamClosed:         streamClosed,
 	}
 }
 
@@ -149,6 +152,14 @@ func (m *outgoingItemsMap) GetStream(num protocol.StreamNum) (item, error) {
 	return s, nil
 }
 
+// NumStreams returns the number of streams that were opened, and are not yet completed.
+func (m *outgoingItemsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.streams)
+}
+
 func (m *outgoingItemsMap) DeleteStream(num protocol.StreamNum) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -189,6 +200,15 @@ func (m *outgoingItemsMap) UpdateSendWindow(limit protocol.ByteCount) {
 	m.mutex.Unlock()
 }
 
+// ForEachStream calls f for every stream that was opened, and is not yet completed.
+func (m *outgoingItemsMap) ForEachStream(f func(item)) {
+	m.mutex.RLock()
+	for _, str := range m.streams {
+		f(str)
+	}
+	m.mutex.RUnlock()
+}
+
 // unblockOpenSync unblocks the next OpenStreamSync go-routine to open a new stream
 func (m *outgoingItemsMap) unblockOpenSync() {
 	if len(m.openQueue) == 0 {
@@ -212,8 +232,9 @@ func (m *outgoingItemsMap) unblockOpenSync() {
 func (m *outgoingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
-	for _, str := range m.streams {
+	for num, str := range m.streams {
 		str.closeForShutdown(err)
+		m.streamClosed(num)
 	}
 	for _, c := range m.openQueue {
 		if c != nil {
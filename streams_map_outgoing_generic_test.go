@@ -37,7 +37,7 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			return &mockGenericStream{num: num}
 		}
 		mockSender = NewMockStreamSender(mockCtrl)
-		m = newOutgoingItemsMap(newItem, mockSender.queueControlFrame)
+		m = newOutgoingItemsMap(newItem, mockSender.queueControlFrame, func(protocol.StreamNum) {})
 	})
 
 	Context("no stream ID limit", func() {
@@ -85,6 +85,17 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			Expect(str).To(BeNil())
 		})
 
+		It("counts the open streams", func() {
+			Expect(m.NumStreams()).To(BeZero())
+			_, err := m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.NumStreams()).To(Equal(2))
+			Expect(m.DeleteStream(1)).To(Succeed())
+			Expect(m.NumStreams()).To(Equal(1))
+		})
+
 		It("errors when deleting a non-existing stream", func() {
 			err := m.DeleteStream(1337)
 			Expect(err).To(HaveOccurred())
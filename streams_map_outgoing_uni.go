@@ -27,6 +27,7 @@ type outgoingUniStreamsMap struct {
 
 	newStream            func(protocol.StreamNum) sendStreamI
 	queueStreamIDBlocked func(*wire.StreamsBlockedFrame)
+	streamClosed         func(protocol.StreamNum) // called for every stream that is still open when the map is closed
 
 	closeErr error
 }
@@ -34,6 +35,7 @@ type outgoingUniStreamsMap struct {
 func newOutgoingUniStreamsMap(
 	newStream func(protocol.StreamNum) sendStreamI,
 	queueControlFrame func(wire.Frame),
+	streamClosed func(protocol.StreamNum),
 ) *outgoingUniStreamsMap {
 	return &outgoingUniStreamsMap{
 		streams:              make(map[protocol.StreamNum]sendStreamI),
@@ -42,6 +44,7 @@ func newOutgoingUniStreamsMap(
 		nextStream:           1,
 		newStream:            newStream,
 		queueStreamIDBlocked: func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streamClosed:         streamClosed,
 	}
 }
 
@@ -151,6 +154,14 @@ func (m *outgoingUniStreamsMap) GetStream(num protocol.StreamNum) (sendStreamI,
 	return s, nil
 }
 
+// NumStreams returns the number of streams that were opened, and are not yet completed.
+func (m *outgoingUniStreamsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.streams)
+}
+
 func (m *outgoingUniStreamsMap) DeleteStream(num protocol.StreamNum) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -191,6 +202,15 @@ func (m *outgoingUniStreamsMap) UpdateSendWindow(limit protocol.ByteCount) {
 	m.mutex.Unlock()
 }
 
+// ForEachStream calls f for every stream that was opened, and is not yet completed.
+func (m *outgoingUniStreamsMap) ForEachStream(f func(sendStreamI)) {
+	m.mutex.RLock()
+	for _, str := range m.streams {
+		f(str)
+	}
+	m.mutex.RUnlock()
+}
+
 // unblockOpenSync unblocks the next OpenStreamSync go-routine to open a new stream
 func (m *outgoingUniStreamsMap) unblockOpenSync() {
 	if len(m.openQueue) == 0 {
@@ -214,8 +234,9 @@ func (m *outgoingUniStreamsMap) unblockOpenSync() {
 func (m *outgoingUniStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
-	for _, str := range m.streams {
+	for num, str := range m.streams {
 		str.closeForShutdown(err)
+		m.streamClosed(num)
 	}
 	for _, c := range m.openQueue {
 		if c != nil {
@@ -5,14 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
 	"github.com/BGrewell/quic-go/internal/flowcontrol"
 	"github.com/BGrewell/quic-go/internal/mocks"
+	mocklogging "github.com/BGrewell/quic-go/internal/mocks/logging"
 	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/qerr"
 	"github.com/BGrewell/quic-go/internal/wire"
+	"github.com/BGrewell/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -89,7 +93,7 @@ var _ = Describe("Streams Map", func() {
 
 			BeforeEach(func() {
 				mockSender = NewMockStreamSender(mockCtrl)
-				m = newStreamsMap(mockSender, newFlowController, MaxBidiStreamNum, MaxUniStreamNum, perspective, protocol.VersionWhatever).(*streamsMap)
+				m = newStreamsMap(mockSender, newFlowController, MaxBidiStreamNum, MaxUniStreamNum, nil, nil, nil, perspective, nil, protocol.VersionWhatever).(*streamsMap)
 			})
 
 			Context("opening", func() {
@@ -397,6 +401,59 @@ var _ = Describe("Streams Map", func() {
 				})
 			}
 
+			Context("write deadline", func() {
+				BeforeEach(func() {
+					allowUnlimitedStreams()
+					mockSender.EXPECT().onHasStreamData(gomock.Any()).AnyTimes()
+				})
+
+				writeUntilDeadline := func(str SendStream, deadline time.Time) <-chan struct{} {
+					done := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(done)
+						_, err := str.Write(make([]byte, 5000))
+						Expect(err).To(MatchError(errDeadline))
+						Expect(time.Now()).To(BeTemporally("~", deadline, scaleDuration(20*time.Millisecond)))
+					}()
+					return done
+				}
+
+				It("unblocks writes on all streams at the session's write deadline", func() {
+					str1, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					str2, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					str3, err := m.GetOrOpenSendStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
+					m.SetWriteDeadline(deadline)
+					done1 := writeUntilDeadline(str1, deadline)
+					done2 := writeUntilDeadline(str2, deadline)
+					done3 := writeUntilDeadline(str3, deadline)
+					Eventually(done1).Should(BeClosed())
+					Eventually(done2).Should(BeClosed())
+					Eventually(done3).Should(BeClosed())
+				})
+
+				It("applies the session's write deadline to streams opened later", func() {
+					deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
+					m.SetWriteDeadline(deadline)
+					str1, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					str2, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					str3, err := m.GetOrOpenSendStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					done1 := writeUntilDeadline(str1, deadline)
+					done2 := writeUntilDeadline(str2, deadline)
+					done3 := writeUntilDeadline(str3, deadline)
+					Eventually(done1).Should(BeClosed())
+					Eventually(done2).Should(BeClosed())
+					Eventually(done3).Should(BeClosed())
+				})
+			})
+
 			Context("handling MAX_STREAMS frames", func() {
 				BeforeEach(func() {
 					mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
@@ -474,6 +531,297 @@ var _ = Describe("Streams Map", func() {
 				Expect(err.Error()).To(Equal(testErr.Error()))
 			})
 
+			It("stops accepting streams", func() {
+				_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+				Expect(err).ToNot(HaveOccurred())
+				m.StopAccepting()
+				_, err = m.AcceptStream(context.Background())
+				Expect(err).To(MatchError(ErrSessionClosing))
+				_, err = m.AcceptUniStream(context.Background())
+				Expect(err).To(MatchError(ErrSessionClosing))
+				// it's still possible to open streams
+				allowUnlimitedStreams()
+				_, err = m.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("counts the open streams", func() {
+				mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+				allowUnlimitedStreams()
+				str, err := m.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.OpenUniStream()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.GetOrOpenReceiveStream(ids.firstIncomingUniStream)
+				Expect(err).ToNot(HaveOccurred())
+				// streams that were not accepted yet are not counted
+				Expect(m.NumOpenStreams()).To(Equal(2))
+				_, err = m.AcceptStream(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.AcceptUniStream(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.NumOpenStreams()).To(Equal(4))
+				Expect(m.DeleteStream(str.StreamID())).To(Succeed())
+				Expect(m.DeleteStream(ids.firstIncomingUniStream)).To(Succeed())
+				Expect(m.NumOpenStreams()).To(Equal(2))
+			})
+
+			It("lists the open streams", func() {
+				mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+				allowUnlimitedStreams()
+				Expect(m.OpenStreams()).To(BeEmpty())
+				_, err := m.GetOrOpenReceiveStream(ids.firstIncomingUniStream)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+				Expect(err).ToNot(HaveOccurred())
+				str, err := m.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = m.OpenUniStream()
+				Expect(err).ToNot(HaveOccurred())
+				// streams that were not accepted yet are listed
+				Expect(m.OpenStreams()).To(Equal([]protocol.StreamID{0, 1, 2, 3}))
+				Expect(m.DeleteStream(str.StreamID())).To(Succeed())
+				Expect(m.DeleteStream(ids.firstIncomingUniStream)).To(Succeed())
+				Expect(m.OpenStreams()).To(ConsistOf(ids.firstIncomingBidiStream, ids.firstOutgoingUniStream))
+			})
+
+			It("lists the open streams while streams are opened and closed concurrently", func() {
+				mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+				allowUnlimitedStreams()
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					for i := 0; i < 200; i++ {
+						str, err := m.OpenStream()
+						Expect(err).ToNot(HaveOccurred())
+						ustr, err := m.OpenUniStream()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(m.DeleteStream(str.StreamID())).To(Succeed())
+						Expect(m.DeleteStream(ustr.StreamID())).To(Succeed())
+					}
+				}()
+				for {
+					select {
+					case <-done:
+						Expect(m.OpenStreams()).To(BeEmpty())
+						return
+					default:
+					}
+					open := m.OpenStreams()
+					// at any time, at most one bidirectional and one unidirectional stream is open
+					Expect(len(open)).To(BeNumerically("<=", 2))
+					Expect(sort.SliceIsSorted(open, func(i, j int) bool { return open[i] < open[j] })).To(BeTrue())
+					for _, id := range open {
+						Expect(id.InitiatedBy()).To(Equal(perspective))
+					}
+				}
+			})
+
+			Context("tracing", func() {
+				var tracer *mocklogging.MockConnectionTracer
+
+				BeforeEach(func() {
+					tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
+					m = newStreamsMap(mockSender, newFlowController, MaxBidiStreamNum, MaxUniStreamNum, nil, nil, nil, perspective, tracer, protocol.VersionWhatever).(*streamsMap)
+					allowUnlimitedStreams()
+				})
+
+				It("traces opened outgoing and incoming streams", func() {
+					tracer.EXPECT().OpenedStream(ids.firstOutgoingBidiStream, perspective)
+					_, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					tracer.EXPECT().OpenedStream(ids.firstIncomingUniStream, perspective.Opposite())
+					_, err = m.GetOrOpenReceiveStream(ids.firstIncomingUniStream)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("traces streams that are completed", func() {
+					tracer.EXPECT().OpenedStream(ids.firstOutgoingUniStream, perspective)
+					_, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					tracer.EXPECT().ClosedStream(ids.firstOutgoingUniStream, logging.StreamCloseReasonCompleted)
+					Expect(m.DeleteStream(ids.firstOutgoingUniStream)).To(Succeed())
+				})
+
+				It("doesn't trace streams that fail to be deleted", func() {
+					Expect(m.DeleteStream(ids.firstOutgoingBidiStream)).ToNot(Succeed())
+				})
+
+				It("traces streams that are still open when the connection is closed", func() {
+					tracer.EXPECT().OpenedStream(gomock.Any(), gomock.Any()).Times(2)
+					_, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					tracer.EXPECT().ClosedStream(ids.firstOutgoingBidiStream, logging.StreamCloseReasonConnectionClosed)
+					tracer.EXPECT().ClosedStream(ids.firstIncomingBidiStream, logging.StreamCloseReasonConnectionClosed)
+					m.CloseWithError(errors.New("test error"))
+				})
+
+				It("traces streams that are reset because 0-RTT was rejected", func() {
+					tracer.EXPECT().OpenedStream(gomock.Any(), gomock.Any()).Times(2)
+					_, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					tracer.EXPECT().ClosedStream(ids.firstOutgoingBidiStream, logging.StreamCloseReason0RTTRejected)
+					tracer.EXPECT().ClosedStream(ids.firstOutgoingUniStream, logging.StreamCloseReason0RTTRejected)
+					m.ResetFor0RTT()
+					// streams that are open when the connection is closed afterwards are traced as usual
+					tracer.EXPECT().OpenedStream(ids.firstIncomingUniStream, perspective.Opposite())
+					_, err = m.GetOrOpenReceiveStream(ids.firstIncomingUniStream)
+					Expect(err).ToNot(HaveOccurred())
+					tracer.EXPECT().ClosedStream(ids.firstIncomingUniStream, logging.StreamCloseReasonConnectionClosed)
+					m.CloseWithError(errors.New("test error"))
+				})
+			})
+
+			Context("new stream callback", func() {
+				var newStreams []Stream
+
+				BeforeEach(func() {
+					newStreams = nil
+					m = newStreamsMap(
+						mockSender,
+						newFlowController,
+						MaxBidiStreamNum,
+						MaxUniStreamNum,
+						func(str Stream) { newStreams = append(newStreams, str) },
+						nil,
+						nil,
+						perspective,
+						nil,
+						protocol.VersionWhatever,
+					).(*streamsMap)
+					allowUnlimitedStreams()
+				})
+
+				It("calls the callback for incoming bidirectional streams, before they can be accepted", func() {
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 4)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(newStreams).To(HaveLen(2))
+					Expect(newStreams[0].StreamID()).To(Equal(ids.firstIncomingBidiStream))
+					Expect(newStreams[1].StreamID()).To(Equal(ids.firstIncomingBidiStream + 4))
+					str, err := m.AcceptStream(context.Background())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(str).To(Equal(newStreams[0]))
+					// the callback is only called once for every stream
+					_, err = m.GetOrOpenSendStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(newStreams).To(HaveLen(2))
+				})
+
+				It("calls the callback without holding the lock of the streams map", func() {
+					var openStreams [][]protocol.StreamID
+					m.onNewStream = func(Stream) { openStreams = append(openStreams, m.OpenStreams()) }
+					m.initMaps()
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 4)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(openStreams).To(HaveLen(2))
+					Expect(openStreams[0]).To(ConsistOf(ids.firstIncomingBidiStream, ids.firstIncomingBidiStream+4))
+				})
+
+				It("doesn't call the callback for locally opened streams", func() {
+					_, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(newStreams).To(BeEmpty())
+				})
+
+				It("doesn't call the callback for incoming unidirectional streams", func() {
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingUniStream)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(newStreams).To(BeEmpty())
+				})
+
+				It("doesn't call the callback for streams that exceed the stream limit", func() {
+					id := protocol.StreamNum(MaxBidiStreamNum+1).StreamID(protocol.StreamTypeBidi, perspective.Opposite())
+					_, err := m.GetOrOpenReceiveStream(id)
+					Expect(err).To(HaveOccurred())
+					Expect(newStreams).To(BeEmpty())
+					_, err = m.GetOrOpenReceiveStream(id - 4)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(newStreams).To(HaveLen(MaxBidiStreamNum))
+				})
+			})
+
+			Context("limiting the total number of streams", func() {
+				var (
+					limiter *streamLimiter
+					m2      *streamsMap
+				)
+
+				BeforeEach(func() {
+					mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+					limiter = newStreamLimiter(3)
+					// the peer may open 2 bidirectional streams, and no unidirectional streams
+					m = newStreamsMap(mockSender, newFlowController, 2, 0, nil, limiter, nil, perspective, nil, protocol.VersionWhatever).(*streamsMap)
+					// a streams map of another session, sharing the same limiter
+					m2 = newStreamsMap(mockSender, newFlowController, 2, 0, nil, limiter, nil, perspective, nil, protocol.VersionWhatever).(*streamsMap)
+					allowUnlimitedStreams()
+				})
+
+				It("holds back credit across sessions", func() {
+					bidi, uni := m.MaxIncomingStreams()
+					Expect(bidi).To(Equal(protocol.StreamNum(2)))
+					Expect(uni).To(BeZero())
+					bidi, _ = m2.MaxIncomingStreams()
+					Expect(bidi).To(Equal(protocol.StreamNum(1)))
+					Expect(limiter.NumCredits()).To(BeEquivalentTo(3))
+					// Both peers can open the streams they were granted credit for.
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 4)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m2.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m2.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 4)
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("grants the held back credit when streams are completed", func() {
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					str, err := m.AcceptStream(context.Background())
+					Expect(err).ToNot(HaveOccurred())
+					// the second session is waiting for credit, and is granted the credit of the completed stream
+					mockSender.EXPECT().onStreamCreditAvailable()
+					Expect(m.DeleteStream(str.StreamID())).To(Succeed())
+					Expect(limiter.NumCredits()).To(BeEquivalentTo(2))
+					m2.GrantStreamCredit()
+					Expect(limiter.NumCredits()).To(BeEquivalentTo(3))
+					_, err = m2.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 4)
+					Expect(err).ToNot(HaveOccurred())
+					// the first session didn't get the credit back
+					_, err = m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream + 8)
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("doesn't count locally opened streams", func() {
+					for i := 0; i < 5; i++ {
+						_, err := m.OpenStream()
+						Expect(err).ToNot(HaveOccurred())
+						_, err = m.OpenUniStream()
+						Expect(err).ToNot(HaveOccurred())
+					}
+					Expect(limiter.NumCredits()).To(BeEquivalentTo(3))
+				})
+
+				It("releases the credit when the session is closed", func() {
+					_, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					mockSender.EXPECT().onStreamCreditAvailable().AnyTimes()
+					m.CloseWithError(errors.New("test done"))
+					// the open stream, and the stream the peer didn't open yet
+					Expect(limiter.NumCredits()).To(BeEquivalentTo(1))
+					m2.GrantStreamCredit()
+					bidi, _ := m2.MaxIncomingStreams()
+					Expect(bidi).To(Equal(protocol.StreamNum(2)))
+				})
+			})
+
 			if perspective == protocol.PerspectiveClient {
 				It("resets for 0-RTT", func() {
 					mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
@@ -0,0 +1,75 @@
+package quic
+
+import (
+	"math"
+	"time"
+
+	"github.com/BGrewell/quic-go/internal/utils"
+)
+
+// tokenCost is the cost of a single token.
+// The budget of a tokenBucket is measured in nanoseconds worth of tokens at the configured rate,
+// such that this cost doesn't depend on the rate.
+const tokenCost = uint64(time.Second)
+
+// A tokenBucket limits the rate of events to rate events per second, allowing bursts of up to maxBurst.
+// It doesn't read the time itself: the caller passes in the current time, using the clock of the session.
+type tokenBucket struct {
+	rate     uint64 // in tokens/s
+	maxBurst uint64 // in units of the budget
+
+	budgetAtLastTake uint64
+	lastTakeTime     time.Time
+}
+
+// newTokenBucket creates a token bucket that allows bursts of burst seconds' worth of tokens.
+// It always allows a burst of at least a single token.
+func newTokenBucket(rate uint64, burst time.Duration) *tokenBucket {
+	// avoid overflows when calculating the maximum burst size
+	rate = utils.MinUint64(rate, math.MaxUint64/utils.MaxUint64(uint64(burst), tokenCost))
+	maxBurst := utils.MaxUint64(uint64(burst)*rate, tokenCost)
+	return &tokenBucket{
+		rate:             rate,
+		maxBurst:         maxBurst,
+		budgetAtLastTake: maxBurst,
+	}
+}
+
+// Take takes a token from the bucket.
+// It returns false if there's no token available at this moment.
+func (b *tokenBucket) Take(now time.Time) bool {
+	budget := b.budget(now)
+	b.lastTakeTime = now
+	if budget < tokenCost {
+		b.budgetAtLastTake = budget
+		return false
+	}
+	b.budgetAtLastTake = budget - tokenCost
+	return true
+}
+
+// HasToken says if a token is available at this moment.
+func (b *tokenBucket) HasToken(now time.Time) bool {
+	return b.budget(now) >= tokenCost
+}
+
+// NextTokenTime returns when the next token will be available.
+// It returns the zero value of time.Time if a token is available immediately.
+func (b *tokenBucket) NextTokenTime() time.Time {
+	if b.budgetAtLastTake >= tokenCost {
+		return time.Time{}
+	}
+	return b.lastTakeTime.Add(time.Duration(math.Ceil(float64(tokenCost-b.budgetAtLastTake)/float64(b.rate))) * time.Nanosecond)
+}
+
+func (b *tokenBucket) budget(now time.Time) uint64 {
+	if b.lastTakeTime.IsZero() {
+		return b.maxBurst
+	}
+	elapsed := uint64(utils.MaxDuration(now.Sub(b.lastTakeTime), 0))
+	// avoid overflows when a lot of time has passed since the last token was taken
+	if elapsed > (b.maxBurst-b.budgetAtLastTake)/b.rate {
+		return b.maxBurst
+	}
+	return b.budgetAtLastTake + elapsed*b.rate
+}
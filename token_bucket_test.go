@@ -0,0 +1,67 @@
+package quic
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token Bucket", func() {
+	It("allows a burst of tokens", func() {
+		b := newTokenBucket(100, 100*time.Millisecond) // allows a burst of 10 tokens
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			Expect(b.HasToken(now)).To(BeTrue())
+			Expect(b.NextTokenTime()).To(BeZero())
+			Expect(b.Take(now)).To(BeTrue())
+		}
+		Expect(b.HasToken(now)).To(BeFalse())
+		Expect(b.Take(now)).To(BeFalse())
+		Expect(b.NextTokenTime()).To(Equal(now.Add(10 * time.Millisecond)))
+	})
+
+	It("allows a single token if the burst is smaller than one token", func() {
+		b := newTokenBucket(10, time.Millisecond)
+		now := time.Now()
+		Expect(b.Take(now)).To(BeTrue())
+		Expect(b.Take(now)).To(BeFalse())
+		Expect(b.HasToken(now.Add(99 * time.Millisecond))).To(BeFalse())
+		Expect(b.HasToken(now.Add(100 * time.Millisecond))).To(BeTrue())
+	})
+
+	It("doesn't lose the budget accumulated before a failed Take", func() {
+		b := newTokenBucket(10, 100*time.Millisecond)
+		now := time.Now()
+		Expect(b.Take(now)).To(BeTrue())
+		Expect(b.Take(now.Add(50 * time.Millisecond))).To(BeFalse())
+		Expect(b.Take(now.Add(100 * time.Millisecond))).To(BeTrue())
+	})
+
+	It("doesn't accumulate more budget than the maximum burst size", func() {
+		b := newTokenBucket(10, 200*time.Millisecond)
+		now := time.Now()
+		Expect(b.Take(now)).To(BeTrue())
+		now = now.Add(24 * time.Hour)
+		Expect(b.Take(now)).To(BeTrue())
+		Expect(b.Take(now)).To(BeTrue())
+		Expect(b.Take(now)).To(BeFalse())
+	})
+
+	It("handles time going backwards", func() {
+		b := newTokenBucket(10, 100*time.Millisecond)
+		now := time.Now()
+		Expect(b.Take(now)).To(BeTrue())
+		Expect(b.Take(now.Add(-time.Hour))).To(BeFalse())
+	})
+
+	It("doesn't overflow for very large rates", func() {
+		b := newTokenBucket(math.MaxUint64, time.Second)
+		now := time.Now()
+		for i := 0; i < 1000; i++ {
+			Expect(b.Take(now)).To(BeTrue())
+			now = now.Add(time.Microsecond)
+		}
+	})
+})
@@ -3,30 +3,39 @@ package quic
 import (
 	"container/list"
 	"sync"
+	"time"
 
+	"github.com/BGrewell/quic-go/internal/protocol"
 	"github.com/BGrewell/quic-go/internal/utils"
 )
 
+// A timedClientToken is a ClientToken, together with the time it was stored.
+type timedClientToken struct {
+	token *ClientToken
+	added time.Time
+}
+
 type singleOriginTokenStore struct {
-	tokens []*ClientToken
+	tokens []timedClientToken
 	len    int
 	p      int
 }
 
 func newSingleOriginTokenStore(size int) *singleOriginTokenStore {
-	return &singleOriginTokenStore{tokens: make([]*ClientToken, size)}
+	return &singleOriginTokenStore{tokens: make([]timedClientToken, size)}
 }
 
-func (s *singleOriginTokenStore) Add(token *ClientToken) {
-	s.tokens[s.p] = token
+func (s *singleOriginTokenStore) Add(token *ClientToken, now time.Time) {
+	s.tokens[s.p] = timedClientToken{token: token, added: now}
 	s.p = s.index(s.p + 1)
 	s.len = utils.Min(s.len+1, len(s.tokens))
 }
 
-func (s *singleOriginTokenStore) Pop() *ClientToken {
+// Pop returns the most recently added token.
+func (s *singleOriginTokenStore) Pop() timedClientToken {
 	s.p = s.index(s.p - 1)
 	token := s.tokens[s.p]
-	s.tokens[s.p] = nil
+	s.tokens[s.p] = timedClientToken{}
 	s.len = utils.Max(s.len-1, 0)
 	return token
 }
@@ -52,19 +61,23 @@ type lruTokenStore struct {
 	q                *list.List
 	capacity         int
 	singleOriginSize int
+	tokenValidity    time.Duration
 }
 
 var _ TokenStore = &lruTokenStore{}
 
 // NewLRUTokenStore creates a new LRU cache for tokens received by the client.
 // maxOrigins specifies how many origins this cache is saving tokens for.
+// When a token for a new origin is added, the least recently used origin is evicted.
 // tokensPerOrigin specifies the maximum number of tokens per origin.
+// Pop returns the most recently stored token. Tokens older than 24 hours are discarded.
 func NewLRUTokenStore(maxOrigins, tokensPerOrigin int) TokenStore {
 	return &lruTokenStore{
 		m:                make(map[string]*list.Element),
 		q:                list.New(),
 		capacity:         maxOrigins,
 		singleOriginSize: tokensPerOrigin,
+		tokenValidity:    protocol.TokenValidity,
 	}
 }
 
@@ -72,9 +85,10 @@ func (s *lruTokenStore) Put(key string, token *ClientToken) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	now := time.Now()
 	if el, ok := s.m[key]; ok {
 		entry := el.Value.(*lruTokenStoreEntry)
-		entry.cache.Add(token)
+		entry.cache.Add(token, now)
 		s.q.MoveToFront(el)
 		return
 	}
@@ -84,7 +98,7 @@ func (s *lruTokenStore) Put(key string, token *ClientToken) {
 			key:   key,
 			cache: newSingleOriginTokenStore(s.singleOriginSize),
 		}
-		entry.cache.Add(token)
+		entry.cache.Add(token, now)
 		s.m[key] = s.q.PushFront(entry)
 		return
 	}
@@ -94,7 +108,7 @@ func (s *lruTokenStore) Put(key string, token *ClientToken) {
 	delete(s.m, entry.key)
 	entry.key = key
 	entry.cache = newSingleOriginTokenStore(s.singleOriginSize)
-	entry.cache.Add(token)
+	entry.cache.Add(token, now)
 	s.q.MoveToFront(elem)
 	s.m[key] = elem
 }
@@ -103,15 +117,24 @@ func (s *lruTokenStore) Pop(key string) *ClientToken {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	var token *ClientToken
-	if el, ok := s.m[key]; ok {
+	el, ok := s.m[key]
+	if !ok {
+		return nil
+	}
+	cache := el.Value.(*lruTokenStoreEntry).cache
+	t := cache.Pop()
+	// Tokens are popped in reverse order of their addition.
+	// If this token is expired, all remaining tokens are expired as well.
+	if time.Since(t.added) > s.tokenValidity {
+		s.q.Remove(el)
+		delete(s.m, key)
+		return nil
+	}
+	if cache.Len() == 0 {
+		s.q.Remove(el)
+		delete(s.m, key)
+	} else {
 		s.q.MoveToFront(el)
-		cache := el.Value.(*lruTokenStoreEntry).cache
-		token = cache.Pop()
-		if cache.Len() == 0 {
-			s.q.Remove(el)
-			delete(s.m, key)
-		}
 	}
-	return token
+	return t.token
 }
@@ -2,6 +2,7 @@ package quic
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -55,6 +56,17 @@ var _ = Describe("Token Cache", func() {
 			Expect(s.Pop(origin)).To(Equal(mockToken(1)))
 			Expect(s.Pop(origin)).To(BeNil())
 		})
+
+		It("doesn't return expired tokens", func() {
+			s.(*lruTokenStore).tokenValidity = 25 * time.Millisecond
+			s.Put(origin, mockToken(1))
+			s.Put(origin, mockToken(2))
+			time.Sleep(50 * time.Millisecond)
+			s.Put(origin, mockToken(3))
+			Expect(s.Pop(origin)).To(Equal(mockToken(3)))
+			Expect(s.Pop(origin)).To(BeNil())
+			Expect(s.Pop(origin)).To(BeNil())
+		})
 	})
 
 	Context("for multiple origins", func() {
@@ -104,5 +116,21 @@ var _ = Describe("Token Cache", func() {
 			Expect(s.Pop("host3")).To(Equal(mockToken(3)))
 			Expect(s.Pop("host4")).To(Equal(mockToken(4)))
 		})
+
+		It("deletes hosts when their tokens expired", func() {
+			s.(*lruTokenStore).tokenValidity = 25 * time.Millisecond
+			s.Put("host1", mockToken(1))
+			s.Put("host1", mockToken(11))
+			time.Sleep(50 * time.Millisecond)
+			s.Put("host2", mockToken(2))
+			s.Put("host3", mockToken(3))
+			Expect(s.Pop("host1")).To(BeNil())
+			// host1 should have been deleted, making space for host4
+			s.Put("host4", mockToken(4))
+			Expect(s.Pop("host2")).To(Equal(mockToken(2)))
+			Expect(s.Pop("host3")).To(Equal(mockToken(3)))
+			Expect(s.Pop("host4")).To(Equal(mockToken(4)))
+			Expect(s.Pop("host1")).To(BeNil())
+		})
 	})
 })